@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
 
 	"github.com/anchore/clio"
 	"github.com/anchore/quill/cmd/quill/cli/commands"
@@ -9,6 +13,7 @@ import (
 	"github.com/anchore/quill/internal/bus"
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/internal/redact"
+	"github.com/anchore/quill/quill/remediation"
 )
 
 func New(id clio.Identification) clio.Application {
@@ -58,16 +63,81 @@ func New(id clio.Identification) clio.Application {
 	p12 := commands.P12(app)
 	p12.AddCommand(commands.P12AttachChain(app))
 	p12.AddCommand(commands.P12Describe(app))
+	p12.AddCommand(commands.P12CreateTest(app))
+
+	csr := commands.CSR(app)
+	csr.AddCommand(commands.CSRCreate(app))
+
+	tkt := commands.Ticket(app)
+	tkt.AddCommand(commands.TicketValidate(app))
+	tkt.AddCommand(commands.TicketDescribe(app))
+
+	trustStore := commands.TrustStore(app)
+	trustStore.AddCommand(commands.TrustStoreShow(app))
+	trustStore.AddCommand(commands.TrustStoreFetch(app))
 
 	root.AddCommand(clio.VersionCommand(id))
 	root.AddCommand(commands.Sign(app))
 	root.AddCommand(commands.Notarize(app))
 	root.AddCommand(commands.SignAndNotarize(app))
 	root.AddCommand(commands.Describe(app))
+	root.AddCommand(commands.Diff(app))
+	root.AddCommand(commands.Conformance(app))
+	root.AddCommand(commands.Lint(app))
+	root.AddCommand(commands.Audit(app))
+	root.AddCommand(commands.Verify(app))
+	root.AddCommand(commands.Detach(app))
+	root.AddCommand(commands.Attach(app))
+	root.AddCommand(commands.Prepare(app))
+	root.AddCommand(commands.Runtime(app))
 	root.AddCommand(commands.EmbeddedCerts(app))
 	root.AddCommand(submission)
 	root.AddCommand(extract)
 	root.AddCommand(p12)
+	root.AddCommand(csr)
+	root.AddCommand(tkt)
+	root.AddCommand(trustStore)
+
+	showRemediationHints(root)
 
 	return app
 }
+
+// showRemediationHints appends the remediation hint of failures (see remediation.Error) to the error shown for every
+// command.
+func showRemediationHints(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		showRemediationHints(c)
+	}
+
+	if cmd.RunE == nil {
+		return
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		r, ok := remediation.Get(err)
+		if !ok {
+			return err
+		}
+		hint := fmt.Sprintf("hint: %s", r.Hint)
+		if r.Command != "" && !strings.Contains(r.Hint, r.Command) {
+			hint += fmt.Sprintf(" (see '%s')", r.Command)
+		}
+		return hintedError{err: err, hint: hint}
+	}
+}
+
+type hintedError struct {
+	err  error
+	hint string
+}
+
+func (e hintedError) Error() string {
+	return strings.TrimSpace(e.err.Error()) + "\n" + e.hint
+}
+
+func (e hintedError) Unwrap() error {
+	return e.err
+}
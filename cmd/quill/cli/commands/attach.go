@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill"
+)
+
+type attachConfig struct {
+	Path      string `yaml:"path" json:"path" mapstructure:"-"`
+	Signature string `yaml:"signature" json:"signature" mapstructure:"-"`
+}
+
+func Attach(app clio.Application) *cobra.Command {
+	opts := &attachConfig{}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "attach PATH SIGNATURE",
+		Short: "attach a detached signature to an unsigned binary",
+		Long:  "patch a detached signature (as written by the detach command) into an unsigned copy of the binary it was made for, in place: the code signature load command is added and the __LINKEDIT segment is grown to hold the signature (no signing identity is needed)",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH":      "the unsigned darwin binary to attach the signature to",
+				"SIGNATURE": "the detached signature file",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(2),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				opts.Signature = args[1]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			if err := quill.AttachSignature(opts.Path, opts.Signature); err != nil {
+				return err
+			}
+
+			bus.Notify(fmt.Sprintf("Attached the signature within %q to %q", opts.Signature, opts.Path))
+			return nil
+		},
+	}, opts)
+}
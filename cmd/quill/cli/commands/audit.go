@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/lint"
+)
+
+type auditConfig struct {
+	Path           string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func Audit(app clio.Application) *cobra.Command {
+	opts := &auditConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "audit PATH",
+		Short: "flag debug entitlements and development signatures within a release",
+		Long:  "check a binary (or every binary within a release directory) for artifacts that must never ship to customers: the get-task-allow or allow-unsigned-executable-memory entitlements, development (not Developer ID) certificates, and ad-hoc signatures",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "the darwin binary or release directory to audit",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			findings, err := lint.Audit(opts.Path)
+			if err != nil {
+				return err
+			}
+
+			return reportFindings(findings, opts.Output, "risky artifact(s)", "No risky artifacts found")
+		},
+	}, opts)
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/extract"
+)
+
+type conformanceConfig struct {
+	Path           string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func Conformance(app clio.Application) *cobra.Command {
+	opts := &conformanceConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "conformance PATH",
+		Short: "compare quill's view of a signature against Apple's codesign tool (macOS only)",
+		Long:  "compare the signature fields parsed by quill (identifier, team ID, flags, hashes, cdhash, authorities, requirements, etc.) against the output of Apple's codesign tool and report any divergences (requires codesign, so this is only available on macOS)",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "the signed darwin binary to compare",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			buf := &strings.Builder{}
+			diverged, err := extract.ShowCodesignConformance(opts.Path, buf, opts.Output)
+			if err != nil {
+				return err
+			}
+
+			bus.Report(buf.String())
+
+			if !diverged {
+				bus.Notify("quill and codesign agree on all compared fields")
+			}
+
+			return nil
+		},
+	}, opts)
+}
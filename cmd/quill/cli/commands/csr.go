@@ -0,0 +1,15 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+)
+
+func CSR(app clio.Application) *cobra.Command {
+	return app.SetupCommand(&cobra.Command{
+		Use:   "csr",
+		Short: "create certificate signing requests for Apple signing certificates",
+		Args:  cobra.NoArgs,
+	})
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+type csrCreateConfig struct {
+	options.CSR `yaml:"csr" json:"csr" mapstructure:"csr"`
+}
+
+func CSRCreate(app clio.Application) *cobra.Command {
+	opts := &csrCreateConfig{
+		CSR: options.DefaultCSR(),
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "create",
+		Short: "generate a private key and certificate signing request to upload to the Apple developer portal",
+		Long: `Generate a private key and a certificate signing request (CSR) with the same subject attributes that
+Keychain Access would use. Upload the CSR to the Apple developer portal when creating a "Developer ID Application"
+certificate, then use the downloaded certificate along with the private key to sign (or create a p12 file).
+
+The private key is generated into (or read from) a PEM file, keys held within a hardware token (PKCS#11) or a
+KMS are not supported.`,
+		Example: `  $ quill csr create --common-name "Jane Doe" --email jane@example.com --country US`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			return createCSR(opts.CSR)
+		},
+	}, opts)
+}
+
+func createCSR(opts options.CSR) error {
+	signer, generated, err := csrSigner(opts)
+	if err != nil {
+		return err
+	}
+
+	der, err := pki.NewCSR(signer, pki.CSRConfig{
+		CommonName: opts.CommonName,
+		Email:      opts.Email,
+		Country:    opts.Country,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create certificate signing request: %w", err)
+	}
+
+	if generated {
+		if err := writePrivateKey(opts.KeyOutput, signer); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(opts.Output, pki.EncodeCSR(der), 0600); err != nil {
+		if generated {
+			// the key is useless without the request, and leaving it behind would fail every retry
+			if rmErr := os.Remove(opts.KeyOutput); rmErr != nil {
+				log.WithFields("path", opts.KeyOutput, "error", rmErr).Warn("unable to remove private key")
+			}
+		}
+		return fmt.Errorf("unable to write certificate signing request: %w", err)
+	}
+
+	bus.Report(fmt.Sprintf("Wrote certificate signing request to %q", opts.Output))
+	if generated {
+		bus.Notify(fmt.Sprintf("Keep the private key %q safe, it is required to sign with the issued certificate", opts.KeyOutput))
+	}
+
+	return nil
+}
+
+func writePrivateKey(path string, signer crypto.Signer) error {
+	keyPEM, err := pki.EncodePrivateKey(signer)
+	if err != nil {
+		return err
+	}
+
+	// never clobber an existing key, it may be the only copy of a key with an issued certificate
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write private key: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(keyPEM); err != nil {
+		// the file was created by us, so a partial key may be removed
+		_ = os.Remove(path)
+		return fmt.Errorf("unable to write private key: %w", err)
+	}
+	log.WithFields("path", path).Info("wrote private key")
+	return nil
+}
+
+func csrSigner(opts options.CSR) (crypto.Signer, bool, error) {
+	if opts.PrivateKey == "" {
+		signer, err := pki.GenerateKey(pki.KeyType(opts.KeyType))
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to generate private key: %w", err)
+		}
+		return signer, true, nil
+	}
+
+	key, err := load.PrivateKeyWithPassphrase(opts.PrivateKey, passphraseProvider(opts.Password))
+	if err != nil {
+		return nil, false, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, false, fmt.Errorf("private key %T is not a signer", key)
+	}
+	return signer, false, nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/cmd/quill/cli/options"
+)
+
+func TestCreateCSR_removesKeyWhenRequestCannotBeWritten(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := options.DefaultCSR()
+	opts.CommonName = "Jane Doe"
+	opts.KeyType = "ecdsa"
+	opts.KeyOutput = filepath.Join(dir, "key.pem")
+	opts.Output = filepath.Join(dir, "missing", "request.csr")
+
+	require.Error(t, createCSR(opts))
+
+	_, err := os.Stat(opts.KeyOutput)
+	assert.True(t, os.IsNotExist(err), "the private key must not be left behind")
+
+	// a retry is not blocked by the key of the failed attempt
+	opts.Output = filepath.Join(dir, "request.csr")
+	require.NoError(t, createCSR(opts))
+	assert.FileExists(t, opts.KeyOutput)
+	assert.FileExists(t, opts.Output)
+}
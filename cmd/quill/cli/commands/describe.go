@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -22,7 +24,7 @@ func Describe(app clio.Application) *cobra.Command {
 	opts := &describeConfig{
 		Format: options.Format{
 			Output:           "text",
-			AllowableFormats: []string{"text", "json"},
+			AllowableFormats: []string{"text", "json", "yaml"},
 		},
 	}
 
@@ -44,13 +46,25 @@ func Describe(app clio.Application) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer bus.Exit()
 
+			if opts.DumpBlob != "" {
+				return dumpBlob(opts.Path, opts.DumpBlob, opts.DumpBlobPath)
+			}
+
 			var err error
 			buf := &strings.Builder{}
-			switch strings.ToLower(opts.Output) {
-			case "text":
+			switch {
+			case opts.Blobs:
+				err = extract.ShowBlobs(opts.Path, buf, opts.Output)
+			case opts.Entitlements:
+				err = extract.ShowEntitlements(opts.Path, buf, opts.Output)
+			case opts.Template != "":
+				err = extract.ShowTemplate(opts.Path, buf, opts.Template)
+			case strings.EqualFold(opts.Output, "text"):
 				err = extract.ShowText(opts.Path, buf, !opts.Detail)
-			case "json":
+			case strings.EqualFold(opts.Output, "json"):
 				err = extract.ShowJSON(opts.Path, buf)
+			case strings.EqualFold(opts.Output, "yaml"):
+				err = extract.ShowYAML(opts.Path, buf)
 			default:
 				err = fmt.Errorf("unknown format: %s", opts.Output)
 			}
@@ -65,3 +79,26 @@ func Describe(app clio.Application) *cobra.Command {
 		},
 	}, opts)
 }
+
+func dumpBlob(path, slotSelector, output string) error {
+	if output == "" {
+		return fmt.Errorf("--dump-blob-output is required with --dump-blob")
+	}
+
+	slot, err := extract.ParseSlot(slotSelector)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := extract.DumpBlob(path, slot, &buf); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("unable to write blob: %w", err)
+	}
+
+	bus.Notify(fmt.Sprintf("Wrote %d bytes (slot 0x%x) to %s", buf.Len(), uint32(slot), output))
+	return nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill"
+)
+
+type detachConfig struct {
+	Path      string `yaml:"path" json:"path" mapstructure:"-"`
+	Signature string `yaml:"signature" json:"signature" mapstructure:"-"`
+}
+
+func Detach(app clio.Application) *cobra.Command {
+	opts := &detachConfig{}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "detach PATH SIGNATURE",
+		Short: "write the signature of a signed binary to a separate file",
+		Long:  "write the signature of a signed binary to a separate file (the binary is left as is), so that it can be attached to an unsigned copy of the binary later with the attach command, or verified with verify --detached-signature",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH":      "the signed darwin binary",
+				"SIGNATURE": "the file to write the signature to",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(2),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				opts.Signature = args[1]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			if err := quill.DetachSignature(opts.Path, opts.Signature); err != nil {
+				return err
+			}
+
+			bus.Notify(fmt.Sprintf("Wrote the signature of %q to %q", opts.Path, opts.Signature))
+			return nil
+		},
+	}, opts)
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/extract"
+)
+
+type diffConfig struct {
+	PathA          string `yaml:"path-a" json:"path-a" mapstructure:"-"`
+	PathB          string `yaml:"path-b" json:"path-b" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func Diff(app clio.Application) *cobra.Command {
+	opts := &diffConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "diff PATH_A PATH_B",
+		Short: "compare the signatures of two macho binaries",
+		Long:  "compare the signatures of two macho binaries field by field (identity, flags, team ID, certificate chain, entitlements, cdhashes, requirements) and report what changed",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH_A": "the darwin binary to compare from (e.g. the previous release)",
+				"PATH_B": "the darwin binary to compare to (e.g. the re-signed release)",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(2),
+			func(_ *cobra.Command, args []string) error {
+				opts.PathA = args[0]
+				opts.PathB = args[1]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			buf := &strings.Builder{}
+			changed, err := extract.ShowDiff(opts.PathA, opts.PathB, buf, opts.Output)
+			if err != nil {
+				return err
+			}
+
+			bus.Report(buf.String())
+
+			if !changed {
+				bus.Notify("No signature differences found")
+			}
+
+			return nil
+		},
+	}, opts)
+}
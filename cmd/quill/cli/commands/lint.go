@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/lint"
+)
+
+type lintConfig struct {
+	Path           string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func Lint(app clio.Application) *cobra.Command {
+	opts := &lintConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "lint PATH",
+		Short: "check a binary or bundle for notarization blockers before submission",
+		Long:  "check a signed binary (or every binary within a bundle directory) for the most common reasons a notarization submission is rejected: unsigned nested code, ad-hoc or non Developer ID signatures, missing hardened runtime, missing secure timestamp, the get-task-allow entitlement, and a too old SDK",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "the signed darwin binary or bundle directory to check",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			findings, err := lint.Lint(opts.Path)
+			if err != nil {
+				return err
+			}
+
+			return reportFindings(findings, opts.Output, "notarization blocker(s)", "No notarization blockers found")
+		},
+	}, opts)
+}
+
+// reportFindings shows the given lint findings, returning an error if any of them has an error severity.
+func reportFindings(findings []lint.Finding, format, errorNoun, cleanMessage string) error {
+	buf := &strings.Builder{}
+	if err := lint.Show(findings, buf, format); err != nil {
+		return err
+	}
+
+	if buf.Len() > 0 {
+		bus.Report(buf.String())
+	}
+
+	if errs := lint.Errors(findings); len(errs) > 0 {
+		return fmt.Errorf("found %d %s", len(errs), errorNoun)
+	}
+
+	if len(findings) == 0 {
+		bus.Notify(cleanMessage)
+	}
+
+	return nil
+}
@@ -11,20 +11,24 @@ import (
 	"github.com/anchore/quill/internal/bus"
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/quill"
+	"github.com/anchore/quill/quill/attest"
 	"github.com/anchore/quill/quill/notary"
 )
 
 var _ fangs.FlagAdder = (*notarizeConfig)(nil)
 
 type notarizeConfig struct {
-	Path           string `yaml:"path" json:"path" mapstructure:"-"`
-	options.Notary `yaml:"notary" json:"notary" mapstructure:"notary"`
-	options.Status `yaml:"status" json:"status" mapstructure:"status"`
-	DryRun         bool `yaml:"dry-run" json:"dry-run" mapstructure:"dry-run"`
+	Paths           []string `yaml:"paths" json:"paths" mapstructure:"-"`
+	options.Notary  `yaml:"notary" json:"notary" mapstructure:"notary"`
+	options.Status  `yaml:"status" json:"status" mapstructure:"status"`
+	options.Profile `yaml:",inline" json:",inline" mapstructure:",squash"`
+	DryRun          bool   `yaml:"dry-run" json:"dry-run" mapstructure:"dry-run"`
+	Manifest        string `yaml:"manifest" json:"manifest" mapstructure:"manifest"`
 }
 
 func (o *notarizeConfig) AddFlags(flags fangs.FlagSet) {
 	flags.BoolVarP(&o.DryRun, "dry-run", "", "dry run mode (do not actually notarize)")
+	flags.StringVarP(&o.Manifest, "manifest", "", "after notarizing, write a JSON manifest listing every binary with its sha256, cdhashes, identity, certificate fingerprint, timestamp, and notarization submission ID to this path")
 }
 
 func Notarize(app clio.Application) *cobra.Command {
@@ -33,46 +37,99 @@ func Notarize(app clio.Application) *cobra.Command {
 	}
 
 	return app.SetupCommand(&cobra.Command{
-		Use:   "notarize PATH",
+		Use:   "notarize PATH...",
 		Short: "notarize a signed a macho binary with Apple's Notary service",
+		Long:  "notarize a signed a macho binary with Apple's Notary service. When several binaries are given (e.g. every executable of a release) they are zipped together and notarized with a single submission (there is nothing to staple afterwards, Gatekeeper looks up the ticket of a notarized binary online). Signed disk images (.dmg) and installer packages (.pkg) are submitted as is, without zipping.",
 		Example: options.FormatPositionalArgsHelp(
 			map[string]string{
-				"PATH": "the signed darwin binary to notarize",
+				"PATH": "the signed darwin binaries (or a disk image or installer package) to notarize",
 			},
 		),
 		Args: chainArgs(
-			cobra.ExactArgs(1),
+			cobra.MinimumNArgs(1),
 			func(_ *cobra.Command, args []string) error {
-				opts.Path = args[0]
+				opts.Paths = args
 				return nil
 			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer bus.Exit()
 
+			if err := opts.Profile.Apply(cmd.Flags().Changed, nil, &opts.Notary); err != nil {
+				return err
+			}
+
 			// TODO: verify path is a signed darwin binary
 			// ... however, we may want to allow notarization of other kinds of assets (zip with darwin binary, etc)
 			if opts.DryRun {
 				log.Warn("[DRY RUN] skipping notarization...")
 				return nil
 			}
-			_, err := notarize(opts.Path, opts.Notary, opts.Status)
-			return err
+
+			var manifest *attest.Manifest
+			if opts.Manifest != "" {
+				// describe the binaries before submitting them, so a binary that cannot be described is not notarized
+				// without a manifest
+				manifest = attest.NewManifest()
+				for _, p := range opts.Paths {
+					if err := manifest.Add(p); err != nil {
+						return err
+					}
+				}
+			}
+
+			var result quill.NotarizeResult
+			var err error
+			if len(opts.Paths) > 1 {
+				result, err = notarizeBinaries(opts.Paths, opts.Notary, opts.Status)
+			} else {
+				result, err = notarize(opts.Paths[0], opts.Notary, opts.Status)
+			}
+			if err != nil {
+				return err
+			}
+
+			if manifest != nil {
+				// several binaries are notarized with a single submission
+				manifest.SetNotarization(manifestNotarization(result))
+				return writeManifest(manifest, opts.Manifest)
+			}
+			return nil
 		},
 	}, opts)
 }
 
-func notarize(binPath string, notaryCfg options.Notary, statusCfg options.Status) (notary.SubmissionStatus, error) {
+func notarize(binPath string, notaryCfg options.Notary, statusCfg options.Status) (quill.NotarizeResult, error) {
+	return quill.NotarizeWithResult(binPath, notarizeConfigFrom(notaryCfg, statusCfg))
+}
+
+func notarizeBinaries(binPaths []string, notaryCfg options.Notary, statusCfg options.Status) (quill.NotarizeResult, error) {
+	return quill.NotarizeBinariesWithResult(binPaths, notarizeConfigFrom(notaryCfg, statusCfg))
+}
+
+func manifestNotarization(result quill.NotarizeResult) attest.ManifestNotarization {
+	return attest.ManifestNotarization{
+		SubmissionID: result.SubmissionID,
+		Status:       string(result.Status),
+	}
+}
+
+func notarizeConfigFrom(notaryCfg options.Notary, statusCfg options.Status) quill.NotarizeConfig {
 	cfg := quill.NewNotarizeConfig(
 		notaryCfg.Issuer,
 		notaryCfg.PrivateKeyID,
 		notaryCfg.PrivateKey,
 	).WithStatusConfig(
 		notary.StatusConfig{
-			Timeout: time.Duration(int64(statusCfg.TimeoutSeconds) * int64(time.Second)),
-			Poll:    time.Duration(int64(statusCfg.PollSeconds) * int64(time.Second)),
-			Wait:    statusCfg.Wait,
+			Timeout:  time.Duration(int64(statusCfg.TimeoutSeconds) * int64(time.Second)),
+			Poll:     time.Duration(int64(statusCfg.PollSeconds) * int64(time.Second)),
+			Wait:     statusCfg.Wait,
+			MaxWait:  time.Duration(int64(statusCfg.MaxWaitSeconds) * int64(time.Second)),
+			Deadline: statusCfg.DeadlineTime(),
 		},
-	)
-	return quill.Notarize(binPath, *cfg)
+	).WithHistoryCheck(statusCfg.CheckHistory)
+	if statusCfg.SkipAccepted {
+		cfg.WithCache(notary.NewSubmissionCache())
+	}
+	return *cfg
 }
@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"crypto/x509"
 	"fmt"
 	"os"
 	"strings"
@@ -15,12 +14,14 @@ import (
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/quill/pki/apple"
 	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/load"
 )
 
 type p12AttachChainConfig struct {
-	Path             string `yaml:"path" json:"path" mapstructure:"-"`
-	options.Keychain `yaml:"keychain" json:"keychain" mapstructure:"keychain"`
-	options.P12      `yaml:"p12" json:"p12" mapstructure:"p12"`
+	Path               string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Keychain   `yaml:"keychain" json:"keychain" mapstructure:"keychain"`
+	options.P12        `yaml:"p12" json:"p12" mapstructure:"p12"`
+	options.TrustStore `yaml:"trust-store" json:"trust-store" mapstructure:"trust-store"`
 }
 
 func P12AttachChain(app clio.Application) *cobra.Command {
@@ -37,8 +38,10 @@ func P12AttachChain(app clio.Application) *cobra.Command {
 			"signing to work the full certificate chain is required to be packed into the binary. If you're on a mac " +
 			"then these certificates can be easily queried from the keychain (and are likely to be there). When not on " +
 			"a mac there are no guarantees.\n\nThis command will create a new p12 file that additionally has the " +
-			"full certificate chain needed for signing, which will be queried from the keychain and from the Apple " +
-			"certs embedded within quill.\n\nThe resulting P12 files can be passed directly into the `sign` " +
+			"full certificate chain needed for signing, which will be queried from the keychain, from the Apple " +
+			"certs embedded within quill, and (as a last resort) downloaded via the AIA URLs of the certificates. The chain " +
+			"is ordered and validated (each certificate must be signed by the next) and a report is shown before the " +
+			"new p12 file is written.\n\nThe resulting P12 files can be passed directly into the `sign` " +
 			"command with the `--p12` option.",
 		Example: options.FormatPositionalArgsHelp(
 			map[string]string{
@@ -55,6 +58,10 @@ func P12AttachChain(app clio.Application) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer bus.Exit()
 
+			if err := opts.TrustStore.Apply(cmd.Context()); err != nil {
+				return err
+			}
+
 			newFilename, err := writeP12WithChain(opts.Path, opts.P12.Password, opts.Keychain.Path, true)
 			if err != nil {
 				return fmt.Errorf("unable to write new p12 with chain attached file=%q : %w", opts.Path, err)
@@ -76,7 +83,9 @@ func P12AttachChain(app clio.Application) *cobra.Command {
 func writeP12WithChain(p12Path, password, keychainPath string, failWithoutFullChain bool) (string, error) {
 	log.WithFields("file", p12Path).Info("attaching certificate chain to p12 file")
 
-	p12Contents, err := loadP12Interactively(p12Path, password)
+	// capture the resolved password so that the new p12 file is encrypted with the same password as the original
+	provider := &capturedPassphrase{provider: passphraseProvider(password), static: password}
+	p12Contents, err := load.P12WithPassphrase(p12Path, provider)
 	if err != nil {
 		return "", err
 	}
@@ -87,32 +96,53 @@ func writeP12WithChain(p12Path, password, keychainPath string, failWithoutFullCh
 
 	log.WithFields("chain-certs", len(p12Contents.Certificates), "signing-cert", fmt.Sprintf("%q", p12Contents.Certificate.Subject.CommonName)).Debug("existing p12 contents")
 
-	if len(p12Contents.Certificates) > 0 {
-		return "", fmt.Errorf("p12 file already has the certificate chain embedded (chain length %d + 1 signing certificate)", len(p12Contents.Certificates))
+	p12Certs := certchain.NewCollection()
+	if err := p12Certs.AddIntermediate(p12Contents.Certificates...); err != nil {
+		return "", err
 	}
 
-	store := certchain.NewCollection().WithStores(apple.GetEmbeddedCertStore())
+	sources := []certchain.Source{
+		{Name: "p12", Searcher: p12Certs},
+	}
 	if keychainPath != "" {
-		store = store.WithSearchers(apple.NewKeychainSearcher(keychainPath))
+		sources = append(sources, certchain.Source{Name: "keychain", Searcher: apple.NewKeychainSearcher(keychainPath)})
+	}
+	sources = append(sources, certchain.Source{Name: apple.GetTrustStore().Source, Searcher: apple.GetTrustStore()})
+	if !apple.ExclusiveTrust() {
+		sources = append(sources, certchain.Source{Name: "aia", Searcher: certchain.NewAIASearcher(p12Contents.Certificate)})
 	}
 
-	var certs = append([]*x509.Certificate{}, p12Contents.Certificates...)
-	remainingCerts, err := certchain.Find(store, p12Contents.Certificate)
+	report, err := certchain.Build(p12Contents.Certificate, sources...)
 	if err != nil {
-		return "", fmt.Errorf("unable to find remaining chain certificates: %w", err)
+		return "", fmt.Errorf("unable to build certificate chain: %w", err)
 	}
-	certs = append(certs, remainingCerts...)
 
-	p12Bytes, err := pkcs12.Modern2023.Encode(p12Contents.PrivateKey, p12Contents.Certificate, certs, password)
-	if err != nil {
-		return "", fmt.Errorf("unable to encode p12 file: %w", err)
+	// show the validation report before writing anything
+	bus.Report(report.String())
+
+	if !report.Complete() && failWithoutFullChain {
+		return "", fmt.Errorf("unable to find the full certificate chain (missing issuer %q)", report.MissingIssuer)
 	}
 
+	// the chain is ordered leaf -> root, the leaf is encoded separately
+	chain := report.Certificates()
+	certs := chain[1:]
+
 	// verify the cert chain before writing...
-	if err := certchain.VerifyForCodeSigning(append(certs, p12Contents.Certificate), failWithoutFullChain); err != nil {
+	if err := certchain.VerifyForCodeSigning(chain, failWithoutFullChain); err != nil {
 		return "", err
 	}
 
+	encodePassword, err := provider.password()
+	if err != nil {
+		return "", err
+	}
+
+	p12Bytes, err := pkcs12.Modern2023.Encode(p12Contents.PrivateKey, p12Contents.Certificate, certs, encodePassword)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode p12 file: %w", err)
+	}
+
 	// write the new file...
 	newFilename := strings.TrimSuffix(p12Path, ".p12") + "-with-chain.p12"
 
@@ -122,3 +152,31 @@ func writeP12WithChain(p12Path, password, keychainPath string, failWithoutFullCh
 
 	return newFilename, nil
 }
+
+type capturedPassphrase struct {
+	provider load.PassphraseProvider
+	// static is the password given by the user (possibly an env, file, or command reference), if any.
+	static   string
+	value    string
+	captured bool
+}
+
+func (c *capturedPassphrase) Passphrase(prompt string) (string, error) {
+	value, err := c.provider.Passphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	c.value = value
+	c.captured = true
+	return value, nil
+}
+
+// password returns the password the p12 file was decrypted with. The provider is only asked for a password when the
+// p12 file has one, so for a p12 file without a password the password given by the user (if any) is used instead:
+// the new p12 file holds the private key and must not be left unencrypted when a password was given.
+func (c *capturedPassphrase) password() (string, error) {
+	if c.captured || c.static == "" {
+		return c.value, nil
+	}
+	return passphraseProvider(c.static).Passphrase("p12 password")
+}
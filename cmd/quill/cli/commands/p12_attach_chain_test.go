@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-logger/adapter/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+
+	intRedact "github.com/anchore/quill/internal/redact"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestMain(m *testing.M) {
+	// passwords are redacted from any output
+	intRedact.Set(redact.NewStore())
+	os.Exit(m.Run())
+}
+
+func TestWriteP12WithChain_password(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		inputPassword string
+		password      string
+		want          string
+	}{
+		{
+			name:          "encrypted input",
+			inputPassword: "secret",
+			password:      "secret",
+			want:          "secret",
+		},
+		{
+			name:     "passwordless input with a password",
+			password: "secret",
+			want:     "secret",
+		},
+		{
+			name:     "passwordless input without a password",
+			password: "",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := pkcs12.Modern2023.Encode(fixture.LeafKey, fixture.Leaf, []*x509.Certificate{fixture.Intermediate, fixture.Root}, tt.inputPassword)
+			require.NoError(t, err)
+			path := filepath.Join(t.TempDir(), "identity.p12")
+			require.NoError(t, os.WriteFile(path, input, 0600))
+
+			output, err := writeP12WithChain(path, tt.password, "", false)
+			require.NoError(t, err)
+
+			by, err := os.ReadFile(output)
+			require.NoError(t, err)
+			_, _, _, err = pkcs12.DecodeChain(by, tt.want)
+			assert.NoError(t, err)
+			if tt.want != "" {
+				_, _, _, err = pkcs12.DecodeChain(by, "")
+				assert.Error(t, err, "the new p12 file must be encrypted")
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+type p12CreateTestConfig struct {
+	Path                 string `yaml:"path" json:"path" mapstructure:"-"`
+	options.TestIdentity `yaml:"test-identity" json:"test-identity" mapstructure:"test-identity"`
+	options.P12          `yaml:"p12" json:"p12" mapstructure:"p12"`
+}
+
+func P12CreateTest(app clio.Application) *cobra.Command {
+	opts := &p12CreateTestConfig{
+		TestIdentity: options.DefaultTestIdentity(),
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "create-test PATH",
+		Short: "create a p12 file with a throwaway (untrusted) Developer ID-like signing identity for testing",
+		Long: "Generate a throwaway root CA, intermediate CA, and a \"Developer ID Application\"-like signing certificate " +
+			"(with the code signing EKU and the team ID as the OU) and write them into a new p12 file along with the " +
+			"signing private key. The root certificate is additionally written next to the p12 file (with a " +
+			"`-root.pem` suffix) so that it can be used as a trust anchor when verifying.\n\nThese identities are never " +
+			"trusted by Apple and are only useful for testing signing and verification without real Developer ID material.",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "path to write the new p12 file to",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			rootPath, err := writeTestP12(opts.Path, opts.P12.Password, opts.TestIdentity)
+			if err != nil {
+				return fmt.Errorf("unable to create test p12 file=%q : %w", opts.Path, err)
+			}
+
+			description, err := describeP12(opts.Path, opts.P12.Password)
+			if err != nil {
+				return fmt.Errorf("unable to describe p12 file=%q : %w", opts.Path, err)
+			}
+
+			bus.Report(description)
+			bus.Notify(fmt.Sprintf("Wrote test p12 file to %q and its root certificate to %q", opts.Path, rootPath))
+
+			return nil
+		},
+	}, opts)
+}
+
+func writeTestP12(path, password string, opts options.TestIdentity) (string, error) {
+	log.WithFields("file", path, "team-id", opts.TeamID).Info("creating test signing identity")
+
+	f, err := testca.New(testca.Config{
+		TeamID:  opts.TeamID,
+		Name:    opts.Name,
+		KeyType: opts.KeyType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p12Bytes, err := f.P12(password)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, p12Bytes, 0600); err != nil {
+		return "", fmt.Errorf("unable to write p12 file: %w", err)
+	}
+
+	rootPath := strings.TrimSuffix(path, ".p12") + "-root.pem"
+	rootPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.Root.Raw})
+	if err := os.WriteFile(rootPath, rootPEM, 0600); err != nil {
+		return "", fmt.Errorf("unable to write root certificate: %w", err)
+	}
+
+	return rootPath, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -70,6 +71,8 @@ func describeP12(file, password string) (string, error) {
 		buf.WriteString(fmt.Sprintf("  - Subject:          CN=%q O=%q OU=%q\n", c.Subject.CommonName, strings.Join(c.Subject.Organization, ","), strings.Join(c.Subject.OrganizationalUnit, ",")))
 		buf.WriteString(fmt.Sprintf("    Subject-Key-ID:   %x\n", c.SubjectKeyId))
 		buf.WriteString(fmt.Sprintf("    Authority-Key-ID: %x\n", c.AuthorityKeyId))
+		buf.WriteString(fmt.Sprintf("    Not-Before:       %s\n", c.NotBefore.Format(time.RFC3339)))
+		buf.WriteString(fmt.Sprintf("    Not-After:        %s\n", c.NotAfter.Format(time.RFC3339)))
 	}
 
 	if p12Contents.Certificate != nil {
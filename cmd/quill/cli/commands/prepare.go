@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill"
+)
+
+type prepareConfig struct {
+	Path          string `yaml:"path" json:"path" mapstructure:"-"`
+	SignatureSize int    `yaml:"signature-size" json:"signature-size" mapstructure:"signature-size"`
+}
+
+func (o *prepareConfig) AddFlags(flags fangs.FlagSet) {
+	flags.IntVarP(
+		&o.SignatureSize,
+		"signature-size", "",
+		"the number of bytes to reserve for the code signature of every architecture (required)",
+	)
+}
+
+func Prepare(app clio.Application) *cobra.Command {
+	opts := &prepareConfig{}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "prepare PATH",
+		Short: "reserve space for the code signature of an unsigned binary without signing it",
+		Long:  "add the code signature load command to an unsigned binary and reserve zeroed space for the signature at the end of the __LINKEDIT segment, in place, so that a later signing step (signing the binary, or attaching a signature made for a copy of the prepared binary) drops the signature into the reserved space without moving any other content",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "the unsigned darwin binary to prepare",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			if opts.SignatureSize <= 0 {
+				return fmt.Errorf("the size to reserve for the signature must be given with --signature-size")
+			}
+
+			if err := quill.PrepareSignature(opts.Path, opts.SignatureSize); err != nil {
+				return err
+			}
+
+			bus.Notify(fmt.Sprintf("Reserved %d bytes for the signature of %q", opts.SignatureSize, opts.Path))
+			return nil
+		},
+	}, opts)
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/lint"
+)
+
+type runtimeConfig struct {
+	Paths          []string `yaml:"paths" json:"paths" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func Runtime(app clio.Application) *cobra.Command {
+	opts := &runtimeConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "runtime PATH...",
+		Short: "report the hardened runtime posture of binaries",
+		Long:  "report every hardened runtime relevant fact of the given binaries (or every binary within the given directories): whether the CS_RUNTIME flag is set, the runtime version, and every runtime exception and resource access entitlement present",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "one or more darwin binaries or directories to report on",
+			},
+		),
+		Args: chainArgs(
+			cobra.MinimumNArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Paths = args
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			var reports []lint.RuntimeReport
+			for _, p := range opts.Paths {
+				r, err := lint.HardenedRuntime(p)
+				if err != nil {
+					return err
+				}
+				reports = append(reports, r...)
+			}
+
+			buf := &strings.Builder{}
+			if err := lint.ShowHardenedRuntime(reports, buf, opts.Output); err != nil {
+				return err
+			}
+
+			bus.Report(buf.String())
+
+			return nil
+		},
+	}, opts)
+}
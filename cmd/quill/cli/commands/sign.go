@@ -1,7 +1,14 @@
 package commands
 
 import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path"
 
 	"github.com/spf13/cobra"
 
@@ -10,11 +17,19 @@ import (
 	"github.com/anchore/quill/internal/bus"
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/quill"
+	"github.com/anchore/quill/quill/attest"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/fulcio"
+	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/provisioning"
 )
 
 type signConfig struct {
-	Path            string `yaml:"path" json:"path" mapstructure:"-"`
-	options.Signing `yaml:"sign" json:"sign" mapstructure:"sign"`
+	Paths              []string `yaml:"paths" json:"paths" mapstructure:"-"`
+	options.Signing    `yaml:"sign" json:"sign" mapstructure:"sign"`
+	options.TrustStore `yaml:"trust-store" json:"trust-store" mapstructure:"trust-store"`
+	options.Profile    `yaml:",inline" json:",inline" mapstructure:",squash"`
 }
 
 func Sign(app clio.Application) *cobra.Command {
@@ -23,55 +38,419 @@ func Sign(app clio.Application) *cobra.Command {
 	}
 
 	return app.SetupCommand(&cobra.Command{
-		Use:   "sign PATH",
-		Short: "sign a macho (darwin) executable binary",
+		Use:   "sign PATH...",
+		Short: "sign one or more macho (darwin) executable binaries, flat installer packages (.pkg), app bundles, iOS app archives (.ipa), or the binaries within zip and tar.gz archives",
 		Example: options.FormatPositionalArgsHelp(
 			map[string]string{
-				"PATH": "the darwin binary to sign",
+				"PATH": "the darwin binaries, installer packages, app bundles, iOS app archives, or zip and tar.gz archives of binaries to sign (all are signed with the same signing material), or '-' to sign a binary read from stdin and write it to stdout",
 			},
 		),
 		Args: chainArgs(
-			cobra.ExactArgs(1),
+			cobra.MinimumNArgs(1),
 			func(_ *cobra.Command, args []string) error {
-				opts.Path = args[0]
+				opts.Paths = args
 				return nil
 			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer bus.Exit()
 
-			return sign(opts.Path, opts.Signing)
+			if err := opts.Profile.Apply(cmd.Flags().Changed, &opts.Signing, nil); err != nil {
+				return err
+			}
+
+			if err := applyTrustStore(cmd.Context(), opts.TrustStore, opts.Offline); err != nil {
+				return err
+			}
+
+			manifest, err := signAll(opts.Paths, opts.Signing)
+			if err != nil {
+				return err
+			}
+			if manifest != nil {
+				return writeManifest(manifest, opts.Manifest)
+			}
+			return nil
 		},
 	}, opts)
 }
 
-func sign(binPath string, opts options.Signing) error {
+// applyTrustStore makes the configured trust store current, a trust store URL cannot be fetched in offline mode.
+func applyTrustStore(ctx context.Context, opts options.TrustStore, offline bool) error {
+	if offline && opts.URL != "" {
+		return fmt.Errorf("a trust store cannot be fetched with --offline (download the bundle and use --trust-store-bundle instead)")
+	}
+	return opts.Apply(ctx)
+}
+
+func sign(binPath string, opts options.Signing) (*attest.Manifest, error) {
+	return signAll([]string{binPath}, opts)
+}
+
+// signAll signs every given binary with the same signing material (which is only resolved once). The returned manifest
+// describes every signed binary when one is configured (nil otherwise), it is up to the caller to write it.
+//
+//nolint:funlen,gocognit,gocyclo
+func signAll(paths []string, opts options.Signing) (*attest.Manifest, error) {
+	if len(paths) > 1 && opts.Attestation != "" {
+		return nil, fmt.Errorf("an attestation can only be written when signing a single binary (use --provenance when signing several)")
+	}
+
+	binPath := paths[0]
+
+	if err := checkStdinSigning(paths, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Offline {
+		network.SetOffline(true)
+		log.Info("offline mode: the signature will not be timestamped")
+	}
+
 	cfg := quill.SigningConfig{
 		Path: binPath,
 	}
 
-	if opts.P12 != "" {
-		if opts.AdHoc {
-			log.Warn("ad-hoc signing is enabled, but a p12 file was also provided. The p12 file will be ignored.")
-		} else {
-			p12Content, err := loadP12Interactively(opts.P12, opts.Password)
-			if err != nil {
-				return fmt.Errorf("unable to decode p12 file: %w", err)
-			}
-			if p12Content == nil {
-				return fmt.Errorf("no content found in the p12 file")
+	switch {
+	case opts.AdHoc && (opts.P12 != "" || opts.Certificate != "" || opts.SigningDir != "" || opts.Keyless):
+		log.Warn("ad-hoc signing is enabled, but signing material was also provided. The signing material will be ignored.")
+	case countNonEmpty(opts.P12, opts.Certificate, opts.SigningDir) > 1:
+		return nil, fmt.Errorf("more than one of a p12 file, PEM certificate, or signing directory were provided, only one source of signing material may be used")
+	case opts.Keyless && countNonEmpty(opts.P12, opts.Certificate, opts.SigningDir) > 0:
+		return nil, fmt.Errorf("keyless signing cannot be combined with a p12 file, PEM certificate, or signing directory")
+	case opts.Keyless:
+		token, err := fulcio.IdentityToken(opts.IdentityToken)
+		if err != nil {
+			return nil, err
+		}
+		sm, err := fulcio.NewSigningMaterial(fulcio.Config{URL: opts.FulcioURL, IdentityToken: token})
+		if err != nil {
+			return nil, fmt.Errorf("unable to obtain a keyless signing certificate: %w", err)
+		}
+		cfg = *quill.NewSigningConfig(binPath, *sm)
+	case opts.SigningDir != "":
+		candidates, err := pki.NewSigningMaterialsFromDirectory(opts.SigningDir, passphraseProvider(opts.Password), opts.FailWithoutFullChain)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signing directory: %w", err)
+		}
+		selected, err := candidates.Resolve(opts.SigningIdentity)
+		if err != nil {
+			return nil, err
+		}
+		cfg = *quill.NewSigningConfig(binPath, *selected)
+	case opts.Certificate != "":
+		if opts.PrivateKey == "" {
+			return nil, fmt.Errorf("a private key is required when signing with a PEM certificate")
+		}
+		if opts.Certificate == "-" && opts.PrivateKey == "-" {
+			return nil, fmt.Errorf("only one of the certificate or private key may be read from stdin")
+		}
+
+		replacement, err := quill.NewSigningConfigFromPEMsWithPassphrase(binPath, opts.Certificate, opts.PrivateKey, passphraseProvider(opts.Password), opts.FailWithoutFullChain)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read PEM signing material: %w", err)
+		}
+		if opts.SigningIdentity != "" {
+			if _, err := pki.SelectIdentity([]*pki.SigningMaterial{&replacement.SigningMaterial}, opts.SigningIdentity); err != nil {
+				return nil, err
 			}
+		}
+		cfg = *replacement
+	case opts.P12 != "":
+		p12Content, err := loadP12Interactively(opts.P12, opts.Password)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode p12 file: %w", err)
+		}
+		if p12Content == nil {
+			return nil, fmt.Errorf("no content found in the p12 file")
+		}
 
-			replacement, err := quill.NewSigningConfigFromP12(binPath, *p12Content, opts.FailWithoutFullChain)
+		if opts.SigningIdentity != "" {
+			candidates, err := pki.NewSigningMaterialsFromP12(*p12Content, opts.FailWithoutFullChain)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read p12: %w", err)
+			}
+			selected, err := candidates.Select(opts.SigningIdentity)
 			if err != nil {
-				return fmt.Errorf("unable to read p12: %w", err)
+				return nil, err
 			}
-			cfg = *replacement
+			cfg = *quill.NewSigningConfig(binPath, *selected)
+			break
+		}
+
+		replacement, err := quill.NewSigningConfigFromP12(binPath, *p12Content, opts.FailWithoutFullChain)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read p12: %w", err)
 		}
+		cfg = *replacement
 	}
 
 	cfg.WithIdentity(opts.Identity)
-	cfg.WithTimestampServer(opts.TimestampServer)
+	cfg.WithIdentifierPrefix(opts.IdentifierPrefix)
+
+	var profiles []*provisioning.Profile
+	for _, p := range opts.ProvisioningProfiles {
+		profile, err := provisioning.Load(p)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	cfg.WithProvisioningProfiles(profiles...)
+	cfg.WithPreservedLinkerSignature(opts.PreserveLinkerSig)
+	cfg.WithLibraryValidation(opts.LibraryValidation)
+	cfg.WithArchiveMembers(opts.ArchiveMembers...)
+
+	blobEdit, err := opts.BlobEdit()
+	if err != nil {
+		return nil, err
+	}
+	if blobEdit != nil {
+		cfg.WithBlobEdit(*blobEdit)
+	}
+	if opts.Resign {
+		cfg.WithResign(opts.ResignMutations()...)
+	}
+
+	loadCommandEdits, err := opts.LoadCommandEdits()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithLoadCommandEdits(loadCommandEdits...)
+	cfg.WithNormalizedLayout(opts.NormalizeLayout)
+
+	cdVersion, err := opts.CodeDirectory()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithCodeDirectoryVersion(cdVersion)
+
+	runtimeVersion, err := opts.Runtime()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithRuntimeVersion(runtimeVersion)
+
+	signingTime, err := opts.SigningTimeSetting()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithSigningTime(signingTime)
+
+	signatureAlgorithm, err := opts.SignatureAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithSignatureAlgorithm(signatureAlgorithm)
+
+	timestampCfg, err := opts.TimestampConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithTimestamp(timestampCfg)
+
+	embedding, err := pki.ParseChainEmbedding(opts.EmbedChain)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithChainEmbedding(embedding)
+
+	expiry, err := opts.ExpiryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	cfg.WithExpiryPolicy(expiry)
+
+	var attestationSigner crypto.Signer
+	if opts.Attestation != "" {
+		// resolve the attestation key before signing, so a bad key does not leave a signed binary without an attestation
+		attestationSigner, err = attestationKey(opts, cfg.SigningMaterial)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var batch *attest.Batch
+	if opts.Provenance != "" {
+		batch = attest.NewBatch(provenanceParameters(opts, cfg))
+	}
+
+	var manifest *attest.Manifest
+	if opts.Manifest != "" {
+		manifest = attest.NewManifest()
+	}
 
-	return quill.Sign(cfg)
+	for _, p := range paths {
+		c := cfg
+		c.Path = p
+		if opts.Identity == "" && c.Identity != "" {
+			// the identifier is derived from the name of each binary
+			c.Identity = path.Base(p)
+		}
+
+		if batch != nil {
+			if err := batch.AddInput(p); err != nil {
+				return nil, err
+			}
+		}
+
+		if p == stdinPath {
+			if err := quill.SignStream(os.Stdin, os.Stdout, c); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := quill.Sign(c); err != nil {
+			if len(paths) > 1 {
+				return nil, fmt.Errorf("unable to sign %q: %w", p, err)
+			}
+			return nil, err
+		}
+
+		if batch != nil {
+			if err := batch.AddOutput(p); err != nil {
+				return nil, err
+			}
+		}
+
+		if manifest != nil {
+			if err := manifest.Add(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.Attestation != "" {
+		if err := writeAttestation(binPath, opts.Attestation, attestationSigner); err != nil {
+			return nil, err
+		}
+	}
+
+	if batch != nil {
+		if err := writeProvenance(batch, opts.Provenance); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// stdinPath is the path standing for a binary read from stdin (the signed binary is written to stdout).
+const stdinPath = "-"
+
+// checkStdinSigning fails when a binary read from stdin cannot be signed with the given options.
+func checkStdinSigning(paths []string, opts options.Signing) error {
+	stdin := false
+	for _, p := range paths {
+		stdin = stdin || p == stdinPath
+	}
+	switch {
+	case !stdin:
+		return nil
+	case len(paths) > 1:
+		return fmt.Errorf("a binary read from stdin must be the only binary to sign")
+	case opts.P12 == stdinPath || opts.Certificate == stdinPath || opts.PrivateKey == stdinPath:
+		return fmt.Errorf("the binary and the signing material cannot both be read from stdin")
+	case opts.Attestation != "" || opts.Provenance != "" || opts.Manifest != "":
+		return fmt.Errorf("an attestation, provenance, or manifest cannot be written for a binary read from stdin")
+	}
+	return nil
+}
+
+func provenanceParameters(opts options.Signing, cfg quill.SigningConfig) attest.SigningParameters {
+	params := attest.SigningParameters{
+		Identifier:       opts.Identity,
+		AdHoc:            cfg.SigningMaterial.Signer == nil,
+		Keyless:          opts.Keyless,
+		TimestampServers: cfg.SigningMaterial.Timestamp.Servers,
+	}
+	if leaf := cfg.SigningMaterial.Leaf(); leaf != nil && !params.AdHoc {
+		digest := sha256.Sum256(leaf.Raw)
+		params.SigningCertificate = leaf.Subject.String()
+		params.CertificateSHA256 = hex.EncodeToString(digest[:])
+		params.ChainEmbedding = opts.EmbedChain
+	}
+	return params
+}
+
+func writeProvenance(batch *attest.Batch, output string) error {
+	by, err := json.Marshal(batch.Provenance())
+	if err != nil {
+		return fmt.Errorf("unable to encode provenance: %w", err)
+	}
+
+	// one statement per line (the in-toto JSON lines bundle format)
+	if err := os.WriteFile(output, append(by, '\n'), 0600); err != nil {
+		return fmt.Errorf("unable to write provenance: %w", err)
+	}
+
+	bus.Notify(fmt.Sprintf("Wrote provenance to %s", output))
+	return nil
+}
+
+func writeManifest(manifest *attest.Manifest, output string) error {
+	by, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode manifest: %w", err)
+	}
+
+	if err := os.WriteFile(output, append(by, '\n'), 0600); err != nil {
+		return fmt.Errorf("unable to write manifest: %w", err)
+	}
+
+	bus.Notify(fmt.Sprintf("Wrote manifest to %s", output))
+	return nil
+}
+
+func attestationKey(opts options.Signing, signingMaterial pki.SigningMaterial) (crypto.Signer, error) {
+	if opts.AttestationKey == "" {
+		if signingMaterial.Signer == nil {
+			return nil, fmt.Errorf("an --attestation-key is required to attest an ad-hoc signature")
+		}
+		return signingMaterial.Signer, nil
+	}
+
+	key, err := load.PrivateKeyWithPassphrase(opts.AttestationKey, passphraseProvider(opts.Password))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read attestation key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("attestation key cannot be used for signing (%T)", key)
+	}
+	return signer, nil
+}
+
+func writeAttestation(binPath, output string, signer crypto.Signer) error {
+	statement, err := attest.NewStatement(binPath)
+	if err != nil {
+		return fmt.Errorf("unable to create attestation: %w", err)
+	}
+
+	envelope, err := attest.Sign(*statement, signer)
+	if err != nil {
+		return err
+	}
+
+	by, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode attestation: %w", err)
+	}
+
+	if err := os.WriteFile(output, append(by, '\n'), 0600); err != nil {
+		return fmt.Errorf("unable to write attestation: %w", err)
+	}
+
+	bus.Notify(fmt.Sprintf("Wrote attestation to %s", output))
+	return nil
+}
+
+func countNonEmpty(values ...string) int {
+	var count int
+	for _, v := range values {
+		if v != "" {
+			count++
+		}
+	}
+	return count
 }
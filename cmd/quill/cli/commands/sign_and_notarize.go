@@ -15,11 +15,13 @@ import (
 var _ fangs.FlagAdder = &signAndNotarizeConfig{}
 
 type signAndNotarizeConfig struct {
-	Path            string `yaml:"path" json:"path" mapstructure:"-"`
-	options.Signing `yaml:"sign" json:"sign" mapstructure:"sign"`
-	options.Notary  `yaml:"notary" json:"notary" mapstructure:"notary"`
-	options.Status  `yaml:"status" json:"status" mapstructure:"status"`
-	DryRun          bool `yaml:"dry-run" json:"dry-run" mapstructure:"dry-run"`
+	Path               string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Signing    `yaml:"sign" json:"sign" mapstructure:"sign"`
+	options.Notary     `yaml:"notary" json:"notary" mapstructure:"notary"`
+	options.Status     `yaml:"status" json:"status" mapstructure:"status"`
+	options.TrustStore `yaml:"trust-store" json:"trust-store" mapstructure:"trust-store"`
+	options.Profile    `yaml:",inline" json:",inline" mapstructure:",squash"`
+	DryRun             bool `yaml:"dry-run" json:"dry-run" mapstructure:"dry-run"`
 }
 
 func (o *signAndNotarizeConfig) AddFlags(flags fangs.FlagSet) {
@@ -50,21 +52,40 @@ func SignAndNotarize(app clio.Application) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer bus.Exit()
 
-			err := sign(opts.Path, opts.Signing)
+			if err := opts.Profile.Apply(cmd.Flags().Changed, &opts.Signing, &opts.Notary); err != nil {
+				return err
+			}
+
+			if opts.Offline && !opts.DryRun {
+				return fmt.Errorf("notarization requires network access and cannot be used with --offline (use --dry-run to only sign)")
+			}
+
+			if err := applyTrustStore(cmd.Context(), opts.TrustStore, opts.Offline); err != nil {
+				return err
+			}
+
+			manifest, err := sign(opts.Path, opts.Signing)
 			if err != nil {
 				return fmt.Errorf("signing failed: %w", err)
 			}
 
 			if opts.DryRun {
 				log.Warn("[DRY RUN] skipping notarization...")
+				if manifest != nil {
+					return writeManifest(manifest, opts.Manifest)
+				}
 				return nil
 			}
 
-			_, err = notarize(opts.Path, opts.Notary, opts.Status)
+			result, err := notarize(opts.Path, opts.Notary, opts.Status)
 			if err != nil {
 				return fmt.Errorf("notarization failed: %w", err)
 			}
 
+			if manifest != nil {
+				manifest.SetNotarization(manifestNotarization(result))
+				return writeManifest(manifest, opts.Manifest)
+			}
 			return nil
 		},
 	}, opts)
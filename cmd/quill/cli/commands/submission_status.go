@@ -52,9 +52,11 @@ func SubmissionStatus(app clio.Application) *cobra.Command {
 				opts.Notary.PrivateKey,
 			).WithStatusConfig(
 				notary.StatusConfig{
-					Timeout: time.Duration(int64(opts.TimeoutSeconds) * int64(time.Second)),
-					Poll:    time.Duration(int64(opts.PollSeconds) * int64(time.Second)),
-					Wait:    opts.Wait,
+					Timeout:  time.Duration(int64(opts.TimeoutSeconds) * int64(time.Second)),
+					Poll:     time.Duration(int64(opts.PollSeconds) * int64(time.Second)),
+					Wait:     opts.Wait,
+					MaxWait:  time.Duration(int64(opts.MaxWaitSeconds) * int64(time.Second)),
+					Deadline: opts.DeadlineTime(),
 				},
 			)
 
@@ -0,0 +1,15 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+)
+
+func Ticket(app clio.Application) *cobra.Command {
+	return app.SetupCommand(&cobra.Command{
+		Use:   "ticket",
+		Short: "inspect the notarization tickets stapled to binaries, disk images, and installer packages",
+		Args:  cobra.NoArgs,
+	})
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/extract"
+	"github.com/anchore/quill/quill/ticket"
+)
+
+type ticketDescribeConfig struct {
+	Path           string `yaml:"path" json:"path" mapstructure:"-"`
+	options.Format `yaml:",inline" json:",inline" mapstructure:",squash"`
+}
+
+func TicketDescribe(app clio.Application) *cobra.Command {
+	opts := &ticketDescribeConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "describe PATH",
+		Short: "show the contents of the notarization ticket stapled to an artifact",
+		Long:  "show what the notarization ticket stapled to the given binary, disk image, or installer package asserts: the record type, when it was issued and when it expires, and the cdhashes it covers",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "the binary, disk image (.dmg), or installer package (.pkg)",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Path = args[0]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			a, err := ticket.ReadArtifact(opts.Path)
+			if err != nil {
+				return err
+			}
+			if a.Ticket == nil {
+				return fmt.Errorf("no ticket is stapled to the %s %q", a.Kind, opts.Path)
+			}
+			details := extract.NewTicketDetails(a.Ticket)
+
+			switch strings.ToLower(opts.Output) {
+			case "json":
+				b, err := json.MarshalIndent(details, "", "  ")
+				if err != nil {
+					return err
+				}
+				bus.Report(string(b))
+			case "text":
+				bus.Report(details.String())
+			default:
+				return fmt.Errorf("unsupported output format: %q", opts.Output)
+			}
+			return nil
+		},
+	}, opts)
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/ticket"
+)
+
+type ticketValidateConfig struct {
+	Paths []string `yaml:"paths" json:"paths" mapstructure:"-"`
+}
+
+func TicketValidate(app clio.Application) *cobra.Command {
+	opts := &ticketValidateConfig{}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "validate PATH...",
+		Short: "validate the notarization ticket stapled to one or more artifacts offline",
+		Long:  "validate the notarization ticket stapled to the given binaries, disk images, or installer packages without any network access (as stapler validate does): the structure of the ticket is checked, as well as that it covers the cdhashes of the code of the artifact (Apple's signature over the ticket is not checked)",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "one or more binaries, disk images (.dmg), or installer packages (.pkg)",
+			},
+		),
+		Args: chainArgs(
+			cobra.MinimumNArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Paths = args
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			buf := &strings.Builder{}
+			var invalid int
+			for _, p := range opts.Paths {
+				r, err := ticket.Validate(p)
+				if err != nil {
+					return err
+				}
+				if !r.Valid() {
+					invalid++
+				}
+				fmt.Fprintf(buf, "%s: %s (%s)\n", r.Path, r.Status, r.Message)
+			}
+
+			bus.Report(buf.String())
+
+			if invalid > 0 {
+				return fmt.Errorf("%d of %d artifacts have no valid stapled ticket", invalid, len(opts.Paths))
+			}
+			return nil
+		},
+	}, opts)
+}
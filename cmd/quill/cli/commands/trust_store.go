@@ -0,0 +1,15 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+)
+
+func TrustStore(app clio.Application) *cobra.Command {
+	return app.SetupCommand(&cobra.Command{
+		Use:   "trust-store",
+		Short: "manage the snapshot of the Apple root and intermediate certificates used to complete and verify certificate chains",
+		Args:  cobra.NoArgs,
+	})
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/pki/apple"
+)
+
+type trustStoreFetchConfig struct {
+	URL  string `yaml:"url" json:"url" mapstructure:"-"`
+	Path string `yaml:"path" json:"path" mapstructure:"-"`
+}
+
+func TrustStoreFetch(app clio.Application) *cobra.Command {
+	opts := &trustStoreFetchConfig{}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "fetch URL PATH",
+		Short: "download a trust store bundle, check it, and write it to a file (e.g. to use it on hosts without network access)",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"URL":  "the URL of the trust store bundle (PEM file of the Apple root and intermediate certificates)",
+				"PATH": "the file to write the bundle to",
+			},
+		),
+		Args: chainArgs(
+			cobra.ExactArgs(2),
+			func(_ *cobra.Command, args []string) error {
+				opts.URL = args[0]
+				opts.Path = args[1]
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			store, err := apple.FetchTrustStore(cmd.Context(), opts.URL)
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(opts.Path, store.Bundle(), 0600); err != nil {
+				return fmt.Errorf("unable to write trust store bundle: %w", err)
+			}
+
+			bus.Notify(fmt.Sprintf("Wrote the trust store bundle to %q (version %q, %d roots, %d intermediates, digest %s)", opts.Path, store.Version, len(store.Roots()), len(store.Intermediates()), store.Digest()))
+			return nil
+		},
+	}, opts)
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/pki/apple"
+)
+
+type trustStoreShowConfig struct {
+	options.Format     `yaml:",inline" json:",inline" mapstructure:",squash"`
+	options.TrustStore `yaml:"trust-store" json:"trust-store" mapstructure:"trust-store"`
+}
+
+type trustStoreSummary struct {
+	Version       string   `json:"version"`
+	Source        string   `json:"source"`
+	Digest        string   `json:"digest"`
+	Roots         []string `json:"roots"`
+	Intermediates []string `json:"intermediates"`
+}
+
+func TrustStoreShow(app clio.Application) *cobra.Command {
+	opts := &trustStoreShowConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the version, digest, and certificates of the trust store (the one embedded into quill unless a bundle or URL is given)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			if err := opts.TrustStore.Apply(cmd.Context()); err != nil {
+				return err
+			}
+
+			store := apple.GetTrustStore()
+			summary := trustStoreSummary{
+				Version: store.Version,
+				Source:  store.Source,
+				Digest:  store.Digest(),
+			}
+			for _, c := range store.Roots() {
+				summary.Roots = append(summary.Roots, c.Subject.CommonName)
+			}
+			for _, c := range store.Intermediates() {
+				summary.Intermediates = append(summary.Intermediates, c.Subject.CommonName)
+			}
+
+			switch strings.ToLower(opts.Output) {
+			case "json":
+				b, err := json.MarshalIndent(summary, "", "  ")
+				if err != nil {
+					return err
+				}
+				bus.Report(string(b))
+			case "text":
+				bus.Report(summary.String())
+			default:
+				return fmt.Errorf("unsupported output format: %q", opts.Output)
+			}
+			return nil
+		},
+	}, opts)
+}
+
+func (s trustStoreSummary) String() string {
+	buf := &strings.Builder{}
+	version := s.Version
+	if version == "" {
+		version = "(unknown)"
+	}
+	fmt.Fprintf(buf, "Version: %s\nSource:  %s\nDigest:  %s\n", version, s.Source, s.Digest)
+	fmt.Fprintf(buf, "Roots (%d):\n", len(s.Roots))
+	for _, cn := range s.Roots {
+		fmt.Fprintf(buf, "  - %s\n", cn)
+	}
+	fmt.Fprintf(buf, "Intermediates (%d):\n", len(s.Intermediates))
+	for _, cn := range s.Intermediates {
+		fmt.Fprintf(buf, "  - %s\n", cn)
+	}
+	return buf.String()
+}
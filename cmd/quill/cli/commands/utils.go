@@ -2,11 +2,9 @@ package commands
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"software.sslmate.com/src/go-pkcs12"
 
 	"github.com/anchore/quill/internal/bus"
 	"github.com/anchore/quill/internal/redact"
@@ -14,38 +12,47 @@ import (
 )
 
 func loadP12Interactively(p12Path, password string) (*load.P12Contents, error) {
-	p12Content, err := load.P12(p12Path, password)
-	if err == nil {
-		return p12Content, nil
-	}
+	return load.P12WithPassphrase(p12Path, passphraseProvider(password))
+}
 
-	if !errors.Is(err, load.ErrNeedPassword) {
-		return nil, err
+// passphraseProvider returns a provider for the configured password value (which may be a literal password or an
+// env:/file:/cmd: reference), falling back to an interactive prompt when no password was configured.
+func passphraseProvider(password string) load.PassphraseProvider {
+	if password != "" {
+		return redactedPassphrase{provider: load.NewPassphraseProvider(password)}
 	}
+	return load.PassphraseFunc(promptForPassphrase)
+}
 
-	by, err := load.BytesFromFileOrEnv(p12Path)
-	if err != nil {
-		return nil, fmt.Errorf("unable to read p12 bytes: %w", err)
+func promptForPassphrase(message string) (string, error) {
+	prompter := bus.PromptForInput(message, true)
+	if prompter == nil {
+		return "", load.ErrNeedPassword
 	}
 
-	prompter := bus.PromptForInput("Enter P12 password:", true)
 	newPassword, err := prompter.Response(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get password from prompt: %w", err)
+		return "", fmt.Errorf("unable to get password from prompt: %w", err)
 	}
 
 	redact.Add(newPassword)
 
-	key, cert, certs, err := pkcs12.DecodeChain(by, newPassword)
+	return newPassword, nil
+}
+
+// redactedPassphrase ensures that passwords resolved from indirect sources (env vars, files, commands) are never
+// shown in any output.
+type redactedPassphrase struct {
+	provider load.PassphraseProvider
+}
+
+func (r redactedPassphrase) Passphrase(prompt string) (string, error) {
+	value, err := r.provider.Passphrase(prompt)
 	if err != nil {
-		return nil, fmt.Errorf("unable to decode p12 file: %w", err)
+		return "", err
 	}
-
-	return &load.P12Contents{
-		PrivateKey:   key,
-		Certificate:  cert,
-		Certificates: certs,
-	}, nil
+	redact.Add(value)
+	return value, nil
 }
 
 func chainArgs(processors ...func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
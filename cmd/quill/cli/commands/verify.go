@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/cmd/quill/cli/options"
+	"github.com/anchore/quill/internal/bus"
+	"github.com/anchore/quill/quill/provisioning"
+	"github.com/anchore/quill/quill/verify"
+)
+
+type verifyConfig struct {
+	Paths              []string `yaml:"paths" json:"paths" mapstructure:"-"`
+	RejectAdHoc        bool     `yaml:"reject-adhoc" json:"reject-adhoc" mapstructure:"reject-adhoc"`
+	Detached           string   `yaml:"detached-signature" json:"detached-signature" mapstructure:"detached-signature"`
+	Identifier         string   `yaml:"identifier" json:"identifier" mapstructure:"identifier"`
+	IdentifierRegex    string   `yaml:"identifier-regex" json:"identifier-regex" mapstructure:"identifier-regex"`
+	TeamID             string   `yaml:"team-id" json:"team-id" mapstructure:"team-id"`
+	Profile            string   `yaml:"provisioning-profile" json:"provisioning-profile" mapstructure:"provisioning-profile"`
+	options.Format     `yaml:",inline" json:",inline" mapstructure:",squash"`
+	options.TrustStore `yaml:"trust-store" json:"trust-store" mapstructure:"trust-store"`
+}
+
+func (o *verifyConfig) AddFlags(flags fangs.FlagSet) {
+	flags.BoolVarP(
+		&o.RejectAdHoc,
+		"reject-adhoc", "",
+		"fail verification when any binary is ad-hoc signed (e.g. for release gates)",
+	)
+	flags.StringVarP(
+		&o.Detached,
+		"detached-signature", "",
+		"verify the binary against the given detached signature file instead of its embedded signature",
+	)
+	flags.StringVarP(
+		&o.Identifier,
+		"identifier", "",
+		"fail verification unless the signature identifier is exactly this value",
+	)
+	flags.StringVarP(
+		&o.IdentifierRegex,
+		"identifier-regex", "",
+		"fail verification unless the signature identifier matches this regular expression (not anchored, e.g. '^com\\.example\\.' requires identifiers to start with 'com.example.')",
+	)
+	flags.StringVarP(
+		&o.TeamID,
+		"team-id", "",
+		"fail verification unless both the code directory team ID and the team of the signing certificate (its OU) are this team ID (ad-hoc signatures fail)",
+	)
+	flags.StringVarP(
+		&o.Profile,
+		"provisioning-profile", "",
+		"fail verification unless the given provisioning profile authorizes the identifier, team, and entitlements of the signature (by default the profile embedded within the bundle of a main executable is used, if any)",
+	)
+}
+
+func Verify(app clio.Application) *cobra.Command {
+	opts := &verifyConfig{
+		Format: options.Format{
+			Output:           "text",
+			AllowableFormats: []string{"text", "json", "yaml"},
+		},
+	}
+
+	return app.SetupCommand(&cobra.Command{
+		Use:   "verify PATH...",
+		Short: "verify the signature of one or more binaries",
+		Long:  "verify the signature of every architecture of the given binaries (or of every binary within the given directories): the page hashes and special slots bound by every code directory, the CMS signature, the certificate chain, the secure timestamp, and the requirements (the text output renders a tree of every check along with the explanation of its outcome, followed by the number of signed, ad-hoc signed, and invalid binaries)",
+		Example: options.FormatPositionalArgsHelp(
+			map[string]string{
+				"PATH": "one or more signed darwin binaries or directories to verify",
+			},
+		),
+		Args: chainArgs(
+			cobra.MinimumNArgs(1),
+			func(_ *cobra.Command, args []string) error {
+				opts.Paths = args
+				return nil
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer bus.Exit()
+
+			if err := opts.TrustStore.Apply(cmd.Context()); err != nil {
+				return err
+			}
+
+			cfg := verify.Config{
+				RejectAdHoc:       opts.RejectAdHoc,
+				DetachedSignature: opts.Detached,
+				Identifier:        opts.Identifier,
+				TeamID:            opts.TeamID,
+			}
+			if opts.IdentifierRegex != "" {
+				pattern, err := regexp.Compile(opts.IdentifierRegex)
+				if err != nil {
+					return fmt.Errorf("invalid identifier regex: %w", err)
+				}
+				cfg.IdentifierPattern = pattern
+			}
+			if opts.Profile != "" {
+				profile, err := provisioning.Load(opts.Profile)
+				if err != nil {
+					return err
+				}
+				cfg.ProvisioningProfile = profile
+			}
+
+			summary, err := verify.VerifyAll(opts.Paths, cfg)
+			if err != nil {
+				return err
+			}
+
+			buf := &strings.Builder{}
+			if err := verify.ShowSummary(*summary, buf, opts.Output); err != nil {
+				return err
+			}
+
+			bus.Report(buf.String())
+
+			if summary.Failed() {
+				if opts.RejectAdHoc && summary.AdHoc > 0 {
+					return fmt.Errorf("signature verification failed: %s (ad-hoc signatures are rejected)", summary)
+				}
+				return fmt.Errorf("signature verification failed: %s", summary)
+			}
+
+			return nil
+		},
+	}, opts)
+}
@@ -0,0 +1,106 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/internal/redact"
+	"github.com/anchore/quill/quill/pki"
+)
+
+var _ interface {
+	fangs.FlagAdder
+	fangs.PostLoader
+	fangs.FieldDescriber
+} = (*CSR)(nil)
+
+type CSR struct {
+	// subject
+	CommonName string `yaml:"common-name" json:"common-name" mapstructure:"common-name"`
+	Email      string `yaml:"email" json:"email" mapstructure:"email"`
+	Country    string `yaml:"country" json:"country" mapstructure:"country"`
+
+	// key
+	KeyType    string `yaml:"key-type" json:"key-type" mapstructure:"key-type"`
+	PrivateKey string `yaml:"private-key" json:"private-key" mapstructure:"private-key"`
+	Password   string `yaml:"password" json:"password" mapstructure:"password"`
+
+	// outputs
+	KeyOutput string `yaml:"key-output" json:"key-output" mapstructure:"key-output"`
+	Output    string `yaml:"output" json:"output" mapstructure:"output"`
+}
+
+func DefaultCSR() CSR {
+	return CSR{
+		KeyType:   string(pki.RSAKeyType),
+		KeyOutput: "quill-signing-key.pem",
+		Output:    "quill.certSigningRequest",
+	}
+}
+
+func (o *CSR) AddFlags(flags fangs.FlagSet) {
+	flags.StringVarP(
+		&o.CommonName,
+		"common-name", "",
+		"the common name for the certificate (e.g. your name)",
+	)
+
+	flags.StringVarP(
+		&o.Email,
+		"email", "",
+		"the email address associated with your Apple developer account",
+	)
+
+	flags.StringVarP(
+		&o.Country,
+		"country", "",
+		"the two letter country code for the certificate subject",
+	)
+
+	flags.StringVarP(
+		&o.KeyType,
+		"key-type", "",
+		fmt.Sprintf("the type of keypair to generate (%q or %q)", pki.RSAKeyType, pki.ECDSAKeyType),
+	)
+
+	flags.StringVarP(
+		&o.PrivateKey,
+		"key", "k",
+		"use an existing PEM private key (file path, base64 contents, or 'env:ENV_VAR') instead of generating one",
+	)
+
+	flags.StringVarP(
+		&o.KeyOutput,
+		"key-output", "",
+		"the path to write the generated private key to",
+	)
+
+	flags.StringVarP(
+		&o.Output,
+		"output", "o",
+		"the path to write the certificate signing request to",
+	)
+}
+
+func (o *CSR) PostLoad() error {
+	redact.Add(o.Password)
+	redactNonFileOrEnvHint(o.PrivateKey)
+
+	if strings.HasPrefix(o.PrivateKey, "pkcs11:") {
+		return fmt.Errorf("private keys within PKCS#11 tokens are not supported, use a PEM private key")
+	}
+
+	if o.PrivateKey == "" {
+		switch pki.KeyType(o.KeyType) {
+		case pki.RSAKeyType, pki.ECDSAKeyType:
+		default:
+			return fmt.Errorf("unsupported key type %q (must be %q or %q)", o.KeyType, pki.RSAKeyType, pki.ECDSAKeyType)
+		}
+	}
+	return nil
+}
+
+func (o *CSR) DescribeFields(d fangs.FieldDescriptionSet) {
+	d.Add(&o.Password, "password to decrypt an existing private key when using --key (can also be 'env:ENV_VAR_NAME', 'file:PATH', or 'cmd:COMMAND' to read the password from another source)")
+}
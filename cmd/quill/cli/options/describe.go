@@ -7,7 +7,12 @@ import (
 var _ fangs.FlagAdder = (*Describe)(nil)
 
 type Describe struct {
-	Detail bool `yaml:"detail" json:"detail" mapstructure:"detail"`
+	Detail       bool   `yaml:"detail" json:"detail" mapstructure:"detail"`
+	Template     string `yaml:"template" json:"template" mapstructure:"template"`
+	Entitlements bool   `yaml:"entitlements" json:"entitlements" mapstructure:"entitlements"`
+	Blobs        bool   `yaml:"blobs" json:"blobs" mapstructure:"blobs"`
+	DumpBlob     string `yaml:"dump-blob" json:"dump-blob" mapstructure:"dump-blob"`
+	DumpBlobPath string `yaml:"dump-blob-output" json:"dump-blob-output" mapstructure:"dump-blob-output"`
 }
 
 func (o *Describe) AddFlags(flags fangs.FlagSet) {
@@ -16,4 +21,34 @@ func (o *Describe) AddFlags(flags fangs.FlagSet) {
 		"detail", "d",
 		"show additional detail of description",
 	)
+
+	flags.StringVarP(
+		&o.Template,
+		"template", "t",
+		"format the description of each binary with a Go template, referencing fields by their JSON names (e.g. '{{(index .superBlob.codeDirectories 0).teamID}}'), used instead of --output",
+	)
+
+	flags.BoolVarP(
+		&o.Entitlements,
+		"entitlements", "",
+		"show only the entitlements (as a formatted plist for text output) along with any differences between the XML and DER entitlements",
+	)
+
+	flags.BoolVarP(
+		&o.Blobs,
+		"blobs", "",
+		"show only the superblob layout: the slot, magic, offset (within the superblob and the file), length, and digest of every blob",
+	)
+
+	flags.StringVarP(
+		&o.DumpBlob,
+		"dump-blob", "",
+		"write the raw bytes of the blob in the given slot (by number or name, e.g. 'cms', 'requirements', or 'code directory') to the --dump-blob-output file",
+	)
+
+	flags.StringVarP(
+		&o.DumpBlobPath,
+		"dump-blob-output", "",
+		"the file to write the raw blob selected with --dump-blob to",
+	)
 }
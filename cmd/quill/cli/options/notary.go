@@ -2,11 +2,13 @@ package options
 
 import (
 	"github.com/anchore/fangs"
+	"github.com/anchore/quill/quill/notary"
 )
 
 var _ interface {
 	fangs.FlagAdder
 	fangs.PostLoader
+	fangs.FieldDescriber
 } = (*Notary)(nil)
 
 type Notary struct {
@@ -16,13 +18,21 @@ type Notary struct {
 	PrivateKey   string `yaml:"key" json:"key" mapstructure:"key"`
 
 	// unbound options
+	RateLimit      int `yaml:"rate-limit" json:"rate-limit" mapstructure:"rate-limit"`
+	RateLimitBurst int `yaml:"rate-limit-burst" json:"rate-limit-burst" mapstructure:"rate-limit-burst"`
 }
 
 func (o *Notary) PostLoad() error {
 	redactNonFileOrEnvHint(o.PrivateKey)
+	notary.SetRateLimit(notary.RateLimit{PerMinute: o.RateLimit, Burst: o.RateLimitBurst})
 	return nil
 }
 
+func (o *Notary) DescribeFields(d fangs.FieldDescriptionSet) {
+	d.Add(&o.RateLimit, "maximum number of notary API requests per minute, shared by every submission and status poll (requests beyond the limit wait for their turn, 0 for no limit)")
+	d.Add(&o.RateLimitBurst, "number of notary API requests that may be made at once before requests are spaced out by the rate limit")
+}
+
 func (o *Notary) AddFlags(flags fangs.FlagSet) {
 	flags.StringVarP(
 		&o.Issuer,
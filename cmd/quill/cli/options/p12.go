@@ -20,5 +20,5 @@ func (o *P12) PostLoad() error {
 }
 
 func (o *P12) DescribeFields(d fangs.FieldDescriptionSet) {
-	d.Add(&o.Password, "password to decrypt the p12 file")
+	d.Add(&o.Password, "password to decrypt the p12 file (can also be 'env:ENV_VAR_NAME', 'file:PATH', or 'cmd:COMMAND' to read the password from another source)")
 }
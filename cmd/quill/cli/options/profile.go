@@ -0,0 +1,94 @@
+package options
+
+import (
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/quill/profile"
+)
+
+var _ interface {
+	fangs.FlagAdder
+	fangs.FieldDescriber
+} = (*Profile)(nil)
+
+// Profile selects a named signing profile from the "profiles" section of the application config.
+type Profile struct {
+	// bound options
+	Name string `yaml:"profile" json:"profile" mapstructure:"profile"`
+
+	// unbound options
+	Profiles profile.Profiles `yaml:"profiles" json:"profiles" mapstructure:"profiles"`
+}
+
+func (o *Profile) AddFlags(flags fangs.FlagSet) {
+	flags.StringVarP(
+		&o.Name,
+		"profile", "",
+		"the name of the signing profile (from the 'profiles' section of the application config) providing the signing material, timestamp server, and notary credentials",
+	)
+}
+
+func (o *Profile) DescribeFields(d fangs.FieldDescriptionSet) {
+	d.Add(&o.Profiles, "named sets of 'sign' and 'notary' options selectable with --profile (values given as flags take precedence over the profile)")
+}
+
+// Apply overrides the signing and notary options with the values set by the selected profile (either may be nil).
+// Options given as a flag (as reported by the changed function) are left untouched, so that the precedence is: flags,
+// then the profile, then the remaining config sources (config file and environment variables).
+func (o *Profile) Apply(changed func(flag string) bool, signing *Signing, notary *Notary) error {
+	if o.Name == "" {
+		return nil
+	}
+
+	p, err := o.Profiles.Get(o.Name)
+	if err != nil {
+		return err
+	}
+
+	set := func(flag string, dst *string, value string) {
+		if value != "" && !changed(flag) {
+			*dst = value
+		}
+	}
+
+	if signing != nil {
+		s := p.Sign
+		material := s.P12 != "" || s.Certificate != "" || s.SigningDir != "" || s.AdHoc
+		if material && !changed("p12") && !changed("certificate") && !changed("signing-dir") && !changed("ad-hoc") {
+			// the profile replaces the signing material of other config sources entirely (mixing sources is an error)
+			signing.P12 = s.P12
+			signing.Certificate = s.Certificate
+			signing.PrivateKey = s.PrivateKey
+			signing.SigningDir = s.SigningDir
+			signing.AdHoc = s.AdHoc
+		}
+
+		set("identity", &signing.Identity, s.Identity)
+		set("signing-identity", &signing.SigningIdentity, s.SigningIdentity)
+		set("embed-chain", &signing.EmbedChain, s.EmbedChain)
+		set("expiry-warning", &signing.ExpiryWarning, s.ExpiryWarning)
+		set("require-valid-until", &signing.RequireValidUntil, s.RequireValidUntil)
+		if s.Password != "" {
+			signing.Password = s.Password
+		}
+		if s.TimestampServer != nil && !changed("timestamp-server") {
+			signing.TimestampServer = *s.TimestampServer
+		}
+
+		if err := signing.PostLoad(); err != nil {
+			return err
+		}
+	}
+
+	if notary != nil {
+		n := p.Notary
+		set("notary-issuer", &notary.Issuer, n.Issuer)
+		set("notary-key-id", &notary.PrivateKeyID, n.KeyID)
+		set("notary-key", &notary.PrivateKey, n.Key)
+
+		if err := notary.PostLoad(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,20 @@
 package options
 
 import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/anchore/fangs"
 	"github.com/anchore/quill/internal/redact"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/fulcio"
+	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/sign"
+	"github.com/anchore/quill/quill/timestamp"
 )
 
 var _ interface {
@@ -13,46 +25,389 @@ var _ interface {
 
 type Signing struct {
 	// bound options
-	Identity             string `yaml:"identity" json:"identity" mapstructure:"identity"`
-	P12                  string `yaml:"p12" json:"p12" mapstructure:"p12"`
-	TimestampServer      string `yaml:"timestamp-server" json:"timestamp-server" mapstructure:"timestamp-server"`
-	AdHoc                bool   `yaml:"ad-hoc" json:"ad-hoc" mapstructure:"ad-hoc"`
-	FailWithoutFullChain bool   `yaml:"fail-without-full-chain" json:"fail-without-full-chain" mapstructure:"fail-without-full-chain"`
+	Identity             string   `yaml:"identity" json:"identity" mapstructure:"identity"`
+	IdentifierPrefix     string   `yaml:"identifier-prefix" json:"identifier-prefix" mapstructure:"identifier-prefix"`
+	P12                  string   `yaml:"p12" json:"p12" mapstructure:"p12"`
+	Certificate          string   `yaml:"certificate" json:"certificate" mapstructure:"certificate"`
+	PrivateKey           string   `yaml:"private-key" json:"private-key" mapstructure:"private-key"`
+	SigningDir           string   `yaml:"signing-dir" json:"signing-dir" mapstructure:"signing-dir"`
+	SigningIdentity      string   `yaml:"signing-identity" json:"signing-identity" mapstructure:"signing-identity"`
+	TimestampServer      string   `yaml:"timestamp-server" json:"timestamp-server" mapstructure:"timestamp-server"`
+	TimestampTimeout     string   `yaml:"timestamp-timeout" json:"timestamp-timeout" mapstructure:"timestamp-timeout"`
+	TimestampRetries     int      `yaml:"timestamp-retries" json:"timestamp-retries" mapstructure:"timestamp-retries"`
+	TimestampDigest      string   `yaml:"timestamp-digest" json:"timestamp-digest" mapstructure:"timestamp-digest"`
+	TimestampNonce       bool     `yaml:"timestamp-nonce" json:"timestamp-nonce" mapstructure:"timestamp-nonce"`
+	TimestampClientCert  string   `yaml:"timestamp-client-cert" json:"timestamp-client-cert" mapstructure:"timestamp-client-cert"`
+	TimestampClientKey   string   `yaml:"timestamp-client-key" json:"timestamp-client-key" mapstructure:"timestamp-client-key"`
+	TimestampCABundle    string   `yaml:"timestamp-ca-bundle" json:"timestamp-ca-bundle" mapstructure:"timestamp-ca-bundle"`
+	EmbedChain           string   `yaml:"embed-chain" json:"embed-chain" mapstructure:"embed-chain"`
+	ExpiryWarning        string   `yaml:"expiry-warning" json:"expiry-warning" mapstructure:"expiry-warning"`
+	RequireValidUntil    string   `yaml:"require-valid-until" json:"require-valid-until" mapstructure:"require-valid-until"`
+	AdHoc                bool     `yaml:"ad-hoc" json:"ad-hoc" mapstructure:"ad-hoc"`
+	PreserveLinkerSig    bool     `yaml:"preserve-linker-signature" json:"preserve-linker-signature" mapstructure:"preserve-linker-signature"`
+	LibraryValidation    bool     `yaml:"library-validation" json:"library-validation" mapstructure:"library-validation"`
+	Keyless              bool     `yaml:"keyless" json:"keyless" mapstructure:"keyless"`
+	FulcioURL            string   `yaml:"fulcio-url" json:"fulcio-url" mapstructure:"fulcio-url"`
+	IdentityToken        string   `yaml:"identity-token" json:"identity-token" mapstructure:"identity-token"`
+	Offline              bool     `yaml:"offline" json:"offline" mapstructure:"offline"`
+	FailWithoutFullChain bool     `yaml:"fail-without-full-chain" json:"fail-without-full-chain" mapstructure:"fail-without-full-chain"`
+	Attestation          string   `yaml:"attestation" json:"attestation" mapstructure:"attestation"`
+	AttestationKey       string   `yaml:"attestation-key" json:"attestation-key" mapstructure:"attestation-key"`
+	Provenance           string   `yaml:"provenance" json:"provenance" mapstructure:"provenance"`
+	Manifest             string   `yaml:"manifest" json:"manifest" mapstructure:"manifest"`
+	ProvisioningProfiles []string `yaml:"provisioning-profiles" json:"provisioning-profiles" mapstructure:"provisioning-profiles"`
+	CodeDirectoryVersion string   `yaml:"code-directory-version" json:"code-directory-version" mapstructure:"code-directory-version"`
+	RuntimeVersion       string   `yaml:"runtime-version" json:"runtime-version" mapstructure:"runtime-version"`
+	SigningTime          string   `yaml:"signing-time" json:"signing-time" mapstructure:"signing-time"`
+	SignatureDigest      string   `yaml:"signature-digest" json:"signature-digest" mapstructure:"signature-digest"`
+	RSAPadding           string   `yaml:"rsa-padding" json:"rsa-padding" mapstructure:"rsa-padding"`
+	RemoveBlob           string   `yaml:"remove-blob" json:"remove-blob" mapstructure:"remove-blob"`
+	ReplaceBlob          string   `yaml:"replace-blob" json:"replace-blob" mapstructure:"replace-blob"`
+	Resign               bool     `yaml:"resign" json:"resign" mapstructure:"resign"`
+	ArchiveMembers       []string `yaml:"archive-members" json:"archive-members" mapstructure:"archive-members"`
+	AddRpaths            []string `yaml:"add-rpaths" json:"add-rpaths" mapstructure:"add-rpaths"`
+	DeleteRpaths         []string `yaml:"delete-rpaths" json:"delete-rpaths" mapstructure:"delete-rpaths"`
+	ChangeDylibs         []string `yaml:"change-dylibs" json:"change-dylibs" mapstructure:"change-dylibs"`
+	InstallName          string   `yaml:"install-name" json:"install-name" mapstructure:"install-name"`
+	NormalizeLayout      bool     `yaml:"normalize-layout" json:"normalize-layout" mapstructure:"normalize-layout"`
 
 	// unbound options
 	Password string `yaml:"password" json:"password" mapstructure:"password"`
 }
 
+const defaultTimestampServer = "http://timestamp.apple.com/ts01"
+
+// sourceDateEpochEnv is the reproducible builds convention for the time to record instead of the current time.
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
 func DefaultSigning() Signing {
 	return Signing{
-		TimestampServer:      "http://timestamp.apple.com/ts01",
+		TimestampServer:      defaultTimestampServer,
+		TimestampTimeout:     timestamp.DefaultTimeout.String(),
+		TimestampRetries:     timestamp.DefaultRetries,
+		TimestampDigest:      "sha256",
+		TimestampNonce:       true,
+		EmbedChain:           string(pki.EmbedIntermediates),
+		SignatureDigest:      "sha256",
+		RSAPadding:           string(pki.PaddingPKCS1v15),
+		ExpiryWarning:        "30d",
 		FailWithoutFullChain: true,
+		FulcioURL:            fulcio.DefaultURL,
 	}
 }
 
 func (o *Signing) PostLoad() error {
 	redact.Add(o.Password)
 	redactNonFileOrEnvHint(o.P12)
+	redactNonFileOrEnvHint(o.PrivateKey)
+	redactNonFileOrEnvHint(o.TimestampClientKey)
+	redactNonFileOrEnvHint(o.AttestationKey)
+	redactNonFileOrEnvHint(o.IdentityToken)
+
+	if sign.IsIdentityTemplate(o.Identity) {
+		if err := sign.ParseIdentityTemplate(o.Identity); err != nil {
+			return err
+		}
+	}
+	if _, err := pki.ParseChainEmbedding(o.EmbedChain); err != nil {
+		return err
+	}
+	if _, err := o.ExpiryPolicy(); err != nil {
+		return err
+	}
+	if _, err := o.TimestampConfig(); err != nil {
+		return err
+	}
+	if _, err := o.CodeDirectory(); err != nil {
+		return err
+	}
+	if _, err := o.Runtime(); err != nil {
+		return err
+	}
+	if _, err := o.SigningTimeSetting(); err != nil {
+		return err
+	}
+	if _, err := o.SignatureAlgorithm(); err != nil {
+		return err
+	}
+	if _, err := o.BlobEdit(); err != nil {
+		return err
+	}
+	if _, err := o.LoadCommandEdits(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// ExpiryPolicy returns the certificate expiry policy described by the options (as of now).
+func (o *Signing) ExpiryPolicy() (pki.ExpiryPolicy, error) {
+	return pki.ParseExpiryPolicy(o.ExpiryWarning, o.RequireValidUntil, time.Now())
+}
+
+// CodeDirectory returns the code directory version to write.
+func (o *Signing) CodeDirectory() (macho.CdVersion, error) {
+	return sign.ParseCodeDirectoryVersion(o.CodeDirectoryVersion)
+}
+
+// Runtime returns the hardened runtime version to record (zero when it should be detected from each binary).
+func (o *Signing) Runtime() (macho.Version, error) {
+	if o.RuntimeVersion == "" {
+		return 0, nil
+	}
+	return macho.ParseVersion(o.RuntimeVersion)
+}
+
+// SigningTimeSetting returns the CMS signing time setting, which is pinned to SOURCE_DATE_EPOCH (when set) unless a
+// signing time is given.
+func (o *Signing) SigningTimeSetting() (pki.SigningTime, error) {
+	value := o.SigningTime
+	if value == "" {
+		value = os.Getenv(sourceDateEpochEnv)
+	}
+	return pki.ParseSigningTime(value)
+}
+
+// SignatureAlgorithm returns the digest and RSA padding of the CMS signature.
+func (o *Signing) SignatureAlgorithm() (pki.SignatureAlgorithm, error) {
+	return pki.ParseSignatureAlgorithm(o.SignatureDigest, o.RSAPadding)
+}
+
+// BlobEdit returns the removal or replacement of a single blob of the existing signature (nil when the binary should be
+// signed from scratch).
+func (o *Signing) BlobEdit() (*sign.BlobEdit, error) {
+	switch {
+	case o.RemoveBlob != "" && o.ReplaceBlob != "":
+		return nil, fmt.Errorf("only one of --remove-blob or --replace-blob may be given")
+	case o.RemoveBlob != "":
+		kind, err := sign.ParseBlobKind(o.RemoveBlob)
+		if err != nil {
+			return nil, err
+		}
+		return &sign.BlobEdit{Kind: kind}, nil
+	case o.ReplaceBlob != "":
+		name, file, ok := strings.Cut(o.ReplaceBlob, "=")
+		if !ok || file == "" {
+			return nil, fmt.Errorf("invalid blob replacement %q (must be KIND=PATH)", o.ReplaceBlob)
+		}
+		kind, err := sign.ParseBlobKind(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read replacement %s: %w", kind, err)
+		}
+		if len(content) == 0 {
+			return nil, fmt.Errorf("replacement %s file %q is empty (use --remove-blob to remove the %s)", kind, file, kind)
+		}
+		return &sign.BlobEdit{Kind: kind, Content: content}, nil
+	}
+	return nil, nil
+}
+
+// LoadCommandEdits returns the load command changes to make to every binary before signing it (as install_name_tool
+// does), in the order install_name_tool applies them.
+func (o *Signing) LoadCommandEdits() ([]macho.LoadCommandEdit, error) {
+	var edits []macho.LoadCommandEdit
+	if o.InstallName != "" {
+		edits = append(edits, macho.SetInstallName(o.InstallName))
+	}
+	for _, change := range o.ChangeDylibs {
+		oldName, newName, ok := strings.Cut(change, "=")
+		if !ok || oldName == "" || newName == "" {
+			return nil, fmt.Errorf("invalid library change %q (must be OLD=NEW)", change)
+		}
+		edits = append(edits, macho.ChangeDylib(oldName, newName))
+	}
+	for _, path := range o.DeleteRpaths {
+		edits = append(edits, macho.RemoveRpath(path))
+	}
+	for _, path := range o.AddRpaths {
+		edits = append(edits, macho.AddRpath(path))
+	}
+	return edits, nil
+}
+
+// ResignMutations returns the changes to make to the existing signature when re-signing (see --resign).
+func (o *Signing) ResignMutations() []sign.Mutation {
+	var mutations []sign.Mutation
+	if o.Identity != "" && !sign.IsIdentityTemplate(o.Identity) {
+		// identity templates are expanded for every binary when signing (see quill.SigningConfig.WithIdentity)
+		mutations = append(mutations, sign.SetIdentifier(o.Identity))
+	}
+	return mutations
+}
+
+// TimestampConfig returns the timestamp settings described by the options.
+func (o *Signing) TimestampConfig() (timestamp.Config, error) {
+	if o.Offline {
+		// the default timestamp server is skipped, however, explicitly requested servers cannot be honored
+		if o.TimestampServer != "" && o.TimestampServer != defaultTimestampServer {
+			return timestamp.Config{}, fmt.Errorf("a timestamp server (%s) cannot be used in offline mode", o.TimestampServer)
+		}
+		return timestamp.Config{}, nil
+	}
+
+	cfg := timestamp.Config{
+		Servers:      timestamp.ParseServers(o.TimestampServer),
+		Retries:      o.TimestampRetries,
+		DisableNonce: !o.TimestampNonce,
+	}
+	if cfg.Retries == 0 {
+		// zero means no retries on the command line (the library treats zero as the default)
+		cfg.Retries = -1
+	}
+
+	hash, err := timestamp.ParseHash(o.TimestampDigest)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Hash = hash
+
+	if o.TimestampTimeout != "" {
+		timeout, err := time.ParseDuration(o.TimestampTimeout)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid timestamp timeout %q: %w", o.TimestampTimeout, err)
+		}
+		cfg.Timeout = timeout
+	}
+
+	var cas []*x509.Certificate
+	if o.TimestampCABundle != "" {
+		by, err := load.BytesFromFileOrEnv(o.TimestampCABundle)
+		if err != nil {
+			return cfg, fmt.Errorf("unable to read timestamp CA bundle: %w", err)
+		}
+		cas, err = load.CertificatesFromPEM(by)
+		if err != nil {
+			return cfg, fmt.Errorf("no certificates found in timestamp CA bundle: %w", err)
+		}
+
+		// a private timestamp authority typically issues the token signing certificate from the same CA
+		cfg.Roots = timestamp.DefaultRoots()
+		for _, ca := range cas {
+			cfg.Roots.AddCert(ca)
+		}
+	}
+
+	if len(cas) > 0 || o.TimestampClientCert != "" || o.TimestampClientKey != "" {
+		tlsCfg, err := timestamp.TLSConfig(o.TimestampClientCert, o.TimestampClientKey, cas)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.TLS = tlsCfg
+	}
+
+	return cfg, nil
+}
+
 func (o *Signing) AddFlags(flags fangs.FlagSet) {
 	flags.StringVarP(
 		&o.Identity,
 		"identity", "",
-		"identifier to encode into the code directory of the code signing super block (default is derived from the name of the binary being solved)",
+		"identifier to encode into the code directory of the code signing super block (default is the CFBundleIdentifier of the Info.plist of the bundle or binary, otherwise derived from the name of the binary being signed).\nThis may be a template expanded for every signed binary with the {{ .Basename }}, {{ .BundleID }}, and {{ .Arch }} placeholders, e.g. 'com.example.{{ .Basename }}'",
+	)
+
+	flags.StringVarP(
+		&o.IdentifierPrefix,
+		"identifier-prefix", "",
+		"prefix given to identifiers derived from bare file names, e.g. 'com.example.' signs 'mytool' as 'com.example.mytool' (similar to codesign --prefix, not applied to --identity, bundle identifiers, or identifiers that already hold a dot)",
 	)
 
 	flags.StringVarP(
 		&o.P12,
 		"p12", "",
-		"path to a PKCS12 file containing the private key, (leaf) signing certificate, remaining certificate chain.\nThis can also be the base64-encoded contents of the p12 file, or 'env:ENV_VAR_NAME' to read the p12 from a different environment variable",
+		"path to a PKCS12 file containing the private key, (leaf) signing certificate, remaining certificate chain.\nThis can also be the base64-encoded contents of the p12 file, 'env:ENV_VAR_NAME' to read the p12 from a different environment variable, 'k8s:NAMESPACE/NAME/KEY' to read it from a Kubernetes Secret, or '-' to read the p12 from stdin",
+	)
+
+	flags.StringVarP(
+		&o.Certificate,
+		"certificate", "",
+		"path to a PEM file containing the (leaf) signing certificate and remaining certificate chain (used with --private-key instead of --p12).\nThis can also be the base64-encoded or PEM contents, 'env:ENV_VAR_NAME' to read the certificates from a different environment variable, 'k8s:NAMESPACE/NAME/KEY' to read it from a Kubernetes Secret, or '-' to read them from stdin",
+	)
+
+	flags.StringVarP(
+		&o.PrivateKey,
+		"private-key", "",
+		"path to a PEM file containing the private key for the signing certificate (used with --certificate instead of --p12).\nThis can also be the base64-encoded or PEM contents, 'env:ENV_VAR_NAME' to read the key from a different environment variable, 'k8s:NAMESPACE/NAME/KEY' to read it from a Kubernetes Secret, or '-' to read the key from stdin",
+	)
+
+	flags.StringVarP(
+		&o.SigningDir,
+		"signing-dir", "",
+		"path to a directory of PEM, DER, and P12 files to resolve the signing identity and certificate chain from (used instead of --p12 or --certificate)",
+	)
+
+	flags.StringVarP(
+		&o.SigningIdentity,
+		"signing-identity", "s",
+		"select the signing identity to use when the signing material holds several (by SHA-1 or SHA-256 certificate fingerprint, or a substring of the certificate common name)",
 	)
 
 	flags.StringVarP(
 		&o.TimestampServer,
 		"timestamp-server", "",
-		"URL to a timestamp server to use for timestamping the signature",
+		"URL to a timestamp server to use for timestamping the signature (a comma separated list of URLs may be given, which are tried in order)",
+	)
+
+	flags.StringVarP(
+		&o.TimestampTimeout,
+		"timestamp-timeout", "",
+		"the time allowed for each timestamp request (e.g. '30s')",
+	)
+
+	flags.IntVarP(
+		&o.TimestampRetries,
+		"timestamp-retries", "",
+		"the number of times a timestamp request is retried (with exponential backoff) after a transient failure before trying the next timestamp server",
+	)
+
+	flags.StringVarP(
+		&o.TimestampDigest,
+		"timestamp-digest", "",
+		"the digest algorithm used for the timestamp request message imprint (sha256, sha384, or sha512)",
+	)
+
+	flags.BoolVarP(
+		&o.TimestampNonce,
+		"timestamp-nonce", "",
+		"include a random nonce in timestamp requests and require it within the returned token (use '--timestamp-nonce=false' for timestamp servers that drop nonces)",
+	)
+
+	flags.StringVarP(
+		&o.TimestampClientCert,
+		"timestamp-client-cert", "",
+		"path to a PEM file containing the client certificate (and optionally the key) to authenticate with a private timestamp server using mutual TLS",
+	)
+
+	flags.StringVarP(
+		&o.TimestampClientKey,
+		"timestamp-client-key", "",
+		"path to a PEM file containing the private key for --timestamp-client-cert",
+	)
+
+	flags.StringVarP(
+		&o.TimestampCABundle,
+		"timestamp-ca-bundle", "",
+		"path to a PEM file of additional CA certificates trusted for a private timestamp server (both its TLS certificate and its timestamp tokens)",
+	)
+
+	flags.StringVarP(
+		&o.EmbedChain,
+		"embed-chain", "",
+		fmt.Sprintf("which certificates of the chain to embed into the signature %s", pki.ChainEmbeddings),
+	)
+
+	flags.StringVarP(
+		&o.ExpiryWarning,
+		"expiry-warning", "",
+		"warn when a certificate of the signing chain expires within this duration (e.g. '30d' or '720h', empty to disable)",
+	)
+
+	flags.StringVarP(
+		&o.RequireValidUntil,
+		"require-valid-until", "",
+		"fail if a certificate of the signing chain expires before this date (YYYY-MM-DD or RFC 3339) or duration from now (e.g. '180d')",
 	)
 
 	flags.BoolVarP(
@@ -60,9 +415,159 @@ func (o *Signing) AddFlags(flags fangs.FlagSet) {
 		"ad-hoc", "",
 		"perform ad-hoc signing. No cryptographic signature is included and --p12 key and certificate input are not needed. Do NOT use this option for production builds.",
 	)
+
+	flags.BoolVarP(
+		&o.PreserveLinkerSig,
+		"preserve-linker-signature", "",
+		"when ad-hoc signing, keep the ad-hoc signature generated by the linker (e.g. for arm64 binaries) instead of replacing it (the signature is replaced anyway when entitlements or bundle details must be bound to it)",
+	)
+
+	flags.BoolVarP(
+		&o.LibraryValidation,
+		"library-validation", "",
+		"require every library loaded by the signed binaries to be signed by Apple or by the same team (the library validation code directory flag, which the com.apple.security.cs.disable-library-validation entitlement cannot relax)",
+	)
+
+	flags.StringVarP(
+		&o.Attestation,
+		"attestation", "",
+		"after signing, write an in-toto attestation of the signing step (the binary digest and cdhash, the identity, certificate fingerprints, and timestamp) as a DSSE envelope to this path",
+	)
+
+	flags.StringVarP(
+		&o.AttestationKey,
+		"attestation-key", "",
+		"path to a PEM file containing the private key to sign the attestation with (default is the private key of the signing identity).\nThis can also be the base64-encoded or PEM contents, or 'env:ENV_VAR_NAME' to read the key from a different environment variable",
+	)
+
+	flags.StringVarP(
+		&o.Provenance,
+		"provenance", "",
+		"after signing, write a SLSA provenance statement (in-toto JSON lines) listing every input and signed output with digests and the signing configuration used to this path",
+	)
+
+	flags.StringVarP(
+		&o.Manifest,
+		"manifest", "",
+		"after signing (and notarizing), write a JSON manifest listing every signed artifact with its sha256, cdhashes, identity, certificate fingerprint, timestamp, and notarization submission ID to this path",
+	)
+
+	flags.StringArrayVarP(
+		&o.ProvisioningProfiles,
+		"provisioning-profile", "",
+		"path to a provisioning profile (.mobileprovision) to embed when signing an app bundle or iOS app archive (.ipa), each bundle is signed with the entitlements of the profile matching its bundle identifier (may be given multiple times, e.g. for app extensions)",
+	)
+
+	flags.StringVarP(
+		&o.CodeDirectoryVersion,
+		"code-directory-version", "",
+		fmt.Sprintf("the code directory format version to write (one of %s, default 0x%x). Older versions are understood by older macOS verifiers but leave out newer fields, such as the hardened runtime version notarization requires", sign.FormatCodeDirectoryVersions(), uint32(sign.DefaultCodeDirectoryVersion)),
+	)
+
+	flags.StringVarP(
+		&o.RuntimeVersion,
+		"runtime-version", "",
+		"the hardened runtime version to record in the code directory (e.g. 13.0, default is the SDK version recorded in the LC_BUILD_VERSION load command of each binary)",
+	)
+
+	flags.StringVarP(
+		&o.SigningTime,
+		"signing-time", "",
+		"the signing time to record in the CMS signature: an RFC 3339 timestamp or seconds since the Unix epoch to pin it (e.g. for reproducible builds), or 'none' to omit it (default is the current time, or SOURCE_DATE_EPOCH when set). A pinned time must be within the validity of the signing certificate and not after the secure timestamp",
+	)
+
+	flags.StringVarP(
+		&o.SignatureDigest,
+		"signature-digest", "",
+		"the digest algorithm of the CMS signature (sha256, sha384, or sha512)",
+	)
+
+	flags.StringVarP(
+		&o.RSAPadding,
+		"rsa-padding", "",
+		fmt.Sprintf("the padding scheme of RSA signatures %s, Apple's tooling signs with pkcs1v15 (ECDSA keys are not affected)", pki.Paddings),
+	)
+
+	flags.StringVarP(
+		&o.RemoveBlob,
+		"remove-blob", "",
+		fmt.Sprintf("remove a single blob %s from the existing signature of the binary, carrying over its identifier and remaining blobs (an empty requirements set replaces removed requirements). A new signature is generated with the given signing material", sign.BlobKinds),
+	)
+
+	flags.StringVarP(
+		&o.ReplaceBlob,
+		"replace-blob", "",
+		"replace a single blob of the existing signature of the binary with the contents of a file, given as KIND=PATH (an entitlements plist, or a compiled requirements set as written by 'csreq -b'), carrying over its identifier and remaining blobs. A new signature is generated with the given signing material",
+	)
+
+	flags.BoolVarP(
+		&o.Resign,
+		"resign", "",
+		"sign binaries again carrying over their existing signature verbatim (identifier, team identifier, flags, entitlements, requirements, and bound bundle details), only changing what is explicitly requested (e.g. --identity or --library-validation)",
+	)
+
+	flags.StringArrayVarP(
+		&o.ArchiveMembers,
+		"archive-member", "",
+		"when signing a zip or tar.gz archive, only sign the binaries whose member name or base name matches this pattern, e.g. 'bin/*' (may be given multiple times, every binary within the archive is signed by default)",
+	)
+
+	flags.StringArrayVarP(
+		&o.AddRpaths,
+		"add-rpath", "",
+		"add a run path (LC_RPATH) to every binary before signing it, e.g. '@executable_path/../Frameworks' (may be given multiple times, as install_name_tool -add_rpath)",
+	)
+
+	flags.StringArrayVarP(
+		&o.DeleteRpaths,
+		"delete-rpath", "",
+		"remove a run path (LC_RPATH) from every binary before signing it (may be given multiple times, as install_name_tool -delete_rpath)",
+	)
+
+	flags.StringArrayVarP(
+		&o.ChangeDylibs,
+		"change-dylib", "",
+		"change the install name of a library the binaries depend on before signing them, given as OLD=NEW (may be given multiple times, as install_name_tool -change)",
+	)
+
+	flags.StringVarP(
+		&o.InstallName,
+		"install-name", "",
+		"set the install name (LC_ID_DYLIB) of the signed dynamic library (as install_name_tool -id)",
+	)
+
+	flags.BoolVarP(
+		&o.NormalizeLayout,
+		"normalize-layout", "",
+		"drop the content following the code signature or __LINKEDIT which no load command refers to (e.g. data appended to the binary) before signing, such binaries are rejected otherwise as signing would overwrite that content",
+	)
+
+	flags.BoolVarP(
+		&o.Keyless,
+		"keyless", "",
+		"sign with an ephemeral key and a short-lived certificate from a Sigstore Fulcio instance in exchange for an OIDC identity token (used instead of --p12 or --certificate).\nThe certificate is NOT trusted by Apple: the binary will not pass Gatekeeper and cannot be notarized, this is only useful when verifying against your own trust roots",
+	)
+
+	flags.StringVarP(
+		&o.FulcioURL,
+		"fulcio-url", "",
+		"the URL of the Fulcio instance to request the --keyless signing certificate from",
+	)
+
+	flags.StringVarP(
+		&o.IdentityToken,
+		"identity-token", "",
+		"the OIDC identity token for --keyless signing (the raw token, a path to a file holding the token, or 'env:ENV_VAR_NAME').\nBy default the token is read from SIGSTORE_ID_TOKEN or requested from GitHub Actions",
+	)
+
+	flags.BoolVarP(
+		&o.Offline,
+		"offline", "",
+		"guarantee that no network access is made: the signature is not timestamped (an explicitly given --timestamp-server is an error) and certificate chains are not completed via AIA downloads",
+	)
 }
 
 func (o *Signing) DescribeFields(d fangs.FieldDescriptionSet) {
 	d.Add(&o.FailWithoutFullChain, "fail without the full certificate chain present in the p12 file")
-	d.Add(&o.Password, "password for the p12 file")
+	d.Add(&o.Password, "password for the p12 file or encrypted private key (can also be 'env:ENV_VAR_NAME', 'file:PATH', or 'cmd:COMMAND' to read the password from another source)")
 }
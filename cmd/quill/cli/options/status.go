@@ -1,6 +1,7 @@
 package options
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/anchore/fangs"
@@ -14,7 +15,11 @@ var _ interface {
 
 type Status struct {
 	// bound options
-	Wait bool `yaml:"wait" json:"wait" mapstructure:"wait"`
+	Wait           bool   `yaml:"wait" json:"wait" mapstructure:"wait"`
+	SkipAccepted   bool   `yaml:"skip-accepted" json:"skip-accepted" mapstructure:"skip-accepted"`
+	CheckHistory   bool   `yaml:"check-history" json:"check-history" mapstructure:"check-history"`
+	MaxWaitSeconds int    `yaml:"max-wait-seconds" json:"max-wait-seconds" mapstructure:"max-wait-seconds"`
+	Deadline       string `yaml:"deadline" json:"deadline" mapstructure:"deadline"`
 
 	// unbound options
 	PollSeconds    int `yaml:"poll-seconds" json:"poll-seconds" mapstructure:"poll-seconds"`
@@ -30,15 +35,54 @@ func DefaultStatus() Status {
 }
 
 func (o *Status) PostLoad() error {
+	if o.MaxWaitSeconds < 0 {
+		return fmt.Errorf("invalid max wait %d seconds", o.MaxWaitSeconds)
+	}
+	if o.Deadline != "" {
+		if _, err := time.Parse(time.RFC3339, o.Deadline); err != nil {
+			return fmt.Errorf("invalid deadline %q (expected an RFC 3339 time, e.g. 2024-01-02T15:04:05Z): %w", o.Deadline, err)
+		}
+	}
 	return nil
 }
 
+// DeadlineTime returns the configured deadline, zero when there is none.
+func (o Status) DeadlineTime() time.Time {
+	// validated by PostLoad
+	t, _ := time.Parse(time.RFC3339, o.Deadline)
+	return t
+}
+
 func (o *Status) AddFlags(flags fangs.FlagSet) {
 	flags.BoolVarP(
 		&o.Wait,
 		"wait", "w",
 		"wait for a conclusive status before exiting (accepted, rejected, or invalid status)",
 	)
+
+	flags.BoolVarP(
+		&o.SkipAccepted,
+		"skip-accepted", "",
+		"skip notarizing artifacts with the same content as a prior accepted submission (remembered within the user cache directory)",
+	)
+
+	flags.BoolVarP(
+		&o.CheckHistory,
+		"check-history", "",
+		"look for a prior accepted submission with the same content within the notary submission history before submitting",
+	)
+
+	flags.IntVarP(
+		&o.MaxWaitSeconds,
+		"max-wait-seconds", "",
+		"stop waiting after this many seconds when the submission is still pending, reporting its ID to resume the wait later (0 for no limit)",
+	)
+
+	flags.StringVarP(
+		&o.Deadline,
+		"deadline", "",
+		"stop waiting at this time (RFC 3339) when the submission is still pending, reporting its ID to resume the wait later",
+	)
 }
 
 func (o *Status) DescribeFields(d fangs.FieldDescriptionSet) {
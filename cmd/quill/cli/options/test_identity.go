@@ -0,0 +1,42 @@
+package options
+
+import (
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+var _ fangs.FlagAdder = (*TestIdentity)(nil)
+
+type TestIdentity struct {
+	TeamID  string `yaml:"team-id" json:"team-id" mapstructure:"team-id"`
+	Name    string `yaml:"name" json:"name" mapstructure:"name"`
+	KeyType string `yaml:"key-type" json:"key-type" mapstructure:"key-type"`
+}
+
+func DefaultTestIdentity() TestIdentity {
+	return TestIdentity{
+		TeamID:  testca.DefaultTeamID,
+		Name:    testca.DefaultName,
+		KeyType: "rsa",
+	}
+}
+
+func (o *TestIdentity) AddFlags(flags fangs.FlagSet) {
+	flags.StringVarP(
+		&o.TeamID,
+		"team-id", "",
+		"the team ID to place within the signing certificate",
+	)
+
+	flags.StringVarP(
+		&o.Name,
+		"name", "",
+		"the developer name to place within the signing certificate",
+	)
+
+	flags.StringVarP(
+		&o.KeyType,
+		"key-type", "",
+		"the type of keys to generate (\"rsa\" or \"ecdsa\")",
+	)
+}
@@ -0,0 +1,79 @@
+package options
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anchore/fangs"
+	"github.com/anchore/quill/quill/pki/apple"
+)
+
+var _ fangs.FlagAdder = (*TrustStore)(nil)
+
+// TrustStore selects the snapshot of the Apple root and intermediate certificates used to complete and verify
+// certificate chains (the snapshot embedded into quill by default).
+type TrustStore struct {
+	Bundle    string `yaml:"bundle" json:"bundle" mapstructure:"bundle"`
+	URL       string `yaml:"url" json:"url" mapstructure:"url"`
+	Pin       string `yaml:"pin" json:"pin" mapstructure:"pin"`
+	Exclusive bool   `yaml:"exclusive" json:"exclusive" mapstructure:"exclusive"`
+}
+
+func (o *TrustStore) AddFlags(flags fangs.FlagSet) {
+	flags.StringVarP(
+		&o.Bundle,
+		"trust-store-bundle", "",
+		"path to a trust store bundle (PEM file of the Apple root and intermediate certificates) to use instead of the certificates embedded into quill",
+	)
+
+	flags.StringVarP(
+		&o.URL,
+		"trust-store-url", "",
+		"https URL to fetch a trust store bundle from, to use instead of the certificates embedded into quill (requires --trust-store-pin)",
+	)
+
+	flags.StringVarP(
+		&o.Pin,
+		"trust-store-pin", "",
+		"fail unless the trust store has the given digest (see 'quill trust-store show'), only trusting the trust store (implies --trust-store-exclusive)",
+	)
+
+	flags.BoolVarP(
+		&o.Exclusive,
+		"trust-store-exclusive", "",
+		"only trust the trust store: the system roots are not trusted and missing chain certificates are not downloaded",
+	)
+}
+
+// Apply makes the configured trust store current for the process (see apple.SetTrustStore), checking the pinned
+// digest and making it the only source of trust as configured. The embedded trust store is kept when no bundle or URL
+// is given. A fetched trust store must be pinned, since whoever serves it decides which signatures are trusted.
+func (o TrustStore) Apply(ctx context.Context) error {
+	var store *apple.TrustStore
+	var err error
+	switch {
+	case o.Bundle != "" && o.URL != "":
+		return fmt.Errorf("only one of a trust store bundle or URL may be given")
+	case o.URL != "" && o.Pin == "":
+		return fmt.Errorf("a trust store fetched from a URL must be pinned with --trust-store-pin (see 'quill trust-store show')")
+	case o.Bundle != "":
+		store, err = apple.LoadTrustStore(o.Bundle)
+	case o.URL != "":
+		store, err = apple.FetchTrustStore(ctx, o.URL)
+	default:
+		store = apple.EmbeddedTrustStore()
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.Pin != "" {
+		if err := store.CheckDigest(o.Pin); err != nil {
+			return err
+		}
+	}
+
+	apple.SetTrustStore(store)
+	apple.SetExclusiveTrust(o.Exclusive || o.Pin != "")
+	return nil
+}
@@ -0,0 +1,14 @@
+package options
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustStore_Apply_urlRequiresPin(t *testing.T) {
+	// the URL is never fetched without a pin
+	err := TrustStore{URL: "https://127.0.0.1:0/bundle.pem"}.Apply(context.Background())
+	require.ErrorContains(t, err, "must be pinned with --trust-store-pin")
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/anchore/quill/internal/redact"
+	"github.com/anchore/quill/quill/pki/load"
 )
 
 func FormatPositionalArgsHelp(args map[string]string) string {
@@ -31,8 +32,12 @@ func FormatPositionalArgsHelp(args map[string]string) string {
 }
 
 func redactNonFileOrEnvHint(value string) {
-	if strings.HasPrefix(value, "env:") {
-		// this is an env hint, the real value will be read downstream of config processing
+	if value == "" || value == "-" {
+		// nothing to redact, or the real value will be read from stdin downstream of config processing
+		return
+	}
+	if strings.HasPrefix(value, "env:") || strings.HasPrefix(value, load.KubernetesSecretPrefix) {
+		// this is an env (or secret) hint, the real value will be read downstream of config processing
 		return
 	}
 	if _, err := os.Stat(value); err == nil {
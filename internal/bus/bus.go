@@ -15,14 +15,34 @@ package bus
 
 import (
 	"github.com/wagoodman/go-partybus"
+
+	"github.com/anchore/quill/quill/event"
+	"github.com/anchore/quill/quill/lifecycle"
 )
 
-var publisher partybus.Publisher
+var (
+	publisher            partybus.Publisher
+	unsubscribeLifecycle func()
+)
 
 // Set sets the singleton event bus publisher. This is optional; if no bus is provided, the library will
-// behave no differently than if a bus had been provided.
+// behave no differently than if a bus had been provided. All lifecycle events are forwarded onto the bus.
 func Set(p partybus.Publisher) {
 	publisher = p
+
+	if unsubscribeLifecycle != nil {
+		unsubscribeLifecycle()
+		unsubscribeLifecycle = nil
+	}
+	if p != nil {
+		unsubscribeLifecycle = lifecycle.Subscribe(lifecycle.SubscriberFunc(func(e lifecycle.Event) {
+			publish(partybus.Event{
+				Type:   event.LifecycleType,
+				Source: e.Path,
+				Value:  e,
+			})
+		}))
+	}
 }
 
 func Get() partybus.Publisher {
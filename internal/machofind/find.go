@@ -0,0 +1,37 @@
+package machofind
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// Binaries returns every macho file within the given directory, sorted by path (symlinks are not followed, since the
+// target is found on its own within a bundle).
+func Binaries(root string) ([]string, error) {
+	var results []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		isMacho, err := macho.IsMachoFile(path)
+		if err != nil || !isMacho {
+			log.WithFields("path", path).Trace("skipping non-macho file")
+			return nil
+		}
+		results = append(results, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search for binaries within %q: %w", root, err)
+	}
+	sort.Strings(results)
+	return results, nil
+}
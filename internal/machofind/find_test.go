@@ -0,0 +1,27 @@
+package machofind
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho/machotest"
+)
+
+func TestBinaries(t *testing.T) {
+	dir := t.TempDir()
+	machotest.Write(t, filepath.Join(dir, "b", "tool"), machotest.Config{})
+	machotest.Write(t, filepath.Join(dir, "a", "helper"), machotest.Config{})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("readme"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "b", "tool"), filepath.Join(dir, "link")))
+
+	got, err := Binaries(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a", "helper"), filepath.Join(dir, "b", "tool")}, got)
+
+	_, err = Binaries(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package attest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PayloadType is the DSSE payload type of an in-toto statement.
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope (see https://github.com/secure-systems-lab/dsse) holding a signed statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a single signature over the envelope payload.
+type Signature struct {
+	// KeyID is the hex encoded sha256 digest of the DER (PKIX) encoded public key.
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Sign wraps the statement into a DSSE envelope signed with the given key (RSA, ECDSA, and Ed25519 keys are
+// supported).
+func Sign(statement Statement, signer crypto.Signer) (*Envelope, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("no key was provided to sign the attestation with")
+	}
+
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode attestation statement: %w", err)
+	}
+
+	keyID, err := KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	message, opts := messageToSign(signer.Public(), pae(PayloadType, payload))
+	sig, err := signer.Sign(rand.Reader, message, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign attestation: %w", err)
+	}
+
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{
+			{
+				KeyID: keyID,
+				Sig:   base64.StdEncoding.EncodeToString(sig),
+			},
+		},
+	}, nil
+}
+
+// Verify checks that the envelope is signed by the given public key and returns the enclosed statement.
+func Verify(envelope Envelope, key crypto.PublicKey) (*Statement, error) {
+	if envelope.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected attestation payload type: %q", envelope.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode attestation payload: %w", err)
+	}
+
+	keyID, err := KeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	message, opts := messageToSign(key, pae(envelope.PayloadType, payload))
+
+	var verified bool
+	for _, s := range envelope.Signatures {
+		if s.KeyID != "" && s.KeyID != keyID {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if verifySignature(key, message, opts, sig) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("no valid attestation signature found for key %s", keyID)
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("unable to decode attestation statement: %w", err)
+	}
+	return &statement, nil
+}
+
+// KeyID returns the identifier of the given public key as used within the envelope signatures.
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode attestation public key: %w", err)
+	}
+	digest := sha256.Sum256(der)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// pae is the DSSE pre-authentication encoding of the payload.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// messageToSign returns what is passed to crypto.Signer: the sha256 digest of the message, or the message itself for
+// Ed25519 keys (which sign the full message).
+func messageToSign(key crypto.PublicKey, message []byte) ([]byte, crypto.SignerOpts) {
+	if _, ok := key.(ed25519.PublicKey); ok {
+		return message, crypto.Hash(0)
+	}
+	digest := sha256.Sum256(message)
+	return digest[:], crypto.SHA256
+}
+
+func verifySignature(key crypto.PublicKey, message []byte, opts crypto.SignerOpts, sig []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, opts.HashFunc(), message, sig)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, message, sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, message, sig) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported attestation key type: %T", key)
+}
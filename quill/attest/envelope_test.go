@@ -0,0 +1,118 @@
+package attest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	statement := Statement{
+		Type: StatementType,
+		Subject: []Subject{
+			{Name: "bin", Digest: map[string]string{"sha256": "abc", "cdhash": "def"}},
+		},
+		PredicateType: PredicateType,
+		Predicate: SigningPredicate{
+			Identifier:   "com.example.bin",
+			Certificates: []CertificateInfo{{Subject: "CN=leaf", Issuer: "CN=ca", SHA256: "123"}},
+			Binaries:     []BinaryInfo{{Architecture: "Aarch64", CDHashes: map[string]string{"sha256": "def"}}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		signer    crypto.Signer
+		verifyKey crypto.PublicKey
+		tamper    bool
+		wantErr   require.ErrorAssertionFunc
+	}{
+		{
+			name:      "rsa",
+			signer:    rsaKey,
+			verifyKey: rsaKey.Public(),
+		},
+		{
+			name:      "ecdsa",
+			signer:    ecKey,
+			verifyKey: ecKey.Public(),
+		},
+		{
+			name:      "ed25519",
+			signer:    edKey,
+			verifyKey: edKey.Public(),
+		},
+		{
+			name:      "wrong key",
+			signer:    ecKey,
+			verifyKey: otherKey.Public(),
+			wantErr:   require.Error,
+		},
+		{
+			name:      "tampered payload",
+			signer:    ecKey,
+			verifyKey: ecKey.Public(),
+			tamper:    true,
+			wantErr:   require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			envelope, err := Sign(statement, tt.signer)
+			require.NoError(t, err)
+			assert.Equal(t, PayloadType, envelope.PayloadType)
+
+			if tt.tamper {
+				tampered := statement
+				tampered.Predicate.Identifier = "com.example.other"
+				other, err := Sign(tampered, otherKey)
+				require.NoError(t, err)
+				envelope.Payload = other.Payload
+			}
+
+			got, err := Verify(*envelope, tt.verifyKey)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, statement, *got)
+		})
+	}
+}
+
+func Test_pae(t *testing.T) {
+	// see https://github.com/secure-systems-lab/dsse/blob/master/protocol.md
+	got := pae("http://example.com/HelloWorld", []byte("hello world"))
+	assert.Equal(t, "DSSEv1 29 http://example.com/HelloWorld 11 hello world", string(got))
+}
+
+func TestSign_noKey(t *testing.T) {
+	_, err := Sign(Statement{}, nil)
+	require.Error(t, err)
+}
+
+func TestVerify_payloadType(t *testing.T) {
+	_, err := Verify(Envelope{PayloadType: "text/plain", Payload: base64.StdEncoding.EncodeToString([]byte("{}"))}, nil)
+	require.Error(t, err)
+}
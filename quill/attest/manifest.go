@@ -0,0 +1,101 @@
+package attest
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// ManifestSchema identifies the version of the manifest emitted.
+const ManifestSchema = "https://github.com/anchore/quill/manifest/v1"
+
+// Manifest enumerates the artifacts of a signing (and notarization) run, with everything needed to identify each
+// signed artifact (e.g. to attach to release notes).
+type Manifest struct {
+	Schema    string             `json:"schema"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Artifacts []ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact describes a signed artifact: its digest, the cdhashes of every architecture, the signing identity,
+// the signing certificate, the secure timestamp (if any), and the notarization submission (if notarized).
+type ManifestArtifact struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	// Binaries are the cdhashes of every architecture of the artifact.
+	Binaries   []BinaryInfo `json:"binaries"`
+	Identifier string       `json:"identifier"`
+	TeamID     string       `json:"teamID,omitempty"`
+	// SigningCertificate is the subject of the leaf certificate (empty for ad-hoc signatures).
+	SigningCertificate string                `json:"signingCertificate,omitempty"`
+	CertificateSHA256  string                `json:"certificateSHA256,omitempty"`
+	Timestamp          *time.Time            `json:"timestamp,omitempty"`
+	TimestampAuthority string                `json:"timestampAuthority,omitempty"`
+	Notarization       *ManifestNotarization `json:"notarization,omitempty"`
+}
+
+// ManifestNotarization is the notary submission of an artifact.
+type ManifestNotarization struct {
+	SubmissionID string `json:"submissionID"`
+	// Status is the final status of the submission, empty when the result was not waited for.
+	Status string `json:"status,omitempty"`
+}
+
+// NewManifest starts a manifest with no artifacts.
+func NewManifest() *Manifest {
+	return &Manifest{
+		Schema:    ManifestSchema,
+		CreatedAt: time.Now().UTC(),
+		Artifacts: []ManifestArtifact{},
+	}
+}
+
+// Add describes the (already signed) artifact at the given path within the manifest.
+func (m *Manifest) Add(path string) error {
+	statement, err := NewStatement(path)
+	if err != nil {
+		return fmt.Errorf("unable to describe %q: %w", path, err)
+	}
+
+	predicate := statement.Predicate
+	artifact := ManifestArtifact{
+		Name:               filepath.Base(path),
+		Path:               path,
+		SHA256:             statement.Subject[0].Digest["sha256"],
+		Binaries:           predicate.Binaries,
+		Identifier:         predicate.Identifier,
+		TeamID:             predicate.TeamID,
+		Timestamp:          predicate.Timestamp,
+		TimestampAuthority: predicate.TimestampAuthority,
+	}
+	if len(predicate.Certificates) > 0 {
+		// the leaf certificate is listed first
+		artifact.SigningCertificate = predicate.Certificates[0].Subject
+		artifact.CertificateSHA256 = predicate.Certificates[0].SHA256
+	}
+
+	m.Artifacts = append(m.Artifacts, artifact)
+	return nil
+}
+
+// SetNotarization records the notary submission of the artifacts with the given paths (every artifact of the
+// manifest when no path is given, e.g. when they were notarized with a single submission).
+func (m *Manifest) SetNotarization(n ManifestNotarization, paths ...string) {
+	for i := range m.Artifacts {
+		if len(paths) > 0 && !contains(paths, m.Artifacts[i].Path) {
+			continue
+		}
+		notarization := n
+		m.Artifacts[i].Notarization = &notarization
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
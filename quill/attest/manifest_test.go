@@ -0,0 +1,63 @@
+package attest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_Add(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "tool")
+	require.NoError(t, os.WriteFile(input, []byte("hello"), 0600))
+
+	m := NewManifest()
+	assert.Equal(t, ManifestSchema, m.Schema)
+
+	// the input is not a signed binary
+	require.Error(t, m.Add(input))
+	assert.Empty(t, m.Artifacts)
+
+	by, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.Contains(t, string(by), `"artifacts":[]`)
+}
+
+func TestManifest_SetNotarization(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  map[string]bool
+	}{
+		{
+			name: "every artifact",
+			want: map[string]bool{"a": true, "b": true},
+		},
+		{
+			name:  "some artifacts",
+			paths: []string{"b"},
+			want:  map[string]bool{"a": false, "b": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManifest()
+			m.Artifacts = []ManifestArtifact{{Path: "a"}, {Path: "b"}}
+
+			m.SetNotarization(ManifestNotarization{SubmissionID: "id", Status: "Accepted"}, tt.paths...)
+
+			for _, a := range m.Artifacts {
+				if !tt.want[a.Path] {
+					assert.Nil(t, a.Notarization, a.Path)
+					continue
+				}
+				require.NotNil(t, a.Notarization, a.Path)
+				assert.Equal(t, "id", a.Notarization.SubmissionID)
+				assert.Equal(t, "Accepted", a.Notarization.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package attest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// ProvenancePredicateType is the SLSA provenance version emitted.
+	ProvenancePredicateType = "https://slsa.dev/provenance/v1"
+	// BuildType identifies a quill signing run within the SLSA provenance (the external parameters are the
+	// SigningParameters).
+	BuildType = "https://github.com/anchore/quill/attestation/batch-signing/v1"
+	// BuilderID identifies quill as the builder of the signed artifacts.
+	BuilderID = "https://github.com/anchore/quill"
+)
+
+// ProvenanceStatement is an in-toto statement with a SLSA provenance predicate.
+type ProvenanceStatement struct {
+	Type          string     `json:"_type"`
+	Subject       []Subject  `json:"subject"`
+	PredicateType string     `json:"predicateType"`
+	Predicate     Provenance `json:"predicate"`
+}
+
+// Provenance is a SLSA v1 provenance predicate (see https://slsa.dev/spec/v1.0/provenance).
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType          string            `json:"buildType"`
+	ExternalParameters SigningParameters `json:"externalParameters"`
+	// ResolvedDependencies are the (unsigned) input artifacts.
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies"`
+}
+
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type BuildMetadata struct {
+	StartedOn  time.Time `json:"startedOn"`
+	FinishedOn time.Time `json:"finishedOn"`
+}
+
+// ResourceDescriptor identifies an artifact by name and digest.
+type ResourceDescriptor struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// SigningParameters is the signing configuration used for a batch.
+type SigningParameters struct {
+	// Identifier is the explicitly requested code directory identifier (empty when derived from each file name).
+	Identifier         string   `json:"identifier,omitempty"`
+	AdHoc              bool     `json:"adHoc"`
+	Keyless            bool     `json:"keyless,omitempty"`
+	SigningCertificate string   `json:"signingCertificate,omitempty"`
+	CertificateSHA256  string   `json:"certificateSHA256,omitempty"`
+	TimestampServers   []string `json:"timestampServers"`
+	ChainEmbedding     string   `json:"chainEmbedding,omitempty"`
+}
+
+// Batch records the inputs and outputs of signing several artifacts with the same configuration, producing a single
+// consolidated provenance document.
+type Batch struct {
+	params  SigningParameters
+	started time.Time
+	inputs  []ResourceDescriptor
+	outputs []Subject
+}
+
+// NewBatch starts recording a batch signed with the given configuration.
+func NewBatch(params SigningParameters) *Batch {
+	if params.TimestampServers == nil {
+		params.TimestampServers = []string{}
+	}
+	return &Batch{
+		params:  params,
+		started: time.Now().UTC(),
+	}
+}
+
+// AddInput records the (unsigned) artifact at the given path, this must be called before the artifact is signed.
+func (b *Batch) AddInput(path string) error {
+	digest, err := fileDigest(path)
+	if err != nil {
+		return err
+	}
+	b.inputs = append(b.inputs, ResourceDescriptor{
+		Name:   filepath.Base(path),
+		Digest: map[string]string{"sha256": digest},
+	})
+	return nil
+}
+
+// AddOutput records the signed artifact at the given path.
+func (b *Batch) AddOutput(path string) error {
+	subject, _, err := signedSubject(path)
+	if err != nil {
+		return fmt.Errorf("unable to describe %q: %w", path, err)
+	}
+	b.outputs = append(b.outputs, subject)
+	return nil
+}
+
+// Provenance returns the provenance statement for every recorded artifact.
+func (b *Batch) Provenance() ProvenanceStatement {
+	inputs := b.inputs
+	if inputs == nil {
+		inputs = []ResourceDescriptor{}
+	}
+	outputs := b.outputs
+	if outputs == nil {
+		outputs = []Subject{}
+	}
+
+	return ProvenanceStatement{
+		Type:          StatementType,
+		Subject:       outputs,
+		PredicateType: ProvenancePredicateType,
+		Predicate: Provenance{
+			BuildDefinition: BuildDefinition{
+				BuildType:            BuildType,
+				ExternalParameters:   b.params,
+				ResolvedDependencies: inputs,
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID:      BuilderID,
+					Version: builderVersion(),
+				},
+				Metadata: BuildMetadata{
+					StartedOn:  b.started,
+					FinishedOn: time.Now().UTC(),
+				},
+			},
+		},
+	}
+}
+
+func builderVersion() map[string]string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return nil
+	}
+	return map[string]string{"quill": info.Main.Version}
+}
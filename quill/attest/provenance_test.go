@@ -0,0 +1,43 @@
+package attest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(input, []byte("hello"), 0600))
+
+	params := SigningParameters{
+		AdHoc:              false,
+		SigningCertificate: "CN=Developer ID Application: Example (TEAM)",
+		TimestampServers:   []string{"http://timestamp.example.com"},
+	}
+
+	b := NewBatch(params)
+	require.NoError(t, b.AddInput(input))
+
+	// the input is not a signed binary
+	require.Error(t, b.AddOutput(input))
+
+	got := b.Provenance()
+	assert.Equal(t, StatementType, got.Type)
+	assert.Equal(t, ProvenancePredicateType, got.PredicateType)
+	assert.Equal(t, []Subject{}, got.Subject)
+	assert.Equal(t, BuildType, got.Predicate.BuildDefinition.BuildType)
+	assert.Equal(t, params, got.Predicate.BuildDefinition.ExternalParameters)
+	assert.Equal(t, []ResourceDescriptor{
+		{
+			Name:   "tool",
+			Digest: map[string]string{"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		},
+	}, got.Predicate.BuildDefinition.ResolvedDependencies)
+	assert.Equal(t, BuilderID, got.Predicate.RunDetails.Builder.ID)
+	assert.False(t, got.Predicate.RunDetails.Metadata.FinishedOn.Before(got.Predicate.RunDetails.Metadata.StartedOn))
+}
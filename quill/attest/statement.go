@@ -0,0 +1,171 @@
+package attest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/anchore/quill/quill/extract"
+)
+
+const (
+	// StatementType is the in-toto statement version emitted.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType identifies the signing predicate (the facts of a quill signing operation).
+	PredicateType = "https://github.com/anchore/quill/attestation/signing/v1"
+)
+
+// Statement is an in-toto statement about a signed binary (see https://github.com/in-toto/attestation).
+type Statement struct {
+	Type          string           `json:"_type"`
+	Subject       []Subject        `json:"subject"`
+	PredicateType string           `json:"predicateType"`
+	Predicate     SigningPredicate `json:"predicate"`
+}
+
+// Subject is the signed artifact. The digest always includes the sha256 of the file, and for single-arch binaries
+// the cdhash (the code directory hash, truncated to 20 bytes as shown by codesign).
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// SigningPredicate describes the signing step: the identity that was signed into the binary, the certificates of
+// the signature, and the secure timestamp (if any).
+type SigningPredicate struct {
+	Identifier         string            `json:"identifier"`
+	TeamID             string            `json:"teamID,omitempty"`
+	Certificates       []CertificateInfo `json:"certificates"`
+	Timestamp          *time.Time        `json:"timestamp,omitempty"`
+	TimestampAuthority string            `json:"timestampAuthority,omitempty"`
+	Binaries           []BinaryInfo      `json:"binaries"`
+}
+
+// CertificateInfo identifies a certificate of the signing chain (leaf first).
+type CertificateInfo struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+	SHA256  string `json:"sha256"`
+}
+
+// BinaryInfo are the code directory hashes of a single architecture of the signed file.
+type BinaryInfo struct {
+	Architecture string            `json:"architecture"`
+	CDHashes     map[string]string `json:"cdhashes"`
+}
+
+// NewStatement describes the (already signed) binary at the given path.
+func NewStatement(path string) (*Statement, error) {
+	subject, allDetails, err := signedSubject(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var predicate SigningPredicate
+	for i, d := range allDetails {
+		sb := d.SuperBlob
+		if sb == nil || len(sb.CodeDirectories) == 0 {
+			return nil, fmt.Errorf("binary is not signed (architecture %s)", d.File.CPU)
+		}
+
+		bin := BinaryInfo{
+			Architecture: d.File.CPU,
+			CDHashes:     map[string]string{},
+		}
+		for _, cd := range sb.CodeDirectories {
+			bin.CDHashes[cd.HashType] = cd.DeclaredDigest.Value
+		}
+		predicate.Binaries = append(predicate.Binaries, bin)
+
+		if i != 0 {
+			// the signing identity is the same for every architecture
+			continue
+		}
+
+		predicate.Identifier = sb.CodeDirectories[0].ID
+		predicate.TeamID = sb.CodeDirectories[0].TeamID
+
+		for _, sig := range sb.Signatures {
+			// the certificate order within the CMS signature is not defined, list the leaf (non-CA) certificate first
+			certs := append([]extract.Certificate(nil), sig.Certificates...)
+			sort.SliceStable(certs, func(i, j int) bool {
+				return !certs[i].Summary.IsCA && certs[j].Summary.IsCA
+			})
+			for _, c := range certs {
+				predicate.Certificates = append(predicate.Certificates, CertificateInfo{
+					Subject: c.Summary.Subject,
+					Issuer:  c.Summary.Issuer,
+					SHA256:  c.Summary.SHA256,
+				})
+			}
+			for _, s := range sig.Signers {
+				if s.Timestamp != nil {
+					ts := s.Timestamp.Time
+					predicate.Timestamp = &ts
+					predicate.TimestampAuthority = s.Timestamp.Authority
+				}
+			}
+		}
+	}
+
+	if predicate.Certificates == nil {
+		predicate.Certificates = []CertificateInfo{}
+	}
+
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []Subject{subject},
+		PredicateType: PredicateType,
+		Predicate:     predicate,
+	}, nil
+}
+
+// signedSubject describes the signed binary at the given path (see Subject).
+func signedSubject(path string) (Subject, []extract.Details, error) {
+	digest, err := fileDigest(path)
+	if err != nil {
+		return Subject{}, nil, err
+	}
+
+	allDetails, err := extract.ParseAllDetails(path)
+	if err != nil {
+		return Subject{}, nil, fmt.Errorf("unable to describe signed binary: %w", err)
+	}
+
+	subject := Subject{
+		Name:   filepath.Base(path),
+		Digest: map[string]string{"sha256": digest},
+	}
+
+	if len(allDetails) == 1 && allDetails[0].SuperBlob != nil && len(allDetails[0].SuperBlob.CodeDirectories) > 0 {
+		subject.Digest["cdhash"] = truncate(allDetails[0].SuperBlob.CodeDirectories[0].DeclaredDigest.Value, 40)
+	}
+
+	return subject, allDetails, nil
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open signed binary: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to hash signed binary: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+package quill
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/sign"
+)
+
+// DetachSignature writes the signature of the signed (single-arch or universal) binary at the given path to the given
+// signature file (the binary is left as is), so that the signature can be attached to an unsigned copy of the binary
+// later (see AttachSignature) or verified on its own.
+func DetachSignature(binPath, signaturePath string) error {
+	signature, err := sign.Detach(binPath)
+	if err != nil {
+		return fmt.Errorf("unable to detach the signature of %q: %w", binPath, err)
+	}
+
+	log.WithFields("binary", binPath, "signature", signaturePath, "bytes", len(signature)).Info("writing detached signature")
+	return os.WriteFile(signaturePath, signature, 0600)
+}
+
+// AttachSignature patches the signature within the given signature file (as written by DetachSignature) into the
+// unsigned copy of the binary at the given path. This allows signing elsewhere (e.g. on a host holding the signing
+// identity) and attaching the signature later without access to the signing identity.
+func AttachSignature(binPath, signaturePath string) error {
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("unable to read signature: %w", err)
+	}
+
+	log.WithFields("binary", binPath, "signature", signaturePath).Info("attaching signature")
+	if err := sign.Attach(binPath, signature); err != nil {
+		return fmt.Errorf("unable to attach the signature to %q: %w", binPath, err)
+	}
+	return nil
+}
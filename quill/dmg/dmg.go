@@ -0,0 +1,58 @@
+/*
+Package dmg reads the trailer ending UDIF disk images (.dmg files), which locates the code signature of signed images.
+The trailer is 512 bytes starting with "koly", the offset (at 296) and the size (at 304) of the code signature within
+the image are big endian.
+*/
+package dmg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// TrailerMagic starts the trailer ending every disk image.
+	TrailerMagic = "koly"
+	// TrailerSize is the size of the trailer ending every disk image.
+	TrailerSize = 512
+
+	codeSignatureOffset = 296
+	codeSignatureSize   = 304
+)
+
+// IsDiskImage indicates if the given reader (of the given size) ends with a disk image trailer.
+func IsDiskImage(r io.ReaderAt, size int64) bool {
+	if size < TrailerSize {
+		return false
+	}
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], size-TrailerSize); err != nil {
+		return false
+	}
+	return string(magic[:]) == TrailerMagic
+}
+
+// CodeSignature returns the offset and the size of the code signature of the disk image read from the given reader (of
+// the given size), the size is zero when the image is not signed.
+func CodeSignature(r io.ReaderAt, size int64) (offset, length uint64, err error) {
+	if !IsDiskImage(r, size) {
+		return 0, 0, fmt.Errorf("not a disk image")
+	}
+
+	var trailer [TrailerSize]byte
+	if _, err := r.ReadAt(trailer[:], size-TrailerSize); err != nil {
+		return 0, 0, err
+	}
+	offset = binary.BigEndian.Uint64(trailer[codeSignatureOffset:])
+	length = binary.BigEndian.Uint64(trailer[codeSignatureSize:])
+	if length == 0 {
+		return 0, 0, nil
+	}
+
+	end := uint64(size - TrailerSize)
+	if offset > end || length > end-offset {
+		return 0, 0, fmt.Errorf("the code signature of the disk image exceeds the image")
+	}
+	return offset, length, nil
+}
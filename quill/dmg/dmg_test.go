@@ -0,0 +1,74 @@
+package dmg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDiskImage returns an image of the given content followed by a trailer locating a code signature at the given
+// offset and of the given size.
+func newDiskImage(content []byte, offset, size uint64) []byte {
+	trailer := make([]byte, TrailerSize)
+	copy(trailer, TrailerMagic)
+	binary.BigEndian.PutUint64(trailer[codeSignatureOffset:], offset)
+	binary.BigEndian.PutUint64(trailer[codeSignatureSize:], size)
+	return append(append([]byte{}, content...), trailer...)
+}
+
+func TestIsDiskImage(t *testing.T) {
+	image := newDiskImage([]byte("image"), 0, 0)
+	assert.True(t, IsDiskImage(bytes.NewReader(image), int64(len(image))))
+
+	notImage := bytes.Repeat([]byte{'x'}, TrailerSize+10)
+	assert.False(t, IsDiskImage(bytes.NewReader(notImage), int64(len(notImage))))
+	assert.False(t, IsDiskImage(bytes.NewReader([]byte("koly")), 4))
+}
+
+func TestCodeSignature(t *testing.T) {
+	tests := []struct {
+		name       string
+		image      []byte
+		wantOffset uint64
+		wantSize   uint64
+		wantErr    require.ErrorAssertionFunc
+	}{
+		{
+			name:       "signed",
+			image:      newDiskImage([]byte("image-signature"), 6, 9),
+			wantOffset: 6,
+			wantSize:   9,
+		},
+		{
+			name:  "unsigned",
+			image: newDiskImage([]byte("image"), 0, 0),
+		},
+		{
+			name:    "signature exceeds the image",
+			image:   newDiskImage([]byte("image"), 2, 9),
+			wantErr: require.Error,
+		},
+		{
+			name:    "not a disk image",
+			image:   []byte("image"),
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			offset, size, err := CodeSignature(bytes.NewReader(tt.image), int64(len(tt.image)))
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantOffset, offset)
+			assert.Equal(t, tt.wantSize, size)
+		})
+	}
+}
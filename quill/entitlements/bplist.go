@@ -0,0 +1,266 @@
+package entitlements
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+// binary property lists (bplist00) are made of an object table, an offset table pointing to each object, and a
+// trailer describing both tables. Objects reference each other by their index within the offset table.
+const (
+	binaryPlistMagic       = "bplist00"
+	binaryPlistTrailerSize = 32
+)
+
+// plistEpoch is the reference date of binary plist dates (seconds since 2001-01-01).
+var plistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ParsePlist decodes an XML or binary property list with a dictionary at the root (e.g. an Info.plist, which Xcode
+// writes in the binary form within app bundles).
+func ParsePlist(b []byte) (Entitlements, error) {
+	if bytes.HasPrefix(b, []byte(binaryPlistMagic)) {
+		return ParseBinary(b)
+	}
+	return ParseXML(b)
+}
+
+// ParseBinary decodes a binary property list (bplist00) with a dictionary at the root.
+func ParseBinary(b []byte) (Entitlements, error) {
+	if !bytes.HasPrefix(b, []byte(binaryPlistMagic)) {
+		return nil, fmt.Errorf("not a binary plist")
+	}
+	if len(b) < len(binaryPlistMagic)+binaryPlistTrailerSize {
+		return nil, fmt.Errorf("binary plist is too short")
+	}
+
+	trailer := b[len(b)-binaryPlistTrailerSize:]
+	p := binaryPlist{
+		data:       b,
+		offsetSize: int(trailer[6]),
+		refSize:    int(trailer[7]),
+	}
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	topObject := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+
+	if p.offsetSize < 1 || p.offsetSize > 8 || p.refSize < 1 || p.refSize > 8 {
+		return nil, fmt.Errorf("invalid binary plist trailer")
+	}
+	end := uint64(len(b) - binaryPlistTrailerSize)
+	if numObjects == 0 || topObject >= numObjects || tableOffset >= end || numObjects > (end-tableOffset)/uint64(p.offsetSize) {
+		return nil, fmt.Errorf("invalid binary plist trailer")
+	}
+
+	p.offsets = make([]uint64, numObjects)
+	for i := range p.offsets {
+		start := tableOffset + uint64(i*p.offsetSize)
+		p.offsets[i] = readUint(b[start : start+uint64(p.offsetSize)])
+		if p.offsets[i] >= tableOffset {
+			return nil, fmt.Errorf("invalid binary plist object offset")
+		}
+	}
+
+	value, err := p.object(topObject, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse binary plist: %w", err)
+	}
+
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plist root is not a dictionary (found %T)", value)
+	}
+	return dict, nil
+}
+
+type binaryPlist struct {
+	data       []byte
+	offsets    []uint64
+	offsetSize int
+	refSize    int
+}
+
+//nolint:funlen,gocyclo
+func (p *binaryPlist) object(ref uint64, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("plist is nested too deeply")
+	}
+	if ref >= uint64(len(p.offsets)) {
+		return nil, fmt.Errorf("object reference %d out of range", ref)
+	}
+
+	off := p.offsets[ref]
+	marker := p.data[off]
+	kind, info := marker>>4, int(marker&0x0f)
+
+	switch kind {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		}
+		return nil, fmt.Errorf("unsupported binary plist marker 0x%02x", marker)
+	case 0x1:
+		by, err := p.bytes(off+1, uint64(1)<<info)
+		if err != nil {
+			return nil, err
+		}
+		if len(by) > 8 {
+			// 128-bit integers are only written for values beyond the int64 range
+			return nil, fmt.Errorf("unsupported %d byte integer", len(by))
+		}
+		// only 8 byte integers are signed, which the conversion takes care of
+		return int64(readUint(by)), nil
+	case 0x2:
+		by, err := p.bytes(off+1, uint64(1)<<info)
+		if err != nil {
+			return nil, err
+		}
+		return readReal(by)
+	case 0x3:
+		if marker != 0x33 {
+			return nil, fmt.Errorf("unsupported binary plist marker 0x%02x", marker)
+		}
+		by, err := p.bytes(off+1, 8)
+		if err != nil {
+			return nil, err
+		}
+		seconds := math.Float64frombits(binary.BigEndian.Uint64(by))
+		return plistEpoch.Add(time.Duration(seconds * float64(time.Second))), nil
+	case 0x4, 0x5, 0x6:
+		count, start, err := p.count(off, info)
+		if err != nil {
+			return nil, err
+		}
+		size := count
+		if kind == 0x6 {
+			size *= 2
+		}
+		by, err := p.bytes(start, size)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 0x4:
+			return append([]byte(nil), by...), nil
+		case 0x5:
+			return string(by), nil
+		}
+		units := make([]uint16, count)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(by[i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0xa:
+		count, start, err := p.count(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(start, count)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, len(refs))
+		for _, r := range refs {
+			v, err := p.object(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case 0xd:
+		count, start, err := p.count(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(start, count*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]interface{}, count)
+		for i := uint64(0); i < count; i++ {
+			k, err := p.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("dictionary key is not a string (found %T)", k)
+			}
+			v, err := p.object(refs[count+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = v
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported binary plist marker 0x%02x", marker)
+}
+
+// count returns the number of elements of a variable length object and where its contents start. Counts of 15 or
+// more are stored as an integer object following the marker.
+func (p *binaryPlist) count(off uint64, info int) (uint64, uint64, error) {
+	if info != 0x0f {
+		return uint64(info), off + 1, nil
+	}
+	marker, err := p.bytes(off+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marker[0]>>4 != 0x1 {
+		return 0, 0, fmt.Errorf("invalid binary plist object count")
+	}
+	size := uint64(1) << (marker[0] & 0x0f)
+	by, err := p.bytes(off+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return readUint(by), off + 2 + size, nil
+}
+
+func (p *binaryPlist) refs(start, count uint64) ([]uint64, error) {
+	if count > uint64(len(p.data)) {
+		return nil, fmt.Errorf("binary plist object exceeds the available data")
+	}
+	by, err := p.bytes(start, count*uint64(p.refSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, count)
+	for i := range refs {
+		refs[i] = readUint(by[i*p.refSize : (i+1)*p.refSize])
+	}
+	return refs, nil
+}
+
+func (p *binaryPlist) bytes(start, size uint64) ([]byte, error) {
+	if start > uint64(len(p.data)) || size > uint64(len(p.data))-start {
+		return nil, fmt.Errorf("binary plist object exceeds the available data")
+	}
+	return p.data[start : start+size], nil
+}
+
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func readReal(b []byte) (float64, error) {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+	return 0, fmt.Errorf("unsupported %d byte real", len(b))
+}
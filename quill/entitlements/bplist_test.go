@@ -0,0 +1,175 @@
+package entitlements
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bplistWriter assembles a binary plist object by object (with single byte object references).
+type bplistWriter struct {
+	objects bytes.Buffer
+	offsets []int
+}
+
+func (w *bplistWriter) add(marker byte, payload ...byte) byte {
+	w.offsets = append(w.offsets, len(binaryPlistMagic)+w.objects.Len())
+	w.objects.WriteByte(marker)
+	w.objects.Write(payload)
+	return byte(len(w.offsets) - 1)
+}
+
+func (w *bplistWriter) str(s string) byte {
+	if len(s) >= 15 {
+		return w.add(0x5f, append([]byte{0x10, byte(len(s))}, s...)...)
+	}
+	return w.add(0x50|byte(len(s)), []byte(s)...)
+}
+
+func (w *bplistWriter) dict(kv ...byte) byte {
+	n := len(kv) / 2
+	var refs []byte
+	for i := 0; i < n; i++ {
+		refs = append(refs, kv[i*2])
+	}
+	for i := 0; i < n; i++ {
+		refs = append(refs, kv[i*2+1])
+	}
+	return w.add(0xd0|byte(n), refs...)
+}
+
+func (w *bplistWriter) bytes(top byte) []byte {
+	var out bytes.Buffer
+	out.WriteString(binaryPlistMagic)
+	out.Write(w.objects.Bytes())
+	tableOffset := out.Len()
+	for _, off := range w.offsets {
+		out.WriteByte(byte(off))
+	}
+	trailer := make([]byte, binaryPlistTrailerSize)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(w.offsets)))
+	binary.BigEndian.PutUint64(trailer[16:], uint64(top))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	out.Write(trailer)
+	return out.Bytes()
+}
+
+func u64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func TestParseBinary(t *testing.T) {
+	w := &bplistWriter{}
+	long := strings.Repeat("x", 20)
+	top := w.dict(
+		w.str("bool"), w.add(0x09),
+		w.str("off"), w.add(0x08),
+		w.str("int"), w.add(0x13, u64(uint64(0xffffffffffffff9c))...), // -100
+		w.str("small"), w.add(0x11, 0x01, 0x00),
+		w.str("real"), w.add(0x23, u64(math.Float64bits(1.5))...),
+		w.str("date"), w.add(0x33, u64(math.Float64bits(86400))...),
+		w.str("data"), w.add(0x43, 'a', 'b', 'c'),
+		w.str("long"), w.str(long),
+		w.str("utf16"), w.add(0x62, 0x00, 0xe9, 0x00, 'a'),
+		w.str("array"), w.add(0xa2, w.str("one"), w.dict()),
+	)
+
+	got, err := ParseBinary(w.bytes(top))
+	require.NoError(t, err)
+	assert.Equal(t, Entitlements{
+		"bool":  true,
+		"off":   false,
+		"int":   int64(-100),
+		"small": int64(256),
+		"real":  1.5,
+		"date":  time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC),
+		"data":  []byte("abc"),
+		"long":  long,
+		"utf16": "éa",
+		"array": []interface{}{"one", map[string]interface{}{}},
+	}, got)
+}
+
+func TestParseBinary_invalid(t *testing.T) {
+	valid := func() *bplistWriter {
+		w := &bplistWriter{}
+		w.dict(w.str("key"), w.str("value"))
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		input func() []byte
+	}{
+		{
+			name:  "not a binary plist",
+			input: func() []byte { return []byte("<plist/>") },
+		},
+		{
+			name:  "truncated",
+			input: func() []byte { return []byte(binaryPlistMagic + "\x00") },
+		},
+		{
+			name: "root is not a dictionary",
+			input: func() []byte {
+				w := &bplistWriter{}
+				return w.bytes(w.str("value"))
+			},
+		},
+		{
+			name: "top object out of range",
+			input: func() []byte {
+				return valid().bytes(7)
+			},
+		},
+		{
+			name: "dangling reference",
+			input: func() []byte {
+				w := &bplistWriter{}
+				return w.bytes(w.add(0xd1, 0x05, 0x06))
+			},
+		},
+		{
+			name: "object exceeds data",
+			input: func() []byte {
+				w := &bplistWriter{}
+				return w.bytes(w.add(0x4f, 0x11, 0xff, 0xff))
+			},
+		},
+		{
+			name: "self referencing dictionary",
+			input: func() []byte {
+				w := &bplistWriter{}
+				key := w.str("key")
+				return w.bytes(w.add(0xd1, key, key+1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBinary(tt.input())
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParsePlist(t *testing.T) {
+	w := &bplistWriter{}
+	binaryPlist := w.bytes(w.dict(w.str("CFBundleIdentifier"), w.str("com.example.app")))
+	xmlPlist := Entitlements{"CFBundleIdentifier": "com.example.app"}.XML()
+
+	for _, input := range [][]byte{binaryPlist, []byte(xmlPlist)} {
+		got, err := ParsePlist(input)
+		require.NoError(t, err)
+		assert.Equal(t, Entitlements{"CFBundleIdentifier": "com.example.app"}, got)
+	}
+}
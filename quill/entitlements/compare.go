@@ -0,0 +1,129 @@
+package entitlements
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Discrepancy is a single difference between the XML and DER representations of the entitlements.
+type Discrepancy struct {
+	// Key is the path to the entitlement (e.g. "com.apple.security.application-groups[0]"), empty for the document.
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+func (d Discrepancy) String() string {
+	if d.Key == "" {
+		return d.Reason
+	}
+	return fmt.Sprintf("%s: %s", d.Key, d.Reason)
+}
+
+// Compare reports every difference between the XML and DER entitlements of a signature. The OS (and notarization)
+// reject signatures where both forms are present but do not describe exactly the same entitlements, so any
+// discrepancy found is a likely cause of launch or notarization failures.
+func Compare(xml, der Entitlements) []Discrepancy {
+	switch {
+	case xml == nil && der == nil:
+		return nil
+	case der == nil:
+		return []Discrepancy{{Reason: "XML entitlements are present without the DER entitlements (required on macOS 12 and later)"}}
+	case xml == nil:
+		return []Discrepancy{{Reason: "DER entitlements are present without the XML entitlements"}}
+	}
+
+	return Diff(xml, der, "XML", "DER")
+}
+
+// Diff reports every difference between two sets of entitlements, the given labels name each side within the
+// reasons (e.g. "XML" and "DER").
+func Diff(a, b Entitlements, aLabel, bLabel string) []Discrepancy {
+	c := comparison{aLabel: aLabel, bLabel: bLabel}
+	c.values("", map[string]interface{}(a), map[string]interface{}(b))
+	return c.results
+}
+
+type comparison struct {
+	aLabel, bLabel string
+	results        []Discrepancy
+}
+
+func (c *comparison) add(path, reason string, args ...interface{}) {
+	c.results = append(c.results, Discrepancy{Key: path, Reason: fmt.Sprintf(reason, args...)})
+}
+
+//nolint:gocyclo
+func (c *comparison) values(path string, a, b interface{}) {
+	if typeName(a) != typeName(b) {
+		c.add(path, "type differs (%s=%s, %s=%s)", c.aLabel, typeName(a), c.bLabel, typeName(b))
+		return
+	}
+
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y := b.(map[string]interface{})
+		for _, key := range sortedKeys(x) {
+			if _, ok := y[key]; !ok {
+				c.add(joinPath(path, key), "only present in the %s entitlements", c.aLabel)
+				continue
+			}
+			c.values(joinPath(path, key), x[key], y[key])
+		}
+		for _, key := range sortedKeys(y) {
+			if _, ok := x[key]; !ok {
+				c.add(joinPath(path, key), "only present in the %s entitlements", c.bLabel)
+			}
+		}
+	case []interface{}:
+		y := b.([]interface{})
+		if len(x) != len(y) {
+			c.add(path, "array length differs (%s=%d, %s=%d)", c.aLabel, len(x), c.bLabel, len(y))
+			return
+		}
+		for i := range x {
+			c.values(fmt.Sprintf("%s[%d]", path, i), x[i], y[i])
+		}
+	case []byte:
+		if !bytes.Equal(x, b.([]byte)) {
+			c.add(path, "value differs")
+		}
+	case time.Time:
+		if !x.Equal(b.(time.Time)) {
+			c.add(path, "value differs (%s=%s, %s=%s)", c.aLabel, x, c.bLabel, b)
+		}
+	default:
+		if a != b {
+			c.add(path, "value differs (%s=%v, %s=%v)", c.aLabel, a, c.bLabel, b)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "real"
+	case string:
+		return "string"
+	case []byte:
+		return "data"
+	case time.Time:
+		return "date"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dictionary"
+	}
+	return fmt.Sprintf("%T", v)
+}
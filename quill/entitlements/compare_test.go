@@ -0,0 +1,69 @@
+package entitlements
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  Entitlements
+		der  Entitlements
+		want []string
+	}{
+		{
+			name: "neither form",
+		},
+		{
+			name: "identical",
+			xml:  Entitlements{"a": true, "b": []interface{}{"x"}},
+			der:  Entitlements{"a": true, "b": []interface{}{"x"}},
+		},
+		{
+			name: "missing DER",
+			xml:  Entitlements{"a": true},
+			want: []string{"XML entitlements are present without the DER entitlements (required on macOS 12 and later)"},
+		},
+		{
+			name: "missing XML",
+			der:  Entitlements{"a": true},
+			want: []string{"DER entitlements are present without the XML entitlements"},
+		},
+		{
+			name: "differences",
+			xml: Entitlements{
+				"only-xml": true,
+				"value":    "a",
+				"type":     "1",
+				"list":     []interface{}{"a", "b"},
+				"nested":   map[string]interface{}{"inner": int64(1)},
+			},
+			der: Entitlements{
+				"only-der": true,
+				"value":    "b",
+				"type":     int64(1),
+				"list":     []interface{}{"a"},
+				"nested":   map[string]interface{}{"inner": int64(2)},
+			},
+			want: []string{
+				"list: array length differs (XML=2, DER=1)",
+				"nested.inner: value differs (XML=1, DER=2)",
+				"only-xml: only present in the XML entitlements",
+				"type: type differs (XML=string, DER=integer)",
+				"value: value differs (XML=a, DER=b)",
+				"only-der: only present in the DER entitlements",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range Compare(tt.xml, tt.der) {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
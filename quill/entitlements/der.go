@@ -0,0 +1,211 @@
+package entitlements
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+)
+
+// DER entitlements (the 0xfade7172 blob) are encoded as: [APPLICATION 16] { INTEGER version, dictionary }, where
+// a dictionary is [CONTEXT 16] { SEQUENCE { UTF8String key, value }... } and arrays are a SEQUENCE of values.
+const (
+	derVersion = 1
+	derTag     = 16
+)
+
+// ParseDER decodes DER encoded entitlements (the payload of the DER entitlements blob).
+func ParseDER(b []byte) (Entitlements, error) {
+	var root asn1.RawValue
+	rest, err := asn1.Unmarshal(b, &root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse DER entitlements: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected trailing data after DER entitlements (%d bytes)", len(rest))
+	}
+	if root.Class != asn1.ClassApplication || root.Tag != derTag || !root.IsCompound {
+		return nil, fmt.Errorf("unexpected DER entitlements root (class=%d tag=%d)", root.Class, root.Tag)
+	}
+
+	var version int
+	rest, err = asn1.Unmarshal(root.Bytes, &version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse DER entitlements version: %w", err)
+	}
+	if version != derVersion {
+		return nil, fmt.Errorf("unsupported DER entitlements version: %d", version)
+	}
+
+	var dictValue asn1.RawValue
+	rest, err = asn1.Unmarshal(rest, &dictValue)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse DER entitlements dictionary: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected trailing data after DER entitlements dictionary (%d bytes)", len(rest))
+	}
+
+	value, err := parseDERValue(dictValue, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse DER entitlements: %w", err)
+	}
+
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("DER entitlements root is not a dictionary (found %T)", value)
+	}
+	return dict, nil
+}
+
+func parseDERValue(raw asn1.RawValue, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("DER entitlements are nested too deeply")
+	}
+
+	switch {
+	case raw.Class == asn1.ClassContextSpecific && raw.Tag == derTag && raw.IsCompound:
+		return parseDERDict(raw.Bytes, depth)
+	case raw.Class != asn1.ClassUniversal:
+		return nil, fmt.Errorf("unsupported DER entitlements value (class=%d tag=%d)", raw.Class, raw.Tag)
+	}
+
+	switch raw.Tag {
+	case asn1.TagBoolean:
+		var v bool
+		if _, err := asn1.Unmarshal(raw.FullBytes, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case asn1.TagInteger:
+		var v *big.Int
+		if _, err := asn1.Unmarshal(raw.FullBytes, &v); err != nil {
+			return nil, err
+		}
+		if !v.IsInt64() {
+			return nil, fmt.Errorf("DER entitlements integer is out of range: %s", v)
+		}
+		return v.Int64(), nil
+	case asn1.TagUTF8String:
+		return string(raw.Bytes), nil
+	case asn1.TagSequence:
+		values := []interface{}{}
+		rest := raw.Bytes
+		for len(rest) > 0 {
+			var item asn1.RawValue
+			var err error
+			rest, err = asn1.Unmarshal(rest, &item)
+			if err != nil {
+				return nil, err
+			}
+			v, err := parseDERValue(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported DER entitlements value (tag=%d)", raw.Tag)
+}
+
+func parseDERDict(b []byte, depth int) (map[string]interface{}, error) {
+	dict := map[string]interface{}{}
+	rest := b
+	for len(rest) > 0 {
+		var entry asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &entry)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Class != asn1.ClassUniversal || entry.Tag != asn1.TagSequence {
+			return nil, fmt.Errorf("unexpected DER entitlements dictionary entry (class=%d tag=%d)", entry.Class, entry.Tag)
+		}
+
+		var key asn1.RawValue
+		valueBytes, err := asn1.Unmarshal(entry.Bytes, &key)
+		if err != nil {
+			return nil, err
+		}
+		if key.Class != asn1.ClassUniversal || key.Tag != asn1.TagUTF8String {
+			return nil, fmt.Errorf("DER entitlements dictionary key is not a string (tag=%d)", key.Tag)
+		}
+
+		var value asn1.RawValue
+		trailing, err := asn1.Unmarshal(valueBytes, &value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse value for key %q: %w", string(key.Bytes), err)
+		}
+		if len(trailing) > 0 {
+			return nil, fmt.Errorf("unexpected trailing data for key %q", string(key.Bytes))
+		}
+
+		v, err := parseDERValue(value, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse value for key %q: %w", string(key.Bytes), err)
+		}
+		dict[string(key.Bytes)] = v
+	}
+	return dict, nil
+}
+
+// DER encodes the entitlements in the DER form (the payload of the DER entitlements blob). Only booleans, integers,
+// strings, arrays, and dictionaries can be represented, any other value is an error.
+func (e Entitlements) DER() ([]byte, error) {
+	dict, err := marshalDERValue(map[string]interface{}(e), "")
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := asn1.Marshal(derVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassApplication,
+		Tag:        derTag,
+		IsCompound: true,
+		Bytes:      append(version, dict...),
+	})
+}
+
+func marshalDERValue(value interface{}, path string) ([]byte, error) {
+	switch v := value.(type) {
+	case bool:
+		return asn1.Marshal(v)
+	case int64:
+		return asn1.Marshal(v)
+	case string:
+		return asn1.MarshalWithParams(v, "utf8")
+	case []interface{}:
+		var content []byte
+		for i, item := range v {
+			b, err := marshalDERValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, b...)
+		}
+		return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: content})
+	case map[string]interface{}:
+		var content []byte
+		for _, key := range sortedKeys(v) {
+			keyBytes, err := asn1.MarshalWithParams(key, "utf8")
+			if err != nil {
+				return nil, err
+			}
+			valueBytes, err := marshalDERValue(v[key], joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			entry, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: append(keyBytes, valueBytes...)})
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, entry...)
+		}
+		return asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: derTag, IsCompound: true, Bytes: content})
+	}
+	return nil, fmt.Errorf("entitlement %q has a value that cannot be DER encoded (%s)", path, typeName(value))
+}
@@ -0,0 +1,113 @@
+package entitlements
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDER(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Entitlements
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "single boolean",
+			input: "702d020101b0283026" + "0c21" + hex.EncodeToString([]byte("com.apple.security.get-task-allow")) + "0101ff",
+			want:  Entitlements{"com.apple.security.get-task-allow": true},
+		},
+		{
+			name:  "empty dictionary",
+			input: "7005020101b000",
+			want:  Entitlements{},
+		},
+		{
+			name:    "wrong root tag",
+			input:   "3005020101b000",
+			wantErr: require.Error,
+		},
+		{
+			name:    "unsupported version",
+			input:   "7005020102b000",
+			wantErr: require.Error,
+		},
+		{
+			name:    "trailing data",
+			input:   "7005020101b00000",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			b, err := hex.DecodeString(tt.input)
+			require.NoError(t, err)
+
+			got, err := ParseDER(b)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEntitlements_DER(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   Entitlements
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "all representable types",
+			input: Entitlements{
+				"com.apple.security.get-task-allow":                true,
+				"com.apple.security.cs.disable-library-validation": false,
+				"com.apple.developer.team-identifier":              "ABCDE12345",
+				"com.apple.security.application-groups":            []interface{}{"group.a", "group.b"},
+				"nested": map[string]interface{}{
+					"count": int64(-42),
+					"empty": []interface{}{},
+				},
+			},
+		},
+		{
+			name:    "reals cannot be encoded",
+			input:   Entitlements{"value": 1.5},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			b, err := tt.input.DER()
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			got, err := ParseDER(b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.input, got)
+		})
+	}
+}
+
+func TestEntitlements_DER_sortsKeys(t *testing.T) {
+	b, err := Entitlements{"b": true, "a": true}.DER()
+	require.NoError(t, err)
+
+	encoded := hex.EncodeToString(b)
+	assert.Less(t, strings.Index(encoded, hex.EncodeToString([]byte("a"))+"0101ff"), strings.Index(encoded, hex.EncodeToString([]byte("b"))+"0101ff"))
+}
@@ -0,0 +1,280 @@
+package entitlements
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entitlements is a decoded entitlements dictionary. Values are one of: bool, int64, float64, string, []byte,
+// time.Time, []interface{}, or map[string]interface{}.
+type Entitlements map[string]interface{}
+
+// maxDepth guards against pathologically nested (malformed) documents.
+const maxDepth = 128
+
+// ParseXML decodes an XML property list with a dictionary at the root (the format of the embedded entitlements blob).
+func ParseXML(b []byte) (Entitlements, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	// the plist DTD is not needed (nor fetched)
+	d.Strict = false
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no plist found")
+			}
+			return nil, fmt.Errorf("unable to parse entitlements plist: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if start.Name.Local == "plist" {
+			continue
+		}
+
+		value, err := parseXMLValue(d, start, 0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse entitlements plist: %w", err)
+		}
+
+		dict, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("entitlements plist root is not a dictionary (found %T)", value)
+		}
+		return dict, nil
+	}
+}
+
+//nolint:funlen,gocyclo
+func parseXMLValue(d *xml.Decoder, start xml.StartElement, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("plist is nested too deeply")
+	}
+
+	switch start.Name.Local {
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	case "string":
+		return charData(d)
+	case "integer":
+		s, err := charData(d)
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(s), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q: %w", s, err)
+		}
+		return v, nil
+	case "real":
+		s, err := charData(d)
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid real %q: %w", s, err)
+		}
+		return v, nil
+	case "date":
+		s, err := charData(d)
+		if err != nil {
+			return nil, err
+		}
+		v, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", s, err)
+		}
+		return v, nil
+	case "data":
+		s, err := charData(d)
+		if err != nil {
+			return nil, err
+		}
+		v, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+		return v, nil
+	case "array":
+		values := []interface{}{}
+		for {
+			next, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if next == nil {
+				return values, nil
+			}
+			v, err := parseXMLValue(d, *next, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+	case "dict":
+		dict := map[string]interface{}{}
+		for {
+			next, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if next == nil {
+				return dict, nil
+			}
+			if next.Name.Local != "key" {
+				return nil, fmt.Errorf("expected dictionary key, found <%s>", next.Name.Local)
+			}
+			key, err := charData(d)
+			if err != nil {
+				return nil, err
+			}
+
+			valueStart, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if valueStart == nil {
+				return nil, fmt.Errorf("missing value for dictionary key %q", key)
+			}
+			v, err := parseXMLValue(d, *valueStart, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = v
+		}
+	}
+	return nil, fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+}
+
+// nextElement returns the next start element, or nil when the end of the enclosing element is reached.
+func nextElement(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// charData returns the text content of the current element (consuming the end element).
+func charData(d *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			return "", fmt.Errorf("unexpected element <%s> within a value", t.Name.Local)
+		case xml.EndElement:
+			return sb.String(), nil
+		}
+	}
+}
+
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+	xmlFooter = "</plist>\n"
+)
+
+// XML renders the entitlements as a formatted XML property list (with keys sorted, as Xcode emits them).
+func (e Entitlements) XML() string {
+	var sb strings.Builder
+	sb.WriteString(xmlHeader)
+	writeXMLValue(&sb, map[string]interface{}(e), 0)
+	sb.WriteString(xmlFooter)
+	return sb.String()
+}
+
+func writeXMLValue(sb *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("\t", depth)
+	switch v := value.(type) {
+	case bool:
+		if v {
+			sb.WriteString(indent + "<true/>\n")
+		} else {
+			sb.WriteString(indent + "<false/>\n")
+		}
+	case int64:
+		sb.WriteString(fmt.Sprintf("%s<integer>%d</integer>\n", indent, v))
+	case float64:
+		sb.WriteString(fmt.Sprintf("%s<real>%s</real>\n", indent, formatReal(v)))
+	case string:
+		sb.WriteString(indent + "<string>" + escapeXML(v) + "</string>\n")
+	case []byte:
+		sb.WriteString(indent + "<data>" + base64.StdEncoding.EncodeToString(v) + "</data>\n")
+	case time.Time:
+		sb.WriteString(indent + "<date>" + v.UTC().Format(time.RFC3339) + "</date>\n")
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString(indent + "<array/>\n")
+			return
+		}
+		sb.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			writeXMLValue(sb, item, depth+1)
+		}
+		sb.WriteString(indent + "</array>\n")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			sb.WriteString(indent + "<dict/>\n")
+			return
+		}
+		sb.WriteString(indent + "<dict>\n")
+		for _, key := range sortedKeys(v) {
+			sb.WriteString(indent + "\t<key>" + escapeXML(key) + "</key>\n")
+			writeXMLValue(sb, v[key], depth+1)
+		}
+		sb.WriteString(indent + "</dict>\n")
+	}
+}
+
+func formatReal(v float64) string {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package entitlements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Entitlements
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "all types",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>bool</key>
+	<true/>
+	<key>off</key>
+	<false/>
+	<key>int</key>
+	<integer>-7</integer>
+	<key>real</key>
+	<real>1.5</real>
+	<key>string</key>
+	<string>a &amp; b</string>
+	<key>data</key>
+	<data>
+	aGVsbG8=
+	</data>
+	<key>date</key>
+	<date>2020-01-02T03:04:05Z</date>
+	<key>array</key>
+	<array>
+		<string>one</string>
+		<array/>
+	</array>
+	<key>dict</key>
+	<dict/>
+</dict>
+</plist>`,
+			want: Entitlements{
+				"bool":   true,
+				"off":    false,
+				"int":    int64(-7),
+				"real":   1.5,
+				"string": "a & b",
+				"data":   []byte("hello"),
+				"date":   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				"array":  []interface{}{"one", []interface{}{}},
+				"dict":   map[string]interface{}{},
+			},
+		},
+		{
+			name:    "root is not a dictionary",
+			input:   `<plist version="1.0"><array/></plist>`,
+			wantErr: require.Error,
+		},
+		{
+			name:    "value without a key",
+			input:   `<plist version="1.0"><dict><true/></dict></plist>`,
+			wantErr: require.Error,
+		},
+		{
+			name:    "no plist",
+			input:   ``,
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseXML([]byte(tt.input))
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEntitlements_XML(t *testing.T) {
+	ents := Entitlements{
+		"com.apple.security.get-task-allow": true,
+		"com.apple.security.application-groups": []interface{}{
+			"group.<a>",
+		},
+		"empty": map[string]interface{}{},
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<string>group.&lt;a&gt;</string>
+	</array>
+	<key>com.apple.security.get-task-allow</key>
+	<true/>
+	<key>empty</key>
+	<dict/>
+</dict>
+</plist>
+`
+	assert.Equal(t, want, ents.XML())
+
+	// the rendered document must round trip
+	got, err := ParseXML([]byte(ents.XML()))
+	require.NoError(t, err)
+	assert.Equal(t, ents, got)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/wagoodman/go-progress"
 
 	"github.com/anchore/bubbly"
+	"github.com/anchore/quill/quill/lifecycle"
 )
 
 type ErrBadPayload struct {
@@ -102,3 +103,16 @@ func ParseCLINotificationType(e partybus.Event) (string, string, error) {
 
 	return context, notification, nil
 }
+
+func ParseLifecycleType(e partybus.Event) (*lifecycle.Event, error) {
+	if err := checkEventType(e.Type, LifecycleType); err != nil {
+		return nil, err
+	}
+
+	l, ok := e.Value.(lifecycle.Event)
+	if !ok {
+		return nil, newPayloadErr(e.Type, "Value", e.Value)
+	}
+
+	return &l, nil
+}
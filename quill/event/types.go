@@ -16,6 +16,9 @@ const (
 
 	TaskType partybus.EventType = typePrefix + "-task"
 
+	// LifecycleType is a partybus event carrying a lifecycle.Event (a single step of a signing or notarization)
+	LifecycleType partybus.EventType = typePrefix + "-lifecycle"
+
 	// CLIExitType is a partybus event indicating the main process is to exit
 	CLIExitType         partybus.EventType = cliTypePrefix + "-exit-event"
 	CLIReportType       partybus.EventType = cliTypePrefix + "-report"
@@ -2,6 +2,8 @@ package extract
 
 import (
 	"crypto"
+	"encoding/base64"
+	"encoding/hex"
 	"strings"
 )
 
@@ -15,6 +17,19 @@ type Digest struct {
 	Value     string `json:"value"`
 }
 
+func newBlobDetails(b []byte) BlobDetails {
+	hashObj := crypto.SHA256
+	hasher := hashObj.New()
+	hasher.Write(b)
+	return BlobDetails{
+		Base64: base64.StdEncoding.EncodeToString(b),
+		Digest: Digest{
+			Algorithm: algorithmName(hashObj),
+			Value:     hex.EncodeToString(hasher.Sum(nil)),
+		},
+	}
+}
+
 func algorithmName(h crypto.Hash) string {
 	return cleanAlgorithmName(h.String())
 }
@@ -0,0 +1,226 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// slotAliases are the additional (short) names accepted when selecting a blob by slot.
+var slotAliases = map[string]macho.SlotType{
+	"cd":        macho.CsSlotCodedirectory,
+	"info":      macho.CsSlotInfoslot,
+	"resources": macho.CsSlotResourcedir,
+	"der":       macho.CsSlotEntitlementsDer,
+	"cms":       macho.CsSlotCmsSignature,
+}
+
+// knownSlots are all slots that can be selected by name.
+var knownSlots = []macho.SlotType{
+	macho.CsSlotCodedirectory,
+	macho.CsSlotInfoslot,
+	macho.CsSlotRequirements,
+	macho.CsSlotResourcedir,
+	macho.CsSlotApplication,
+	macho.CsSlotEntitlements,
+	macho.CsSlotRepSpecific,
+	macho.CsSlotEntitlementsDer,
+	macho.CsSlotAlternateCodedirectories,
+	macho.CsSlotAlternateCodedirectories + 1,
+	macho.CsSlotAlternateCodedirectories + 2,
+	macho.CsSlotAlternateCodedirectories + 3,
+	macho.CsSlotAlternateCodedirectories + 4,
+	macho.CsSlotCmsSignature,
+	macho.CsSlotIdentificationslot,
+	macho.CsSlotTicketslot,
+}
+
+// ParseSlot resolves a slot selector, which is either the slot number (decimal or 0x prefixed hex) or the slot name
+// as shown by describe (case, whitespace, and punctuation insensitive, e.g. "code directory", "entitlements-der",
+// "alternate-code-directory-1"), or one of the short names: cd, info, resources, der, cms.
+func ParseSlot(value string) (macho.SlotType, error) {
+	if v, err := strconv.ParseUint(strings.TrimSpace(value), 0, 32); err == nil {
+		return macho.SlotType(v), nil
+	}
+
+	name := normalizeSlotName(value)
+	if slot, ok := slotAliases[name]; ok {
+		return slot, nil
+	}
+	for _, slot := range knownSlots {
+		if normalizeSlotName(slot.Name()) == name {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown blob slot: %q", value)
+}
+
+func normalizeSlotName(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, value)
+}
+
+func getBlobIndexDetails(m File, layout []macho.BlobLayout) []BlobIndexDetails {
+	var results []BlobIndexDetails
+	for _, b := range layout {
+		details := BlobIndexDetails{
+			Slot: DescribedValue{
+				Value:       uint32(b.Type),
+				Description: b.Type.Name(),
+			},
+			Offset:     b.Offset,
+			FileOffset: b.FileOffset,
+			Magic:      magicName(b.Magic),
+			Length:     b.Length,
+		}
+
+		by, err := m.internalFile.BlobBytes(b)
+		if err != nil {
+			log.Warnf("unable to read blob for slot=%d: %v", b.Type, err)
+		} else {
+			sum := sha256.Sum256(by)
+			details.Digest = &Digest{
+				Algorithm: "sha256",
+				Value:     hex.EncodeToString(sum[:]),
+			}
+		}
+
+		results = append(results, details)
+	}
+	return results
+}
+
+// BlobLayoutDetails is the superblob layout of a single binary.
+type BlobLayoutDetails struct {
+	// FileOffset is the offset of the superblob relative to the start of the (thin) binary.
+	FileOffset uint32             `json:"fileOffset"`
+	Size       uint32             `json:"size"`
+	Magic      string             `json:"magic"`
+	Length     uint32             `json:"length"`
+	Blobs      []BlobIndexDetails `json:"blobs"`
+}
+
+func getBlobLayoutDetails(m File) (*BlobLayoutDetails, error) {
+	cmd, _, err := m.internalFile.CodeSigningCmd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+
+	header, layout, err := m.internalFile.SuperBlobLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlobLayoutDetails{
+		FileOffset: cmd.DataOffset,
+		Size:       cmd.DataSize,
+		Magic:      magicName(header.Magic),
+		Length:     header.Length,
+		Blobs:      getBlobIndexDetails(m, layout),
+	}, nil
+}
+
+func (d BlobLayoutDetails) String() string {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"Slot", "Magic", "Offset", "File Offset", "Length", "Digest"})
+	for _, b := range d.Blobs {
+		var digest string
+		if b.Digest != nil {
+			digest = b.Digest.Algorithm + ":" + b.Digest.Value
+		}
+		t.AppendRow(table.Row{
+			fmt.Sprintf("0x%x (%s)", b.Slot.Value, b.Slot.Description),
+			b.Magic,
+			fmt.Sprintf("0x%x", b.Offset),
+			fmt.Sprintf("0x%x", b.FileOffset),
+			b.Length,
+			digest,
+		})
+	}
+
+	return fmt.Sprintf("Superblob: %s at file offset 0x%x (length %d of %d allocated bytes)\n%s\n", d.Magic, d.FileOffset, d.Length, d.Size, t.Render())
+}
+
+// ShowBlobs writes the layout of every blob within the superblob (slot, magic, offsets, length, and digest) of every
+// binary within the given file.
+func ShowBlobs(path string, writer io.Writer, format string) error {
+	mfs, err := NewFile(path)
+	if err != nil {
+		return err
+	}
+
+	var layouts []BlobLayoutDetails
+	for _, f := range mfs {
+		if !f.internalFile.HasCodeSigningCmd() {
+			layouts = append(layouts, BlobLayoutDetails{})
+			continue
+		}
+		layout, err := getBlobLayoutDetails(*f)
+		if err != nil {
+			return err
+		}
+		layouts = append(layouts, *layout)
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return encodeJSON(layouts, writer)
+	case "yaml":
+		return encodeYAML(layouts, writer)
+	case "text":
+		for i, l := range layouts {
+			prefix := ""
+			if i != 0 {
+				prefix = "\n"
+			}
+			body := "No superblob found (this binary is not signed)\n"
+			if l.Magic != "" {
+				body = l.String()
+			}
+			if _, err := fmt.Fprintf(writer, "%sBinary %d of %d:\n\n%s", prefix, i+1, len(layouts), body); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// DumpBlob writes the raw bytes (including the blob header) of the blob in the given slot of a thin binary.
+func DumpBlob(path string, slot macho.SlotType, writer io.Writer) error {
+	mfs, err := NewFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(mfs) != 1 {
+		return fmt.Errorf("dumping a blob is only supported for single-arch binaries (found %d architectures)", len(mfs))
+	}
+
+	b, err := mfs[0].internalFile.SlotBytes(slot)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("no blob found for slot 0x%x (%s)", uint32(slot), slot.Name())
+	}
+
+	_, err = writer.Write(b)
+	return err
+}
@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+func TestParseSlot(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    macho.SlotType
+		wantErr require.ErrorAssertionFunc
+	}{
+		{value: "0", want: macho.CsSlotCodedirectory},
+		{value: "0x10000", want: macho.CsSlotCmsSignature},
+		{value: "cms", want: macho.CsSlotCmsSignature},
+		{value: "CMS signature", want: macho.CsSlotCmsSignature},
+		{value: "code-directory", want: macho.CsSlotCodedirectory},
+		{value: "requirements", want: macho.CsSlotRequirements},
+		{value: "entitlements", want: macho.CsSlotEntitlements},
+		{value: "entitlements-der", want: macho.CsSlotEntitlementsDer},
+		{value: "alternate code directory 1", want: macho.CsSlotAlternateCodedirectories + 1},
+		{value: "bogus", wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseSlot(tt.value)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
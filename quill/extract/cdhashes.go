@@ -0,0 +1,119 @@
+package extract
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// CDHashesDetails describes the signed attributes binding the hashes of all code directories to the signature.
+type CDHashesDetails struct {
+	// Plist are the (truncated) cdhashes listed by the cdhashes plist attribute (in code directory order).
+	Plist []string `json:"plist,omitempty"`
+	// Hashes are the full cdhashes listed by the cdhashes attribute (with their digest algorithm).
+	Hashes []Digest `json:"hashes,omitempty"`
+}
+
+func buildCDHashes(si protocol.SignerInfo) *CDHashesDetails {
+	var details CDHashesDetails
+
+	if si.SignedAttrs.HasAttribute(macho.OIDCDHashesPlist) {
+		rv, err := si.SignedAttrs.GetOnlyAttributeValueBytes(macho.OIDCDHashesPlist)
+		if err != nil {
+			log.Debugf("unable to get cdhashes plist attribute: %v", err)
+		} else if details.Plist, err = parseCDHashesPlist(rv); err != nil {
+			log.Debugf("unable to parse cdhashes plist attribute: %v", err)
+		}
+	}
+
+	if si.SignedAttrs.HasAttribute(macho.OIDCDHashes) {
+		hashes, err := parseCDHashes(si.SignedAttrs)
+		if err != nil {
+			log.Debugf("unable to parse cdhashes attribute: %v", err)
+		}
+		details.Hashes = hashes
+	}
+
+	if details.Plist == nil && details.Hashes == nil {
+		return nil
+	}
+	return &details
+}
+
+func parseCDHashesPlist(rv asn1.RawValue) ([]string, error) {
+	var raw []byte
+	if _, err := asn1.Unmarshal(rv.FullBytes, &raw); err != nil {
+		return nil, err
+	}
+
+	doc, err := entitlements.ParseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := doc["cdhashes"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no cdhashes array")
+	}
+
+	var hashes []string
+	for _, v := range values {
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected cdhash value type: %T", v)
+		}
+		hashes = append(hashes, hex.EncodeToString(b))
+	}
+	return hashes, nil
+}
+
+func parseCDHashes(attrs protocol.Attributes) ([]Digest, error) {
+	var hashes []Digest
+	for _, attr := range attrs {
+		if !attr.Type.Equal(macho.OIDCDHashes) {
+			continue
+		}
+
+		// the attribute value is a set of (algorithm, digest) sequences
+		rest := attr.RawValue.Bytes
+		for len(rest) > 0 {
+			var h macho.CDHash
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &h); err != nil {
+				return hashes, err
+			}
+
+			algorithm := h.Algorithm.String()
+			if hash, ok := oid.DigestAlgorithmToCryptoHash[h.Algorithm.String()]; ok {
+				algorithm = algorithmName(hash)
+			}
+			hashes = append(hashes, Digest{Algorithm: algorithm, Value: hex.EncodeToString(h.Digest)})
+		}
+	}
+	return hashes, nil
+}
+
+func (c CDHashesDetails) String() string {
+	var sb strings.Builder
+	if len(c.Plist) > 0 {
+		sb.WriteString("Plist:\n")
+		for _, h := range c.Plist {
+			sb.WriteString("  " + h + "\n")
+		}
+	}
+	if len(c.Hashes) > 0 {
+		sb.WriteString("Hashes:\n")
+		for _, h := range c.Hashes {
+			sb.WriteString(fmt.Sprintf("  %s:%s\n", h.Algorithm, h.Value))
+		}
+	}
+	return sb.String()
+}
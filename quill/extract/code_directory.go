@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/anchore/quill/quill/macho"
@@ -20,6 +21,20 @@ type CodeDirectoryDetails struct {
 	ID             string          `json:"id"`
 	Platform       uint8           `json:"platform"`
 	Flags          DescribedValue  `json:"flags"`
+	HashType       string          `json:"hashType"`
+	HashSize       uint8           `json:"hashSize"`
+	PageSize       uint32          `json:"pageSize"`
+	CodeLimit      uint64          `json:"codeLimit"`
+	ExecSegment    ExecSegment     `json:"execSegment"`
+	RuntimeVersion string          `json:"runtimeVersion,omitempty"`
+	// LinkerSigned indicates the ad-hoc signature was generated by the linker rather than a code signing tool.
+	LinkerSigned bool `json:"linkerSigned,omitempty"`
+}
+
+type ExecSegment struct {
+	Base  uint64         `json:"base"`
+	Limit uint64         `json:"limit"`
+	Flags DescribedValue `json:"flags"`
 }
 
 type DescribedValue struct {
@@ -27,6 +42,21 @@ type DescribedValue struct {
 	Description string      `json:"description"`
 }
 
+// Uint returns the value as an unsigned integer (for values of any integer type), false is returned for any other
+// (or a negative) value.
+func (v DescribedValue) Uint() (uint64, bool) {
+	rv := reflect.ValueOf(v.Value)
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() >= 0 {
+			return uint64(rv.Int()), true
+		}
+	}
+	return 0, false
+}
+
 type SectionDigest struct {
 	Index  int64  `json:"index"`
 	Offset uint64 `json:"offset"`
@@ -102,12 +132,34 @@ func getCodeDirectories(m File) (cdObjs []CodeDirectoryDetails) {
 					Value:       cd.Header.Flags,
 					Description: cd.Header.Flags.String(),
 				},
+				HashType:  cleanAlgorithmName(cd.Header.HashType.String()),
+				HashSize:  cd.Header.HashSize,
+				PageSize:  pageSize(cd.Header.PageSize),
+				CodeLimit: cd.CodeLimit,
+				ExecSegment: ExecSegment{
+					Base:  cd.Header.ExecSegBase,
+					Limit: cd.Header.ExecSegLimit,
+					Flags: DescribedValue{
+						Value:       uint64(cd.Header.ExecSegFlags),
+						Description: cd.Header.ExecSegFlags.String(),
+					},
+				},
+				RuntimeVersion: cd.RuntimeVersion,
+				LinkerSigned:   uint32(cd.Header.Flags)&uint32(macho.LinkerSigned) != 0,
 			},
 		)
 	}
 	return cdObjs
 }
 
+// pageSize converts the log2 page size of the code directory to bytes (zero meaning a single infinitely sized page).
+func pageSize(bits uint8) uint32 {
+	if bits == 0 {
+		return 0
+	}
+	return 1 << bits
+}
+
 func (c CodeDirectoryDetails) String(hideVerboseData bool) string {
 	var specialDigests []string
 	for _, d := range c.SpecialDigests {
@@ -135,7 +187,7 @@ func (c CodeDirectoryDetails) String(hideVerboseData bool) string {
 
 	return tprintf(
 		`Version:  {{.Version.Description}}
-Flags:    {{.Flags.Description}}
+Flags:    {{.Flags.Description}}{{if .LinkerSigned}} (ad-hoc signature generated by the linker){{end}}
 ID:       {{.ID}}
 TeamID:   {{.TeamID}}
 Digest:   {{.DeclaredDigest.Algorithm}}:{{.DeclaredDigest.Value}}
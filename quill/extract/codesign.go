@@ -0,0 +1,330 @@
+package extract
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// ErrCodesignUnavailable indicates that Apple's codesign tool is not available on this host (it is only shipped with
+// macOS).
+var ErrCodesignUnavailable = errors.New("codesign is not available on this host (it is only available on macOS)")
+
+// CodesignDescription is the subset of the "codesign -d --verbose=4 -r-" output that is compared against quill's own
+// parsing of a signature.
+type CodesignDescription struct {
+	Identifier           string
+	TeamIdentifier       string
+	CodeDirectoryVersion string
+	Flags                string
+	Hashes               string
+	HashType             string
+	PageSize             string
+	CDHash               string
+	RuntimeVersion       string
+	Authorities          []string
+	Timestamped          bool
+	RequirementsCount    string
+	RequirementsSize     string
+	Designated           string
+}
+
+// ParseCodesignOutput parses the (combined stdout and stderr) output of "codesign -d --verbose=4 -r-".
+func ParseCodesignOutput(output string) CodesignDescription {
+	var d CodesignDescription
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "designated => ") {
+			// note: an implicit designated requirement is commented ("# designated => ...") and is not stored
+			d.Designated = strings.TrimPrefix(line, "designated => ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Identifier":
+			d.Identifier = value
+		case "TeamIdentifier":
+			if value != "not set" {
+				d.TeamIdentifier = value
+			}
+		case "CodeDirectory v":
+			// e.g. "20500 size=15012 flags=0x10000(runtime) hashes=458+2 location=embedded"
+			fields := strings.Fields(value)
+			if len(fields) > 0 {
+				d.CodeDirectoryVersion = fields[0]
+			}
+			attrs := attributes(fields)
+			d.Flags, _, _ = strings.Cut(attrs["flags"], "(")
+			d.Hashes = attrs["hashes"]
+		case "Hash type":
+			// e.g. "sha256 size=32"
+			d.HashType = strings.Fields(value + " ")[0]
+		case "Page size":
+			d.PageSize = value
+		case "CDHash":
+			d.CDHash = value
+		case "Runtime Version":
+			d.RuntimeVersion = value
+		case "Authority":
+			d.Authorities = append(d.Authorities, value)
+		case "Timestamp":
+			d.Timestamped = true
+		case "Internal requirements count":
+			// e.g. "1 size=124"
+			fields := strings.Fields(value)
+			if len(fields) > 0 {
+				d.RequirementsCount = fields[0]
+			}
+			d.RequirementsSize = attributes(fields)["size"]
+		}
+	}
+	return d
+}
+
+// attributes parses "key=value" fields.
+func attributes(fields []string) map[string]string {
+	attrs := make(map[string]string)
+	for _, f := range fields {
+		if k, v, ok := strings.Cut(f, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+// ConformanceCheck is a single field compared between quill and codesign.
+type ConformanceCheck struct {
+	Field    string `json:"field"`
+	Quill    string `json:"quill"`
+	Codesign string `json:"codesign"`
+	Match    bool   `json:"match"`
+}
+
+// ConformanceReport is the comparison between quill and codesign for a single architecture.
+type ConformanceReport struct {
+	Architecture string             `json:"architecture"`
+	Checks       []ConformanceCheck `json:"checks"`
+}
+
+// Divergences returns the checks where quill and codesign do not agree.
+func (r ConformanceReport) Divergences() []ConformanceCheck {
+	var results []ConformanceCheck
+	for _, c := range r.Checks {
+		if !c.Match {
+			results = append(results, c)
+		}
+	}
+	return results
+}
+
+// CompareWithCodesign compares quill's parsing of the signature of every binary within the given file against the
+// output of Apple's codesign tool (see ErrCodesignUnavailable).
+func CompareWithCodesign(path string) ([]ConformanceReport, error) {
+	if _, err := exec.LookPath("codesign"); err != nil {
+		return nil, ErrCodesignUnavailable
+	}
+
+	allDetails, err := ParseAllDetails(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []ConformanceReport
+	for _, d := range allDetails {
+		args := []string{"-d", "--verbose=4", "-r-"}
+		if len(allDetails) > 1 {
+			args = append(args, "--arch", codesignArch(d.File.CPU))
+		}
+		args = append(args, path)
+
+		log.WithFields("args", strings.Join(args, " ")).Trace("running codesign")
+
+		// note: codesign writes the description to stderr and the requirements to stdout
+		out, err := exec.Command("codesign", args...).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("unable to run codesign (%s): %w", strings.TrimSpace(string(out)), err)
+		}
+
+		reports = append(reports, ConformanceReport{
+			Architecture: architecture(d),
+			Checks:       compareCodesign(d, ParseCodesignOutput(string(out))),
+		})
+	}
+	return reports, nil
+}
+
+// codesignArch maps the CPU name of the binary to the architecture name used by codesign.
+func codesignArch(cpu string) string {
+	switch strings.ToLower(cpu) {
+	case "aarch64":
+		return "arm64"
+	case "amd64":
+		return "x86_64"
+	case "arm64_32":
+		return "arm64_32"
+	}
+	return strings.ToLower(cpu)
+}
+
+//nolint:funlen
+func compareCodesign(d Details, c CodesignDescription) []ConformanceCheck {
+	var checks []ConformanceCheck
+	add := func(field, quill, codesign string) {
+		checks = append(checks, ConformanceCheck{
+			Field:    field,
+			Quill:    quill,
+			Codesign: codesign,
+			Match:    quill == codesign,
+		})
+	}
+
+	var cd CodeDirectoryDetails
+	if d.SuperBlob != nil && len(d.SuperBlob.CodeDirectories) > 0 {
+		cd = d.SuperBlob.CodeDirectories[0]
+	}
+
+	add("identifier", cd.ID, c.Identifier)
+	add("teamIdentifier", cd.TeamID, c.TeamIdentifier)
+	add("codeDirectoryVersion", hexValue(cd.Version, ""), c.CodeDirectoryVersion)
+	add("flags", hexValue(cd.Flags, "0x"), c.Flags)
+	add("hashes", fmt.Sprintf("%d+%d", len(cd.PageDigests), len(cd.SpecialDigests)), c.Hashes)
+	add("hashType", cd.HashType, c.HashType)
+	add("pageSize", pageSizeString(cd.PageSize), c.PageSize)
+	add("cdhash", truncateCDHash(cd.DeclaredDigest.Value), c.CDHash)
+	add("runtimeVersion", cd.RuntimeVersion, c.RuntimeVersion)
+
+	var authorities []string
+	var timestamped bool
+	var requirementsCount, requirementsSize, designated string
+	if d.SuperBlob != nil {
+		for _, sig := range d.SuperBlob.Signatures {
+			for _, cert := range sig.Certificates {
+				if cert.Parsed != nil {
+					authorities = append(authorities, cert.Parsed.Subject.CommonName)
+				}
+			}
+			for _, s := range sig.Signers {
+				if s.Timestamp != nil {
+					timestamped = true
+				}
+			}
+		}
+
+		for _, r := range d.SuperBlob.Requirements {
+			requirementsCount = strconv.Itoa(len(r.Statements))
+			if by, err := base64.StdEncoding.DecodeString(r.Blob.Base64); err == nil && len(by) > 0 {
+				requirementsSize = strconv.Itoa(len(by))
+			}
+			for _, s := range r.Statements {
+				if s.Type == macho.DesignatedRequirementType.String() {
+					designated = s.Expression
+				}
+			}
+		}
+	}
+
+	// the chain order within the CMS signature is not defined, so only the set of authorities is compared
+	add("authorities", sortedJoin(authorities), sortedJoin(c.Authorities))
+	add("timestamped", strconv.FormatBool(timestamped), strconv.FormatBool(c.Timestamped))
+	add("requirementsCount", requirementsCount, c.RequirementsCount)
+	add("requirementsSize", requirementsSize, c.RequirementsSize)
+	add("designatedRequirement", designated, c.Designated)
+
+	return checks
+}
+
+// hexValue formats an integer value as hex (ignoring any String method of the value type).
+func hexValue(v DescribedValue, prefix string) string {
+	n, ok := v.Uint()
+	if !ok {
+		return ""
+	}
+	return prefix + strconv.FormatUint(n, 16)
+}
+
+func pageSizeString(size uint32) string {
+	if size == 0 {
+		return "none"
+	}
+	return strconv.FormatUint(uint64(size), 10)
+}
+
+// truncateCDHash returns the cdhash as shown by codesign (the digest of the code directory truncated to 20 bytes).
+func truncateCDHash(value string) string {
+	if len(value) > 40 {
+		return value[:40]
+	}
+	return value
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// ShowCodesignConformance writes the comparison of quill's parsing of the signature against codesign, returning true
+// if any divergences were found.
+func ShowCodesignConformance(path string, writer io.Writer, format string) (bool, error) {
+	reports, err := CompareWithCodesign(path)
+	if err != nil {
+		return false, err
+	}
+
+	var diverged bool
+	for _, r := range reports {
+		if len(r.Divergences()) > 0 {
+			diverged = true
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return diverged, encodeJSON(reports, writer)
+	case "yaml":
+		return diverged, encodeYAML(reports, writer)
+	case "text":
+		for i, r := range reports {
+			if i != 0 {
+				if _, err := io.WriteString(writer, "\n"); err != nil {
+					return diverged, err
+				}
+			}
+			if _, err := fmt.Fprintf(writer, "Architecture %s:\n%s\n", r.Architecture, r); err != nil {
+				return diverged, err
+			}
+		}
+		return diverged, nil
+	}
+	return diverged, fmt.Errorf("unknown format: %s", format)
+}
+
+func (r ConformanceReport) String() string {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"Field", "Quill", "Codesign", "Status"})
+	for _, c := range r.Checks {
+		status := "ok"
+		if !c.Match {
+			status = "DIVERGED"
+		}
+		t.AppendRow(table.Row{c.Field, c.Quill, c.Codesign, status})
+	}
+	return t.Render()
+}
@@ -0,0 +1,90 @@
+package extract
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+const codesignOutput = `Executable=/tmp/bin
+Identifier=bin
+Format=Mach-O thin (arm64)
+CodeDirectory v=20500 size=15012 flags=0x10000(runtime) hashes=458+2 location=embedded
+Hash type=sha256 size=32
+CandidateCDHash sha256=d907e3812c79194a7ca395f6b04cd4a3a63f0ec8
+CDHash=d907e3812c79194a7ca395f6b04cd4a3a63f0ec8
+Signature size=2703
+Authority=Developer ID Application: Quill Test (QUILLTEST1)
+Authority=Quill Test Developer ID Certification Authority
+Timestamp=Jan 2, 2024 at 10:00:00
+Info.plist=not bound
+TeamIdentifier=not set
+Runtime Version=14.0.0
+Sealed Resources=none
+Internal requirements count=1 size=124
+designated => identifier bin and certificate leaf[subject.OU] = QUILLTEST1
+`
+
+func TestParseCodesignOutput(t *testing.T) {
+	assert.Equal(t, CodesignDescription{
+		Identifier:           "bin",
+		CodeDirectoryVersion: "20500",
+		Flags:                "0x10000",
+		Hashes:               "458+2",
+		HashType:             "sha256",
+		CDHash:               "d907e3812c79194a7ca395f6b04cd4a3a63f0ec8",
+		RuntimeVersion:       "14.0.0",
+		Authorities: []string{
+			"Developer ID Application: Quill Test (QUILLTEST1)",
+			"Quill Test Developer ID Certification Authority",
+		},
+		Timestamped:       true,
+		RequirementsCount: "1",
+		RequirementsSize:  "124",
+		Designated:        "identifier bin and certificate leaf[subject.OU] = QUILLTEST1",
+	}, ParseCodesignOutput(codesignOutput))
+}
+
+func Test_compareCodesign(t *testing.T) {
+	details := Details{
+		SuperBlob: &SuperBlobDetails{
+			CodeDirectories: []CodeDirectoryDetails{
+				{
+					ID:             "bin",
+					Version:        DescribedValue{Value: uint32(0x20500)},
+					Flags:          DescribedValue{Value: uint32(0x10000)},
+					PageDigests:    make([]SectionDigest, 458),
+					SpecialDigests: make([]SectionDigest, 2),
+					HashType:       "sha256",
+					PageSize:       4096,
+					DeclaredDigest: SectionDigest{Digest: Digest{Value: "d907e3812c79194a7ca395f6b04cd4a3a63f0ec8b58d35825e1b92a9f1690638"}},
+					RuntimeVersion: "14.0.0",
+				},
+			},
+			Requirements: []RequirementDetails{
+				{
+					Blob: BlobDetails{Base64: base64.StdEncoding.EncodeToString(make([]byte, 124))},
+					Statements: []RequirementStatementDetails{
+						{
+							Type:       macho.DesignatedRequirementType.String(),
+							Expression: "identifier bin and certificate leaf[subject.OU] = QUILLTEST2",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var diverged []string
+	for _, c := range compareCodesign(details, ParseCodesignOutput(codesignOutput)) {
+		if !c.Match {
+			diverged = append(diverged, c.Field)
+		}
+	}
+
+	// the page size is not part of the sample output, everything else is a deliberate difference
+	assert.Equal(t, []string{"pageSize", "authorities", "timestamped", "designatedRequirement"}, diverged)
+}
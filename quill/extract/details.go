@@ -13,9 +13,9 @@ import (
 )
 
 type Details struct {
-	File MachoDetails `json:"file"`
-	// TODO: raw superblob info
-	SuperBlob *SuperBlobDetails `json:"superBlob,omitempty"`
+	File         MachoDetails         `json:"file"`
+	LoadCommands []LoadCommandDetails `json:"loadCommands"`
+	SuperBlob    *SuperBlobDetails    `json:"superBlob,omitempty"`
 	// TODO: helper output to show if the binary is signed or not?
 }
 
@@ -35,9 +35,22 @@ func (d Details) String(hideVerboseData bool) (r string) {
 		for idx, req := range d.SuperBlob.Requirements {
 			r += fmt.Sprintf("\nRequirements (block %d):\n", idx+1) + doIndent(req.String(), "  ")
 		}
-	}
 
-	// TODO: add entitlements
+		for _, ent := range d.SuperBlob.Entitlements {
+			r += fmt.Sprintf("\nEntitlements (%s):\n", ent.Format) + doIndent(ent.String(), "  ")
+		}
+
+		if len(d.SuperBlob.EntitlementsDiscrepancies) > 0 {
+			r += "\nEntitlements discrepancies (XML vs DER):\n"
+			for _, disc := range d.SuperBlob.EntitlementsDiscrepancies {
+				r += fmt.Sprintf("  - %s\n", disc)
+			}
+		}
+
+		if d.SuperBlob.Ticket != nil {
+			r += "\nNotarization Ticket:\n" + doIndent(d.SuperBlob.Ticket.String(), "  ")
+		}
+	}
 
 	return r
 }
@@ -49,8 +62,9 @@ type File struct {
 
 func ParseDetails(m File) Details {
 	return Details{
-		File:      getMachoDetails(m),
-		SuperBlob: getSuperBlobDetails(m),
+		File:         getMachoDetails(m),
+		LoadCommands: getLoadCommands(m),
+		SuperBlob:    getSuperBlobDetails(m),
 	}
 }
 
@@ -0,0 +1,298 @@
+package extract
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+// Difference is a single signature field that differs between two binaries.
+type Difference struct {
+	Field string `json:"field"`
+	A     string `json:"a,omitempty"`
+	B     string `json:"b,omitempty"`
+	// Reason describes the difference when it is not a simple change of value (e.g. for nested entitlements).
+	Reason string `json:"reason,omitempty"`
+}
+
+func (d Difference) String() string {
+	if d.Reason != "" {
+		return fmt.Sprintf("%s: %s", d.Field, d.Reason)
+	}
+	return fmt.Sprintf("%s: %s -> %s", d.Field, d.A, d.B)
+}
+
+// BinaryDiff are the signature differences for a single architecture of the compared binaries.
+type BinaryDiff struct {
+	Architecture string       `json:"architecture"`
+	Differences  []Difference `json:"differences"`
+}
+
+// signatureFields is the flattened view of the signature fields that are compared between binaries.
+type signatureFields struct {
+	fields       map[string]string
+	entitlements entitlements.Entitlements
+}
+
+// fieldOrder is the order in which differences are reported (any other, e.g. indexed, field follows).
+var fieldOrder = []string{
+	"signed",
+	"identifier",
+	"teamID",
+	"flags",
+	"codeDirectoryVersion",
+	"hashType",
+	"runtimeVersion",
+	"execSegmentFlags",
+	"cdhashes",
+	"identity",
+	"certificateChain",
+	"timestamped",
+	"timestampAuthority",
+	"requirements",
+}
+
+// DiffSignatures compares the signatures of two (possibly multi-arch) binaries field by field (identity, flags,
+// team ID, certificate chain, entitlements, cdhashes, etc.), pairing the binaries by architecture.
+func DiffSignatures(pathA, pathB string) ([]BinaryDiff, error) {
+	a, err := ParseAllDetails(pathA)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe %q: %w", pathA, err)
+	}
+	b, err := ParseAllDetails(pathB)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe %q: %w", pathB, err)
+	}
+
+	byArch := func(details []Details) (map[string]Details, []string) {
+		m := make(map[string]Details)
+		var order []string
+		for _, d := range details {
+			arch := architecture(d)
+			if _, ok := m[arch]; !ok {
+				order = append(order, arch)
+			}
+			m[arch] = d
+		}
+		return m, order
+	}
+
+	aByArch, aOrder := byArch(a)
+	bByArch, bOrder := byArch(b)
+
+	var archs []string
+	archs = append(archs, aOrder...)
+	for _, arch := range bOrder {
+		if _, ok := aByArch[arch]; !ok {
+			archs = append(archs, arch)
+		}
+	}
+
+	var results []BinaryDiff
+	for _, arch := range archs {
+		da, inA := aByArch[arch]
+		db, inB := bByArch[arch]
+
+		diff := BinaryDiff{Architecture: arch}
+		switch {
+		case !inA:
+			diff.Differences = []Difference{{Field: "architecture", A: "(missing)", B: "present"}}
+		case !inB:
+			diff.Differences = []Difference{{Field: "architecture", A: "present", B: "(missing)"}}
+		default:
+			diff.Differences = diffFields(collectSignatureFields(da), collectSignatureFields(db))
+		}
+		results = append(results, diff)
+	}
+
+	return results, nil
+}
+
+func architecture(d Details) string {
+	if d.File.SubCPU == "" {
+		return d.File.CPU
+	}
+	return fmt.Sprintf("%s (%s)", d.File.CPU, d.File.SubCPU)
+}
+
+func collectSignatureFields(d Details) signatureFields {
+	fields := map[string]string{
+		"signed": "no",
+	}
+	result := signatureFields{fields: fields}
+
+	sb := d.SuperBlob
+	if sb == nil {
+		return result
+	}
+	fields["signed"] = "yes"
+
+	var cdhashes []string
+	for i, cd := range sb.CodeDirectories {
+		cdhashes = append(cdhashes, cd.HashType+":"+cd.DeclaredDigest.Value)
+		if i != 0 {
+			continue
+		}
+		// the primary code directory describes the signature (alternates only differ by the hash type)
+		fields["identifier"] = cd.ID
+		fields["teamID"] = cd.TeamID
+		fields["flags"] = cd.Flags.Description
+		fields["codeDirectoryVersion"] = cd.Version.Description
+		fields["hashType"] = cd.HashType
+		fields["runtimeVersion"] = cd.RuntimeVersion
+		fields["execSegmentFlags"] = cd.ExecSegment.Flags.Description
+	}
+	fields["cdhashes"] = strings.Join(cdhashes, ", ")
+
+	for _, sig := range sb.Signatures {
+		var chain []string
+		for _, c := range sig.Certificates {
+			chain = append(chain, fmt.Sprintf("%s (sha256:%s)", c.Summary.Subject, c.Summary.SHA256))
+		}
+		fields["certificateChain"] = strings.Join(chain, ", ")
+
+		if leaf := leafCertificate(sig.Certificates); leaf != nil {
+			fields["identity"] = leaf.Summary.Subject
+		}
+
+		fields["timestamped"] = "no"
+		for _, s := range sig.Signers {
+			if s.Timestamp != nil {
+				fields["timestamped"] = "yes"
+				fields["timestampAuthority"] = s.Timestamp.Authority
+			}
+		}
+	}
+
+	var requirements []string
+	for _, r := range sb.Requirements {
+		for _, s := range r.Statements {
+			requirements = append(requirements, fmt.Sprintf("%s => %s", s.Type, s.Expression))
+		}
+	}
+	fields["requirements"] = strings.Join(requirements, "; ")
+
+	for _, e := range sb.Entitlements {
+		// prefer the XML entitlements, differences between the XML and DER forms are reported by describe
+		if result.entitlements == nil || e.Format == "xml" {
+			result.entitlements = e.Entitlements
+		}
+	}
+
+	return result
+}
+
+// leafCertificate returns the (non-CA) signing certificate of the chain.
+func leafCertificate(certs []Certificate) *Certificate {
+	for i := range certs {
+		if !certs[i].Summary.IsCA {
+			return &certs[i]
+		}
+	}
+	return nil
+}
+
+func diffFields(a, b signatureFields) []Difference {
+	keys := make(map[string]struct{})
+	for k := range a.fields {
+		keys[k] = struct{}{}
+	}
+	for k := range b.fields {
+		keys[k] = struct{}{}
+	}
+
+	var ordered []string
+	for _, k := range fieldOrder {
+		if _, ok := keys[k]; ok {
+			ordered = append(ordered, k)
+			delete(keys, k)
+		}
+	}
+	var rest []string
+	for k := range keys {
+		rest = append(rest, k)
+	}
+	sort.Strings(rest)
+	ordered = append(ordered, rest...)
+
+	var results []Difference
+	for _, k := range ordered {
+		av, bv := valueOrMissing(a.fields, k), valueOrMissing(b.fields, k)
+		if av != bv {
+			results = append(results, Difference{Field: k, A: av, B: bv})
+		}
+	}
+
+	switch {
+	case a.entitlements == nil && b.entitlements == nil:
+	case a.entitlements == nil:
+		results = append(results, Difference{Field: "entitlements", A: "(missing)", B: "present"})
+	case b.entitlements == nil:
+		results = append(results, Difference{Field: "entitlements", A: "present", B: "(missing)"})
+	default:
+		for _, d := range entitlements.Diff(a.entitlements, b.entitlements, "A", "B") {
+			results = append(results, Difference{Field: "entitlements[" + d.Key + "]", Reason: d.Reason})
+		}
+	}
+
+	return results
+}
+
+func valueOrMissing(fields map[string]string, key string) string {
+	v, ok := fields[key]
+	if !ok || v == "" {
+		return "(missing)"
+	}
+	return v
+}
+
+// ShowDiff writes the signature differences between the two given binaries, returning true if any were found.
+func ShowDiff(pathA, pathB string, writer io.Writer, format string) (bool, error) {
+	diffs, err := DiffSignatures(pathA, pathB)
+	if err != nil {
+		return false, err
+	}
+
+	var changed bool
+	for _, d := range diffs {
+		if len(d.Differences) > 0 {
+			changed = true
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return changed, encodeJSON(diffs, writer)
+	case "yaml":
+		return changed, encodeYAML(diffs, writer)
+	case "text":
+		return changed, writeDiffText(diffs, writer)
+	}
+	return changed, fmt.Errorf("unknown format: %s", format)
+}
+
+func writeDiffText(diffs []BinaryDiff, writer io.Writer) error {
+	var sb strings.Builder
+	for i, d := range diffs {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("Architecture %s:\n", d.Architecture))
+		if len(d.Differences) == 0 {
+			sb.WriteString("  no signature differences\n")
+			continue
+		}
+		for _, diff := range d.Differences {
+			if diff.Reason != "" {
+				sb.WriteString(fmt.Sprintf("  - %s\n", diff))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("  - %s:\n      a: %s\n      b: %s\n", diff.Field, diff.A, diff.B))
+		}
+	}
+	_, err := io.WriteString(writer, sb.String())
+	return err
+}
@@ -0,0 +1,76 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+func Test_diffFields(t *testing.T) {
+	signed := func(teamID string, ents entitlements.Entitlements) Details {
+		return Details{
+			SuperBlob: &SuperBlobDetails{
+				CodeDirectories: []CodeDirectoryDetails{
+					{
+						ID:             "com.example.bin",
+						TeamID:         teamID,
+						Flags:          DescribedValue{Description: "runtime"},
+						HashType:       "sha256",
+						DeclaredDigest: SectionDigest{Digest: Digest{Value: "abc" + teamID}},
+					},
+				},
+				Entitlements: []EntitlementDetails{
+					{Format: "xml", Entitlements: ents},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		a    Details
+		b    Details
+		want []string
+	}{
+		{
+			name: "identical",
+			a:    signed("TEAM1", entitlements.Entitlements{"a": true}),
+			b:    signed("TEAM1", entitlements.Entitlements{"a": true}),
+		},
+		{
+			name: "unsigned vs signed",
+			a:    Details{},
+			b:    signed("TEAM1", nil),
+			want: []string{
+				"signed: no -> yes",
+				"identifier: (missing) -> com.example.bin",
+				"teamID: (missing) -> TEAM1",
+				"flags: (missing) -> runtime",
+				"hashType: (missing) -> sha256",
+				"cdhashes: (missing) -> sha256:abcTEAM1",
+			},
+		},
+		{
+			name: "team ID and entitlements changed",
+			a:    signed("TEAM1", entitlements.Entitlements{"a": true, "b": true}),
+			b:    signed("TEAM2", entitlements.Entitlements{"a": false}),
+			want: []string{
+				"teamID: TEAM1 -> TEAM2",
+				"cdhashes: sha256:abcTEAM1 -> sha256:abcTEAM2",
+				"entitlements[a]: value differs (A=true, B=false)",
+				"entitlements[b]: only present in the A entitlements",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range diffFields(collectSignatureFields(tt.a), collectSignatureFields(tt.b)) {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,10 +1,155 @@
 package extract
 
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+)
+
 type EntitlementDetails struct {
 	Blob BlobDetails `json:"blob"`
+	// Plist is the XML entitlements plist (empty for the DER representation).
+	Plist string `json:"plist,omitempty"`
+	// Format is either "xml" or "der".
+	Format string `json:"format"`
+	// Entitlements are the decoded entitlements (empty when the blob could not be decoded).
+	Entitlements entitlements.Entitlements `json:"entitlements,omitempty"`
+}
+
+func getEntitlements(m File) []EntitlementDetails {
+	cs := m.blacktopFile.CodeSignature()
+	if cs == nil {
+		return nil
+	}
+
+	var ents []EntitlementDetails
+	if cs.Entitlements != "" {
+		decoded, err := entitlements.ParseXML([]byte(cs.Entitlements))
+		if err != nil {
+			log.Warnf("unable to decode XML entitlements: %v", err)
+		}
+		ents = append(ents, EntitlementDetails{
+			Blob:         newBlobDetails([]byte(cs.Entitlements)),
+			Plist:        cs.Entitlements,
+			Format:       "xml",
+			Entitlements: decoded,
+		})
+	}
+	if len(cs.EntitlementsDER) > 0 {
+		decoded, err := entitlements.ParseDER(cs.EntitlementsDER)
+		if err != nil {
+			log.Warnf("unable to decode DER entitlements: %v", err)
+		}
+		ents = append(ents, EntitlementDetails{
+			Blob:         newBlobDetails(cs.EntitlementsDER),
+			Format:       "der",
+			Entitlements: decoded,
+		})
+	}
+	return ents
 }
 
-func getEntitlements(_ File) []EntitlementDetails {
-	// TODO
-	return nil
+// compareEntitlements reports the differences between the XML and DER entitlements.
+func compareEntitlements(ents []EntitlementDetails) []entitlements.Discrepancy {
+	var xml, der entitlements.Entitlements
+	for _, e := range ents {
+		switch e.Format {
+		case "xml":
+			xml = e.Entitlements
+		case "der":
+			der = e.Entitlements
+		}
+	}
+	return entitlements.Compare(xml, der)
+}
+
+func (e EntitlementDetails) String() string {
+	if e.Entitlements == nil {
+		return "(unable to decode entitlements)\n"
+	}
+	return e.Entitlements.XML()
+}
+
+// EntitlementsReport is the entitlements of a single binary along with any differences between the XML and DER forms.
+type EntitlementsReport struct {
+	XML           entitlements.Entitlements  `json:"xml"`
+	DER           entitlements.Entitlements  `json:"der"`
+	Discrepancies []entitlements.Discrepancy `json:"discrepancies"`
+}
+
+func getEntitlementsReport(m File) EntitlementsReport {
+	ents := getEntitlements(m)
+	report := EntitlementsReport{
+		Discrepancies: compareEntitlements(ents),
+	}
+	for _, e := range ents {
+		switch e.Format {
+		case "xml":
+			report.XML = e.Entitlements
+		case "der":
+			report.DER = e.Entitlements
+		}
+	}
+	return report
+}
+
+func (r EntitlementsReport) String() string {
+	var sb strings.Builder
+	switch {
+	case r.XML != nil:
+		sb.WriteString(r.XML.XML())
+	case r.DER != nil:
+		sb.WriteString(r.DER.XML())
+	default:
+		sb.WriteString("No entitlements found\n")
+	}
+
+	if len(r.Discrepancies) == 0 {
+		if r.XML != nil && r.DER != nil {
+			sb.WriteString("\nXML and DER entitlements match\n")
+		}
+		return sb.String()
+	}
+
+	sb.WriteString("\nXML and DER entitlements discrepancies:\n")
+	for _, d := range r.Discrepancies {
+		sb.WriteString(fmt.Sprintf("  - %s\n", d))
+	}
+	return sb.String()
+}
+
+// ShowEntitlements writes only the entitlements of every binary within the given file (as a formatted plist for the
+// "text" format) along with the differences between the XML and DER entitlements.
+func ShowEntitlements(path string, writer io.Writer, format string) error {
+	mfs, err := NewFile(path)
+	if err != nil {
+		return err
+	}
+
+	var reports []EntitlementsReport
+	for _, f := range mfs {
+		reports = append(reports, getEntitlementsReport(*f))
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return encodeJSON(reports, writer)
+	case "yaml":
+		return encodeYAML(reports, writer)
+	case "text":
+		for i, r := range reports {
+			prefix := ""
+			if i != 0 {
+				prefix = "\n"
+			}
+			if _, err := fmt.Fprintf(writer, "%sBinary %d of %d:\n\n%s", prefix, i+1, len(reports), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format: %s", format)
 }
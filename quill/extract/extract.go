@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 
 	blacktopMacho "github.com/blacktop/go-macho"
+	"gopkg.in/yaml.v3"
 
 	macholibre "github.com/anchore/go-macholibre"
 	"github.com/anchore/quill/internal/utils"
@@ -72,21 +74,127 @@ func newFile(path string) (*File, error) {
 	}, nil
 }
 
-func ShowJSON(path string, writer io.Writer) error {
+// NewFileFS is like NewFile, but the (possibly multi-arch) binary is read from the given file system (e.g. an
+// embed.FS or a zip.Reader) without writing temp files.
+func NewFileFS(fsys fs.FS, name string) ([]*File, error) {
+	internalFiles, err := macho.OpenFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var mfs []*File
+	for _, internalFile := range internalFiles {
+		blacktopMachoFile, err := blacktopMacho.NewFile(internalFile.ReaderAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse macho formatted file with blacktop: %w", err)
+		}
+		mfs = append(mfs, &File{
+			blacktopFile: blacktopMachoFile,
+			internalFile: internalFile,
+		})
+	}
+	return mfs, nil
+}
+
+// ParseAllDetails returns the details of every binary within the given (possibly multi-arch) file.
+func ParseAllDetails(path string) ([]Details, error) {
 	mfs, err := NewFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return parseAllDetails(mfs), nil
+}
 
+// ParseAllDetailsFS is like ParseAllDetails, but the binary is read from the given file system.
+func ParseAllDetailsFS(fsys fs.FS, name string) ([]Details, error) {
+	mfs, err := NewFileFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parseAllDetails(mfs), nil
+}
+
+func parseAllDetails(mfs []*File) []Details {
 	var allDetails []Details
 	for _, f := range mfs {
 		details := ParseDetails(*f)
 		allDetails = append(allDetails, details)
 	}
+	return allDetails
+}
+
+func ShowJSON(path string, writer io.Writer) error {
+	allDetails, err := ParseAllDetails(path)
+	if err != nil {
+		return err
+	}
 
+	return encodeJSON(allDetails, writer)
+}
+
+// ShowJSONFS is like ShowJSON, but the binary is read from the given file system.
+func ShowJSONFS(fsys fs.FS, name string, writer io.Writer) error {
+	allDetails, err := ParseAllDetailsFS(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	return encodeJSON(allDetails, writer)
+}
+
+func encodeJSON(v interface{}, writer io.Writer) error {
 	en := json.NewEncoder(writer)
 	en.SetIndent("", "  ")
-	return en.Encode(allDetails)
+	return en.Encode(v)
+}
+
+// ShowYAML writes the same document as ShowJSON, but YAML encoded.
+func ShowYAML(path string, writer io.Writer) error {
+	allDetails, err := ParseAllDetails(path)
+	if err != nil {
+		return err
+	}
+	return encodeYAML(allDetails, writer)
+}
+
+// ShowYAMLFS is like ShowYAML, but the binary is read from the given file system.
+func ShowYAMLFS(fsys fs.FS, name string, writer io.Writer) error {
+	allDetails, err := ParseAllDetailsFS(fsys, name)
+	if err != nil {
+		return err
+	}
+	return encodeYAML(allDetails, writer)
+}
+
+// encodeYAML encodes the given value as YAML using the JSON field names and ordering (so both formats describe the
+// same document without maintaining a second set of struct tags).
+func encodeYAML(v interface{}, writer io.Writer) error {
+	by, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is a subset of YAML, decoding into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(by, &node); err != nil {
+		return fmt.Errorf("unable to convert to YAML: %w", err)
+	}
+	resetYAMLStyle(&node)
+
+	en := yaml.NewEncoder(writer)
+	en.SetIndent(2)
+	if err := en.Encode(&node); err != nil {
+		return err
+	}
+	return en.Close()
+}
+
+// resetYAMLStyle drops the flow and quoting styles carried over from the JSON input, so the output is block style.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
 }
 
 func ShowText(path string, writer io.Writer, hideVerboseData bool) error {
@@ -94,7 +202,19 @@ func ShowText(path string, writer io.Writer, hideVerboseData bool) error {
 	if err != nil {
 		return err
 	}
+	return showText(mfs, writer, hideVerboseData)
+}
+
+// ShowTextFS is like ShowText, but the binary is read from the given file system.
+func ShowTextFS(fsys fs.FS, name string, writer io.Writer, hideVerboseData bool) error {
+	mfs, err := NewFileFS(fsys, name)
+	if err != nil {
+		return err
+	}
+	return showText(mfs, writer, hideVerboseData)
+}
 
+func showText(mfs []*File, writer io.Writer, hideVerboseData bool) (err error) {
 	for i, f := range mfs {
 		details := ParseDetails(*f)
 		detailString := details.String(hideVerboseData)
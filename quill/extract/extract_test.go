@@ -0,0 +1,104 @@
+package extract
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_encodeYAML(t *testing.T) {
+	details := []Details{
+		{
+			File: MachoDetails{
+				Magic: "64-bit MachO",
+				Flags: []string{"PIE"},
+			},
+			LoadCommands: []LoadCommandDetails{
+				{Index: 0, Command: "LC_SEGMENT_64", Size: 72, Description: "__PAGEZERO"},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, encodeYAML(details, buf))
+
+	// uses the JSON field names (in order) and block style
+	want := `- file:
+    magic: 64-bit MachO
+    type: ""
+    cpu: ""
+    subcpu: ""
+    flags:
+      - PIE
+    libs: null
+    loadCommandsCount: 0
+    loadCommandSize: 0
+    uuid: ""
+  loadCommands:
+    - index: 0
+      command: LC_SEGMENT_64
+      size: 72
+      description: __PAGEZERO
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func Test_executeTemplate(t *testing.T) {
+	details := []Details{
+		{
+			File: MachoDetails{CPU: "AARCH64", Flags: []string{"DyldLink", "PIE"}, LoadCommandCount: 15},
+			SuperBlob: &SuperBlobDetails{
+				CodeDirectories: []CodeDirectoryDetails{
+					{TeamID: "QUILLTEST1", DeclaredDigest: SectionDigest{Digest: Digest{Value: "abcd"}}},
+				},
+			},
+		},
+		{
+			File: MachoDetails{CPU: "AMD64"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "single field",
+			tmpl: "{{.file.cpu}}",
+			want: "AARCH64\nAMD64\n",
+		},
+		{
+			name: "nested field",
+			tmpl: "{{with .superBlob}}{{(index .codeDirectories 0).teamID}} {{(index .codeDirectories 0).declaredDigest.value}}{{end}}",
+			want: "QUILLTEST1 abcd\n\n",
+		},
+		{
+			name: "functions",
+			tmpl: "{{join \",\" .file.flags}}|{{lower .file.cpu}}|{{.file.loadCommandsCount}}",
+			want: "DyldLink,PIE|aarch64|15\n|amd64|0\n",
+		},
+		{
+			name:    "invalid template",
+			tmpl:    "{{.file.cpu",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			buf := &bytes.Buffer{}
+			err := executeTemplate(details, buf, tt.tmpl)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package extract
+
+import "strings"
+
+type LoadCommandDetails struct {
+	Index       int    `json:"index"`
+	Command     string `json:"command"`
+	Size        uint32 `json:"size"`
+	Description string `json:"description"`
+}
+
+func getLoadCommands(m File) []LoadCommandDetails {
+	var cmds []LoadCommandDetails
+	for idx, l := range m.blacktopFile.Loads {
+		cmds = append(cmds, LoadCommandDetails{
+			Index:       idx,
+			Command:     l.Command().String(),
+			Size:        l.LoadSize(),
+			Description: strings.TrimSpace(l.String()),
+		})
+	}
+	return cmds
+}
@@ -3,6 +3,8 @@ package extract
 import (
 	"encoding/json"
 	"strings"
+
+	blacktopMacho "github.com/blacktop/go-macho"
 )
 
 type MachoDetails struct {
@@ -15,6 +17,14 @@ type MachoDetails struct {
 	LoadCommandCount uint32   `json:"loadCommandsCount"`
 	LoadCommandSize  uint32   `json:"loadCommandSize"`
 	UUID             string   `json:"uuid"`
+	// Build is the target platform and versions (from LC_BUILD_VERSION or the legacy LC_VERSION_MIN_* commands).
+	Build *BuildDetails `json:"build,omitempty"`
+}
+
+type BuildDetails struct {
+	Platform string `json:"platform"`
+	MinOS    string `json:"minOS"`
+	SDK      string `json:"sdk"`
 }
 
 func getMachoDetails(m File) MachoDetails {
@@ -33,7 +43,29 @@ func getMachoDetails(m File) MachoDetails {
 		LoadCommandCount: m.blacktopFile.NCommands,
 		LoadCommandSize:  m.blacktopFile.SizeCommands,
 		UUID:             uuidStr,
+		Build:            getBuildDetails(m),
+	}
+}
+
+func getBuildDetails(m File) *BuildDetails {
+	if bv := m.blacktopFile.BuildVersion(); bv != nil {
+		return &BuildDetails{
+			Platform: bv.Platform.String(),
+			MinOS:    bv.Minos.String(),
+			SDK:      bv.Sdk.String(),
+		}
+	}
+
+	for _, l := range m.blacktopFile.Loads {
+		if v, ok := l.(*blacktopMacho.VersionMinMacOSX); ok {
+			return &BuildDetails{
+				Platform: "macOS",
+				MinOS:    v.Version.String(),
+				SDK:      v.Sdk.String(),
+			}
+		}
 	}
+	return nil
 }
 
 func (m MachoDetails) String() (r string) {
@@ -50,6 +82,9 @@ Flags:        {{.FormattedFlags}}
 Libraries:    {{.FormattedLibs}}
 LoadCommands: {{.LoadCommandCount}}
 UUID:         {{.UUID}}
+{{- if .Build}}
+Platform:     {{.Build.Platform}} (minimum OS {{.Build.MinOS}}, SDK {{.Build.SDK}})
+{{- end}}
 `,
 		struct {
 			MachoDetails
@@ -5,26 +5,69 @@ import (
 	"strings"
 
 	"github.com/blacktop/go-macho/pkg/codesign/types"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
 )
 
 type RequirementDetails struct {
-	Blob         BlobDetails         `json:"blob"`
-	Requirements []types.Requirement `json:"requirements"`
+	Blob BlobDetails `json:"blob"`
+	// Statements are the decoded requirements rendered in the code requirement language (as "codesign -d -r-" does).
+	Statements   []RequirementStatementDetails `json:"statements"`
+	Requirements []types.Requirement           `json:"requirements"`
+}
+
+type RequirementStatementDetails struct {
+	Type       string `json:"type"`
+	Expression string `json:"expression"`
 }
 
 func getRequirements(m File) []RequirementDetails {
-	return []RequirementDetails{
-		{
-			// TODO: extract blob
-			Requirements: m.blacktopFile.CodeSignature().Requirements,
-		},
+	details := RequirementDetails{
+		Requirements: m.blacktopFile.CodeSignature().Requirements,
+	}
+
+	b, err := m.internalFile.SlotBytes(macho.CsSlotRequirements)
+	if err != nil {
+		log.Warnf("unable to read requirements blob: %v", err)
+		return []RequirementDetails{details}
+	}
+	if b == nil {
+		return []RequirementDetails{details}
 	}
+
+	details.Blob = newBlobDetails(b)
+
+	statements, err := macho.DecodeRequirementSet(b)
+	if err != nil {
+		log.Warnf("unable to decode requirements: %v", err)
+		return []RequirementDetails{details}
+	}
+
+	for _, s := range statements {
+		details.Statements = append(details.Statements, RequirementStatementDetails{
+			Type:       s.Type.String(),
+			Expression: s.Expression,
+		})
+	}
+
+	return []RequirementDetails{details}
 }
 
 func (r RequirementDetails) String() string {
 	var reqs []string
-	for idx, req := range r.Requirements {
-		reqs = append(reqs, fmt.Sprintf("Req %d (type=%s): %s", idx, req.Type, req.Detail))
+	switch {
+	case len(r.Statements) > 0:
+		for _, s := range r.Statements {
+			reqs = append(reqs, fmt.Sprintf("%s => %s", s.Type, s.Expression))
+		}
+	case len(r.Requirements) > 0:
+		// the requirements could not be decoded, fallback to the raw description
+		for idx, req := range r.Requirements {
+			reqs = append(reqs, fmt.Sprintf("Req %d (type=%s): %s", idx, req.Type, req.Detail))
+		}
+	default:
+		reqs = append(reqs, "(none)")
 	}
 
 	return tprintf(
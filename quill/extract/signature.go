@@ -1,8 +1,10 @@
 package extract
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/github/smimesign/ietf-cms/protocol"
 
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
 )
 
 type SignatureDetails struct {
@@ -24,8 +27,20 @@ type SignatureDetails struct {
 }
 
 type Certificate struct {
-	PEM    string            `json:"pem"`
-	Parsed *x509.Certificate `json:"parsed"`
+	PEM     string             `json:"pem"`
+	Summary CertificateSummary `json:"summary"`
+	Parsed  *x509.Certificate  `json:"parsed"`
+}
+
+// CertificateSummary is the subset of certificate fields most useful for identifying a certificate.
+type CertificateSummary struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	IsCA         bool      `json:"isCA"`
+	SHA256       string    `json:"sha256"`
 }
 
 type Signer struct {
@@ -33,6 +48,8 @@ type Signer struct {
 	Signature        AlgorithmWithValue `json:"signature"`
 	SignedAttributes []Attribute        `json:"signedAttributes"`
 	DigestAlgorithm  Algorithm          `json:"digestAlgorithm"`
+	Timestamp        *TimestampDetails  `json:"timestamp,omitempty"`
+	CDHashes         *CDHashesDetails   `json:"cdHashes,omitempty"`
 }
 
 type Attribute struct {
@@ -143,6 +160,8 @@ func buildSigners(psd *protocol.SignedData) []Signer {
 				AlgorithmOID:     s.DigestAlgorithm.Algorithm.String(),
 				Base64Parameters: base64.StdEncoding.EncodeToString(s.DigestAlgorithm.Parameters.Bytes),
 			},
+			Timestamp: buildTimestamp(s),
+			CDHashes:  buildCDHashes(s),
 		})
 	}
 
@@ -163,14 +182,28 @@ func buildCerts(psd *protocol.SignedData) ([]Certificate, error) {
 	var certs []Certificate
 	for idx, cert := range parsedCerts {
 		certs = append(certs, Certificate{
-			PEM:    base64.StdEncoding.EncodeToString(psd.Certificates[idx].Bytes),
-			Parsed: cert,
+			PEM:     base64.StdEncoding.EncodeToString(psd.Certificates[idx].Bytes),
+			Summary: summarizeCertificate(cert),
+			Parsed:  cert,
 		})
 	}
 
 	return certs, nil
 }
 
+func summarizeCertificate(cert *x509.Certificate) CertificateSummary {
+	sum := sha256.Sum256(cert.Raw)
+	return CertificateSummary{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: cert.SerialNumber.Text(16),
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+		IsCA:         cert.IsCA,
+		SHA256:       hex.EncodeToString(sum[:]),
+	}
+}
+
 func findEarliestSigningTime(psd *protocol.SignedData) time.Time {
 	// it seems that the timestamp set is based on the sign time, not any certificate information
 	var earliestTime = time.Now()
@@ -179,6 +212,10 @@ func findEarliestSigningTime(psd *protocol.SignedData) time.Time {
 		return earliestTime
 	}
 	for _, s := range psd.SignerInfos {
+		if !s.SignedAttrs.HasAttribute(oid.AttributeSigningTime) {
+			// the signing time attribute is optional
+			continue
+		}
 		t, err := s.GetSigningTimeAttribute()
 		if err != nil {
 			log.Warn("unable to get signing time attribute: %v", err)
@@ -200,6 +237,10 @@ func (a Attribute) String() string {
 		oidHint = "(message digest)"
 	case oid.AttributeContentType.String():
 		oidHint = "(content type)"
+	case macho.OIDCDHashesPlist.String():
+		oidHint = "(cdhashes plist)"
+	case macho.OIDCDHashes.String():
+		oidHint = "(cdhashes)"
 	}
 	return tprintf(
 		`OID:        {{.OID}} {{.OIDHint}}
@@ -234,18 +275,25 @@ func (s Signer) String() string {
 		atts = append(atts, fmt.Sprintf("Attribute %d:\n%s", idx+1, doIndent(a.String(), "  ")))
 	}
 
+	var cdHashes string
+	if s.CDHashes != nil {
+		cdHashes = "CDHashes:\n" + doIndent(s.CDHashes.String(), "  ")
+	}
+
 	return tprintf(
 		`Signature: {{.FormattedSignature}}
-{{.FormattedAttributes}}
+{{.FormattedAttributes}}{{.FormattedCDHashes}}
 `,
 		struct {
 			Signer
 			FormattedAttributes string
 			FormattedSignature  string
+			FormattedCDHashes   string
 		}{
 			Signer:              s,
 			FormattedAttributes: strings.Join(atts, ""),
 			FormattedSignature:  "\n" + strings.TrimRight(doIndent(s.Signature.String(), "  "), " \n"),
+			FormattedCDHashes:   cdHashes,
 		},
 	)
 }
@@ -329,6 +377,9 @@ func (c Certificate) String() string {
 Issuer:
   CN:  {{.Parsed.Issuer.CommonName}}
   OU:  {{.IOU}}
+Validity:
+  NotBefore:  {{.NotBefore}}
+  NotAfter:   {{.NotAfter}}
 KeyUsage:   {{.Usage}} {{.UsageHint}}
 Extensions: {{.Extensions}}
 ExtendedKeyUsage: {{.ExtendedUsage}}
@@ -341,6 +392,8 @@ ExtendedKeyUsage: {{.ExtendedUsage}}
 			UsageHint     string
 			ExtendedUsage string
 			Extensions    string
+			NotBefore     string
+			NotAfter      string
 		}{
 			Certificate:   c,
 			SOU:           strings.Join(c.Parsed.Subject.OrganizationalUnit, ", "),
@@ -349,6 +402,8 @@ ExtendedKeyUsage: {{.ExtendedUsage}}
 			UsageHint:     usageHint,
 			ExtendedUsage: usagesStr,
 			Extensions:    strings.Join(exts, ", "),
+			NotBefore:     c.Parsed.NotBefore.Format(time.RFC3339),
+			NotAfter:      c.Parsed.NotAfter.Format(time.RFC3339),
 		},
 	)
 }
@@ -1,12 +1,37 @@
 package extract
 
+import (
+	"fmt"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+)
+
 type SuperBlobDetails struct {
 	Offset          uint32                 `json:"offset"`
 	Size            uint32                 `json:"size"`
+	Magic           string                 `json:"magic"`
+	Length          uint32                 `json:"length"`
+	Blobs           []BlobIndexDetails     `json:"blobs"`
 	CodeDirectories []CodeDirectoryDetails `json:"codeDirectories"`
 	Requirements    []RequirementDetails   `json:"requirements"`
 	Entitlements    []EntitlementDetails   `json:"entitlements"`
-	Signatures      []SignatureDetails     `json:"signatures"`
+	// EntitlementsDiscrepancies are the differences between the XML and DER entitlements (if any).
+	EntitlementsDiscrepancies []entitlements.Discrepancy `json:"entitlementsDiscrepancies,omitempty"`
+	Signatures                []SignatureDetails         `json:"signatures"`
+	// Ticket is the stapled notarization ticket (if any).
+	Ticket *TicketDetails `json:"ticket,omitempty"`
+}
+
+// BlobIndexDetails is a single entry of the superblob index (offsets are relative to the start of the superblob).
+type BlobIndexDetails struct {
+	Slot       DescribedValue `json:"slot"`
+	Offset     uint32         `json:"offset"`
+	FileOffset uint64         `json:"fileOffset"`
+	Magic      string         `json:"magic"`
+	Length     uint32         `json:"length"`
+	Digest     *Digest        `json:"digest,omitempty"`
 }
 
 func getSuperBlobDetails(m File) *SuperBlobDetails {
@@ -14,12 +39,31 @@ func getSuperBlobDetails(m File) *SuperBlobDetails {
 	if signingLoadCmd == nil {
 		return nil
 	}
-	return &SuperBlobDetails{
+
+	details := &SuperBlobDetails{
 		Offset:          signingLoadCmd.Offset,
 		Size:            signingLoadCmd.Size,
 		CodeDirectories: getCodeDirectories(m),
 		Requirements:    getRequirements(m),
 		Entitlements:    getEntitlements(m),
 		Signatures:      getSignatures(m),
+		Ticket:          getTicket(m),
 	}
+	details.EntitlementsDiscrepancies = compareEntitlements(details.Entitlements)
+
+	header, layout, err := m.internalFile.SuperBlobLayout()
+	if err != nil {
+		log.Warnf("unable to read superblob layout: %v", err)
+		return details
+	}
+
+	details.Magic = magicName(header.Magic)
+	details.Length = header.Length
+	details.Blobs = getBlobIndexDetails(m, layout)
+
+	return details
+}
+
+func magicName(m macho.Magic) string {
+	return fmt.Sprintf("0x%08x (%s)", uint32(m), m.Name())
 }
@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// ShowTemplate formats the details of every binary within the given (possibly multi-arch) file with the given Go
+// template (one line per binary, like "docker inspect --format"). The template is executed against the same document
+// that ShowJSON writes, so fields are referenced by their JSON names (e.g. '{{(index .superBlob.codeDirectories 0).teamID}}').
+func ShowTemplate(path string, writer io.Writer, tmpl string) error {
+	allDetails, err := ParseAllDetails(path)
+	if err != nil {
+		return err
+	}
+	return executeTemplate(allDetails, writer, tmpl)
+}
+
+func executeTemplate(allDetails []Details, writer io.Writer, tmpl string) error {
+	t, err := template.New("describe").Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("unable to parse template: %w", err)
+	}
+
+	for _, details := range allDetails {
+		doc, err := toDocument(details)
+		if err != nil {
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := t.Execute(buf, doc); err != nil {
+			return fmt.Errorf("unable to execute template: %w", err)
+		}
+		if _, err := fmt.Fprintln(writer, buf.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		by, err := json.Marshal(v)
+		return string(by), err
+	},
+	"join": func(sep string, v interface{}) string {
+		list, _ := v.([]interface{})
+		var values []string
+		for _, e := range list {
+			values = append(values, fmt.Sprint(e))
+		}
+		return strings.Join(values, sep)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// toDocument converts the details to the generic form of the JSON document (keyed by JSON field names).
+func toDocument(details Details) (interface{}, error) {
+	by, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(by))
+	// keep large numbers (e.g. offsets) intact instead of converting them to floats
+	d.UseNumber()
+
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
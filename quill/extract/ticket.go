@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/ticket"
+)
+
+// TicketDetails describes the notarization ticket stapled to the code (see ticket.Parse).
+type TicketDetails struct {
+	Version    uint32     `json:"version"`
+	RecordType string     `json:"recordType"`
+	IssuedAt   time.Time  `json:"issuedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	// CDHashes are the (truncated) cdhashes covered by the ticket.
+	CDHashes []Digest `json:"cdHashes"`
+	// Error is why the ticket could not be decoded (the other fields are unset).
+	Error string `json:"error,omitempty"`
+}
+
+// NewTicketDetails decodes the given stapled notarization ticket.
+func NewTicketDetails(raw []byte) *TicketDetails {
+	t, err := ticket.Parse(raw)
+	if err != nil {
+		return &TicketDetails{Error: err.Error()}
+	}
+
+	details := &TicketDetails{
+		Version:    t.Version,
+		RecordType: t.RecordType.String(),
+		IssuedAt:   t.IssuedAt,
+	}
+	if !t.ExpiresAt.IsZero() {
+		expires := t.ExpiresAt
+		details.ExpiresAt = &expires
+	}
+	for _, h := range t.CDHashes {
+		details.CDHashes = append(details.CDHashes, Digest{
+			Algorithm: hashTypeName(h.HashType),
+			Value:     h.String(),
+		})
+	}
+	return details
+}
+
+func hashTypeName(t macho.HashType) string {
+	switch t {
+	case macho.HashTypeSha1:
+		return "sha1"
+	case macho.HashTypeSha256, macho.HashTypeSha256Truncated:
+		return "sha256"
+	case macho.HashTypeSha384:
+		return "sha384"
+	}
+	return fmt.Sprintf("unknown (%d)", t)
+}
+
+func getTicket(m File) *TicketDetails {
+	sb, err := m.internalFile.ReadSuperBlob()
+	if err != nil {
+		log.Debugf("unable to read superblob: %v", err)
+		return nil
+	}
+
+	e := sb.Entry(macho.CsSlotTicketslot)
+	if e == nil {
+		return nil
+	}
+
+	raw, err := macho.UnwrapBlobWrapper(e.Data)
+	if err != nil {
+		return &TicketDetails{Error: fmt.Sprintf("unable to read the stapled ticket: %v", err)}
+	}
+	return NewTicketDetails(raw)
+}
+
+func (t TicketDetails) String() string {
+	if t.Error != "" {
+		return fmt.Sprintf("Malformed ticket: %s\n", t.Error)
+	}
+
+	expires := "never"
+	if t.ExpiresAt != nil {
+		expires = t.ExpiresAt.Format(time.RFC3339)
+	}
+
+	var hashes []string
+	for _, h := range t.CDHashes {
+		hashes = append(hashes, fmt.Sprintf("  - %s (%s)", h.Value, h.Algorithm))
+	}
+
+	return tprintf(
+		`Version:     {{.Version}}
+Record Type: {{.RecordType}}
+Issued:      {{.Issued}}
+Expires:     {{.Expires}}
+CDHashes:
+{{.Hashes}}
+`,
+		struct {
+			TicketDetails
+			Issued  string
+			Expires string
+			Hashes  string
+		}{
+			TicketDetails: t,
+			Issued:        t.IssuedAt.Format(time.RFC3339),
+			Expires:       expires,
+			Hashes:        strings.Join(hashes, "\n"),
+		},
+	)
+}
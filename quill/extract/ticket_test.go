@@ -0,0 +1,44 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+func TestNewTicketDetails(t *testing.T) {
+	b := make([]byte, 36)
+	copy(b, "s8ch")
+	binary.LittleEndian.PutUint32(b[4:], 1)
+	binary.LittleEndian.PutUint32(b[12:], 1)
+	binary.LittleEndian.PutUint64(b[16:], 1700000000)
+	binary.LittleEndian.PutUint32(b[32:], 1)
+	b = append(b, byte(macho.HashTypeSha256), 20)
+	b = append(b, bytes.Repeat([]byte{0xab}, 20)...)
+	b = append(b, "signature"...)
+	binary.LittleEndian.PutUint32(b[8:], uint32(len(b)))
+
+	got := NewTicketDetails(b)
+	require.Empty(t, got.Error)
+	assert.Equal(t, uint32(1), got.Version)
+	assert.Equal(t, "code", got.RecordType)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), got.IssuedAt)
+	assert.Nil(t, got.ExpiresAt)
+	assert.Equal(t, []Digest{{Algorithm: "sha256", Value: "abababababababababababababababababababab"}}, got.CDHashes)
+
+	s := got.String()
+	assert.Contains(t, s, "Record Type: code")
+	assert.Contains(t, s, "Issued:      2023-11-14T22:13:20Z")
+	assert.Contains(t, s, "Expires:     never")
+	assert.Contains(t, s, "  - abababababababababababababababababababab (sha256)")
+
+	bad := NewTicketDetails([]byte("bogus"))
+	assert.NotEmpty(t, bad.Error)
+	assert.Contains(t, bad.String(), "Malformed ticket")
+}
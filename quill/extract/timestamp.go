@@ -0,0 +1,85 @@
+package extract
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"time"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// TimestampDetails describes the RFC 3161 timestamp token attached to a signer (as an unsigned attribute).
+type TimestampDetails struct {
+	Time           time.Time `json:"time"`
+	SerialNumber   string    `json:"serialNumber"`
+	Policy         string    `json:"policy"`
+	MessageImprint Digest    `json:"messageImprint"`
+	Authority      string    `json:"authority"`
+}
+
+func buildTimestamp(si protocol.SignerInfo) *TimestampDetails {
+	if !si.UnsignedAttrs.HasAttribute(oid.AttributeTimeStampToken) {
+		return nil
+	}
+
+	rv, err := si.UnsignedAttrs.GetOnlyAttributeValueBytes(oid.AttributeTimeStampToken)
+	if err != nil {
+		log.Debugf("unable to get timestamp token attribute: %v", err)
+		return nil
+	}
+
+	ci, err := protocol.ParseContentInfo(rv.FullBytes)
+	if err != nil {
+		log.Debugf("unable to parse timestamp token: %v", err)
+		return nil
+	}
+
+	sd, err := ci.SignedDataContent()
+	if err != nil {
+		log.Debugf("unable to parse timestamp token signed data: %v", err)
+		return nil
+	}
+
+	info, err := timestamp.ParseInfo(sd.EncapContentInfo)
+	if err != nil {
+		log.Debugf("unable to parse timestamp token info: %v", err)
+		return nil
+	}
+
+	details := &TimestampDetails{
+		Time:   info.GenTime,
+		Policy: info.Policy.String(),
+	}
+	if info.SerialNumber != nil {
+		details.SerialNumber = info.SerialNumber.Text(16)
+	}
+	if hash, err := info.MessageImprint.Hash(); err == nil {
+		details.MessageImprint.Algorithm = algorithmName(hash)
+	}
+	details.MessageImprint.Value = hex.EncodeToString(info.MessageImprint.HashedMessage)
+
+	certs, err := sd.X509Certificates()
+	if err != nil {
+		log.Debugf("unable to parse timestamp token certificates: %v", err)
+	}
+	if tsa := findTimestampAuthority(certs); tsa != nil {
+		details.Authority = tsa.Subject.CommonName
+	}
+
+	return details
+}
+
+func findTimestampAuthority(certs []*x509.Certificate) *x509.Certificate {
+	for _, c := range certs {
+		for _, u := range c.ExtKeyUsage {
+			if u == x509.ExtKeyUsageTimeStamping {
+				return c
+			}
+		}
+	}
+	return nil
+}
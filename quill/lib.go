@@ -10,7 +10,7 @@ import (
 	intRedact "github.com/anchore/quill/internal/redact"
 )
 
-// SetLogger sets the logger object used for all logging calls.
+// SetLogger sets the logger object used for all logging calls (see logging.Set for loggers other than go-logger).
 func SetLogger(logger logger.Logger) {
 	useOrAddRedactor()
 	log.Set(logger)
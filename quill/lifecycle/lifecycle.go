@@ -0,0 +1,126 @@
+/*
+Package lifecycle publishes structured events describing the progress of quill operations (parsing binaries, hashing
+pages, timestamping, patching signatures, and notarization) to subscribers registered by the embedding application.
+This allows building a progress UI without depending on quill's CLI or event bus types. When an event bus is set
+(see quill.SetBus) every event is additionally published onto the bus as an event.LifecycleType event.
+
+Subscribers are called synchronously from within the operation that publishes the event, so they should return
+quickly and must not call back into quill.
+*/
+package lifecycle
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	// ParseStarted is published when a binary is opened for parsing (Path is the binary).
+	ParseStarted Type = "parse-started"
+	// HashProgress is published while hashing the pages of a binary (Path is the binary, Progress is from 0 to 1).
+	HashProgress Type = "hash-progress"
+	// TimestampStarted is published when a timestamp token is requested (Detail is the server URL).
+	TimestampStarted Type = "timestamp-started"
+	// TimestampFinished is published when a timestamp server responded (Detail is the server URL, Err is set when
+	// the server failed).
+	TimestampFinished Type = "timestamp-finished"
+	// PatchStarted is published when the signature is written into a binary (Path is the binary).
+	PatchStarted Type = "patch-started"
+	// SignFinished is published when a binary has been signed (Path is the binary).
+	SignFinished Type = "sign-finished"
+	// NotarySubmitted is published when Apple accepted a new submission (Path is the submission name, Submission is
+	// the submission ID).
+	NotarySubmitted Type = "notary-submitted"
+	// NotaryUploaded is published when the payload of a submission has been uploaded.
+	NotaryUploaded Type = "notary-uploaded"
+	// NotaryStatusChanged is published when the status of a submission changes (Detail is the new status).
+	NotaryStatusChanged Type = "notary-status-changed"
+)
+
+// Event describes a single step of a quill operation. Fields that do not apply to the event type are left empty.
+type Event struct {
+	Type Type
+	Time time.Time
+	// Path is the binary (or notary submission name) the event is about.
+	Path string
+	// Progress is the fraction of the work done (from 0 to 1), only for HashProgress events.
+	Progress float64
+	// Detail is additional information, such as the timestamp server URL or the notary submission status.
+	Detail string
+	// Submission is the notary submission ID, only for notary events.
+	Submission string
+	// Err is set when the step failed.
+	Err error
+}
+
+// Subscriber receives published events.
+type Subscriber interface {
+	Handle(Event)
+}
+
+// SubscriberFunc adapts a function to the Subscriber interface.
+type SubscriberFunc func(Event)
+
+func (f SubscriberFunc) Handle(e Event) {
+	f(e)
+}
+
+type subscription struct {
+	id         int
+	subscriber Subscriber
+}
+
+var (
+	lock          sync.RWMutex
+	nextID        int
+	subscriptions []subscription
+)
+
+// Subscribe registers the given subscriber for all events, returning a function that removes the subscription.
+func Subscribe(s Subscriber) (unsubscribe func()) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	id := nextID
+	nextID++
+	subscriptions = append(subscriptions, subscription{id: id, subscriber: s})
+
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		for i, sub := range subscriptions {
+			if sub.id == id {
+				subscriptions = append(subscriptions[:i:i], subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Enabled indicates if there is any subscriber, allowing publishers to skip preparing events nobody receives.
+func Enabled() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return len(subscriptions) > 0
+}
+
+// Publish delivers the event to every subscriber in the order they subscribed (the event time is set when unset).
+func Publish(e Event) {
+	lock.RLock()
+	subs := subscriptions
+	lock.RUnlock()
+
+	if len(subs) == 0 {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	for _, s := range subs {
+		s.subscriber.Handle(e)
+	}
+}
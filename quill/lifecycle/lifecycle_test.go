@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	var got []string
+	first := Subscribe(SubscriberFunc(func(e Event) {
+		got = append(got, "first:"+e.Path)
+	}))
+	second := Subscribe(SubscriberFunc(func(e Event) {
+		got = append(got, "second:"+e.Path)
+	}))
+
+	require.True(t, Enabled())
+
+	Publish(Event{Type: ParseStarted, Path: "a"})
+	first()
+	Publish(Event{Type: ParseStarted, Path: "b"})
+	second()
+	Publish(Event{Type: ParseStarted, Path: "c"})
+
+	assert.Equal(t, []string{"first:a", "second:a", "second:b"}, got)
+	assert.False(t, Enabled())
+}
+
+func TestPublish_setsTime(t *testing.T) {
+	var got Event
+	unsubscribe := Subscribe(SubscriberFunc(func(e Event) {
+		got = e
+	}))
+	defer unsubscribe()
+
+	Publish(Event{Type: HashProgress, Progress: 0.5})
+
+	assert.Equal(t, HashProgress, got.Type)
+	assert.Equal(t, 0.5, got.Progress)
+	assert.False(t, got.Time.IsZero())
+}
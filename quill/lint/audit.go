@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anchore/quill/quill/extract"
+)
+
+// Rule identifiers for the checks made by Audit (in addition to RuleGetTaskAllow and RuleAdhocSignature).
+const (
+	RuleUnsignedExecutableMemory = "allow-unsigned-executable-memory"
+	RuleDevelopmentCertificate   = "development-certificate"
+)
+
+const allowUnsignedExecutableMemory = "com.apple.security.cs.allow-unsigned-executable-memory"
+
+// developmentCertificatePrefixes are the common name prefixes of the certificates Apple issues for development (these
+// are only trusted on the devices of the team and must not be used for shipped artifacts).
+var developmentCertificatePrefixes = []string{
+	"Apple Development:",
+	"Mac Developer:",
+	"iPhone Developer:",
+}
+
+// Audit checks the given binary (or every binary within the given release directory) for artifacts that must never
+// ship to customers: binaries with the get-task-allow or allow-unsigned-executable-memory entitlements, or binaries
+// that are ad-hoc signed or signed with a development (not Developer ID) certificate. Unsigned binaries are not
+// reported (see Lint for notarization readiness).
+func Audit(path string) ([]Finding, error) {
+	return run(path, audit)
+}
+
+func audit(d extract.Details, _ bool) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	sb := d.SuperBlob
+	if sb == nil || len(sb.CodeDirectories) == 0 {
+		return nil
+	}
+
+	flags, _ := sb.CodeDirectories[0].Flags.Uint()
+	certs := certificates(sb)
+
+	if flags&flagAdhoc != 0 || len(certs) == 0 {
+		add(RuleAdhocSignature, SeverityError, "binary is ad-hoc signed (it is not tied to a signing identity)")
+	} else if leaf := leafCertificate(certs); leaf != nil && leaf.Parsed != nil {
+		cn := leaf.Parsed.Subject.CommonName
+		switch {
+		case hasAnyPrefix(cn, developmentCertificatePrefixes):
+			add(RuleDevelopmentCertificate, SeverityError, "binary is signed with a development certificate (%s)", cn)
+		case !strings.HasPrefix(cn, developerIDPrefix):
+			add(RuleNotDeveloperID, SeverityWarning, "binary is not signed with a Developer ID Application certificate (%s)", cn)
+		}
+	}
+
+	for _, e := range sb.Entitlements {
+		format := strings.ToUpper(e.Format)
+		if v, ok := e.Entitlements[getTaskAllow].(bool); ok && v {
+			add(RuleGetTaskAllow, SeverityError, "the %s entitlement is set within the %s entitlements (allows other processes to attach a debugger)", getTaskAllow, format)
+		}
+		if v, ok := e.Entitlements[allowUnsignedExecutableMemory].(bool); ok && v {
+			add(RuleUnsignedExecutableMemory, SeverityError, "the %s entitlement is set within the %s entitlements (disables code signing protections of memory pages)", allowUnsignedExecutableMemory, format)
+		}
+	}
+
+	return findings
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
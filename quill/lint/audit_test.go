@@ -0,0 +1,61 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/extract"
+)
+
+func Test_audit(t *testing.T) {
+	const developerID = "Developer ID Application: Example (TEAM)"
+
+	tests := []struct {
+		name    string
+		details extract.Details
+		want    []string
+	}{
+		{
+			name:    "release build",
+			details: signed(flagRuntime, developerID, true, entitlements.Entitlements{"com.apple.security.cs.allow-jit": true}),
+		},
+		{
+			name:    "unsigned binaries are not reported",
+			details: extract.Details{},
+		},
+		{
+			name:    "ad-hoc signed",
+			details: signed(flagAdhoc, "", false, nil),
+			want:    []string{RuleAdhocSignature},
+		},
+		{
+			name:    "development certificate",
+			details: signed(flagRuntime, "Apple Development: Example (TEAM)", true, nil),
+			want:    []string{RuleDevelopmentCertificate},
+		},
+		{
+			name:    "other certificate",
+			details: signed(flagRuntime, "Example Corp Code Signing", true, nil),
+			want:    []string{RuleNotDeveloperID},
+		},
+		{
+			name: "debug entitlements",
+			details: signed(flagRuntime, developerID, true, entitlements.Entitlements{
+				getTaskAllow:                  true,
+				allowUnsignedExecutableMemory: true,
+			}),
+			want: []string{RuleGetTaskAllow, RuleUnsignedExecutableMemory},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []string
+			for _, f := range audit(tt.details, false) {
+				rules = append(rules, f.Rule)
+			}
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+	"gopkg.in/yaml.v3"
+
+	"github.com/anchore/quill/internal/machofind"
+	"github.com/anchore/quill/quill/extract"
+)
+
+// Severity indicates whether a finding will block notarization (error) or is likely to cause problems (warning).
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifiers for every check made by Lint.
+const (
+	RuleUnsignedCode           = "unsigned-code"
+	RuleAdhocSignature         = "adhoc-signature"
+	RuleNotDeveloperID         = "not-developer-id"
+	RuleMissingHardenedRuntime = "missing-hardened-runtime"
+	RuleMissingSecureTimestamp = "missing-secure-timestamp"
+	RuleGetTaskAllow           = "get-task-allow"
+	RuleEntitlementsMismatch   = "entitlements-mismatch"
+	RuleSHA1Only               = "sha1-only"
+	RuleSDKTooOld              = "sdk-too-old"
+	RuleLibraryValidation      = "library-validation-conflict"
+)
+
+// see https://developer.apple.com/documentation/security/notarizing_macos_software_before_distribution/resolving_common_notarization_issues
+const (
+	developerIDPrefix  = "Developer ID Application:"
+	getTaskAllow       = "com.apple.security.get-task-allow"
+	disableLV          = "com.apple.security.cs.disable-library-validation"
+	flagAdhoc          = 0x2
+	flagRequireLV      = 0x2000
+	flagRuntime        = 0x10000
+	minimumMacOSSDK    = "10.9"
+	macOSPlatform      = "macOS"
+	sha256HashTypeName = "sha256"
+)
+
+// Finding is a single notarization blocker (or likely problem) found within a binary.
+type Finding struct {
+	// Path is the binary the finding applies to (relative to the linted bundle, if a directory was given).
+	Path         string   `json:"path"`
+	Architecture string   `json:"architecture,omitempty"`
+	Rule         string   `json:"rule"`
+	Severity     Severity `json:"severity"`
+	Message      string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s [%s] %s", f.Severity, f.location(), f.Rule, f.Message)
+}
+
+func (f Finding) location() string {
+	return location(f.Path, f.Architecture)
+}
+
+func location(path, arch string) string {
+	if arch == "" {
+		return path
+	}
+	return fmt.Sprintf("%s (%s)", path, arch)
+}
+
+// Errors returns the findings that will block notarization.
+func Errors(findings []Finding) []Finding {
+	var results []Finding
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			results = append(results, f)
+		}
+	}
+	return results
+}
+
+// Lint checks the given binary (or every binary within the given bundle directory) for the most common reasons that
+// a notarization submission is rejected: unsigned (nested) code, ad-hoc or non Developer ID signatures, a missing
+// hardened runtime or secure timestamp, the get-task-allow entitlement, and a too old SDK.
+func Lint(path string) ([]Finding, error) {
+	return run(path, check)
+}
+
+// checker returns the findings for a single (single-arch) binary, nested is true if the binary was found within a
+// directory.
+type checker func(d extract.Details, nested bool) []Finding
+
+func run(path string, c checker) ([]Finding, error) {
+	var findings []Finding
+	err := walk(path, func(b binary) {
+		for _, f := range c(b.details, b.nested) {
+			f.Path = b.name
+			f.Architecture = b.arch
+			findings = append(findings, f)
+		}
+	})
+	return findings, err
+}
+
+// binary is a single (single-arch) binary found within the linted path.
+type binary struct {
+	// name is the path of the file relative to the linted directory (or the file name of a linted file).
+	name string
+	// arch is the CPU of the binary, only set for multi-arch files.
+	arch    string
+	nested  bool
+	details extract.Details
+}
+
+// walk calls the given function for every binary within the given file (or within every macho file of the given
+// directory).
+func walk(path string, fn func(binary)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("unable to lint %q: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return walkFile(path, filepath.Base(path), false, fn)
+	}
+
+	files, err := machofind.Binaries(path)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no darwin binaries found within %q", path)
+	}
+
+	for _, f := range files {
+		rel, err := filepath.Rel(path, f)
+		if err != nil {
+			rel = f
+		}
+		if err := walkFile(f, filepath.ToSlash(rel), true, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkFile(path, name string, nested bool, fn func(binary)) error {
+	allDetails, err := extract.ParseAllDetails(path)
+	if err != nil {
+		return fmt.Errorf("unable to describe %q: %w", path, err)
+	}
+
+	for _, d := range allDetails {
+		b := binary{name: name, nested: nested, details: d}
+		if len(allDetails) > 1 {
+			b.arch = d.File.CPU
+		}
+		fn(b)
+	}
+	return nil
+}
+
+// check runs every rule against the details of a single (single-arch) binary.
+//
+//nolint:funlen
+func check(d extract.Details, nested bool) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	findings = append(findings, checkSDK(d.File.Build)...)
+
+	sb := d.SuperBlob
+	if sb == nil || len(sb.CodeDirectories) == 0 {
+		if nested {
+			add(RuleUnsignedCode, SeverityError, "nested code is not signed (every binary within a bundle must be signed)")
+		} else {
+			add(RuleUnsignedCode, SeverityError, "binary is not signed")
+		}
+		return findings
+	}
+
+	cd := sb.CodeDirectories[0]
+	flags, _ := cd.Flags.Uint()
+
+	certs := certificates(sb)
+
+	var timestamped bool
+	for _, sig := range sb.Signatures {
+		for _, s := range sig.Signers {
+			if s.Timestamp != nil {
+				timestamped = true
+			}
+		}
+	}
+
+	switch {
+	case flags&flagAdhoc != 0 || len(certs) == 0:
+		add(RuleAdhocSignature, SeverityError, "binary is ad-hoc signed (notarization requires a Developer ID Application certificate)")
+	default:
+		if leaf := leafCertificate(certs); leaf == nil || leaf.Parsed == nil || !strings.HasPrefix(leaf.Parsed.Subject.CommonName, developerIDPrefix) {
+			subject := "(no leaf certificate)"
+			switch {
+			case leaf == nil:
+			case leaf.Summary.Subject == "":
+				// e.g. short-lived Sigstore certificates, where the identity is only within the subject alternative names
+				subject = "a certificate with an empty subject"
+			default:
+				subject = leaf.Summary.Subject
+			}
+			add(RuleNotDeveloperID, SeverityError, "binary is not signed with a Developer ID Application certificate (signed by %s)", subject)
+		}
+		if !timestamped {
+			add(RuleMissingSecureTimestamp, SeverityError, "signature does not include a secure timestamp")
+		}
+	}
+
+	if flags&flagRuntime == 0 {
+		add(RuleMissingHardenedRuntime, SeverityError, "hardened runtime is not enabled (sign with the runtime flag)")
+	}
+
+	var hasSHA256 bool
+	for _, c := range sb.CodeDirectories {
+		if c.HashType == sha256HashTypeName {
+			hasSHA256 = true
+		}
+	}
+	if !hasSHA256 {
+		add(RuleSHA1Only, SeverityError, "signature does not include a sha256 code directory")
+	}
+
+	for _, e := range sb.Entitlements {
+		if v, ok := e.Entitlements[getTaskAllow].(bool); ok && v {
+			add(RuleGetTaskAllow, SeverityError, "the %s entitlement is set within the %s entitlements (debug builds cannot be notarized)", getTaskAllow, strings.ToUpper(e.Format))
+		}
+		if v, ok := e.Entitlements[disableLV].(bool); ok && v && flags&flagRequireLV != 0 {
+			add(RuleLibraryValidation, SeverityWarning, "library validation is required by the code directory flags, yet the %s entitlement is set within the %s entitlements (the flag takes precedence, so libraries signed by other teams fail to load)", disableLV, strings.ToUpper(e.Format))
+		}
+	}
+
+	for _, disc := range sb.EntitlementsDiscrepancies {
+		add(RuleEntitlementsMismatch, SeverityWarning, "XML and DER entitlements differ: %s", disc)
+	}
+
+	return findings
+}
+
+func checkSDK(build *extract.BuildDetails) []Finding {
+	if build == nil || build.Platform != macOSPlatform || build.SDK == "" {
+		return nil
+	}
+	if compareVersions(build.SDK, minimumMacOSSDK) < 0 {
+		return []Finding{{
+			Rule:     RuleSDKTooOld,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("binary was built with the macOS %s SDK (notarization requires the macOS %s SDK or later)", build.SDK, minimumMacOSSDK),
+		}}
+	}
+	return nil
+}
+
+// certificates returns the certificates of every CMS signature within the superblob.
+func certificates(sb *extract.SuperBlobDetails) []extract.Certificate {
+	var certs []extract.Certificate
+	for _, sig := range sb.Signatures {
+		certs = append(certs, sig.Certificates...)
+	}
+	return certs
+}
+
+func leafCertificate(certs []extract.Certificate) *extract.Certificate {
+	for i := range certs {
+		if !certs[i].Summary.IsCA {
+			return &certs[i]
+		}
+	}
+	return nil
+}
+
+// compareVersions compares two dotted versions (e.g. "10.9" and "10.15.1"), missing components are treated as zero.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		av, bv := versionComponent(as, i), versionComponent(bs, i)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionComponent(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	v, err := strconv.Atoi(parts[i])
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// Show writes the findings in the given format ("text", "json", or "yaml").
+func Show(findings []Finding, writer io.Writer, format string) error {
+	if findings == nil {
+		// always render an (empty) list for machine readable formats
+		findings = []Finding{}
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return encodeJSON(findings, writer)
+	case "yaml":
+		return encodeYAML(findings, writer)
+	case "text":
+		if len(findings) == 0 {
+			return nil
+		}
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"Severity", "Path", "Rule", "Message"})
+		for _, f := range findings {
+			t.AppendRow(table.Row{f.Severity, f.location(), f.Rule, f.Message})
+		}
+		_, err := io.WriteString(writer, t.Render()+"\n")
+		return err
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func encodeJSON(v interface{}, writer io.Writer) error {
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func encodeYAML(v interface{}, writer io.Writer) error {
+	enc := yaml.NewEncoder(writer)
+	defer enc.Close()
+	return enc.Encode(v)
+}
@@ -0,0 +1,141 @@
+package lint
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/extract"
+)
+
+// signed returns the details of a binary signed with the given code directory flags and (leaf) certificate.
+func signed(flags uint32, cn string, timestamped bool, ents entitlements.Entitlements) extract.Details {
+	var signers []extract.Signer
+	if timestamped {
+		signers = append(signers, extract.Signer{Timestamp: &extract.TimestampDetails{}})
+	} else {
+		signers = append(signers, extract.Signer{})
+	}
+	var certs []extract.Certificate
+	if cn != "" {
+		certs = append(certs, extract.Certificate{
+			Summary: extract.CertificateSummary{Subject: "CN=" + cn},
+			Parsed:  &x509.Certificate{Subject: pkix.Name{CommonName: cn}},
+		})
+	}
+	return extract.Details{
+		File: extract.MachoDetails{
+			Build: &extract.BuildDetails{Platform: "macOS", MinOS: "11.0", SDK: "13.1"},
+		},
+		SuperBlob: &extract.SuperBlobDetails{
+			CodeDirectories: []extract.CodeDirectoryDetails{
+				{HashType: "sha256", Flags: extract.DescribedValue{Value: flags}},
+			},
+			Entitlements: []extract.EntitlementDetails{
+				{Format: "xml", Entitlements: ents},
+			},
+			Signatures: []extract.SignatureDetails{
+				{Certificates: certs, Signers: signers},
+			},
+		},
+	}
+}
+
+func Test_check(t *testing.T) {
+	oldSDK := signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, nil)
+	oldSDK.File.Build = &extract.BuildDetails{Platform: "macOS", MinOS: "10.6", SDK: "10.8"}
+
+	sha1Only := signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, nil)
+	sha1Only.SuperBlob.CodeDirectories[0].HashType = "sha1"
+
+	tests := []struct {
+		name    string
+		details extract.Details
+		nested  bool
+		want    []string
+	}{
+		{
+			name:    "notarizable",
+			details: signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{"com.apple.security.cs.allow-jit": true}),
+		},
+		{
+			name:    "unsigned",
+			details: extract.Details{},
+			want:    []string{RuleUnsignedCode},
+		},
+		{
+			name:    "unsigned nested code",
+			details: extract.Details{},
+			nested:  true,
+			want:    []string{RuleUnsignedCode},
+		},
+		{
+			name:    "ad-hoc signed",
+			details: signed(flagAdhoc, "", false, nil),
+			want:    []string{RuleAdhocSignature, RuleMissingHardenedRuntime},
+		},
+		{
+			name:    "development certificate without a timestamp",
+			details: signed(flagRuntime, "Apple Development: Example (TEAM)", false, nil),
+			want:    []string{RuleNotDeveloperID, RuleMissingSecureTimestamp},
+		},
+		{
+			name:    "get-task-allow",
+			details: signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{getTaskAllow: true}),
+			want:    []string{RuleGetTaskAllow},
+		},
+		{
+			name:    "get-task-allow disabled",
+			details: signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{getTaskAllow: false}),
+		},
+		{
+			name:    "library validation with the disable-library-validation entitlement",
+			details: signed(flagRuntime|flagRequireLV, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{disableLV: true}),
+			want:    []string{RuleLibraryValidation},
+		},
+		{
+			name:    "disable-library-validation without library validation",
+			details: signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{disableLV: true}),
+		},
+		{
+			name:    "old SDK",
+			details: oldSDK,
+			want:    []string{RuleSDKTooOld},
+		},
+		{
+			name:    "sha1 only",
+			details: sha1Only,
+			want:    []string{RuleSHA1Only},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []string
+			for _, f := range check(tt.details, tt.nested) {
+				rules = append(rules, f.Rule)
+			}
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+}
+
+func Test_compareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "10.9", b: "10.9", want: 0},
+		{a: "10.9.0", b: "10.9", want: 0},
+		{a: "10.8", b: "10.9", want: -1},
+		{a: "10.15", b: "10.9", want: 1},
+		{a: "11.0", b: "10.9", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
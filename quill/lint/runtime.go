@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/extract"
+)
+
+// runtimeExceptions are the entitlements that relax the protections of the hardened runtime, see
+// https://developer.apple.com/documentation/security/hardened_runtime
+var runtimeExceptions = []string{
+	"com.apple.security.cs.allow-jit",
+	"com.apple.security.cs.allow-unsigned-executable-memory",
+	"com.apple.security.cs.allow-dyld-environment-variables",
+	"com.apple.security.cs.disable-library-validation",
+	"com.apple.security.cs.disable-executable-page-protection",
+	"com.apple.security.cs.debugger",
+	getTaskAllow,
+}
+
+// resourceAccessEntitlements are the entitlements that grant a hardened runtime process access to protected resources.
+var resourceAccessEntitlements = []string{
+	"com.apple.security.device.audio-input",
+	"com.apple.security.device.camera",
+	"com.apple.security.personal-information.location",
+	"com.apple.security.personal-information.addressbook",
+	"com.apple.security.personal-information.calendars",
+	"com.apple.security.personal-information.photos-library",
+	"com.apple.security.automation.apple-events",
+}
+
+// RuntimeReport describes the hardened runtime posture of a single binary.
+type RuntimeReport struct {
+	Path         string `json:"path"`
+	Architecture string `json:"architecture,omitempty"`
+	Signed       bool   `json:"signed"`
+	// Runtime indicates that the hardened runtime (CS_RUNTIME) flag is set on the code directory.
+	Runtime bool `json:"runtime"`
+	// RuntimeVersion is the SDK version the hardened runtime behavior is based on (empty when not recorded).
+	RuntimeVersion string `json:"runtimeVersion,omitempty"`
+	// Exceptions are the runtime exception entitlements that are enabled.
+	Exceptions []string `json:"exceptions"`
+	// ResourceAccess are the resource access entitlements that are enabled.
+	ResourceAccess []string `json:"resourceAccess"`
+}
+
+// HardenedRuntime reports the hardened runtime facts (CS_RUNTIME flag, runtime version, and every runtime exception
+// and resource access entitlement) of the given binary, or of every binary within the given directory.
+func HardenedRuntime(path string) ([]RuntimeReport, error) {
+	var reports []RuntimeReport
+	err := walk(path, func(b binary) {
+		r := runtimeReport(b.details)
+		r.Path = b.name
+		r.Architecture = b.arch
+		reports = append(reports, r)
+	})
+	return reports, err
+}
+
+func runtimeReport(d extract.Details) RuntimeReport {
+	r := RuntimeReport{
+		Exceptions:     []string{},
+		ResourceAccess: []string{},
+	}
+
+	sb := d.SuperBlob
+	if sb == nil || len(sb.CodeDirectories) == 0 {
+		return r
+	}
+	r.Signed = true
+
+	cd := sb.CodeDirectories[0]
+	flags, _ := cd.Flags.Uint()
+	r.Runtime = flags&flagRuntime != 0
+	r.RuntimeVersion = cd.RuntimeVersion
+
+	// the XML and DER entitlements should be identical, but any exception enabled in either is reported
+	var ents []entitlements.Entitlements
+	for _, e := range sb.Entitlements {
+		ents = append(ents, e.Entitlements)
+	}
+	r.Exceptions = enabled(ents, runtimeExceptions)
+	r.ResourceAccess = enabled(ents, resourceAccessEntitlements)
+
+	return r
+}
+
+// enabled returns the given keys that are set to true in any of the given entitlements.
+func enabled(ents []entitlements.Entitlements, keys []string) []string {
+	results := []string{}
+	for _, key := range keys {
+		for _, e := range ents {
+			if v, ok := e[key].(bool); ok && v {
+				results = append(results, key)
+				break
+			}
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// ShowHardenedRuntime writes the hardened runtime reports in the given format ("text", "json", or "yaml").
+func ShowHardenedRuntime(reports []RuntimeReport, writer io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return encodeJSON(reports, writer)
+	case "yaml":
+		return encodeYAML(reports, writer)
+	case "text":
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"Path", "Runtime", "Runtime Version", "Exceptions", "Resource Access"})
+		for _, r := range reports {
+			runtime := "no"
+			switch {
+			case !r.Signed:
+				runtime = "(unsigned)"
+			case r.Runtime:
+				runtime = "yes"
+			}
+			t.AppendRow(table.Row{
+				location(r.Path, r.Architecture),
+				runtime,
+				r.RuntimeVersion,
+				strings.Join(r.Exceptions, "\n"),
+				strings.Join(r.ResourceAccess, "\n"),
+			})
+		}
+		_, err := io.WriteString(writer, t.Render()+"\n")
+		return err
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/extract"
+)
+
+func Test_runtimeReport(t *testing.T) {
+	withRuntimeVersion := signed(flagRuntime, "Developer ID Application: Example (TEAM)", true, entitlements.Entitlements{
+		"com.apple.security.cs.allow-jit":                  true,
+		"com.apple.security.cs.disable-library-validation": true,
+		"com.apple.security.cs.debugger":                   false,
+		"com.apple.security.device.camera":                 true,
+		"com.apple.security.application-groups":            []interface{}{"group.example"},
+	})
+	withRuntimeVersion.SuperBlob.CodeDirectories[0].RuntimeVersion = "13.1.0"
+
+	tests := []struct {
+		name    string
+		details extract.Details
+		want    RuntimeReport
+	}{
+		{
+			name:    "unsigned",
+			details: extract.Details{},
+			want:    RuntimeReport{Exceptions: []string{}, ResourceAccess: []string{}},
+		},
+		{
+			name:    "signed without the hardened runtime",
+			details: signed(flagAdhoc, "", false, nil),
+			want:    RuntimeReport{Signed: true, Exceptions: []string{}, ResourceAccess: []string{}},
+		},
+		{
+			name:    "hardened runtime with exceptions",
+			details: withRuntimeVersion,
+			want: RuntimeReport{
+				Signed:         true,
+				Runtime:        true,
+				RuntimeVersion: "13.1.0",
+				Exceptions: []string{
+					"com.apple.security.cs.allow-jit",
+					"com.apple.security.cs.disable-library-validation",
+				},
+				ResourceAccess: []string{"com.apple.security.device.camera"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, runtimeReport(tt.details))
+		})
+	}
+}
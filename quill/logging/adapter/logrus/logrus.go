@@ -0,0 +1,38 @@
+// Package logrus adapts a logrus logger to the logging.Logger interface. This lives in its own package so only
+// consumers using logrus depend on it.
+package logrus
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anchore/quill/quill/logging"
+)
+
+// New adapts the given logrus logger (or entry).
+func New(l logrus.FieldLogger) logging.Logger {
+	return logging.LoggerFunc(func(level logging.Level, msg string, fields ...interface{}) {
+		entry := l.WithFields(toFields(fields))
+		switch level {
+		case logging.ErrorLevel:
+			entry.Error(msg)
+		case logging.WarnLevel:
+			entry.Warn(msg)
+		case logging.InfoLevel:
+			entry.Info(msg)
+		case logging.DebugLevel:
+			entry.Debug(msg)
+		default:
+			entry.Trace(msg)
+		}
+	})
+}
+
+func toFields(keysAndValues []interface{}) logrus.Fields {
+	fields := logrus.Fields{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+	return fields
+}
@@ -0,0 +1,24 @@
+package logrus
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/logging"
+)
+
+func TestNew(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	l.SetLevel(logrus.TraceLevel)
+
+	New(l).Log(logging.WarnLevel, "expiring", "days", 10)
+
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Equal(t, "expiring", hook.LastEntry().Message)
+	assert.Equal(t, logrus.Fields{"days": 10}, hook.LastEntry().Data)
+}
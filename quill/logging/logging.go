@@ -0,0 +1,166 @@
+/*
+Package logging allows library consumers to route quill's log output into the logging of the host application. Any
+logger can be plugged in by implementing the single method Logger interface; adapters are provided for log/slog
+(Slog), zap's sugared logger (Zap), and logrus (see the adapter/logrus package). Quill does not log anything until a
+logger is set.
+*/
+package logging
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anchore/go-logger"
+	"github.com/anchore/go-logger/adapter/discard"
+	"github.com/anchore/go-logger/adapter/redact"
+	"github.com/anchore/quill/internal/log"
+	intRedact "github.com/anchore/quill/internal/redact"
+)
+
+type Level int
+
+const (
+	ErrorLevel Level = iota
+	WarnLevel
+	InfoLevel
+	DebugLevel
+	TraceLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case ErrorLevel:
+		return "error"
+	case WarnLevel:
+		return "warn"
+	case InfoLevel:
+		return "info"
+	case DebugLevel:
+		return "debug"
+	case TraceLevel:
+		return "trace"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Logger receives every log entry emitted by quill. Fields are alternating key and value pairs (as with log/slog and
+// zap's sugared logger). Filtering by level is left to the logger.
+type Logger interface {
+	Log(level Level, msg string, fields ...interface{})
+}
+
+// LoggerFunc adapts a function to the Logger interface.
+type LoggerFunc func(level Level, msg string, fields ...interface{})
+
+func (f LoggerFunc) Log(level Level, msg string, fields ...interface{}) {
+	f(level, msg, fields...)
+}
+
+// Set routes all log output of quill to the given logger (nil disables logging). Secrets read by quill (such as
+// passwords and keys) are redacted from messages and fields before reaching the logger.
+func Set(l Logger) {
+	if l == nil {
+		log.Set(discard.New())
+		return
+	}
+
+	if intRedact.Get() == nil {
+		intRedact.Set(redact.NewStore())
+	}
+	log.Set(adapter{logger: l})
+}
+
+var _ logger.Logger = (*adapter)(nil)
+
+// adapter implements the logger interface used throughout quill on top of a Logger.
+type adapter struct {
+	logger Logger
+	fields []interface{}
+}
+
+func (a adapter) log(level Level, msg string) {
+	a.logger.Log(level, msg, a.fields...)
+}
+
+func (a adapter) Errorf(format string, args ...interface{}) {
+	a.log(ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (a adapter) Error(args ...interface{}) {
+	a.log(ErrorLevel, fmt.Sprint(args...))
+}
+
+func (a adapter) Warnf(format string, args ...interface{}) {
+	a.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (a adapter) Warn(args ...interface{}) {
+	a.log(WarnLevel, fmt.Sprint(args...))
+}
+
+func (a adapter) Infof(format string, args ...interface{}) {
+	a.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+func (a adapter) Info(args ...interface{}) {
+	a.log(InfoLevel, fmt.Sprint(args...))
+}
+
+func (a adapter) Debugf(format string, args ...interface{}) {
+	a.log(DebugLevel, fmt.Sprintf(format, args...))
+}
+
+func (a adapter) Debug(args ...interface{}) {
+	a.log(DebugLevel, fmt.Sprint(args...))
+}
+
+func (a adapter) Tracef(format string, args ...interface{}) {
+	a.log(TraceLevel, fmt.Sprintf(format, args...))
+}
+
+func (a adapter) Trace(args ...interface{}) {
+	a.log(TraceLevel, fmt.Sprint(args...))
+}
+
+func (a adapter) WithFields(fields ...interface{}) logger.MessageLogger {
+	return a.with(fields)
+}
+
+func (a adapter) Nested(fields ...interface{}) logger.Logger {
+	return a.with(fields)
+}
+
+func (a adapter) with(fields []interface{}) adapter {
+	combined := append([]interface{}(nil), a.fields...)
+	return adapter{
+		logger: a.logger,
+		fields: append(combined, flatten(fields)...),
+	}
+}
+
+// flatten converts fields given either as key and value pairs or as logger.Fields maps into key and value pairs.
+func flatten(fields []interface{}) []interface{} {
+	var results []interface{}
+	for i := 0; i < len(fields); i++ {
+		if m, ok := fields[i].(logger.Fields); ok {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				results = append(results, k, m[k])
+			}
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			// a key without a value
+			results = append(results, fmt.Sprint(fields[i]), nil)
+			break
+		}
+		results = append(results, fmt.Sprint(fields[i]), fields[i+1])
+		i++
+	}
+	return results
+}
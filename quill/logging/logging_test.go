@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anchore/go-logger"
+	"github.com/anchore/quill/internal/log"
+)
+
+type entry struct {
+	level  Level
+	msg    string
+	fields []interface{}
+}
+
+func TestSet(t *testing.T) {
+	var got []entry
+	Set(LoggerFunc(func(level Level, msg string, fields ...interface{}) {
+		got = append(got, entry{level: level, msg: msg, fields: fields})
+	}))
+	defer Set(nil)
+
+	log.Infof("signing %s", "bin")
+	log.WithFields("binary", "bin", "arch", "arm64").Debug("hashing")
+	log.Nested("binary", "bin").WithFields(logger.Fields{"b": 2, "a": 1}).Trace("nested")
+	log.Warn("dangling", " args")
+
+	assert.Equal(t, []entry{
+		{level: InfoLevel, msg: "signing bin"},
+		{level: DebugLevel, msg: "hashing", fields: []interface{}{"binary", "bin", "arch", "arm64"}},
+		{level: TraceLevel, msg: "nested", fields: []interface{}{"binary", "bin", "a", 1, "b", 2}},
+		{level: WarnLevel, msg: "dangling args"},
+	}, got)
+}
+
+func Test_flatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "key value pairs",
+			fields: []interface{}{"a", 1, "b", "two"},
+			want:   []interface{}{"a", 1, "b", "two"},
+		},
+		{
+			name:   "key without a value",
+			fields: []interface{}{"a", 1, "b"},
+			want:   []interface{}{"a", 1, "b", nil},
+		},
+		{
+			name:   "fields map",
+			fields: []interface{}{logger.Fields{"z": 1, "y": 2}, "a", 3},
+			want:   []interface{}{"y", 2, "z", 1, "a", 3},
+		},
+		{
+			name:   "non-string keys",
+			fields: []interface{}{1, 2},
+			want:   []interface{}{"1", 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flatten(tt.fields))
+		})
+	}
+}
+
+type sugared struct {
+	got []string
+}
+
+func (s *sugared) record(level, msg string, kv []interface{}) {
+	s.got = append(s.got, fmt.Sprintf("%s %s %v", level, msg, kv))
+}
+
+func (s *sugared) Errorw(msg string, kv ...interface{}) { s.record("error", msg, kv) }
+func (s *sugared) Warnw(msg string, kv ...interface{})  { s.record("warn", msg, kv) }
+func (s *sugared) Infow(msg string, kv ...interface{})  { s.record("info", msg, kv) }
+func (s *sugared) Debugw(msg string, kv ...interface{}) { s.record("debug", msg, kv) }
+
+func TestZap(t *testing.T) {
+	s := &sugared{}
+	l := Zap(s)
+
+	l.Log(ErrorLevel, "failed", "binary", "bin")
+	l.Log(InfoLevel, "signed")
+	l.Log(TraceLevel, "detail")
+
+	assert.Equal(t, []string{
+		"error failed [binary bin]",
+		"info signed []",
+		"debug detail []",
+	}, s.got)
+}
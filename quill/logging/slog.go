@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelTrace is the slog level used for quill trace entries (below slog.LevelDebug).
+const LevelTrace = slog.LevelDebug - 4
+
+// Slog adapts a log/slog logger.
+func Slog(l *slog.Logger) Logger {
+	return LoggerFunc(func(level Level, msg string, fields ...interface{}) {
+		l.Log(context.Background(), slogLevel(level), msg, fields...)
+	})
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case ErrorLevel:
+		return slog.LevelError
+	case WarnLevel:
+		return slog.LevelWarn
+	case InfoLevel:
+		return slog.LevelInfo
+	case DebugLevel:
+		return slog.LevelDebug
+	}
+	return LevelTrace
+}
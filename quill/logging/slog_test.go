@@ -0,0 +1,29 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	l := Slog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: LevelTrace,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	l.Log(WarnLevel, "expiring", "days", 10)
+	l.Log(TraceLevel, "hashed")
+
+	assert.Equal(t, "level=WARN msg=expiring days=10\nlevel=DEBUG-4 msg=hashed\n", buf.String())
+}
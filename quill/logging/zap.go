@@ -0,0 +1,27 @@
+package logging
+
+// SugaredLogger is the subset of the zap.SugaredLogger methods used by the Zap adapter (this avoids a dependency on
+// zap).
+type SugaredLogger interface {
+	Errorw(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+}
+
+// Zap adapts a zap sugared logger (e.g. zap.L().Sugar()). Zap has no trace level, trace entries are logged at the
+// debug level.
+func Zap(l SugaredLogger) Logger {
+	return LoggerFunc(func(level Level, msg string, fields ...interface{}) {
+		switch level {
+		case ErrorLevel:
+			l.Errorw(msg, fields...)
+		case WarnLevel:
+			l.Warnw(msg, fields...)
+		case InfoLevel:
+			l.Infow(msg, fields...)
+		default:
+			l.Debugw(msg, fields...)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package macho
 
 import (
 	"fmt"
+	"io"
 	"unsafe"
 
 	"github.com/go-restruct/restruct"
@@ -25,12 +26,54 @@ func NewBlob(m Magic, p []byte) Blob {
 	return Blob{
 		BlobHeader: BlobHeader{
 			Magic:  m,
-			Length: uint32(len(p) + int(unsafe.Sizeof(Blob{}.Magic)) + int(unsafe.Sizeof(Blob{}.Length))),
+			Length: uint32(len(p) + blobHeaderSize),
 		},
 		Payload: p,
 	}
 }
 
+// blobHeaderSize is the size of the magic and length framing every blob.
+const blobHeaderSize = int(unsafe.Sizeof(BlobHeader{}))
+
+// ParseBlob reads the blob at the start of the given bytes: the magic and length framing followed by the payload (the
+// bytes past the length of the blob are ignored, e.g. the remainder of a superblob).
+func ParseBlob(b []byte) (Blob, error) {
+	if len(b) < blobHeaderSize {
+		return Blob{}, malformed("blob", "too short (%d bytes)", len(b))
+	}
+	header := BlobHeader{
+		Magic:  Magic(SigningOrder.Uint32(b[0:])),
+		Length: SigningOrder.Uint32(b[4:]),
+	}
+	if int(header.Length) < blobHeaderSize || uint64(header.Length) > uint64(len(b)) {
+		return Blob{}, malformed("blob", "0x%x has an invalid length (%d of %d bytes)", uint32(header.Magic), header.Length, len(b))
+	}
+	return Blob{BlobHeader: header, Payload: b[blobHeaderSize:header.Length]}, nil
+}
+
+// UnwrapBlob returns the payload of the given blob, which must have the given magic.
+func UnwrapBlob(b []byte, magic Magic) ([]byte, error) {
+	blob, err := ParseBlob(b)
+	if err != nil {
+		return nil, err
+	}
+	if blob.Magic != magic {
+		return nil, malformed("blob", "unexpected magic 0x%x (expected %s)", uint32(blob.Magic), magic.Name())
+	}
+	return blob.Payload, nil
+}
+
+// NewBlobWrapper wraps arbitrary content (e.g. the DER encoded CMS signature) into a blob wrapper, the blob stored in
+// the CMS signature slot.
+func NewBlobWrapper(content []byte) Blob {
+	return NewBlob(MagicBlobwrapper, content)
+}
+
+// UnwrapBlobWrapper returns the content of the given blob wrapper (e.g. the DER encoded CMS signature).
+func UnwrapBlobWrapper(b []byte) ([]byte, error) {
+	return UnwrapBlob(b, MagicBlobwrapper)
+}
+
 func (b Blob) Pack() ([]byte, error) {
 	by, err := restruct.Pack(SigningOrder, &b)
 	if err != nil {
@@ -38,3 +81,17 @@ func (b Blob) Pack() ([]byte, error) {
 	}
 	return by, err
 }
+
+// WriteTo writes the encoded blob (as Pack does) without materializing it, e.g. to hash a large code directory.
+func (b Blob) WriteTo(w io.Writer) (int64, error) {
+	var header [8]byte
+	SigningOrder.PutUint32(header[0:], uint32(b.Magic))
+	SigningOrder.PutUint32(header[4:], b.Length)
+
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(b.Payload)
+	return int64(n + m), err
+}
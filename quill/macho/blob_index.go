@@ -1,5 +1,7 @@
 package macho
 
+import "fmt"
+
 // Definitions From: https://github.com/Apple-FOSS-Mirror/Security/blob/5bcad85836c8bbb383f660aaf25b555a805a48e4/OSX/sec/Security/Tool/codesign.c#L53-L89
 
 const (
@@ -23,3 +25,34 @@ type BlobIndex struct {
 	Type   SlotType // type of entry
 	Offset uint32   // offset of entry (relative to superblob file offset)
 }
+
+// Name describes the slot (e.g. "code directory"), "unknown" for slots quill does not know about.
+func (t SlotType) Name() string {
+	switch {
+	case t == CsSlotCodedirectory:
+		return "code directory"
+	case t == CsSlotInfoslot:
+		return "info plist"
+	case t == CsSlotRequirements:
+		return "requirements"
+	case t == CsSlotResourcedir:
+		return "resource directory"
+	case t == CsSlotApplication:
+		return "application"
+	case t == CsSlotEntitlements:
+		return "entitlements"
+	case t == CsSlotRepSpecific:
+		return "rep specific"
+	case t == CsSlotEntitlementsDer:
+		return "entitlements (DER)"
+	case t >= CsSlotAlternateCodedirectories && t < CsSlotAlternateCodedirectoryLimit:
+		return fmt.Sprintf("alternate code directory %d", t-CsSlotAlternateCodedirectories)
+	case t == CsSlotCmsSignature:
+		return "CMS signature"
+	case t == CsSlotIdentificationslot:
+		return "identification"
+	case t == CsSlotTicketslot:
+		return "ticket"
+	}
+	return "unknown"
+}
@@ -1,9 +1,11 @@
 package macho
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewBlob(t *testing.T) {
@@ -37,3 +39,46 @@ func TestNewBlob(t *testing.T) {
 		})
 	}
 }
+
+func TestBlob_WriteTo(t *testing.T) {
+	for _, b := range []Blob{
+		NewBlob(MagicCodedirectory, []byte("payload!")),
+		NewBlob(MagicRequirements, nil),
+	} {
+		expected, err := b.Pack()
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		n, err := b.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(expected)), n)
+		assert.Equal(t, expected, buf.Bytes())
+	}
+}
+
+func TestParseBlob(t *testing.T) {
+	wrapped, err := NewBlobWrapper([]byte("cms")).Pack()
+	require.NoError(t, err)
+
+	// bytes following the blob are not part of it
+	blob, err := ParseBlob(append(wrapped, 0, 0, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, NewBlobWrapper([]byte("cms")), blob)
+
+	content, err := UnwrapBlobWrapper(wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cms"), content)
+
+	_, err = UnwrapBlob(wrapped, MagicEmbeddedEntitlements)
+	require.Error(t, err)
+
+	for _, invalid := range [][]byte{
+		nil,
+		wrapped[:4],
+		wrapped[:len(wrapped)-1],
+		{0xfa, 0xde, 0x0b, 0x01, 0, 0, 0, 4},
+	} {
+		_, err := ParseBlob(invalid)
+		assert.Error(t, err, "%x", invalid)
+	}
+}
@@ -0,0 +1,92 @@
+package macho
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-restruct/restruct"
+)
+
+const (
+	LcVersionMinMacosx   LoadCommandType = 0x24
+	LcVersionMinIphoneos LoadCommandType = 0x25
+	LcVersionMinTvos     LoadCommandType = 0x2f
+	LcVersionMinWatchos  LoadCommandType = 0x30
+	LcBuildVersion       LoadCommandType = 0x32
+)
+
+// Version is a version packed as xxxx.yy.zz (e.g. 0x000c0100 is 12.1.0), as found in the build version load commands
+// and the runtime version of a code directory.
+type Version uint32
+
+func NewVersion(major uint16, minor, patch uint8) Version {
+	return Version(uint32(major)<<16 | uint32(minor)<<8 | uint32(patch))
+}
+
+// ParseVersion parses a dotted version with a major, minor, and optional patch number (e.g. "12.1").
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid version %q: expected MAJOR.MINOR[.PATCH]", s)
+	}
+	bitSizes := []int{16, 8, 8}
+	var numbers [3]uint64
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, bitSizes[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		numbers[i] = n
+	}
+	return NewVersion(uint16(numbers[0]), uint8(numbers[1]), uint8(numbers[2])), nil
+}
+
+func (v Version) String() string {
+	major, minor, patch := uint32(v)>>16, uint32(v)>>8&0xff, uint32(v)&0xff
+	if patch == 0 {
+		return fmt.Sprintf("%d.%d", major, minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+}
+
+// BuildVersionCommand is the Mach-O LcBuildVersion load command (the build tool entries which follow it are left out).
+type BuildVersionCommand struct {
+	Cmd      LoadCommandType // LcBuildVersion
+	Size     uint32          // sizeof this command, including the build tool entries
+	Platform uint32
+	MinOS    Version
+	SDK      Version
+	NTools   uint32
+}
+
+// VersionMinCommand is one of the (older) Mach-O LcVersionMin* load commands, which LcBuildVersion superseded.
+type VersionMinCommand struct {
+	Cmd     LoadCommandType
+	Size    uint32 // sizeof this command (16)
+	Version Version
+	SDK     Version
+}
+
+// SDKVersion returns the version of the SDK the binary was built with, as recorded by the LcBuildVersion (or older
+// LcVersionMin*) load command. Zero is returned when the binary does not record it.
+func (m *File) SDKVersion() (Version, error) {
+	for _, l := range m.Loads {
+		data := l.Raw()
+		switch LoadCommandType(m.ByteOrder.Uint32(data)) {
+		case LcBuildVersion:
+			var value BuildVersionCommand
+			if err := restruct.Unpack(data, m.ByteOrder, &value); err != nil {
+				return 0, fmt.Errorf("unable to read build version load command: %w", err)
+			}
+			return value.SDK, nil
+		case LcVersionMinMacosx, LcVersionMinIphoneos, LcVersionMinTvos, LcVersionMinWatchos:
+			var value VersionMinCommand
+			if err := restruct.Unpack(data, m.ByteOrder, &value); err != nil {
+				return 0, fmt.Errorf("unable to read minimum version load command: %w", err)
+			}
+			return value.SDK, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,105 @@
+package macho
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr require.ErrorAssertionFunc
+	}{
+		{input: "12.1", want: 0x000c0100},
+		{input: "10.15.4", want: 0x000a0f04},
+		{input: "12", wantErr: require.Error},
+		{input: "12.1.0.1", wantErr: require.Error},
+		{input: "12.256", wantErr: require.Error},
+		{input: "twelve.1", wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseVersion(tt.input)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVersion_String(t *testing.T) {
+	assert.Equal(t, "12.1", Version(0x000c0100).String())
+	assert.Equal(t, "10.15.4", Version(0x000a0f04).String())
+}
+
+// writeMachoWithLoads writes a minimal arm64 executable holding the given load commands.
+func writeMachoWithLoads(t *testing.T, cmds ...[]byte) string {
+	t.Helper()
+	by := make([]byte, fileHeaderSize64)
+	binary.LittleEndian.PutUint32(by[0:], 0xfeedfacf) // MH_MAGIC_64
+	binary.LittleEndian.PutUint32(by[4:], 0x0100000c) // CPU_TYPE_ARM64
+	binary.LittleEndian.PutUint32(by[12:], 0x2)       // MH_EXECUTE
+	binary.LittleEndian.PutUint32(by[16:], uint32(len(cmds)))
+	for _, cmd := range cmds {
+		by = append(by, cmd...)
+	}
+	binary.LittleEndian.PutUint32(by[20:], uint32(len(by)-fileHeaderSize64))
+
+	path := filepath.Join(t.TempDir(), "bin")
+	require.NoError(t, os.WriteFile(path, by, 0600))
+	return path
+}
+
+func loadCommand(fields ...uint32) []byte {
+	by := make([]byte, 4*len(fields))
+	for i, f := range fields {
+		binary.LittleEndian.PutUint32(by[4*i:], f)
+	}
+	return by
+}
+
+func TestFile_SDKVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []byte
+		want Version
+	}{
+		{
+			name: "build version",
+			cmd:  loadCommand(uint32(LcBuildVersion), 24, 1, 0x000b0000, 0x000d0300, 0),
+			want: NewVersion(13, 3, 0),
+		},
+		{
+			name: "minimum version",
+			cmd:  loadCommand(uint32(LcVersionMinMacosx), 16, 0x000a0900, 0x000a0e00),
+			want: NewVersion(10, 14, 0),
+		},
+		{
+			name: "not recorded",
+			// LC_UUID
+			cmd: loadCommand(0x1b, 24, 0, 0, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewReadOnlyFile(writeMachoWithLoads(t, tt.cmd))
+			require.NoError(t, err)
+			defer m.Close()
+
+			got, err := m.SDKVersion()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package macho
+
+import (
+	"encoding/asn1"
+	"encoding/base64"
+	"strings"
+)
+
+// CDHashTruncatedSize is the size of a cdhash as listed by the cdhashes plist (and sealed by bundle resources).
+const CDHashTruncatedSize = 20
+
+// the CMS signed attributes binding the hashes of all code directories (the primary and alternate ones) to the single
+// CMS signature (over the primary code directory), which lets verifiers trust code directories of other hash types.
+var (
+	// OIDCDHashesPlist is the attribute holding a plist listing the (truncated) cdhash of every code directory.
+	OIDCDHashesPlist = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 9, 1}
+	// OIDCDHashes is the attribute holding the full cdhash of every code directory along with its digest algorithm.
+	OIDCDHashes = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 9, 2}
+)
+
+// CDHash is an entry of the OIDCDHashes attribute.
+type CDHash struct {
+	Algorithm asn1.ObjectIdentifier
+	Digest    []byte
+}
+
+// CDHashesPlist encodes the value of the OIDCDHashesPlist attribute for the given cdhashes (in code directory order),
+// formatted exactly as codesign does.
+func CDHashesPlist(cdHashes [][]byte) []byte {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>cdhashes</key>
+	<array>
+`)
+	for _, h := range cdHashes {
+		if len(h) > CDHashTruncatedSize {
+			h = h[:CDHashTruncatedSize]
+		}
+		sb.WriteString("\t\t<data>\n\t\t" + base64.StdEncoding.EncodeToString(h) + "\n\t\t</data>\n")
+	}
+	sb.WriteString(`	</array>
+</dict>
+</plist>
+`)
+	return []byte(sb.String())
+}
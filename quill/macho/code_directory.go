@@ -1,5 +1,13 @@
 package macho
 
+import (
+	"bytes"
+	"fmt"
+	"unsafe"
+
+	"github.com/go-restruct/restruct"
+)
+
 // Definitions From: https://github.com/Apple-FOSS-Mirror/Security/blob/5bcad85836c8bbb383f660aaf25b555a805a48e4/OSX/sec/Security/Tool/codesign.c#L53-L89
 
 const (
@@ -73,12 +81,19 @@ type CdVersion uint32
 type CdFlag uint32
 type ExecSegFlag uint64
 
+// CodeDirectory is a code directory: the header along with the variable content it locates (identifier, team
+// identifier, and the special and code slot hashes). It is used both to generate code directories (see Blob) and to read
+// them back (see ParseCodeDirectory).
 type CodeDirectory struct {
 	CodeDirectoryHeader
-	// followed by dynamic content as located by offset fields above
+	// Payload follows the header of the version (see CodeDirectoryHeaderSize), the offsets of the header are relative
+	// to the start of the blob, which is the blob header followed by the code directory header and the payload.
 	Payload []byte
 }
 
+// CodeDirectoryHeader is the fixed part of a code directory. Every version appends fields to the header of the
+// previous version, only the header of the code directory version is encoded (see CodeDirectoryHeaderSize) and the
+// fields of newer versions are zero when parsed.
 type CodeDirectoryHeader struct {
 	Version       CdVersion // compatibility version
 	Flags         CdFlag    // setup and mode flags
@@ -109,14 +124,170 @@ type CodeDirectoryHeader struct {
 	EndWithCodeLimit64 [0]uint8
 
 	// Version 0x20400
-	ExecSegBase  uint64      // offset of executable segment
-	ExecSegLimit uint64      // limit of executable segment
-	ExecSegFlags ExecSegFlag // exec segment flags
+	ExecSegBase    uint64      // offset of executable segment
+	ExecSegLimit   uint64      // limit of executable segment
+	ExecSegFlags   ExecSegFlag // exec segment flags
+	EndWithExecSeg [0]uint8
 
 	// Version 0x20500
 	Runtime          uint32 // Runtime version encoded as an unsigned int
 	PreEncryptOffset uint32 // offset of pre-encrypt hash slots
+	EndWithRuntime   [0]uint8
 
 	// Version 0x20600
-	// TODO: linkage options
+	LinkageHashType           uint8  // type of hash of the linkage (cdHashType* constants)
+	LinkageApplicationType    uint8  // type of the application the linkage is for
+	LinkageApplicationSubType uint16 // subtype of the application the linkage is for
+	LinkageOffset             uint32 // offset of the linkage hash
+	LinkageSize               uint32 // size of the linkage hash
+	EndWithLinkage            [0]uint8
+}
+
+// CodeDirectoryHeaderSize is the encoded size of the header of the given code directory version (the payload follows
+// directly), zero for versions older than EarliestVersion or newer than CompatibilityLimit.
+func CodeDirectoryHeaderSize(version CdVersion) int {
+	var h CodeDirectoryHeader
+	switch {
+	case version < EarliestVersion || version > CompatibilityLimit:
+		return 0
+	case version >= SupportsLinkage:
+		return int(unsafe.Offsetof(h.EndWithLinkage))
+	case version >= SupportsRuntime:
+		return int(unsafe.Offsetof(h.EndWithRuntime))
+	case version >= SupportsExecseg:
+		return int(unsafe.Offsetof(h.EndWithExecSeg))
+	case version >= SupportsCodelimit64:
+		return int(unsafe.Offsetof(h.EndWithCodeLimit64))
+	case version >= SupportsTeamid:
+		return int(unsafe.Offsetof(h.EndWithTeam))
+	case version >= SupportsScatter:
+		return int(unsafe.Offsetof(h.EndWithScatter))
+	}
+	return int(unsafe.Offsetof(h.EndEarliest))
+}
+
+// ParseCodeDirectory decodes the given code directory blob (including the blob header). Only the header fields of the
+// version of the code directory are read, the fields of newer versions are left zero.
+func ParseCodeDirectory(blob []byte) (*CodeDirectory, error) {
+	if len(blob) < blobHeaderSize+CodeDirectoryHeaderSize(EarliestVersion) {
+		return nil, malformed("code directory", "too short (%d bytes)", len(blob))
+	}
+	if magic := Magic(SigningOrder.Uint32(blob)); magic != MagicCodedirectory {
+		return nil, malformed("code directory", "unexpected magic: 0x%x", uint32(magic))
+	}
+	if length := SigningOrder.Uint32(blob[4:]); int(length) != len(blob) {
+		return nil, malformed("code directory", "length %d does not match the blob length %d", length, len(blob))
+	}
+
+	version := CdVersion(SigningOrder.Uint32(blob[blobHeaderSize:]))
+	headerSize := CodeDirectoryHeaderSize(version)
+	if headerSize == 0 {
+		return nil, malformed("code directory", "unsupported version 0x%x", uint32(version))
+	}
+	if len(blob) < blobHeaderSize+headerSize {
+		return nil, malformed("code directory", "too short for version 0x%x", uint32(version))
+	}
+
+	// only the fields of the version are decoded, the remainder of the (full) header is left zeroed
+	var cd CodeDirectory
+	headerBytes := make([]byte, CodeDirectoryHeaderSize(CompatibilityLimit))
+	copy(headerBytes, blob[blobHeaderSize:blobHeaderSize+headerSize])
+	if err := restruct.Unpack(headerBytes, SigningOrder, &cd.CodeDirectoryHeader); err != nil {
+		return nil, fmt.Errorf("unable to decode code directory header: %w", err)
+	}
+	cd.Payload = blob[blobHeaderSize+headerSize:]
+	return &cd, nil
+}
+
+// Blob encodes the code directory, only the header fields of its version are written.
+func (cd CodeDirectory) Blob() (*Blob, error) {
+	headerSize := CodeDirectoryHeaderSize(cd.Version)
+	if headerSize == 0 {
+		return nil, fmt.Errorf("unsupported code directory version 0x%x", uint32(cd.Version))
+	}
+
+	headerBytes, err := restruct.Pack(SigningOrder, &cd.CodeDirectoryHeader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode code directory: %w", err)
+	}
+
+	payload := make([]byte, 0, headerSize+len(cd.Payload))
+	payload = append(payload, headerBytes[:headerSize]...)
+	payload = append(payload, cd.Payload...)
+
+	blob := NewBlob(MagicCodedirectory, payload)
+	return &blob, nil
+}
+
+// bytesAt returns the content at the given offset (relative to the start of the blob) up to the end of the payload.
+func (cd CodeDirectory) bytesAt(offset uint32) ([]byte, error) {
+	start := int64(offset) - int64(blobHeaderSize) - int64(CodeDirectoryHeaderSize(cd.Version))
+	if start < 0 || start >= int64(len(cd.Payload)) {
+		return nil, malformed("code directory", "offset %d is out of bounds", offset)
+	}
+	return cd.Payload[start:], nil
+}
+
+func (cd CodeDirectory) cString(offset uint32) (string, error) {
+	s, err := cd.bytesAt(offset)
+	if err != nil {
+		return "", err
+	}
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s), nil
+}
+
+// Identifier is the identifier of the signed code.
+func (cd CodeDirectory) Identifier() (string, error) {
+	return cd.cString(cd.IdentOffset)
+}
+
+// TeamID is the team identifier, empty when the code directory has none.
+func (cd CodeDirectory) TeamID() (string, error) {
+	if cd.Version < SupportsTeamid || cd.TeamOffset == 0 {
+		return "", nil
+	}
+	return cd.cString(cd.TeamOffset)
+}
+
+// Limit is the size of the signed code (CodeLimit64 when set, CodeLimit otherwise).
+func (cd CodeDirectory) Limit() uint64 {
+	if cd.Version >= SupportsCodelimit64 && cd.CodeLimit64 != 0 {
+		return cd.CodeLimit64
+	}
+	return uint64(cd.CodeLimit)
+}
+
+// PageBytes is the size of the pages hashed by the code slots (the code limit when there is a single page).
+func (cd CodeDirectory) PageBytes() uint64 {
+	if cd.PageSize == 0 {
+		return cd.Limit()
+	}
+	return 1 << cd.PageSize
+}
+
+// SlotHash returns the hash at the given index: code slots start at zero, special slots have negative indexes (e.g.
+// -int(CsSlotRequirements) for the requirements).
+func (cd CodeDirectory) SlotHash(index int64) ([]byte, error) {
+	size := int64(cd.HashSize)
+	start := int64(cd.HashOffset) + index*size
+	if start < 0 || start > int64(^uint32(0)) {
+		return nil, malformed("code directory", "slot %d hash is out of bounds", index)
+	}
+	b, err := cd.bytesAt(uint32(start))
+	if err != nil || int64(len(b)) < size {
+		return nil, malformed("code directory", "slot %d hash is out of bounds", index)
+	}
+	return b[:size], nil
+}
+
+// SpecialSlotHash returns the hash of the given special slot, nil is returned when the code directory has no such
+// slot (an all-zero hash means the slot is unused).
+func (cd CodeDirectory) SpecialSlotHash(slot SlotType) ([]byte, error) {
+	if slot == 0 || uint32(slot) > cd.NSpecialSlots {
+		return nil, nil
+	}
+	return cd.SlotHash(-int64(slot))
 }
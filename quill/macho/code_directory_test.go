@@ -0,0 +1,186 @@
+package macho
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeDirectoryHeaderSize(t *testing.T) {
+	// sizes from the CodeDirectory definition of Apple's cs_blobs.h
+	tests := []struct {
+		version CdVersion
+		want    int
+	}{
+		{version: EarliestVersion, want: 36},
+		{version: SupportsScatter, want: 40},
+		{version: SupportsTeamid, want: 44},
+		{version: SupportsCodelimit64, want: 56},
+		{version: SupportsExecseg, want: 80},
+		{version: SupportsRuntime, want: 88},
+		{version: SupportsLinkage, want: 100},
+		{version: SupportsRuntime + 1, want: 88},
+		{version: 0x20000, want: 0},
+		{version: CompatibilityLimit + 1, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("0x%x", uint32(tt.version)), func(t *testing.T) {
+			assert.Equal(t, tt.want, CodeDirectoryHeaderSize(tt.version))
+		})
+	}
+}
+
+func TestParseCodeDirectory(t *testing.T) {
+	const id, team = "com.example.tool", "TEAMID1234"
+	hashes := [][]byte{
+		{0xbb, 0xbb}, // slot -2
+		{0xaa, 0xaa}, // slot -1
+		{0x01, 0x01}, // page 0
+		{0x02, 0x02}, // page 1
+	}
+
+	for _, version := range []CdVersion{SupportsScatter, SupportsTeamid, SupportsCodelimit64, SupportsExecseg, SupportsRuntime, SupportsLinkage} {
+		t.Run(fmt.Sprintf("0x%x", uint32(version)), func(t *testing.T) {
+			idOffset := uint32(unsafe.Sizeof(BlobHeader{})) + uint32(CodeDirectoryHeaderSize(version))
+			payload := []byte(id + "\x00")
+			var teamOffset uint32
+			if version >= SupportsTeamid {
+				teamOffset = idOffset + uint32(len(payload))
+				payload = append(payload, team+"\x00"...)
+			}
+			hashOffset := idOffset + uint32(len(payload)) + 2*2
+			for _, h := range hashes {
+				payload = append(payload, h...)
+			}
+
+			cd := CodeDirectory{
+				CodeDirectoryHeader: CodeDirectoryHeader{
+					Version:                version,
+					Flags:                  Runtime,
+					HashOffset:             hashOffset,
+					IdentOffset:            idOffset,
+					NSpecialSlots:          2,
+					NCodeSlots:             2,
+					CodeLimit:              0x1800,
+					HashSize:               2,
+					HashType:               HashTypeSha256,
+					PageSize:               PageSizeBits,
+					TeamOffset:             teamOffset,
+					CodeLimit64:            0x1800,
+					ExecSegLimit:           0x1000,
+					ExecSegFlags:           ExecsegMainBinary,
+					Runtime:                0x000d0000,
+					LinkageHashType:        uint8(HashTypeSha256),
+					LinkageApplicationType: 1,
+				},
+				Payload: payload,
+			}
+
+			blob, err := cd.Blob()
+			require.NoError(t, err)
+			by, err := blob.Pack()
+			require.NoError(t, err)
+			require.Len(t, by, int(idOffset)+len(payload))
+
+			parsed, err := ParseCodeDirectory(by)
+			require.NoError(t, err)
+			assert.Equal(t, version, parsed.Version)
+			assert.Equal(t, payload, parsed.Payload)
+
+			got, err := parsed.Identifier()
+			require.NoError(t, err)
+			assert.Equal(t, id, got)
+
+			got, err = parsed.TeamID()
+			require.NoError(t, err)
+			if version >= SupportsTeamid {
+				assert.Equal(t, team, got)
+			} else {
+				assert.Empty(t, got)
+			}
+
+			h, err := parsed.SpecialSlotHash(CsSlotRequirements)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0xbb, 0xbb}, h)
+			h, err = parsed.SlotHash(1)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0x02, 0x02}, h)
+			_, err = parsed.SlotHash(2)
+			require.Error(t, err)
+
+			assert.Equal(t, uint64(0x1800), parsed.Limit())
+			assert.Equal(t, uint64(PageSize), parsed.PageBytes())
+
+			// the fields of newer versions are not encoded
+			if version < SupportsCodelimit64 {
+				assert.Zero(t, parsed.CodeLimit64)
+			}
+			if version < SupportsExecseg {
+				assert.Zero(t, parsed.ExecSegFlags)
+			} else {
+				assert.Equal(t, ExecsegMainBinary, parsed.ExecSegFlags)
+			}
+			if version < SupportsRuntime {
+				assert.Zero(t, parsed.Runtime)
+			} else {
+				assert.Equal(t, uint32(0x000d0000), parsed.Runtime)
+			}
+			if version < SupportsLinkage {
+				assert.Zero(t, parsed.LinkageHashType)
+			} else {
+				assert.Equal(t, uint8(HashTypeSha256), parsed.LinkageHashType)
+				assert.Equal(t, uint8(1), parsed.LinkageApplicationType)
+			}
+		})
+	}
+}
+
+func TestParseCodeDirectory_invalid(t *testing.T) {
+	valid := NewBlob(MagicCodedirectory, make([]byte, CodeDirectoryHeaderSize(SupportsRuntime)))
+	SigningOrder.PutUint32(valid.Payload, uint32(SupportsRuntime))
+
+	tests := []struct {
+		name  string
+		input func() []byte
+	}{
+		{
+			name: "too short",
+			input: func() []byte {
+				return []byte{0xfa, 0xde, 0x0c, 0x02, 0, 0, 0, 12, 0, 2, 4, 0}
+			},
+		},
+		{
+			name: "unexpected magic",
+			input: func() []byte {
+				b := NewBlob(MagicRequirements, valid.Payload)
+				by, _ := b.Pack()
+				return by
+			},
+		},
+		{
+			name: "unsupported version",
+			input: func() []byte {
+				by, _ := valid.Pack()
+				SigningOrder.PutUint32(by[8:], uint32(CompatibilityLimit+1))
+				return by
+			},
+		},
+		{
+			name: "header exceeds the blob",
+			input: func() []byte {
+				by, _ := valid.Pack()
+				SigningOrder.PutUint32(by[8:], uint32(SupportsLinkage))
+				return by
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCodeDirectory(tt.input())
+			require.Error(t, err)
+		})
+	}
+}
@@ -1,5 +1,14 @@
 package macho
 
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-restruct/restruct"
+
+	"github.com/anchore/quill/internal/log"
+)
+
 const LcCodeSignature LoadCommandType = 0x1d
 
 type LoadCommandType uint32
@@ -11,3 +20,180 @@ type CodeSigningCommand struct {
 	DataOffset uint32          // file offset of data in __LINKEDIT segment
 	DataSize   uint32          // file size of data in __LINKEDIT segment
 }
+
+// The functions below are the building blocks of the signing layout: quill adds an empty LcCodeSignature command at
+// the end of __LINKEDIT (AddEmptyCodeSigningCmd), then reserves exactly the size of the superblob (ReserveCodeSignature)
+// and writes it (WriteCodeSignature). Custom layouts (e.g. reserving extra space to be able to re-sign in place) can
+// be built by calling them with other offsets and sizes. Code signatures must be the last content of the binary and
+// are usually 16-byte aligned (as codesign does).
+
+// AddCodeSigningCmd adds an LcCodeSignature load command referencing dataSize bytes at dataOffset (the bytes themselves
+// are neither reserved nor written, see ReserveCodeSignature). The binary must not be signed already, there must be room
+// for the command after the existing load commands and the data must start at or after the end of __LINKEDIT.
+func (m *File) AddCodeSigningCmd(dataOffset, dataSize uint32) (err error) {
+	log.WithFields("offset", dataOffset, "size", dataSize).Trace("adding code signing loader command")
+
+	if m.HasCodeSigningCmd() {
+		return fmt.Errorf("loader command already exists, cannot add another")
+	}
+	linkEditSeg := m.Segment("__LINKEDIT")
+	if linkEditSeg == nil {
+		return fmt.Errorf("binary has no __LINKEDIT segment")
+	}
+	if end := linkEditSeg.Offset + linkEditSeg.Filesz; uint64(dataOffset) < end {
+		return fmt.Errorf("code signature offset 0x%x overlaps the content of __LINKEDIT (ending at 0x%x)", dataOffset, end)
+	}
+	if !m.hasRoomForNewCmd() {
+		return fmt.Errorf("no room for a new loader command")
+	}
+
+	codeSigningCmd := CodeSigningCommand{
+		Cmd:        LcCodeSignature,
+		Size:       uint32(unsafe.Sizeof(CodeSigningCommand{})),
+		DataOffset: dataOffset,
+		DataSize:   dataSize,
+	}
+
+	codeSigningCmdBytes, err := restruct.Pack(m.ByteOrder, &codeSigningCmd)
+	if err != nil {
+		return fmt.Errorf("unable to create new code signing loader command: %w", err)
+	}
+
+	if err = m.Patch(codeSigningCmdBytes, int(codeSigningCmd.Size), m.nextCmdOffset()); err != nil {
+		return fmt.Errorf("unable to patch code signing loader command: %w", err)
+	}
+
+	// update macho header to reflect the new command
+	header := m.FileHeader
+	header.Ncmd++
+	header.Cmdsz += codeSigningCmd.Size
+
+	headerBytes, err := restruct.Pack(m.ByteOrder, &header)
+	if err != nil {
+		return fmt.Errorf("unable to pack modified macho header: %w", err)
+	}
+
+	if err = m.Patch(headerBytes, len(headerBytes), 0); err != nil {
+		return fmt.Errorf("unable to patch macho header: %w", err)
+	}
+	return nil
+}
+
+// SetCodeSigningCmd sets the data offset and size of the existing LcCodeSignature load command (the __LINKEDIT segment
+// is left untouched, see ReserveCodeSignature).
+func (m *File) SetCodeSigningCmd(dataOffset, dataSize uint32) (err error) {
+	log.WithFields("offset", dataOffset, "size", dataSize).Trace("updating code signing loader command")
+
+	cmd, offset, err := m.CodeSigningCmd()
+	if err != nil {
+		return fmt.Errorf("unable to update existing signing loader command: %w", err)
+	}
+	if cmd == nil {
+		return fmt.Errorf("binary has no code signing loader command")
+	}
+
+	cmd.DataOffset = dataOffset
+	cmd.DataSize = dataSize
+
+	b, err := restruct.Pack(m.ByteOrder, cmd)
+	if err != nil {
+		return fmt.Errorf("unable to update code signing loader command: %w", err)
+	}
+
+	return m.Patch(b, int(cmd.Size), offset)
+}
+
+// SetLinkEditEnd resizes the __LINKEDIT segment so that its content ends at the given file offset, growing its virtual
+// memory size when needed.
+func (m *File) SetLinkEditEnd(end uint64) error {
+	linkEditSegment := m.Segment("__LINKEDIT")
+	if linkEditSegment == nil {
+		return fmt.Errorf("binary has no __LINKEDIT segment")
+	}
+	if end < linkEditSegment.Offset {
+		return fmt.Errorf("__LINKEDIT cannot end at 0x%x, before its start (0x%x)", end, linkEditSegment.Offset)
+	}
+
+	header := linkEditSegment.SegmentHeader
+	header.Filesz = end - header.Offset
+	if header.Memsz == 0 {
+		header.Memsz = PageSize
+	}
+	for header.Filesz > header.Memsz {
+		header.Memsz *= 2
+	}
+	if err := m.UpdateSegmentHeader(header); err != nil {
+		return fmt.Errorf("failed to update linkedit segment size: %w", err)
+	}
+	return nil
+}
+
+// ReserveCodeSignature points the LcCodeSignature load command (added when missing) at dataSize bytes at dataOffset
+// and resizes __LINKEDIT to end with them, the range must follow the content referenced by the other load commands
+// (see File.LinkEditContents). The reserved size may exceed the size of the superblob to write (see
+// WriteCodeSignature), the remaining bytes are zeros.
+func (m *File) ReserveCodeSignature(dataOffset, dataSize uint32) error {
+	// the signature must follow the rest of the content of __LINKEDIT (e.g. chained fixups, exports trie, and symbols)
+	contentEnd, err := m.linkEditContentEnd()
+	if err != nil {
+		return err
+	}
+	if uint64(dataOffset) < contentEnd {
+		return fmt.Errorf("code signature offset 0x%x overlaps the content of __LINKEDIT (ending at 0x%x)", dataOffset, contentEnd)
+	}
+
+	if !m.HasCodeSigningCmd() {
+		if err := m.AddCodeSigningCmd(dataOffset, dataSize); err != nil {
+			return err
+		}
+	} else if err := m.SetCodeSigningCmd(dataOffset, dataSize); err != nil {
+		return fmt.Errorf("unable to update code signature loader command: %w", err)
+	}
+
+	return m.SetLinkEditEnd(uint64(dataOffset) + uint64(dataSize))
+}
+
+// WriteCodeSignature writes the given superblob at the offset referenced by the LcCodeSignature load command, the rest
+// of the reserved space (see ReserveCodeSignature) is zeroed.
+func (m *File) WriteCodeSignature(superBlob []byte) error {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+	if cmd == nil {
+		return fmt.Errorf("binary has no code signing loader command")
+	}
+	if uint64(len(superBlob)) > uint64(cmd.DataSize) {
+		return fmt.Errorf("code signature (%d bytes) exceeds the reserved space (%d bytes)", len(superBlob), cmd.DataSize)
+	}
+
+	content := make([]byte, cmd.DataSize)
+	copy(content, superBlob)
+	if err = m.Patch(content, len(content), uint64(cmd.DataOffset)); err != nil {
+		return fmt.Errorf("failed to patch super blob onto macho binary: %w", err)
+	}
+	return nil
+}
+
+// HasReservedCodeSignature indicates the binary has an LcCodeSignature load command whose space is reserved but not
+// written yet (it holds no superblob, only zeros), as left by preparing the binary for signing.
+func (m *File) HasReservedCodeSignature() (bool, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return false, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+	if cmd == nil || cmd.DataSize == 0 {
+		return false, nil
+	}
+
+	content, err := m.ReadBytes(uint64(cmd.DataOffset), uint64(cmd.DataSize))
+	if err != nil {
+		return false, err
+	}
+	for _, b := range content {
+		if b != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,157 @@
+package macho
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLinkEditMacho writes a binary with the given load commands and a __LINKEDIT segment holding 0x40 bytes of
+// content at 0x500.
+func writeLinkEditMacho(t *testing.T, cmds ...[]byte) string {
+	t.Helper()
+	linkEdit := loadCommand(uint32(LcSegment64), 72,
+		// __LINKEDIT
+		0x494c5f5f, 0x44454b4e, 0x5449, 0,
+		// vmaddr, vmsize, fileoff, filesize (64-bit)
+		0x8000, 0, 0x1000, 0, 0x500, 0, 0x40, 0,
+		// maxprot, initprot, nsects, flags
+		1, 1, 0, 0)
+
+	path := writeEditableMacho(t, 0x400, append([][]byte{linkEdit}, cmds...)...)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	content := make([]byte, 0x40)
+	for i := range content {
+		content[i] = 0xbb
+	}
+	_, err = f.Write(content)
+	require.NoError(t, err)
+	return path
+}
+
+func TestFile_ReserveCodeSignature(t *testing.T) {
+	path := writeLinkEditMacho(t)
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	// reserve more room than needed, aligned past the end of __LINKEDIT
+	require.NoError(t, m.ReserveCodeSignature(0x550, 0x2000))
+
+	cmd, _, err := m.CodeSigningCmd()
+	require.NoError(t, err)
+	assert.Equal(t, CodeSigningCommand{Cmd: LcCodeSignature, Size: 16, DataOffset: 0x550, DataSize: 0x2000}, *cmd)
+
+	linkEdit := m.Segment("__LINKEDIT")
+	assert.Equal(t, uint64(0x2050), linkEdit.Filesz)
+	assert.Equal(t, uint64(0x4000), linkEdit.Memsz)
+
+	superBlob := []byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 12, 0, 0, 0, 0}
+	require.NoError(t, m.WriteCodeSignature(superBlob))
+	require.Error(t, m.WriteCodeSignature(make([]byte, 0x2001)))
+
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, by, 0x2550)
+	assert.Equal(t, byte(0xbb), by[0x53f])
+	assert.Equal(t, superBlob, by[0x550:0x55c])
+	assert.Equal(t, make([]byte, 0x2000-len(superBlob)), by[0x55c:])
+
+	// the reservation can be shrunk in place
+	require.NoError(t, m.ReserveCodeSignature(0x550, 0x100))
+	assert.Equal(t, uint64(0x150), m.Segment("__LINKEDIT").Filesz)
+
+	read, err := m.ReadSuperBlob()
+	require.NoError(t, err)
+	assert.Equal(t, MagicEmbeddedSignature, read.Magic)
+}
+
+func TestFile_ReserveCodeSignature_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset uint32
+	}{
+		{
+			name:   "before __LINKEDIT",
+			offset: 0x400,
+		},
+		{
+			name:   "overlaps __LINKEDIT",
+			offset: 0x530,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewFile(writeLinkEditMacho(t))
+			require.NoError(t, err)
+			defer m.Close()
+
+			require.Error(t, m.ReserveCodeSignature(tt.offset, 0x100))
+			assert.False(t, m.HasCodeSigningCmd())
+		})
+	}
+}
+
+func TestFile_SetCodeSigningCmd_unsigned(t *testing.T) {
+	m, err := NewFile(writeLinkEditMacho(t))
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.Error(t, m.SetCodeSigningCmd(0x540, 0x100))
+	require.Error(t, m.WriteCodeSignature([]byte{0}))
+}
+
+func TestFile_Copy(t *testing.T) {
+	path := writeLinkEditMacho(t)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	m, err := NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	c, err := m.Copy()
+	require.NoError(t, err)
+	defer c.Close()
+
+	// the copy is writable (and grows as needed) while the binary is left untouched
+	require.NoError(t, c.ReserveCodeSignature(0x540, 0x100))
+	require.NoError(t, c.WriteCodeSignature([]byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 12, 0, 0, 0, 0}))
+	assert.Equal(t, int64(0x640), c.Size())
+	assert.True(t, c.HasCodeSigningCmd())
+
+	require.NoError(t, c.RemoveSigningContent())
+	assert.Equal(t, int64(0x540), c.Size())
+	assert.False(t, c.HasCodeSigningCmd())
+
+	assert.False(t, m.HasCodeSigningCmd())
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, by)
+}
+
+func TestFile_HasReservedCodeSignature(t *testing.T) {
+	m, err := NewFile(writeLinkEditMacho(t))
+	require.NoError(t, err)
+	defer m.Close()
+
+	reserved, err := m.HasReservedCodeSignature()
+	require.NoError(t, err)
+	assert.False(t, reserved, "unsigned")
+
+	require.NoError(t, m.ReserveCodeSignature(0x540, 0x100))
+	require.NoError(t, m.WriteCodeSignature(nil))
+	reserved, err = m.HasReservedCodeSignature()
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	require.NoError(t, m.WriteCodeSignature([]byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 12, 0, 0, 0, 0}))
+	reserved, err = m.HasReservedCodeSignature()
+	require.NoError(t, err)
+	assert.False(t, reserved, "signed")
+}
@@ -0,0 +1,43 @@
+package macho
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MalformedError indicates that a binary or its signature is inconsistent (e.g. an offset or a length exceeding the
+// content it refers to), as found in corrupted or hostile binaries. Every offset and length read from a binary is
+// checked before use, so malformed input is reported with this error rather than reading out of bounds.
+type MalformedError struct {
+	// Structure is what was being read (e.g. "superblob" or "code directory").
+	Structure string
+	// Reason describes the inconsistency.
+	Reason string
+}
+
+func (e *MalformedError) Error() string {
+	return fmt.Sprintf("malformed %s: %s", e.Structure, e.Reason)
+}
+
+// IsMalformed indicates if the given error was caused by a malformed binary or signature.
+func IsMalformed(err error) bool {
+	var malformed *MalformedError
+	return errors.As(err, &malformed)
+}
+
+func malformed(structure, format string, args ...interface{}) error {
+	return &MalformedError{Structure: structure, Reason: fmt.Sprintf(format, args...)}
+}
+
+// ReadBytes reads size bytes at the given offset of the binary, the range is checked against the size of the binary
+// first (offsets and sizes read from the binary are untrusted, this also bounds the allocation).
+func (m *File) ReadBytes(offset, size uint64) ([]byte, error) {
+	if offset > uint64(m.size) || size > uint64(m.size)-offset {
+		return nil, malformed("binary", "range [0x%x, +0x%x) exceeds the binary (%d bytes)", offset, size, m.size)
+	}
+	b := make([]byte, size)
+	if _, err := m.ReadAt(b, int64(offset)); err != nil {
+		return nil, fmt.Errorf("unable to read binary: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,64 @@
+package macho
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_ReadBytes(t *testing.T) {
+	m, err := NewReadOnlyFile(writeLinkEditMacho(t))
+	require.NoError(t, err)
+	defer m.Close()
+
+	b, err := m.ReadBytes(0x53e, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xbb, 0xbb}, b)
+
+	b, err = m.ReadBytes(uint64(m.Size()), 0)
+	require.NoError(t, err)
+	assert.Empty(t, b)
+
+	for _, r := range [][2]uint64{
+		{0x53f, 2},
+		{uint64(m.Size()) + 1, 0},
+		// would overflow the end offset
+		{0x10, ^uint64(0)},
+		{^uint64(0), 0x10},
+	} {
+		_, err := m.ReadBytes(r[0], r[1])
+		require.Error(t, err)
+		assert.True(t, IsMalformed(err), "range %v", r)
+	}
+}
+
+func TestIsMalformed(t *testing.T) {
+	superBlob := []byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 20, 0xff, 0xff, 0xff, 0xff}
+
+	for name, parse := range map[string]func() error{
+		"superblob": func() error {
+			_, err := ParseSuperBlob(superBlob)
+			return err
+		},
+		"code directory": func() error {
+			_, err := ParseCodeDirectory(superBlob)
+			return err
+		},
+		"blob": func() error {
+			_, err := ParseBlob(superBlob)
+			return err
+		},
+		"requirement": func() error {
+			_, err := DecodeRequirement([]byte{0xfa, 0xde, 0x0c, 0x00, 0xff, 0xff, 0xff, 0xff})
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := parse()
+			require.Error(t, err)
+			assert.True(t, IsMalformed(err))
+			assert.Contains(t, err.Error(), "malformed "+name)
+		})
+	}
+}
@@ -4,16 +4,21 @@ import (
 	"bytes"
 	"debug/macho"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"os"
+	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/go-restruct/restruct"
 
 	macholibre "github.com/anchore/go-macholibre"
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/metrics"
 )
 
 const (
@@ -28,6 +33,9 @@ const (
 
 type File struct {
 	path string
+	// content is the binary when held in memory rather than read from path (see NewReadOnlyFileFromBytes).
+	content []byte
+	size    int64
 	io.ReadSeekCloser
 	io.ReaderAt
 	io.WriterAt
@@ -35,6 +43,8 @@ type File struct {
 }
 
 func NewFile(path string) (*File, error) {
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.ParseStarted, Path: path})
+
 	m := &File{
 		path: path,
 	}
@@ -43,6 +53,8 @@ func NewFile(path string) (*File, error) {
 }
 
 func NewReadOnlyFile(path string) (*File, error) {
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.ParseStarted, Path: path})
+
 	m := &File{
 		path: path,
 	}
@@ -50,6 +62,52 @@ func NewReadOnlyFile(path string) (*File, error) {
 	return m, m.refresh(false)
 }
 
+// NewReadOnlyFileFromBytes parses the given single-arch binary held in memory, the name is only used to describe it.
+func NewReadOnlyFileFromBytes(name string, content []byte) (*File, error) {
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.ParseStarted, Path: name})
+
+	m := &File{
+		path:    name,
+		content: content,
+	}
+
+	return m, m.refresh(false)
+}
+
+// Copy returns a writable copy of the binary held in memory, changes made to the copy (e.g. by a trial signing pass)
+// are not written to the binary.
+func (m *File) Copy() (*File, error) {
+	content, err := m.ReadBytes(0, uint64(m.size))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &File{
+		path:    m.path,
+		content: append([]byte(nil), content...),
+	}
+	c.WriterAt = contentWriter{c}
+
+	return c, c.refresh(true)
+}
+
+// contentWriter writes to the binary held in memory, growing it as needed.
+type contentWriter struct {
+	m *File
+}
+
+func (w contentWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset %d", off)
+	}
+	if end := off + int64(len(p)); end > int64(len(w.m.content)) {
+		grown := make([]byte, end)
+		copy(grown, w.m.content)
+		w.m.content = grown
+	}
+	return copy(w.m.content[off:], p), nil
+}
+
 func IsMachoFile(path string) (bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -72,6 +130,19 @@ func (m *File) refresh(withWrite bool) error {
 		}
 	}
 
+	if m.content != nil {
+		r := bytes.NewReader(m.content)
+		o, err := macho.NewFile(r)
+		if err != nil {
+			return fmt.Errorf("unable to parse macho file: %w", err)
+		}
+		m.ReadSeekCloser = nopCloser{r}
+		m.ReaderAt = r
+		m.File = o
+		m.size = int64(len(m.content))
+		return nil
+	}
+
 	flags := os.O_RDONLY
 	if withWrite {
 		flags = os.O_RDWR
@@ -91,16 +162,33 @@ func (m *File) refresh(withWrite bool) error {
 		return fmt.Errorf("unable to reset macho file cursor: %w", err)
 	}
 
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat macho file: %w", err)
+	}
+
 	m.ReadSeekCloser = f
 	m.ReaderAt = f
 	if withWrite {
 		m.WriterAt = f
 	}
 	m.File = o
+	m.size = info.Size()
 
 	return nil
 }
 
+// Size returns the size of the binary in bytes.
+func (m *File) Size() int64 {
+	return m.size
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
 func (m *File) Close() error {
 	if err := m.ReadSeekCloser.Close(); err != nil {
 		return err
@@ -147,68 +235,32 @@ func (m *File) hasRoomForNewCmd() bool {
 	return true
 }
 
+// AddEmptyCodeSigningCmd adds an LcCodeSignature load command referencing (empty) data at the end of __LINKEDIT.
 func (m *File) AddEmptyCodeSigningCmd() (err error) {
 	log.Trace("adding empty code signing loader command")
 
-	if m.HasCodeSigningCmd() {
-		return fmt.Errorf("loader command already exists, cannot add another")
-	}
-	if !m.hasRoomForNewCmd() {
-		return fmt.Errorf("no room for a new loader command")
-	}
-
 	// since there is no signing command, we know that the __LINKEDIT section does not
 	// contain any signing content, thus, the end of this section is the offset for
 	// the new signing content. (though, we don't know the size yet)
 	linkEditSeg := m.Segment("__LINKEDIT")
-
-	codeSigningCmd := CodeSigningCommand{
-		Cmd:        LcCodeSignature,
-		Size:       uint32(unsafe.Sizeof(CodeSigningCommand{})),
-		DataOffset: uint32(linkEditSeg.Offset + linkEditSeg.Filesz),
-	}
-
-	codeSigningCmdBytes, err := restruct.Pack(m.ByteOrder, &codeSigningCmd)
-	if err != nil {
-		return fmt.Errorf("unable to create new code signing loader command: %w", err)
-	}
-
-	if err = m.Patch(codeSigningCmdBytes, int(codeSigningCmd.Size), m.nextCmdOffset()); err != nil {
-		return fmt.Errorf("unable to patch code signing loader command: %w", err)
+	if linkEditSeg == nil {
+		return fmt.Errorf("binary has no __LINKEDIT segment")
 	}
 
-	// update macho header to reflect the new command
-	header := m.FileHeader
-	header.Ncmd++
-	header.Cmdsz += codeSigningCmd.Size
-
-	headerBytes, err := restruct.Pack(m.ByteOrder, &header)
-	if err != nil {
-		return fmt.Errorf("unable to pack modified macho header: %w", err)
-	}
-
-	if err = m.Patch(headerBytes, len(headerBytes), 0); err != nil {
-		return fmt.Errorf("unable to patch macho header: %w", err)
-	}
-	return nil
+	return m.AddCodeSigningCmd(uint32(linkEditSeg.Offset+linkEditSeg.Filesz), 0)
 }
 
+// UpdateCodeSigningCmdDataSize sets the data size of the existing LcCodeSignature load command.
 func (m *File) UpdateCodeSigningCmdDataSize(newSize int) (err error) {
-	log.WithFields("size", newSize).Trace("updating code signing loader command")
-
-	cmd, offset, err := m.CodeSigningCmd()
+	cmd, _, err := m.CodeSigningCmd()
 	if err != nil {
 		return fmt.Errorf("unable to update existing signing loader command: %w", err)
 	}
-
-	cmd.DataSize = uint32(newSize)
-
-	b, err := restruct.Pack(m.ByteOrder, &cmd)
-	if err != nil {
-		return fmt.Errorf("unable to update code signing loader command: %w", err)
+	if cmd == nil {
+		return fmt.Errorf("binary has no code signing loader command")
 	}
 
-	return m.Patch(b, int(cmd.Size), offset)
+	return m.SetCodeSigningCmd(cmd.DataOffset, uint32(newSize))
 }
 
 func (m *File) UpdateSegmentHeader(h macho.SegmentHeader) (err error) {
@@ -230,6 +282,11 @@ func (m *File) UpdateSegmentHeader(h macho.SegmentHeader) (err error) {
 	return m.Patch(b, len(b), offset)
 }
 
+// IsExecutable indicates if the binary is an executable (rather than e.g. a library or a bundle).
+func (m *File) IsExecutable() bool {
+	return m.Type == macho.TypeExec
+}
+
 func (m *File) HasCodeSigningCmd() bool {
 	_, offset, _ := m.CodeSigningCmd()
 	return offset != 0
@@ -244,8 +301,10 @@ func (m *File) RemoveSigningContent() error {
 		return fmt.Errorf("unable to extract existing code signing cmd: %w", err)
 	}
 
-	if !m.isSigningCommandLastLoader() {
-		return fmt.Errorf("code signing command is not the last loader command, so cannot remove it (easily) without corrupting the binary")
+	// the signature can be removed when nothing but unreferenced content follows it (see NormalizeLayout), otherwise
+	// removing it would corrupt the binary
+	if err := m.CheckLayout(); err != nil && !IsNormalizableLayout(err) {
+		return fmt.Errorf("unable to remove the code signature without corrupting the binary: %w", err)
 	}
 	// update the macho header to reflect the removed command
 	header := m.FileHeader
@@ -272,9 +331,53 @@ func (m *File) RemoveSigningContent() error {
 		return fmt.Errorf("unable to remove superblob from binary: %w", err)
 	}
 
+	// the signature is the last content of __LINKEDIT (after e.g. the chained fixups, exports trie, and symbol table):
+	// shrinking the segment back to the start of the signature keeps the content ordering intact and lets the next
+	// signature be placed where the previous one was, rather than after a gap of zeros
+	signatureEnd := uint64(cmd.DataOffset) + uint64(cmd.DataSize)
+	if linkEditSeg := m.Segment("__LINKEDIT"); linkEditSeg != nil && linkEditSeg.Offset+linkEditSeg.Filesz == signatureEnd {
+		contentEnd, err := m.linkEditContentEnd()
+		if err != nil {
+			return err
+		}
+		if contentEnd <= uint64(cmd.DataOffset) {
+			log.Trace("shrinking the __LINKEDIT segment to remove the superblob")
+			if err := m.SetLinkEditEnd(uint64(cmd.DataOffset)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if signatureEnd == uint64(m.size) {
+		log.Trace("truncating the binary to remove the superblob")
+		if err := m.truncate(int64(cmd.DataOffset)); err != nil {
+			return fmt.Errorf("unable to remove superblob from binary: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// truncate shrinks the binary to the given size.
+func (m *File) truncate(size int64) error {
+	if _, ok := m.WriterAt.(contentWriter); ok {
+		if size < 0 || size > int64(len(m.content)) {
+			return fmt.Errorf("invalid size %d", size)
+		}
+		m.content = m.content[:size]
+		return m.refresh(true)
+	}
+
+	f, ok := m.ReadSeekCloser.(*os.File)
+	if !ok || m.WriterAt == nil {
+		return fmt.Errorf("writes not allowed")
+	}
+	if err := f.Truncate(size); err != nil {
+		return fmt.Errorf("unable to truncate macho binary: %w", err)
+	}
+	return m.refresh(true)
+}
+
 func (m *File) isSigningCommandLastLoader() bool {
 	var found bool
 	for _, l := range m.Loads {
@@ -308,6 +411,13 @@ func (m *File) CodeSigningCmd() (*CodeSigningCommand, uint64, error) {
 	return nil, 0, nil
 }
 
+// codeBuffers holds the buffers the content of binaries is read into for hashing.
+var codeBuffers = sync.Pool{
+	New: func() interface{} {
+		return new([]byte)
+	},
+}
+
 func (m *File) HashPages(hasher hash.Hash) (hashes [][]byte, err error) {
 	cmd, _, err := m.CodeSigningCmd()
 	if err != nil {
@@ -318,85 +428,105 @@ func (m *File) HashPages(hasher hash.Hash) (hashes [][]byte, err error) {
 		// hash everything up until a signature! (this means that the loader for the code signature must already be in place!)
 		return nil, fmt.Errorf("LcCodeSignature is not present, any generated page hashes will be wrong. Bailing")
 	}
+	if int64(cmd.DataOffset) > m.size {
+		return nil, malformed("code signing command", "data offset 0x%x exceeds the binary (%d bytes)", cmd.DataOffset, m.size)
+	}
 
-	if _, err = m.Seek(0, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("unable to seek within macho binary: %w", err)
+	// the content is only needed while hashing, so the buffer is reused across binaries (and signing passes)
+	buf := codeBuffers.Get().(*[]byte)
+	defer codeBuffers.Put(buf)
+	if cap(*buf) < int(cmd.DataOffset) {
+		*buf = make([]byte, cmd.DataOffset)
 	}
+	b := (*buf)[:cmd.DataOffset]
 
-	limitedReader := io.LimitReader(m, int64(cmd.DataOffset))
-	b, err := io.ReadAll(limitedReader)
-	if err != nil {
+	n, err := m.ReadAt(b, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("unable to read binary: %w", err)
 	}
+	b = b[:n]
 
-	hashes, err = hashChunks(hasher, PageSize, b)
+	var progress func(done, total int)
+	if lifecycle.Enabled() {
+		var lastPercent = -1
+		progress = func(done, total int) {
+			// report at most once per percent of the binary hashed
+			percent := done * 100 / total
+			if percent == lastPercent {
+				return
+			}
+			lastPercent = percent
+			lifecycle.Publish(lifecycle.Event{Type: lifecycle.HashProgress, Path: m.path, Progress: float64(done) / float64(total)})
+		}
+	}
+
+	start := time.Now()
+	hashes, err = hashChunks(hasher, PageSize, b, progress)
+	if err == nil {
+		metrics.Count(metrics.HashedBytes, float64(len(b)), nil)
+		if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+			metrics.Observe(metrics.HashThroughput, float64(len(b))/elapsed, nil)
+		}
+	}
 
 	log.WithFields("pages", len(hashes), "offset", int64(cmd.DataOffset)).Trace("hashed pages")
 
 	return hashes, err
 }
 
-func (m *File) CDBytes(order binary.ByteOrder, ith int) (cd []byte, err error) {
+// RehashHeaderPages returns the page hashes of the binary when only its header and load commands changed since the
+// given page hashes were made (e.g. patching the signature offsets between signing passes): only the pages holding the
+// header and load commands are hashed again. Every page is hashed again when the number of pages changed.
+func (m *File) RehashHeaderPages(hasher hash.Hash, previous [][]byte) ([][]byte, error) {
 	cmd, _, err := m.CodeSigningCmd()
 	if err != nil {
 		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
 	}
-
-	superBlobBytes := make([]byte, cmd.DataSize)
-	if _, err := m.ReadAt(superBlobBytes, int64(cmd.DataOffset)); err != nil {
-		return nil, fmt.Errorf("unable to extract code signing block from macho binary: %w", err)
+	if cmd == nil || len(previous) != (int(cmd.DataOffset)+PageSize-1)/PageSize {
+		return m.HashPages(hasher)
 	}
 
-	superBlobReader := bytes.NewReader(superBlobBytes)
+	end := (m.nextCmdOffset() + PageSize - 1) / PageSize * PageSize
+	if end > uint64(cmd.DataOffset) {
+		end = uint64(cmd.DataOffset)
+	}
 
-	csBlob := SuperBlob{}
-	if err := binary.Read(superBlobReader, SigningOrder, &csBlob.SuperBlobHeader); err != nil {
-		return nil, fmt.Errorf("unable to extract superblob header from macho binary: %w", err)
+	b, err := m.ReadBytes(0, end)
+	if err != nil {
+		return nil, err
 	}
+	head, err := hashChunks(hasher, PageSize, b, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([][]byte, len(previous))
+	copy(hashes, previous)
+	copy(hashes, head)
 
-	csBlob.Index = make([]BlobIndex, csBlob.Count)
-	if err := binary.Read(superBlobReader, SigningOrder, &csBlob.Index); err != nil {
+	log.WithFields("pages", len(head), "offset", int64(cmd.DataOffset)).Trace("hashed header pages")
+
+	return hashes, nil
+}
+
+// CDBytes returns the ith code directory blob (the primary code directory first) of the signed binary. The blob is
+// returned as is, the order is kept for compatibility.
+func (m *File) CDBytes(order binary.ByteOrder, ith int) (cd []byte, err error) {
+	superBlob, err := m.ReadSuperBlob()
+	if err != nil {
 		return nil, err
 	}
 
 	var found int
-blobIndex:
-	for _, index := range csBlob.Index {
-		if _, err := superBlobReader.Seek(int64(index.Offset), io.SeekStart); err != nil {
-			return nil, fmt.Errorf("unable to seek to code signing blob index=%d: %w", index.Offset, err)
-		}
-
-		switch index.Type {
+	for _, entry := range superBlob.Entries {
+		switch entry.Slot {
 		case CsSlotCodedirectory, CsSlotAlternateCodedirectories:
 			found++
 			if found <= ith {
-				continue blobIndex
-			}
-
-			var cdBlobHeader BlobHeader
-			// read the header
-			if err := binary.Read(superBlobReader, SigningOrder, &cdBlobHeader); err != nil {
-				return nil, err
-			}
-
-			var cdHeader CodeDirectoryHeader
-			if err := binary.Read(superBlobReader, SigningOrder, &cdHeader); err != nil {
-				return nil, err
-			}
-
-			// head back to the beginning of the CD
-			if _, err := superBlobReader.Seek(int64(index.Offset), io.SeekStart); err != nil {
-				return nil, fmt.Errorf("unable to seek to code directory: %w", err)
+				continue
 			}
-
-			cdBytes := make([]byte, cdBlobHeader.Length)
-			// note: though the binary may be LE or BE, for hashing we always use LE
-			// note: the entire blob is encoded, not just the code directory (which is only the blob payload)
-			if err := binary.Read(superBlobReader, order, &cdBytes); err != nil {
-				return nil, err
-			}
-
-			return cdBytes, nil
+			// note: the entire blob is returned (hashed), not just the code directory (which is only the blob payload)
+			return entry.Data, nil
 		}
 	}
 	return nil, ErrNoCodeDirectory
@@ -404,55 +534,15 @@ blobIndex:
 
 var ErrNoCodeDirectory = fmt.Errorf("unable to find code directory")
 
+// CMSBlobBytes returns the CMS signature blob of the signed binary.
 func (m *File) CMSBlobBytes(order binary.ByteOrder) (cd []byte, err error) {
-	cmd, _, err := m.CodeSigningCmd()
+	superBlob, err := m.ReadSuperBlob()
 	if err != nil {
-		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
-	}
-
-	superBlobBytes := make([]byte, cmd.DataSize)
-	if _, err := m.ReadAt(superBlobBytes, int64(cmd.DataOffset)); err != nil {
-		return nil, fmt.Errorf("unable to extract code signing block from macho binary: %w", err)
-	}
-
-	superBlobReader := bytes.NewReader(superBlobBytes)
-
-	csBlob := SuperBlob{}
-	if err := binary.Read(superBlobReader, SigningOrder, &csBlob.SuperBlobHeader); err != nil {
-		return nil, fmt.Errorf("unable to extract superblob header from macho binary: %w", err)
-	}
-
-	csBlob.Index = make([]BlobIndex, csBlob.Count)
-	if err := binary.Read(superBlobReader, SigningOrder, &csBlob.Index); err != nil {
 		return nil, err
 	}
 
-	for _, index := range csBlob.Index {
-		if _, err := superBlobReader.Seek(int64(index.Offset), io.SeekStart); err != nil {
-			return nil, fmt.Errorf("unable to seek to code signing blob index=%d: %w", index.Offset, err)
-		}
-
-		switch index.Type { //nolint:gocritic
-		case CsSlotCmsSignature:
-
-			var blobHeader BlobHeader
-			// read the header
-			if err := binary.Read(superBlobReader, SigningOrder, &blobHeader); err != nil {
-				return nil, err
-			}
-
-			// head back to the beginning of the CD
-			if _, err := superBlobReader.Seek(int64(index.Offset), io.SeekStart); err != nil {
-				return nil, fmt.Errorf("unable to seek to CMS bob: %w", err)
-			}
-
-			b := make([]byte, blobHeader.Length)
-			if err := binary.Read(superBlobReader, order, &b); err != nil {
-				return nil, err
-			}
-
-			return b, nil
-		}
+	if entry := superBlob.Entry(CsSlotCmsSignature); entry != nil {
+		return entry.Data, nil
 	}
 	return nil, fmt.Errorf("unable to find CMS blob")
 }
@@ -468,6 +558,31 @@ func (m *File) HashCD(hasher hash.Hash) (hash []byte, err error) {
 	return hasher.Sum(nil), nil
 }
 
+// CodeDirectoryFlags returns the flags of the (primary) code directory of the signed binary.
+func (m *File) CodeDirectoryFlags() (CdFlag, error) {
+	cdBytes, err := m.CDBytes(SigningOrder, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var header CodeDirectoryHeader
+	flags, err := codeDirectoryField(cdBytes, unsafe.Offsetof(header.Flags))
+	return CdFlag(flags), err
+}
+
+// IsLinkerSigned indicates the binary carries the ad-hoc signature generated by the linker (as ld does for arm64
+// binaries), rather than a signature made by a code signing tool.
+func (m *File) IsLinkerSigned() (bool, error) {
+	if !m.HasCodeSigningCmd() {
+		return false, nil
+	}
+	flags, err := m.CodeDirectoryFlags()
+	if err != nil {
+		return false, err
+	}
+	return flags&LinkerSigned != 0, nil
+}
+
 func packSegment(magic uint32, order binary.ByteOrder, h macho.SegmentHeader) ([]byte, error) {
 	var name [16]byte
 	copy(name[:], h.Name)
@@ -501,3 +616,13 @@ func packSegment(magic uint32, order binary.ByteOrder, h macho.SegmentHeader) ([
 		Flag:    h.Flag,
 	})
 }
+
+// codeDirectoryField reads the uint32 code directory header field at the given offset (relative to the start of the
+// code directory header) from the given code directory blob.
+func codeDirectoryField(cdBytes []byte, offset uintptr) (uint32, error) {
+	start := int(unsafe.Sizeof(BlobHeader{}) + offset)
+	if len(cdBytes) < start+4 {
+		return 0, fmt.Errorf("code directory is too short")
+	}
+	return SigningOrder.Uint32(cdBytes[start:]), nil
+}
@@ -0,0 +1,51 @@
+package macho
+
+import (
+	"bytes"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// OpenFS parses the (possibly multi-arch) binary with the given name within the given file system, returning a
+// read-only file for every architecture. The binary is read into memory, nothing is written to disk (unlike the
+// extraction of multi-arch binaries from a path).
+func OpenFS(fsys fs.FS, name string) ([]*File, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read binary: %w", err)
+	}
+	return NewReadOnlyFilesFromBytes(name, content)
+}
+
+// NewReadOnlyFilesFromBytes parses the given (possibly multi-arch) binary held in memory, returning a read-only file
+// for every architecture.
+func NewReadOnlyFilesFromBytes(name string, content []byte) ([]*File, error) {
+	fat, err := macho.NewFatFile(bytes.NewReader(content))
+	if err != nil {
+		if errors.Is(err, macho.ErrNotFat) {
+			m, err := NewReadOnlyFileFromBytes(name, content)
+			if err != nil {
+				return nil, err
+			}
+			return []*File{m}, nil
+		}
+		return nil, fmt.Errorf("unable to parse multi-arch binary: %w", err)
+	}
+	defer fat.Close()
+
+	var files []*File
+	for _, arch := range fat.Arches {
+		start, end := uint64(arch.Offset), uint64(arch.Offset)+uint64(arch.Size)
+		if end > uint64(len(content)) {
+			return nil, fmt.Errorf("architecture cpu=%s exceeds the multi-arch binary", arch.Cpu)
+		}
+		m, err := NewReadOnlyFileFromBytes(name, content[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse architecture cpu=%s of multi-arch binary: %w", arch.Cpu, err)
+		}
+		files = append(files, m)
+	}
+	return files, nil
+}
@@ -0,0 +1,89 @@
+package macho
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thinBinary returns a minimal executable (without load commands) for the given CPU.
+func thinBinary(cpu macho.Cpu) []byte {
+	by := make([]byte, fileHeaderSize64)
+	binary.LittleEndian.PutUint32(by[0:], macho.Magic64)
+	binary.LittleEndian.PutUint32(by[4:], uint32(cpu))
+	binary.LittleEndian.PutUint32(by[12:], uint32(macho.TypeExec))
+	return by
+}
+
+// universalBinary returns a multi-arch binary holding the given binaries (page aligned).
+func universalBinary(cpus ...macho.Cpu) []byte {
+	by := make([]byte, 8+20*len(cpus))
+	binary.BigEndian.PutUint32(by[0:], macho.MagicFat)
+	binary.BigEndian.PutUint32(by[4:], uint32(len(cpus)))
+	for i, cpu := range cpus {
+		thin := thinBinary(cpu)
+		offset := PageSize * (i + 1)
+		arch := by[8+20*i:]
+		binary.BigEndian.PutUint32(arch[0:], uint32(cpu))
+		binary.BigEndian.PutUint32(arch[8:], uint32(offset))
+		binary.BigEndian.PutUint32(arch[12:], uint32(len(thin)))
+		binary.BigEndian.PutUint32(arch[16:], PageSizeBits)
+
+		by = append(by, make([]byte, offset-len(by))...)
+		by = append(by, thin...)
+	}
+	return by
+}
+
+func TestOpenFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"thin":      {Data: thinBinary(macho.CpuArm64)},
+		"universal": {Data: universalBinary(macho.CpuAmd64, macho.CpuArm64)},
+		"text":      {Data: []byte("not a binary")},
+	}
+
+	tests := []struct {
+		name    string
+		cpus    []macho.Cpu
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "thin",
+			cpus:    []macho.Cpu{macho.CpuArm64},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "universal",
+			cpus:    []macho.Cpu{macho.CpuAmd64, macho.CpuArm64},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "text",
+			wantErr: require.Error,
+		},
+		{
+			name:    "missing",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := OpenFS(fsys, tt.name)
+			tt.wantErr(t, err)
+
+			var cpus []macho.Cpu
+			for _, f := range files {
+				cpus = append(cpus, f.Cpu)
+				assert.Equal(t, int64(fileHeaderSize64), f.Size())
+				assert.False(t, f.HasCodeSigningCmd())
+				assert.ErrorContains(t, f.Patch([]byte{0}, 1, 0), "writes not allowed")
+				require.NoError(t, f.Close())
+			}
+			assert.Equal(t, tt.cpus, cpus)
+		})
+	}
+}
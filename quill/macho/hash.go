@@ -1,10 +1,6 @@
 package macho
 
-import (
-	"bytes"
-	"hash"
-	"io"
-)
+import "hash"
 
 const (
 	HashTypeNohash          HashType = 0
@@ -17,33 +13,31 @@ const (
 
 type HashType uint8
 
-func hashChunks(hasher hash.Hash, chunkSize int, data []byte) (hashes [][]byte, err error) {
+// hashChunks returns the digest of every chunk of the data, calling progress (when given) with the number of bytes
+// hashed after each chunk. The digests share a single pre-sized backing array.
+func hashChunks(hasher hash.Hash, chunkSize int, data []byte, progress func(done, total int)) (hashes [][]byte, err error) {
 	var dataSize = len(data)
-	var dataReader = bytes.NewReader(data)
-	var buf = make([]byte, chunkSize)
-
-loop:
-	for idx := 0; idx < dataSize; {
-		bufferLen, err := io.ReadFull(dataReader, buf)
-		switch err {
-		case nil, io.ErrUnexpectedEOF:
-			break
-		case io.EOF:
-			break loop
-		default:
-			return nil, err
-		}
+	var count = (dataSize + chunkSize - 1) / chunkSize
+
+	hashes = make([][]byte, 0, count)
+	sums := make([]byte, 0, count*hasher.Size())
 
-		if idx+bufferLen > dataSize {
-			bufferLen = dataSize - idx
+	for idx := 0; idx < dataSize; idx += chunkSize {
+		end := idx + chunkSize
+		if end > dataSize {
+			end = dataSize
 		}
-		idx += bufferLen
 
 		hasher.Reset()
-		hasher.Write(buf[:bufferLen])
-		sum := hasher.Sum(nil)
+		hasher.Write(data[idx:end])
+		start := len(sums)
+		sums = hasher.Sum(sums)
 
-		hashes = append(hashes, sum)
+		hashes = append(hashes, sums[start:len(sums):len(sums)])
+
+		if progress != nil {
+			progress(end, dataSize)
+		}
 	}
 	return hashes, nil
 }
@@ -4,10 +4,13 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"hash"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho/machotest"
 )
 
 func Test_hashChunks(t *testing.T) {
@@ -78,7 +81,7 @@ func Test_hashChunks(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotHashes, err := hashChunks(tt.args.hasher, tt.args.chunkSize, []byte(tt.args.data))
+			gotHashes, err := hashChunks(tt.args.hasher, tt.args.chunkSize, []byte(tt.args.data), nil)
 			require.NoError(t, err)
 			var gotHexHash []string
 			for _, b := range gotHashes {
@@ -88,3 +91,43 @@ func Test_hashChunks(t *testing.T) {
 		})
 	}
 }
+
+func Test_hashChunks_progress(t *testing.T) {
+	var got [][2]int
+	_, err := hashChunks(sha256.New(), 4, []byte("0123456789"), func(done, total int) {
+		got = append(got, [2]int{done, total})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{4, 10}, {8, 10}, {10, 10}}, got)
+}
+
+func TestFile_RehashHeaderPages(t *testing.T) {
+	// an arm64 executable with a __TEXT segment (3 pages) followed by a __LINKEDIT segment
+	path := filepath.Join(t.TempDir(), "bin")
+	machotest.Write(t, path, machotest.Config{TextSize: 3 * PageSize})
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+	require.NoError(t, m.AddEmptyCodeSigningCmd())
+
+	previous, err := m.HashPages(sha256.New())
+	require.NoError(t, err)
+	require.Len(t, previous, 4)
+
+	// patch the load commands (as is done between signing passes)
+	require.NoError(t, m.UpdateCodeSigningCmdDataSize(0x1234))
+
+	expected, err := m.HashPages(sha256.New())
+	require.NoError(t, err)
+	assert.NotEqual(t, previous[0], expected[0])
+
+	actual, err := m.RehashHeaderPages(sha256.New(), previous)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// the pages are hashed from scratch when the binary does not have the same number of pages
+	actual, err = m.RehashHeaderPages(sha256.New(), previous[:2])
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
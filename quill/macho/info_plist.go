@@ -0,0 +1,47 @@
+package macho
+
+import (
+	"debug/macho"
+	"errors"
+	"fmt"
+)
+
+// EmbeddedInfoPlist returns the Info.plist linked into the (single-arch or universal) binary at the given path as the
+// __TEXT,__info_plist section (typically by command line tools, which are not within a bundle). Nil is returned when
+// the binary has no embedded Info.plist.
+func EmbeddedInfoPlist(path string) ([]byte, error) {
+	fat, err := macho.OpenFat(path)
+	switch {
+	case err == nil:
+		defer fat.Close()
+		for _, arch := range fat.Arches {
+			if raw, err := infoPlistSection(arch.File); raw != nil || err != nil {
+				return raw, err
+			}
+		}
+		return nil, nil
+	case !errors.Is(err, macho.ErrNotFat):
+		return nil, fmt.Errorf("unable to open universal binary: %w", err)
+	}
+
+	f, err := macho.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open binary: %w", err)
+	}
+	defer f.Close()
+	return infoPlistSection(f)
+}
+
+func infoPlistSection(f *macho.File) ([]byte, error) {
+	for _, s := range f.Sections {
+		if s.Seg != "__TEXT" || s.Name != "__info_plist" {
+			continue
+		}
+		raw, err := s.Data()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the embedded Info.plist: %w", err)
+		}
+		return raw, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+package macho
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textSegmentWithInfoPlist is a __TEXT segment load command holding an __info_plist section with the given contents,
+// which directly follow the load command (of a binary with only this load command).
+func textSegmentWithInfoPlist(plist string) []byte {
+	name := func(s string) [16]byte {
+		var by [16]byte
+		copy(by[:], s)
+		return by
+	}
+	const cmdSize = 72 + 80
+	contentsOffset := fileHeaderSize64 + cmdSize
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, struct {
+		Cmd, Size                         uint32
+		Name                              [16]byte
+		Addr, MemSize, Offset, FileSize   uint64
+		MaxProt, Prot, NSections, Flags   uint32
+		SectName, SegName                 [16]byte
+		SectAddr, SectSize                uint64
+		SectOffset, Align, RelOff, NReloc uint32
+		SectFlags, Res1, Res2, Res3       uint32
+	}{
+		Cmd: 0x19, Size: cmdSize, Name: name("__TEXT"),
+		MemSize: uint64(contentsOffset + len(plist)), FileSize: uint64(contentsOffset + len(plist)),
+		MaxProt: 5, Prot: 5, NSections: 1,
+		SectName: name("__info_plist"), SegName: name("__TEXT"),
+		SectAddr: uint64(contentsOffset), SectSize: uint64(len(plist)), SectOffset: uint32(contentsOffset),
+	})
+	return buf.Bytes()
+}
+
+func TestEmbeddedInfoPlist(t *testing.T) {
+	plist := `<plist version="1.0"><dict><key>CFBundleIdentifier</key><string>com.example.tool</string></dict></plist>`
+
+	t.Run("embedded", func(t *testing.T) {
+		path := writeMachoWithLoads(t, textSegmentWithInfoPlist(plist))
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString(plist)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		raw, err := EmbeddedInfoPlist(path)
+		require.NoError(t, err)
+		assert.Equal(t, plist, string(raw))
+	})
+
+	t.Run("not embedded", func(t *testing.T) {
+		raw, err := EmbeddedInfoPlist(writeMachoWithLoads(t, loadCommand(0x1b, 24, 0, 0, 0, 0)))
+		require.NoError(t, err)
+		assert.Nil(t, raw)
+	})
+
+	t.Run("not a binary", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "script.sh")
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh"), 0600))
+		_, err := EmbeddedInfoPlist(path)
+		require.Error(t, err)
+	})
+}
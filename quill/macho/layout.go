@@ -0,0 +1,78 @@
+package macho
+
+import (
+	"fmt"
+)
+
+// BlobLayout describes where a single blob is located within the superblob.
+type BlobLayout struct {
+	Type       SlotType
+	Offset     uint32 // relative to the start of the superblob
+	FileOffset uint64 // relative to the start of the (thin) binary
+	Magic      Magic
+	Length     uint32
+}
+
+// SuperBlobLayout reads the superblob header along with the slot type, offset, magic, and length of every blob
+// indexed by the superblob.
+func (m *File) SuperBlobLayout() (*SuperBlobHeader, []BlobLayout, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+
+	superBlob, err := m.ReadSuperBlob()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var layout []BlobLayout
+	for _, entry := range superBlob.Entries {
+		layout = append(layout, BlobLayout{
+			Type:       entry.Slot,
+			Offset:     entry.Offset,
+			FileOffset: uint64(cmd.DataOffset) + uint64(entry.Offset),
+			Magic:      entry.Magic,
+			Length:     uint32(len(entry.Data)),
+		})
+	}
+
+	return &superBlob.SuperBlobHeader, layout, nil
+}
+
+// SlotBytes returns the raw bytes (including the blob header) of the first blob within the superblob for the given
+// slot type. A nil slice is returned (without an error) when there is no blob for the slot.
+func (m *File) SlotBytes(slot SlotType) ([]byte, error) {
+	_, layout, err := m.SuperBlobLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blob := range layout {
+		if blob.Type == slot {
+			return m.BlobBytes(blob)
+		}
+	}
+	return nil, nil
+}
+
+// BlobBytes returns the raw bytes (including the blob header) of the given blob.
+func (m *File) BlobBytes(blob BlobLayout) ([]byte, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+
+	if cmd == nil {
+		return nil, fmt.Errorf("binary is not signed")
+	}
+	if uint64(blob.Offset)+uint64(blob.Length) > uint64(cmd.DataSize) {
+		return nil, malformed("superblob", "blob for slot=%d exceeds the code signing block", blob.Type)
+	}
+
+	b, err := m.ReadBytes(blob.FileOffset, uint64(blob.Length))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read blob for slot=%d: %w", blob.Type, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,130 @@
+package macho
+
+import (
+	"debug/macho"
+	"fmt"
+	"sort"
+)
+
+// load commands referencing content of the __LINKEDIT segment
+const (
+	LcSymtab                 LoadCommandType = 0x2
+	LcDysymtab               LoadCommandType = 0xb
+	LcSegmentSplitInfo       LoadCommandType = 0x1e
+	LcDyldInfo               LoadCommandType = 0x22
+	LcDyldInfoOnly           LoadCommandType = 0x22 | lcReqDyld
+	LcFunctionStarts         LoadCommandType = 0x26
+	LcDataInCode             LoadCommandType = 0x29
+	LcDylibCodeSignDrs       LoadCommandType = 0x2b
+	LcLinkerOptimizationHint LoadCommandType = 0x2e
+	LcDyldExportsTrie        LoadCommandType = 0x33 | lcReqDyld
+	LcDyldChainedFixups      LoadCommandType = 0x34 | lcReqDyld
+)
+
+// LinkEditDataCommand is a Mach-O load command referencing a range of __LINKEDIT (linkedit_data_command), e.g. the
+// chained fixups (LcDyldChainedFixups) and the exports trie (LcDyldExportsTrie) of binaries made by modern linkers.
+type LinkEditDataCommand struct {
+	Cmd        LoadCommandType
+	Size       uint32
+	DataOffset uint32 // file offset of data in __LINKEDIT segment
+	DataSize   uint32 // file size of data in __LINKEDIT segment
+}
+
+// LinkEditContent is a range of __LINKEDIT referenced by a load command (see File.LinkEditContents).
+type LinkEditContent struct {
+	Cmd LoadCommandType
+	// Offset and Size are the range of the content within the (single-arch) binary.
+	Offset uint64
+	Size   uint64
+}
+
+// LinkEditContents returns the ranges of __LINKEDIT referenced by the load commands of the binary (fixups, exports,
+// symbols, strings...) in file order, apart from the code signature. The code signature must follow all of them, which
+// is checked when it is added or resized.
+func (m *File) LinkEditContents() ([]LinkEditContent, error) {
+	var contents []LinkEditContent
+	add := func(cmd LoadCommandType, offset, size uint64) {
+		if size != 0 {
+			contents = append(contents, LinkEditContent{Cmd: cmd, Offset: offset, Size: size})
+		}
+	}
+
+	nlistSize := uint64(12)
+	if m.Magic == macho.Magic64 {
+		nlistSize = 16
+	}
+
+	for i, l := range m.Loads {
+		data := l.Raw()
+		if len(data) < 8 {
+			return nil, malformed("load command", "%d is truncated", i)
+		}
+		cmd := LoadCommandType(m.ByteOrder.Uint32(data))
+		field := func(index int) uint64 {
+			return uint64(m.ByteOrder.Uint32(data[8+4*index:]))
+		}
+
+		switch cmd {
+		case LcSymtab:
+			if len(data) < 24 {
+				return nil, malformed("load command", "%d (0x%x) is truncated", i, uint32(cmd))
+			}
+			add(cmd, field(0), field(1)*nlistSize)
+			add(cmd, field(2), field(3))
+		case LcDysymtab:
+			if len(data) < 80 {
+				return nil, malformed("load command", "%d (0x%x) is truncated", i, uint32(cmd))
+			}
+			moduleSize := uint64(52)
+			if m.Magic == macho.Magic64 {
+				moduleSize = 56
+			}
+			add(cmd, field(6), field(7)*8)          // table of contents
+			add(cmd, field(8), field(9)*moduleSize) // module table
+			add(cmd, field(10), field(11)*4)        // referenced symbols
+			add(cmd, field(12), field(13)*4)        // indirect symbols
+			add(cmd, field(14), field(15)*8)        // external relocations
+			add(cmd, field(16), field(17)*8)        // local relocations
+		case LcDyldInfo, LcDyldInfoOnly:
+			if len(data) < 48 {
+				return nil, malformed("load command", "%d (0x%x) is truncated", i, uint32(cmd))
+			}
+			for f := 0; f < 10; f += 2 {
+				add(cmd, field(f), field(f+1))
+			}
+		case LcSegmentSplitInfo, LcFunctionStarts, LcDataInCode, LcDylibCodeSignDrs, LcLinkerOptimizationHint,
+			LcDyldExportsTrie, LcDyldChainedFixups:
+			if len(data) < 16 {
+				return nil, malformed("load command", "%d (0x%x) is truncated", i, uint32(cmd))
+			}
+			add(cmd, field(0), field(1))
+		}
+	}
+
+	sort.SliceStable(contents, func(i, j int) bool {
+		return contents[i].Offset < contents[j].Offset
+	})
+	return contents, nil
+}
+
+// linkEditContentEnd returns the end offset of the content of __LINKEDIT preceding the code signature, which is the
+// start of __LINKEDIT when the load commands reference no content.
+func (m *File) linkEditContentEnd() (uint64, error) {
+	linkEditSeg := m.Segment("__LINKEDIT")
+	if linkEditSeg == nil {
+		return 0, fmt.Errorf("binary has no __LINKEDIT segment")
+	}
+
+	contents, err := m.LinkEditContents()
+	if err != nil {
+		return 0, err
+	}
+
+	end := linkEditSeg.Offset
+	for _, c := range contents {
+		if c.Offset+c.Size > end {
+			end = c.Offset + c.Size
+		}
+	}
+	return end, nil
+}
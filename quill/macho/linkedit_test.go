@@ -0,0 +1,63 @@
+package macho
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_LinkEditContents(t *testing.T) {
+	// __LINKEDIT holds 0x40 bytes at 0x500 (see writeLinkEditMacho), laid out as ld does for chained fixups binaries
+	path := writeLinkEditMacho(t,
+		// symoff, nsyms, stroff, strsize (no symbols, so the content is not parsed as a symbol table)
+		loadCommand(uint32(LcSymtab), 24, 0x530, 0, 0x530, 0x10),
+		loadCommand(uint32(LcDyldExportsTrie), 16, 0x520, 0x8),
+		loadCommand(uint32(LcDyldChainedFixups), 16, 0x500, 0x20),
+		loadCommand(uint32(LcFunctionStarts), 16, 0x528, 0x8),
+	)
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	contents, err := m.LinkEditContents()
+	require.NoError(t, err)
+	assert.Equal(t, []LinkEditContent{
+		{Cmd: LcDyldChainedFixups, Offset: 0x500, Size: 0x20},
+		{Cmd: LcDyldExportsTrie, Offset: 0x520, Size: 0x8},
+		{Cmd: LcFunctionStarts, Offset: 0x528, Size: 0x8},
+		{Cmd: LcSymtab, Offset: 0x530, Size: 0x10},
+	}, contents)
+
+	cmds, err := m.LoadCommands()
+	require.NoError(t, err)
+	assert.Equal(t, LinkEditDataCommand{Cmd: LcDyldChainedFixups, Size: 16, DataOffset: 0x500, DataSize: 0x20}, cmds[4].Value)
+
+	// the signature cannot overlap the string table, even when resizing an existing signature
+	require.NoError(t, m.ReserveCodeSignature(0x540, 0x100))
+	require.Error(t, m.ReserveCodeSignature(0x538, 0x100))
+}
+
+func TestFile_RemoveSigningContent_linkEdit(t *testing.T) {
+	path := writeLinkEditMacho(t, loadCommand(uint32(LcDyldChainedFixups), 16, 0x500, 0x40))
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.NoError(t, m.ReserveCodeSignature(0x540, 0x100))
+	require.NoError(t, m.WriteCodeSignature([]byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 12, 0, 0, 0, 0}))
+	assert.Equal(t, int64(0x640), m.Size())
+
+	// removing the signature restores the original layout: __LINKEDIT and the binary end with the chained fixups
+	require.NoError(t, m.RemoveSigningContent())
+	assert.False(t, m.HasCodeSigningCmd())
+	assert.Equal(t, uint64(0x40), m.Segment("__LINKEDIT").Filesz)
+	assert.Equal(t, int64(0x540), m.Size())
+
+	require.NoError(t, m.AddEmptyCodeSigningCmd())
+	cmd, _, err := m.CodeSigningCmd()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x540), cmd.DataOffset)
+}
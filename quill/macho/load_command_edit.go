@@ -0,0 +1,340 @@
+package macho
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"io"
+
+	"github.com/go-restruct/restruct"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+const LcRpath LoadCommandType = 0x1c | lcReqDyld
+
+// RpathCommand is the Mach-O LcRpath load command, adding a directory to the run path searched for @rpath libraries.
+type RpathCommand struct {
+	Cmd  LoadCommandType // LcRpath
+	Size uint32
+	Path string
+}
+
+// LoadCommandEdit changes the load commands of a binary (see e.g. AddRpath and ChangeDylib). Edits invalidate any
+// existing signature, the binary has to be signed afterwards.
+type LoadCommandEdit func(*File) error
+
+// AddRpath adds a run path (LcRpath) to the binary, unless it is already present.
+func AddRpath(path string) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		if findRpath(cmds, path) >= 0 {
+			return nil
+		}
+		return m.AddLoadCommand(m.NewRpathCommand(path))
+	}
+}
+
+// RemoveRpath removes the given run path (LcRpath) from the binary, it is an error if the run path is not present.
+func RemoveRpath(path string) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		i := findRpath(cmds, path)
+		if i < 0 {
+			return fmt.Errorf("no run path %q found", path)
+		}
+		return m.RemoveLoadCommand(i)
+	}
+}
+
+// AddDylib adds a dependency on the given library with a load command of the given type (e.g. LcLoadDylib or
+// LcLoadWeakDylib), after the existing dependencies so the ordinals of the existing libraries are kept.
+func AddDylib(cmd LoadCommandType, name string, current, compat Version) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		if findDylib(cmds, name) >= 0 {
+			return fmt.Errorf("the binary already depends on %q", name)
+		}
+		return m.AddLoadCommand(m.NewDylibCommand(cmd, name, current, compat))
+	}
+}
+
+// RemoveDylib removes the dependency on the given library. This changes the ordinals of the libraries loaded after it,
+// so it is only safe for libraries no symbol is bound to (or when they are the last dependency).
+func RemoveDylib(name string) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		i := findDylib(cmds, name)
+		if i < 0 || cmds[i].Type == LcIDDylib {
+			return fmt.Errorf("the binary does not depend on %q", name)
+		}
+		return m.RemoveLoadCommand(i)
+	}
+}
+
+// ChangeDylib replaces the install name of a library the binary depends on (as install_name_tool -change does),
+// keeping the type, versions, and position of the load command.
+func ChangeDylib(oldName, newName string) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		i := findDylib(cmds, oldName)
+		if i < 0 || cmds[i].Type == LcIDDylib {
+			return fmt.Errorf("the binary does not depend on %q", oldName)
+		}
+		d := cmds[i].Value.(DylibCommand)
+		return m.ReplaceLoadCommand(i, m.NewDylibCommand(d.Cmd, newName, d.CurrentVersion, d.CompatibilityVersion))
+	}
+}
+
+// SetInstallName replaces the install name (LcIDDylib) of a dynamic library (as install_name_tool -id does).
+func SetInstallName(name string) LoadCommandEdit {
+	return func(m *File) error {
+		cmds, err := m.LoadCommands()
+		if err != nil {
+			return err
+		}
+		for i, c := range cmds {
+			if c.Type == LcIDDylib {
+				d := c.Value.(DylibCommand)
+				return m.ReplaceLoadCommand(i, m.NewDylibCommand(LcIDDylib, name, d.CurrentVersion, d.CompatibilityVersion))
+			}
+		}
+		return fmt.Errorf("the binary is not a dynamic library (there is no install name)")
+	}
+}
+
+func findRpath(cmds []LoadCommand, path string) int {
+	for i, c := range cmds {
+		if r, ok := c.Value.(RpathCommand); ok && r.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+func findDylib(cmds []LoadCommand, name string) int {
+	for i, c := range cmds {
+		if d, ok := c.Value.(DylibCommand); ok && d.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// NewRpathCommand encodes an LcRpath load command for the binary (padded to the load command alignment).
+func (m *File) NewRpathCommand(path string) []byte {
+	return m.newStringCommand(LcRpath, 12, path, nil)
+}
+
+// NewDylibCommand encodes a dylib load command of the given type for the binary (padded to the load command
+// alignment).
+func (m *File) NewDylibCommand(cmd LoadCommandType, name string, current, compat Version) []byte {
+	return m.newStringCommand(cmd, 24, name, []uint32{0, uint32(current), uint32(compat)})
+}
+
+// newStringCommand encodes a load command holding a string (placed after the fixed fields), the fields following
+// the string offset are given.
+func (m *File) newStringCommand(cmd LoadCommandType, headerSize int, s string, fields []uint32) []byte {
+	size := m.alignLoadCommand(headerSize + len(s) + 1)
+	by := make([]byte, size)
+	m.ByteOrder.PutUint32(by[0:], uint32(cmd))
+	m.ByteOrder.PutUint32(by[4:], uint32(size))
+	m.ByteOrder.PutUint32(by[8:], uint32(headerSize))
+	for i, f := range fields {
+		m.ByteOrder.PutUint32(by[12+4*i:], f)
+	}
+	copy(by[headerSize:], s)
+	return by
+}
+
+// loadCommandAlignment is the alignment of the size of every load command.
+func (m *File) loadCommandAlignment() int {
+	if m.Magic == macho.Magic64 {
+		return 8
+	}
+	return 4
+}
+
+func (m *File) alignLoadCommand(size int) int {
+	align := m.loadCommandAlignment()
+	return (size + align - 1) &^ (align - 1)
+}
+
+// AddLoadCommand inserts the given encoded load command, before the code signature command when the binary has one
+// (which must stay the last command), otherwise after all other commands. There must be enough free (zeroed) space
+// between the load commands and the first section of the binary.
+func (m *File) AddLoadCommand(cmd []byte) error {
+	if err := m.checkLoadCommand(cmd); err != nil {
+		return err
+	}
+
+	raws := m.rawLoadCommands()
+	at := len(raws)
+	for i, raw := range raws {
+		if LoadCommandType(m.ByteOrder.Uint32(raw)) == LcCodeSignature {
+			at = i
+			break
+		}
+	}
+
+	log.WithFields("type", fmt.Sprintf("0x%x", m.ByteOrder.Uint32(cmd)), "size", len(cmd), "index", at).Trace("adding load command")
+
+	edited := append(append(append([][]byte{}, raws[:at]...), cmd), raws[at:]...)
+	return m.writeLoadCommands(edited)
+}
+
+// RemoveLoadCommand removes the load command at the given index (as returned by LoadCommands), moving the commands
+// following it up. Segments and the code signature cannot be removed (see RemoveSigningContent).
+func (m *File) RemoveLoadCommand(index int) error {
+	raws := m.rawLoadCommands()
+	if index < 0 || index >= len(raws) {
+		return fmt.Errorf("no load command at index %d", index)
+	}
+	if err := checkEditable(LoadCommandType(m.ByteOrder.Uint32(raws[index]))); err != nil {
+		return err
+	}
+
+	log.WithFields("index", index).Trace("removing load command")
+
+	edited := append(append([][]byte{}, raws[:index]...), raws[index+1:]...)
+	return m.writeLoadCommands(edited)
+}
+
+// ReplaceLoadCommand replaces the load command at the given index with the given encoded load command, which may be of
+// a different size (the commands following it are moved accordingly).
+func (m *File) ReplaceLoadCommand(index int, cmd []byte) error {
+	raws := m.rawLoadCommands()
+	if index < 0 || index >= len(raws) {
+		return fmt.Errorf("no load command at index %d", index)
+	}
+	if err := checkEditable(LoadCommandType(m.ByteOrder.Uint32(raws[index]))); err != nil {
+		return err
+	}
+	if err := m.checkLoadCommand(cmd); err != nil {
+		return err
+	}
+
+	log.WithFields("index", index, "size", len(cmd)).Trace("replacing load command")
+
+	edited := append([][]byte{}, raws...)
+	edited[index] = cmd
+	return m.writeLoadCommands(edited)
+}
+
+func checkEditable(cmd LoadCommandType) error {
+	switch cmd {
+	case LcSegment, LcSegment64:
+		return fmt.Errorf("segment load commands cannot be edited")
+	case LcCodeSignature:
+		return fmt.Errorf("the code signature load command cannot be edited (sign the binary instead)")
+	}
+	return nil
+}
+
+func (m *File) checkLoadCommand(cmd []byte) error {
+	if len(cmd) < 8 {
+		return fmt.Errorf("load command is too short (%d bytes)", len(cmd))
+	}
+	if size := m.ByteOrder.Uint32(cmd[4:]); int(size) != len(cmd) {
+		return fmt.Errorf("load command size %d does not match its length %d", size, len(cmd))
+	}
+	if len(cmd)%m.loadCommandAlignment() != 0 {
+		return fmt.Errorf("load command size %d is not a multiple of %d", len(cmd), m.loadCommandAlignment())
+	}
+	switch LoadCommandType(m.ByteOrder.Uint32(cmd)) {
+	case LcSegment, LcSegment64, LcCodeSignature:
+		return checkEditable(LoadCommandType(m.ByteOrder.Uint32(cmd)))
+	}
+	return nil
+}
+
+func (m *File) rawLoadCommands() [][]byte {
+	raws := make([][]byte, 0, len(m.Loads))
+	for _, l := range m.Loads {
+		raws = append(raws, l.Raw())
+	}
+	return raws
+}
+
+// loadCommandSpaceEnd returns the file offset where the space available to load commands ends: the start of the first
+// section (or of the first segment holding content after the header when there are no sections).
+func (m *File) loadCommandSpaceEnd() uint64 {
+	end := uint64(m.size)
+	for _, seg := range m.Segments() {
+		if seg.Offset > 0 && seg.FileSize > 0 && seg.Offset < end {
+			end = seg.Offset
+		}
+		for _, s := range seg.Sections {
+			if !s.IsZerofill() && s.Offset > 0 && uint64(s.Offset) < end {
+				end = uint64(s.Offset)
+			}
+		}
+	}
+	return end
+}
+
+// writeLoadCommands replaces all load commands of the binary with the given (encoded) commands, updating the number
+// and size of the commands within the header. Space freed by shrinking the commands is zeroed.
+func (m *File) writeLoadCommands(cmds [][]byte) error {
+	var buf bytes.Buffer
+	for _, c := range cmds {
+		buf.Write(c)
+	}
+
+	start := m.firstCmdOffset()
+	oldSize := uint64(m.FileHeader.Cmdsz)
+	newSize := uint64(buf.Len())
+
+	if newSize > oldSize {
+		end := m.loadCommandSpaceEnd()
+		if start+newSize > end {
+			return fmt.Errorf("no room for the load commands: %d bytes are needed but only %d bytes are available before the first section (the binary has to be linked with -headerpad)", newSize, end-start)
+		}
+		// the space taken must be unused padding
+		padding := make([]byte, newSize-oldSize)
+		if _, err := io.ReadFull(io.NewSectionReader(m.ReaderAt, int64(start+oldSize), int64(len(padding))), padding); err != nil {
+			return fmt.Errorf("unable to read the padding after the load commands: %w", err)
+		}
+		if !bytes.Equal(padding, make([]byte, len(padding))) {
+			return fmt.Errorf("no room for the load commands: the space after the load commands is not empty")
+		}
+	} else {
+		buf.Write(make([]byte, oldSize-newSize))
+	}
+
+	header := m.FileHeader
+	header.Ncmd = uint32(len(cmds))
+	header.Cmdsz = uint32(newSize)
+
+	headerBytes, err := restruct.Pack(m.ByteOrder, &header)
+	if err != nil {
+		return fmt.Errorf("unable to pack modified macho header: %w", err)
+	}
+
+	if m.WriterAt == nil {
+		return fmt.Errorf("writes not allowed")
+	}
+	// the binary is only parsed again once both the commands and the header are consistent
+	if _, err := m.WriteAt(buf.Bytes(), int64(start)); err != nil {
+		return fmt.Errorf("unable to patch load commands: %w", err)
+	}
+	if _, err := m.WriteAt(headerBytes, 0); err != nil {
+		return fmt.Errorf("unable to patch macho header: %w", err)
+	}
+	return m.refresh(true)
+}
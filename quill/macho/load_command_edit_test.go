@@ -0,0 +1,179 @@
+package macho
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEditableMacho writes a binary with the given load commands followed by zeroed padding up to its __DATA segment
+// (holding content at contentOffset), which bounds the space available to the load commands.
+func writeEditableMacho(t *testing.T, contentOffset uint32, cmds ...[]byte) string {
+	t.Helper()
+	data := loadCommand(uint32(LcSegment64), 72,
+		// __DATA
+		0x41445f5f, 0x4154, 0, 0,
+		// vmaddr, vmsize, fileoff, filesize (64-bit)
+		0x4000, 0, 0x100, 0, contentOffset, 0, 0x100, 0,
+		// maxprot, initprot, nsects, flags
+		3, 3, 0, 0)
+
+	path := writeMachoWithLoads(t, append([][]byte{data}, cmds...)...)
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(by), int(contentOffset))
+
+	by = append(by, make([]byte, int(contentOffset)-len(by))...)
+	content := make([]byte, 0x100)
+	for i := range content {
+		content[i] = 0xaa
+	}
+	require.NoError(t, os.WriteFile(path, append(by, content...), 0600))
+	return path
+}
+
+func loadCommandTypes(t *testing.T, m *File) []LoadCommandType {
+	t.Helper()
+	cmds, err := m.LoadCommands()
+	require.NoError(t, err)
+	var types []LoadCommandType
+	for _, c := range cmds {
+		types = append(types, c.Type)
+	}
+	return types
+}
+
+func TestFile_LoadCommandEdits(t *testing.T) {
+	libSystem := dylibCommand(LcLoadDylib, "/usr/lib/libSystem.B.dylib", NewVersion(1319, 0, 0), NewVersion(1, 0, 0))
+	libFoo := dylibCommand(LcLoadDylib, "/usr/local/lib/libfoo.dylib", NewVersion(2, 0, 0), NewVersion(1, 0, 0))
+	uuid := loadCommand(uint32(LcUUID), 24, 1, 2, 3, 4)
+
+	path := writeEditableMacho(t, 0x400, libSystem, libFoo, uuid)
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	for _, edit := range []LoadCommandEdit{
+		ChangeDylib("/usr/local/lib/libfoo.dylib", "@rpath/libfoo.dylib"),
+		AddRpath("@executable_path/../lib"),
+		AddRpath("@executable_path/../lib"), // already present
+		AddRpath("/opt/lib"),
+		RemoveRpath("/opt/lib"),
+	} {
+		require.NoError(t, edit(m))
+	}
+
+	assert.Equal(t, []LoadCommandType{LcSegment64, LcLoadDylib, LcLoadDylib, LcUUID, LcRpath}, loadCommandTypes(t, m))
+
+	cmds, err := m.LoadCommands()
+	require.NoError(t, err)
+	assert.Equal(t, DylibCommand{
+		Cmd:                  LcLoadDylib,
+		Size:                 48,
+		Name:                 "@rpath/libfoo.dylib",
+		CurrentVersion:       NewVersion(2, 0, 0),
+		CompatibilityVersion: NewVersion(1, 0, 0),
+	}, cmds[2].Value)
+	assert.Equal(t, RpathCommand{Cmd: LcRpath, Size: 40, Path: "@executable_path/../lib"}, cmds[4].Value)
+
+	var size uint32
+	for _, c := range cmds {
+		size += uint32(len(c.Raw))
+	}
+	assert.Equal(t, uint32(5), m.FileHeader.Ncmd)
+	assert.Equal(t, size, m.FileHeader.Cmdsz)
+
+	// the space freed by shrinking the commands is zeroed, the segment content is untouched
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	end := fileHeaderSize64 + int(size)
+	assert.Equal(t, make([]byte, 0x400-end), by[end:0x400])
+	assert.Equal(t, byte(0xaa), by[0x400])
+
+	// a new reader agrees with the edited binary
+	reread, err := NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer reread.Close()
+	assert.Equal(t, loadCommandTypes(t, m), loadCommandTypes(t, reread))
+}
+
+func TestFile_AddLoadCommand_beforeCodeSignature(t *testing.T) {
+	path := writeEditableMacho(t, 0x400, loadCommand(uint32(LcCodeSignature), 16, 0x500, 0))
+
+	m, err := NewFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.NoError(t, AddRpath("@loader_path")(m))
+	assert.Equal(t, []LoadCommandType{LcSegment64, LcRpath, LcCodeSignature}, loadCommandTypes(t, m))
+
+	_, _, err = m.CodeSigningCmd()
+	require.NoError(t, err)
+}
+
+func TestFile_LoadCommandEdits_errors(t *testing.T) {
+	dylib := func(t *testing.T) *File {
+		// the commands take 72 + 56 bytes, leaving 8 bytes of padding before the segment content
+		path := writeEditableMacho(t, fileHeaderSize64+72+56+8,
+			dylibCommand(LcIDDylib, "/usr/local/lib/libfoo.1.dylib", NewVersion(1, 0, 0), NewVersion(1, 0, 0)))
+		m, err := NewFile(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { m.Close() })
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		edit    LoadCommandEdit
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "no room",
+			edit:    AddRpath("@loader_path/../Frameworks"),
+			wantErr: require.Error,
+		},
+		{
+			name:    "fits in the padding",
+			edit:    SetInstallName("/usr/local/lib/libfoo.1.0.0.dylib"),
+			wantErr: require.NoError,
+		},
+		{
+			name:    "shrinks",
+			edit:    SetInstallName("@rpath/libfoo.dylib"),
+			wantErr: require.NoError,
+		},
+		{
+			name:    "missing run path",
+			edit:    RemoveRpath("/opt/lib"),
+			wantErr: require.Error,
+		},
+		{
+			name:    "install name is not a dependency",
+			edit:    ChangeDylib("/usr/local/lib/libfoo.1.dylib", "@rpath/libfoo.dylib"),
+			wantErr: require.Error,
+		},
+		{
+			name: "segment",
+			edit: func(m *File) error {
+				return m.RemoveLoadCommand(0)
+			},
+			wantErr: require.Error,
+		},
+		{
+			name: "misaligned",
+			edit: func(m *File) error {
+				return m.AddLoadCommand(loadCommand(uint32(LcRpath), 12, 12))
+			},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := dylib(t)
+			tt.wantErr(t, tt.edit(m))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package macho
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+
+	"github.com/go-restruct/restruct"
+)
+
+// lcReqDyld marks load commands that dyld must understand to load the binary.
+const lcReqDyld LoadCommandType = 0x80000000
+
+const (
+	LcSegment         LoadCommandType = 0x1
+	LcLoadDylib       LoadCommandType = 0xc
+	LcIDDylib         LoadCommandType = 0xd
+	LcLoadWeakDylib   LoadCommandType = 0x18 | lcReqDyld
+	LcSegment64       LoadCommandType = 0x19
+	LcUUID            LoadCommandType = 0x1b
+	LcReexportDylib   LoadCommandType = 0x1f | lcReqDyld
+	LcLazyLoadDylib   LoadCommandType = 0x20
+	LcLoadUpwardDylib LoadCommandType = 0x23 | lcReqDyld
+)
+
+// LoadCommand is a single load command of a binary (see File.LoadCommands).
+type LoadCommand struct {
+	Type LoadCommandType
+	// Offset is the file offset of the command within the (single-arch) binary.
+	Offset uint64
+	// Raw is the entire command, including the command type and size.
+	Raw []byte
+	// Value is the decoded command for the types quill knows about, nil otherwise:
+	//   - macho.SegmentHeader (from debug/macho) for LcSegment and LcSegment64
+	//   - DylibCommand for LcIDDylib and the LcLoad*Dylib, LcReexportDylib, and LcLazyLoadDylib commands
+	//   - BuildVersionCommand for LcBuildVersion
+	//   - VersionMinCommand for the LcVersionMin* commands
+	//   - UUIDCommand for LcUUID
+	//   - RpathCommand for LcRpath
+	//   - LinkEditDataCommand for LcDyldChainedFixups, LcDyldExportsTrie, and the other linkedit_data_command types
+	//   - CodeSigningCommand for LcCodeSignature
+	Value interface{}
+}
+
+// DylibCommand is a Mach-O load command naming a dynamic library: the install name of the library itself (LcIDDylib)
+// or of a library the binary depends on (LcLoadDylib and friends).
+type DylibCommand struct {
+	Cmd                  LoadCommandType
+	Size                 uint32
+	Name                 string
+	Timestamp            uint32
+	CurrentVersion       Version
+	CompatibilityVersion Version
+}
+
+// UUIDCommand is the Mach-O LcUUID load command, identifying the build of the binary (and its debug symbols).
+type UUIDCommand struct {
+	Cmd  LoadCommandType // LcUUID
+	Size uint32          // sizeof this command (24)
+	UUID [16]byte
+}
+
+// String formats the UUID as dwarfdump and otool do (e.g. "6B4A3B3A-...").
+func (u UUIDCommand) String() string {
+	b := u.UUID
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// LoadCommands returns every load command of the binary in order, decoding the commands quill knows about (see
+// LoadCommand.Value).
+func (m *File) LoadCommands() ([]LoadCommand, error) {
+	var (
+		cmds   []LoadCommand
+		offset = m.firstCmdOffset()
+	)
+	for i, l := range m.Loads {
+		data := l.Raw()
+		if len(data) < 8 {
+			return nil, malformed("load command", "%d is truncated", i)
+		}
+		lc := LoadCommand{
+			Type:   LoadCommandType(m.ByteOrder.Uint32(data)),
+			Offset: offset,
+			Raw:    data,
+		}
+
+		value, err := m.decodeLoadCommand(l, lc.Type, data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode load command %d (0x%x): %w", i, uint32(lc.Type), err)
+		}
+		lc.Value = value
+
+		cmds = append(cmds, lc)
+		offset += uint64(len(data))
+	}
+	return cmds, nil
+}
+
+func (m *File) decodeLoadCommand(l macho.Load, cmd LoadCommandType, data []byte) (interface{}, error) {
+	switch cmd {
+	case LcSegment, LcSegment64:
+		if seg, ok := l.(*macho.Segment); ok {
+			return seg.SegmentHeader, nil
+		}
+		return nil, fmt.Errorf("unable to read segment")
+	case LcIDDylib, LcLoadDylib, LcLoadWeakDylib, LcReexportDylib, LcLazyLoadDylib, LcLoadUpwardDylib:
+		return m.decodeDylibCommand(data)
+	case LcBuildVersion:
+		var value BuildVersionCommand
+		return value, restruct.Unpack(data, m.ByteOrder, &value)
+	case LcVersionMinMacosx, LcVersionMinIphoneos, LcVersionMinTvos, LcVersionMinWatchos:
+		var value VersionMinCommand
+		return value, restruct.Unpack(data, m.ByteOrder, &value)
+	case LcUUID:
+		var value UUIDCommand
+		return value, restruct.Unpack(data, m.ByteOrder, &value)
+	case LcRpath:
+		path, err := m.loadCommandString(data, 12)
+		if err != nil {
+			return nil, err
+		}
+		return RpathCommand{Cmd: cmd, Size: m.ByteOrder.Uint32(data[4:]), Path: path}, nil
+	case LcCodeSignature:
+		var value CodeSigningCommand
+		return value, restruct.Unpack(data, m.ByteOrder, &value)
+	case LcSegmentSplitInfo, LcFunctionStarts, LcDataInCode, LcDylibCodeSignDrs, LcLinkerOptimizationHint,
+		LcDyldExportsTrie, LcDyldChainedFixups:
+		var value LinkEditDataCommand
+		return value, restruct.Unpack(data, m.ByteOrder, &value)
+	}
+	return nil, nil
+}
+
+func (m *File) decodeDylibCommand(data []byte) (DylibCommand, error) {
+	name, err := m.loadCommandString(data, 24)
+	if err != nil {
+		return DylibCommand{}, err
+	}
+
+	return DylibCommand{
+		Cmd:                  LoadCommandType(m.ByteOrder.Uint32(data[0:])),
+		Size:                 m.ByteOrder.Uint32(data[4:]),
+		Name:                 name,
+		Timestamp:            m.ByteOrder.Uint32(data[12:]),
+		CurrentVersion:       Version(m.ByteOrder.Uint32(data[16:])),
+		CompatibilityVersion: Version(m.ByteOrder.Uint32(data[20:])),
+	}, nil
+}
+
+// loadCommandString reads the NUL terminated string of a load command, found at the offset held by the field
+// following the command size (the string follows the fixed fields of the given size).
+func (m *File) loadCommandString(data []byte, headerSize int) (string, error) {
+	if len(data) < headerSize {
+		return "", malformed("load command", "truncated (%d bytes)", len(data))
+	}
+	offset := m.ByteOrder.Uint32(data[8:])
+	if int(offset) < headerSize || int(offset) >= len(data) {
+		return "", malformed("load command", "string offset %d is out of bounds", offset)
+	}
+	s := data[offset:]
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return string(s), nil
+}
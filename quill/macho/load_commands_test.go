@@ -0,0 +1,78 @@
+package macho
+
+import (
+	"debug/macho"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dylibCommand returns a dylib load command of the given type naming the given library.
+func dylibCommand(cmd LoadCommandType, name string, current, compat Version) []byte {
+	size := (24 + len(name) + 1 + 7) &^ 7
+	by := loadCommand(uint32(cmd), uint32(size), 24, 2, uint32(current), uint32(compat))
+	by = append(by, name...)
+	return append(by, make([]byte, size-len(by))...)
+}
+
+func TestFile_LoadCommands(t *testing.T) {
+	segment := loadCommand(uint32(LcSegment64), 72,
+		// __TEXT
+		0x45545f5f, 0x5458, 0, 0,
+		// vmaddr, vmsize, fileoff, filesize (64-bit)
+		0, 0, 0x4000, 0, 0, 0, 0x4000, 0,
+		// maxprot, initprot, nsects, flags
+		5, 5, 0, 0)
+
+	path := writeMachoWithLoads(t,
+		segment,
+		dylibCommand(LcLoadDylib, "/usr/lib/libSystem.B.dylib", NewVersion(1319, 0, 0), NewVersion(1, 0, 0)),
+		dylibCommand(LcLoadWeakDylib, "@rpath/Weak.framework/Weak", NewVersion(2, 1, 0), NewVersion(2, 0, 0)),
+		loadCommand(uint32(LcBuildVersion), 24, 1, 0x000b0000, 0x000d0300, 0),
+		loadCommand(uint32(LcUUID), 24, 0x04030201, 0x08070605, 0x0c0b0a09, 0x100f0e0d),
+		loadCommand(0x2a, 16, 0, 0), // LC_SOURCE_VERSION (not decoded)
+		loadCommand(uint32(LcCodeSignature), 16, 0x4000, 0x200),
+	)
+
+	m, err := NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	cmds, err := m.LoadCommands()
+	require.NoError(t, err)
+	require.Len(t, cmds, 7)
+
+	var types []LoadCommandType
+	for _, c := range cmds {
+		types = append(types, c.Type)
+	}
+	assert.Equal(t, []LoadCommandType{LcSegment64, LcLoadDylib, LcLoadWeakDylib, LcBuildVersion, LcUUID, 0x2a, LcCodeSignature}, types)
+
+	assert.Equal(t, uint64(fileHeaderSize64), cmds[0].Offset)
+	assert.Equal(t, cmds[0].Offset+72, cmds[1].Offset)
+
+	seg, ok := cmds[0].Value.(macho.SegmentHeader)
+	require.True(t, ok)
+	assert.Equal(t, "__TEXT", seg.Name)
+	assert.Equal(t, uint64(0x4000), seg.Memsz)
+
+	assert.Equal(t, DylibCommand{
+		Cmd:                  LcLoadDylib,
+		Size:                 56,
+		Name:                 "/usr/lib/libSystem.B.dylib",
+		Timestamp:            2,
+		CurrentVersion:       NewVersion(1319, 0, 0),
+		CompatibilityVersion: NewVersion(1, 0, 0),
+	}, cmds[1].Value)
+	assert.Equal(t, "@rpath/Weak.framework/Weak", cmds[2].Value.(DylibCommand).Name)
+
+	assert.Equal(t, NewVersion(13, 3, 0), cmds[3].Value.(BuildVersionCommand).SDK)
+	assert.Equal(t, "01020304-0506-0708-090A-0B0C0D0E0F10", cmds[4].Value.(UUIDCommand).String())
+	assert.Nil(t, cmds[5].Value)
+	assert.Equal(t, CodeSigningCommand{Cmd: LcCodeSignature, Size: 16, DataOffset: 0x4000, DataSize: 0x200}, cmds[6].Value)
+
+	_, offset, err := m.CodeSigningCmd()
+	require.NoError(t, err)
+	assert.Equal(t, offset, cmds[6].Offset)
+}
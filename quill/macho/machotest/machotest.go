@@ -0,0 +1,85 @@
+// Package machotest builds minimal mach-o binaries for tests.
+package machotest
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// DefaultTextSize is the size of the __TEXT segment when none is configured.
+	DefaultTextSize = 0x2000
+
+	// LinkEditSize is the size of the (unsigned) __LINKEDIT segment.
+	LinkEditSize = 0x100
+
+	pageSize    = 0x1000
+	segmentSize = 0x4000
+)
+
+// Config describes a minimal (unsigned) arm64 executable: a __TEXT segment (holding the header and load commands)
+// followed by a __LINKEDIT segment.
+type Config struct {
+	// TextSize is the size of the __TEXT segment (DefaultTextSize when zero).
+	TextSize uint64
+	// Loads are load commands added after the __TEXT and __LINKEDIT segments (anything binary.Write accepts).
+	Loads []interface{}
+}
+
+// Binary returns the content of a minimal executable. Every page after the first one is filled with a non-zero
+// pattern, so that the page hashes differ.
+func Binary(t testing.TB, cfg Config) []byte {
+	t.Helper()
+
+	textSize := cfg.TextSize
+	if textSize == 0 {
+		textSize = DefaultTextSize
+	}
+
+	loads := append([]interface{}{
+		macho.Segment64{Cmd: macho.LoadCmdSegment64, Len: 72, Name: segName("__TEXT"), Memsz: textSize, Filesz: textSize, Maxprot: 5, Prot: 5},
+		macho.Segment64{Cmd: macho.LoadCmdSegment64, Len: 72, Name: segName("__LINKEDIT"), Addr: roundUp(textSize, segmentSize), Memsz: segmentSize, Offset: textSize, Filesz: LinkEditSize, Maxprot: 1, Prot: 1},
+	}, cfg.Loads...)
+
+	var loadBytes bytes.Buffer
+	for _, l := range loads {
+		require.NoError(t, binary.Write(&loadBytes, binary.LittleEndian, l))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, macho.FileHeader{
+		Magic: macho.Magic64, Cpu: macho.CpuArm64, Type: macho.TypeExec, Ncmd: uint32(len(loads)), Cmdsz: uint32(loadBytes.Len()),
+	}))
+	buf.Write([]byte{0, 0, 0, 0}) // reserved (64-bit header)
+	buf.Write(loadBytes.Bytes())
+
+	content := make([]byte, textSize+LinkEditSize)
+	copy(content, buf.Bytes())
+	for i := pageSize; i < len(content); i++ {
+		content[i] = byte(i)
+	}
+	return content
+}
+
+// Write writes a minimal executable to the given path (creating its parent directories).
+func Write(t testing.TB, path string, cfg Config) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, Binary(t, cfg), 0700)) //nolint:gosec
+}
+
+func segName(name string) (b [16]byte) {
+	copy(b[:], name)
+	return b
+}
+
+func roundUp(n, multiple uint64) uint64 {
+	return (n + multiple - 1) / multiple * multiple
+}
@@ -0,0 +1,33 @@
+package machotest
+
+import (
+	"bytes"
+	"debug/macho"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinary(t *testing.T) {
+	rpath := struct {
+		Cmd, Len, Offset uint32
+		Path             [20]byte
+	}{Cmd: uint32(macho.LoadCmdRpath), Len: 32, Offset: 12}
+
+	content := Binary(t, Config{TextSize: 3 * 0x1000, Loads: []interface{}{rpath}})
+	assert.Len(t, content, 3*0x1000+LinkEditSize)
+
+	f, err := macho.NewFile(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, macho.CpuArm64, f.Cpu)
+	assert.Equal(t, macho.TypeExec, f.Type)
+	require.Len(t, f.Loads, 3)
+
+	text, linkEdit := f.Segment("__TEXT"), f.Segment("__LINKEDIT")
+	require.NotNil(t, text)
+	require.NotNil(t, linkEdit)
+	assert.Equal(t, uint64(3*0x1000), text.Filesz)
+	assert.Equal(t, text.Filesz, linkEdit.Offset)
+	assert.Equal(t, uint64(0x4000), linkEdit.Addr)
+}
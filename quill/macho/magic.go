@@ -19,3 +19,30 @@ const (
 type Magic uint32
 
 var SigningOrder = binary.BigEndian
+
+// Name describes the blob magic (e.g. "code directory"), "unknown" for magics quill does not know about.
+func (m Magic) Name() string {
+	switch m {
+	case MagicRequirement:
+		return "requirement"
+	case MagicRequirements:
+		return "requirements"
+	case MagicCodedirectory:
+		return "code directory"
+	case MagicEmbeddedSignature:
+		return "embedded signature"
+	case MagicEmbeddedSignatureOld:
+		return "embedded signature (old)"
+	case MagicLibraryDependencyBlob:
+		return "library dependency"
+	case MagicEmbeddedEntitlements:
+		return "embedded entitlements"
+	case MagicEmbeddedEntitlementsDer:
+		return "embedded entitlements (DER)"
+	case MagicDetachedSignature:
+		return "detached signature"
+	case MagicBlobwrapper:
+		return "blob wrapper"
+	}
+	return "unknown"
+}
@@ -0,0 +1,606 @@
+package macho
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requirement expression opcodes (see Security.framework requirement.h)
+const (
+	reqOpFalse              uint32 = iota // unconditionally false
+	reqOpTrue                             // unconditionally true
+	reqOpIdent                            // match canonical code [string]
+	reqOpAppleAnchor                      // signed by Apple as Apple's product
+	reqOpAnchorHash                       // match anchor [cert hash]
+	reqOpInfoKeyValue                     // *legacy* - use opInfoKeyField [key; value]
+	reqOpAnd                              // binary prefix expr AND expr [expr; expr]
+	reqOpOr                               // binary prefix expr OR expr [expr; expr]
+	reqOpCDHash                           // match hash of CodeDirectory directly [cd hash]
+	reqOpNot                              // logical inverse [expr]
+	reqOpInfoKeyField                     // Info.plist key field [string; match suffix]
+	reqOpCertField                        // Certificate field [cert index; field name; match suffix]
+	reqOpTrustedCert                      // require trust settings to approve one particular cert [cert index]
+	reqOpTrustedCerts                     // require trust settings to approve the cert chain
+	reqOpCertGeneric                      // Certificate component by OID [cert index; oid; match suffix]
+	reqOpAppleGenericAnchor               // signed by Apple in any capacity
+	reqOpEntitlementField                 // entitlement dictionary field [string; match suffix]
+	reqOpCertPolicy                       // Certificate policy by OID [cert index; oid; match suffix]
+	reqOpNamedAnchor                      // named anchor type
+	reqOpNamedCode                        // named subroutine
+	reqOpPlatform                         // platform constraint [integer]
+	reqOpNotarized                        // has a developer id+ ticket
+	reqOpCertFieldDate                    // extension value as timestamp [cert index; field name; match suffix]
+	reqOpLegacyDevID                      // meets legacy (pre-notarization required) policy
+)
+
+const (
+	reqOpFlagMask     uint32 = 0xFF000000
+	reqOpGenericFalse uint32 = 0x80000000 // unknown opcode that evaluates to false (followed by a skippable blob)
+	reqOpGenericSkip  uint32 = 0x40000000 // unknown opcode that can be skipped (followed by a skippable blob)
+)
+
+// requirement match suffix operations
+const (
+	reqMatchExists       uint32 = iota // anything but explicit "false" - no value stored
+	reqMatchEqual                      // equal (CFEqual)
+	reqMatchContains                   // partial match (substring)
+	reqMatchBeginsWith                 // partial match (initial substring)
+	reqMatchEndsWith                   // partial match (terminal substring)
+	reqMatchLessThan                   // less than (string with numeric comparison)
+	reqMatchGreaterThan                // greater than (string with numeric comparison)
+	reqMatchLessEqual                  // less or equal (string with numeric comparison)
+	reqMatchGreaterEqual               // greater or equal (string with numeric comparison)
+	reqMatchOn                         // on (timestamp comparison)
+	reqMatchBefore                     // before (timestamp comparison)
+	reqMatchAfter                      // after (timestamp comparison)
+	reqMatchOnOrBefore                 // on or before (timestamp comparison)
+	reqMatchOnOrAfter                  // on or after (timestamp comparison)
+	reqMatchAbsent                     // not present (kCFNull)
+)
+
+// requirementExprForm is the only requirement kind (the expression form) that can be decoded.
+const requirementExprForm uint32 = 1
+
+// maxRequirementDepth guards against pathologically nested (malformed) expressions.
+const maxRequirementDepth = 256
+
+// cfAbsoluteTimeEpoch is the reference date for CFAbsoluteTime values (seconds since 2001-01-01 UTC).
+var cfAbsoluteTimeEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// syntax levels, used to decide when a sub-expression needs parentheses
+const (
+	slPrimary = iota
+	slAnd
+	slOr
+	slTop
+)
+
+func (t RequirementType) String() string {
+	switch t {
+	case HostRequirementType:
+		return "host"
+	case GuestRequirementType:
+		return "guest"
+	case DesignatedRequirementType:
+		return "designated"
+	case LibraryRequirementType:
+		return "library"
+	case PluginRequirementType:
+		return "plugin"
+	}
+	return fmt.Sprintf("type %d", uint32(t))
+}
+
+// RequirementStatement is a single decoded requirement from a requirements set.
+type RequirementStatement struct {
+	Type RequirementType
+	// Expression is the requirement in the textual code requirement language (as shown by "codesign -d -r-").
+	Expression string
+}
+
+func (r RequirementStatement) String() string {
+	return fmt.Sprintf("%s => %s", r.Type, r.Expression)
+}
+
+// DecodeRequirementSet decodes a requirements set blob (0xfade0c01, including the blob header) into each of the
+// contained requirements rendered in the textual code requirement language.
+func DecodeRequirementSet(b []byte) ([]RequirementStatement, error) {
+	r := requirementReader{data: b}
+
+	magic, err := r.uint32()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read requirements set header: %w", err)
+	}
+	if Magic(magic) != MagicRequirements {
+		return nil, fmt.Errorf("unexpected requirements set magic: 0x%x", magic)
+	}
+
+	length, err := r.uint32()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read requirements set header: %w", err)
+	}
+	if int(length) > len(b) {
+		return nil, malformed("requirements set", "length (%d) exceeds available data (%d)", length, len(b))
+	}
+	r.data = b[:length]
+
+	count, err := r.uint32()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read requirements set count: %w", err)
+	}
+
+	var statements []RequirementStatement
+	for i := uint32(0); i < count; i++ {
+		reqType, err := r.uint32()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read requirements set index=%d: %w", i, err)
+		}
+		offset, err := r.uint32()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read requirements set index=%d: %w", i, err)
+		}
+		if int(offset) >= len(r.data) {
+			return nil, malformed("requirements set", "requirement index=%d has an out of bounds offset=%d", i, offset)
+		}
+
+		expr, err := DecodeRequirement(r.data[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %s requirement: %w", RequirementType(reqType), err)
+		}
+
+		statements = append(statements, RequirementStatement{
+			Type:       RequirementType(reqType),
+			Expression: expr,
+		})
+	}
+
+	return statements, nil
+}
+
+// DecodeRequirement decodes a single requirement blob (0xfade0c00, including the blob header) into the textual code
+// requirement language.
+func DecodeRequirement(b []byte) (string, error) {
+	r := requirementReader{data: b}
+
+	magic, err := r.uint32()
+	if err != nil {
+		return "", fmt.Errorf("unable to read requirement header: %w", err)
+	}
+	if Magic(magic) != MagicRequirement {
+		return "", fmt.Errorf("unexpected requirement magic: 0x%x", magic)
+	}
+
+	length, err := r.uint32()
+	if err != nil {
+		return "", fmt.Errorf("unable to read requirement header: %w", err)
+	}
+	if int(length) > len(b) {
+		return "", malformed("requirement", "length (%d) exceeds available data (%d)", length, len(b))
+	}
+	r.data = b[:length]
+
+	kind, err := r.uint32()
+	if err != nil {
+		return "", fmt.Errorf("unable to read requirement kind: %w", err)
+	}
+	if kind != requirementExprForm {
+		return "", fmt.Errorf("unsupported requirement kind: %d", kind)
+	}
+
+	d := requirementDumper{reader: r}
+	if err := d.expr(slTop, 0); err != nil {
+		return "", err
+	}
+	return d.sb.String(), nil
+}
+
+// requirementReader reads the 4-byte aligned (big endian) fields of a requirement.
+type requirementReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *requirementReader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, malformed("requirement", "unexpected end of data at offset=%d", r.pos)
+	}
+	v := SigningOrder.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *requirementReader) int64() (int64, error) {
+	if r.pos+8 > len(r.data) {
+		return 0, malformed("requirement", "unexpected end of data at offset=%d", r.pos)
+	}
+	v := SigningOrder.Uint64(r.data[r.pos:])
+	r.pos += 8
+	return int64(v), nil
+}
+
+// bytes reads a length prefixed value, which is padded to a 4-byte boundary.
+func (r *requirementReader) bytes() ([]byte, error) {
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(r.pos)+uint64(length) > uint64(len(r.data)) {
+		return nil, malformed("requirement", "value length (%d) exceeds available data at offset=%d", length, r.pos)
+	}
+	v := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(roundUpToWord(length))
+	if r.pos > len(r.data) {
+		r.pos = len(r.data)
+	}
+	return v, nil
+}
+
+func roundUpToWord(n uint32) uint32 {
+	return (n + 3) &^ 3
+}
+
+// requirementDumper renders a requirement expression, mirroring the output of the Security.framework requirement
+// dumper (used by "codesign -d -r-").
+type requirementDumper struct {
+	reader requirementReader
+	sb     strings.Builder
+}
+
+func (d *requirementDumper) print(s string) {
+	d.sb.WriteString(s)
+}
+
+//nolint:funlen,gocyclo
+func (d *requirementDumper) expr(level, depth int) error {
+	if depth > maxRequirementDepth {
+		return malformed("requirement", "expression is nested too deeply")
+	}
+
+	op, err := d.reader.uint32()
+	if err != nil {
+		return err
+	}
+
+	switch op &^ reqOpFlagMask {
+	case reqOpFalse:
+		d.print("never")
+	case reqOpTrue:
+		d.print("always")
+	case reqOpIdent:
+		d.print("identifier ")
+		return d.data(false)
+	case reqOpAppleAnchor:
+		d.print("anchor apple")
+	case reqOpAppleGenericAnchor:
+		d.print("anchor apple generic")
+	case reqOpAnchorHash:
+		d.print("certificate")
+		if err := d.certSlot(); err != nil {
+			return err
+		}
+		d.print(" = ")
+		return d.hashData()
+	case reqOpInfoKeyValue:
+		d.print("info[")
+		if err := d.data(true); err != nil {
+			return err
+		}
+		d.print("] = ")
+		return d.data(false)
+	case reqOpAnd:
+		return d.binary(" and ", slAnd, level, depth)
+	case reqOpOr:
+		return d.binary(" or ", slOr, level, depth)
+	case reqOpNot:
+		d.print("! ")
+		return d.expr(slPrimary, depth+1)
+	case reqOpCDHash:
+		d.print("cdhash ")
+		return d.hashData()
+	case reqOpInfoKeyField:
+		d.print("info[")
+		return d.fieldMatch(d.dotString)
+	case reqOpEntitlementField:
+		d.print("entitlement[")
+		return d.fieldMatch(d.dotString)
+	case reqOpCertField:
+		d.print("certificate")
+		if err := d.certSlot(); err != nil {
+			return err
+		}
+		d.print("[")
+		return d.fieldMatch(d.dotString)
+	case reqOpCertGeneric:
+		return d.certOID("field.")
+	case reqOpCertPolicy:
+		return d.certOID("policy.")
+	case reqOpCertFieldDate:
+		return d.certOID("timestamp.")
+	case reqOpTrustedCert:
+		d.print("certificate")
+		if err := d.certSlot(); err != nil {
+			return err
+		}
+		d.print(" trusted")
+	case reqOpTrustedCerts:
+		d.print("anchor trusted")
+	case reqOpNamedAnchor:
+		d.print("anchor apple ")
+		return d.data(false)
+	case reqOpNamedCode:
+		d.print("(")
+		if err := d.data(false); err != nil {
+			return err
+		}
+		d.print(")")
+	case reqOpPlatform:
+		platform, err := d.reader.uint32()
+		if err != nil {
+			return err
+		}
+		d.print(fmt.Sprintf("platform = %d", int32(platform)))
+	case reqOpNotarized:
+		d.print("notarized")
+	case reqOpLegacyDevID:
+		d.print("legacy")
+	default:
+		switch {
+		case op&reqOpGenericFalse != 0:
+			d.print(fmt.Sprintf(" false /* opcode %d */", op&^reqOpFlagMask))
+		case op&reqOpGenericSkip != 0:
+			d.print(fmt.Sprintf(" /* opcode %d */", op&^reqOpFlagMask))
+		default:
+			return fmt.Errorf("unsupported requirement opcode: %d", op)
+		}
+		_, err := d.reader.bytes()
+		return err
+	}
+	return nil
+}
+
+func (d *requirementDumper) binary(operator string, opLevel, level, depth int) error {
+	if level < opLevel {
+		d.print("(")
+	}
+	if err := d.expr(opLevel, depth+1); err != nil {
+		return err
+	}
+	d.print(operator)
+	if err := d.expr(opLevel, depth+1); err != nil {
+		return err
+	}
+	if level < opLevel {
+		d.print(")")
+	}
+	return nil
+}
+
+func (d *requirementDumper) certOID(prefix string) error {
+	d.print("certificate")
+	if err := d.certSlot(); err != nil {
+		return err
+	}
+	d.print("[" + prefix)
+	return d.fieldMatch(d.oid)
+}
+
+// fieldMatch renders a field name (with the given renderer), the closing bracket and the match suffix.
+func (d *requirementDumper) fieldMatch(field func() error) error {
+	if err := field(); err != nil {
+		return err
+	}
+	d.print("]")
+	return d.match()
+}
+
+func (d *requirementDumper) certSlot() error {
+	slot, err := d.reader.uint32()
+	if err != nil {
+		return err
+	}
+	switch int32(slot) {
+	case 0:
+		d.print(" leaf")
+	case -1:
+		d.print(" root")
+	default:
+		d.print(fmt.Sprintf(" %d", int32(slot)))
+	}
+	return nil
+}
+
+//nolint:gocyclo
+func (d *requirementDumper) match() error {
+	op, err := d.reader.uint32()
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case reqMatchExists:
+		d.print(" /* exists */")
+		return nil
+	case reqMatchAbsent:
+		d.print(" absent ")
+		return nil
+	case reqMatchEqual:
+		d.print(" = ")
+		return d.data(false)
+	case reqMatchContains:
+		d.print(" ~ ")
+		return d.data(false)
+	case reqMatchBeginsWith:
+		d.print(" = ")
+		if err := d.data(false); err != nil {
+			return err
+		}
+		d.print("*")
+		return nil
+	case reqMatchEndsWith:
+		d.print(" = *")
+		return d.data(false)
+	case reqMatchLessThan:
+		d.print(" < ")
+		return d.data(false)
+	case reqMatchGreaterThan:
+		d.print(" > ")
+		return d.data(false)
+	case reqMatchLessEqual:
+		d.print(" <= ")
+		return d.data(false)
+	case reqMatchGreaterEqual:
+		d.print(" >= ")
+		return d.data(false)
+	case reqMatchOn:
+		d.print(" = ")
+		return d.timestamp()
+	case reqMatchBefore:
+		d.print(" < ")
+		return d.timestamp()
+	case reqMatchAfter:
+		d.print(" > ")
+		return d.timestamp()
+	case reqMatchOnOrBefore:
+		d.print(" <= ")
+		return d.timestamp()
+	case reqMatchOnOrAfter:
+		d.print(" >= ")
+		return d.timestamp()
+	}
+	return fmt.Errorf("unsupported requirement match operation: %d", op)
+}
+
+func (d *requirementDumper) timestamp() error {
+	seconds, err := d.reader.int64()
+	if err != nil {
+		return err
+	}
+	at := cfAbsoluteTimeEpoch.Add(time.Duration(seconds) * time.Second)
+	d.print(fmt.Sprintf("timestamp %q", at.Format(time.RFC3339)))
+	return nil
+}
+
+func (d *requirementDumper) dotString() error {
+	return d.data(true)
+}
+
+func (d *requirementDumper) hashData() error {
+	v, err := d.reader.bytes()
+	if err != nil {
+		return err
+	}
+	d.print(`H"` + hex.EncodeToString(v) + `"`)
+	return nil
+}
+
+func (d *requirementDumper) oid() error {
+	v, err := d.reader.bytes()
+	if err != nil {
+		return err
+	}
+	oid, err := decodeOID(v)
+	if err != nil {
+		return err
+	}
+	d.print(oid)
+	return nil
+}
+
+// data renders a value as a bare word when possible, otherwise as a quoted string or (for binary values) as hex.
+func (d *requirementDumper) data(dotOK bool) error {
+	v, err := d.reader.bytes()
+	if err != nil {
+		return err
+	}
+	d.print(formatRequirementValue(v, dotOK))
+	return nil
+}
+
+const (
+	valueSimple = iota
+	valuePrintable
+	valueBinary
+)
+
+func formatRequirementValue(v []byte, dotOK bool) string {
+	mode := valueSimple
+	if len(v) == 0 {
+		mode = valuePrintable
+	}
+
+	for i, c := range v {
+		switch {
+		case isAlnum(c) || (c == '.' && dotOK):
+			if i == 0 && c >= '0' && c <= '9' {
+				// bare words cannot start with a digit
+				mode = valuePrintable
+			}
+		case c >= 0x20 && c < 0x7f, c == '\t', c == '\n', c == '\v', c == '\f', c == '\r':
+			mode = valuePrintable
+		default:
+			mode = valueBinary
+		}
+		if mode == valueBinary {
+			break
+		}
+	}
+
+	switch mode {
+	case valueSimple:
+		return string(v)
+	case valuePrintable:
+		var sb strings.Builder
+		sb.WriteByte('"')
+		for _, c := range v {
+			if c == '\\' || c == '"' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		}
+		sb.WriteByte('"')
+		return sb.String()
+	}
+	return "0x" + hex.EncodeToString(v)
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// decodeOID decodes DER encoded object identifier content (without the tag and length) into dotted notation.
+func decodeOID(v []byte) (string, error) {
+	if len(v) == 0 {
+		return "", fmt.Errorf("empty object identifier")
+	}
+
+	var parts []string
+	var value uint64
+	for i, b := range v {
+		if value > (1<<57)-1 {
+			return "", fmt.Errorf("object identifier component is too large")
+		}
+		value = value<<7 | uint64(b&0x7f)
+		if b&0x80 != 0 {
+			if i == len(v)-1 {
+				return "", fmt.Errorf("truncated object identifier")
+			}
+			continue
+		}
+
+		if len(parts) == 0 {
+			// the first component encodes the first two arcs
+			first := value / 40
+			if first > 2 {
+				first = 2
+			}
+			parts = append(parts, strconv.FormatUint(first, 10), strconv.FormatUint(value-first*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(value, 10))
+		}
+		value = 0
+	}
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,143 @@
+package macho
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requirementBlob wraps the given hex encoded expression within a requirement blob (of the expression kind).
+func requirementBlob(t *testing.T, exprHex string) []byte {
+	t.Helper()
+	expr, err := hex.DecodeString(exprHex)
+	require.NoError(t, err)
+
+	b := make([]byte, 12, 12+len(expr))
+	binary.BigEndian.PutUint32(b[0:], uint32(MagicRequirement))
+	binary.BigEndian.PutUint32(b[4:], uint32(12+len(expr)))
+	binary.BigEndian.PutUint32(b[8:], requirementExprForm)
+	return append(b, expr...)
+}
+
+func TestDecodeRequirement(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "never",
+			expr: "00000000",
+			want: "never",
+		},
+		{
+			name: "quoted identifier",
+			expr: "00000002000000067468652d69640000",
+			want: `identifier "the-id"`,
+		},
+		{
+			name: "bare identifier",
+			expr: "000000020000000362696e00",
+			want: "identifier bin",
+		},
+		{
+			name: "and conjunction",
+			expr: "0000000600000002000000067468652d696400000000000f",
+			want: `identifier "the-id" and anchor apple generic`,
+		},
+		{
+			name: "certificate field by oid",
+			expr: "0000000e000000010000000a2a864886f76364060206000000000000",
+			want: "certificate 1[field.1.2.840.113635.100.6.2.6] /* exists */",
+		},
+		{
+			name: "root certificate field by oid",
+			expr: "0000000effffffff0000000a2a864886f76364060206000000000000",
+			want: "certificate root[field.1.2.840.113635.100.6.2.6] /* exists */",
+		},
+		{
+			name: "leaf certificate subject OU",
+			expr: "0000000b000000000000000a7375626a6563742e4f55000000000001000000074d415443484d4500",
+			want: "certificate leaf[subject.OU] = MATCHME",
+		},
+		{
+			name: "values starting with a digit are quoted",
+			expr: "0000000b000000000000000a7375626a6563742e4f550000000000010000000439414243",
+			want: `certificate leaf[subject.OU] = "9ABC"`,
+		},
+		{
+			name: "or within and is parenthesized",
+			// and(or(true, false), not(cdhash))
+			expr: "00000006" + "00000007" + "00000001" + "00000000" + "00000009" + "00000008" + "00000002" + "abcd0000",
+			want: `(always or never) and ! cdhash H"abcd"`,
+		},
+		{
+			name: "info field with prefix match",
+			expr: "0000000a" + "00000008" + "6b65792e6e616d65" + "00000003" + "00000003" + "61626300",
+			want: "info[key.name] = abc*",
+		},
+		{
+			name: "entitlement field with binary value",
+			expr: "00000010" + "00000001" + "6b000000" + "00000001" + "00000002" + "00ff0000",
+			want: "entitlement[k] = 0x00ff",
+		},
+		{
+			name: "skippable unknown opcode",
+			expr: "400000ff" + "00000004" + "deadbeef",
+			want: " /* opcode 255 */",
+		},
+		{
+			name:    "unknown opcode",
+			expr:    "000000ff",
+			wantErr: require.Error,
+		},
+		{
+			name:    "truncated value",
+			expr:    "00000002000000ff",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := DecodeRequirement(requirementBlob(t, tt.expr))
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodeRequirementSet(t *testing.T) {
+	req := requirementBlob(t, "000000020000000362696e00")
+
+	// header (magic, length, count) + one index entry (type, offset)
+	set := make([]byte, 20, 20+len(req))
+	binary.BigEndian.PutUint32(set[0:], uint32(MagicRequirements))
+	binary.BigEndian.PutUint32(set[4:], uint32(20+len(req)))
+	binary.BigEndian.PutUint32(set[8:], 1)
+	binary.BigEndian.PutUint32(set[12:], uint32(DesignatedRequirementType))
+	binary.BigEndian.PutUint32(set[16:], 20)
+	set = append(set, req...)
+
+	got, err := DecodeRequirementSet(set)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "designated => identifier bin", got[0].String())
+
+	// an empty set (as written for ad-hoc signatures)
+	got, err = DecodeRequirementSet([]byte{0xfa, 0xde, 0x0c, 0x01, 0, 0, 0, 12, 0, 0, 0, 0})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = DecodeRequirementSet([]byte{0xfa, 0xde, 0x0c, 0x00, 0, 0, 0, 12, 0, 0, 0, 0})
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package macho
+
+import (
+	"debug/macho"
+)
+
+// VMProt is the virtual memory protection of a segment (read, write, execute).
+type VMProt uint32
+
+const (
+	VMProtRead    VMProt = 0x1
+	VMProtWrite   VMProt = 0x2
+	VMProtExecute VMProt = 0x4
+)
+
+// String formats the protection as vmmap does (e.g. "r-x").
+func (p VMProt) String() string {
+	by := []byte("---")
+	if p&VMProtRead != 0 {
+		by[0] = 'r'
+	}
+	if p&VMProtWrite != 0 {
+		by[1] = 'w'
+	}
+	if p&VMProtExecute != 0 {
+		by[2] = 'x'
+	}
+	return string(by)
+}
+
+// section types and attributes (the low and high bits of the section flags)
+const (
+	sectionTypeMask             = 0x000000ff
+	sectionZerofill             = 0x1
+	sectionGBZerofill           = 0xc
+	sectionThreadLocalZerofill  = 0x12
+	sectionAttrPureInstructions = 0x80000000
+	sectionAttrSomeInstructions = 0x00000400
+)
+
+// Segment is a segment of a binary along with its sections (see File.Segments).
+type Segment struct {
+	Name string
+	// Addr and VMSize are the virtual memory range of the segment.
+	Addr   uint64
+	VMSize uint64
+	// Offset and FileSize are the range of the segment within the (single-arch) binary.
+	Offset   uint64
+	FileSize uint64
+	MaxProt  VMProt
+	InitProt VMProt
+	Flags    uint32
+	Sections []Section
+}
+
+// Section is a section of a segment.
+type Section struct {
+	Name    string
+	Segment string
+	// Addr and Size are the virtual memory range of the section.
+	Addr uint64
+	Size uint64
+	// Offset is the offset of the section within the (single-arch) binary, zero when the section has no content in the
+	// file (see IsZerofill).
+	Offset uint32
+	// Align is the alignment of the section as a power of two.
+	Align uint32
+	Flags uint32
+}
+
+// Type is the section type, the low byte of the section flags (e.g. 0x1 for S_ZEROFILL).
+func (s Section) Type() uint8 {
+	return uint8(s.Flags & sectionTypeMask)
+}
+
+// IsZerofill indicates the section only exists in memory (it takes no space in the file).
+func (s Section) IsZerofill() bool {
+	switch s.Type() {
+	case sectionZerofill, sectionGBZerofill, sectionThreadLocalZerofill:
+		return true
+	}
+	return false
+}
+
+// HasInstructions indicates the section holds machine instructions.
+func (s Section) HasInstructions() bool {
+	return s.Flags&(sectionAttrPureInstructions|sectionAttrSomeInstructions) != 0
+}
+
+// Section returns the section of the segment with the given name, nil if there is none.
+func (s Segment) Section(name string) *Section {
+	for i := range s.Sections {
+		if s.Sections[i].Name == name {
+			return &s.Sections[i]
+		}
+	}
+	return nil
+}
+
+// ContainsOffset indicates the given file offset is within the file range of the segment.
+func (s Segment) ContainsOffset(offset uint64) bool {
+	return offset >= s.Offset && offset-s.Offset < s.FileSize
+}
+
+// Segments returns every segment of the binary in load command order, each with its sections.
+func (m *File) Segments() []Segment {
+	var segments []Segment
+	for _, l := range m.Loads {
+		seg, ok := l.(*macho.Segment)
+		if !ok {
+			continue
+		}
+		s := Segment{
+			Name:     seg.Name,
+			Addr:     seg.Addr,
+			VMSize:   seg.Memsz,
+			Offset:   seg.Offset,
+			FileSize: seg.Filesz,
+			MaxProt:  VMProt(seg.Maxprot),
+			InitProt: VMProt(seg.Prot),
+			Flags:    seg.Flag,
+		}
+		for _, sect := range m.Sections {
+			if sect.Seg != seg.Name {
+				continue
+			}
+			s.Sections = append(s.Sections, Section{
+				Name:    sect.Name,
+				Segment: sect.Seg,
+				Addr:    sect.Addr,
+				Size:    sect.Size,
+				Offset:  sect.Offset,
+				Align:   sect.Align,
+				Flags:   sect.Flags,
+			})
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// FindSection returns the section with the given name within the given segment (e.g. "__TEXT" and "__text"), nil if
+// there is none.
+func (m *File) FindSection(segment, section string) *Section {
+	for _, s := range m.Segments() {
+		if s.Name == segment {
+			return s.Section(section)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package macho
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Segments(t *testing.T) {
+	text := loadCommand(uint32(LcSegment64), 72+2*80,
+		// __TEXT
+		0x45545f5f, 0x5458, 0, 0,
+		// vmaddr, vmsize, fileoff, filesize (64-bit)
+		0, 1, 0x4000, 0, 0, 0, 0x4000, 0,
+		// maxprot, initprot, nsects, flags
+		5, 5, 2, 0)
+	text = append(text, loadCommand(
+		// __text, __TEXT
+		0x65745f5f, 0x7478, 0, 0, 0x45545f5f, 0x5458, 0, 0,
+		// addr, size (64-bit), offset, align, reloff, nreloc
+		0x3f00, 1, 0x40, 0, 0x3f00, 2, 0, 0,
+		// S_REGULAR | S_ATTR_PURE_INSTRUCTIONS | S_ATTR_SOME_INSTRUCTIONS, reserved
+		0x80000400, 0, 0, 0)...)
+	text = append(text, loadCommand(
+		// __const, __TEXT
+		0x6f635f5f, 0x74736e, 0, 0, 0x45545f5f, 0x5458, 0, 0,
+		0x3f40, 1, 0x20, 0, 0x3f40, 3, 0, 0,
+		0, 0, 0, 0)...)
+
+	data := loadCommand(uint32(LcSegment64), 72+80,
+		// __DATA
+		0x41445f5f, 0x4154, 0, 0,
+		0x4000, 1, 0x4000, 0, 0x4000, 0, 0, 0,
+		3, 3, 1, 0)
+	data = append(data, loadCommand(
+		// __bss, __DATA
+		0x73625f5f, 0x73, 0, 0, 0x41445f5f, 0x4154, 0, 0,
+		0x4000, 1, 0x100, 0, 0, 3, 0, 0,
+		// S_ZEROFILL
+		0x1, 0, 0, 0)...)
+
+	m, err := NewReadOnlyFile(writeMachoWithLoads(t, text, loadCommand(uint32(LcUUID), 24, 0, 0, 0, 0), data))
+	require.NoError(t, err)
+	defer m.Close()
+
+	segments := m.Segments()
+	require.Len(t, segments, 2)
+
+	assert.Equal(t, "__TEXT", segments[0].Name)
+	assert.Equal(t, uint64(0x100000000), segments[0].Addr)
+	assert.Equal(t, uint64(0x4000), segments[0].FileSize)
+	assert.Equal(t, "r-x", segments[0].InitProt.String())
+	assert.True(t, segments[0].ContainsOffset(0x3fff))
+	assert.False(t, segments[0].ContainsOffset(0x4000))
+
+	require.Len(t, segments[0].Sections, 2)
+	assert.Equal(t, Section{
+		Name:    "__text",
+		Segment: "__TEXT",
+		Addr:    0x100003f00,
+		Size:    0x40,
+		Offset:  0x3f00,
+		Align:   2,
+		Flags:   0x80000400,
+	}, segments[0].Sections[0])
+	assert.True(t, segments[0].Sections[0].HasInstructions())
+	assert.False(t, segments[0].Section("__const").HasInstructions())
+
+	assert.Equal(t, "__DATA", segments[1].Name)
+	assert.Equal(t, "rw-", segments[1].MaxProt.String())
+	assert.Zero(t, segments[1].FileSize)
+	assert.False(t, segments[1].ContainsOffset(0x4000))
+
+	bss := m.FindSection("__DATA", "__bss")
+	require.NotNil(t, bss)
+	assert.True(t, bss.IsZerofill())
+	assert.Equal(t, uint8(0x1), bss.Type())
+
+	assert.Nil(t, m.FindSection("__DATA", "__text"))
+	assert.Nil(t, m.FindSection("__LINKEDIT", "__text"))
+}
@@ -0,0 +1,134 @@
+package macho
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LayoutError indicates the binary does not end with its code signature (or with __LINKEDIT when unsigned) as signing
+// expects: the signature is written at the end of __LINKEDIT, which would overwrite or misplace any content found
+// there.
+type LayoutError struct {
+	// Offset and Size are the range of the unexpected content within the (single-arch) binary.
+	Offset uint64
+	Size   uint64
+	Reason string
+	// Normalizable indicates NormalizeLayout can fix the layout, dropping the unexpected content (which no load command
+	// refers to).
+	Normalizable bool
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("unexpected binary layout: %s", e.Reason)
+}
+
+// IsNormalizableLayout indicates if the given error was caused by a layout which NormalizeLayout can fix.
+func IsNormalizableLayout(err error) bool {
+	var layout *LayoutError
+	return errors.As(err, &layout) && layout.Normalizable
+}
+
+// CheckLayout checks the binary ends with its code signature, itself ending __LINKEDIT and following the content
+// referenced by the other load commands (see LinkEditContents), or with __LINKEDIT when the binary is unsigned. A
+// *LayoutError describes the first inconsistency found.
+func (m *File) CheckLayout() error {
+	linkEditSeg := m.Segment("__LINKEDIT")
+	if linkEditSeg == nil {
+		return &LayoutError{Reason: "the binary has no __LINKEDIT segment to hold a code signature"}
+	}
+	linkEditEnd := linkEditSeg.Offset + linkEditSeg.Filesz
+
+	contentEnd, err := m.linkEditContentEnd()
+	if err != nil {
+		return err
+	}
+
+	// end is where the binary is expected to end: after the signature, or after __LINKEDIT when unsigned
+	end := linkEditEnd
+	if m.HasCodeSigningCmd() {
+		cmd, _, err := m.CodeSigningCmd()
+		if err != nil {
+			return fmt.Errorf("unable to extract code signing cmd: %w", err)
+		}
+		if !m.isSigningCommandLastLoader() {
+			return &LayoutError{Reason: "the code signing load command is not the last load command"}
+		}
+		if uint64(cmd.DataOffset) < contentEnd {
+			return &LayoutError{
+				Offset: uint64(cmd.DataOffset),
+				Size:   contentEnd - uint64(cmd.DataOffset),
+				Reason: fmt.Sprintf("the code signature (at 0x%x) overlaps the content of __LINKEDIT (ending at 0x%x)", cmd.DataOffset, contentEnd),
+			}
+		}
+		end = uint64(cmd.DataOffset) + uint64(cmd.DataSize)
+
+		switch {
+		case linkEditEnd > end:
+			return &LayoutError{
+				Offset:       end,
+				Size:         linkEditEnd - end,
+				Reason:       fmt.Sprintf("the code signature (ending at 0x%x) is followed by 0x%x bytes of __LINKEDIT which no load command refers to", end, linkEditEnd-end),
+				Normalizable: end <= uint64(m.size),
+			}
+		case linkEditEnd < end:
+			return &LayoutError{
+				Offset:       linkEditEnd,
+				Size:         end - linkEditEnd,
+				Reason:       fmt.Sprintf("the code signature (ending at 0x%x) extends past the end of __LINKEDIT (at 0x%x)", end, linkEditEnd),
+				Normalizable: end <= uint64(m.size),
+			}
+		}
+	} else if linkEditEnd < contentEnd {
+		return &LayoutError{
+			Offset: linkEditEnd,
+			Size:   contentEnd - linkEditEnd,
+			Reason: fmt.Sprintf("the load commands refer to content past the end of __LINKEDIT (at 0x%x)", linkEditEnd),
+		}
+	}
+
+	switch size := uint64(m.size); {
+	case size < end:
+		return &LayoutError{
+			Offset: size,
+			Size:   end - size,
+			Reason: fmt.Sprintf("the binary (%d bytes) ends before its __LINKEDIT content (at 0x%x)", size, end),
+		}
+	case size > end:
+		return &LayoutError{
+			Offset:       end,
+			Size:         size - end,
+			Reason:       fmt.Sprintf("the binary has 0x%x bytes of trailing data after the end of __LINKEDIT (at 0x%x), which signing would overwrite", size-end, end),
+			Normalizable: true,
+		}
+	}
+	return nil
+}
+
+// NormalizeLayout fixes the layout problems reported by CheckLayout which can be fixed without relocating content:
+// content which no load command refers to (e.g. data appended to the binary) is dropped, and __LINKEDIT is resized to
+// end with the code signature.
+func (m *File) NormalizeLayout() error {
+	err := m.CheckLayout()
+	if err == nil {
+		return nil
+	}
+	if !IsNormalizableLayout(err) {
+		return err
+	}
+
+	linkEditSeg := m.Segment("__LINKEDIT")
+	end := linkEditSeg.Offset + linkEditSeg.Filesz
+	if cmd, _, err := m.CodeSigningCmd(); err == nil && cmd != nil {
+		end = uint64(cmd.DataOffset) + uint64(cmd.DataSize)
+		if err := m.SetLinkEditEnd(end); err != nil {
+			return err
+		}
+	}
+
+	if uint64(m.size) > end {
+		if err := m.truncate(int64(end)); err != nil {
+			return err
+		}
+	}
+	return m.CheckLayout()
+}
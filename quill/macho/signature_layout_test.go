@@ -0,0 +1,132 @@
+package macho
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_CheckLayout(t *testing.T) {
+	superBlob := []byte{0xfa, 0xde, 0x0c, 0xc0, 0, 0, 0, 12, 0, 0, 0, 0}
+
+	tests := []struct {
+		name string
+		// prepare changes the binary written by writeLinkEditMacho (__LINKEDIT holding 0x40 bytes of chained fixups at
+		// 0x500)
+		prepare          func(t *testing.T, m *File)
+		wantErr          bool
+		wantNormalizable bool
+		wantSize         int64
+	}{
+		{
+			name:     "unsigned",
+			prepare:  func(t *testing.T, m *File) {},
+			wantSize: 0x540,
+		},
+		{
+			name: "signed",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+				require.NoError(t, m.WriteCodeSignature(superBlob))
+			},
+			wantSize: 0x560,
+		},
+		{
+			name: "unsigned with trailing data",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.Patch([]byte("appended"), 8, 0x540))
+			},
+			wantErr:          true,
+			wantNormalizable: true,
+			wantSize:         0x540,
+		},
+		{
+			name: "signed with trailing data",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+				require.NoError(t, m.WriteCodeSignature(superBlob))
+				require.NoError(t, m.Patch([]byte("appended"), 8, 0x560))
+			},
+			wantErr:          true,
+			wantNormalizable: true,
+			wantSize:         0x560,
+		},
+		{
+			name: "signature followed by unreferenced __LINKEDIT content",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+				require.NoError(t, m.WriteCodeSignature(superBlob))
+				require.NoError(t, m.Patch(make([]byte, 0x10), 0x10, 0x560))
+				require.NoError(t, m.SetLinkEditEnd(0x570))
+			},
+			wantErr:          true,
+			wantNormalizable: true,
+			wantSize:         0x560,
+		},
+		{
+			name: "signature overlapping __LINKEDIT content",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+				require.NoError(t, m.WriteCodeSignature(superBlob))
+				require.NoError(t, m.SetCodeSigningCmd(0x520, 0x40))
+			},
+			wantErr:  true,
+			wantSize: 0x560,
+		},
+		{
+			name: "signature past the end of the binary",
+			prepare: func(t *testing.T, m *File) {
+				require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+				require.NoError(t, m.truncate(0x550))
+			},
+			wantErr:  true,
+			wantSize: 0x550,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLinkEditMacho(t, loadCommand(uint32(LcDyldChainedFixups), 16, 0x500, 0x40))
+			m, err := NewFile(path)
+			require.NoError(t, err)
+			defer m.Close()
+			tt.prepare(t, m)
+
+			err = m.CheckLayout()
+			if !tt.wantErr {
+				require.NoError(t, err)
+			} else {
+				var layoutErr *LayoutError
+				require.ErrorAs(t, err, &layoutErr)
+				assert.Equal(t, tt.wantNormalizable, IsNormalizableLayout(err))
+			}
+
+			err = m.NormalizeLayout()
+			if tt.wantErr && !tt.wantNormalizable {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSize, info.Size())
+		})
+	}
+}
+
+func TestFile_RemoveSigningContent_overlap(t *testing.T) {
+	m, err := NewFile(writeLinkEditMacho(t, loadCommand(uint32(LcDyldChainedFixups), 16, 0x500, 0x40)))
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.NoError(t, m.ReserveCodeSignature(0x540, 0x20))
+	require.NoError(t, m.SetCodeSigningCmd(0x520, 0x40))
+
+	// zeroing the signature would also zero the end of the __LINKEDIT content
+	require.Error(t, m.RemoveSigningContent())
+	by, err := os.ReadFile(m.path)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xbb), by[0x53f])
+}
@@ -76,3 +76,33 @@ func (s *SuperBlob) Finalize(paddingTarget int) {
 
 	log.WithFields("bytes", s.Length, "correction", padCorrection, "target", paddingTarget, "bytes-before-correction", lenBeforeCorrection).Trace("superblob size")
 }
+
+// Bytes encodes the (finalized) superblob into a single pre-sized buffer, as restruct.Pack does without reflection or
+// intermediate buffers.
+func (s *SuperBlob) Bytes() []byte {
+	size := int(unsafe.Sizeof(s.SuperBlobHeader)) + int(unsafe.Sizeof(BlobIndex{}))*len(s.Index) + len(s.Pad)
+	for _, b := range s.Blobs {
+		size += int(unsafe.Sizeof(b.BlobHeader)) + len(b.Payload)
+	}
+
+	// the padding is left zeroed
+	by := make([]byte, size)
+	put := func(off int, v uint32) int {
+		SigningOrder.PutUint32(by[off:], v)
+		return off + 4
+	}
+
+	off := put(0, uint32(s.Magic))
+	off = put(off, s.Length)
+	off = put(off, s.Count)
+	for _, i := range s.Index {
+		off = put(off, uint32(i.Type))
+		off = put(off, i.Offset)
+	}
+	for _, b := range s.Blobs {
+		off = put(off, uint32(b.Magic))
+		off = put(off, b.Length)
+		off += copy(by[off:], b.Payload)
+	}
+	return by
+}
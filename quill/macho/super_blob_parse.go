@@ -0,0 +1,113 @@
+package macho
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// SuperBlobEntry is a single blob indexed by a superblob (see ParseSuperBlob).
+type SuperBlobEntry struct {
+	Slot SlotType
+	// Offset is the offset of the blob relative to the start of the superblob.
+	Offset uint32
+	Magic  Magic
+	// Data is the entire blob, including the blob header.
+	Data []byte
+}
+
+// Payload is the content of the blob following the blob header.
+func (e SuperBlobEntry) Payload() []byte {
+	return e.Data[blobHeaderSize:]
+}
+
+// ParsedSuperBlob is a superblob read back from an embedded or detached signature (see ParseSuperBlob), the blobs
+// refer to the parsed bytes.
+type ParsedSuperBlob struct {
+	SuperBlobHeader
+	Entries []SuperBlobEntry
+}
+
+// ParseSuperBlob reads the header and every indexed blob of the given superblob (e.g. the code signature of a binary,
+// see File.ReadSuperBlob). The index and the length of every blob are checked against the given bytes, the magic is
+// not checked (embedded signatures use MagicEmbeddedSignature, detached signatures MagicDetachedSignature).
+func ParseSuperBlob(b []byte) (*ParsedSuperBlob, error) {
+	var s ParsedSuperBlob
+	headerSize := uint64(unsafe.Sizeof(s.SuperBlobHeader))
+	if uint64(len(b)) < headerSize {
+		return nil, malformed("superblob", "too short (%d bytes)", len(b))
+	}
+	s.Magic = Magic(SigningOrder.Uint32(b[0:]))
+	s.Length = SigningOrder.Uint32(b[4:])
+	s.Count = SigningOrder.Uint32(b[8:])
+
+	indexSize := uint64(unsafe.Sizeof(BlobIndex{}))
+	if headerSize+uint64(s.Count)*indexSize > uint64(len(b)) {
+		return nil, malformed("superblob", "index exceeds the signature (%d entries)", s.Count)
+	}
+
+	for i := uint64(0); i < uint64(s.Count); i++ {
+		at := headerSize + i*indexSize
+		slot := SlotType(SigningOrder.Uint32(b[at:]))
+		start := uint64(SigningOrder.Uint32(b[at+4:]))
+
+		if start+uint64(blobHeaderSize) > uint64(len(b)) {
+			return nil, malformed("superblob", "blob for slot=0x%x exceeds the signature", uint32(slot))
+		}
+		length := uint64(SigningOrder.Uint32(b[start+4:]))
+		if length < uint64(blobHeaderSize) || start+length > uint64(len(b)) {
+			return nil, malformed("superblob", "blob for slot=0x%x has an invalid length (%d)", uint32(slot), length)
+		}
+
+		s.Entries = append(s.Entries, SuperBlobEntry{
+			Slot:   slot,
+			Offset: uint32(start),
+			Magic:  Magic(SigningOrder.Uint32(b[start:])),
+			Data:   b[start : start+length],
+		})
+	}
+	return &s, nil
+}
+
+// Entry returns the first blob for the given slot, nil if there is none.
+func (s ParsedSuperBlob) Entry(slot SlotType) *SuperBlobEntry {
+	for i := range s.Entries {
+		if s.Entries[i].Slot == slot {
+			return &s.Entries[i]
+		}
+	}
+	return nil
+}
+
+// CodeDirectories returns the primary code directory followed by the alternate code directories (of other hash
+// types), in index order.
+func (s ParsedSuperBlob) CodeDirectories() []SuperBlobEntry {
+	var cds []SuperBlobEntry
+	if primary := s.Entry(CsSlotCodedirectory); primary != nil {
+		cds = append(cds, *primary)
+	}
+	for _, e := range s.Entries {
+		if e.Slot >= CsSlotAlternateCodedirectories && e.Slot < CsSlotAlternateCodedirectoryLimit {
+			cds = append(cds, e)
+		}
+	}
+	return cds
+}
+
+// ReadSuperBlob reads and parses the code signature superblob of the signed binary.
+func (m *File) ReadSuperBlob() (*ParsedSuperBlob, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+
+	if cmd == nil {
+		return nil, fmt.Errorf("binary is not signed")
+	}
+
+	superBlobBytes, err := m.ReadBytes(uint64(cmd.DataOffset), uint64(cmd.DataSize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract code signing block from macho binary: %w", err)
+	}
+
+	return ParseSuperBlob(superBlobBytes)
+}
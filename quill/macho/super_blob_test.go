@@ -4,6 +4,7 @@ import (
 	"testing"
 	"unsafe"
 
+	"github.com/go-restruct/restruct"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -261,3 +262,108 @@ func assertSuperBlobs(t *testing.T, expected []Blob, s SuperBlob) (int, []int) {
 	}
 	return expectedBlobLength, expectedBlobOffsets
 }
+
+func TestSuperBlob_Bytes(t *testing.T) {
+	s := NewSuperBlob(MagicEmbeddedSignature)
+	s.Add(CsSlotCodedirectory, &Blob{BlobHeader: BlobHeader{Magic: MagicCodedirectory, Length: 16}, Payload: []byte("payload!")})
+	requirements := NewBlob(MagicRequirements, nil)
+	s.Add(CsSlotRequirements, &requirements)
+	cms := NewBlob(MagicBlobwrapper, []byte("signature"))
+	s.Add(CsSlotCmsSignature, &cms)
+	s.Finalize(0)
+
+	// the encoding is the same as the reflection based encoding
+	expected, err := restruct.Pack(SigningOrder, &s)
+	require.NoError(t, err)
+	assert.Equal(t, expected, s.Bytes())
+}
+
+func TestParseSuperBlob(t *testing.T) {
+	cd := NewBlob(MagicCodedirectory, []byte("primary!"))
+	alternate := NewBlob(MagicCodedirectory, []byte("alternate"))
+	requirements := NewBlob(MagicRequirements, nil)
+	cms := NewBlob(MagicBlobwrapper, []byte("cms"))
+
+	sb := NewSuperBlob(MagicEmbeddedSignature)
+	sb.Add(CsSlotCodedirectory, &cd)
+	sb.Add(CsSlotRequirements, &requirements)
+	sb.Add(CsSlotAlternateCodedirectories, &alternate)
+	sb.Add(CsSlotCmsSignature, &cms)
+	sb.Finalize(0)
+	by := sb.Bytes()
+
+	parsed, err := ParseSuperBlob(by)
+	require.NoError(t, err)
+	assert.Equal(t, sb.SuperBlobHeader, parsed.SuperBlobHeader)
+	require.Len(t, parsed.Entries, 4)
+
+	for i, e := range parsed.Entries {
+		assert.Equal(t, sb.Index[i].Type, e.Slot)
+		assert.Equal(t, sb.Index[i].Offset, e.Offset)
+		assert.Equal(t, sb.Blobs[i].Magic, e.Magic)
+		assert.Equal(t, sb.Blobs[i].Payload, append([]byte(nil), e.Payload()...))
+		packed, err := sb.Blobs[i].Pack()
+		require.NoError(t, err)
+		assert.Equal(t, packed, e.Data)
+	}
+
+	cds := parsed.CodeDirectories()
+	require.Len(t, cds, 2)
+	assert.Equal(t, []byte("primary!"), cds[0].Payload())
+	assert.Equal(t, []byte("alternate"), cds[1].Payload())
+
+	require.NotNil(t, parsed.Entry(CsSlotCmsSignature))
+	assert.Equal(t, []byte("cms"), parsed.Entry(CsSlotCmsSignature).Payload())
+	assert.Nil(t, parsed.Entry(CsSlotEntitlements))
+
+	assert.Equal(t, "alternate code directory 0", CsSlotAlternateCodedirectories.Name())
+	assert.Equal(t, "blob wrapper", parsed.Entry(CsSlotCmsSignature).Magic.Name())
+}
+
+func TestParseSuperBlob_invalid(t *testing.T) {
+	cd := NewBlob(MagicCodedirectory, []byte("payload!"))
+	sb := NewSuperBlob(MagicEmbeddedSignature)
+	sb.Add(CsSlotCodedirectory, &cd)
+	sb.Finalize(0)
+	valid := sb.Bytes()
+
+	tests := []struct {
+		name  string
+		input func() []byte
+	}{
+		{
+			name:  "truncated header",
+			input: func() []byte { return valid[:8] },
+		},
+		{
+			name: "index exceeds the signature",
+			input: func() []byte {
+				by := append([]byte(nil), valid[:20]...)
+				SigningOrder.PutUint32(by[8:], 100)
+				return by
+			},
+		},
+		{
+			name: "blob offset exceeds the signature",
+			input: func() []byte {
+				by := append([]byte(nil), valid...)
+				SigningOrder.PutUint32(by[16:], uint32(len(by)))
+				return by
+			},
+		},
+		{
+			name: "blob length exceeds the signature",
+			input: func() []byte {
+				by := append([]byte(nil), valid...)
+				SigningOrder.PutUint32(by[24:], uint32(len(by)))
+				return by
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSuperBlob(tt.input())
+			require.Error(t, err)
+		})
+	}
+}
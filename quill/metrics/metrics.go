@@ -0,0 +1,92 @@
+/*
+Package metrics exposes instrumentation of signing and notarization (sign durations, page hashing throughput,
+timestamp authority latency, and notary wait times) through a Recorder that library consumers can wire into
+Prometheus, OpenTelemetry, or any other metrics system. Nothing is recorded until a recorder is set.
+*/
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+type Metric string
+
+const (
+	// SignDuration is the time taken to sign a single-arch binary, in seconds (labels: result).
+	SignDuration Metric = "quill_sign_duration_seconds"
+	// Signatures counts signed binaries (labels: result, adhoc).
+	Signatures Metric = "quill_signatures_total"
+	// HashedBytes counts the bytes of binaries hashed into code directories.
+	HashedBytes Metric = "quill_hashed_bytes_total"
+	// HashThroughput is the page hashing throughput of a binary, in bytes per second.
+	HashThroughput Metric = "quill_hash_throughput_bytes_per_second"
+	// TimestampLatency is the time taken by a single request to a timestamp server, in seconds (labels: server,
+	// result).
+	TimestampLatency Metric = "quill_timestamp_request_duration_seconds"
+	// NotaryWait is the time spent waiting for a notarization result, in seconds (labels: status).
+	NotaryWait Metric = "quill_notary_wait_duration_seconds"
+	// NotarySubmissions counts completed notarization submissions (labels: status).
+	NotarySubmissions Metric = "quill_notary_submissions_total"
+)
+
+const (
+	// ResultSuccess and ResultFailure are the values of the "result" label.
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Labels are the dimensions of a single measurement.
+type Labels map[string]string
+
+// Recorder receives every measurement. Count is used for counters and Observe for distributions (durations and
+// throughput), both may be called concurrently.
+type Recorder interface {
+	Count(metric Metric, delta float64, labels Labels)
+	Observe(metric Metric, value float64, labels Labels)
+}
+
+var (
+	lock     sync.RWMutex
+	recorder Recorder
+)
+
+// Set sets the recorder for all measurements (nil disables recording).
+func Set(r Recorder) {
+	lock.Lock()
+	defer lock.Unlock()
+	recorder = r
+}
+
+func get() Recorder {
+	lock.RLock()
+	defer lock.RUnlock()
+	return recorder
+}
+
+// Count increments the given counter, doing nothing when no recorder is set.
+func Count(metric Metric, delta float64, labels Labels) {
+	if r := get(); r != nil {
+		r.Count(metric, delta, labels)
+	}
+}
+
+// Observe records a single value of the given distribution, doing nothing when no recorder is set.
+func Observe(metric Metric, value float64, labels Labels) {
+	if r := get(); r != nil {
+		r.Observe(metric, value, labels)
+	}
+}
+
+// ObserveSince records the time elapsed since the given start of the given duration metric (in seconds).
+func ObserveSince(metric Metric, start time.Time, labels Labels) {
+	Observe(metric, time.Since(start).Seconds(), labels)
+}
+
+// Result returns the "result" label value for the given error.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
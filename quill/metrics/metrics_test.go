@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type measurement struct {
+	kind   string
+	metric Metric
+	value  float64
+	labels Labels
+}
+
+type memoryRecorder struct {
+	got []measurement
+}
+
+func (r *memoryRecorder) Count(metric Metric, delta float64, labels Labels) {
+	r.got = append(r.got, measurement{kind: "count", metric: metric, value: delta, labels: labels})
+}
+
+func (r *memoryRecorder) Observe(metric Metric, value float64, labels Labels) {
+	r.got = append(r.got, measurement{kind: "observe", metric: metric, value: value, labels: labels})
+}
+
+func TestSet(t *testing.T) {
+	// nothing is recorded (and nothing fails) without a recorder
+	Count(Signatures, 1, nil)
+
+	r := &memoryRecorder{}
+	Set(r)
+	defer Set(nil)
+
+	Count(Signatures, 1, Labels{"result": ResultSuccess})
+	ObserveSince(SignDuration, time.Now().Add(-time.Second), nil)
+
+	Set(nil)
+	Count(Signatures, 1, nil)
+
+	if assert.Len(t, r.got, 2) {
+		assert.Equal(t, measurement{kind: "count", metric: Signatures, value: 1, labels: Labels{"result": ResultSuccess}}, r.got[0])
+		assert.Equal(t, SignDuration, r.got[1].metric)
+		assert.GreaterOrEqual(t, r.got[1].value, 1.0)
+	}
+}
+
+func TestResult(t *testing.T) {
+	assert.Equal(t, ResultSuccess, Result(nil))
+	assert.Equal(t, ResultFailure, Result(errors.New("failed")))
+}
@@ -0,0 +1,68 @@
+// Package network is the single decision point for network access made by quill. Every outbound request (timestamp
+// servers, certificate chain (AIA) downloads, revocation checks, Kubernetes secrets, Fulcio, trust store downloads, and
+// the notary service) must be allowed by Allow first, so that offline mode (see SetOffline) is guaranteed to make no
+// network calls and so that all network access can be audited from one place.
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// Purpose describes why quill wants to access the network.
+type Purpose string
+
+const (
+	// Timestamp is a request to an RFC 3161 timestamp server.
+	Timestamp Purpose = "timestamp"
+	// AIA is the download of an issuer certificate via the Authority Information Access URL of a certificate.
+	AIA Purpose = "aia"
+	// Revocation is a certificate revocation (OCSP or CRL) check.
+	Revocation Purpose = "revocation"
+	// Kubernetes is the retrieval of signing material from a Kubernetes Secret.
+	Kubernetes Purpose = "kubernetes"
+	// Notary is a request to the Apple notary service.
+	Notary Purpose = "notary"
+	// Fulcio is a request for a short-lived signing certificate from a Sigstore Fulcio instance.
+	Fulcio Purpose = "fulcio"
+	// OIDC is a request for an OIDC identity token (e.g. from GitHub Actions) to exchange with Fulcio.
+	OIDC Purpose = "oidc"
+	// TrustStore is the download of a snapshot of the Apple root and intermediate certificates.
+	TrustStore Purpose = "trust-store"
+)
+
+// ErrOffline is returned (wrapped) for any network access attempted while in offline mode.
+var ErrOffline = errors.New("network access is disabled (offline mode)")
+
+var offline int32
+
+// SetOffline enables or disables offline mode for the process, in which all network access is refused.
+func SetOffline(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&offline, v)
+	if enabled {
+		log.Info("offline mode enabled: no network access will be made")
+	}
+}
+
+// Offline indicates if offline mode is enabled.
+func Offline() bool {
+	return atomic.LoadInt32(&offline) == 1
+}
+
+// Allow returns an error (wrapping ErrOffline) if network access for the given purpose to the given target (e.g. a
+// URL or host) is not allowed.
+func Allow(purpose Purpose, target string) error {
+	if Offline() {
+		log.WithFields("purpose", purpose, "target", target).Debug("network access refused (offline mode)")
+		return fmt.Errorf("%w: refusing %s request to %s", ErrOffline, purpose, target)
+	}
+	log.WithFields("purpose", purpose, "target", target).Trace("network access")
+	return nil
+}
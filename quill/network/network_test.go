@@ -0,0 +1,25 @@
+package network
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllow(t *testing.T) {
+	t.Cleanup(func() { SetOffline(false) })
+
+	require.NoError(t, Allow(Timestamp, "http://timestamp.example.com"))
+
+	SetOffline(true)
+	assert.True(t, Offline())
+	err := Allow(Timestamp, "http://timestamp.example.com")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrOffline))
+	assert.Contains(t, err.Error(), "http://timestamp.example.com")
+
+	SetOffline(false)
+	require.NoError(t, Allow(AIA, "http://certs.example.com/ca.cer"))
+}
@@ -3,6 +3,7 @@ package quill
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,12 +11,19 @@ import (
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/quill/event"
 	"github.com/anchore/quill/quill/notary"
+	"github.com/anchore/quill/quill/remediation"
 )
 
 type NotarizeConfig struct {
 	StatusConfig notary.StatusConfig
 	HTTPTimeout  time.Duration
 	TokenConfig  notary.TokenConfig
+	// Cache skips submitting payloads that were accepted before (by the digest of their content) and remembers
+	// accepted submissions (nil disables caching).
+	Cache *notary.SubmissionCache
+	// CheckHistory looks for a prior accepted submission of the payload within the notary submission history of the
+	// team before submitting it.
+	CheckHistory bool
 }
 
 func NewNotarizeConfig(issuer, privateKeyID, privateKey string) *NotarizeConfig {
@@ -42,6 +50,20 @@ func (c *NotarizeConfig) WithStatusConfig(cfg notary.StatusConfig) *NotarizeConf
 	return c
 }
 
+// WithCache skips notarizing artifacts with the same content as a prior accepted submission remembered by the given
+// cache (and remembers accepted submissions).
+func (c *NotarizeConfig) WithCache(cache *notary.SubmissionCache) *NotarizeConfig {
+	c.Cache = cache
+	return c
+}
+
+// WithHistoryCheck skips notarizing artifacts with the same content as a prior accepted submission found within the
+// notary submission history.
+func (c *NotarizeConfig) WithHistoryCheck(check bool) *NotarizeConfig {
+	c.CheckHistory = check
+	return c
+}
+
 /*
 
 Source: https://developer.apple.com/documentation/security/notarizing_macos_software_before_distribution
@@ -79,7 +101,22 @@ Apple's notary service requires you to adopt the following protections:
 
 */
 
+// NotarizeResult is the outcome of notarizing an artifact.
+type NotarizeResult struct {
+	// SubmissionID identifies the notary submission (the prior accepted submission when notarization was skipped), empty
+	// when nothing was submitted.
+	SubmissionID string
+	// Status is the final status of the submission, empty when not waiting for the result.
+	Status notary.SubmissionStatus
+}
+
 func Notarize(path string, cfg NotarizeConfig) (notary.SubmissionStatus, error) {
+	result, err := NotarizeWithResult(path, cfg)
+	return result.Status, err
+}
+
+// NotarizeWithResult notarizes the artifact at the given path as Notarize does, returning the notary submission as well.
+func NotarizeWithResult(path string, cfg NotarizeConfig) (NotarizeResult, error) {
 	log.WithFields("binary", path).Info("notarizing binary")
 
 	mon := bus.PublishTask(
@@ -96,26 +133,97 @@ func Notarize(path string, cfg NotarizeConfig) (notary.SubmissionStatus, error)
 
 	mon.Stage.Current = "validating binary"
 
+	if err := checkSigned(path); err != nil {
+		return NotarizeResult{}, err
+	}
+
+	return submit(mon, cfg, func() (*notary.Payload, error) {
+		return notary.NewPayload(path)
+	})
+}
+
+// NotarizeBinaries notarizes the given signed standalone binaries (e.g. every executable of a release) with a single
+// submission: the binaries are zipped together, submitted once, and the result applies to every binary. There is
+// nothing to staple afterwards: Apple does not support stapling a ticket to a Mach-O binary, Gatekeeper looks up the
+// ticket of each notarized binary online (by its code directory hash) instead.
+func NotarizeBinaries(paths []string, cfg NotarizeConfig) (notary.SubmissionStatus, error) {
+	result, err := NotarizeBinariesWithResult(paths, cfg)
+	return result.Status, err
+}
+
+// NotarizeBinariesWithResult notarizes the given binaries as NotarizeBinaries does, returning the (single) notary
+// submission as well.
+func NotarizeBinariesWithResult(paths []string, cfg NotarizeConfig) (NotarizeResult, error) {
+	if len(paths) == 0 {
+		return NotarizeResult{}, fmt.Errorf("no binaries to notarize")
+	}
+	log.WithFields("binaries", len(paths)).Info("notarizing binaries")
+
+	mon := bus.PublishTask(
+		event.Title{
+			Default:      "Notarize binaries",
+			WhileRunning: "Notarizing binaries",
+			OnSuccess:    "Notarized binaries",
+		},
+		fmt.Sprintf("%d binaries", len(paths)),
+		-1,
+	)
+
+	defer mon.SetCompleted()
+
+	mon.Stage.Current = "validating binaries"
+
+	for _, p := range paths {
+		if err := checkSigned(p); err != nil {
+			return NotarizeResult{}, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	result, err := submit(mon, cfg, func() (*notary.Payload, error) {
+		return notary.NewBinariesPayload(filepath.Base(paths[0])+".zip", paths...)
+	})
+	if err == nil && result.Status == notary.AcceptedStatus {
+		for _, p := range paths {
+			log.WithFields("binary", p).Info("notarized binary (the ticket is looked up online, binaries cannot hold a stapled ticket)")
+		}
+	}
+	return result, err
+}
+
+func checkSigned(path string) error {
 	if isSigned, err := IsSigned(path); err != nil {
-		return "", fmt.Errorf("unable to determine if binary is signed: %+v", err)
+		return fmt.Errorf("unable to determine if binary is signed: %+v", err)
 	} else if !isSigned {
-		return "", fmt.Errorf("binary is not signed thus will not pass notarization")
+		return remediation.Wrap(fmt.Errorf("binary is not signed thus will not pass notarization"), remediation.NotSigned,
+			"sign the binary with a Developer ID certificate before notarizing it (or use 'quill sign-and-notarize')", "quill sign")
 	}
+	return nil
+}
 
+// submit uploads the given payload to the notary service, waiting for the result unless configured otherwise.
+func submit(mon *event.ManualStagedProgress, cfg NotarizeConfig, payload func() (*notary.Payload, error)) (NotarizeResult, error) {
 	mon.Stage.Current = "initializing client"
 
 	token, err := notary.NewSignedToken(cfg.TokenConfig)
 	if err != nil {
-		return "", err
+		return NotarizeResult{}, err
 	}
 
 	a := notary.NewAPIClient(token, cfg.HTTPTimeout)
 
 	mon.Stage.Current = "processing payload"
 
-	bin, err := notary.NewPayload(path)
+	bin, err := payload()
 	if err != nil {
-		return "", err
+		return NotarizeResult{}, err
+	}
+
+	if prior, err := priorSubmission(a, cfg, bin.Digest); err != nil {
+		return NotarizeResult{}, err
+	} else if prior != nil {
+		log.WithFields("id", prior.ID, "digest", bin.Digest, "accepted", prior.Date.Format(time.RFC3339)).Info("skipping notarization, identical content was accepted before")
+		mon.Stage.Current = "accepted before"
+		return NotarizeResult{SubmissionID: prior.ID, Status: notary.AcceptedStatus}, nil
 	}
 
 	mon.Stage.Current = "submitting"
@@ -123,12 +231,12 @@ func Notarize(path string, cfg NotarizeConfig) (notary.SubmissionStatus, error)
 	sub := notary.NewSubmission(a, bin)
 
 	if err := sub.Start(context.Background()); err != nil {
-		return "", fmt.Errorf("unable to start submission: %+v", err)
+		return NotarizeResult{}, fmt.Errorf("unable to start submission: %+v", err)
 	}
 
 	if !cfg.StatusConfig.Wait {
 		log.WithFields("id", sub.ID()).Infof("Submission started but configured to not wait for the results")
-		return "", nil
+		return NotarizeResult{SubmissionID: sub.ID()}, nil
 	}
 
 	statusCfg := cfg.StatusConfig.WithProgress(&mon.Stage)
@@ -137,5 +245,42 @@ func Notarize(path string, cfg NotarizeConfig) (notary.SubmissionStatus, error)
 
 	mon.Stage.Current = strings.ToLower(fmt.Sprintf("status %q", string(status)))
 
-	return status, err
+	if notary.IsPending(err) {
+		log.WithFields("id", sub.ID()).Info("stopped waiting for the notarization result, the submission is still pending")
+		mon.Stage.Current = "still pending"
+	}
+
+	if err == nil && status == notary.AcceptedStatus && cfg.Cache != nil {
+		accepted := notary.AcceptedSubmission{ID: sub.ID(), Name: filepath.Base(bin.Path), Digest: bin.Digest, Date: time.Now()}
+		if err := cfg.Cache.Record(accepted); err != nil {
+			log.WithFields("id", sub.ID(), "error", err).Warn("unable to cache accepted submission")
+		}
+	}
+
+	return NotarizeResult{SubmissionID: sub.ID(), Status: status}, err
+}
+
+// priorSubmission returns the prior accepted submission of the payload with the given digest found within the cache
+// or the submission history (as configured), nil if there is none.
+func priorSubmission(a *notary.APIClient, cfg NotarizeConfig, digest string) (*notary.AcceptedSubmission, error) {
+	if cfg.Cache != nil {
+		if prior, ok := cfg.Cache.Lookup(digest); ok {
+			return prior, nil
+		}
+	}
+
+	if !cfg.CheckHistory {
+		return nil, nil
+	}
+
+	prior, ok, err := notary.FindAcceptedSubmission(context.Background(), a, digest)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if cfg.Cache != nil {
+		if err := cfg.Cache.Record(*prior); err != nil {
+			log.WithFields("id", prior.ID, "error", err).Debug("unable to cache accepted submission")
+		}
+	}
+	return prior, nil
 }
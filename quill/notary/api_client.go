@@ -83,7 +83,7 @@ func (s APIClient) uploadBinary(ctx context.Context, response submissionResponse
 			reader: bin.Reader,
 			size:   bin.Size(),
 		},
-		ContentType: aws.String("application/zip"),
+		ContentType: aws.String(bin.kind().ContentType()),
 	}
 
 	_, err = uploader.UploadWithContext(ctx, input)
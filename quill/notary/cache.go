@@ -0,0 +1,108 @@
+package notary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// SubmissionCache remembers accepted submissions by the digest of their payload, so that artifacts with unchanged
+// content (e.g. across CI re-runs) are not notarized again.
+type SubmissionCache struct {
+	Dir string
+}
+
+// AcceptedSubmission is a prior accepted submission of a payload.
+type AcceptedSubmission struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	// Date is when the submission was accepted (or created, when found within the submission history).
+	Date time.Time `json:"date"`
+}
+
+// NewSubmissionCache creates a cache within the user cache directory.
+func NewSubmissionCache() *SubmissionCache {
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "quill", "notary")
+	}
+	return &SubmissionCache{Dir: cacheDir}
+}
+
+// Lookup returns the accepted submission of the payload with the given digest, if any.
+func (c SubmissionCache) Lookup(digest string) (*AcceptedSubmission, bool) {
+	if c.Dir == "" || digest == "" {
+		return nil, false
+	}
+
+	by, err := os.ReadFile(c.path(digest))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.WithFields("digest", digest, "error", err).Debug("unable to read cached submission")
+		}
+		return nil, false
+	}
+
+	var sub AcceptedSubmission
+	if err := json.Unmarshal(by, &sub); err != nil || sub.Digest != digest {
+		log.WithFields("digest", digest).Debug("ignoring invalid cached submission")
+		return nil, false
+	}
+	return &sub, true
+}
+
+// Record remembers the given accepted submission.
+func (c SubmissionCache) Record(sub AcceptedSubmission) error {
+	if c.Dir == "" {
+		return nil
+	}
+	if sub.Digest == "" {
+		return fmt.Errorf("unable to cache a submission without a digest")
+	}
+
+	by, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("unable to create submission cache: %w", err)
+	}
+	return os.WriteFile(c.path(sub.Digest), by, 0600)
+}
+
+func (c SubmissionCache) path(digest string) string {
+	return filepath.Join(c.Dir, filepath.Base(digest)+".json")
+}
+
+// FindAcceptedSubmission looks for an accepted submission of the payload with the given digest within the submission
+// history of the team (submissions made by quill are named after the digest of their payload).
+func FindAcceptedSubmission(ctx context.Context, a api, digest string) (*AcceptedSubmission, bool, error) {
+	if digest == "" {
+		return nil, false, nil
+	}
+
+	history, err := ExistingSubmission(a, "").List(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to list prior submissions: %w", err)
+	}
+
+	for _, item := range history {
+		if item.Status != AcceptedStatus || !strings.Contains(item.Name, "-"+digest+"-") {
+			continue
+		}
+		sub := AcceptedSubmission{ID: item.ID, Name: item.Name, Digest: digest}
+		if created, err := time.Parse(time.RFC3339, item.CreatedDate); err == nil {
+			sub.Date = created
+		}
+		return &sub, true, nil
+	}
+	return nil, false, nil
+}
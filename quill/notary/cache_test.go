@@ -0,0 +1,64 @@
+package notary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionCache(t *testing.T) {
+	cache := SubmissionCache{Dir: t.TempDir()}
+
+	_, ok := cache.Lookup("abc")
+	assert.False(t, ok)
+
+	accepted := AcceptedSubmission{ID: "id-1", Name: "tool", Digest: "abc", Date: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	require.NoError(t, cache.Record(accepted))
+
+	got, ok := cache.Lookup("abc")
+	require.True(t, ok)
+	assert.Equal(t, accepted, *got)
+
+	_, ok = cache.Lookup("other")
+	assert.False(t, ok)
+
+	require.Error(t, cache.Record(AcceptedSubmission{ID: "id-2"}))
+
+	// without a directory nothing is cached
+	disabled := SubmissionCache{}
+	require.NoError(t, disabled.Record(accepted))
+	_, ok = disabled.Lookup("abc")
+	assert.False(t, ok)
+}
+
+func TestFindAcceptedSubmission(t *testing.T) {
+	item := func(id, name, status string) submissionListResponseData {
+		return submissionListResponseData{
+			submissionResponseDescriptor: submissionResponseDescriptor{ID: id},
+			Attributes: submissionListResponseAttributes{
+				Name:        name,
+				Status:      status,
+				CreatedDate: "2024-01-02T03:04:05Z",
+			},
+		}
+	}
+
+	api := newMockAPI()
+	api.listResponse = &submissionListResponse{Data: []submissionListResponseData{
+		item("1", "tool-abc-12345678", "Invalid"),
+		item("2", "tool-def-12345678", "Accepted"),
+		item("3", "tool-abc-87654321", "Accepted"),
+	}}
+
+	got, ok, err := FindAcceptedSubmission(context.Background(), api, "abc")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, AcceptedSubmission{ID: "3", Name: "tool-abc-87654321", Digest: "abc", Date: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}, *got)
+
+	_, ok, err = FindAcceptedSubmission(context.Background(), api, "xyz")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/network"
 )
 
 type httpClient struct {
@@ -48,7 +49,37 @@ func (s httpClient) post(ctx context.Context, endpoint string, body io.Reader) (
 }
 
 func (s httpClient) do(request *http.Request) (*http.Response, error) {
+	if err := network.Allow(network.Notary, request.URL.String()); err != nil {
+		return nil, err
+	}
+
 	log.Tracef("http %s %s", request.Method, request.URL)
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token))
-	return s.client.Do(request)
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(request.Context()); err != nil {
+			return nil, err
+		}
+
+		response, err := s.client.Do(request)
+		if err != nil || response.StatusCode != http.StatusTooManyRequests || attempt == maxThrottledRetries {
+			return response, err
+		}
+
+		// throttled by the notary API: wait (as told by the API, when it does) and try again
+		d := retryAfter(response, attempt)
+		response.Body.Close()
+		log.WithFields("url", request.URL, "wait", d).Debug("notary API request throttled, retrying")
+		if err := sleep(request.Context(), d); err != nil {
+			return nil, err
+		}
+
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+	}
 }
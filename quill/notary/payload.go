@@ -14,23 +14,73 @@ import (
 	"github.com/klauspost/compress/zip"
 
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/dmg"
 	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/xar"
 )
 
+// PayloadKind is the format of a payload, the notary service tells formats apart by the extension of the submission
+// name.
+type PayloadKind string
+
+const (
+	// ZipPayload is a zip archive (of binaries or bundles).
+	ZipPayload PayloadKind = "zip"
+	// DiskImagePayload is a (signed) disk image, submitted as is.
+	DiskImagePayload PayloadKind = "dmg"
+	// PackagePayload is a (signed) flat installer package, submitted as is.
+	PackagePayload PayloadKind = "pkg"
+)
+
+// Extension is the file extension of the payload format (e.g. ".dmg").
+func (k PayloadKind) Extension() string {
+	return "." + string(k)
+}
+
+// ContentType is the MIME type of the payload format.
+func (k PayloadKind) ContentType() string {
+	switch k {
+	case DiskImagePayload:
+		return "application/x-apple-diskimage"
+	case PackagePayload:
+		return "application/x-xar"
+	}
+	return "application/zip"
+}
+
 type Payload struct {
-	*bytes.Reader // zip file with the binary
+	*bytes.Reader // zip file with the binary, or the disk image or installer package as is
 	Path          string
 	Digest        string
+	// Kind is the format of the payload (ZipPayload when unset).
+	Kind PayloadKind
 }
 
+func (p Payload) kind() PayloadKind {
+	if p.Kind == "" {
+		return ZipPayload
+	}
+	return p.Kind
+}
+
+// NewPayload prepares the file at the given path for submission: zip archives, disk images, and installer packages
+// (detected by their content, not their extension) are submitted as is, binaries are zipped.
 func NewPayload(path string) (*Payload, error) {
+	kind, err := fileKind(path)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "" {
+		return prepareFile(path, kind)
+	}
+
 	contentType, err := fileContentType(path)
 	if err != nil {
 		return nil, err
 	}
 	switch contentType {
 	case "application/zip":
-		return prepareZip(path)
+		return prepareFile(path, ZipPayload)
 	default:
 		return prepareBinary(path)
 	}
@@ -38,8 +88,31 @@ func NewPayload(path string) (*Payload, error) {
 	// TODO: support repackaging tar.gz for easy with goreleaser
 }
 
-func prepareZip(path string) (*Payload, error) {
-	log.Trace("using provided zip as payload")
+// fileKind returns the kind of the disk image or installer package at the given path, empty for any other file.
+func fileKind(path string) (PayloadKind, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case xar.IsArchive(f):
+		return PackagePayload, nil
+	case dmg.IsDiskImage(f, info.Size()):
+		return DiskImagePayload, nil
+	}
+	return "", nil
+}
+
+// prepareFile uses the file at the given path as the payload as is.
+func prepareFile(path string, kind PayloadKind) (*Payload, error) {
+	log.WithFields("kind", kind).Trace("using provided file as payload")
 
 	f, err := os.Open(path)
 
@@ -58,59 +131,87 @@ func prepareZip(path string) (*Payload, error) {
 	}
 
 	if buf.Len() == 0 {
-		return nil, fmt.Errorf("zip file is empty")
+		return nil, fmt.Errorf("%s file is empty", kind)
 	}
 
 	return &Payload{
 		Reader: bytes.NewReader(buf.Bytes()),
 		Path:   path,
 		Digest: hex.EncodeToString(h.Sum(nil)),
+		Kind:   kind,
 	}, nil
 }
 
 func prepareBinary(path string) (*Payload, error) {
 	log.Trace("zipping up binary payload")
+	return prepareBinaries(path, path)
+}
 
-	// verify that we're opening a macho file (not a zip of the binary or anything else)
-	isMacho, err := macho.IsMachoFile(path)
-	if err != nil {
-		return nil, err
-	}
-	if !isMacho {
-		return nil, fmt.Errorf("binary file is not a darwin macho executable file")
+// NewBinariesPayload zips the given signed binaries into a single payload (named after the given path), so that
+// several standalone binaries (e.g. every executable of a release) are notarized by a single submission.
+func NewBinariesPayload(path string, binPaths ...string) (*Payload, error) {
+	if len(binPaths) == 0 {
+		return nil, fmt.Errorf("no binaries to notarize")
 	}
+	log.WithFields("binaries", len(binPaths)).Trace("zipping up binaries payload")
+	return prepareBinaries(path, binPaths...)
+}
 
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+func prepareBinaries(path string, binPaths ...string) (*Payload, error) {
+	var entries []zipEntry
+	names := make(map[string]string)
+	for _, binPath := range binPaths {
+		// verify that we're opening a macho file (not a zip of the binary or anything else)
+		isMacho, err := macho.IsMachoFile(binPath)
+		if err != nil {
+			return nil, err
+		}
+		if !isMacho {
+			return nil, fmt.Errorf("binary file is not a darwin macho executable file: %q", binPath)
+		}
+
+		// every binary is at the root of the zip, so the names must be unique
+		name := filepath.Base(binPath)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("binaries %q and %q have the same name", other, binPath)
+		}
+		names[name] = binPath
+
+		entries = append(entries, zipEntry{name: name, path: binPath})
 	}
 
-	zippedBinary, err := createZip(filepath.Base(path), f)
+	zipped, err := createZip(entries...)
 	if err != nil {
 		return nil, err
 	}
 
 	h := sha256.New()
 
-	n, err := io.Copy(h, bytes.NewReader(zippedBinary.Bytes()))
+	n, err := io.Copy(h, bytes.NewReader(zipped.Bytes()))
 	if err != nil {
 		return nil, err
 	}
 
 	log.WithFields("bytes", n, "digest", hex.EncodeToString(h.Sum(nil))).Trace("hashed zip")
 
-	if zippedBinary.Len() == 0 {
+	if zipped.Len() == 0 {
 		return nil, fmt.Errorf("zip file is empty")
 	}
 
 	return &Payload{
-		Reader: bytes.NewReader(zippedBinary.Bytes()),
+		Reader: bytes.NewReader(zipped.Bytes()),
 		Path:   path,
 		Digest: hex.EncodeToString(h.Sum(nil)),
+		Kind:   ZipPayload,
 	}, nil
 }
 
-func createZip(name string, reader io.Reader) (*bytes.Buffer, error) {
+type zipEntry struct {
+	name string
+	path string
+}
+
+func createZip(entries ...zipEntry) (*bytes.Buffer, error) {
 	buf := bytes.Buffer{}
 
 	// note: the stdlib zip utility runs into the same problem as described here:
@@ -119,27 +220,42 @@ func createZip(name string, reader io.Reader) (*bytes.Buffer, error) {
 	// which is why we're using another library
 	w := zip.NewWriter(&buf)
 
-	f, err := w.Create(name)
-	if err != nil {
-		return nil, err
-	}
-
-	n, err := io.Copy(f, reader)
-	if err != nil {
-		return nil, err
-	} else if n == 0 {
-		return nil, fmt.Errorf("binary file is empty")
+	for _, e := range entries {
+		if err := addZipEntry(w, e); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
 
-	log.WithFields("bytes", buf.Len(), "name", name).Trace("wrote binary payload to zip")
+	log.WithFields("bytes", buf.Len(), "entries", len(entries)).Trace("wrote binary payload to zip")
 
 	return &buf, nil
 }
 
+func addZipEntry(w *zip.Writer, e zipEntry) error {
+	reader, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	f, err := w.Create(e.name)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, reader)
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("binary file is empty: %q", e.path)
+	}
+	return nil
+}
+
 func fileContentType(path string) (string, error) {
 	f, err := os.Open(path)
 
@@ -0,0 +1,94 @@
+package notary
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/dmg"
+	"github.com/anchore/quill/quill/macho/machotest"
+)
+
+func TestNewBinariesPayload(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a", "tool"), filepath.Join(dir, "b", "helper")
+	machotest.Write(t, a, machotest.Config{})
+	machotest.Write(t, b, machotest.Config{})
+
+	payload, err := NewBinariesPayload("release.zip", a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "release.zip", payload.Path)
+	assert.Len(t, payload.Digest, 64)
+
+	content, err := io.ReadAll(payload)
+	require.NoError(t, err)
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"tool", "helper"}, names)
+}
+
+func TestNewBinariesPayload_invalid(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a", "tool"), filepath.Join(dir, "b", "tool")
+	machotest.Write(t, a, machotest.Config{})
+	machotest.Write(t, b, machotest.Config{})
+
+	_, err := NewBinariesPayload("release.zip", a, b)
+	require.ErrorContains(t, err, "have the same name")
+
+	notBinary := filepath.Join(dir, "README")
+	require.NoError(t, os.WriteFile(notBinary, []byte("readme"), 0600))
+	_, err = NewBinariesPayload("release.zip", a, notBinary)
+	require.Error(t, err)
+
+	_, err = NewBinariesPayload("release.zip")
+	require.ErrorContains(t, err, "no binaries to notarize")
+}
+
+func TestNewPayload_asIs(t *testing.T) {
+	dir := t.TempDir()
+
+	image := filepath.Join(dir, "release.dmg")
+	trailer := make([]byte, dmg.TrailerSize)
+	copy(trailer, dmg.TrailerMagic)
+	require.NoError(t, os.WriteFile(image, append([]byte("image"), trailer...), 0600))
+
+	// detected by content, not by extension
+	pkg := filepath.Join(dir, "installer")
+	require.NoError(t, os.WriteFile(pkg, []byte("xar!-the-rest-of-the-package"), 0600))
+
+	tests := []struct {
+		path     string
+		wantKind PayloadKind
+		wantType string
+	}{
+		{path: image, wantKind: DiskImagePayload, wantType: "application/x-apple-diskimage"},
+		{path: pkg, wantKind: PackagePayload, wantType: "application/x-xar"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.wantKind), func(t *testing.T) {
+			payload, err := NewPayload(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKind, payload.Kind)
+			assert.Equal(t, tt.wantType, payload.Kind.ContentType())
+			assert.Len(t, payload.Digest, 64)
+
+			content, err := io.ReadAll(payload)
+			require.NoError(t, err)
+			want, err := os.ReadFile(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, want, content, "the payload is submitted as is")
+		})
+	}
+}
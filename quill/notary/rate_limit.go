@@ -0,0 +1,113 @@
+package notary
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// RateLimit bounds the requests made to the notary API by the process: every submission and status poll shares the
+// same limit, and requests beyond the limit are queued (they wait for their turn) rather than failing.
+type RateLimit struct {
+	// PerMinute is the number of requests allowed per minute (zero disables the limit).
+	PerMinute int
+	// Burst is the number of requests that may be made at once before requests are spaced out (at least one).
+	Burst int
+}
+
+// maxThrottledRetries is the number of times a request throttled by the notary API (HTTP 429) is retried.
+const maxThrottledRetries = 3
+
+var limiter = &rateLimiter{}
+
+// SetRateLimit sets the limit shared by every request made to the notary API by the process.
+func SetRateLimit(limit RateLimit) {
+	limiter.set(limit)
+	if limit.PerMinute > 0 {
+		log.WithFields("per-minute", limit.PerMinute, "burst", limit.Burst).Debug("notary API rate limit")
+	}
+}
+
+// rateLimiter spaces out requests evenly (a request is allowed once the previous requests have been spaced out,
+// allowing a burst of requests when idle).
+type rateLimiter struct {
+	lock     sync.Mutex
+	interval time.Duration
+	burst    int
+	// next is when the next request would be allowed if there were no burst.
+	next time.Time
+}
+
+func (l *rateLimiter) set(limit RateLimit) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.interval = 0
+	if limit.PerMinute > 0 {
+		l.interval = time.Minute / time.Duration(limit.PerMinute)
+	}
+	l.burst = limit.Burst
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	l.next = time.Time{}
+}
+
+// reserve takes the next slot, returning how long to wait before making the request.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.interval == 0 {
+		return 0
+	}
+
+	next := l.next
+	if next.Before(now) {
+		next = now
+	}
+	l.next = next.Add(l.interval)
+
+	if d := next.Add(-time.Duration(l.burst-1) * l.interval).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// wait blocks until the request may be made (or the context is done).
+func (l *rateLimiter) wait(ctx context.Context) error {
+	d := l.reserve(time.Now())
+	if d == 0 {
+		return nil
+	}
+
+	log.WithFields("wait", d).Debug("waiting for notary API rate limit")
+	return sleep(ctx, d)
+}
+
+// retryAfter returns how long to wait before retrying a request throttled by the notary API.
+func retryAfter(response *http.Response, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(1<<attempt) * 5 * time.Second
+}
+
+// sleep waits for the given duration (unless the context is done first).
+var sleep = sleepContext
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package notary
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	l := &rateLimiter{}
+	now := time.Now()
+
+	// no limit
+	assert.Zero(t, l.reserve(now))
+
+	l.set(RateLimit{PerMinute: 60, Burst: 2})
+	assert.Zero(t, l.reserve(now))
+	assert.Zero(t, l.reserve(now))
+	// requests beyond the burst are spaced out (queued in order)
+	assert.Equal(t, time.Second, l.reserve(now))
+	assert.Equal(t, 2*time.Second, l.reserve(now))
+
+	// after being idle the burst is available again
+	later := now.Add(time.Minute)
+	assert.Zero(t, l.reserve(later))
+	assert.Zero(t, l.reserve(later))
+	assert.Equal(t, time.Second, l.reserve(later))
+}
+
+func Test_httpClient_throttled(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	t.Cleanup(func() { sleep = sleepContext })
+
+	var calls int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "7")
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+	c := newHTTPClient("the-token", time.Second*3)
+
+	resp, err := c.get(context.TODO(), s.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{7 * time.Second, 10 * time.Second}, slept)
+}
@@ -8,13 +8,39 @@ import (
 	"time"
 
 	"github.com/wagoodman/go-progress"
+
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/metrics"
 )
 
 type StatusConfig struct {
 	Timeout time.Duration
 	Poll    time.Duration
 	Wait    bool
-	stage   *progress.Stage
+	// MaxWait bounds the wait for a conclusive status (0 for no bound): once elapsed, a submission still in progress
+	// ends the wait with a PendingError instead of failing, so the wait can be resumed later by submission ID.
+	MaxWait time.Duration
+	// Deadline is the time to stop waiting for a conclusive status (zero for none), as with MaxWait.
+	Deadline time.Time
+	stage    *progress.Stage
+}
+
+// PendingError is returned when the wait for a submission ends (see StatusConfig.MaxWait and StatusConfig.Deadline)
+// while Apple is still processing it. The submission is neither accepted nor rejected: its status can be checked again
+// later by ID.
+type PendingError struct {
+	ID     string
+	Waited time.Duration
+}
+
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("submission %s is still pending after waiting %s (resume with 'quill submission status %s --wait')", e.ID, e.Waited.Round(time.Second), e.ID)
+}
+
+// IsPending indicates if the given error is (or wraps) a PendingError.
+func IsPending(err error) bool {
+	var pending *PendingError
+	return errors.As(err, &pending)
 }
 
 func (c *StatusConfig) WithProgress(stage *progress.Stage) *StatusConfig {
@@ -23,6 +49,21 @@ func (c *StatusConfig) WithProgress(stage *progress.Stage) *StatusConfig {
 }
 
 func PollStatus(ctx context.Context, sub *Submission, cfg StatusConfig) (SubmissionStatus, error) {
+	start := time.Now()
+	status, err := pollStatus(ctx, sub, cfg)
+
+	label := metrics.Labels{"status": string(status)}
+	if status == "" {
+		// the status of failed submissions is not returned
+		label["status"] = metrics.ResultFailure
+	}
+	metrics.ObserveSince(metrics.NotaryWait, start, label)
+	metrics.Count(metrics.NotarySubmissions, 1, label)
+
+	return status, err
+}
+
+func pollStatus(ctx context.Context, sub *Submission, cfg StatusConfig) (SubmissionStatus, error) {
 	var err error
 
 	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
@@ -30,6 +71,9 @@ func PollStatus(ctx context.Context, sub *Submission, cfg StatusConfig) (Submiss
 
 	var status SubmissionStatus = PendingStatus
 
+	start := time.Now()
+	stop := cfg.stopWaitingAt(start)
+
 	var count int
 	for !status.isCompleted() {
 		select {
@@ -37,18 +81,28 @@ func PollStatus(ctx context.Context, sub *Submission, cfg StatusConfig) (Submiss
 			return TimeoutStatus, errors.New("timeout waiting for notarize submission response")
 
 		default:
+			// the status is requested at least once, even when the deadline already passed
+			if count > 0 && !stop.IsZero() && !time.Now().Before(stop) {
+				return PendingStatus, &PendingError{ID: sub.ID(), Waited: time.Since(start)}
+			}
+
 			count++
+			previous := status
 			status, err = sub.Status(ctx)
 			if err != nil {
 				return "", err
 			}
 
+			if status != previous || count == 1 {
+				lifecycle.Publish(lifecycle.Event{Type: lifecycle.NotaryStatusChanged, Path: sub.name, Submission: sub.ID(), Detail: string(status)})
+			}
+
 			if cfg.stage != nil {
 				cfg.stage.Current = fmt.Sprintf("status %q, poll %d", strings.ToLower(string(status)), count)
 			}
 
 			if !status.isCompleted() {
-				time.Sleep(cfg.Poll)
+				time.Sleep(pollDelay(cfg.Poll, stop))
 			}
 		}
 	}
@@ -63,3 +117,29 @@ func PollStatus(ctx context.Context, sub *Submission, cfg StatusConfig) (Submiss
 
 	return status, nil
 }
+
+// stopWaitingAt returns when to stop waiting for a conclusive status (the earliest of MaxWait and Deadline), zero when
+// the wait is only bound by the timeout.
+func (c StatusConfig) stopWaitingAt(start time.Time) time.Time {
+	stop := c.Deadline
+	if c.MaxWait > 0 {
+		if maxWait := start.Add(c.MaxWait); stop.IsZero() || maxWait.Before(stop) {
+			stop = maxWait
+		}
+	}
+	return stop
+}
+
+// pollDelay returns the time until the next status request, which is not after the time to stop waiting.
+func pollDelay(poll time.Duration, stop time.Time) time.Duration {
+	if !stop.IsZero() {
+		remaining := time.Until(stop)
+		if remaining < 0 {
+			return 0
+		}
+		if remaining < poll {
+			return remaining
+		}
+	}
+	return poll
+}
@@ -0,0 +1,83 @@
+package notary
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollStatus_stillPending(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       StatusConfig
+		wantPolls int
+	}{
+		{
+			name: "max wait",
+			cfg:  StatusConfig{Timeout: time.Minute, Poll: time.Millisecond, MaxWait: 20 * time.Millisecond},
+		},
+		{
+			name: "deadline",
+			cfg:  StatusConfig{Timeout: time.Minute, Poll: time.Millisecond, Deadline: time.Now().Add(20 * time.Millisecond)},
+		},
+		{
+			name:      "deadline passed",
+			cfg:       StatusConfig{Timeout: time.Minute, Poll: time.Millisecond, Deadline: time.Now().Add(-time.Hour)},
+			wantPolls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockAPI().mockStatus("In Progress")
+			sub := ExistingSubmission(m, "the-id")
+
+			status, err := PollStatus(context.Background(), sub, tt.cfg)
+			require.Error(t, err)
+			assert.Equal(t, SubmissionStatus(PendingStatus), status)
+			assert.True(t, IsPending(err))
+
+			var pending *PendingError
+			require.True(t, errors.As(err, &pending))
+			assert.Equal(t, "the-id", pending.ID)
+			assert.Contains(t, err.Error(), "quill submission status the-id --wait")
+
+			if tt.wantPolls > 0 {
+				assert.Len(t, m.called, tt.wantPolls)
+			} else {
+				assert.NotEmpty(t, m.called)
+			}
+		})
+	}
+}
+
+func TestPollStatus_completesBeforeMaxWait(t *testing.T) {
+	sub := ExistingSubmission(newMockAPI().mockStatus("Accepted"), "the-id")
+
+	status, err := PollStatus(context.Background(), sub, StatusConfig{Timeout: time.Minute, Poll: time.Millisecond, MaxWait: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, SubmissionStatus(AcceptedStatus), status)
+}
+
+func TestStatusConfig_stopWaitingAt(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		cfg  StatusConfig
+		want time.Time
+	}{
+		{name: "unbound"},
+		{name: "max wait", cfg: StatusConfig{MaxWait: time.Minute}, want: start.Add(time.Minute)},
+		{name: "deadline", cfg: StatusConfig{Deadline: start.Add(time.Hour)}, want: start.Add(time.Hour)},
+		{name: "max wait first", cfg: StatusConfig{MaxWait: time.Minute, Deadline: start.Add(time.Hour)}, want: start.Add(time.Minute)},
+		{name: "deadline first", cfg: StatusConfig{MaxWait: time.Hour, Deadline: start.Add(time.Minute)}, want: start.Add(time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.stopWaitingAt(start))
+		})
+	}
+}
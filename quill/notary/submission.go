@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"math/rand"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
 )
 
 type SubmissionStatus string
@@ -49,12 +51,20 @@ type SubmissionList struct {
 
 func NewSubmission(a api, bin *Payload) *Submission {
 	return &Submission{
-		name:   filepath.Base(bin.Path + "-" + bin.Digest + "-" + randomString(8)),
+		name:   submissionName(bin),
 		binary: bin,
 		api:    a,
 	}
 }
 
+// submissionName names the submission after the payload (the name is unique), with the extension of the payload format
+// the notary service relies on.
+func submissionName(bin *Payload) string {
+	ext := bin.kind().Extension()
+	name := strings.TrimSuffix(filepath.Base(bin.Path), ext)
+	return name + "-" + bin.Digest + "-" + randomString(8) + ext
+}
+
 func ExistingSubmission(a api, id string) *Submission {
 	return &Submission{
 		id:  id,
@@ -92,8 +102,14 @@ func (s *Submission) Start(ctx context.Context) error {
 	s.id = response.Data.ID
 
 	log.WithFields("id", s.id, "name", s.name).Trace("received submission id")
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.NotarySubmitted, Path: s.name, Submission: s.id})
+
+	if err := s.api.uploadBinary(ctx, *response, *s.binary); err != nil {
+		return err
+	}
 
-	return s.api.uploadBinary(ctx, *response, *s.binary)
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.NotaryUploaded, Path: s.name, Submission: s.id})
+	return nil
 }
 
 func (s Submission) Status(ctx context.Context) (SubmissionStatus, error) {
@@ -169,3 +169,23 @@ func Test_submission_start(t *testing.T) {
 		})
 	}
 }
+
+func Test_submissionName(t *testing.T) {
+	tests := []struct {
+		payload    Payload
+		wantPrefix string
+		wantSuffix string
+	}{
+		{payload: Payload{Path: "some/tool", Digest: "abc"}, wantPrefix: "tool-abc-", wantSuffix: ".zip"},
+		{payload: Payload{Path: "some/release.zip", Digest: "abc", Kind: ZipPayload}, wantPrefix: "release-abc-", wantSuffix: ".zip"},
+		{payload: Payload{Path: "some/release.dmg", Digest: "abc", Kind: DiskImagePayload}, wantPrefix: "release-abc-", wantSuffix: ".dmg"},
+		{payload: Payload{Path: "some/installer", Digest: "abc", Kind: PackagePayload}, wantPrefix: "installer-abc-", wantSuffix: ".pkg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.payload.Path, func(t *testing.T) {
+			name := submissionName(&tt.payload)
+			assert.True(t, strings.HasPrefix(name, tt.wantPrefix), "got %q", name)
+			assert.True(t, strings.HasSuffix(name, tt.wantSuffix), "got %q", name)
+		})
+	}
+}
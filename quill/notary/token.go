@@ -9,6 +9,7 @@ import (
 
 	"github.com/anchore/quill/internal/log"
 	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/remediation"
 )
 
 type TokenConfig struct {
@@ -16,6 +17,8 @@ type TokenConfig struct {
 	PrivateKeyID  string
 	TokenLifetime time.Duration
 	PrivateKey    string
+	// Key is an already loaded private key, used instead of reading PrivateKey when set.
+	Key *ecdsa.PrivateKey
 }
 
 func NewSignedToken(cfg TokenConfig) (string, error) {
@@ -36,9 +39,14 @@ func NewSignedToken(cfg TokenConfig) (string, error) {
 		Method: method,
 	}
 
-	key, err := loadPrivateKey(cfg.PrivateKey)
-	if err != nil {
-		return "", err
+	key := cfg.Key
+	if key == nil {
+		var err error
+		key, err = loadPrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return "", remediation.Wrap(err, remediation.NotaryCredentials,
+				"provide the App Store Connect API private key (the .p8 file downloaded from App Store Connect) with --notary-key or QUILL_NOTARY_KEY", "")
+		}
 	}
 
 	return token.SignedString(key)
@@ -0,0 +1,50 @@
+package apple
+
+import (
+	"crypto/x509"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+)
+
+const appleOrganization = "Apple Inc."
+
+// IsAppleIssued indicates if the given certificate was issued by one of Apple's certification authorities (e.g.
+// the WWDR or Developer ID intermediates), in which case the bundled Apple certificates can complete its chain.
+func IsAppleIssued(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	for _, org := range cert.Issuer.Organization {
+		if org == appleOrganization {
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteChain returns the given certificates along with any Apple intermediate and root certificates of the current
+// trust store (see GetTrustStore) needed to complete the chain for the given leaf. This is most useful when the signing
+// material was exported from Keychain Access without the intermediates. Certificates that are already present are not
+// repeated.
+func CompleteChain(leaf *x509.Certificate, certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	store := certchain.NewCollection().WithStores(GetTrustStore())
+	if err := store.AddIntermediate(certs...); err != nil {
+		return nil, err
+	}
+
+	found, err := certchain.Find(store, leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	var result []*x509.Certificate
+	for _, c := range append(append([]*x509.Certificate{}, certs...), found...) {
+		if _, ok := seen[string(c.Raw)]; ok {
+			continue
+		}
+		seen[string(c.Raw)] = struct{}{}
+		result = append(result, c)
+	}
+	return result, nil
+}
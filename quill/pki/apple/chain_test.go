@@ -0,0 +1,69 @@
+package apple
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+func embeddedCert(t *testing.T, name string) *x509.Certificate {
+	t.Helper()
+	by, err := content.ReadFile("certs/" + name)
+	require.NoError(t, err)
+	certs, err := load.CertificatesFromPEM(by)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	return certs[0]
+}
+
+func TestIsAppleIssued(t *testing.T) {
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want bool
+	}{
+		{
+			name: "developer ID intermediate",
+			cert: embeddedCert(t, "intermediate/DeveloperIDG2CA.pem"),
+			want: true,
+		},
+		{
+			name: "other issuer",
+			cert: &x509.Certificate{Issuer: pkix.Name{Organization: []string{"Example, Inc."}}},
+			want: false,
+		},
+		{
+			name: "nil certificate",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsAppleIssued(tt.cert))
+		})
+	}
+}
+
+func TestCompleteChain(t *testing.T) {
+	for _, name := range []string{
+		"intermediate/DeveloperIDCA.pem",
+		"intermediate/DeveloperIDG2CA.pem",
+		"intermediate/AppleWWDRCAG3.pem",
+	} {
+		t.Run(name, func(t *testing.T) {
+			intermediate := embeddedCert(t, name)
+
+			got, err := CompleteChain(intermediate, []*x509.Certificate{intermediate})
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+
+			assert.Equal(t, intermediate.Raw, got[0].Raw)
+			assert.Equal(t, "Apple Root CA", got[1].Subject.CommonName)
+		})
+	}
+}
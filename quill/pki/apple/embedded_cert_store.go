@@ -2,13 +2,12 @@ package apple
 
 import (
 	"bytes"
-	"crypto/x509"
 	"embed"
 	"fmt"
 	"path"
+	"strings"
 
 	"github.com/anchore/quill/quill/pki/certchain"
-	"github.com/anchore/quill/quill/pki/load"
 )
 
 //go:generate go run ./internal/generate/
@@ -16,78 +15,46 @@ import (
 //go:embed certs
 var content embed.FS
 
-var _ certchain.Store = (*embeddedCertStore)(nil)
-
-type embeddedCertStore struct {
-	rootCerts         []*x509.Certificate
-	rootPEMs          [][]byte
-	intermediateCerts []*x509.Certificate
-	intermediatePEMs  [][]byte
-	certsByCN         map[string][]*x509.Certificate
-}
-
 const certDir = "certs"
 
-var singleton *embeddedCertStore
+var embedded *TrustStore
 
+// GetEmbeddedCertStore returns the Apple certificates embedded into quill (see EmbeddedTrustStore), whatever the
+// current trust store is.
 func GetEmbeddedCertStore() certchain.Store {
-	return singleton
+	return embedded
+}
+
+// EmbeddedTrustStore returns the snapshot of the Apple certificates embedded into quill.
+func EmbeddedTrustStore() *TrustStore {
+	return embedded
 }
 
 func init() {
 	var err error
-	singleton, err = newEmbeddedCertStore()
+	embedded, err = newEmbeddedTrustStore()
 	if err != nil {
 		panic(err)
 	}
 }
 
-func newEmbeddedCertStore() (*embeddedCertStore, error) {
-	var err error
-
-	store := &embeddedCertStore{}
-
-	store.rootPEMs, err = getPEMs(path.Join(certDir, "root"))
+func newEmbeddedTrustStore() (*TrustStore, error) {
+	rootPEMs, err := getPEMs(path.Join(certDir, "root"))
 	if err != nil {
 		return nil, fmt.Errorf("unable to load root certificates: %w", err)
 	}
 
-	store.intermediatePEMs, err = getPEMs(path.Join(certDir, "intermediate"))
+	intermediatePEMs, err := getPEMs(path.Join(certDir, "intermediate"))
 	if err != nil {
 		return nil, fmt.Errorf("unable to load root certificates: %w", err)
 	}
 
-	store.rootCerts, err = load.CertificatesFromPEMs(store.rootPEMs)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse root certificates: %w", err)
-	}
-
-	store.intermediateCerts, err = load.CertificatesFromPEMs(store.intermediatePEMs)
+	version, err := content.ReadFile(path.Join(certDir, "version"))
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse intermediate certificates: %w", err)
-	}
-
-	store.certsByCN = make(map[string][]*x509.Certificate)
-	for _, cert := range store.intermediateCerts {
-		store.certsByCN[cert.Subject.CommonName] = append(store.certsByCN[cert.Subject.CommonName], cert)
+		return nil, fmt.Errorf("unable to read the trust store version: %w", err)
 	}
-	for _, cert := range store.rootCerts {
-		store.certsByCN[cert.Subject.CommonName] = append(store.certsByCN[cert.Subject.CommonName], cert)
-	}
-
-	return store, nil
-}
-
-func (s embeddedCertStore) CertificatesByCN(commonName string) ([]*x509.Certificate, error) {
-	return s.certsByCN[commonName], nil
-}
-
-func (s embeddedCertStore) RootPEMs() [][]byte {
-	return s.rootPEMs
-}
 
-func (s embeddedCertStore) IntermediatePEMs() [][]byte {
-	return s.intermediatePEMs
+	return newTrustStore(strings.TrimSpace(string(version)), "embedded", rootPEMs, intermediatePEMs)
 }
 
 func getPEMs(certsDir string) ([][]byte, error) {
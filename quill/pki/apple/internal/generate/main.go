@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -69,6 +70,11 @@ func main() {
 		}
 	}
 
+	// the snapshot is versioned by the date it was taken (see apple.TrustStore)
+	if err := os.WriteFile(path.Join(CertsDir, "version"), []byte(time.Now().UTC().Format("2006-01-02")+"\n"), 0600); err != nil {
+		log.Fatalf("Error writing trust store version: %v", err)
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		log.Fatalf("Error getting current working directory: %v", err)
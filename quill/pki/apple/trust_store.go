@@ -0,0 +1,310 @@
+package apple
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+var _ certchain.Store = (*TrustStore)(nil)
+
+const (
+	// bundleVersionPrefix starts the line of a trust store bundle holding the version of the snapshot.
+	bundleVersionPrefix = "# version:"
+
+	// maxBundleSize guards against fetching (or reading) something that is not a trust store bundle.
+	maxBundleSize = 16 << 20
+
+	fetchTimeout = 30 * time.Second
+)
+
+// fetchClient fetches trust store bundles (replaced by tests to trust their TLS server).
+var fetchClient = &http.Client{Timeout: fetchTimeout}
+
+// TrustStore is a versioned snapshot of the Apple root and intermediate certificates that certificate chains are
+// completed and verified with. The snapshot embedded into quill (see EmbeddedTrustStore) is taken from
+// https://www.apple.com/certificateauthority/ when quill is released. Since Apple rotates its intermediates, a newer
+// snapshot can be read from a bundle (see LoadTrustStore and FetchTrustStore) and made current (see SetTrustStore) at
+// runtime, without requiring a quill release.
+//
+// A bundle is a PEM file of every certificate of the snapshot (self-signed certificates are roots, the others are
+// intermediates), optionally preceded by a "# version: <version>" line.
+type TrustStore struct {
+	// Version identifies the snapshot (the date it was taken for the embedded snapshot), empty when unknown.
+	Version string
+	// Source describes where the snapshot was read from ("embedded", a path, or a URL).
+	Source string
+
+	rootCerts         []*x509.Certificate
+	rootPEMs          [][]byte
+	intermediateCerts []*x509.Certificate
+	intermediatePEMs  [][]byte
+	certsByCN         map[string][]*x509.Certificate
+}
+
+func newTrustStore(version, source string, rootPEMs, intermediatePEMs [][]byte) (*TrustStore, error) {
+	var err error
+
+	store := &TrustStore{
+		Version:          version,
+		Source:           source,
+		rootPEMs:         rootPEMs,
+		intermediatePEMs: intermediatePEMs,
+	}
+
+	store.rootCerts, err = load.CertificatesFromPEMs(store.rootPEMs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse root certificates: %w", err)
+	}
+
+	store.intermediateCerts, err = load.CertificatesFromPEMs(store.intermediatePEMs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse intermediate certificates: %w", err)
+	}
+
+	store.certsByCN = make(map[string][]*x509.Certificate)
+	for _, cert := range store.intermediateCerts {
+		store.certsByCN[cert.Subject.CommonName] = append(store.certsByCN[cert.Subject.CommonName], cert)
+	}
+	for _, cert := range store.rootCerts {
+		store.certsByCN[cert.Subject.CommonName] = append(store.certsByCN[cert.Subject.CommonName], cert)
+	}
+
+	return store, nil
+}
+
+func (s TrustStore) CertificatesByCN(commonName string) ([]*x509.Certificate, error) {
+	return s.certsByCN[commonName], nil
+}
+
+func (s TrustStore) RootPEMs() [][]byte {
+	return s.rootPEMs
+}
+
+func (s TrustStore) IntermediatePEMs() [][]byte {
+	return s.intermediatePEMs
+}
+
+// Roots returns the root certificates of the snapshot.
+func (s TrustStore) Roots() []*x509.Certificate {
+	return s.rootCerts
+}
+
+// Intermediates returns the intermediate certificates of the snapshot.
+func (s TrustStore) Intermediates() []*x509.Certificate {
+	return s.intermediateCerts
+}
+
+// Digest identifies the certificates of the snapshot: the hex encoded SHA-256 digest over the (DER) certificates,
+// roots first, in the order of the snapshot.
+func (s TrustStore) Digest() string {
+	h := sha256.New()
+	for _, c := range append(append([]*x509.Certificate{}, s.rootCerts...), s.intermediateCerts...) {
+		h.Write(c.Raw)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Bundle encodes the snapshot as a bundle (see ParseTrustStore).
+func (s TrustStore) Bundle() []byte {
+	var buf bytes.Buffer
+	if s.Version != "" {
+		fmt.Fprintf(&buf, "%s %s\n", bundleVersionPrefix, s.Version)
+	}
+	for _, c := range append(append([]*x509.Certificate{}, s.rootCerts...), s.intermediateCerts...) {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return buf.Bytes()
+}
+
+// ParseTrustStore reads a trust store snapshot from the given bundle (see TrustStore), read from the given source.
+func ParseTrustStore(bundle []byte, source string) (*TrustStore, error) {
+	var version string
+	scanner := bufio.NewScanner(bytes.NewReader(bundle))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); strings.HasPrefix(line, bundleVersionPrefix) {
+			version = strings.TrimSpace(strings.TrimPrefix(line, bundleVersionPrefix))
+			break
+		}
+	}
+
+	var rootPEMs, intermediatePEMs [][]byte
+	rest := bundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse certificate from trust store bundle: %w", err)
+		}
+
+		encoded := bytes.TrimRight(pem.EncodeToMemory(block), "\n")
+		if isSelfSigned(cert) {
+			rootPEMs = append(rootPEMs, encoded)
+		} else {
+			intermediatePEMs = append(intermediatePEMs, encoded)
+		}
+	}
+
+	if len(rootPEMs) == 0 {
+		return nil, fmt.Errorf("the trust store bundle has no root certificate")
+	}
+
+	return newTrustStore(version, source, rootPEMs, intermediatePEMs)
+}
+
+// isSelfSigned indicates if the certificate is issued by itself (the signature is not checked, since older Apple roots
+// are signed with SHA-1, which is rejected by crypto/x509).
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
+	}
+	return len(cert.AuthorityKeyId) == 0 || bytes.Equal(cert.AuthorityKeyId, cert.SubjectKeyId)
+}
+
+// LoadTrustStore reads a trust store snapshot from the bundle file at the given path (see TrustStore).
+func LoadTrustStore(path string) (*TrustStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open trust store bundle: %w", err)
+	}
+	defer f.Close()
+
+	bundle, err := readBundle(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read trust store bundle %q: %w", path, err)
+	}
+	return ParseTrustStore(bundle, path)
+}
+
+// FetchTrustStore downloads a trust store snapshot from the bundle at the given HTTPS URL (see TrustStore). Since the
+// snapshot becomes the trust anchors, it is never fetched over an unauthenticated connection. This is subject to
+// offline mode (see network.Allow).
+func FetchTrustStore(ctx context.Context, bundleURL string) (*TrustStore, error) {
+	u, err := url.Parse(bundleURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust store URL %q: %w", bundleURL, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("the trust store URL %q must use https", bundleURL)
+	}
+
+	if err := network.Allow(network.TrustStore, bundleURL); err != nil {
+		return nil, err
+	}
+
+	log.WithFields("url", bundleURL).Debug("fetching trust store bundle")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch trust store bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch trust store bundle from %q: HTTP %d", bundleURL, resp.StatusCode)
+	}
+
+	bundle, err := readBundle(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read trust store bundle from %q: %w", bundleURL, err)
+	}
+	return ParseTrustStore(bundle, bundleURL)
+}
+
+func readBundle(r io.Reader) ([]byte, error) {
+	bundle, err := io.ReadAll(io.LimitReader(r, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle) > maxBundleSize {
+		return nil, fmt.Errorf("the bundle exceeds %d bytes", maxBundleSize)
+	}
+	return bundle, nil
+}
+
+// CheckDigest returns an error when the snapshot is not the one with the given digest (see Digest), which pins trust
+// decisions to a specific snapshot. The digest may be prefixed with "sha256:".
+func (s TrustStore) CheckDigest(digest string) error {
+	want := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(digest), "sha256:"))
+	if got := s.Digest(); got != want {
+		return fmt.Errorf("the trust store (version %q from %s) has digest %s, but %s is pinned", s.Version, s.Source, got, want)
+	}
+	return nil
+}
+
+var (
+	currentLock sync.RWMutex
+	current     *TrustStore
+	exclusive   bool
+)
+
+// GetTrustStore returns the current trust store: the one set with SetTrustStore, or the embedded snapshot otherwise.
+// Certificate chains are completed and verified with the current trust store.
+func GetTrustStore() *TrustStore {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	if current == nil {
+		return embedded
+	}
+	return current
+}
+
+// SetTrustStore makes the given snapshot the current trust store for the process (nil restores the embedded
+// snapshot).
+func SetTrustStore(s *TrustStore) {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = s
+	if s != nil {
+		log.WithFields("version", s.Version, "source", s.Source, "digest", s.Digest()).Debug("using trust store")
+	}
+}
+
+// SetExclusiveTrust makes the current trust store the only source of trust for the process (or restores the defaults),
+// so trust decisions only depend on the snapshot: the system roots are not trusted for verification and missing
+// chain certificates are not downloaded (via the Authority Information Access URLs of certificates).
+func SetExclusiveTrust(enabled bool) {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	exclusive = enabled
+	if enabled {
+		log.Debug("only trusting the trust store (no system roots or downloaded certificates)")
+	}
+}
+
+// ExclusiveTrust indicates if the current trust store is the only source of trust (see SetExclusiveTrust).
+func ExclusiveTrust() bool {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return exclusive
+}
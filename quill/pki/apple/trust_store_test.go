@@ -0,0 +1,140 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/network"
+)
+
+func TestEmbeddedTrustStore(t *testing.T) {
+	store := EmbeddedTrustStore()
+	assert.NotEmpty(t, store.Version)
+	assert.Equal(t, "embedded", store.Source)
+	assert.Len(t, store.Digest(), 64)
+	assert.Len(t, store.Roots(), len(store.RootPEMs()))
+	assert.Len(t, store.Intermediates(), len(store.IntermediatePEMs()))
+
+	certs, err := store.CertificatesByCN("Developer ID Certification Authority")
+	require.NoError(t, err)
+	assert.NotEmpty(t, certs)
+}
+
+func TestParseTrustStore(t *testing.T) {
+	embedded := EmbeddedTrustStore()
+
+	store, err := ParseTrustStore(embedded.Bundle(), "bundle.pem")
+	require.NoError(t, err)
+	assert.Equal(t, embedded.Version, store.Version)
+	assert.Equal(t, "bundle.pem", store.Source)
+	assert.Equal(t, embedded.Digest(), store.Digest(), "self-signed certificates are read as roots, the others as intermediates")
+	assert.Len(t, store.Roots(), len(embedded.Roots()))
+	assert.Len(t, store.Intermediates(), len(embedded.Intermediates()))
+
+	// the version is optional
+	unversioned := *embedded
+	unversioned.Version = ""
+	store, err = ParseTrustStore(unversioned.Bundle(), "bundle.pem")
+	require.NoError(t, err)
+	assert.Empty(t, store.Version)
+
+	_, err = ParseTrustStore(embedded.IntermediatePEMs()[0], "bundle.pem")
+	require.ErrorContains(t, err, "no root certificate")
+
+	_, err = ParseTrustStore([]byte("-----BEGIN CERTIFICATE-----\nYm9ndXM=\n-----END CERTIFICATE-----\n"), "bundle.pem")
+	require.Error(t, err)
+}
+
+func TestLoadTrustStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	require.NoError(t, os.WriteFile(path, EmbeddedTrustStore().Bundle(), 0600))
+
+	store, err := LoadTrustStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, store.Source)
+	assert.Equal(t, EmbeddedTrustStore().Digest(), store.Digest())
+
+	_, err = LoadTrustStore(filepath.Join(t.TempDir(), "missing.pem"))
+	require.Error(t, err)
+}
+
+func TestFetchTrustStore(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle.pem" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(EmbeddedTrustStore().Bundle())
+	})
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	previous := fetchClient
+	fetchClient = server.Client()
+	defer func() { fetchClient = previous }()
+
+	store, err := FetchTrustStore(context.Background(), server.URL+"/bundle.pem")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/bundle.pem", store.Source)
+	assert.Equal(t, EmbeddedTrustStore().Digest(), store.Digest())
+
+	_, err = FetchTrustStore(context.Background(), server.URL+"/missing.pem")
+	require.ErrorContains(t, err, "HTTP 404")
+
+	// the trust anchors are never fetched over an unauthenticated connection
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	_, err = FetchTrustStore(context.Background(), plain.URL+"/bundle.pem")
+	require.ErrorContains(t, err, "must use https")
+
+	network.SetOffline(true)
+	defer network.SetOffline(false)
+	_, err = FetchTrustStore(context.Background(), server.URL+"/bundle.pem")
+	require.True(t, errors.Is(err, network.ErrOffline))
+}
+
+func TestSetTrustStore(t *testing.T) {
+	assert.Same(t, EmbeddedTrustStore(), GetTrustStore())
+
+	store, err := ParseTrustStore(EmbeddedTrustStore().Bundle(), "bundle.pem")
+	require.NoError(t, err)
+
+	SetTrustStore(store)
+	defer SetTrustStore(nil)
+	assert.Same(t, store, GetTrustStore())
+	assert.Same(t, EmbeddedTrustStore(), GetEmbeddedCertStore(), "the embedded store is unchanged")
+
+	SetTrustStore(nil)
+	assert.Same(t, EmbeddedTrustStore(), GetTrustStore())
+}
+
+func TestTrustStore_CheckDigest(t *testing.T) {
+	store := EmbeddedTrustStore()
+	digest := store.Digest()
+
+	require.NoError(t, store.CheckDigest(digest))
+	require.NoError(t, store.CheckDigest("sha256:"+strings.ToUpper(digest)))
+
+	err := store.CheckDigest(strings.Repeat("0", 64))
+	require.ErrorContains(t, err, "is pinned")
+}
+
+func TestSetExclusiveTrust(t *testing.T) {
+	assert.False(t, ExclusiveTrust())
+
+	SetExclusiveTrust(true)
+	defer SetExclusiveTrust(false)
+	assert.True(t, ExclusiveTrust())
+
+	SetExclusiveTrust(false)
+	assert.False(t, ExclusiveTrust())
+}
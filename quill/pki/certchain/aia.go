@@ -0,0 +1,160 @@
+package certchain
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+var _ Searcher = (*AIASearcher)(nil)
+
+// AIASearcher finds issuer certificates by following the Authority Information Access (AIA) "CA Issuers" URLs of
+// known certificates. Every certificate that is fetched becomes known as well, so the full chain can be resolved
+// starting from only the leaf certificate. Downloaded certificates are cached on disk, and in memory for the rest of
+// the process (so signing many artifacts only reads or fetches each issuer once).
+type AIASearcher struct {
+	Client   *http.Client
+	CacheDir string
+
+	lock  sync.Mutex
+	known []*x509.Certificate
+}
+
+// NewAIASearcher creates a searcher seeded with the given (typically leaf) certificates, caching downloads within
+// the user cache directory.
+func NewAIASearcher(certs ...*x509.Certificate) *AIASearcher {
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "quill", "aia")
+	}
+
+	return &AIASearcher{
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		CacheDir: cacheDir,
+		known:    certs,
+	}
+}
+
+func (s *AIASearcher) CertificatesByCN(commonName string) ([]*x509.Certificate, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var results []*x509.Certificate
+	for _, cert := range s.known {
+		if cert.Issuer.CommonName != commonName || cert.Issuer.CommonName == cert.Subject.CommonName {
+			continue
+		}
+
+		for _, u := range cert.IssuingCertificateURL {
+			issuers, err := s.fetch(u)
+			if err != nil {
+				// this is a best-effort search, other stores may still be able to provide the certificate
+				log.WithFields("url", u, "error", err).Debug("unable to fetch issuer certificate")
+				continue
+			}
+
+			for _, issuer := range issuers {
+				if issuer.Subject.CommonName != commonName {
+					continue
+				}
+				results = append(results, issuer)
+			}
+		}
+	}
+
+	s.known = append(s.known, results...)
+
+	return results, nil
+}
+
+// fetchedIssuers holds the issuer certificates already read or fetched by any searcher (by AIA URL).
+var fetchedIssuers sync.Map
+
+func (s *AIASearcher) fetch(u string) ([]*x509.Certificate, error) {
+	if certs, ok := fetchedIssuers.Load(u); ok {
+		return certs.([]*x509.Certificate), nil
+	}
+
+	certs, err := s.fetchUncached(u)
+	if err != nil {
+		return nil, err
+	}
+	fetchedIssuers.Store(u, certs)
+	return certs, nil
+}
+
+func (s *AIASearcher) fetchUncached(u string) ([]*x509.Certificate, error) {
+	cachePath := s.cachePath(u)
+	if cachePath != "" {
+		if by, err := os.ReadFile(cachePath); err == nil {
+			log.WithFields("url", u).Trace("using cached issuer certificate")
+			return parseIssuerCertificates(by)
+		}
+	}
+
+	if err := network.Allow(network.AIA, u); err != nil {
+		return nil, err
+	}
+
+	log.WithFields("url", u).Debug("fetching issuer certificate via AIA")
+
+	resp, err := s.Client.Get(u) //nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// issuer certificates are small, anything beyond this is not a certificate
+	by, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := parseIssuerCertificates(by)
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err == nil {
+			if err := os.WriteFile(cachePath, by, 0600); err != nil {
+				log.WithFields("path", cachePath, "error", err).Debug("unable to cache issuer certificate")
+			}
+		}
+	}
+
+	return certs, nil
+}
+
+func (s *AIASearcher) cachePath(u string) string {
+	if s.CacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(s.CacheDir, hex.EncodeToString(sum[:])+".cer")
+}
+
+// parseIssuerCertificates parses either DER (the common case for AIA URLs) or PEM encoded certificates.
+func parseIssuerCertificates(by []byte) ([]*x509.Certificate, error) {
+	if certs, err := x509.ParseCertificates(by); err == nil && len(certs) > 0 {
+		return certs, nil
+	}
+	return load.CertificatesFromPEM(by)
+}
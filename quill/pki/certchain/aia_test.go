@@ -0,0 +1,98 @@
+package certchain
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIASearcher(t *testing.T) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "quill-test-aia-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/ca.cer" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(caDER)
+	}))
+	t.Cleanup(server.Close)
+
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "quill-test-aia-leaf"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		IssuingCertificateURL: []string{server.URL + "/ca.cer"},
+		SubjectKeyId:          []byte{5, 6, 7, 8},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+
+	searcher := NewAIASearcher(leaf)
+	searcher.CacheDir = cacheDir
+
+	chain, err := Find(NewCollection().WithSearchers(searcher), leaf)
+	require.NoError(t, err)
+	require.Len(t, chain, 1)
+	assert.Equal(t, ca.Raw, chain[0].Raw)
+	assert.Equal(t, 1, requests)
+
+	// a new searcher should use the cached certificate instead of fetching it again
+	cached := NewAIASearcher(leaf)
+	cached.CacheDir = cacheDir
+
+	got, err := cached.CertificatesByCN("quill-test-aia-ca")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, ca.Raw, got[0].Raw)
+	assert.Equal(t, 1, requests)
+
+	// fetched certificates are kept in memory as well, even without a cache directory
+	inMemory := NewAIASearcher(leaf)
+	inMemory.CacheDir = ""
+
+	got, err = inMemory.CertificatesByCN("quill-test-aia-ca")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, 1, requests)
+
+	// no known certificate is issued by an unknown CN
+	got, err = cached.CertificatesByCN("something else")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,161 @@
+package certchain
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// maxChainLength guards against cycles in (malformed) certificate chains.
+const maxChainLength = 10
+
+// Source is a named searcher used while building a chain (the name is used for reporting where each certificate
+// came from, e.g. "p12", "keychain", "embedded", or "aia").
+type Source struct {
+	Name     string
+	Searcher Searcher
+}
+
+// Link is a single certificate within a built chain.
+type Link struct {
+	Certificate *x509.Certificate
+	Source      string
+}
+
+// Report describes the result of building a certificate chain from a leaf certificate up to a root.
+type Report struct {
+	// Links is the chain ordered from the leaf to the root (or as far as the chain could be built).
+	Links []Link
+	// MissingIssuer is the common name of the first issuer that could not be found (empty when complete).
+	MissingIssuer string
+	// Problems are issues found with the certificates in the chain (e.g. expired certificates).
+	Problems []string
+}
+
+// Complete indicates if the chain ends at a self-signed root certificate.
+func (r Report) Complete() bool {
+	if len(r.Links) == 0 {
+		return false
+	}
+	return r.MissingIssuer == "" && isSelfSigned(r.Links[len(r.Links)-1].Certificate)
+}
+
+// Certificates returns the chain certificates ordered from leaf to root.
+func (r Report) Certificates() []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, l := range r.Links {
+		certs = append(certs, l.Certificate)
+	}
+	return certs
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	sb.WriteString("Certificate chain:\n")
+	for i, l := range r.Links {
+		role := "intermediate"
+		switch {
+		case i == 0:
+			role = "leaf"
+		case isSelfSigned(l.Certificate):
+			role = "root"
+		}
+		sb.WriteString(fmt.Sprintf("  %-14s %q (source: %s, expires: %s)\n", "["+role+"]", l.Certificate.Subject.CommonName, l.Source, l.Certificate.NotAfter.Format("2006-01-02")))
+	}
+
+	if r.Complete() {
+		sb.WriteString("Status: complete\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Status: incomplete (missing issuer %q)\n", r.MissingIssuer))
+	}
+
+	for _, p := range r.Problems {
+		sb.WriteString(fmt.Sprintf("Problem: %s\n", p))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Build builds the certificate chain for the given leaf certificate by searching the given sources (in order) for
+// each issuer. Unlike Find, each issuer candidate must have actually signed the certificate below it (so the chain
+// returned is ordered and cryptographically linked). An incomplete chain is not an error, see Report.Complete.
+func Build(leaf *x509.Certificate, sources ...Source) (*Report, error) {
+	if leaf == nil {
+		return nil, fmt.Errorf("no certificate provided")
+	}
+
+	report := &Report{
+		Links: []Link{{Certificate: leaf, Source: "leaf"}},
+	}
+
+	current := leaf
+	for !isSelfSigned(current) {
+		if len(report.Links) >= maxChainLength {
+			return nil, fmt.Errorf("certificate chain exceeds %d certificates", maxChainLength)
+		}
+
+		issuer, source := findIssuer(current, sources)
+		if issuer == nil {
+			report.MissingIssuer = current.Issuer.CommonName
+			break
+		}
+
+		report.Links = append(report.Links, Link{Certificate: issuer, Source: source})
+		current = issuer
+	}
+
+	now := time.Now()
+	for _, l := range report.Links {
+		switch {
+		case now.After(l.Certificate.NotAfter):
+			report.Problems = append(report.Problems, fmt.Sprintf("certificate %q expired on %s", l.Certificate.Subject.CommonName, l.Certificate.NotAfter.Format(time.RFC3339)))
+		case now.Before(l.Certificate.NotBefore):
+			report.Problems = append(report.Problems, fmt.Sprintf("certificate %q is not valid until %s", l.Certificate.Subject.CommonName, l.Certificate.NotBefore.Format(time.RFC3339)))
+		}
+	}
+
+	return report, nil
+}
+
+// findIssuer returns the certificate (and the name of its source) that signed the given certificate. A source that
+// cannot be searched (e.g. a keychain that does not exist on this platform) has no candidates, so that the remaining
+// sources are still searched.
+func findIssuer(cert *x509.Certificate, sources []Source) (*x509.Certificate, string) {
+	for _, source := range sources {
+		candidates, err := source.Searcher.CertificatesByCN(cert.Issuer.CommonName)
+		if err != nil {
+			log.WithFields("cn", cert.Issuer.CommonName, "source", source.Name, "error", err).Debug("unable to search for issuer")
+			continue
+		}
+
+		// prefer candidates with matching key identifiers, but always require a valid signature
+		var fallback *x509.Certificate
+		for _, candidate := range candidates {
+			if !bytes.Equal(cert.RawIssuer, candidate.RawSubject) {
+				continue
+			}
+			if err := cert.CheckSignatureFrom(candidate); err != nil {
+				log.WithFields("cn", candidate.Subject.CommonName, "source", source.Name, "error", err).Trace("issuer candidate did not sign certificate")
+				continue
+			}
+			if len(cert.AuthorityKeyId) > 0 && bytes.Equal(cert.AuthorityKeyId, candidate.SubjectKeyId) {
+				return candidate, source.Name
+			}
+			if fallback == nil {
+				fallback = candidate
+			}
+		}
+		if fallback != nil {
+			return fallback, source.Name
+		}
+	}
+	return nil, ""
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
@@ -0,0 +1,137 @@
+package certchain
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testIssuer struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func issueTestCert(t *testing.T, cn string, isCA bool, parent *testIssuer) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testIssuer{cert: cert, key: key}
+}
+
+func collectionOf(t *testing.T, certs ...*x509.Certificate) *Collection {
+	c := NewCollection()
+	require.NoError(t, c.AddIntermediate(certs...))
+	return c
+}
+
+type failingSearcher struct{}
+
+func (failingSearcher) CertificatesByCN(string) ([]*x509.Certificate, error) {
+	return nil, errors.New("no PEM blocks found")
+}
+
+func TestBuild(t *testing.T) {
+	root := issueTestCert(t, "test-root", true, nil)
+	intermediate := issueTestCert(t, "test-intermediate", true, root)
+	leaf := issueTestCert(t, "test-leaf", false, intermediate)
+
+	// same CN as the real intermediate, but did not sign the leaf
+	impostor := issueTestCert(t, "test-intermediate", true, root)
+
+	tests := []struct {
+		name        string
+		sources     []Source
+		wantCNs     []string
+		wantSources []string
+		complete    bool
+		missing     string
+	}{
+		{
+			name: "complete chain from multiple sources",
+			sources: []Source{
+				{Name: "p12", Searcher: collectionOf(t, impostor.cert, intermediate.cert)},
+				{Name: "embedded", Searcher: collectionOf(t, root.cert)},
+			},
+			wantCNs:     []string{"test-leaf", "test-intermediate", "test-root"},
+			wantSources: []string{"leaf", "p12", "embedded"},
+			complete:    true,
+		},
+		{
+			name: "failing source before a working one",
+			sources: []Source{
+				{Name: "keychain", Searcher: failingSearcher{}},
+				{Name: "p12", Searcher: collectionOf(t, intermediate.cert, root.cert)},
+			},
+			wantCNs:     []string{"test-leaf", "test-intermediate", "test-root"},
+			wantSources: []string{"leaf", "p12", "p12"},
+			complete:    true,
+		},
+		{
+			name: "missing root",
+			sources: []Source{
+				{Name: "p12", Searcher: collectionOf(t, intermediate.cert)},
+			},
+			wantCNs:     []string{"test-leaf", "test-intermediate"},
+			wantSources: []string{"leaf", "p12"},
+			missing:     "test-root",
+		},
+		{
+			name: "only an impostor intermediate",
+			sources: []Source{
+				{Name: "p12", Searcher: collectionOf(t, impostor.cert, root.cert)},
+			},
+			wantCNs:     []string{"test-leaf"},
+			wantSources: []string{"leaf"},
+			missing:     "test-intermediate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Build(leaf.cert, tt.sources...)
+			require.NoError(t, err)
+
+			var cns, sources []string
+			for _, l := range report.Links {
+				cns = append(cns, l.Certificate.Subject.CommonName)
+				sources = append(sources, l.Source)
+			}
+
+			assert.Equal(t, tt.wantCNs, cns)
+			assert.Equal(t, tt.wantSources, sources)
+			assert.Equal(t, tt.complete, report.Complete())
+			assert.Equal(t, tt.missing, report.MissingIssuer)
+			assert.Empty(t, report.Problems)
+			assert.NotEmpty(t, report.String())
+		})
+	}
+}
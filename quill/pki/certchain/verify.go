@@ -1,12 +1,19 @@
 package certchain
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/anchore/quill/internal/log"
 )
 
+// verifiedChains holds the chains that already passed verification (by chain digest), along with the time the first
+// certificate of the chain expires, so a batch signing run only verifies the same chain once.
+var verifiedChains sync.Map
+
 func VerifyForCodeSigning(certs []*x509.Certificate, failWithoutFullChain bool) error {
 	log.WithFields("chain-size", len(certs)).Trace("verifying certificate chain")
 
@@ -45,20 +52,24 @@ func VerifyForCodeSigning(certs []*x509.Certificate, failWithoutFullChain bool)
 		return nil
 	}
 
+	usage := x509.ExtKeyUsageCodeSigning // we know this is a signing cert..
+	if isInstaller(leaf) {
+		// installer certificates have an Apple specific extended key usage (unknown to the x509 package)
+		usage = x509.ExtKeyUsageAny
+	}
+
 	// verify with the chain
 	opts := x509.VerifyOptions{
 		Roots:         roots,
 		Intermediates: intermediates,
-		KeyUsages: []x509.ExtKeyUsage{
-			x509.ExtKeyUsageCodeSigning, // we know this is a signing cert..
-		},
+		KeyUsages:     []x509.ExtKeyUsage{usage},
 	}
 
-	// ignore "devid_execute" critical extension
+	// ignore "devid_execute" and "devid_install" critical extensions
 	temp := leaf.UnhandledCriticalExtensions[:0]
 	for _, ex := range leaf.UnhandledCriticalExtensions {
 		switch ex.String() {
-		case "1.2.840.113635.100.6.1.13":
+		case "1.2.840.113635.100.6.1.13", "1.2.840.113635.100.6.1.14":
 			continue
 		default:
 			temp = append(temp, ex)
@@ -70,8 +81,47 @@ func VerifyForCodeSigning(certs []*x509.Certificate, failWithoutFullChain bool)
 		log.Warnf("certificate has unhandled critical extensions: %v", leaf.UnhandledCriticalExtensions)
 	}
 
+	key := chainDigest(certs, usage)
+	if expires, ok := verifiedChains.Load(key); ok && time.Now().Before(expires.(time.Time)) {
+		log.Trace("certificate chain already verified")
+		return nil
+	}
+
 	if _, err := leaf.Verify(opts); err != nil {
 		return fmt.Errorf("failed to verify certificate chain: %w", err)
 	}
+	verifiedChains.Store(key, firstExpiry(certs))
 	return nil
 }
+
+// chainDigest identifies the given (sorted) chain verified for the given usage.
+func chainDigest(certs []*x509.Certificate, usage x509.ExtKeyUsage) [sha256.Size]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d", usage)
+	for _, c := range certs {
+		sum := sha256.Sum256(c.Raw)
+		h.Write(sum[:])
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+func firstExpiry(certs []*x509.Certificate) time.Time {
+	var expiry time.Time
+	for _, c := range certs {
+		if expiry.IsZero() || c.NotAfter.Before(expiry) {
+			expiry = c.NotAfter
+		}
+	}
+	return expiry
+}
+
+func isInstaller(leaf *x509.Certificate) bool {
+	for _, u := range leaf.UnknownExtKeyUsage {
+		if u.String() == "1.2.840.113635.100.4.13" {
+			return true
+		}
+	}
+	return false
+}
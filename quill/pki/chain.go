@@ -0,0 +1,94 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/pki/apple"
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/remediation"
+)
+
+// completeChain verifies the given certificates for code signing. If verification fails, the missing chain
+// certificates for the given leaf are searched for first within the current trust store (see apple.GetTrustStore) and
+// then by following the Authority Information Access URLs of the certificates (downloading the issuers, unless only the
+// trust store is trusted, see apple.SetExclusiveTrust). When the full chain is not required, failing to complete the
+// chain is not an error.
+func completeChain(leaf *x509.Certificate, certs []*x509.Certificate, failWithoutFullChain bool) ([]*x509.Certificate, error) {
+	chain, err := findChain(leaf, certs, failWithoutFullChain)
+	if err != nil {
+		return nil, remediation.Wrap(err, remediation.IncompleteChain,
+			"attach the intermediate certificates of the signing certificate to the signing material (for a P12 file run 'quill p12 attach-chain')",
+			"quill p12 attach-chain")
+	}
+	return chain, nil
+}
+
+func findChain(leaf *x509.Certificate, certs []*x509.Certificate, failWithoutFullChain bool) ([]*x509.Certificate, error) {
+	err := certchain.VerifyForCodeSigning(certs, failWithoutFullChain)
+	if err == nil && len(certs) > 1 {
+		return certs, nil
+	}
+	if leaf == nil {
+		return nil, err
+	}
+
+	completed, completeErr := findRemainingChain(leaf, certs, failWithoutFullChain)
+	if completeErr != nil {
+		if err == nil {
+			// the chain was not required, use what we have
+			return certs, nil
+		}
+		return nil, completeErr
+	}
+	return completed, nil
+}
+
+func findRemainingChain(leaf *x509.Certificate, certs []*x509.Certificate, failWithoutFullChain bool) ([]*x509.Certificate, error) {
+	var err error
+
+	// verification failed, try again but attempt to find more certs from the embedded certs in quill
+	if apple.IsAppleIssued(leaf) {
+		// the most common case: an Apple issued certificate exported without the WWDR / Developer ID intermediates
+		withEmbedded, findErr := apple.CompleteChain(leaf, certs)
+		if findErr == nil {
+			if err = certchain.VerifyForCodeSigning(withEmbedded, failWithoutFullChain); err == nil {
+				return withEmbedded, nil
+			}
+		}
+	}
+
+	store := certchain.NewCollection().WithStores(apple.GetTrustStore())
+	if apple.ExclusiveTrust() {
+		// only the trust store is searched, missing certificates are not downloaded
+		log.WithFields("leaf", leaf.Subject.CommonName).Debug("searching missing chain certificates within the trust store only")
+	} else {
+		// still missing certificates, follow the AIA URLs of the certificates we have
+		log.WithFields("leaf", leaf.Subject.CommonName).Debug("attempting to fetch missing chain certificates via AIA")
+		store = store.WithSearchers(certchain.NewAIASearcher(certs...))
+	}
+	if addErr := store.AddIntermediate(certs...); addErr != nil {
+		return nil, addErr
+	}
+	remainingCerts, findErr := certchain.Find(store, leaf)
+	if findErr != nil {
+		return nil, fmt.Errorf("unable to find remaining chain certificates: %w", findErr)
+	}
+
+	withFetched := append(certs, remainingCerts...)
+	if err := certchain.VerifyForCodeSigning(withFetched, failWithoutFullChain); err != nil {
+		return nil, err
+	}
+	return withFetched, nil
+}
+
+// leafCertificate returns the first non-CA certificate from the given certificates.
+func leafCertificate(certs []*x509.Certificate) *x509.Certificate {
+	for _, c := range certs {
+		if !c.IsCA {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// ChainEmbedding controls which certificates of the signing chain are embedded into the CMS SignedData.
+type ChainEmbedding string
+
+const (
+	// EmbedLeafOnly embeds only the signing (leaf) certificate.
+	EmbedLeafOnly ChainEmbedding = "leaf"
+
+	// EmbedIntermediates embeds the signing certificate and all intermediate certificates, but not the root (this is
+	// the default).
+	EmbedIntermediates ChainEmbedding = "intermediates"
+
+	// EmbedFullChain embeds every certificate of the chain, including the root.
+	EmbedFullChain ChainEmbedding = "full"
+)
+
+// ChainEmbeddings is every supported ChainEmbedding.
+var ChainEmbeddings = []ChainEmbedding{EmbedLeafOnly, EmbedIntermediates, EmbedFullChain}
+
+// ParseChainEmbedding parses the user-facing name of a ChainEmbedding (an empty value is the default).
+func ParseChainEmbedding(value string) (ChainEmbedding, error) {
+	switch ChainEmbedding(strings.ToLower(strings.TrimSpace(value))) {
+	case "", EmbedIntermediates:
+		return EmbedIntermediates, nil
+	case EmbedLeafOnly:
+		return EmbedLeafOnly, nil
+	case EmbedFullChain:
+		return EmbedFullChain, nil
+	}
+	return "", fmt.Errorf("invalid chain embedding %q (must be one of %s)", value, ChainEmbeddings)
+}
+
+// EmbeddedCerts returns the certificates that should be embedded into the CMS SignedData according to the chain
+// embedding setting.
+func (sm *SigningMaterial) EmbeddedCerts() []*x509.Certificate {
+	switch sm.ChainEmbedding {
+	case EmbedFullChain:
+		return sm.Certs
+	case EmbedLeafOnly:
+		if leaf := sm.Leaf(); leaf != nil {
+			return []*x509.Certificate{leaf}
+		}
+		return sm.Certs
+	default:
+		var certs []*x509.Certificate
+		for _, c := range sm.Certs {
+			if isSelfSigned(c) {
+				continue
+			}
+			certs = append(certs, c)
+		}
+		return certs
+	}
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.IsCA && bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
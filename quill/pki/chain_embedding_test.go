@@ -0,0 +1,111 @@
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+)
+
+func newTestChain(t *testing.T) []*x509.Certificate {
+	t.Helper()
+
+	issue := func(cn string, isCA bool, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  isCA,
+			BasicConstraintsValid: true,
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert, key
+	}
+
+	root, rootKey := issue("test-root", true, nil, nil)
+	intermediate, intermediateKey := issue("test-intermediate", true, root, rootKey)
+	leaf, _ := issue("test-leaf", false, intermediate, intermediateKey)
+
+	return certchain.Sort([]*x509.Certificate{leaf, intermediate, root})
+}
+
+func TestSigningMaterial_EmbeddedCerts(t *testing.T) {
+	chain := newTestChain(t)
+
+	tests := []struct {
+		embedding ChainEmbedding
+		wantCNs   []string
+	}{
+		{
+			embedding: EmbedLeafOnly,
+			wantCNs:   []string{"test-leaf"},
+		},
+		{
+			embedding: EmbedIntermediates,
+			wantCNs:   []string{"test-intermediate", "test-leaf"},
+		},
+		{
+			embedding: "",
+			wantCNs:   []string{"test-intermediate", "test-leaf"},
+		},
+		{
+			embedding: EmbedFullChain,
+			wantCNs:   []string{"test-root", "test-intermediate", "test-leaf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.embedding), func(t *testing.T) {
+			sm := SigningMaterial{Certs: chain, ChainEmbedding: tt.embedding}
+
+			var cns []string
+			for _, c := range sm.EmbeddedCerts() {
+				cns = append(cns, c.Subject.CommonName)
+			}
+			assert.Equal(t, tt.wantCNs, cns)
+		})
+	}
+}
+
+func TestParseChainEmbedding(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ChainEmbedding
+		wantErr require.ErrorAssertionFunc
+	}{
+		{value: "", want: EmbedIntermediates},
+		{value: "leaf", want: EmbedLeafOnly},
+		{value: "Intermediates", want: EmbedIntermediates},
+		{value: "full", want: EmbedFullChain},
+		{value: "everything", wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseChainEmbedding(tt.value)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// KeyType is the type of keypair to generate for a certificate signing request.
+type KeyType string
+
+const (
+	// RSAKeyType is a 2048 bit RSA keypair (what Keychain Access generates, accepted for all Apple certificate types).
+	RSAKeyType KeyType = "rsa"
+
+	// ECDSAKeyType is a P-256 ECDSA keypair.
+	ECDSAKeyType KeyType = "ecdsa"
+)
+
+// oidEmailAddress is the PKCS#9 emailAddress attribute, which the Apple developer portal expects within the subject
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// CSRConfig describes the subject of a certificate signing request. This mirrors the fields that Keychain Access
+// ("Request a Certificate From a Certificate Authority") prompts for.
+type CSRConfig struct {
+	CommonName string
+	Email      string
+	Country    string
+}
+
+// GenerateKey generates a new keypair of the given type, suitable for an Apple code signing certificate.
+func GenerateKey(keyType KeyType) (crypto.Signer, error) {
+	switch KeyType(strings.ToLower(string(keyType))) {
+	case "", RSAKeyType:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case ECDSAKeyType:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	return nil, fmt.Errorf("unsupported key type %q (must be %q or %q)", keyType, RSAKeyType, ECDSAKeyType)
+}
+
+// NewCSR creates a DER encoded certificate signing request for the given signer. Any crypto.Signer may be used,
+// so the private key may live within a hardware token or KMS as long as it exposes a crypto.Signer.
+func NewCSR(signer crypto.Signer, cfg CSRConfig) ([]byte, error) {
+	if cfg.CommonName == "" {
+		return nil, fmt.Errorf("a common name is required for the certificate signing request")
+	}
+
+	subject := pkix.Name{
+		CommonName: cfg.CommonName,
+	}
+	if cfg.Country != "" {
+		subject.Country = []string{cfg.Country}
+	}
+	if cfg.Email != "" {
+		subject.ExtraNames = append(subject.ExtraNames, pkix.AttributeTypeAndValue{
+			Type:  oidEmailAddress,
+			Value: cfg.Email,
+		})
+	}
+
+	template := &x509.CertificateRequest{
+		Subject: subject,
+	}
+
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		template.SignatureAlgorithm = x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		template.SignatureAlgorithm = x509.ECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", signer.Public())
+	}
+
+	return x509.CreateCertificateRequest(rand.Reader, template, signer)
+}
+
+// EncodeCSR PEM encodes the given DER certificate signing request (the format uploaded to the Apple developer portal).
+func EncodeCSR(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+// EncodePrivateKey PEM encodes the given private key (as PKCS#8).
+func EncodePrivateKey(key crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,90 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+func TestNewCSR(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType KeyType
+		cfg     CSRConfig
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "rsa key with full subject",
+			keyType: RSAKeyType,
+			cfg: CSRConfig{
+				CommonName: "Alex Example",
+				Email:      "alex@example.com",
+				Country:    "US",
+			},
+		},
+		{
+			name:    "ecdsa key",
+			keyType: ECDSAKeyType,
+			cfg: CSRConfig{
+				CommonName: "Alex Example",
+			},
+		},
+		{
+			name:    "missing common name",
+			keyType: RSAKeyType,
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			key, err := GenerateKey(tt.keyType)
+			require.NoError(t, err)
+
+			der, err := NewCSR(key, tt.cfg)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			block, _ := pem.Decode(EncodeCSR(der))
+			require.NotNil(t, block)
+			assert.Equal(t, "CERTIFICATE REQUEST", block.Type)
+
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			require.NoError(t, err)
+			require.NoError(t, csr.CheckSignature())
+
+			assert.Equal(t, tt.cfg.CommonName, csr.Subject.CommonName)
+			if tt.cfg.Country != "" {
+				assert.Equal(t, []string{tt.cfg.Country}, csr.Subject.Country)
+			}
+			if tt.cfg.Email != "" {
+				assert.Contains(t, csr.Subject.String(), tt.cfg.Email)
+			}
+
+			switch tt.keyType {
+			case RSAKeyType:
+				assert.IsType(t, &rsa.PublicKey{}, csr.PublicKey)
+			case ECDSAKeyType:
+				assert.IsType(t, &ecdsa.PublicKey{}, csr.PublicKey)
+			}
+
+			// the written key must be loadable for signing later on
+			keyPEM, err := EncodePrivateKey(key)
+			require.NoError(t, err)
+			_, err = load.PrivateKeyFromPEM(keyPEM, nil)
+			require.NoError(t, err)
+		})
+	}
+}
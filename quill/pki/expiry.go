@@ -0,0 +1,101 @@
+package pki
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultExpiryWarning is how far ahead of certificate expiration a warning is raised when signing.
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
+// ExpiryPolicy describes how certificate expiration is treated when signing. Note that a timestamped signature
+// remains valid after the signing certificate expires, however, a certificate that expires soon cannot be used for
+// signing (for much) longer.
+type ExpiryPolicy struct {
+	// WarnWithin raises a warning when any certificate of the chain expires within this duration (zero disables).
+	WarnWithin time.Duration
+	// RequireValidUntil fails validation if any certificate of the chain expires before this time (zero disables).
+	RequireValidUntil time.Time
+}
+
+// ParseExpiryPolicy parses the user-facing expiry settings. The warning window is a duration (e.g. "720h" or "30d")
+// and the required validity is either a date ("2025-01-31"), an RFC 3339 timestamp, or a duration from now.
+func ParseExpiryPolicy(warnWithin, requireValidUntil string, now time.Time) (ExpiryPolicy, error) {
+	var policy ExpiryPolicy
+
+	if warnWithin != "" {
+		d, err := parseDuration(warnWithin)
+		if err != nil {
+			return policy, fmt.Errorf("invalid expiry warning window %q: %w", warnWithin, err)
+		}
+		policy.WarnWithin = d
+	}
+
+	if requireValidUntil != "" {
+		t, err := parseHorizon(requireValidUntil, now)
+		if err != nil {
+			return policy, fmt.Errorf("invalid required validity %q: %w", requireValidUntil, err)
+		}
+		policy.RequireValidUntil = t
+	}
+
+	return policy, nil
+}
+
+func checkExpiry(r *ValidationResult, sm *SigningMaterial, now time.Time) {
+	policy := sm.ExpiryPolicy
+	for _, c := range sm.Certs {
+		if now.After(c.NotAfter) {
+			// already reported as a validity problem
+			continue
+		}
+
+		switch {
+		case !policy.RequireValidUntil.IsZero() && c.NotAfter.Before(policy.RequireValidUntil):
+			r.add(SeverityError, "expiry", "certificate %q expires on %s, before the required %s", c.Subject.CommonName, c.NotAfter.Format(time.RFC3339), policy.RequireValidUntil.Format(time.RFC3339))
+		case policy.WarnWithin > 0 && c.NotAfter.Before(now.Add(policy.WarnWithin)):
+			r.add(SeverityWarning, "expiry", "certificate %q expires soon on %s (in %s)", c.Subject.CommonName, c.NotAfter.Format(time.RFC3339), formatDays(c.NotAfter.Sub(now)))
+		}
+	}
+}
+
+// parseDuration is like time.ParseDuration, but additionally supports a number of days (e.g. "90d").
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func parseHorizon(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := parseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date (YYYY-MM-DD), RFC 3339 timestamp, or a duration")
+	}
+	return now.Add(d), nil
+}
+
+func formatDays(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+	days := int(d.Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
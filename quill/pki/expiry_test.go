@@ -0,0 +1,115 @@
+package pki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestParseExpiryPolicy(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		warnWithin        string
+		requireValidUntil string
+		want              ExpiryPolicy
+		wantErr           require.ErrorAssertionFunc
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:       "days",
+			warnWithin: "30d",
+			want:       ExpiryPolicy{WarnWithin: 30 * 24 * time.Hour},
+		},
+		{
+			name:       "go duration",
+			warnWithin: "12h",
+			want:       ExpiryPolicy{WarnWithin: 12 * time.Hour},
+		},
+		{
+			name:              "date",
+			requireValidUntil: "2025-06-30",
+			want:              ExpiryPolicy{RequireValidUntil: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:              "relative",
+			requireValidUntil: "10d",
+			want:              ExpiryPolicy{RequireValidUntil: now.Add(10 * 24 * time.Hour)},
+		},
+		{
+			name:       "bad duration",
+			warnWithin: "soon",
+			wantErr:    require.Error,
+		},
+		{
+			name:              "bad horizon",
+			requireValidUntil: "06/30/2025",
+			wantErr:           require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseExpiryPolicy(tt.warnWithin, tt.requireValidUntil, now)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateCertificateMaterial_Expiry(t *testing.T) {
+	// all certificates expire in ~23 hours
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		policy       ExpiryPolicy
+		wantErrors   int
+		wantWarnings int
+	}{
+		{
+			name: "no policy",
+		},
+		{
+			name:   "outside of warning window",
+			policy: ExpiryPolicy{WarnWithin: time.Hour},
+		},
+		{
+			name:         "within warning window",
+			policy:       ExpiryPolicy{WarnWithin: 30 * 24 * time.Hour},
+			wantWarnings: 3,
+		},
+		{
+			name:       "expires before required horizon",
+			policy:     ExpiryPolicy{WarnWithin: 30 * 24 * time.Hour, RequireValidUntil: time.Now().Add(48 * time.Hour)},
+			wantErrors: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &SigningMaterial{
+				Signer:       fixture.LeafKey,
+				Certs:        certchain.Sort(fixture.Chain()),
+				ExpiryPolicy: tt.policy,
+			}
+
+			result := ValidateCertificateMaterial(sm, time.Now())
+			assert.Len(t, result.Errors(), tt.wantErrors)
+			assert.Len(t, result.Warnings(), tt.wantWarnings)
+		})
+	}
+}
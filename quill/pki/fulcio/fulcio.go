@@ -0,0 +1,204 @@
+// Package fulcio obtains short-lived code signing certificates from a Sigstore Fulcio certificate authority in
+// exchange for an OIDC identity token ("keyless" signing). Certificates from Fulcio chain to the Sigstore (or an
+// organization's own) roots, never to Apple: binaries signed with them are not trusted by Gatekeeper and cannot be
+// notarized. This is only useful for organizations that verify internal tools against their own trust roots.
+package fulcio
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+const (
+	// DefaultURL is the public Sigstore Fulcio instance.
+	DefaultURL = "https://fulcio.sigstore.dev"
+
+	// DefaultTimeout is the time allowed for the certificate request.
+	DefaultTimeout = 30 * time.Second
+
+	signingCertPath = "/api/v2/signingCert"
+)
+
+// Config describes how to obtain a certificate from Fulcio.
+type Config struct {
+	// URL is the base URL of the Fulcio instance (default is DefaultURL).
+	URL string
+	// IdentityToken is the (raw) OIDC identity token to exchange for a certificate (see IdentityToken).
+	IdentityToken string
+	// Timeout is the time allowed for the certificate request (default is DefaultTimeout).
+	Timeout time.Duration
+}
+
+type signingCertRequest struct {
+	Credentials struct {
+		OIDCIdentityToken string `json:"oidcIdentityToken"`
+	} `json:"credentials"`
+	PublicKeyRequest struct {
+		PublicKey struct {
+			Algorithm string `json:"algorithm"`
+			Content   string `json:"content"`
+		} `json:"publicKey"`
+		ProofOfPossession string `json:"proofOfPossession"`
+	} `json:"publicKeyRequest"`
+}
+
+type certificateChain struct {
+	Chain struct {
+		Certificates []string `json:"certificates"`
+	} `json:"chain"`
+}
+
+type signingCertResponse struct {
+	SignedCertificateEmbeddedSct *certificateChain `json:"signedCertificateEmbeddedSct"`
+	SignedCertificateDetachedSct *certificateChain `json:"signedCertificateDetachedSct"`
+}
+
+// NewSigningMaterial generates an ephemeral (ECDSA P-256) key and requests a code signing certificate for it from
+// Fulcio. The key only exists in memory and the certificate is only valid for a few minutes, so the returned signing
+// material must be used right away.
+func NewSigningMaterial(cfg Config) (*pki.SigningMaterial, error) {
+	if cfg.URL == "" {
+		cfg.URL = DefaultURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.IdentityToken == "" {
+		return nil, fmt.Errorf("an OIDC identity token is required to request a certificate from Fulcio")
+	}
+
+	challenge, err := subject(cfg.IdentityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ephemeral signing key: %w", err)
+	}
+
+	certs, err := requestCertificate(cfg, key, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := certs[0]
+	if !load.PublicKeyMatches(key, leaf) {
+		return nil, fmt.Errorf("certificate returned by Fulcio does not match the ephemeral signing key")
+	}
+
+	log.WithFields("identity", strings.Join(identities(leaf), ", "), "expires", leaf.NotAfter.Format(time.RFC3339)).
+		Warn("signing with a Sigstore (Fulcio) certificate: this is NOT an Apple trusted identity, the binary will not pass Gatekeeper and cannot be notarized")
+
+	return pki.NewSigningMaterial(key, certs), nil
+}
+
+func requestCertificate(cfg Config, key crypto.Signer, challenge string) ([]*x509.Certificate, error) {
+	endpoint := strings.TrimSuffix(cfg.URL, "/") + signingCertPath
+	if err := network.Allow(network.Fulcio, endpoint); err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode ephemeral public key: %w", err)
+	}
+
+	// the proof of possession is a signature over the subject of the identity token
+	digest := sha256.Sum256([]byte(challenge))
+	proof, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create proof of possession: %w", err)
+	}
+
+	var req signingCertRequest
+	req.Credentials.OIDCIdentityToken = cfg.IdentityToken
+	req.PublicKeyRequest.PublicKey.Algorithm = "ECDSA"
+	req.PublicKeyRequest.PublicKey.Content = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	req.PublicKeyRequest.ProofOfPossession = base64.StdEncoding.EncodeToString(proof)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	log.WithFields("url", endpoint).Debug("requesting signing certificate from Fulcio")
+
+	resp, err := (&http.Client{Timeout: cfg.Timeout}).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request certificate from Fulcio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Fulcio response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("fulcio rejected the certificate request (%s): %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return parseResponse(respBody)
+}
+
+func parseResponse(body []byte) ([]*x509.Certificate, error) {
+	var resp signingCertResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("unable to decode Fulcio response: %w", err)
+	}
+
+	chain := resp.SignedCertificateEmbeddedSct
+	if chain == nil {
+		chain = resp.SignedCertificateDetachedSct
+	}
+	if chain == nil || len(chain.Chain.Certificates) == 0 {
+		return nil, fmt.Errorf("no certificates found in Fulcio response")
+	}
+
+	var certs []*x509.Certificate
+	for _, p := range chain.Chain.Certificates {
+		c, err := load.CertificatesFromPEM([]byte(p))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse certificate from Fulcio response: %w", err)
+		}
+		certs = append(certs, c...)
+	}
+	return certs, nil
+}
+
+// identities returns the subject alternative names of a Fulcio certificate (Fulcio certificates have an empty
+// subject, the identity is the email or URI of the token subject).
+func identities(cert *x509.Certificate) []string {
+	var results []string
+	results = append(results, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		results = append(results, u.String())
+	}
+	return results
+}
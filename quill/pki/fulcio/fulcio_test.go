@@ -0,0 +1,196 @@
+package fulcio
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func newToken(claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	by, _ := json.Marshal(claims)
+	return header + "." + base64.RawURLEncoding.EncodeToString(by) + ".c2lnbmF0dXJl"
+}
+
+// newFulcio returns a fake Fulcio instance which issues certificates from the given CA (checking the proof of
+// possession over the given identity).
+func newFulcio(t *testing.T, ca *testca.Fixture, identity string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != signingCertPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req signingCertRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		block, _ := pem.Decode([]byte(req.PublicKeyRequest.PublicKey.Content))
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		proof, _ := base64.StdEncoding.DecodeString(req.PublicKeyRequest.ProofOfPossession)
+		digest := sha256.Sum256([]byte(identity))
+		if !ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), digest[:], proof) {
+			http.Error(w, "invalid proof of possession", http.StatusUnauthorized)
+			return
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber:   big.NewInt(42),
+			NotBefore:      time.Now().Add(-time.Minute),
+			NotAfter:       time.Now().Add(10 * time.Minute),
+			EmailAddresses: []string{identity},
+			KeyUsage:       x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		}, ca.Intermediate, pub, ca.IntermediateKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var resp signingCertResponse
+		resp.SignedCertificateEmbeddedSct = &certificateChain{}
+		for _, c := range [][]byte{der, ca.Intermediate.Raw, ca.Root.Raw} {
+			resp.SignedCertificateEmbeddedSct.Chain.Certificates = append(resp.SignedCertificateEmbeddedSct.Chain.Certificates,
+				string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c})))
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestNewSigningMaterial(t *testing.T) {
+	ca, err := testca.New(testca.Config{KeyType: "ecdsa"})
+	require.NoError(t, err)
+
+	server := newFulcio(t, ca, "dev@example.com")
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "email identity",
+			token: newToken(map[string]interface{}{"sub": "1234", "email": "dev@example.com", "email_verified": true}),
+		},
+		{
+			name:    "unverified email",
+			token:   newToken(map[string]interface{}{"sub": "1234", "email": "dev@example.com", "email_verified": false}),
+			wantErr: require.Error,
+		},
+		{
+			name:    "identity rejected by fulcio",
+			token:   newToken(map[string]interface{}{"sub": "someone-else"}),
+			wantErr: require.Error,
+		},
+		{
+			name:    "no token",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			sm, err := NewSigningMaterial(Config{URL: server.URL, IdentityToken: tt.token})
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			require.Len(t, sm.Certs, 3)
+			assert.Equal(t, []string{"dev@example.com"}, sm.Leaf().EmailAddresses)
+			assert.Equal(t, sm.Signer.Public(), sm.Leaf().PublicKey)
+		})
+	}
+}
+
+func Test_subject(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "email",
+			token: newToken(map[string]interface{}{"sub": "1234", "email": "dev@example.com", "email_verified": true}),
+			want:  "dev@example.com",
+		},
+		{
+			name:  "subject only (e.g. a workload identity)",
+			token: newToken(map[string]interface{}{"sub": "repo:example/tool:ref:refs/heads/main"}),
+			want:  "repo:example/tool:ref:refs/heads/main",
+		},
+		{
+			name:    "not a JWT",
+			token:   "not-a-token",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := subject(tt.token)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIdentityToken(t *testing.T) {
+	token := newToken(map[string]interface{}{"sub": "1234"})
+
+	t.Setenv("QUILL_TEST_ID_TOKEN", token)
+	got, err := IdentityToken("env:QUILL_TEST_ID_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, token, got)
+
+	got, err = IdentityToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, token, got)
+
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" || r.URL.Query().Get("audience") != audience {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"value": %q}`, token)
+	}))
+	defer github.Close()
+
+	t.Setenv(sigstoreTokenEnv, "")
+	t.Setenv(githubTokenURLEnv, github.URL+"/token?api-version=2.0")
+	t.Setenv(githubTokenTokenEnv, "request-token")
+	got, err = IdentityToken("")
+	require.NoError(t, err)
+	assert.Equal(t, token, got)
+}
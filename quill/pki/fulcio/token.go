@@ -0,0 +1,144 @@
+package fulcio
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/anchore/quill/quill/network"
+)
+
+const (
+	// sigstoreTokenEnv is the conventional variable holding an identity token for Sigstore tooling.
+	sigstoreTokenEnv = "SIGSTORE_ID_TOKEN"
+
+	// the GitHub Actions variables for requesting an identity token (when the workflow has "id-token: write")
+	githubTokenURLEnv   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	githubTokenTokenEnv = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	audience = "sigstore"
+)
+
+// IdentityToken resolves the OIDC identity token to exchange for a certificate. The given value may be the raw
+// token, a path to a file holding the token, or 'env:ENV_VAR_NAME'. When no value is given the token is taken from
+// SIGSTORE_ID_TOKEN, or requested from GitHub Actions (which requires the "id-token: write" workflow permission).
+func IdentityToken(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		token := strings.TrimSpace(os.Getenv(name))
+		if token == "" {
+			return "", fmt.Errorf("no identity token found in environment variable %q", name)
+		}
+		return token, nil
+	case looksLikeJWT(value):
+		return value, nil
+	case value != "":
+		by, err := os.ReadFile(value)
+		if err != nil {
+			return "", fmt.Errorf("unable to read identity token: %w", err)
+		}
+		return strings.TrimSpace(string(by)), nil
+	}
+
+	if token := os.Getenv(sigstoreTokenEnv); token != "" {
+		return strings.TrimSpace(token), nil
+	}
+
+	if os.Getenv(githubTokenURLEnv) != "" && os.Getenv(githubTokenTokenEnv) != "" {
+		return githubActionsToken(os.Getenv(githubTokenURLEnv), os.Getenv(githubTokenTokenEnv))
+	}
+
+	return "", fmt.Errorf("no OIDC identity token found (provide one explicitly, set %s, or run within GitHub Actions with the id-token permission)", sigstoreTokenEnv)
+}
+
+func githubActionsToken(requestURL, bearer string) (string, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", githubTokenURLEnv, err)
+	}
+	q := u.Query()
+	q.Set("audience", audience)
+	u.RawQuery = q.Encode()
+
+	if err := network.Allow(network.OIDC, u.Host); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+bearer)
+
+	resp, err := (&http.Client{Timeout: DefaultTimeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to request identity token from GitHub Actions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read identity token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to request identity token from GitHub Actions (%s)", resp.Status)
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unable to decode identity token response: %w", err)
+	}
+	if result.Value == "" {
+		return "", fmt.Errorf("no identity token returned by GitHub Actions")
+	}
+	return result.Value, nil
+}
+
+type claims struct {
+	Subject       string      `json:"sub"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+}
+
+// subject returns the identity Fulcio expects the proof of possession to be made over: the (verified) email of the
+// token, otherwise the token subject.
+func subject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("identity token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode identity token: %w", err)
+	}
+
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", fmt.Errorf("unable to decode identity token claims: %w", err)
+	}
+
+	if c.Email != "" {
+		// the claim is a boolean for most providers, however, some encode it as a string
+		if v, ok := c.EmailVerified.(bool); ok && !v {
+			return "", fmt.Errorf("the email of the identity token is not verified")
+		}
+		return c.Email, nil
+	}
+	if c.Subject == "" {
+		return "", fmt.Errorf("identity token has no subject")
+	}
+	return c.Subject, nil
+}
+
+func looksLikeJWT(value string) bool {
+	return strings.Count(value, ".") == 2 && strings.HasPrefix(value, "ey")
+}
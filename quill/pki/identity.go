@@ -0,0 +1,198 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/sha1" //nolint:gosec // SHA-1 fingerprints are used for identification only (as codesign does)
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+// Fingerprints returns the hex encoded SHA-1 and SHA-256 fingerprints of the given certificate.
+func Fingerprints(cert *x509.Certificate) (sha1Hex string, sha256Hex string) {
+	s1 := sha1.Sum(cert.Raw) //nolint:gosec // see import
+	s256 := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(s1[:]), hex.EncodeToString(s256[:])
+}
+
+// MatchesIdentity indicates if the given certificate is selected by the given selector, which is either a SHA-1 or
+// SHA-256 fingerprint of the certificate (case-insensitive, optionally colon separated) or a substring of the
+// subject common name (this mirrors "codesign -s <identity>").
+func MatchesIdentity(cert *x509.Certificate, selector string) bool {
+	if cert == nil || selector == "" {
+		return false
+	}
+
+	sha1Hex, sha256Hex := Fingerprints(cert)
+	switch fp := normalizeFingerprint(selector); fp {
+	case sha1Hex, sha256Hex:
+		return true
+	}
+
+	return strings.Contains(cert.Subject.CommonName, selector)
+}
+
+func normalizeFingerprint(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.ReplaceAll(value, ":", "")
+	return strings.ReplaceAll(value, " ", "")
+}
+
+// SelectIdentity returns the single candidate whose signing (leaf) certificate matches the given selector (see
+// MatchesIdentity). An error is returned if no candidate or more than one candidate matches.
+func SelectIdentity(candidates []*SigningMaterial, selector string) (*SigningMaterial, error) {
+	return Candidates{Identities: candidates}.Select(selector)
+}
+
+// ResolveIdentity is like SelectIdentity, however, when no selector is given the only candidate is used (if there is
+// more than one candidate an error is returned).
+func ResolveIdentity(candidates []*SigningMaterial, selector string) (*SigningMaterial, error) {
+	return Candidates{Identities: candidates}.Resolve(selector)
+}
+
+// Candidates are the signing identities found within signing material, along with the pairings of a key and a
+// certificate that cannot be used (so that selecting such an identity reports why instead of not finding it).
+type Candidates struct {
+	Identities []*SigningMaterial
+	Rejected   []RejectedIdentity
+}
+
+// RejectedIdentity is a pairing of a key and a certificate whose certificate chain cannot be verified.
+type RejectedIdentity struct {
+	Certificate *x509.Certificate
+	Err         error
+}
+
+// Select returns the single identity whose signing (leaf) certificate matches the given selector (see
+// MatchesIdentity). An error is returned if no identity or more than one identity matches, which explains why the
+// matching identity was rejected (if any).
+func (c Candidates) Select(selector string) (*SigningMaterial, error) {
+	var matches []*SigningMaterial
+	for _, i := range c.Identities {
+		if i == nil {
+			continue
+		}
+		if MatchesIdentity(i.Leaf(), selector) {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		for _, r := range c.Rejected {
+			if MatchesIdentity(r.Certificate, selector) {
+				return nil, fmt.Errorf("signing identity matching %q cannot be used: %w", selector, r.Err)
+			}
+		}
+		return nil, fmt.Errorf("no signing identity found matching %q (from %d candidates)", selector, len(c.Identities))
+	case 1:
+		log.WithFields("identity", matches[0].Leaf().Subject.CommonName).Debug("selected signing identity")
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("ambiguous signing identity %q matches %d identities: %s", selector, len(matches), describeIdentities(matches))
+	}
+}
+
+// Resolve is like Select, however, when no selector is given the only identity is used (if there is more than one
+// identity an error is returned).
+func (c Candidates) Resolve(selector string) (*SigningMaterial, error) {
+	if selector != "" {
+		return c.Select(selector)
+	}
+
+	switch len(c.Identities) {
+	case 0:
+		if len(c.Rejected) > 0 {
+			return nil, c.Rejected[len(c.Rejected)-1].Err
+		}
+		return nil, fmt.Errorf("no signing identities found")
+	case 1:
+		return c.Identities[0], nil
+	default:
+		return nil, fmt.Errorf("found %d signing identities, select one by fingerprint or common name: %s", len(c.Identities), describeIdentities(c.Identities))
+	}
+}
+
+// NewSigningMaterialsFromKeysAndCerts pairs each private key with every (non-CA) certificate for its public key,
+// resolving the remaining chain for each pairing from the other given certificates, the Apple certificates embedded
+// into quill, and (as a last resort) the Authority Information Access URLs of the certificates. Pairings whose chain
+// cannot be verified are rejected, with the reason reported when such an identity is selected.
+func NewSigningMaterialsFromKeysAndCerts(keys []crypto.PrivateKey, certs []*x509.Certificate, failWithoutFullChain bool) (*Candidates, error) {
+	certsCollection := certchain.NewCollection()
+	if err := certsCollection.AddIntermediate(certs...); err != nil {
+		return nil, err
+	}
+
+	candidates := &Candidates{}
+	for _, key := range keys {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			continue
+		}
+
+		for _, cert := range certs {
+			if cert.IsCA || !load.PublicKeyMatches(key, cert) {
+				continue
+			}
+
+			// only the given certificates that are part of the chain for this leaf are kept
+			chain, _ := certchain.Find(certsCollection, cert)
+			allCerts, err := completeChain(cert, append([]*x509.Certificate{cert}, chain...), failWithoutFullChain)
+			if err != nil {
+				err = fmt.Errorf("unable to verify certificate chain for %q: %w", cert.Subject.CommonName, err)
+				log.Warn(err.Error())
+				candidates.Rejected = append(candidates.Rejected, RejectedIdentity{Certificate: cert, Err: err})
+				continue
+			}
+
+			candidates.Identities = append(candidates.Identities, NewSigningMaterial(signer, allCerts))
+		}
+	}
+
+	return candidates, nil
+}
+
+// NewSigningMaterialsFromP12 returns a candidate signing identity for every private key and matching certificate
+// within the given P12 contents.
+func NewSigningMaterialsFromP12(p12Content load.P12Contents, failWithoutFullChain bool) (*Candidates, error) {
+	keys := p12Content.PrivateKeys
+	if len(keys) == 0 && p12Content.PrivateKey != nil {
+		keys = []crypto.PrivateKey{p12Content.PrivateKey}
+	}
+
+	var certs []*x509.Certificate
+	if p12Content.Certificate != nil {
+		certs = append(certs, p12Content.Certificate)
+	}
+	certs = append(certs, p12Content.Certificates...)
+
+	return NewSigningMaterialsFromKeysAndCerts(keys, certs, failWithoutFullChain)
+}
+
+// NewSigningMaterialsFromDirectory returns a candidate signing identity for every private key within the given
+// directory of PEM/DER/P12 files, paired to its certificate by public key.
+func NewSigningMaterialsFromDirectory(dir string, provider load.PassphraseProvider, failWithoutFullChain bool) (*Candidates, error) {
+	contents, err := load.Directory(dir, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields("keys", len(contents.PrivateKeys), "certs", len(contents.Certificates), "dir", dir).Debug("read signing material directory")
+
+	return NewSigningMaterialsFromKeysAndCerts(contents.PrivateKeys, contents.Certificates, failWithoutFullChain)
+}
+
+func describeIdentities(candidates []*SigningMaterial) string {
+	var names []string
+	for _, c := range candidates {
+		_, fp := Fingerprints(c.Leaf())
+		names = append(names, fmt.Sprintf("%q (%s)", c.Leaf().Subject.CommonName, fp))
+	}
+	return strings.Join(names, ", ")
+}
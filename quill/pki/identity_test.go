@@ -0,0 +1,182 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdentity(t *testing.T, cn string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return key, cert
+}
+
+func TestMatchesIdentity(t *testing.T) {
+	_, cert := newTestIdentity(t, "Developer ID Application: Anchore, Inc. (9MJHKYX5AT)")
+	sha1Hex, sha256Hex := Fingerprints(cert)
+
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+	}{
+		{
+			name:     "sha1 fingerprint",
+			selector: sha1Hex,
+			want:     true,
+		},
+		{
+			name:     "sha256 fingerprint",
+			selector: sha256Hex,
+			want:     true,
+		},
+		{
+			name:     "upper case colon separated sha1 fingerprint",
+			selector: colonSeparated(strings.ToUpper(sha1Hex)),
+			want:     true,
+		},
+		{
+			name:     "common name substring",
+			selector: "Anchore, Inc.",
+			want:     true,
+		},
+		{
+			name:     "different fingerprint",
+			selector: strings.Repeat("0", 40),
+			want:     false,
+		},
+		{
+			name:     "different common name",
+			selector: "Other Corp",
+			want:     false,
+		},
+		{
+			name:     "empty selector",
+			selector: "",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchesIdentity(cert, tt.selector))
+		})
+	}
+}
+
+func TestSelectIdentity(t *testing.T) {
+	key1, cert1 := newTestIdentity(t, "Developer ID Application: First (AAAAAAAAAA)")
+	key2, cert2 := newTestIdentity(t, "Developer ID Application: Second (BBBBBBBBBB)")
+	_, unrelated := newTestIdentity(t, "Developer ID Application: Unrelated (CCCCCCCCCC)")
+
+	candidates, err := NewSigningMaterialsFromKeysAndCerts(
+		[]crypto.PrivateKey{key1, key2},
+		[]*x509.Certificate{unrelated, cert2, cert1},
+		false,
+	)
+	require.NoError(t, err)
+	require.Len(t, candidates.Identities, 2)
+	require.Empty(t, candidates.Rejected)
+
+	_, fp2 := Fingerprints(cert2)
+
+	tests := []struct {
+		name     string
+		selector string
+		wantCN   string
+		wantErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:     "select by fingerprint",
+			selector: fp2,
+			wantCN:   cert2.Subject.CommonName,
+		},
+		{
+			name:     "select by common name",
+			selector: "First",
+			wantCN:   cert1.Subject.CommonName,
+		},
+		{
+			name:     "ambiguous selector",
+			selector: "Developer ID Application",
+			wantErr:  require.Error,
+		},
+		{
+			name:     "certificate without a key is not a candidate",
+			selector: "Unrelated",
+			wantErr:  require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := candidates.Select(tt.selector)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCN, got.Leaf().Subject.CommonName)
+		})
+	}
+}
+
+func TestCandidates_rejected(t *testing.T) {
+	key1, cert1 := newTestIdentity(t, "Developer ID Application: First (AAAAAAAAAA)")
+	key2, cert2 := newTestIdentity(t, "Developer ID Application: Second (BBBBBBBBBB)")
+
+	// the self-signed certificates do not chain to an Apple root
+	candidates, err := NewSigningMaterialsFromKeysAndCerts(
+		[]crypto.PrivateKey{key1, key2},
+		[]*x509.Certificate{cert1, cert2},
+		true,
+	)
+	require.NoError(t, err)
+	assert.Empty(t, candidates.Identities)
+	require.Len(t, candidates.Rejected, 2)
+
+	_, err = candidates.Select("Second")
+	require.ErrorContains(t, err, `unable to verify certificate chain for "Developer ID Application: Second (BBBBBBBBBB)"`)
+
+	_, err = candidates.Select("Other")
+	require.ErrorContains(t, err, `no signing identity found matching "Other"`)
+
+	_, err = candidates.Resolve("")
+	require.ErrorContains(t, err, "unable to verify certificate chain")
+}
+
+func colonSeparated(s string) string {
+	var parts []string
+	for i := 0; i+2 <= len(s); i += 2 {
+		parts = append(parts, s[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
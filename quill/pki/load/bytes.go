@@ -10,7 +10,32 @@ import (
 	"github.com/anchore/quill/internal/log"
 )
 
+// StdinPath is the path value that indicates that content should be read from stdin.
+const StdinPath = "-"
+
+// BytesFromFileOrEnv resolves the given value to content bytes. The value may be:
+//   - "-" to read the content from stdin
+//   - "env:VAR_NAME" to read the content from an environment variable
+//   - "k8s:namespace/name/key" to read the content from a key within a Kubernetes Secret
+//   - a path to a file on disk
+//   - the content itself
+//
+// Content that is read from the environment or provided directly may either be base64 encoded (required for binary
+// content such as P12 files) or PEM encoded text. In no case is the content written to disk.
 func BytesFromFileOrEnv(path string) ([]byte, error) {
+	if path == StdinPath {
+		log.Trace("loading bytes from stdin")
+		return io.ReadAll(os.Stdin)
+	}
+
+	if strings.HasPrefix(path, KubernetesSecretPrefix) {
+		ref, err := ParseKubernetesSecretRef(path)
+		if err != nil {
+			return nil, err
+		}
+		return KubernetesSecret(*ref)
+	}
+
 	if strings.HasPrefix(path, "env:") {
 		// comes from an env var...
 		fields := strings.Split(path, "env:")
@@ -20,42 +45,52 @@ func BytesFromFileOrEnv(path string) ([]byte, error) {
 		envVar := fields[1]
 
 		log.WithFields("var", envVar).Trace("loading bytes from environment")
-
 		value := os.Getenv(envVar)
 		if value == "" {
 			return nil, fmt.Errorf("no key found in environment variable %q", envVar)
 		}
 
-		keyBytes, err := base64.StdEncoding.DecodeString(value)
+		keyBytes, err := decodeContent(value)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("unable to decode content from environment variable %q: %w", envVar, err)
 		}
 		return keyBytes, nil
 	}
 
 	// comes from the config...
-
 	if _, err := os.Stat(path); err != nil {
 		log.Trace("using bytes from config")
-
-		decodedKey, err := base64.StdEncoding.DecodeString(path)
+		decodedKey, err := decodeContent(path)
 		if err != nil {
 			return nil, fmt.Errorf("unable to base64 decode key: %w", err)
 		}
-
 		return decodedKey, nil
 	}
 
 	// comes from a file...
-
 	log.WithFields("path", path).Trace("loading bytes from file")
-
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-
 	defer f.Close()
 
 	return io.ReadAll(f)
 }
+
+func decodeContent(value string) ([]byte, error) {
+	if isPEM(value) {
+		// PEM content is already text-safe, so there is no need for it to be additionally base64 encoded
+		return []byte(value), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func isPEM(value string) bool {
+	return strings.Contains(value, "-----BEGIN ")
+}
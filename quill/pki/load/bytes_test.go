@@ -0,0 +1,106 @@
+package load
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesFromFileOrEnv(t *testing.T) {
+	pemContent := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	binaryContent := []byte{0x30, 0x82, 0x01, 0x00, 0xff}
+
+	t.Setenv("QUILL_TEST_BASE64", base64.StdEncoding.EncodeToString(binaryContent))
+	t.Setenv("QUILL_TEST_PEM", pemContent)
+	t.Setenv("QUILL_TEST_BASE64_PEM", base64.StdEncoding.EncodeToString([]byte(pemContent)))
+	t.Setenv("QUILL_TEST_GARBAGE", "not base64!")
+
+	filePath := filepath.Join(t.TempDir(), "content.bin")
+	require.NoError(t, os.WriteFile(filePath, binaryContent, 0600))
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []byte
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "base64 content from environment",
+			value: "env:QUILL_TEST_BASE64",
+			want:  binaryContent,
+		},
+		{
+			name:  "PEM content from environment",
+			value: "env:QUILL_TEST_PEM",
+			want:  []byte(pemContent),
+		},
+		{
+			name:  "base64 encoded PEM content from environment",
+			value: "env:QUILL_TEST_BASE64_PEM",
+			want:  []byte(pemContent),
+		},
+		{
+			name:    "undecodable content from environment",
+			value:   "env:QUILL_TEST_GARBAGE",
+			wantErr: require.Error,
+		},
+		{
+			name:    "missing environment variable",
+			value:   "env:QUILL_TEST_DOES_NOT_EXIST",
+			wantErr: require.Error,
+		},
+		{
+			name:  "base64 content directly",
+			value: base64.StdEncoding.EncodeToString(binaryContent),
+			want:  binaryContent,
+		},
+		{
+			name:  "PEM content directly",
+			value: pemContent,
+			want:  []byte(pemContent),
+		},
+		{
+			name:  "content from file",
+			value: filePath,
+			want:  binaryContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := BytesFromFileOrEnv(tt.value)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBytesFromFileOrEnv_Stdin(t *testing.T) {
+	content := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	original := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = original
+		_ = r.Close()
+	})
+
+	got, err := BytesFromFileOrEnv(StdinPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
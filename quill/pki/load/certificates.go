@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io"
 
 	"github.com/anchore/quill/internal/log"
 )
@@ -15,24 +16,23 @@ func Certificates(path string) ([]*x509.Certificate, error) {
 		return nil, fmt.Errorf("unable to read signing certificate: %w", err)
 	}
 
-	chainBlockBytes := decodeChainFromPEM(certPEM)
-
-	if len(chainBlockBytes) == 0 {
-		return nil, fmt.Errorf("no certificates found")
+	certs, err := CertificatesFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("no certificates found: %w", err)
 	}
 
-	var certs []*x509.Certificate
-
-	for i, certBytes := range chainBlockBytes {
-		c, err := x509.ParseCertificate(certBytes)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse certificate %d of %d: %w", i+1, len(chainBlockBytes), err)
-		}
+	return certs, nil
+}
 
-		certs = append(certs, c)
+// CertificatesFromReader reads all PEM encoded certificates from the given reader (e.g. stdin or a secret manager
+// response body).
+func CertificatesFromReader(reader io.Reader) ([]*x509.Certificate, error) {
+	certPEM, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing certificate: %w", err)
 	}
 
-	return certs, nil
+	return CertificatesFromPEM(certPEM)
 }
 
 func CertificatesFromPEMs(pems [][]byte) ([]*x509.Certificate, error) {
@@ -0,0 +1,122 @@
+package load
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// DirectoryContents is all signing material (private keys and certificates) found within a directory.
+type DirectoryContents struct {
+	PrivateKeys  []crypto.PrivateKey
+	Certificates []*x509.Certificate
+}
+
+// Directory reads all PEM (.pem, .crt, .cer, .key), DER (.der, .cer) and P12 (.p12, .pfx) files found directly
+// within the given directory. The given provider is consulted (at most once) when encrypted material is found.
+func Directory(dir string, provider PassphraseProvider) (*DirectoryContents, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing material directory: %w", err)
+	}
+
+	if provider != nil {
+		provider = &onceProvider{provider: provider}
+	}
+
+	var contents DirectoryContents
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+
+		switch ext {
+		case ".p12", ".pfx":
+			log.WithFields("path", path).Trace("reading p12 from directory")
+			p12, err := P12WithPassphrase(path, provider)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %q: %w", path, err)
+			}
+			keys := p12.PrivateKeys
+			if len(keys) == 0 {
+				keys = []crypto.PrivateKey{p12.PrivateKey}
+			}
+			contents.PrivateKeys = append(contents.PrivateKeys, keys...)
+			contents.Certificates = append(contents.Certificates, p12.Certificate)
+			contents.Certificates = append(contents.Certificates, p12.Certificates...)
+		case ".pem", ".crt", ".cer", ".der", ".key":
+			log.WithFields("path", path).Trace("reading PEM/DER from directory")
+			by, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %q: %w", path, err)
+			}
+			if err := contents.addPEMOrDER(by, provider); err != nil {
+				return nil, fmt.Errorf("unable to read %q: %w", path, err)
+			}
+		default:
+			log.WithFields("path", path).Trace("skipping non signing material file")
+		}
+	}
+
+	return &contents, nil
+}
+
+func (c *DirectoryContents) addPEMOrDER(by []byte, provider PassphraseProvider) error {
+	if !isPEM(string(by)) {
+		cert, err := x509.ParseCertificate(by)
+		if err != nil {
+			return fmt.Errorf("unable to parse DER certificate: %w", err)
+		}
+		c.Certificates = append(c.Certificates, cert)
+		return nil
+	}
+
+	rest := by
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil
+		}
+
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("unable to parse certificate: %w", err)
+			}
+			c.Certificates = append(c.Certificates, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			key, err := PrivateKeyFromPEM(pem.EncodeToMemory(block), provider)
+			if err != nil {
+				return err
+			}
+			c.PrivateKeys = append(c.PrivateKeys, key)
+		}
+	}
+}
+
+// onceProvider asks the wrapped provider for a passphrase only once, reusing the answer for all remaining material.
+type onceProvider struct {
+	provider PassphraseProvider
+	once     sync.Once
+	value    string
+	err      error
+}
+
+func (o *onceProvider) Passphrase(prompt string) (string, error) {
+	o.once.Do(func() {
+		o.value, o.err = o.provider.Passphrase(prompt)
+	})
+	return o.value, o.err
+}
@@ -0,0 +1,89 @@
+package load
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func newSelfSignedCert(t *testing.T, cn string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return key, cert
+}
+
+func TestDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	// a PEM with both the key and certificate
+	pemKey, pemCert := newSelfSignedCert(t, "pem identity")
+	combined := append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pemCert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pemKey)})...,
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "identity.pem"), combined, 0600))
+
+	// a DER certificate on its own
+	_, derCert := newSelfSignedCert(t, "der certificate")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.cer"), derCert.Raw, 0600))
+
+	// a password protected P12
+	p12Key, p12Cert := newSelfSignedCert(t, "p12 identity")
+	p12Bytes, err := pkcs12.Modern2023.Encode(p12Key, p12Cert, nil, "5w0rdf15h")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "identity.p12"), p12Bytes, 0600))
+
+	// files that should be ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("nothing to see here"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.pem"), 0700))
+
+	var prompts int
+	provider := PassphraseFunc(func(string) (string, error) {
+		prompts++
+		return "5w0rdf15h", nil
+	})
+
+	got, err := Directory(dir, provider)
+	require.NoError(t, err)
+
+	assert.Len(t, got.PrivateKeys, 2)
+	assert.Equal(t, 1, prompts)
+
+	var cns []string
+	for _, c := range got.Certificates {
+		cns = append(cns, c.Subject.CommonName)
+	}
+	assert.ElementsMatch(t, []string{"pem identity", "der certificate", "p12 identity"}, cns)
+
+	for _, k := range got.PrivateKeys {
+		assert.True(t, PublicKeyMatches(k, pemCert) || PublicKeyMatches(k, p12Cert))
+	}
+}
@@ -0,0 +1,336 @@
+package load
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/network"
+)
+
+const (
+	// KubernetesSecretPrefix is the value prefix that indicates that content should be read from a Kubernetes Secret
+	// (e.g. "k8s:namespace/name/key").
+	KubernetesSecretPrefix = "k8s:"
+
+	inClusterTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// KubernetesSecretRef identifies a single key within a Kubernetes Secret.
+type KubernetesSecretRef struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// ParseKubernetesSecretRef parses a value of the form "k8s:namespace/name/key" (the prefix is optional).
+func ParseKubernetesSecretRef(value string) (*KubernetesSecretRef, error) {
+	fields := strings.Split(strings.TrimPrefix(value, KubernetesSecretPrefix), "/")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("kubernetes secret reference must be of the form 'namespace/name/key': %q", value)
+	}
+	for _, f := range fields {
+		if f == "" {
+			return nil, fmt.Errorf("kubernetes secret reference must be of the form 'namespace/name/key': %q", value)
+		}
+	}
+	return &KubernetesSecretRef{
+		Namespace: fields[0],
+		Name:      fields[1],
+		Key:       fields[2],
+	}, nil
+}
+
+func (r KubernetesSecretRef) String() string {
+	return fmt.Sprintf("%s/%s/%s", r.Namespace, r.Name, r.Key)
+}
+
+// KubernetesSecret fetches the content of a single key within a Kubernetes Secret. When running within a pod the
+// in-cluster service account credentials are used, otherwise the current context of the kubeconfig file (from
+// KUBECONFIG or ~/.kube/config) is used.
+func KubernetesSecret(ref KubernetesSecretRef) ([]byte, error) {
+	if err := network.Allow(network.Kubernetes, ref.String()); err != nil {
+		return nil, err
+	}
+
+	cfg, err := kubernetesClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to configure kubernetes client: %w", err)
+	}
+	return cfg.secret(ref)
+}
+
+type kubernetesClient struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+type kubernetesSecret struct {
+	Data map[string][]byte `json:"data"`
+}
+
+func (c kubernetesClient) secret(ref KubernetesSecretRef) ([]byte, error) {
+	log.WithFields("secret", ref.String()).Trace("fetching kubernetes secret")
+
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", strings.TrimSuffix(c.server, "/"), url.PathEscape(ref.Namespace), url.PathEscape(ref.Name))
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch kubernetes secret %q: %w", ref.String(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read kubernetes secret %q: %w", ref.String(), err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch kubernetes secret %q: unexpected status %d", ref.String(), resp.StatusCode)
+	}
+
+	var secret kubernetesSecret
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("unable to decode kubernetes secret %q: %w", ref.String(), err)
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in kubernetes secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+
+	return value, nil
+}
+
+func kubernetesClientConfig() (*kubernetesClient, error) {
+	if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" && port != "" {
+		if _, err := os.Stat(inClusterTokenPath); err == nil {
+			return inClusterConfig(host, port)
+		}
+	}
+
+	path := os.Getenv("KUBECONFIG")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine kubeconfig location: %w", err)
+		}
+		path = filepath.Join(home, ".kube", "config")
+	} else {
+		// only the first entry in a list of kubeconfig files is considered
+		path = filepath.SplitList(path)[0]
+	}
+
+	return kubeconfigClientConfig(path)
+}
+
+func inClusterConfig(host, port string) (*kubernetesClient, error) {
+	log.Trace("using in-cluster kubernetes credentials")
+
+	token, err := os.ReadFile(inClusterTokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account token: %w", err)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	ca, err := os.ReadFile(inClusterCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account CA: %w", err)
+	}
+
+	if tlsConfig.RootCAs, err = certPool(ca); err != nil {
+		return nil, err
+	}
+
+	return &kubernetesClient{
+		server: "https://" + net.JoinHostPort(host, port),
+		token:  strings.TrimSpace(string(token)),
+		client: newKubernetesHTTPClient(tlsConfig),
+	}, nil
+}
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Exec                  interface{} `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+//nolint:funlen,gocognit
+func kubeconfigClientConfig(path string) (*kubernetesClient, error) {
+	log.WithFields("path", path).Trace("using kubeconfig kubernetes credentials")
+
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read kubeconfig: %w", err)
+	}
+
+	var cfg kubeconfig
+	if err := yaml.Unmarshal(by, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse kubeconfig: %w", err)
+	}
+
+	var clusterName, userName string
+	for _, c := range cfg.Contexts {
+		if c.Name == cfg.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			break
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("unable to find current context %q in kubeconfig", cfg.CurrentContext)
+	}
+
+	baseDir := filepath.Dir(path)
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	client := &kubernetesClient{}
+
+	var foundCluster bool
+	for _, c := range cfg.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		foundCluster = true
+		client.server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify //nolint:gosec // this is an explicit user choice
+
+		ca, err := kubeconfigData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read cluster certificate authority: %w", err)
+		}
+		if len(ca) > 0 {
+			if tlsConfig.RootCAs, err = certPool(ca); err != nil {
+				return nil, err
+			}
+		}
+		break
+	}
+	if !foundCluster || client.server == "" {
+		return nil, fmt.Errorf("unable to find cluster %q in kubeconfig", clusterName)
+	}
+
+	for _, u := range cfg.Users {
+		if u.Name != userName {
+			continue
+		}
+
+		if u.User.Exec != nil {
+			return nil, fmt.Errorf("kubeconfig exec credential plugins are not supported (user %q)", userName)
+		}
+
+		client.token = u.User.Token
+		if client.token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(resolvePath(u.User.TokenFile, baseDir))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read user token file: %w", err)
+			}
+			client.token = strings.TrimSpace(string(token))
+		}
+
+		certPEM, err := kubeconfigData(u.User.ClientCertificateData, u.User.ClientCertificate, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read user client certificate: %w", err)
+		}
+		keyPEM, err := kubeconfigData(u.User.ClientKeyData, u.User.ClientKey, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read user client key: %w", err)
+		}
+		if len(certPEM) > 0 && len(keyPEM) > 0 {
+			pair, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load user client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		break
+	}
+
+	client.client = newKubernetesHTTPClient(tlsConfig)
+
+	return client, nil
+}
+
+func kubeconfigData(data, path, baseDir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path != "" {
+		return os.ReadFile(resolvePath(path, baseDir))
+	}
+	return nil, nil
+}
+
+func resolvePath(path, baseDir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+func certPool(pemBytes []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("no valid CA certificates found")
+	}
+	return pool, nil
+}
+
+func newKubernetesHTTPClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+}
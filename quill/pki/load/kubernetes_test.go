@@ -0,0 +1,111 @@
+package load
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKubernetesSecretRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *KubernetesSecretRef
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "with prefix",
+			value: "k8s:build/signing/cert.p12",
+			want:  &KubernetesSecretRef{Namespace: "build", Name: "signing", Key: "cert.p12"},
+		},
+		{
+			name:  "without prefix",
+			value: "build/signing/key.pem",
+			want:  &KubernetesSecretRef{Namespace: "build", Name: "signing", Key: "key.pem"},
+		},
+		{
+			name:    "missing key",
+			value:   "k8s:build/signing",
+			wantErr: require.Error,
+		},
+		{
+			name:    "empty field",
+			value:   "k8s:build//key.pem",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseKubernetesSecretRef(tt.value)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKubernetesSecret_kubeconfig(t *testing.T) {
+	content := []byte{0x30, 0x82, 0x01, 0x00, 0xff}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/namespaces/build/secrets/signing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"kind":"Secret","data":{"cert.p12":%q}}`, base64.StdEncoding.EncodeToString(content))
+	}))
+	t.Cleanup(server.Close)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	kubeconfigPath := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfigPath, []byte(fmt.Sprintf(`
+apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context:
+    cluster: test-cluster
+    user: test-user
+clusters:
+- name: test-cluster
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: test-user
+  user:
+    token: s3cr3t
+`, server.URL, base64.StdEncoding.EncodeToString(ca))), 0600))
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", kubeconfigPath)
+
+	got, err := BytesFromFileOrEnv("k8s:build/signing/cert.p12")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	_, err = BytesFromFileOrEnv("k8s:build/signing/missing.p12")
+	require.Error(t, err)
+
+	_, err = BytesFromFileOrEnv("k8s:other/signing/cert.p12")
+	require.Error(t, err)
+}
@@ -17,6 +17,9 @@ type P12Contents struct {
 	PrivateKey   crypto.PrivateKey
 	Certificate  *x509.Certificate
 	Certificates []*x509.Certificate
+	// PrivateKeys contains every private key found within the P12 file (including PrivateKey). This is only
+	// larger than one when the P12 file holds multiple identities.
+	PrivateKeys []crypto.PrivateKey
 }
 
 func P12(path, password string) (*P12Contents, error) {
@@ -25,7 +28,7 @@ func P12(path, password string) (*P12Contents, error) {
 		return nil, fmt.Errorf("unable to read p12 bytes: %w", err)
 	}
 
-	key, cert, certs, err := pkcs12.DecodeChain(by, password)
+	contents, err := decodeP12(by, password)
 	if err != nil {
 		if errors.Is(err, pkcs12.ErrIncorrectPassword) && password == "" {
 			log.Debug("p12 file requires a password but none provided")
@@ -34,9 +37,150 @@ func P12(path, password string) (*P12Contents, error) {
 		return nil, fmt.Errorf("unable to decode p12 file: %w", err)
 	}
 
+	return contents, nil
+}
+
+// P12WithPassphrase reads the P12 file at the given path, only consulting the given provider for a password if the
+// file cannot be decoded without one.
+func P12WithPassphrase(path string, provider PassphraseProvider) (*P12Contents, error) {
+	by, err := BytesFromFileOrEnv(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read p12 bytes: %w", err)
+	}
+
+	return P12FromBytes(by, provider)
+}
+
+// P12FromBytes decodes the given (DER encoded) P12 content, only consulting the given provider for a password if the
+// content cannot be decoded without one.
+func P12FromBytes(by []byte, provider PassphraseProvider) (*P12Contents, error) {
+	contents, err := decodeP12(by, "")
+	if err == nil {
+		return contents, nil
+	}
+
+	if !errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return nil, fmt.Errorf("unable to decode p12 file: %w", err)
+	}
+
+	if provider == nil {
+		log.Debug("p12 file requires a password but no passphrase provider given")
+		return nil, ErrNeedPassword
+	}
+
+	password, err := provider.Passphrase("Enter P12 password:")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get p12 password: %w", err)
+	}
+
+	contents, err = decodeP12(by, password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode p12 file: %w", err)
+	}
+
+	return contents, nil
+}
+
+func decodeP12(by []byte, password string) (*P12Contents, error) {
+	key, cert, certs, err := pkcs12.DecodeChain(by, password)
+	if err != nil {
+		if !errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			// this may be a P12 with several identities, which DecodeChain does not support
+			if contents, bagErr := decodeP12Bags(by, password); bagErr == nil {
+				return contents, nil
+			}
+		}
+		return nil, err
+	}
+
+	if err := CheckSigningKey(key); err != nil {
+		return nil, err
+	}
+
 	return &P12Contents{
 		PrivateKey:   key,
 		Certificate:  cert,
 		Certificates: certs,
+		PrivateKeys:  []crypto.PrivateKey{key},
 	}, nil
 }
+
+// decodeP12Bags decodes every key and certificate bag within the P12 content. The first key is used as the primary
+// private key and the first certificate matching that key as the primary certificate.
+func decodeP12Bags(by []byte, password string) (*P12Contents, error) {
+	blocks, err := pkcs12.ToPEM(by, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents P12Contents
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse certificate from p12: %w", err)
+			}
+			certs = append(certs, cert)
+		case "PRIVATE KEY":
+			key, err := parseP12PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			if err := CheckSigningKey(key); err != nil {
+				return nil, err
+			}
+			contents.PrivateKeys = append(contents.PrivateKeys, key)
+		}
+	}
+
+	if len(contents.PrivateKeys) == 0 {
+		return nil, fmt.Errorf("no private key found in p12")
+	}
+	contents.PrivateKey = contents.PrivateKeys[0]
+
+	for _, cert := range certs {
+		if contents.Certificate == nil && PublicKeyMatches(contents.PrivateKey, cert) {
+			contents.Certificate = cert
+			continue
+		}
+		contents.Certificates = append(contents.Certificates, cert)
+	}
+
+	if contents.Certificate == nil {
+		return nil, fmt.Errorf("no certificate found in p12 for the private key")
+	}
+
+	return &contents, nil
+}
+
+func parseP12PrivateKey(by []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(by); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(by); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(by)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key from p12: %w", err)
+	}
+	return key, nil
+}
+
+// PublicKeyMatches indicates if the given certificate is for the public half of the given private key.
+func PublicKeyMatches(key crypto.PrivateKey, cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+	return pub.Equal(cert.PublicKey)
+}
@@ -0,0 +1,97 @@
+package load
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/anchore/quill/internal/log"
+)
+
+// PassphraseProvider supplies the password needed to decrypt signing material (P12 files, encrypted PEM keys,
+// hardware tokens, etc.). The provider is only consulted when the material is actually encrypted, so interactive
+// implementations will not prompt unnecessarily.
+type PassphraseProvider interface {
+	Passphrase(prompt string) (string, error)
+}
+
+// PassphraseFunc adapts an ordinary function (such as an interactive prompt) into a PassphraseProvider.
+type PassphraseFunc func(prompt string) (string, error)
+
+func (f PassphraseFunc) Passphrase(prompt string) (string, error) {
+	return f(prompt)
+}
+
+// StaticPassphrase is a PassphraseProvider that always returns the same (already known) password.
+type StaticPassphrase string
+
+func (s StaticPassphrase) Passphrase(_ string) (string, error) {
+	return string(s), nil
+}
+
+// EnvPassphrase is a PassphraseProvider that reads the password from the given environment variable.
+type EnvPassphrase string
+
+func (e EnvPassphrase) Passphrase(_ string) (string, error) {
+	log.WithFields("var", string(e)).Trace("reading passphrase from environment")
+	value, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("no passphrase found in environment variable %q", string(e))
+	}
+	return value, nil
+}
+
+// FilePassphrase is a PassphraseProvider that reads the password from the given file (a single trailing newline
+// is ignored).
+type FilePassphrase string
+
+func (f FilePassphrase) Passphrase(_ string) (string, error) {
+	log.WithFields("path", string(f)).Trace("reading passphrase from file")
+	by, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("unable to read passphrase file: %w", err)
+	}
+	return trimNewline(string(by)), nil
+}
+
+// CommandPassphrase is a PassphraseProvider that runs an external command (e.g. a secret manager CLI) and uses
+// its standard output as the password (a single trailing newline is ignored).
+type CommandPassphrase []string
+
+func (c CommandPassphrase) Passphrase(_ string) (string, error) {
+	if len(c) == 0 {
+		return "", fmt.Errorf("no passphrase command provided")
+	}
+	log.WithFields("command", c[0]).Trace("reading passphrase from command")
+	cmd := exec.Command(c[0], c[1:]...) //nolint:gosec // running a user-provided command is the intent
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to run passphrase command %q: %w", c[0], err)
+	}
+	return trimNewline(string(out)), nil
+}
+
+// NewPassphraseProvider creates a PassphraseProvider from a user-facing value. The value may be:
+//   - "env:VAR_NAME" to read the password from an environment variable
+//   - "file:/path/to/file" to read the password from a file
+//   - "cmd:command args..." to read the password from the output of a command
+//   - anything else is taken as the password itself
+func NewPassphraseProvider(value string) PassphraseProvider {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return EnvPassphrase(strings.TrimPrefix(value, "env:"))
+	case strings.HasPrefix(value, "file:"):
+		return FilePassphrase(strings.TrimPrefix(value, "file:"))
+	case strings.HasPrefix(value, "cmd:"):
+		return CommandPassphrase(strings.Fields(strings.TrimPrefix(value, "cmd:")))
+	default:
+		return StaticPassphrase(value)
+	}
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
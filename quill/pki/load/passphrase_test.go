@@ -0,0 +1,91 @@
+package load
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPassphraseProvider(t *testing.T) {
+	t.Setenv("QUILL_TEST_PASSPHRASE", "from-env")
+
+	passFile := filepath.Join(t.TempDir(), "pass.txt")
+	require.NoError(t, os.WriteFile(passFile, []byte("from-file\n"), 0600))
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "literal password",
+			value: "5w0rdf15h",
+			want:  "5w0rdf15h",
+		},
+		{
+			name:  "empty password",
+			value: "",
+			want:  "",
+		},
+		{
+			name:  "from environment",
+			value: "env:QUILL_TEST_PASSPHRASE",
+			want:  "from-env",
+		},
+		{
+			name:    "missing environment variable",
+			value:   "env:QUILL_TEST_PASSPHRASE_DOES_NOT_EXIST",
+			wantErr: require.Error,
+		},
+		{
+			name:  "from file",
+			value: "file:" + passFile,
+			want:  "from-file",
+		},
+		{
+			name:    "missing file",
+			value:   "file:" + filepath.Join(t.TempDir(), "missing.txt"),
+			wantErr: require.Error,
+		},
+		{
+			name:  "from command",
+			value: "cmd:echo from-command",
+			want:  "from-command",
+		},
+		{
+			name:    "empty command",
+			value:   "cmd:",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := NewPassphraseProvider(tt.value).Passphrase("prompt")
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPassphraseFunc(t *testing.T) {
+	var gotPrompt string
+	p := PassphraseFunc(func(prompt string) (string, error) {
+		gotPrompt = prompt
+		return "secret", nil
+	})
+
+	got, err := p.Passphrase("Enter P12 password:")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got)
+	assert.Equal(t, "Enter P12 password:", gotPrompt)
+}
@@ -0,0 +1,200 @@
+package load
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"  //nolint:gosec // 3DES is only supported for decrypting existing (older) keys
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is the PBKDF2 default PRF (RFC 8018)
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3   = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// ErrUnsupportedPKCS8Encryption indicates that an encrypted PKCS#8 key uses an encryption scheme that is not
+// supported (only PBES2 with PBKDF2 or scrypt and AES-CBC or 3DES-CBC is supported).
+var ErrUnsupportedPKCS8Encryption = errors.New("unsupported PKCS#8 encryption scheme")
+
+// encryptedPrivateKeyInfo is defined in RFC 5208 section 6
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params is defined in RFC 8018 appendix A.4
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params is defined in RFC 8018 appendix A.2
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// scryptParams is defined in RFC 7914 section 7.1
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// DecryptPKCS8PrivateKey decrypts and parses a DER encoded (PBES2) encrypted PKCS#8 private key, as emitted by
+// default by modern versions of OpenSSL (e.g. "openssl pkcs8 -topk8" or "openssl genpkey -aes256").
+func DecryptPKCS8PrivateKey(der []byte, password []byte) (crypto.PrivateKey, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("unable to parse encrypted PKCS#8 key: %w", err)
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPKCS8Encryption, info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("unable to parse PBES2 parameters: %w", err)
+	}
+
+	block, keyLen, iv, err := pbes2Cipher(params.EncryptionScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pbes2Key(params.KeyDerivationFunc, password, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := block(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != c.BlockSize() {
+		return nil, fmt.Errorf("encrypted PKCS#8 key has an invalid IV length")
+	}
+
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%c.BlockSize() != 0 {
+		return nil, fmt.Errorf("encrypted PKCS#8 key has an invalid length")
+	}
+
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	plaintext, err = unpad(plaintext, c.BlockSize())
+	if err != nil {
+		// a bad padding is almost certainly the result of the wrong password
+		return nil, fmt.Errorf("unable to decrypt PKCS#8 key (incorrect password?): %w", err)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse decrypted PKCS#8 key (incorrect password?): %w", err)
+	}
+	return privateKey, nil
+}
+
+func pbes2Cipher(scheme pkix.AlgorithmIdentifier) (func([]byte) (cipher.Block, error), int, []byte, error) {
+	var iv []byte
+	if _, err := asn1.Unmarshal(scheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, 0, nil, fmt.Errorf("unable to parse encryption IV: %w", err)
+	}
+
+	switch {
+	case scheme.Algorithm.Equal(oidAES128CBC):
+		return aes.NewCipher, 16, iv, nil
+	case scheme.Algorithm.Equal(oidAES192CBC):
+		return aes.NewCipher, 24, iv, nil
+	case scheme.Algorithm.Equal(oidAES256CBC):
+		return aes.NewCipher, 32, iv, nil
+	case scheme.Algorithm.Equal(oidDESEDE3):
+		return des.NewTripleDESCipher, 24, iv, nil
+	}
+	return nil, 0, nil, fmt.Errorf("%w: cipher %s", ErrUnsupportedPKCS8Encryption, scheme.Algorithm)
+}
+
+func pbes2Key(kdf pkix.AlgorithmIdentifier, password []byte, keyLen int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("unable to parse PBKDF2 parameters: %w", err)
+		}
+		prf, err := pbkdf2PRF(params.PRF)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keyLen, prf), nil
+
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("unable to parse scrypt parameters: %w", err)
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLen)
+	}
+	return nil, fmt.Errorf("%w: key derivation function %s", ErrUnsupportedPKCS8Encryption, kdf.Algorithm)
+}
+
+func pbkdf2PRF(prf pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(prf.Algorithm) == 0, prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA224):
+		return sha256.New224, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w: PBKDF2 PRF %s", ErrUnsupportedPKCS8Encryption, prf.Algorithm)
+}
+
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty plaintext")
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-n], nil
+}
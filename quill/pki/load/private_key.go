@@ -2,6 +2,8 @@ package load
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -11,12 +13,26 @@ import (
 )
 
 func PrivateKey(path string, password string) (crypto.PrivateKey, error) {
+	return PrivateKeyWithPassphrase(path, StaticPassphrase(password))
+}
+
+// PrivateKeyWithPassphrase reads the PEM encoded private key at the given path, only consulting the given provider
+// for a password if the key is encrypted.
+func PrivateKeyWithPassphrase(path string, provider PassphraseProvider) (crypto.PrivateKey, error) {
 	log.Debug("loading private key")
 
 	b, err := BytesFromFileOrEnv(path)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read private key: %w", err)
 	}
+
+	return PrivateKeyFromPEM(b, provider)
+}
+
+// PrivateKeyFromPEM parses the given PEM encoded private key, only consulting the given provider for a password if
+// the key is encrypted.
+func PrivateKeyFromPEM(b []byte, provider PassphraseProvider) (crypto.PrivateKey, error) {
+	var err error
 	pemObj, _ := pem.Decode(b)
 
 	if pemObj == nil {
@@ -24,10 +40,14 @@ func PrivateKey(path string, password string) (crypto.PrivateKey, error) {
 	}
 
 	switch pemObj.Type {
-	case "RSA PRIVATE KEY", "PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+	case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
 		// pass
 	default:
-		return nil, fmt.Errorf("RSA private key is of the wrong type: %q", pemObj.Type)
+		return nil, fmt.Errorf("private key is of the wrong type: %q", pemObj.Type)
+	}
+
+	if pemObj.Type == "ENCRYPTED PRIVATE KEY" {
+		return encryptedPKCS8PrivateKey(pemObj.Bytes, provider)
 	}
 
 	var privPemBytes []byte
@@ -44,6 +64,16 @@ func PrivateKey(path string, password string) (crypto.PrivateKey, error) {
 
 		log.Trace("decrypting private key")
 
+		if provider == nil {
+			return nil, ErrNeedPassword
+		}
+
+		var password string
+		password, err = provider.Passphrase("Enter private key password:")
+		if err != nil {
+			return nil, fmt.Errorf("unable to get private key password: %w", err)
+		}
+
 		//nolint: staticcheck // we have no other alternatives
 		privPemBytes, err = x509.DecryptPEMBlock(pemObj, []byte(password))
 		if err != nil {
@@ -56,15 +86,57 @@ func PrivateKey(path string, password string) (crypto.PrivateKey, error) {
 
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParsePKCS1PrivateKey(privPemBytes); err != nil {
-		if parsedKey, err = x509.ParsePKCS8PrivateKey(privPemBytes); err != nil {
-			return nil, fmt.Errorf("unable to parse RSA private key: %w", err)
+		if parsedKey, err = x509.ParseECPrivateKey(privPemBytes); err != nil {
+			if parsedKey, err = x509.ParsePKCS8PrivateKey(privPemBytes); err != nil {
+				return nil, fmt.Errorf("unable to parse private key: %w", err)
+			}
 		}
 	}
 
-	privateKey, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("unable to find RSA private key after parsing: %w", err)
+	if err := CheckSigningKey(parsedKey); err != nil {
+		return nil, err
+	}
+
+	return parsedKey, nil
+}
+
+func encryptedPKCS8PrivateKey(der []byte, provider PassphraseProvider) (crypto.PrivateKey, error) {
+	log.Trace("decrypting PKCS#8 private key")
+
+	if provider == nil {
+		return nil, ErrNeedPassword
+	}
+
+	password, err := provider.Passphrase("Enter private key password:")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get private key password: %w", err)
+	}
+
+	key, err := DecryptPKCS8PrivateKey(der, []byte(password))
+	if err != nil {
+		return nil, err
 	}
 
-	return privateKey, nil
+	if err := CheckSigningKey(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// CheckSigningKey ensures the given private key is usable for code signing: either an RSA key or an ECDSA key on
+// the P-256 or P-384 curve.
+func CheckSigningKey(key crypto.PrivateKey) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384():
+			return nil
+		}
+		return fmt.Errorf("unsupported ECDSA curve %q (only P-256 and P-384 are supported)", k.Curve.Params().Name)
+	default:
+		return fmt.Errorf("unsupported private key type %T (only RSA and ECDSA keys are supported)", key)
+	}
 }
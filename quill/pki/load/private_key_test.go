@@ -1,6 +1,13 @@
 package load
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +46,77 @@ func Test_loadPrivateKeyFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestPrivateKeyFromPEM_keyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	newECKey := func(curve elliptic.Curve) *ecdsa.PrivateKey {
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		return k
+	}
+
+	sec1 := func(k *ecdsa.PrivateKey) []byte {
+		by, err := x509.MarshalECPrivateKey(k)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: by})
+	}
+
+	pkcs8 := func(k interface{}) []byte {
+		by, err := x509.MarshalPKCS8PrivateKey(k)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: by})
+	}
+
+	tests := []struct {
+		name    string
+		pem     []byte
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "RSA PKCS1",
+			pem:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		},
+		{
+			name: "RSA PKCS8",
+			pem:  pkcs8(rsaKey),
+		},
+		{
+			name: "ECDSA P-256 SEC1",
+			pem:  sec1(newECKey(elliptic.P256())),
+		},
+		{
+			name: "ECDSA P-384 SEC1",
+			pem:  sec1(newECKey(elliptic.P384())),
+		},
+		{
+			name: "ECDSA P-256 PKCS8",
+			pem:  pkcs8(newECKey(elliptic.P256())),
+		},
+		{
+			name:    "ECDSA P-521 is not supported",
+			pem:     sec1(newECKey(elliptic.P521())),
+			wantErr: require.Error,
+		},
+		{
+			name:    "wrong PEM type",
+			pem:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("nope")}),
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := PrivateKeyFromPEM(tt.pem, nil)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			_, ok := got.(crypto.Signer)
+			assert.True(t, ok)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+)
+
+// Padding is the padding scheme of RSA signatures.
+type Padding string
+
+const (
+	// PaddingPKCS1v15 is RSASSA-PKCS1-v1_5, which is what codesign uses (this is the default).
+	PaddingPKCS1v15 Padding = "pkcs1v15"
+
+	// PaddingPSS is RSASSA-PSS (with MGF1 over the signature digest and a salt as long as the digest).
+	PaddingPSS Padding = "pss"
+)
+
+// Paddings is every supported Padding.
+var Paddings = []Padding{PaddingPKCS1v15, PaddingPSS}
+
+// signatureDigests are the supported digest algorithms of the CMS signature (by user-facing name).
+var signatureDigests = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// SignatureAlgorithm selects how the CMS signature is made. The zero value matches codesign: a SHA-256 digest, and
+// PKCS #1 v1.5 padding for RSA keys.
+type SignatureAlgorithm struct {
+	// Digest is the digest algorithm of the signed attributes and the signed content (crypto.SHA256 when unset).
+	Digest crypto.Hash
+	// Padding is the padding scheme for RSA keys (PaddingPKCS1v15 when unset), it does not apply to ECDSA keys.
+	Padding Padding
+}
+
+// ParseSignatureAlgorithm parses the user-facing digest algorithm name (sha256, sha384, or sha512) and RSA padding
+// scheme (an empty value is the default).
+func ParseSignatureAlgorithm(digest, padding string) (SignatureAlgorithm, error) {
+	var alg SignatureAlgorithm
+
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(digest), "-", ""))
+	if name != "" {
+		h, ok := signatureDigests[name]
+		if !ok {
+			return alg, fmt.Errorf("unsupported signature digest algorithm %q (must be one of sha256, sha384, or sha512)", digest)
+		}
+		alg.Digest = h
+	}
+
+	switch p := Padding(strings.ToLower(strings.TrimSpace(padding))); p {
+	case "", PaddingPKCS1v15:
+	case PaddingPSS:
+		alg.Padding = p
+	default:
+		return alg, fmt.Errorf("invalid RSA padding %q (must be one of %s)", padding, Paddings)
+	}
+
+	return alg, nil
+}
+
+// Hash returns the digest algorithm to sign with.
+func (a SignatureAlgorithm) Hash() crypto.Hash {
+	if a.Digest == 0 {
+		return crypto.SHA256
+	}
+	return a.Digest
+}
+
+// PSS indicates RSA keys sign with RSASSA-PSS.
+func (a SignatureAlgorithm) PSS() bool {
+	return a.Padding == PaddingPSS
+}
+
+// SignerOpts returns the options to sign the given digest with for a key with the given public key.
+func (a SignatureAlgorithm) SignerOpts(pub crypto.PublicKey) crypto.SignerOpts {
+	if _, ok := pub.(*rsa.PublicKey); ok && a.PSS() {
+		return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.Hash()}
+	}
+	return a.Hash()
+}
+
+// String describes the algorithm (e.g. "sha384 (pss)").
+func (a SignatureAlgorithm) String() string {
+	name := strings.ToLower(strings.ReplaceAll(a.Hash().String(), "-", ""))
+	if a.PSS() {
+		return name + " (" + string(PaddingPSS) + ")"
+	}
+	return name
+}
+
+// Validate checks the algorithm is supported for the given public key.
+func (a SignatureAlgorithm) Validate(pub crypto.PublicKey) error {
+	var supported bool
+	for _, h := range signatureDigests {
+		supported = supported || h == a.Hash()
+	}
+	if !supported {
+		return fmt.Errorf("unsupported signature digest algorithm %s", a.Hash())
+	}
+	if _, ok := pub.(*rsa.PublicKey); !ok && a.PSS() {
+		return fmt.Errorf("%s padding only applies to RSA keys (the signing key is %T)", PaddingPSS, pub)
+	}
+	return nil
+}
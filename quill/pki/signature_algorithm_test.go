@@ -0,0 +1,81 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignatureAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		digest  string
+		padding string
+		want    SignatureAlgorithm
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:    "explicit defaults",
+			digest:  "sha256",
+			padding: "pkcs1v15",
+			want:    SignatureAlgorithm{Digest: crypto.SHA256},
+		},
+		{
+			name:    "sha384 with pss",
+			digest:  "SHA-384",
+			padding: "PSS",
+			want:    SignatureAlgorithm{Digest: crypto.SHA384, Padding: PaddingPSS},
+		},
+		{
+			name:    "weak digest",
+			digest:  "sha1",
+			wantErr: require.Error,
+		},
+		{
+			name:    "unknown padding",
+			padding: "oaep",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseSignatureAlgorithm(tt.digest, tt.padding)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSignatureAlgorithm_SignerOpts(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, crypto.SHA256, SignatureAlgorithm{}.SignerOpts(rsaKey.Public()))
+
+	pss := SignatureAlgorithm{Digest: crypto.SHA384, Padding: PaddingPSS}
+	assert.Equal(t, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA384}, pss.SignerOpts(rsaKey.Public()))
+	require.NoError(t, pss.Validate(rsaKey.Public()))
+
+	// PSS does not apply to ECDSA keys
+	assert.Equal(t, crypto.SHA384, pss.SignerOpts(ecKey.Public()))
+	require.Error(t, pss.Validate(ecKey.Public()))
+	require.NoError(t, SignatureAlgorithm{Digest: crypto.SHA512}.Validate(ecKey.Public()))
+	require.Error(t, SignatureAlgorithm{Digest: crypto.SHA1}.Validate(ecKey.Public()))
+}
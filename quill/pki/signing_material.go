@@ -1,47 +1,111 @@
 package pki
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/x509"
 	"encoding/asn1"
 	"fmt"
+	"io"
 
-	"github.com/anchore/quill/quill/pki/apple"
 	"github.com/anchore/quill/quill/pki/certchain"
 	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/timestamp"
 )
 
+// SigningMaterial is the key and certificate chain to sign with, along with the settings of the CMS signature. The
+// certificate chain and key are parsed once when the material is created and signing only reads from it, so the same
+// material may be shared by concurrent Sign calls (e.g. signing many binaries without reloading a P12 for each one) as
+// long as its fields are not modified while in use. Settings are meant to be changed on a copy (see the With methods of
+// quill.SigningConfig), which keeps sharing the parsed key and chain.
 type SigningMaterial struct {
-	Signer          crypto.Signer
-	Certs           []*x509.Certificate
-	TimestampServer string
+	Signer         crypto.Signer
+	Certs          []*x509.Certificate
+	Timestamp      timestamp.Config
+	ChainEmbedding ChainEmbedding
+	ExpiryPolicy   ExpiryPolicy
+	SigningTime    SigningTime
+	// SignatureAlgorithm selects the digest and RSA padding of the CMS signature (the zero value matches codesign).
+	SignatureAlgorithm SignatureAlgorithm
+
+	// signingCert is the certificate of the Signer within Certs, found when the material was created.
+	signingCert *x509.Certificate
+}
+
+// NewSigningMaterial creates signing material for the given key and certificate chain (in any order), finding the
+// certificate of the key up front so it is not searched for on every signature.
+func NewSigningMaterial(signer crypto.Signer, certs []*x509.Certificate) *SigningMaterial {
+	sm := &SigningMaterial{
+		Signer: signer,
+		Certs:  certchain.Sort(certs),
+	}
+	sm.signingCert, _ = sm.findSigningCertificate()
+	return sm
 }
 
 func NewSigningMaterialFromPEMs(certFile, privateKeyPath, password string, failWithoutFullChain bool) (*SigningMaterial, error) {
-	var certs []*x509.Certificate
-	var privateKey crypto.PrivateKey
-	var err error
+	return NewSigningMaterialFromPEMsWithPassphrase(certFile, privateKeyPath, load.StaticPassphrase(password), failWithoutFullChain)
+}
 
-	switch {
-	case certFile != "" && privateKeyPath != "":
-		certs, err = load.Certificates(certFile)
-		if err != nil {
-			return nil, err
-		}
+// NewSigningMaterialFromPEMsWithPassphrase is like NewSigningMaterialFromPEMs, but the password for an encrypted
+// private key is only requested from the given provider when it is needed.
+func NewSigningMaterialFromPEMsWithPassphrase(certFile, privateKeyPath string, provider load.PassphraseProvider, failWithoutFullChain bool) (*SigningMaterial, error) {
+	if certFile == "" || privateKeyPath == "" {
+		return nil, nil
+	}
 
-		if len(certs) > 0 {
-			if err := certchain.VerifyForCodeSigning(certs, failWithoutFullChain); err != nil {
-				return nil, err
-			}
-		}
+	if certFile == load.StdinPath && privateKeyPath == load.StdinPath {
+		return nil, fmt.Errorf("only one of the certificate or private key may be read from stdin")
+	}
 
-		privateKey, err = load.PrivateKey(privateKeyPath, password)
-		if err != nil {
+	certPEM, err := load.BytesFromFileOrEnv(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing certificate: %w", err)
+	}
+
+	keyPEM, err := load.BytesFromFileOrEnv(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+
+	return newSigningMaterialFromPEMBytes(certPEM, keyPEM, provider, failWithoutFullChain)
+}
+
+// NewSigningMaterialFromPEMReaders is like NewSigningMaterialFromPEMsWithPassphrase, but reads the PEM encoded
+// certificate chain and private key from the given readers (e.g. stdin or a secret manager response).
+func NewSigningMaterialFromPEMReaders(certReader, privateKeyReader io.Reader, provider load.PassphraseProvider, failWithoutFullChain bool) (*SigningMaterial, error) {
+	if certReader == nil || privateKeyReader == nil {
+		return nil, fmt.Errorf("both a certificate and private key reader are required")
+	}
+
+	certPEM, err := io.ReadAll(certReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing certificate: %w", err)
+	}
+
+	keyPEM, err := io.ReadAll(privateKeyReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+
+	return newSigningMaterialFromPEMBytes(certPEM, keyPEM, provider, failWithoutFullChain)
+}
+
+func newSigningMaterialFromPEMBytes(certPEM, keyPEM []byte, provider load.PassphraseProvider, failWithoutFullChain bool) (*SigningMaterial, error) {
+	certs, err := load.CertificatesFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("no certificates found: %w", err)
+	}
+
+	if len(certs) > 0 {
+		if certs, err = completeChain(leafCertificate(certs), certs, failWithoutFullChain); err != nil {
 			return nil, err
 		}
+	}
 
-	default:
-		return nil, nil
+	privateKey, err := load.PrivateKeyFromPEM(keyPEM, provider)
+	if err != nil {
+		return nil, err
 	}
 
 	signer, ok := privateKey.(crypto.Signer)
@@ -49,10 +113,7 @@ func NewSigningMaterialFromPEMs(certFile, privateKeyPath, password string, failW
 		return nil, fmt.Errorf("unable to derive signer from private key")
 	}
 
-	return &SigningMaterial{
-		Signer: signer,
-		Certs:  certchain.Sort(certs),
-	}, nil
+	return NewSigningMaterial(signer, certs), nil
 }
 
 func NewSigningMaterialFromP12(p12Content load.P12Contents, failWithoutFullChain bool) (*SigningMaterial, error) {
@@ -71,26 +132,12 @@ func NewSigningMaterialFromP12(p12Content load.P12Contents, failWithoutFullChain
 		return nil, fmt.Errorf("unable to derive signer from private key")
 	}
 
-	if len(allCerts) > 0 {
-		if err := certchain.VerifyForCodeSigning(allCerts, failWithoutFullChain); err != nil {
-			store := certchain.NewCollection().WithStores(apple.GetEmbeddedCertStore())
-
-			// verification failed, try again but attempt to find more certs from the embedded certs in quill
-			remainingCerts, err := certchain.Find(store, p12Content.Certificate)
-			if err != nil {
-				return nil, fmt.Errorf("unable to find remaining chain certificates: %w", err)
-			}
-			allCerts = append(allCerts, remainingCerts...)
-			if err := certchain.VerifyForCodeSigning(allCerts, failWithoutFullChain); err != nil {
-				return nil, err
-			}
-		}
+	allCerts, err := completeChain(p12Content.Certificate, allCerts, failWithoutFullChain)
+	if err != nil {
+		return nil, err
 	}
 
-	return &SigningMaterial{
-		Signer: signer,
-		Certs:  certchain.Sort(allCerts),
-	}, nil
+	return NewSigningMaterial(signer, allCerts), nil
 }
 
 func (sm *SigningMaterial) HasCertWithOrg(org string) bool {
@@ -135,3 +182,32 @@ func (sm *SigningMaterial) Leaf() *x509.Certificate {
 
 	return leaf
 }
+
+// SigningCertificate returns the certificate of the signing key within the certificate chain.
+func (sm *SigningMaterial) SigningCertificate() (*x509.Certificate, error) {
+	if sm.signingCert != nil && load.PublicKeyMatches(sm.Signer, sm.signingCert) {
+		return sm.signingCert, nil
+	}
+	// the material was not created with NewSigningMaterial (or the key was replaced since)
+	return sm.findSigningCertificate()
+}
+
+func (sm *SigningMaterial) findSigningCertificate() (*x509.Certificate, error) {
+	if sm.Signer == nil {
+		return nil, fmt.Errorf("no signing key")
+	}
+	pub, err := x509.MarshalPKIXPublicKey(sm.Signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range sm.Certs {
+		certPub, err := x509.MarshalPKIXPublicKey(c.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(pub, certPub) {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no certificate matches the signing key")
+}
@@ -0,0 +1,63 @@
+package pki
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningMaterial_SigningCertificate(t *testing.T) {
+	key, cert := newTestIdentity(t, "signer")
+	otherKey, otherCert := newTestIdentity(t, "other")
+
+	shared := NewSigningMaterial(key, []*x509.Certificate{otherCert, cert})
+
+	replaced := *shared
+	replaced.Signer = otherKey
+
+	tests := []struct {
+		name     string
+		material *SigningMaterial
+		want     *x509.Certificate
+		wantErr  string
+	}{
+		{
+			name:     "resolved on creation",
+			material: shared,
+			want:     cert,
+		},
+		{
+			name:     "created without a constructor",
+			material: &SigningMaterial{Signer: key, Certs: []*x509.Certificate{otherCert, cert}},
+			want:     cert,
+		},
+		{
+			name:     "key replaced after creation",
+			material: &replaced,
+			want:     otherCert,
+		},
+		{
+			name:     "no matching certificate",
+			material: NewSigningMaterial(key, []*x509.Certificate{otherCert}),
+			wantErr:  "no certificate matches the signing key",
+		},
+		{
+			name:     "no key",
+			material: &SigningMaterial{Certs: []*x509.Certificate{cert}},
+			wantErr:  "no signing key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.material.SigningCertificate()
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package pki
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SigningTimeSkew is how far the CMS signing time may be ahead of the secure timestamp (clock skew between the signing
+// host and the timestamp authority).
+const SigningTimeSkew = 5 * time.Minute
+
+// signingTimeNone is the user-facing value omitting the signing time attribute.
+const signingTimeNone = "none"
+
+// SigningTime controls the signing time attribute of the CMS signature. The zero value stamps the current time.
+type SigningTime struct {
+	// Omit leaves out the signing time attribute (the secure timestamp, if any, is then the only record of when the
+	// signature was made).
+	Omit bool
+	// At pins the signing time (e.g. to SOURCE_DATE_EPOCH for reproducible builds), the current time is stamped when
+	// zero.
+	At time.Time
+}
+
+// ParseSigningTime parses the user-facing signing time setting: empty stamps the current time, "none" omits the
+// attribute, otherwise the time is pinned to the given RFC 3339 timestamp or number of seconds since the Unix epoch
+// (as SOURCE_DATE_EPOCH holds).
+func ParseSigningTime(value string) (SigningTime, error) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "":
+		return SigningTime{}, nil
+	case signingTimeNone:
+		return SigningTime{Omit: true}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return SigningTime{At: t.UTC()}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return SigningTime{At: time.Unix(seconds, 0).UTC()}, nil
+	}
+	return SigningTime{}, fmt.Errorf("invalid signing time %q (must be %q, an RFC 3339 timestamp, or seconds since the Unix epoch)", value, signingTimeNone)
+}
+
+// Time returns the signing time to stamp (as of now), false is returned when the attribute is omitted.
+func (st SigningTime) Time(now time.Time) (time.Time, bool) {
+	switch {
+	case st.Omit:
+		return time.Time{}, false
+	case !st.At.IsZero():
+		return st.At.UTC(), true
+	}
+	return now.UTC(), true
+}
+
+// String describes the setting as accepted by ParseSigningTime (empty for the current time).
+func (st SigningTime) String() string {
+	switch {
+	case st.Omit:
+		return signingTimeNone
+	case !st.At.IsZero():
+		return st.At.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+// CheckSigningTime verifies the given signing time is consistent with the signing certificate validity (verifiers
+// evaluate the certificate chain as of the signing time) and, when the signature is timestamped, with the secure
+// timestamp (the signature cannot have been made after it was timestamped).
+func CheckSigningTime(signingTime time.Time, sm SigningMaterial, timestamped time.Time) error {
+	if leaf := sm.Leaf(); leaf != nil && (signingTime.Before(leaf.NotBefore) || signingTime.After(leaf.NotAfter)) {
+		return fmt.Errorf("signing time %s is outside the validity of the signing certificate (%s to %s)",
+			signingTime.Format(time.RFC3339), leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	if !timestamped.IsZero() && signingTime.After(timestamped.Add(SigningTimeSkew)) {
+		return fmt.Errorf("signing time %s is after the secure timestamp %s", signingTime.Format(time.RFC3339), timestamped.Format(time.RFC3339))
+	}
+	return nil
+}
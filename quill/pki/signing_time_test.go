@@ -0,0 +1,136 @@
+package pki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestParseSigningTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    SigningTime
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "current time",
+		},
+		{
+			name:  "omitted",
+			value: "None",
+			want:  SigningTime{Omit: true},
+		},
+		{
+			name:  "RFC 3339",
+			value: "2024-01-15T10:30:00+01:00",
+			want:  SigningTime{At: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:  "seconds since the epoch",
+			value: "1705311000",
+			want:  SigningTime{At: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:    "date only",
+			value:   "2024-01-15",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseSigningTime(tt.value)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			// the setting round trips
+			again, err := ParseSigningTime(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestSigningTime_Time(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	pinned := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	got, ok := SigningTime{}.Time(now)
+	assert.True(t, ok)
+	assert.Equal(t, now, got)
+
+	got, ok = SigningTime{At: pinned}.Time(now)
+	assert.True(t, ok)
+	assert.Equal(t, pinned, got)
+
+	_, ok = SigningTime{Omit: true}.Time(now)
+	assert.False(t, ok)
+}
+
+func TestCheckSigningTime(t *testing.T) {
+	// all certificates are valid from an hour ago for ~23 hours
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+	sm := SigningMaterial{
+		Signer: fixture.LeafKey,
+		Certs:  certchain.Sort(fixture.Chain()),
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		signingTime time.Time
+		timestamped time.Time
+		wantErr     require.ErrorAssertionFunc
+	}{
+		{
+			name:        "not timestamped",
+			signingTime: now,
+		},
+		{
+			name:        "before the timestamp",
+			signingTime: now.Add(-time.Minute),
+			timestamped: now,
+		},
+		{
+			name:        "within the clock skew of the timestamp",
+			signingTime: now.Add(time.Minute),
+			timestamped: now,
+		},
+		{
+			name:        "after the timestamp",
+			signingTime: now.Add(time.Hour),
+			timestamped: now,
+			wantErr:     require.Error,
+		},
+		{
+			name:        "before the certificate validity",
+			signingTime: now.Add(-48 * time.Hour),
+			wantErr:     require.Error,
+		},
+		{
+			name:        "after the certificate validity",
+			signingTime: now.Add(48 * time.Hour),
+			wantErr:     require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			tt.wantErr(t, CheckSigningTime(tt.signingTime, sm, tt.timestamped))
+		})
+	}
+}
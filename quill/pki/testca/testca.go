@@ -0,0 +1,308 @@
+// Package testca mints throwaway certificate authorities and Developer ID-like signing identities. This allows for
+// exercising signing and verification end-to-end without real Apple Developer ID material. Certificates from this
+// package are never trusted by Apple (or by quill's embedded Apple roots).
+package testca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // SHA-1 is only used for deriving subject key identifiers (RFC 5280 section 4.2.1.2)
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	// DefaultTeamID is the team ID used when none is configured.
+	DefaultTeamID = "QUILLTEST1"
+
+	// DefaultName is the developer (organization) name used when none is configured.
+	DefaultName = "Quill Test"
+)
+
+var (
+	// oidUserID is the subject UID attribute, which Apple sets to the team ID on developer certificates
+	oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+	// OIDDeveloperIDApplication marks a leaf certificate as a "Developer ID Application" certificate
+	OIDDeveloperIDApplication = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 13}
+
+	// OIDDeveloperIDInstaller marks a leaf certificate as a "Developer ID Installer" certificate
+	OIDDeveloperIDInstaller = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 14}
+
+	// OIDExtKeyUsageInstaller is the extended key usage of "Developer ID Installer" certificates
+	OIDExtKeyUsageInstaller = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 4, 13}
+
+	// OIDDeveloperIDCA marks an intermediate certificate as a Developer ID certification authority
+	OIDDeveloperIDCA = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 2, 6}
+
+	// asn1Null is the DER encoding of the ASN.1 NULL value (the value used for Apple's marker extensions)
+	asn1Null = []byte{0x05, 0x00}
+)
+
+// Config describes the fixture certificates to generate.
+type Config struct {
+	// TeamID is placed within the leaf OU and UID attributes (as Apple does for Developer ID certificates).
+	TeamID string
+	// Name is the developer name, used for the leaf organization and common name.
+	Name string
+	// KeyType is either "rsa" (default, 2048 bit) or "ecdsa" (P-256) and applies to all generated keys.
+	KeyType string
+	// Validity is how long the certificates are valid for (default 24 hours).
+	Validity time.Duration
+	// NotBefore is the start of the validity period (default one hour ago, allowing for some clock skew).
+	NotBefore time.Time
+}
+
+// Fixture is a root CA, intermediate CA, and leaf signing identity issued by the intermediate.
+type Fixture struct {
+	Root         *x509.Certificate
+	RootKey      crypto.Signer
+	Intermediate *x509.Certificate
+	// IntermediateKey can be used to issue additional leaf certificates (see Fixture.IssueLeaf).
+	IntermediateKey crypto.Signer
+	Leaf            *x509.Certificate
+	LeafKey         crypto.Signer
+
+	cfg Config
+}
+
+// New generates a new root, intermediate, and leaf certificate according to the given configuration.
+func New(cfg Config) (*Fixture, error) {
+	cfg = cfg.withDefaults()
+
+	rootKey, err := newKey(cfg.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := issue(&x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         fmt.Sprintf("%s Root CA", cfg.Name),
+			OrganizationalUnit: []string{fmt.Sprintf("%s Certification Authority", cfg.Name)},
+			Organization:       []string{cfg.Name},
+			Country:            []string{"US"},
+		},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, cfg, rootKey, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create root certificate: %w", err)
+	}
+
+	intermediateKey, err := newKey(cfg.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediate, err := issue(&x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         fmt.Sprintf("%s Developer ID Certification Authority", cfg.Name),
+			OrganizationalUnit: []string{fmt.Sprintf("%s Certification Authority", cfg.Name)},
+			Organization:       []string{cfg.Name},
+			Country:            []string{"US"},
+		},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: OIDDeveloperIDCA, Value: asn1Null},
+		},
+	}, cfg, intermediateKey, root, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create intermediate certificate: %w", err)
+	}
+
+	f := &Fixture{
+		Root:            root,
+		RootKey:         rootKey,
+		Intermediate:    intermediate,
+		IntermediateKey: intermediateKey,
+		cfg:             cfg,
+	}
+
+	f.Leaf, f.LeafKey, err = f.IssueLeaf(cfg.Name, cfg.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// IssueLeaf issues an additional "Developer ID Application" signing certificate from the intermediate CA.
+func (f *Fixture) IssueLeaf(name, teamID string) (*x509.Certificate, crypto.Signer, error) {
+	key, err := newKey(f.cfg.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaf, err := issue(&x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         fmt.Sprintf("Developer ID Application: %s (%s)", name, teamID),
+			OrganizationalUnit: []string{teamID},
+			Organization:       []string{name},
+			Country:            []string{"US"},
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: oidUserID, Value: teamID},
+			},
+		},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: OIDDeveloperIDApplication, Critical: true, Value: asn1Null},
+		},
+	}, f.cfg, key, f.Intermediate, f.IntermediateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create leaf certificate: %w", err)
+	}
+
+	return leaf, key, nil
+}
+
+// IssueInstallerLeaf issues a "Developer ID Installer" signing certificate (for signing installer packages) from the
+// intermediate CA.
+func (f *Fixture) IssueInstallerLeaf(name, teamID string) (*x509.Certificate, crypto.Signer, error) {
+	key, err := newKey(f.cfg.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaf, err := issue(&x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         fmt.Sprintf("Developer ID Installer: %s (%s)", name, teamID),
+			OrganizationalUnit: []string{teamID},
+			Organization:       []string{name},
+			Country:            []string{"US"},
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: oidUserID, Value: teamID},
+			},
+		},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		UnknownExtKeyUsage:    []asn1.ObjectIdentifier{OIDExtKeyUsageInstaller},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: OIDDeveloperIDInstaller, Critical: true, Value: asn1Null},
+		},
+	}, f.cfg, key, f.Intermediate, f.IntermediateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create installer certificate: %w", err)
+	}
+
+	return leaf, key, nil
+}
+
+// Chain returns the leaf, intermediate, and root certificates (in that order).
+func (f *Fixture) Chain() []*x509.Certificate {
+	return []*x509.Certificate{f.Leaf, f.Intermediate, f.Root}
+}
+
+// Roots returns a pool containing only the fixture root certificate (for verification).
+func (f *Fixture) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(f.Root)
+	return pool
+}
+
+// CertificatesPEM returns the PEM encoded leaf, intermediate, and root certificates (in that order).
+func (f *Fixture) CertificatesPEM() []byte {
+	var buf []byte
+	for _, c := range f.Chain() {
+		buf = append(buf, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return buf
+}
+
+// LeafKeyPEM returns the PEM encoded (PKCS#8) leaf private key.
+func (f *Fixture) LeafKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(f.LeafKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// P12 returns a P12 file containing the leaf private key, leaf certificate, and the remaining chain (the same
+// shape as a p12 file that has had "quill p12 attach-chain" run against it).
+func (f *Fixture) P12(password string) ([]byte, error) {
+	by, err := pkcs12.Modern2023.Encode(f.LeafKey, f.Leaf, []*x509.Certificate{f.Intermediate, f.Root}, password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode p12: %w", err)
+	}
+	return by, nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.TeamID == "" {
+		c.TeamID = DefaultTeamID
+	}
+	if c.Name == "" {
+		c.Name = DefaultName
+	}
+	if c.Validity == 0 {
+		c.Validity = 24 * time.Hour
+	}
+	if c.NotBefore.IsZero() {
+		c.NotBefore = time.Now().Add(-time.Hour)
+	}
+	return c
+}
+
+func newKey(keyType string) (crypto.Signer, error) {
+	switch strings.ToLower(keyType) {
+	case "", "rsa":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", keyType)
+}
+
+func issue(template *x509.Certificate, cfg Config, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(pub, &spki); err != nil {
+		return nil, err
+	}
+	ski := sha1.Sum(spki.PublicKey.Bytes) //nolint:gosec // see import
+
+	template.SerialNumber = serial
+	template.NotBefore = cfg.NotBefore
+	template.NotAfter = cfg.NotBefore.Add(cfg.Validity)
+	template.SubjectKeyId = ski[:]
+
+	if parent == nil {
+		// self-signed
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
@@ -0,0 +1,109 @@
+package testca
+
+import (
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantCN   string
+		wantTeam string
+		wantErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:     "defaults",
+			wantCN:   "Developer ID Application: Quill Test (QUILLTEST1)",
+			wantTeam: DefaultTeamID,
+		},
+		{
+			name: "ecdsa with custom team",
+			cfg: Config{
+				TeamID:  "ABCDE12345",
+				Name:    "Example Corp",
+				KeyType: "ecdsa",
+			},
+			wantCN:   "Developer ID Application: Example Corp (ABCDE12345)",
+			wantTeam: "ABCDE12345",
+		},
+		{
+			name: "unsupported key type",
+			cfg: Config{
+				KeyType: "dsa",
+			},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			f, err := New(tt.cfg)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.wantCN, f.Leaf.Subject.CommonName)
+			assert.Equal(t, []string{tt.wantTeam}, f.Leaf.Subject.OrganizationalUnit)
+			assert.Equal(t, f.Intermediate.SubjectKeyId, f.Leaf.AuthorityKeyId)
+			assert.Equal(t, f.Root.SubjectKeyId, f.Intermediate.AuthorityKeyId)
+
+			require.NoError(t, certchain.VerifyForCodeSigning(f.Chain(), true))
+
+			// the P12 must be consumable the same way as one from Apple
+			by, err := f.P12("5w0rdf15h")
+			require.NoError(t, err)
+
+			p12, err := load.P12FromBytes(by, load.StaticPassphrase("5w0rdf15h"))
+			require.NoError(t, err)
+			assert.Equal(t, f.Leaf.Raw, p12.Certificate.Raw)
+			assert.Len(t, p12.Certificates, 2)
+			assert.True(t, load.PublicKeyMatches(p12.PrivateKey, f.Leaf))
+
+			keyPEM, err := f.LeafKeyPEM()
+			require.NoError(t, err)
+			_, err = load.PrivateKeyFromPEM(keyPEM, nil)
+			require.NoError(t, err)
+
+			certs, err := load.CertificatesFromPEM(f.CertificatesPEM())
+			require.NoError(t, err)
+			assert.Len(t, certs, 3)
+		})
+	}
+}
+
+func TestFixture_IssueLeaf(t *testing.T) {
+	f, err := New(Config{})
+	require.NoError(t, err)
+
+	leaf, key, err := f.IssueLeaf("Other Dev", "OTHER00001")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Developer ID Application: Other Dev (OTHER00001)", leaf.Subject.CommonName)
+	assert.True(t, load.PublicKeyMatches(key, leaf))
+	require.NoError(t, leaf.CheckSignatureFrom(f.Intermediate))
+}
+
+func TestFixture_IssueInstallerLeaf(t *testing.T) {
+	f, err := New(Config{})
+	require.NoError(t, err)
+
+	leaf, key, err := f.IssueInstallerLeaf("Other Dev", "OTHER00001")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Developer ID Installer: Other Dev (OTHER00001)", leaf.Subject.CommonName)
+	assert.Equal(t, []asn1.ObjectIdentifier{OIDExtKeyUsageInstaller}, leaf.UnknownExtKeyUsage)
+	assert.True(t, load.PublicKeyMatches(key, leaf))
+	require.NoError(t, leaf.CheckSignatureFrom(f.Intermediate))
+}
@@ -0,0 +1,176 @@
+package testca
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+)
+
+// oidTestTSAPolicy is the TSA policy placed within all issued timestamp tokens
+var oidTestTSAPolicy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+// TSA is an RFC 3161 timestamp authority (served over HTTP) issued by the fixture intermediate CA.
+type TSA struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+
+	chain []*x509.Certificate
+
+	lock  sync.Mutex
+	count int
+	// Status, when non-zero, is returned as the PKI status (with no token) for every request (e.g. 2 for rejection).
+	Status int
+	// TamperImprint issues tokens over a different message imprint than requested (for testing clients).
+	TamperImprint bool
+	// DropNonce issues tokens without the requested nonce (as some TSAs do).
+	DropNonce bool
+	// TamperNonce issues tokens with a different nonce than requested (as a replayed token would have).
+	TamperNonce bool
+	// Time, when non-zero, is the time of every issued token (the current time otherwise).
+	Time time.Time
+}
+
+// NewTSA issues a timestamping certificate from the intermediate CA and returns a TSA using it.
+func (f *Fixture) NewTSA() (*TSA, error) {
+	key, err := newKey(f.cfg.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := issue(&x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("%s Timestamp Authority", f.cfg.Name),
+			Organization: []string{f.cfg.Name},
+			Country:      []string{"US"},
+		},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+	}, f.cfg, key, f.Intermediate, f.IntermediateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create timestamp authority certificate: %w", err)
+	}
+
+	return &TSA{
+		Certificate: cert,
+		Key:         key,
+		chain:       []*x509.Certificate{cert, f.Intermediate},
+	}, nil
+}
+
+// Requests returns the number of timestamp requests served so far.
+func (t *TSA) Requests() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.count
+}
+
+func (t *TSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.lock.Lock()
+	t.count++
+	t.lock.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req timestamp.Request
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := t.respond(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	_, _ = w.Write(resp)
+}
+
+func (t *TSA) respond(req timestamp.Request) ([]byte, error) {
+	if t.Status != 0 {
+		return asn1.Marshal(timestamp.Response{
+			Status: timestamp.PKIStatusInfo{Status: t.Status},
+		})
+	}
+
+	imprint := req.MessageImprint
+	if t.TamperImprint {
+		imprint.HashedMessage = append([]byte{}, imprint.HashedMessage...)
+		imprint.HashedMessage[0] ^= 0xff
+	}
+
+	nonce := req.Nonce
+	switch {
+	case t.DropNonce:
+		nonce = nil
+	case t.TamperNonce && nonce != nil:
+		nonce = new(big.Int).Add(nonce, big.NewInt(1))
+	}
+
+	genTime := time.Now()
+	if !t.Time.IsZero() {
+		genTime = t.Time
+	}
+
+	info, err := asn1.Marshal(timestamp.Info{
+		Version:        1,
+		Policy:         oidTestTSAPolicy,
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(time.Now().UnixNano()),
+		GenTime:        genTime.UTC().Truncate(time.Second),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	eci, err := protocol.NewEncapsulatedContentInfo(oid.ContentTypeTSTInfo, info)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := protocol.NewSignedData(eci)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []*x509.Certificate
+	if req.CertReq {
+		chain = t.chain
+	} else {
+		chain = []*x509.Certificate{t.Certificate}
+	}
+	if err := sd.AddSignerInfo(chain, t.Key); err != nil {
+		return nil, err
+	}
+	if !req.CertReq {
+		sd.ClearCertificates()
+	}
+
+	token, err := sd.ContentInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(timestamp.Response{
+		Status:         timestamp.PKIStatusInfo{Status: 0},
+		TimeStampToken: token,
+	})
+}
@@ -0,0 +1,270 @@
+package pki
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/remediation"
+)
+
+// Severity indicates if a validation finding prevents signing (SeverityError) or is only informational
+// (SeverityWarning).
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+var (
+	// Apple certificate policy marker extensions found on leaf code signing certificates
+	oidDeveloperIDApplication = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 13}
+	oidDeveloperIDInstaller   = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 14}
+	oidIPhoneDeveloper        = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 2}
+	oidIPhoneDistribution     = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 4}
+	oidMacAppStore            = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 7}
+	oidMacDeveloper           = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 12}
+
+	// the extended key usage of Developer ID Installer certificates (instead of code signing)
+	oidExtKeyUsageInstaller = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 4, 13}
+)
+
+// Purpose is what the signing material is used to sign, which determines the expected certificate policy.
+type Purpose int
+
+const (
+	// PurposeCodeSigning is for signing binaries (requiring a Developer ID Application certificate).
+	PurposeCodeSigning Purpose = iota
+	// PurposeInstaller is for signing flat installer packages (requiring a Developer ID Installer certificate).
+	PurposeInstaller
+)
+
+// Finding is a single result of validating signing material.
+type Finding struct {
+	Severity Severity
+	// Check is a short name for the check that produced the finding (e.g. "key-usage", "validity").
+	Check   string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s (%s): %s", f.Severity, f.Check, f.Message)
+}
+
+// ValidationResult is the set of findings from validating signing material.
+type ValidationResult struct {
+	Findings []Finding
+}
+
+// Warnings returns all findings that do not prevent signing.
+func (r ValidationResult) Warnings() []Finding {
+	return r.bySeverity(SeverityWarning)
+}
+
+// Errors returns all findings that prevent signing.
+func (r ValidationResult) Errors() []Finding {
+	return r.bySeverity(SeverityError)
+}
+
+// Err returns an error describing all findings with SeverityError (or nil if there are none).
+func (r ValidationResult) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	var messages []string
+	for _, f := range errs {
+		messages = append(messages, fmt.Sprintf("%s: %s", f.Check, f.Message))
+	}
+	return remediation.Wrap(fmt.Errorf("invalid signing material: %s", strings.Join(messages, "; ")), remediation.InvalidSigningMaterial,
+		"inspect the signing certificate with 'quill p12 describe' and renew or replace it in the Apple developer portal",
+		"quill p12 describe")
+}
+
+func (r ValidationResult) bySeverity(s Severity) []Finding {
+	var findings []Finding
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func (r *ValidationResult) add(s Severity, check, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{Severity: s, Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks that the signing material is usable for code signing (as of now). See ValidateCertificateMaterial.
+func (sm *SigningMaterial) Validate() ValidationResult {
+	return ValidateCertificateMaterial(sm, time.Now())
+}
+
+// ValidateFor checks that the signing material is usable for the given purpose (as of now).
+func (sm *SigningMaterial) ValidateFor(purpose Purpose) ValidationResult {
+	return ValidateCertificateMaterialFor(sm, purpose, time.Now())
+}
+
+// ValidateCertificateMaterial checks the signing material as of the given time:
+//   - the private key matches the signing (leaf) certificate
+//   - the leaf certificate allows for code signing (extended key usage and key usage)
+//   - the leaf certificate carries an Apple code signing policy marker (Developer ID is required for notarization)
+//   - every certificate is within its validity window (and satisfies the expiry policy, see ExpiryPolicy)
+//   - the chain is ordered, each certificate is signed by the next, and intermediates are CAs
+//
+// Problems that would result in a signature which does not verify are errors, everything else is a warning.
+func ValidateCertificateMaterial(sm *SigningMaterial, now time.Time) ValidationResult {
+	return ValidateCertificateMaterialFor(sm, PurposeCodeSigning, now)
+}
+
+// ValidateCertificateMaterialFor is like ValidateCertificateMaterial, but checks the extended key usage and Apple
+// policy marker expected for the given purpose (e.g. Developer ID Installer certificates for installer packages).
+func ValidateCertificateMaterialFor(sm *SigningMaterial, purpose Purpose, now time.Time) ValidationResult {
+	var r ValidationResult
+	if sm == nil || sm.Signer == nil {
+		// ad-hoc signing, there is no certificate material to validate
+		return r
+	}
+
+	leaf := sm.Leaf()
+	if leaf == nil {
+		r.add(SeverityError, "leaf", "no signing (non-CA) certificate found")
+		return r
+	}
+
+	if !load.PublicKeyMatches(sm.Signer, leaf) {
+		r.add(SeverityError, "key-match", "private key does not match the signing certificate %q", leaf.Subject.CommonName)
+	}
+
+	validateUsage(&r, leaf, purpose)
+	validatePolicy(&r, leaf, purpose)
+
+	for _, c := range sm.Certs {
+		switch {
+		case now.After(c.NotAfter):
+			r.add(SeverityError, "validity", "certificate %q expired on %s", c.Subject.CommonName, c.NotAfter.Format(time.RFC3339))
+		case now.Before(c.NotBefore):
+			r.add(SeverityError, "validity", "certificate %q is not valid until %s", c.Subject.CommonName, c.NotBefore.Format(time.RFC3339))
+		}
+	}
+
+	checkExpiry(&r, sm, now)
+	validateChain(&r, sm.Certs)
+
+	return r
+}
+
+func validateUsage(r *ValidationResult, leaf *x509.Certificate, purpose Purpose) {
+	if leaf.IsCA {
+		r.add(SeverityError, "basic-constraints", "signing certificate %q is a CA certificate", leaf.Subject.CommonName)
+	}
+
+	if leaf.KeyUsage != 0 && leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		r.add(SeverityError, "key-usage", "signing certificate %q does not allow digital signatures", leaf.Subject.CommonName)
+	}
+
+	if len(leaf.ExtKeyUsage) == 0 && len(leaf.UnknownExtKeyUsage) == 0 {
+		r.add(SeverityWarning, "extended-key-usage", "signing certificate %q has no extended key usage (code signing expected)", leaf.Subject.CommonName)
+		return
+	}
+
+	for _, u := range leaf.ExtKeyUsage {
+		if u == x509.ExtKeyUsageAny || (u == x509.ExtKeyUsageCodeSigning && purpose == PurposeCodeSigning) {
+			return
+		}
+	}
+
+	if purpose == PurposeInstaller {
+		for _, u := range leaf.UnknownExtKeyUsage {
+			if u.Equal(oidExtKeyUsageInstaller) {
+				return
+			}
+		}
+		r.add(SeverityError, "extended-key-usage", "signing certificate %q does not allow signing installer packages", leaf.Subject.CommonName)
+		return
+	}
+	r.add(SeverityError, "extended-key-usage", "signing certificate %q does not allow code signing", leaf.Subject.CommonName)
+}
+
+func validatePolicy(r *ValidationResult, leaf *x509.Certificate, purpose Purpose) {
+	has := func(oid asn1.ObjectIdentifier) bool {
+		for _, ext := range leaf.Extensions {
+			if ext.Id.Equal(oid) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if purpose == PurposeInstaller {
+		switch {
+		case has(oidDeveloperIDInstaller):
+			if len(leaf.Subject.OrganizationalUnit) == 0 {
+				r.add(SeverityWarning, "team-id", "signing certificate %q has no team ID (subject OU)", leaf.Subject.CommonName)
+			}
+		case has(oidDeveloperIDApplication):
+			r.add(SeverityError, "apple-policy", "signing certificate %q is a Developer ID Application certificate (a Developer ID Installer certificate is required to sign installer packages)", leaf.Subject.CommonName)
+		default:
+			r.add(SeverityWarning, "apple-policy", "signing certificate %q is not a Developer ID Installer certificate (the signed package will not be trusted by macOS)", leaf.Subject.CommonName)
+		}
+		return
+	}
+
+	switch {
+	case has(oidDeveloperIDApplication):
+		if len(leaf.Subject.OrganizationalUnit) == 0 {
+			r.add(SeverityWarning, "team-id", "signing certificate %q has no team ID (subject OU)", leaf.Subject.CommonName)
+		}
+	case has(oidDeveloperIDInstaller):
+		r.add(SeverityError, "apple-policy", "signing certificate %q is a Developer ID Installer certificate (a Developer ID Application certificate is required to sign binaries)", leaf.Subject.CommonName)
+	case has(oidMacDeveloper), has(oidMacAppStore), has(oidIPhoneDeveloper), has(oidIPhoneDistribution):
+		r.add(SeverityWarning, "apple-policy", "signing certificate %q is not a Developer ID Application certificate (the signed binary cannot be notarized)", leaf.Subject.CommonName)
+	default:
+		r.add(SeverityWarning, "apple-policy", "signing certificate %q has no Apple code signing policy extension (the signed binary will not be trusted by macOS)", leaf.Subject.CommonName)
+	}
+}
+
+// checkedSignatures holds the outcome of the issuer signature checks already made (by certificate and issuer digest),
+// since the chain is validated again for every artifact signed with the same material.
+var checkedSignatures sync.Map
+
+func checkSignatureFrom(c, parent *x509.Certificate) error {
+	key := [2][sha256.Size]byte{sha256.Sum256(c.Raw), sha256.Sum256(parent.Raw)}
+	if result, ok := checkedSignatures.Load(key); ok {
+		err, _ := result.(error)
+		return err
+	}
+
+	err := c.CheckSignatureFrom(parent)
+	checkedSignatures.Store(key, err)
+	return err
+}
+
+func validateChain(r *ValidationResult, certs []*x509.Certificate) {
+	if len(certs) == 1 {
+		r.add(SeverityWarning, "chain", "only the signing certificate is present (the certificate chain is incomplete)")
+		return
+	}
+
+	// certificates are ordered from the root to the leaf
+	for i, c := range certs {
+		if i > 0 {
+			if err := checkSignatureFrom(c, certs[i-1]); err != nil {
+				r.add(SeverityError, "chain", "certificate %q is not signed by %q: %v", c.Subject.CommonName, certs[i-1].Subject.CommonName, err)
+			}
+		}
+		if i < len(certs)-1 && !c.IsCA {
+			r.add(SeverityError, "chain", "certificate %q is used as an issuer but is not a CA certificate", c.Subject.CommonName)
+		}
+	}
+
+	if root := certs[0]; !isSelfSigned(root) {
+		r.add(SeverityWarning, "chain", "the certificate chain does not end at a root certificate (last issuer %q)", root.Issuer.CommonName)
+	}
+}
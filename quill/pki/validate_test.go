@@ -0,0 +1,176 @@
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/certchain"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestValidateCertificateMaterial(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	other, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	// a TLS server certificate issued by the fixture intermediate (no code signing EKU nor Apple policy)
+	serverLeaf := func() *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: "server"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, fixture.Intermediate, fixture.LeafKey.Public(), fixture.IntermediateKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}()
+
+	installerLeaf, installerKey, err := fixture.IssueInstallerLeaf("Quill Test", testca.DefaultTeamID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		material     *SigningMaterial
+		purpose      Purpose
+		now          time.Time
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:     "ad-hoc",
+			material: &SigningMaterial{},
+		},
+		{
+			name: "valid chain",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  certchain.Sort(fixture.Chain()),
+			},
+		},
+		{
+			name: "leaf only",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  []*x509.Certificate{fixture.Leaf},
+			},
+			wantWarnings: []string{"chain"},
+		},
+		{
+			name: "no leaf",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  []*x509.Certificate{fixture.Root},
+			},
+			wantErrors: []string{"leaf"},
+		},
+		{
+			name: "mismatched key",
+			material: &SigningMaterial{
+				Signer: other.LeafKey,
+				Certs:  certchain.Sort(fixture.Chain()),
+			},
+			wantErrors: []string{"key-match"},
+		},
+		{
+			name: "expired",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  certchain.Sort(fixture.Chain()),
+			},
+			now:        time.Now().Add(48 * time.Hour),
+			wantErrors: []string{"validity", "validity", "validity"},
+		},
+		{
+			name: "not yet valid",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  certchain.Sort(fixture.Chain()),
+			},
+			now:        time.Now().Add(-48 * time.Hour),
+			wantErrors: []string{"validity", "validity", "validity"},
+		},
+		{
+			name: "broken chain",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  []*x509.Certificate{other.Root, other.Intermediate, fixture.Leaf},
+			},
+			wantErrors: []string{"chain"},
+		},
+		{
+			name: "not a code signing certificate",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  []*x509.Certificate{fixture.Root, fixture.Intermediate, serverLeaf},
+			},
+			wantErrors:   []string{"key-usage", "extended-key-usage"},
+			wantWarnings: []string{"apple-policy"},
+		},
+		{
+			name: "installer certificate",
+			material: &SigningMaterial{
+				Signer: installerKey,
+				Certs:  []*x509.Certificate{fixture.Root, fixture.Intermediate, installerLeaf},
+			},
+			purpose: PurposeInstaller,
+		},
+		{
+			name: "installer certificate used for code signing",
+			material: &SigningMaterial{
+				Signer: installerKey,
+				Certs:  []*x509.Certificate{fixture.Root, fixture.Intermediate, installerLeaf},
+			},
+			wantErrors: []string{"extended-key-usage", "apple-policy"},
+		},
+		{
+			name: "application certificate used for an installer",
+			material: &SigningMaterial{
+				Signer: fixture.LeafKey,
+				Certs:  certchain.Sort(fixture.Chain()),
+			},
+			purpose:    PurposeInstaller,
+			wantErrors: []string{"extended-key-usage", "apple-policy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+
+			result := ValidateCertificateMaterialFor(tt.material, tt.purpose, now)
+
+			checks := func(findings []Finding) []string {
+				var names []string
+				for _, f := range findings {
+					names = append(names, f.Check)
+				}
+				return names
+			}
+
+			assert.Equal(t, tt.wantErrors, checks(result.Errors()))
+			assert.Equal(t, tt.wantWarnings, checks(result.Warnings()))
+
+			if len(tt.wantErrors) > 0 {
+				require.Error(t, result.Err())
+			} else {
+				require.NoError(t, result.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package quill
+
+import (
+	"fmt"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/sign"
+)
+
+// PrepareSignature reserves signatureSize bytes for the code signature of every architecture of the unsigned binary at
+// the given path without signing it (see sign.Prepare), so that a later signing step (e.g. signing a copy elsewhere
+// and attaching the signature with AttachSignature) drops the signature into the reserved space.
+func PrepareSignature(binPath string, signatureSize int) error {
+	log.WithFields("binary", binPath, "bytes", signatureSize).Info("reserving code signature space")
+	if err := sign.Prepare(binPath, signatureSize); err != nil {
+		return fmt.Errorf("unable to prepare %q for signing: %w", binPath, err)
+	}
+	return nil
+}
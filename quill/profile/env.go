@@ -0,0 +1,114 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// the environment variables read by Environment, these are the same variables used to configure the quill CLI
+const (
+	EnvIdentity          = "QUILL_SIGN_IDENTITY"
+	EnvP12               = "QUILL_SIGN_P12"
+	EnvCertificate       = "QUILL_SIGN_CERTIFICATE"
+	EnvPrivateKey        = "QUILL_SIGN_PRIVATE_KEY"
+	EnvPassword          = "QUILL_SIGN_PASSWORD"
+	EnvSigningDir        = "QUILL_SIGN_SIGNING_DIR"
+	EnvSigningIdentity   = "QUILL_SIGN_SIGNING_IDENTITY"
+	EnvTimestampServer   = "QUILL_SIGN_TIMESTAMP_SERVER"
+	EnvEmbedChain        = "QUILL_SIGN_EMBED_CHAIN"
+	EnvExpiryWarning     = "QUILL_SIGN_EXPIRY_WARNING"
+	EnvRequireValidUntil = "QUILL_SIGN_REQUIRE_VALID_UNTIL"
+	EnvAdHoc             = "QUILL_SIGN_AD_HOC"
+	EnvNotaryIssuer      = "QUILL_NOTARY_ISSUER"
+	EnvNotaryKeyID       = "QUILL_NOTARY_KEY_ID"
+	EnvNotaryKey         = "QUILL_NOTARY_KEY"
+)
+
+// Environment returns the profile described by the QUILL_* environment variables (unset variables leave the option
+// unset). Values can be env:, file:, or cmd: hints just as in the CLI. Combine it with explicitly configured options
+// using Merge, e.g.:
+//
+//	env, err := profile.Environment()
+//	...
+//	cfg, err := env.Merge(explicit).SigningConfig(path)
+func Environment() (Profile, error) {
+	return environment(os.LookupEnv)
+}
+
+func environment(lookup func(string) (string, bool)) (Profile, error) {
+	var p Profile
+
+	for name, dst := range map[string]*string{
+		EnvIdentity:          &p.Sign.Identity,
+		EnvP12:               &p.Sign.P12,
+		EnvCertificate:       &p.Sign.Certificate,
+		EnvPrivateKey:        &p.Sign.PrivateKey,
+		EnvPassword:          &p.Sign.Password,
+		EnvSigningDir:        &p.Sign.SigningDir,
+		EnvSigningIdentity:   &p.Sign.SigningIdentity,
+		EnvEmbedChain:        &p.Sign.EmbedChain,
+		EnvExpiryWarning:     &p.Sign.ExpiryWarning,
+		EnvRequireValidUntil: &p.Sign.RequireValidUntil,
+		EnvNotaryIssuer:      &p.Notary.Issuer,
+		EnvNotaryKeyID:       &p.Notary.KeyID,
+		EnvNotaryKey:         &p.Notary.Key,
+	} {
+		if value, ok := lookup(name); ok {
+			*dst = value
+		}
+	}
+
+	// an empty timestamp server (that is set) disables timestamping
+	if value, ok := lookup(EnvTimestampServer); ok {
+		p.Sign.TimestampServer = &value
+	}
+
+	if value, ok := lookup(EnvAdHoc); ok && value != "" {
+		adHoc, err := strconv.ParseBool(value)
+		if err != nil {
+			return p, fmt.Errorf("invalid %s value %q: %w", EnvAdHoc, value, err)
+		}
+		p.Sign.AdHoc = adHoc
+	}
+
+	return p, nil
+}
+
+// Merge returns the profile with the options set by the given (explicit) profile taking precedence. When the given
+// profile sets any signing material (a p12 file, certificate, signing directory, or ad-hoc signing) it replaces all
+// signing material of the profile, so that sources are never mixed.
+func (p Profile) Merge(explicit Profile) Profile {
+	merged := p
+	s, e := &merged.Sign, explicit.Sign
+
+	if e.P12 != "" || e.Certificate != "" || e.SigningDir != "" || e.AdHoc {
+		s.P12 = e.P12
+		s.Certificate = e.Certificate
+		s.PrivateKey = e.PrivateKey
+		s.SigningDir = e.SigningDir
+		s.AdHoc = e.AdHoc
+	}
+
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+
+	override(&s.Identity, e.Identity)
+	override(&s.Password, e.Password)
+	override(&s.SigningIdentity, e.SigningIdentity)
+	override(&s.EmbedChain, e.EmbedChain)
+	override(&s.ExpiryWarning, e.ExpiryWarning)
+	override(&s.RequireValidUntil, e.RequireValidUntil)
+	if e.TimestampServer != nil {
+		s.TimestampServer = e.TimestampServer
+	}
+
+	override(&merged.Notary.Issuer, explicit.Notary.Issuer)
+	override(&merged.Notary.KeyID, explicit.Notary.KeyID)
+	override(&merged.Notary.Key, explicit.Notary.Key)
+
+	return merged
+}
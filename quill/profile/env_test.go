@@ -0,0 +1,134 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Profile
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "empty environment",
+		},
+		{
+			name: "signing and notary options",
+			env: map[string]string{
+				EnvP12:             "env:P12_CONTENTS",
+				EnvPassword:        "file:/run/secrets/p12-password",
+				EnvIdentity:        "com.example.app",
+				EnvTimestampServer: "",
+				EnvNotaryIssuer:    "issuer-id",
+				EnvNotaryKeyID:     "ABCDE12345",
+				EnvNotaryKey:       "env:NOTARY_KEY",
+			},
+			want: Profile{
+				Sign: Signing{
+					Identity:        "com.example.app",
+					P12:             "env:P12_CONTENTS",
+					Password:        "file:/run/secrets/p12-password",
+					TimestampServer: strPtr(""),
+				},
+				Notary: Notary{
+					Issuer: "issuer-id",
+					KeyID:  "ABCDE12345",
+					Key:    "env:NOTARY_KEY",
+				},
+			},
+		},
+		{
+			name: "ad-hoc",
+			env:  map[string]string{EnvAdHoc: "true"},
+			want: Profile{Sign: Signing{AdHoc: true}},
+		},
+		{
+			name:    "invalid ad-hoc",
+			env:     map[string]string{EnvAdHoc: "sometimes"},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := environment(func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			})
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProfile_Merge(t *testing.T) {
+	env := Profile{
+		Sign: Signing{
+			P12:             "env:P12_CONTENTS",
+			Password:        "env:P12_PASSWORD",
+			Identity:        "com.example.env",
+			TimestampServer: strPtr(""),
+		},
+		Notary: Notary{Issuer: "issuer-id", KeyID: "ABCDE12345", Key: "env:NOTARY_KEY"},
+	}
+
+	tests := []struct {
+		name     string
+		explicit Profile
+		want     Profile
+	}{
+		{
+			name: "nothing explicit",
+			want: env,
+		},
+		{
+			name: "explicit options take precedence",
+			explicit: Profile{
+				Sign:   Signing{Identity: "com.example.app", TimestampServer: strPtr("http://tsa.example.com")},
+				Notary: Notary{KeyID: "ZYXWV54321"},
+			},
+			want: Profile{
+				Sign: Signing{
+					P12:             "env:P12_CONTENTS",
+					Password:        "env:P12_PASSWORD",
+					Identity:        "com.example.app",
+					TimestampServer: strPtr("http://tsa.example.com"),
+				},
+				Notary: Notary{Issuer: "issuer-id", KeyID: "ZYXWV54321", Key: "env:NOTARY_KEY"},
+			},
+		},
+		{
+			name:     "explicit signing material replaces the environment",
+			explicit: Profile{Sign: Signing{Certificate: "cert.pem", PrivateKey: "key.pem"}},
+			want: Profile{
+				Sign: Signing{
+					Certificate:     "cert.pem",
+					PrivateKey:      "key.pem",
+					Password:        "env:P12_PASSWORD",
+					Identity:        "com.example.env",
+					TimestampServer: strPtr(""),
+				},
+				Notary: env.Notary,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, env.Merge(tt.explicit))
+		})
+	}
+}
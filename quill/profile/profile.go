@@ -0,0 +1,179 @@
+/*
+Package profile reads named signing profiles: reusable sets of signing and notarization options defined once in a
+YAML file (such as the quill application config) and selected by name, e.g.:
+
+	profiles:
+	  release:
+	    sign:
+	      p12: env:RELEASE_P12
+	      password: env:RELEASE_P12_PASSWORD
+	      timestamp-server: http://timestamp.apple.com/ts01
+	    notary:
+	      issuer: 69a6de7e-...
+	      key-id: ABCDE12345
+	      key: env:RELEASE_NOTARY_KEY
+
+The same options can be read from the QUILL_* environment variables used by the CLI with Environment, which is
+convenient in containerized CI jobs.
+*/
+package profile
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/anchore/quill/quill"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+// Profile is a named set of signing and notarization options. Empty values are not set by the profile.
+type Profile struct {
+	Sign   Signing `yaml:"sign" json:"sign" mapstructure:"sign"`
+	Notary Notary  `yaml:"notary" json:"notary" mapstructure:"notary"`
+}
+
+// Signing are the signing options of a profile (with the same meaning as the "sign" options of the application
+// config).
+type Signing struct {
+	Identity        string `yaml:"identity" json:"identity" mapstructure:"identity"`
+	P12             string `yaml:"p12" json:"p12" mapstructure:"p12"`
+	Certificate     string `yaml:"certificate" json:"certificate" mapstructure:"certificate"`
+	PrivateKey      string `yaml:"private-key" json:"private-key" mapstructure:"private-key"`
+	Password        string `yaml:"password" json:"password" mapstructure:"password"`
+	SigningDir      string `yaml:"signing-dir" json:"signing-dir" mapstructure:"signing-dir"`
+	SigningIdentity string `yaml:"signing-identity" json:"signing-identity" mapstructure:"signing-identity"`
+	// TimestampServer is a comma separated list of timestamp server URLs, an explicitly empty value disables
+	// timestamping (nil leaves the default in place).
+	TimestampServer   *string `yaml:"timestamp-server" json:"timestamp-server" mapstructure:"timestamp-server"`
+	EmbedChain        string  `yaml:"embed-chain" json:"embed-chain" mapstructure:"embed-chain"`
+	ExpiryWarning     string  `yaml:"expiry-warning" json:"expiry-warning" mapstructure:"expiry-warning"`
+	RequireValidUntil string  `yaml:"require-valid-until" json:"require-valid-until" mapstructure:"require-valid-until"`
+	AdHoc             bool    `yaml:"ad-hoc" json:"ad-hoc" mapstructure:"ad-hoc"`
+}
+
+// Notary are the App Store Connect API credentials of a profile.
+type Notary struct {
+	Issuer string `yaml:"issuer" json:"issuer" mapstructure:"issuer"`
+	KeyID  string `yaml:"key-id" json:"key-id" mapstructure:"key-id"`
+	Key    string `yaml:"key" json:"key" mapstructure:"key"`
+}
+
+// Profiles are the profiles of a config file by name.
+type Profiles map[string]Profile
+
+// Load reads the "profiles" section of the given YAML file.
+func Load(path string) (Profiles, error) {
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read profiles: %w", err)
+	}
+
+	var doc struct {
+		Profiles Profiles `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(by, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse profiles from %q: %w", path, err)
+	}
+	return doc.Profiles, nil
+}
+
+// Names returns the sorted names of all profiles.
+func (p Profiles) Names() []string {
+	var names []string
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the profile with the given name.
+func (p Profiles) Get(name string) (*Profile, error) {
+	profile, ok := p[name]
+	if !ok {
+		if len(p) == 0 {
+			return nil, fmt.Errorf("no signing profile named %q (no profiles are defined)", name)
+		}
+		return nil, fmt.Errorf("no signing profile named %q (available: %s)", name, strings.Join(p.Names(), ", "))
+	}
+	return &profile, nil
+}
+
+// SigningConfig resolves the signing material of the profile (a P12 file, PEM certificate and key, or a signing
+// directory; or ad-hoc signing) into a signing config for the given binary.
+func (p Profile) SigningConfig(binaryPath string) (*quill.SigningConfig, error) {
+	s := p.Sign
+	provider := load.NewPassphraseProvider(s.Password)
+
+	var cfg *quill.SigningConfig
+	switch {
+	case s.AdHoc:
+		cfg = quill.NewSigningConfig(binaryPath, pki.SigningMaterial{})
+	case s.P12 != "":
+		contents, err := load.P12WithPassphrase(s.P12, provider)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read p12: %w", err)
+		}
+		cfg, err = quill.NewSigningConfigFromP12(binaryPath, *contents, true)
+		if err != nil {
+			return nil, err
+		}
+	case s.Certificate != "":
+		var err error
+		cfg, err = quill.NewSigningConfigFromPEMsWithPassphrase(binaryPath, s.Certificate, s.PrivateKey, provider, true)
+		if err != nil {
+			return nil, err
+		}
+	case s.SigningDir != "":
+		candidates, err := pki.NewSigningMaterialsFromDirectory(s.SigningDir, provider, true)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signing directory: %w", err)
+		}
+		selected, err := candidates.Resolve(s.SigningIdentity)
+		if err != nil {
+			return nil, err
+		}
+		cfg = quill.NewSigningConfig(binaryPath, *selected)
+	default:
+		return nil, fmt.Errorf("the profile has no signing material (set one of p12, certificate, signing-dir, or ad-hoc)")
+	}
+
+	cfg.WithIdentity(s.Identity)
+
+	if s.TimestampServer != nil {
+		cfg.WithTimestampServer(*s.TimestampServer)
+	}
+
+	if s.ExpiryWarning != "" || s.RequireValidUntil != "" {
+		expiry, err := pki.ParseExpiryPolicy(s.ExpiryWarning, s.RequireValidUntil, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		cfg.WithExpiryPolicy(expiry)
+	}
+
+	if s.EmbedChain != "" {
+		embedding, err := pki.ParseChainEmbedding(s.EmbedChain)
+		if err != nil {
+			return nil, err
+		}
+		cfg.WithChainEmbedding(embedding)
+	}
+
+	return cfg, nil
+}
+
+// NotarizeConfig returns the notarization config for the credentials of the profile.
+func (p Profile) NotarizeConfig() (*quill.NotarizeConfig, error) {
+	n := p.Notary
+	if n.Issuer == "" || n.KeyID == "" || n.Key == "" {
+		return nil, fmt.Errorf("the profile has no notary credentials (issuer, key-id, and key are required)")
+	}
+	return quill.NewNotarizeConfig(n.Issuer, n.KeyID, n.Key), nil
+}
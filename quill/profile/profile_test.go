@@ -0,0 +1,124 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const config = `
+log:
+  level: info
+
+profiles:
+  release:
+    sign:
+      p12: env:RELEASE_P12
+      password: env:RELEASE_P12_PASSWORD
+      timestamp-server: ""
+    notary:
+      issuer: issuer-id
+      key-id: ABCDE12345
+      key: env:RELEASE_NOTARY_KEY
+  local:
+    sign:
+      ad-hoc: true
+      identity: com.example.app
+`
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".quill.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	profiles, err := Load(writeConfig(t, config))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"local", "release"}, profiles.Names())
+
+	release := profiles["release"]
+	assert.Equal(t, "env:RELEASE_P12", release.Sign.P12)
+	require.NotNil(t, release.Sign.TimestampServer)
+	assert.Equal(t, "", *release.Sign.TimestampServer)
+	assert.Equal(t, Notary{Issuer: "issuer-id", KeyID: "ABCDE12345", Key: "env:RELEASE_NOTARY_KEY"}, release.Notary)
+
+	local := profiles["local"]
+	assert.True(t, local.Sign.AdHoc)
+	assert.Nil(t, local.Sign.TimestampServer)
+
+	_, err = Load(writeConfig(t, "profiles: [not, a, map]"))
+	assert.Error(t, err)
+}
+
+func TestProfiles_Get(t *testing.T) {
+	profiles, err := Load(writeConfig(t, config))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		profiles Profiles
+		profile  string
+		wantErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:     "defined profile",
+			profiles: profiles,
+			profile:  "local",
+		},
+		{
+			name:     "lists available profiles",
+			profiles: profiles,
+			profile:  "missing",
+			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.EqualError(t, err, `no signing profile named "missing" (available: local, release)`)
+			},
+		},
+		{
+			name:    "no profiles",
+			profile: "missing",
+			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.EqualError(t, err, `no signing profile named "missing" (no profiles are defined)`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			p, err := tt.profiles.Get(tt.profile)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.profiles[tt.profile], *p)
+		})
+	}
+}
+
+func TestProfile_SigningConfig(t *testing.T) {
+	cfg, err := Profile{Sign: Signing{AdHoc: true, Identity: "com.example.app"}}.SigningConfig("/path/to/bin")
+	require.NoError(t, err)
+	assert.Equal(t, "/path/to/bin", cfg.Path)
+	assert.Equal(t, "com.example.app", cfg.Identity)
+	assert.Nil(t, cfg.SigningMaterial.Signer)
+
+	_, err = Profile{}.SigningConfig("/path/to/bin")
+	assert.Error(t, err)
+}
+
+func TestProfile_NotarizeConfig(t *testing.T) {
+	cfg, err := Profile{Notary: Notary{Issuer: "issuer-id", KeyID: "ABCDE12345", Key: "key.p8"}}.NotarizeConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "issuer-id", cfg.TokenConfig.Issuer)
+	assert.Equal(t, "ABCDE12345", cfg.TokenConfig.PrivateKeyID)
+
+	_, err = Profile{Notary: Notary{Issuer: "issuer-id"}}.NotarizeConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package provisioning
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+// Inconsistencies returns why the bundle with the given identifier, signed by the given team with the given
+// entitlements, is not authorized by the profile (nothing when it is). This is the classic "entitlement not in profile"
+// rejection: the profile must authorize the bundle identifier and be issued to the team (which is not checked when
+// empty, e.g. for ad-hoc signatures), and the entitlements must be a subset of the entitlements of the profile.
+func (p Profile) Inconsistencies(bundleID, teamID string, ents entitlements.Entitlements) []string {
+	var issues []string
+	if !p.Matches(bundleID) {
+		issues = append(issues, fmt.Sprintf("the application identifier %q does not authorize the bundle identifier %q", p.ApplicationIdentifier(), bundleID))
+	}
+	if teamID != "" && !p.issuedTo(teamID) {
+		issues = append(issues, fmt.Sprintf("the profile is issued to team %q, not to the signing team %q", p.TeamID(), teamID))
+	}
+
+	var keys []string
+	for k := range ents {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		allowed, ok := p.Entitlements[k]
+		switch {
+		case !ok:
+			issues = append(issues, fmt.Sprintf("the entitlement %q is not authorized", k))
+		case !authorizes(allowed, ents[k]):
+			issues = append(issues, fmt.Sprintf("the entitlement %q is %v, which is not authorized (the profile allows %v)", k, ents[k], allowed))
+		}
+	}
+	return issues
+}
+
+func (p Profile) issuedTo(teamID string) bool {
+	if len(p.TeamIdentifiers) == 0 {
+		return p.TeamID() == teamID
+	}
+	for _, t := range p.TeamIdentifiers {
+		if t == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizes indicates if the entitlement value allowed by a profile authorizes the given (signed) value: booleans may
+// only be enabled when the profile enables them, strings must match the allowed value (which may end with a "*"
+// wildcard) or one of the allowed values, and every item of an array must be authorized.
+func authorizes(allowed, value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		a, ok := allowed.(bool)
+		return ok && (a || !v)
+	case string:
+		switch a := allowed.(type) {
+		case string:
+			return matchesWildcard(a, v)
+		case []interface{}:
+			for _, item := range a {
+				if s, ok := item.(string); ok && matchesWildcard(s, v) {
+					return true
+				}
+			}
+		}
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if !authorizes(allowed, item) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(allowed, value)
+}
+
+func matchesWildcard(pattern, value string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
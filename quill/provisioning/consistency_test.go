@@ -0,0 +1,86 @@
+package provisioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+func TestProfile_Inconsistencies(t *testing.T) {
+	p, err := Parse(newProfile(t, "TEAM123456.com.example.*", entitlements.Entitlements{
+		"keychain-access-groups":                   []interface{}{"TEAM123456.*"},
+		"com.apple.developer.associated-domains":   "*",
+		"com.apple.developer.icloud-container-ids": []interface{}{"iCloud.com.example.app", "iCloud.com.example.shared"},
+		"aps-environment":                          "production",
+		"com.apple.security.app-sandbox":           false,
+	}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		bundleID string
+		teamID   string
+		ents     entitlements.Entitlements
+		want     []string
+	}{
+		{
+			name:     "entitlements of the profile",
+			bundleID: "com.example.app",
+			teamID:   "TEAM123456",
+			ents:     p.EntitlementsFor("com.example.app"),
+		},
+		{
+			name:     "subset of the profile",
+			bundleID: "com.example.app",
+			teamID:   "TEAM123456",
+			ents: entitlements.Entitlements{
+				"get-task-allow":                           false,
+				"keychain-access-groups":                   []interface{}{"TEAM123456.com.example.app"},
+				"com.apple.developer.associated-domains":   []interface{}{"applinks:example.com"},
+				"com.apple.developer.icloud-container-ids": []interface{}{"iCloud.com.example.shared"},
+			},
+		},
+		{
+			name:     "ad-hoc signature (no team)",
+			bundleID: "com.example.app",
+			ents:     entitlements.Entitlements{"aps-environment": "production"},
+		},
+		{
+			name:     "bundle identifier not authorized",
+			bundleID: "org.other.app",
+			teamID:   "TEAM123456",
+			want:     []string{`the application identifier "TEAM123456.com.example.*" does not authorize the bundle identifier "org.other.app"`},
+		},
+		{
+			name:     "other team",
+			bundleID: "com.example.app",
+			teamID:   "OTHER12345",
+			want:     []string{`the profile is issued to team "TEAM123456", not to the signing team "OTHER12345"`},
+		},
+		{
+			name:     "entitlements not in the profile",
+			bundleID: "com.example.app",
+			teamID:   "TEAM123456",
+			ents: entitlements.Entitlements{
+				"com.apple.security.app-sandbox":           true,
+				"com.apple.developer.icloud-container-ids": []interface{}{"iCloud.org.other"},
+				"aps-environment":                          "development",
+				"com.apple.developer.healthkit":            true,
+			},
+			want: []string{
+				`the entitlement "aps-environment" is development, which is not authorized (the profile allows production)`,
+				`the entitlement "com.apple.developer.healthkit" is not authorized`,
+				`the entitlement "com.apple.developer.icloud-container-ids" is [iCloud.org.other], which is not authorized (the profile allows [iCloud.com.example.app iCloud.com.example.shared])`,
+				`the entitlement "com.apple.security.app-sandbox" is true, which is not authorized (the profile allows false)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.Inconsistencies(tt.bundleID, tt.teamID, tt.ents))
+		})
+	}
+}
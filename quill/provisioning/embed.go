@@ -0,0 +1,47 @@
+package provisioning
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// EmbeddedName is the name of the provisioning profile within an iOS app (or app extension) bundle.
+	EmbeddedName = "embedded.mobileprovision"
+	// EmbeddedMacOSName is the name of the provisioning profile within the Contents directory of a macOS app bundle.
+	EmbeddedMacOSName = "embedded.provisionprofile"
+)
+
+// EmbeddedPath is where the provisioning profile is embedded within the bundle at the given directory: within the
+// Contents directory for (deep) macOS bundles, at the bundle root for (shallow) iOS bundles.
+func EmbeddedPath(bundleDir string) string {
+	contents := filepath.Join(bundleDir, "Contents")
+	if info, err := os.Stat(contents); err == nil && info.IsDir() {
+		return filepath.Join(contents, EmbeddedMacOSName)
+	}
+	return filepath.Join(bundleDir, EmbeddedName)
+}
+
+// LoadEmbedded reads the provisioning profile embedded within the bundle at the given directory (nil when the bundle
+// has no embedded profile).
+func LoadEmbedded(bundleDir string) (*Profile, error) {
+	path := EmbeddedPath(bundleDir)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Embed writes the provisioning profile into the bundle at the given directory, replacing any embedded profile. The
+// bundle must be (re)signed afterwards since the profile is part of the sealed resources.
+func Embed(bundleDir string, p *Profile) error {
+	if len(p.Raw) == 0 {
+		return fmt.Errorf("provisioning profile %q has no signed content to embed", p.Name)
+	}
+	if err := os.WriteFile(EmbeddedPath(bundleDir), p.Raw, 0644); err != nil { //nolint:gosec
+		return fmt.Errorf("unable to embed provisioning profile: %w", err)
+	}
+	return nil
+}
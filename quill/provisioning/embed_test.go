@@ -0,0 +1,39 @@
+package provisioning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedPath(t *testing.T) {
+	ios := t.TempDir()
+	assert.Equal(t, filepath.Join(ios, EmbeddedName), EmbeddedPath(ios))
+
+	macos := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(macos, "Contents"), 0755))
+	assert.Equal(t, filepath.Join(macos, "Contents", EmbeddedMacOSName), EmbeddedPath(macos))
+}
+
+func TestEmbed(t *testing.T) {
+	p, err := Parse(newProfile(t, "TEAM123456.*", nil))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	embedded, err := LoadEmbedded(dir)
+	require.NoError(t, err)
+	assert.Nil(t, embedded)
+
+	require.NoError(t, Embed(dir, p))
+
+	embedded, err = LoadEmbedded(dir)
+	require.NoError(t, err)
+	require.NotNil(t, embedded)
+	assert.Equal(t, p.Raw, embedded.Raw)
+
+	require.Error(t, Embed(dir, &Profile{Name: "unsigned"}))
+}
@@ -0,0 +1,251 @@
+// Package provisioning reads provisioning profiles (.mobileprovision and .provisionprofile files), which authorize a
+// team to sign an app (by its bundle identifier) with a set of entitlements, optionally restricted to a set of
+// devices. A provisioning profile is a plist wrapped in a CMS signature from Apple, iOS apps (and macOS apps using
+// restricted entitlements) are only launchable when the profile is embedded within the app bundle.
+package provisioning
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	cms "github.com/github/smimesign/ietf-cms"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+const (
+	applicationIdentifierKey = "application-identifier"
+	keychainAccessGroupsKey  = "keychain-access-groups"
+)
+
+// Profile is a parsed provisioning profile.
+type Profile struct {
+	Name string
+	UUID string
+	// TeamIdentifiers are the teams allowed to sign with the profile (typically a single team).
+	TeamIdentifiers []string
+	// ApplicationIdentifierPrefixes prefix the application identifier (typically the team ID).
+	ApplicationIdentifierPrefixes []string
+	// Entitlements are the entitlements the profile authorizes, the application identifier may hold a wildcard.
+	Entitlements entitlements.Entitlements
+	// Platforms are the platforms the profile is valid for (e.g. "iOS" or "OSX").
+	Platforms []string
+	// ProvisionedDevices are the UDIDs of the devices the profile allows the app to run on (empty for distribution
+	// profiles).
+	ProvisionedDevices []string
+	// ProvisionsAllDevices is set for enterprise (in-house) profiles, which allow the app to run on any device.
+	ProvisionsAllDevices bool
+	// DeveloperCertificates are the signing certificates allowed to sign with the profile.
+	DeveloperCertificates []*x509.Certificate
+	CreationDate          time.Time
+	ExpirationDate        time.Time
+	// Raw is the original (CMS signed) profile, which is what gets embedded within a bundle.
+	Raw []byte
+}
+
+// Load reads the provisioning profile at the given path.
+func Load(path string) (*Profile, error) {
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read provisioning profile: %w", err)
+	}
+	p, err := Parse(by)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse provisioning profile %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a provisioning profile. The CMS signature is not verified.
+func Parse(by []byte) (*Profile, error) {
+	sd, err := cms.ParseSignedData(by)
+	if err != nil {
+		return nil, fmt.Errorf("not a CMS signed provisioning profile: %w", err)
+	}
+	content, err := sd.GetData()
+	if err != nil || len(content) == 0 {
+		return nil, fmt.Errorf("provisioning profile has no content")
+	}
+
+	doc, err := entitlements.ParsePlist(content)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Profile{
+		Name:                          stringValue(doc["Name"]),
+		UUID:                          stringValue(doc["UUID"]),
+		TeamIdentifiers:               stringValues(doc["TeamIdentifier"]),
+		ApplicationIdentifierPrefixes: stringValues(doc["ApplicationIdentifierPrefix"]),
+		Platforms:                     stringValues(doc["Platform"]),
+		ProvisionedDevices:            stringValues(doc["ProvisionedDevices"]),
+		Raw:                           by,
+	}
+	p.ProvisionsAllDevices, _ = doc["ProvisionsAllDevices"].(bool)
+	p.CreationDate, _ = doc["CreationDate"].(time.Time)
+	p.ExpirationDate, _ = doc["ExpirationDate"].(time.Time)
+
+	certs, _ := doc["DeveloperCertificates"].([]interface{})
+	for i, c := range certs {
+		der, ok := c.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("invalid developer certificate %d", i)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse developer certificate %d: %w", i, err)
+		}
+		p.DeveloperCertificates = append(p.DeveloperCertificates, cert)
+	}
+
+	if ents, ok := doc["Entitlements"].(map[string]interface{}); ok {
+		p.Entitlements = ents
+	}
+	if p.ApplicationIdentifier() == "" {
+		return nil, fmt.Errorf("provisioning profile has no application identifier")
+	}
+	return &p, nil
+}
+
+// ApplicationIdentifier is the application identifier authorized by the profile (the prefix followed by a bundle
+// identifier, which may end with a "*" wildcard).
+func (p Profile) ApplicationIdentifier() string {
+	return stringValue(p.Entitlements[applicationIdentifierKey])
+}
+
+// TeamID is the team the profile is issued to.
+func (p Profile) TeamID() string {
+	if len(p.TeamIdentifiers) > 0 {
+		return p.TeamIdentifiers[0]
+	}
+	if len(p.ApplicationIdentifierPrefixes) > 0 {
+		return p.ApplicationIdentifierPrefixes[0]
+	}
+	return ""
+}
+
+// Expired indicates if the profile is expired at the given time (profiles without an expiration date never expire).
+func (p Profile) Expired(at time.Time) bool {
+	return !p.ExpirationDate.IsZero() && at.After(p.ExpirationDate)
+}
+
+// IsMacOS indicates if the profile is for macOS apps (a .provisionprofile).
+func (p Profile) IsMacOS() bool {
+	for _, platform := range p.Platforms {
+		if platform == "OSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsDevice indicates if the app signed with the profile may run on the device with the given UDID.
+func (p Profile) AllowsDevice(udid string) bool {
+	if p.ProvisionsAllDevices {
+		return true
+	}
+	for _, d := range p.ProvisionedDevices {
+		if strings.EqualFold(d, udid) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsCertificate indicates if the given signing certificate is one of the developer certificates of the profile.
+func (p Profile) AllowsCertificate(cert *x509.Certificate) bool {
+	for _, c := range p.DeveloperCertificates {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches indicates if the profile authorizes the given bundle identifier.
+func (p Profile) Matches(bundleID string) bool {
+	prefix, pattern := p.splitApplicationIdentifier()
+	if prefix == "" {
+		return false
+	}
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(bundleID, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == bundleID
+}
+
+// EntitlementsFor returns the entitlements to sign the given bundle with: the profile entitlements where wildcard
+// application identifiers (and keychain access groups) are resolved to the bundle identifier.
+func (p Profile) EntitlementsFor(bundleID string) entitlements.Entitlements {
+	ents := make(entitlements.Entitlements, len(p.Entitlements))
+	for k, v := range p.Entitlements {
+		ents[k] = v
+	}
+
+	prefix, pattern := p.splitApplicationIdentifier()
+	if prefix == "" || !strings.HasSuffix(pattern, "*") {
+		return ents
+	}
+
+	resolved := prefix + "." + bundleID
+	ents[applicationIdentifierKey] = resolved
+
+	if groups, ok := ents[keychainAccessGroupsKey].([]interface{}); ok {
+		var updated []interface{}
+		for _, g := range groups {
+			if s, ok := g.(string); ok && strings.HasSuffix(s, "*") && strings.HasPrefix(resolved, strings.TrimSuffix(s, "*")) {
+				g = resolved
+			}
+			updated = append(updated, g)
+		}
+		ents[keychainAccessGroupsKey] = updated
+	}
+	return ents
+}
+
+func (p Profile) splitApplicationIdentifier() (string, string) {
+	appID := p.ApplicationIdentifier()
+	for _, prefix := range append(p.ApplicationIdentifierPrefixes, p.TeamIdentifiers...) {
+		if strings.HasPrefix(appID, prefix+".") {
+			return prefix, strings.TrimPrefix(appID, prefix+".")
+		}
+	}
+	return "", ""
+}
+
+// Select returns the profile authorizing the given bundle identifier, preferring exact matches over wildcards
+// (nil when no profile matches).
+func Select(profiles []*Profile, bundleID string) *Profile {
+	var wildcard *Profile
+	for _, p := range profiles {
+		if !p.Matches(bundleID) {
+			continue
+		}
+		if !strings.HasSuffix(p.ApplicationIdentifier(), "*") {
+			return p
+		}
+		if wildcard == nil || len(p.ApplicationIdentifier()) > len(wildcard.ApplicationIdentifier()) {
+			wildcard = p
+		}
+	}
+	return wildcard
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func stringValues(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var values []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
@@ -0,0 +1,212 @@
+package provisioning
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+// newProfile creates a CMS signed provisioning profile for the given application identifier.
+func newProfile(t *testing.T, appID string, extra entitlements.Entitlements) []byte {
+	t.Helper()
+	return newProfileWithFields(t, appID, extra, nil)
+}
+
+// newProfileWithFields creates a CMS signed provisioning profile, overriding the given top level fields.
+func newProfileWithFields(t *testing.T, appID string, extra, fields entitlements.Entitlements) []byte {
+	t.Helper()
+
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	ents := map[string]interface{}{
+		"application-identifier":              appID,
+		"com.apple.developer.team-identifier": "TEAM123456",
+		"get-task-allow":                      true,
+	}
+	for k, v := range extra {
+		ents[k] = v
+	}
+
+	doc := entitlements.Entitlements{
+		"Name":                        "Quill Test Profile",
+		"UUID":                        "3f8e43a1-0d2c-4b5e-9a6f-000000000001",
+		"TeamIdentifier":              []interface{}{"TEAM123456"},
+		"ApplicationIdentifierPrefix": []interface{}{"TEAM123456"},
+		"Entitlements":                ents,
+	}
+	for k, v := range fields {
+		doc[k] = v
+	}
+
+	by, err := cms.Sign([]byte(doc.XML()), []*x509.Certificate{fixture.Leaf}, fixture.LeafKey)
+	require.NoError(t, err)
+	return by
+}
+
+func TestParse(t *testing.T) {
+	raw := newProfile(t, "TEAM123456.com.example.app", nil)
+
+	p, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Quill Test Profile", p.Name)
+	assert.Equal(t, "3f8e43a1-0d2c-4b5e-9a6f-000000000001", p.UUID)
+	assert.Equal(t, []string{"TEAM123456"}, p.TeamIdentifiers)
+	assert.Equal(t, "TEAM123456", p.TeamID())
+	assert.Equal(t, "TEAM123456.com.example.app", p.ApplicationIdentifier())
+	assert.Equal(t, true, p.Entitlements["get-task-allow"])
+	assert.Equal(t, raw, p.Raw)
+}
+
+func TestParse_details(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := created.AddDate(1, 0, 0)
+
+	p, err := Parse(newProfileWithFields(t, "TEAM123456.com.example.app", nil, entitlements.Entitlements{
+		"Platform":              []interface{}{"iOS"},
+		"ProvisionedDevices":    []interface{}{"00008030-001A2B3C4D5E6F70", "00008101-000A1B2C3D4E5F60"},
+		"DeveloperCertificates": []interface{}{fixture.Leaf.Raw},
+		"CreationDate":          created,
+		"ExpirationDate":        expires,
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"iOS"}, p.Platforms)
+	assert.False(t, p.IsMacOS())
+	assert.Equal(t, []string{"00008030-001A2B3C4D5E6F70", "00008101-000A1B2C3D4E5F60"}, p.ProvisionedDevices)
+	assert.False(t, p.ProvisionsAllDevices)
+	assert.True(t, p.AllowsDevice("00008030-001a2b3c4d5e6f70"))
+	assert.False(t, p.AllowsDevice("00008030-FFFFFFFFFFFFFFFF"))
+	assert.True(t, created.Equal(p.CreationDate))
+	assert.True(t, expires.Equal(p.ExpirationDate))
+	assert.False(t, p.Expired(created))
+	assert.True(t, p.Expired(expires.Add(time.Second)))
+
+	require.Len(t, p.DeveloperCertificates, 1)
+	assert.True(t, p.AllowsCertificate(fixture.Leaf))
+	assert.False(t, p.AllowsCertificate(fixture.Intermediate))
+
+	enterprise, err := Parse(newProfileWithFields(t, "TEAM123456.*", nil, entitlements.Entitlements{
+		"Platform":             []interface{}{"OSX"},
+		"ProvisionsAllDevices": true,
+	}))
+	require.NoError(t, err)
+	assert.True(t, enterprise.IsMacOS())
+	assert.True(t, enterprise.AllowsDevice("any"))
+	assert.False(t, enterprise.Expired(time.Now()))
+}
+
+func TestParse_invalid(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	noAppID, err := cms.Sign([]byte(entitlements.Entitlements{"Name": "x"}.XML()), []*x509.Certificate{fixture.Leaf}, fixture.LeafKey)
+	require.NoError(t, err)
+
+	badCert, err := cms.Sign([]byte(entitlements.Entitlements{
+		"Entitlements":          map[string]interface{}{"application-identifier": "TEAM123456.*"},
+		"TeamIdentifier":        []interface{}{"TEAM123456"},
+		"DeveloperCertificates": []interface{}{[]byte("not a certificate")},
+	}.XML()), []*x509.Certificate{fixture.Leaf}, fixture.LeafKey)
+	require.NoError(t, err)
+
+	notPlist, err := cms.Sign([]byte("not a plist"), []*x509.Certificate{fixture.Leaf}, fixture.LeafKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "not CMS", input: []byte("<plist/>")},
+		{name: "not a plist", input: notPlist},
+		{name: "no application identifier", input: noAppID},
+		{name: "invalid developer certificate", input: badCert},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.mobileprovision")
+	require.NoError(t, os.WriteFile(path, newProfile(t, "TEAM123456.*", nil), 0600))
+
+	p, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "TEAM123456.*", p.ApplicationIdentifier())
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestProfile_Matches(t *testing.T) {
+	tests := []struct {
+		appID    string
+		bundleID string
+		want     bool
+	}{
+		{appID: "TEAM123456.com.example.app", bundleID: "com.example.app", want: true},
+		{appID: "TEAM123456.com.example.app", bundleID: "com.example.app.widget", want: false},
+		{appID: "TEAM123456.com.example.*", bundleID: "com.example.app.widget", want: true},
+		{appID: "TEAM123456.*", bundleID: "org.other", want: true},
+		{appID: "OTHER.com.example.app", bundleID: "com.example.app", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.appID+" "+tt.bundleID, func(t *testing.T) {
+			p, err := Parse(newProfile(t, tt.appID, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.Matches(tt.bundleID))
+		})
+	}
+}
+
+func TestProfile_EntitlementsFor(t *testing.T) {
+	wildcard, err := Parse(newProfile(t, "TEAM123456.*", entitlements.Entitlements{
+		"keychain-access-groups": []interface{}{"TEAM123456.*", "TEAM123456.shared"},
+	}))
+	require.NoError(t, err)
+
+	ents := wildcard.EntitlementsFor("com.example.app")
+	assert.Equal(t, "TEAM123456.com.example.app", ents["application-identifier"])
+	assert.Equal(t, []interface{}{"TEAM123456.com.example.app", "TEAM123456.shared"}, ents["keychain-access-groups"])
+	assert.Equal(t, true, ents["get-task-allow"])
+
+	// the profile itself is left untouched
+	assert.Equal(t, "TEAM123456.*", wildcard.ApplicationIdentifier())
+
+	explicit, err := Parse(newProfile(t, "TEAM123456.com.example.app", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "TEAM123456.com.example.app", explicit.EntitlementsFor("com.example.app")["application-identifier"])
+}
+
+func TestSelect(t *testing.T) {
+	parse := func(appID string) *Profile {
+		p, err := Parse(newProfile(t, appID, nil))
+		require.NoError(t, err)
+		return p
+	}
+	wildcard := parse("TEAM123456.*")
+	example := parse("TEAM123456.com.example.*")
+	app := parse("TEAM123456.com.example.app")
+	profiles := []*Profile{wildcard, example, app}
+
+	assert.Equal(t, app, Select(profiles, "com.example.app"))
+	assert.Equal(t, example, Select(profiles, "com.example.app.widget"))
+	assert.Equal(t, wildcard, Select(profiles, "org.other"))
+	assert.Nil(t, Select([]*Profile{app}, "org.other"))
+}
@@ -0,0 +1,73 @@
+/*
+Package remediation attaches machine readable guidance to common failures. Errors returned by quill that have a known
+fix wrap an *Error, which can be retrieved with errors.As (or Get) so that wrapping tools can show actionable hints:
+
+	var r *remediation.Error
+	if errors.As(err, &r) {
+		fmt.Printf("%s (%s)\n", r.Hint, r.Code)
+	}
+*/
+package remediation
+
+import "errors"
+
+// Code identifies a class of failure.
+type Code string
+
+const (
+	// IncompleteChain indicates that the certificate chain of the signing certificate could not be completed.
+	IncompleteChain Code = "incomplete-certificate-chain"
+	// InvalidSigningMaterial indicates that the signing certificate or key cannot be used to sign (e.g. expired).
+	InvalidSigningMaterial Code = "invalid-signing-material"
+	// TimestampUnavailable indicates that no timestamp server returned a timestamp token.
+	TimestampUnavailable Code = "timestamp-unavailable"
+	// TimestampOffline indicates that timestamping was requested while network access is disabled.
+	TimestampOffline Code = "timestamp-offline"
+	// NotSigned indicates that a binary must be signed before the operation (e.g. notarization).
+	NotSigned Code = "binary-not-signed"
+	// NotaryCredentials indicates that the App Store Connect API key used for notarization could not be loaded.
+	NotaryCredentials Code = "notary-credentials"
+	// UnexpectedLayout indicates that a binary does not end with its code signature (or __LINKEDIT), e.g. because data
+	// was appended to it.
+	UnexpectedLayout Code = "unexpected-binary-layout"
+)
+
+// Error is a failure with guidance on how to resolve it.
+type Error struct {
+	Code Code
+	// Hint is a human readable suggestion for resolving the failure.
+	Hint string
+	// Command is the quill command that resolves the failure (empty when there is none).
+	Command string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap attaches remediation guidance to the given error (nil errors stay nil).
+func Wrap(err error, code Code, hint, command string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Code:    code,
+		Hint:    hint,
+		Command: command,
+		Err:     err,
+	}
+}
+
+// Get returns the remediation guidance attached to the given error (if any).
+func Get(err error) (*Error, bool) {
+	var r *Error
+	if errors.As(err, &r) {
+		return r, true
+	}
+	return nil, false
+}
@@ -0,0 +1,29 @@
+package remediation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	assert.NoError(t, Wrap(nil, NotSigned, "sign it", "quill sign"))
+
+	cause := errors.New("binary is not signed")
+	err := fmt.Errorf("unable to notarize: %w", Wrap(cause, NotSigned, "sign it", "quill sign"))
+
+	assert.Equal(t, "unable to notarize: binary is not signed", err.Error())
+	assert.ErrorIs(t, err, cause)
+
+	r, ok := Get(err)
+	require.True(t, ok)
+	assert.Equal(t, NotSigned, r.Code)
+	assert.Equal(t, "sign it", r.Hint)
+	assert.Equal(t, "quill sign", r.Command)
+
+	_, ok = Get(cause)
+	assert.False(t, ok)
+}
@@ -2,31 +2,89 @@ package quill
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"strings"
 
 	blacktopMacho "github.com/blacktop/go-macho"
 
 	macholibre "github.com/anchore/go-macholibre"
 	"github.com/anchore/quill/internal/bus"
 	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/dmg"
 	"github.com/anchore/quill/quill/event"
 	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/network"
 	"github.com/anchore/quill/quill/pki"
 	"github.com/anchore/quill/quill/pki/load"
+	"github.com/anchore/quill/quill/provisioning"
+	"github.com/anchore/quill/quill/remediation"
 	"github.com/anchore/quill/quill/sign"
+	"github.com/anchore/quill/quill/timestamp"
+	"github.com/anchore/quill/quill/xar"
 )
 
 type SigningConfig struct {
 	SigningMaterial pki.SigningMaterial
 	Identity        string
 	Path            string
+	// IdentifierPrefix prefixes the identity when it is derived from the file name (see sign.PrefixIdentifier), it is
+	// not applied to identities set with WithIdentity or taken from an Info.plist.
+	IdentifierPrefix string
+	// ProvisioningProfiles are embedded into the app bundles they authorize when signing an app bundle or archive.
+	ProvisioningProfiles []*provisioning.Profile
+	// CodeDirectoryVersion is the code directory format version to write (the default version when zero).
+	CodeDirectoryVersion macho.CdVersion
+	// RuntimeVersion is the hardened runtime version to record (the SDK version of each binary when zero).
+	RuntimeVersion macho.Version
+	// PreserveLinkerSignature keeps the linker-generated ad-hoc signature of binaries when ad-hoc signing (see
+	// sign.BinaryOptions).
+	PreserveLinkerSignature bool
+	// LibraryValidation sets the library validation flag on the code directory of every signed binary.
+	LibraryValidation bool
+	// BlobEdit removes or replaces a single blob of the existing signature of the binary instead of signing it from
+	// scratch (see sign.EditBlob).
+	BlobEdit *sign.BlobEdit
+	// Resign signs binaries again carrying over their existing signature, after applying the Mutations (see
+	// sign.Resign).
+	Resign    bool
+	Mutations []sign.Mutation
+	// ArchiveMembers are the patterns selecting the binaries to sign within a zip or tar.gz archive (see sign.Archive),
+	// every binary within the archive is signed when empty.
+	ArchiveMembers []string
+	// LoadCommandEdits are applied to every binary before it is signed (see sign.BinaryOptions).
+	LoadCommandEdits []macho.LoadCommandEdit
+	// NormalizeLayout drops unreferenced content following the signature or __LINKEDIT of binaries before signing
+	// them (see sign.BinaryOptions).
+	NormalizeLayout bool
+
+	explicitIdentity bool
+	// identityTemplate is the identity set with WithIdentity when it holds placeholders, which are expanded for every
+	// binary with identityFields (see sign.ExpandIdentity).
+	identityTemplate string
+	identityFields   sign.IdentityFields
+}
+
+// NewSigningConfig creates a signing config for the given binary with already resolved signing material.
+func NewSigningConfig(binaryPath string, signingMaterial pki.SigningMaterial) *SigningConfig {
+	return &SigningConfig{
+		Path:            binaryPath,
+		Identity:        path.Base(binaryPath),
+		SigningMaterial: signingMaterial,
+	}
 }
 
 func NewSigningConfigFromPEMs(binaryPath, certificate, privateKey, password string, failWithoutFullChain bool) (*SigningConfig, error) {
+	return NewSigningConfigFromPEMsWithPassphrase(binaryPath, certificate, privateKey, load.StaticPassphrase(password), failWithoutFullChain)
+}
+
+// NewSigningConfigFromPEMsWithPassphrase is like NewSigningConfigFromPEMs, but the private key password is requested
+// from the given provider (only if the key is encrypted) instead of being passed in as plaintext.
+func NewSigningConfigFromPEMsWithPassphrase(binaryPath, certificate, privateKey string, provider load.PassphraseProvider, failWithoutFullChain bool) (*SigningConfig, error) {
 	var signingMaterial pki.SigningMaterial
 	if certificate != "" {
-		sm, err := pki.NewSigningMaterialFromPEMs(certificate, privateKey, password, failWithoutFullChain)
+		sm, err := pki.NewSigningMaterialFromPEMsWithPassphrase(certificate, privateKey, provider, failWithoutFullChain)
 		if err != nil {
 			return nil, err
 		}
@@ -41,6 +99,21 @@ func NewSigningConfigFromPEMs(binaryPath, certificate, privateKey, password stri
 	}, nil
 }
 
+// NewSigningConfigFromPEMReaders is like NewSigningConfigFromPEMsWithPassphrase, but the PEM encoded certificate
+// chain and private key are read from the given readers instead of from file paths.
+func NewSigningConfigFromPEMReaders(binaryPath string, certificate, privateKey io.Reader, provider load.PassphraseProvider, failWithoutFullChain bool) (*SigningConfig, error) {
+	sm, err := pki.NewSigningMaterialFromPEMReaders(certificate, privateKey, provider, failWithoutFullChain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningConfig{
+		Path:            binaryPath,
+		Identity:        path.Base(binaryPath),
+		SigningMaterial: *sm,
+	}, nil
+}
+
 func NewSigningConfigFromP12(binaryPath string, p12Content load.P12Contents, failWithoutFullChain bool) (*SigningConfig, error) {
 	signingMaterial, err := pki.NewSigningMaterialFromP12(p12Content, failWithoutFullChain)
 	if err != nil {
@@ -54,25 +127,269 @@ func NewSigningConfigFromP12(binaryPath string, p12Content load.P12Contents, fai
 	}, nil
 }
 
+// WithIdentity sets the identifier to sign with. The identity may be a template expanded for every signed binary
+// (e.g. "com.example.{{ .Basename }}", see sign.ExpandIdentity), which gives each binary of a batch, archive, or
+// universal binary its own identifier.
 func (c *SigningConfig) WithIdentity(id string) *SigningConfig {
 	if id != "" {
 		c.Identity = id
+		c.explicitIdentity = true
+		c.identityTemplate = ""
+		if sign.IsIdentityTemplate(id) {
+			c.identityTemplate = id
+		}
 	}
 	return c
 }
 
+// WithIdentifierPrefix sets the prefix given to identities derived from bare file names (e.g. "com.example." turns
+// "mytool" into "com.example.mytool"), similar to codesign --prefix. When signing app bundles, the prefix is given to
+// nested code identified by file name.
+func (c *SigningConfig) WithIdentifierPrefix(prefix string) *SigningConfig {
+	c.IdentifierPrefix = prefix
+	return c
+}
+
+// resolveIdentity settles the identifier to sign the binary with: the identity set with WithIdentity, otherwise the
+// CFBundleIdentifier of the binary's Info.plist (see sign.InfoPlistIdentifier), otherwise the (prefixed) file name.
+func (c *SigningConfig) resolveIdentity() error {
+	if c.identityTemplate != "" {
+		return c.resolveIdentityFields()
+	}
+	if c.explicitIdentity {
+		return nil
+	}
+	id, err := sign.InfoPlistIdentifier(c.Path)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		id = sign.PrefixIdentifier(c.IdentifierPrefix, c.Identity)
+	}
+	c.Identity = id
+	c.explicitIdentity = true
+	return nil
+}
+
+// resolveIdentityFields settles the identity template values of the binary which do not depend on the architecture
+// (before a universal binary is split into temp files).
+func (c *SigningConfig) resolveIdentityFields() error {
+	id, err := sign.InfoPlistIdentifier(c.Path)
+	if err != nil {
+		return err
+	}
+	c.identityFields.BundleID = id
+	if c.identityFields.Basename == "" {
+		c.identityFields.Basename = path.Base(c.Path)
+	}
+	return nil
+}
+
+// expandIdentity expands the identity template (if any) for the single-arch binary about to be signed.
+func (c *SigningConfig) expandIdentity() error {
+	if c.identityTemplate == "" {
+		return nil
+	}
+	arch, err := sign.BinaryArch(c.Path)
+	if err != nil {
+		return err
+	}
+	fields := c.identityFields
+	fields.Arch = arch
+
+	if c.Identity, err = sign.ExpandIdentity(c.identityTemplate, fields); err != nil {
+		return err
+	}
+	if c.Resign {
+		// the identifier of the existing signature is replaced with the expanded identity
+		c.Mutations = append(append([]sign.Mutation{}, c.Mutations...), sign.SetIdentifier(c.Identity))
+	}
+	return nil
+}
+
+// WithTimestampServer sets the timestamp server(s) to use, which may be a comma separated list of URLs (tried in
+// order until one succeeds). An empty value disables timestamping.
 func (c *SigningConfig) WithTimestampServer(url string) *SigningConfig {
-	c.SigningMaterial.TimestampServer = url
+	return c.WithTimestampServers(timestamp.ParseServers(url)...)
+}
+
+// WithTimestampServers sets the timestamp servers to use, tried in order until one succeeds.
+func (c *SigningConfig) WithTimestampServers(urls ...string) *SigningConfig {
+	c.SigningMaterial.Timestamp.Servers = urls
+	return c
+}
+
+// WithTimestamp sets how timestamp tokens are requested for the signature (see timestamp.Config).
+func (c *SigningConfig) WithTimestamp(cfg timestamp.Config) *SigningConfig {
+	c.SigningMaterial.Timestamp = cfg
+	return c
+}
+
+// WithExpiryPolicy controls when certificate expiration results in a warning or a failure before signing.
+func (c *SigningConfig) WithExpiryPolicy(policy pki.ExpiryPolicy) *SigningConfig {
+	c.SigningMaterial.ExpiryPolicy = policy
+	return c
+}
+
+// WithChainEmbedding controls which certificates of the signing chain are embedded into the CMS signature.
+func (c *SigningConfig) WithChainEmbedding(embedding pki.ChainEmbedding) *SigningConfig {
+	c.SigningMaterial.ChainEmbedding = embedding
+	return c
+}
+
+// WithSigningTime controls the signing time attribute of the CMS signature, which may be pinned (e.g. for reproducible
+// builds) or omitted instead of stamping the current time.
+func (c *SigningConfig) WithSigningTime(st pki.SigningTime) *SigningConfig {
+	c.SigningMaterial.SigningTime = st
+	return c
+}
+
+// WithSignatureAlgorithm selects the digest and RSA padding of the CMS signature, which default to what codesign uses
+// (SHA-256 with PKCS #1 v1.5 padding).
+func (c *SigningConfig) WithSignatureAlgorithm(alg pki.SignatureAlgorithm) *SigningConfig {
+	c.SigningMaterial.SignatureAlgorithm = alg
+	return c
+}
+
+// WithProvisioningProfiles sets the provisioning profiles to embed when signing an app bundle or archive, each
+// bundle is signed with the entitlements of the profile authorizing its bundle identifier.
+func (c *SigningConfig) WithProvisioningProfiles(profiles ...*provisioning.Profile) *SigningConfig {
+	c.ProvisioningProfiles = profiles
+	return c
+}
+
+// WithCodeDirectoryVersion sets the code directory format version to write (see sign.CodeDirectoryVersions), older
+// versions are compatible with older verifiers.
+func (c *SigningConfig) WithCodeDirectoryVersion(version macho.CdVersion) *SigningConfig {
+	c.CodeDirectoryVersion = version
+	return c
+}
+
+// WithRuntimeVersion overrides the hardened runtime version recorded in the code directory, which otherwise is the SDK
+// version the binary was built with (notarization rejects binaries built with an SDK older than macOS 10.9).
+func (c *SigningConfig) WithRuntimeVersion(version macho.Version) *SigningConfig {
+	c.RuntimeVersion = version
+	return c
+}
+
+// WithPreservedLinkerSignature keeps the ad-hoc signature the linker generates (e.g. for arm64 binaries) when ad-hoc
+// signing, instead of replacing it with a new ad-hoc signature.
+func (c *SigningConfig) WithPreservedLinkerSignature(preserve bool) *SigningConfig {
+	c.PreserveLinkerSignature = preserve
+	return c
+}
+
+// WithLibraryValidation requires the libraries loaded by the signed binaries to be signed by Apple or by the same team
+// (codesign --options library), which the com.apple.security.cs.disable-library-validation entitlement cannot relax.
+func (c *SigningConfig) WithLibraryValidation(enabled bool) *SigningConfig {
+	c.LibraryValidation = enabled
+	return c
+}
+
+// WithBlobEdit removes or replaces a single blob (e.g. the entitlements) of the existing signature of the binary,
+// carrying over the rest of the signature. A new signature is generated with the configured signing material.
+func (c *SigningConfig) WithBlobEdit(edit sign.BlobEdit) *SigningConfig {
+	c.BlobEdit = &edit
+	return c
+}
+
+// WithResign carries over the existing signature of the binary (the identifier, flags, entitlements, requirements,
+// and so on) after applying the given mutations, rather than signing it from scratch.
+func (c *SigningConfig) WithResign(mutations ...sign.Mutation) *SigningConfig {
+	c.Resign = true
+	c.Mutations = mutations
+	return c
+}
+
+// WithArchiveMembers selects the binaries to sign when signing a zip or tar.gz archive, by patterns matched against
+// the member names and their base names (e.g. "bin/*" or "mytool").
+func (c *SigningConfig) WithArchiveMembers(patterns ...string) *SigningConfig {
+	c.ArchiveMembers = patterns
+	return c
+}
+
+// WithLoadCommandEdits edits the load commands of every binary before signing it, e.g. macho.AddRpath("@loader_path/../lib")
+// or macho.ChangeDylib(old, new).
+func (c *SigningConfig) WithLoadCommandEdits(edits ...macho.LoadCommandEdit) *SigningConfig {
+	c.LoadCommandEdits = edits
+	return c
+}
+
+// WithNormalizedLayout drops the content following the code signature or __LINKEDIT of every binary which no load
+// command refers to (e.g. data appended to the binary) before signing it, instead of rejecting the binary.
+func (c *SigningConfig) WithNormalizedLayout(normalize bool) *SigningConfig {
+	c.NormalizeLayout = normalize
 	return c
 }
 
+// binaryOptions are the options applied to every signed binary.
+func (c SigningConfig) binaryOptions() sign.BinaryOptions {
+	return sign.BinaryOptions{
+		CodeDirectoryVersion:    c.CodeDirectoryVersion,
+		RuntimeVersion:          c.RuntimeVersion,
+		PreserveLinkerSignature: c.PreserveLinkerSignature,
+		LibraryValidation:       c.LibraryValidation,
+		LoadCommandEdits:        c.LoadCommandEdits,
+		NormalizeLayout:         c.NormalizeLayout,
+	}
+}
+
+// Resign signs the signed binary (single-arch or universal) at the given path again with the given signing material,
+// carrying over every attribute of its existing signature after applying the given mutations (e.g.
+// sign.AddFlags(macho.Runtime) to only turn on the hardened runtime).
+func Resign(path string, signingMaterial pki.SigningMaterial, mutations ...sign.Mutation) error {
+	return Sign(*NewSigningConfig(path, signingMaterial).WithResign(mutations...))
+}
+
+// Sign signs the binary (single-arch or universal), flat installer package (.pkg), app bundle (.app), iOS app
+// archive (.ipa), or the binaries within a zip or tar.gz archive at the configured path in place. App bundles, and
+// binaries with an Info.plist, are identified by their bundle identifier unless an identity is set with WithIdentity.
 func Sign(cfg SigningConfig) error {
+	info, err := os.Stat(cfg.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() || sign.IsIPA(cfg.Path) {
+		if cfg.BlobEdit != nil || cfg.Resign {
+			return errResignUnsupported
+		}
+		return signApp(cfg, info.IsDir())
+	}
+	if sign.IsArchive(cfg.Path) {
+		return signArchive(cfg)
+	}
+
 	f, err := os.Open(cfg.Path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
+	isPackage := xar.IsArchive(f)
+
+	purpose := pki.PurposeCodeSigning
+	if isPackage {
+		purpose = pki.PurposeInstaller
+	}
+	if err := validateSigningMaterial(cfg.SigningMaterial, purpose); err != nil {
+		return err
+	}
+
+	if err := checkOfflineTimestamp(cfg.SigningMaterial); err != nil {
+		return err
+	}
+
+	if isPackage {
+		if cfg.BlobEdit != nil || cfg.Resign {
+			return errResignUnsupported
+		}
+		return signPackage(cfg)
+	}
+
+	if err := cfg.resolveIdentity(); err != nil {
+		return err
+	}
+
 	if macholibre.IsUniversalMachoBinary(f) {
 		return signMultiarchBinary(cfg)
 	}
@@ -96,6 +413,31 @@ func Sign(cfg SigningConfig) error {
 	return err
 }
 
+var errResignUnsupported = fmt.Errorf("only binary signatures can be edited or carried over, sign app bundles and installer packages from scratch instead")
+
+// checkOfflineTimestamp fails when timestamping was requested while network access is disabled.
+func checkOfflineTimestamp(sm pki.SigningMaterial) error {
+	if network.Offline() && sm.Timestamp.Enabled() {
+		return remediation.Wrap(fmt.Errorf("timestamping was requested (%s), but network access is disabled (offline mode)", strings.Join(sm.Timestamp.Servers, ", ")),
+			remediation.TimestampOffline, "pass --timestamp-server \"\" to sign without a secure timestamp, or sign without --offline", "")
+	}
+	return nil
+}
+
+// validateSigningMaterial logs all warnings about the signing material, only returning an error for problems that
+// would result in a signature that cannot be verified.
+func validateSigningMaterial(sm pki.SigningMaterial, purpose pki.Purpose) error {
+	if leaf := sm.Leaf(); leaf != nil && sm.Signer != nil {
+		log.WithFields("identity", leaf.Subject.CommonName, "expires", leaf.NotAfter.Format("2006-01-02")).Info("signing certificate")
+	}
+
+	result := sm.ValidateFor(purpose)
+	for _, w := range result.Warnings() {
+		log.WithFields("check", w.Check).Warn(w.Message)
+	}
+	return result.Err()
+}
+
 //nolint:funlen
 func signMultiarchBinary(cfg SigningConfig) error {
 	log.WithFields("binary", cfg.Path).Info("signing multi-arch binary")
@@ -194,61 +536,153 @@ func signMultiarchBinary(cfg SigningConfig) error {
 func signSingleBinary(cfg SigningConfig) error {
 	log.WithFields("binary", cfg.Path).Info("signing binary")
 
-	m, err := macho.NewFile(cfg.Path)
-	if err != nil {
+	if err := cfg.expandIdentity(); err != nil {
 		return err
 	}
 
-	// check there already isn't a LcCodeSignature loader already (if there is, bail)
-	if m.HasCodeSigningCmd() {
-		log.Debug("binary already signed, removing signature...")
-		if err := m.RemoveSigningContent(); err != nil {
-			return fmt.Errorf("unable to remove existing code signature: %+v", err)
-		}
-	}
-
 	if cfg.SigningMaterial.Signer == nil {
 		bus.Notify("Warning: performed ad-hoc sign, which means that anyone can alter the binary contents without you knowing (there is no cryptographic signature)")
 		log.Warnf("only ad-hoc signing, which means that anyone can alter the binary contents without you knowing (there is no cryptographic signature)")
 	}
 
-	// (patch) add empty LcCodeSignature loader (offset and size references are not set)
-	if err = m.AddEmptyCodeSigningCmd(); err != nil {
-		return err
+	if cfg.Resign || cfg.BlobEdit != nil {
+		mutations := append([]sign.Mutation{}, cfg.Mutations...)
+		if cfg.BlobEdit != nil {
+			bus.Notify(fmt.Sprintf("Note: editing the signature (%s) generates a new signature, the existing signature is discarded", cfg.BlobEdit))
+			mutations = append(mutations, cfg.BlobEdit.Mutation())
+		}
+		return sign.Resign(cfg.Path, cfg.SigningMaterial, cfg.binaryOptions(), mutations...)
 	}
 
-	// first pass: add the signed data with the dummy loader
-	log.Debugf("estimating signing material size")
-	superBlobSize, sbBytes, err := sign.GenerateSigningSuperBlob(cfg.Identity, m, cfg.SigningMaterial, 0)
+	return sign.BinaryWithOptions(cfg.Path, cfg.Identity, cfg.SigningMaterial, cfg.binaryOptions())
+}
+
+func signPackage(cfg SigningConfig) error {
+	log.WithFields("package", cfg.Path).Info("signing installer package")
+
+	mon := bus.PublishTask(
+		event.Title{
+			Default:      "Sign package",
+			WhileRunning: "Signing package",
+			OnSuccess:    "Signed package",
+		},
+		cfg.Path,
+		-1,
+	)
+
+	err := sign.Package(cfg.Path, cfg.SigningMaterial)
 	if err != nil {
-		return fmt.Errorf("failed to add signing data on pass=1: %w", err)
+		mon.Err = err
+	} else {
+		mon.SetCompleted()
 	}
+	return err
+}
 
-	// (patch) make certain offset and size references to the superblob are finalized in the binary
-	log.Debugf("patching binary with updated superblob offsets")
-	if err = sign.UpdateSuperBlobOffsetReferences(m, uint64(len(sbBytes))); err != nil {
-		return nil
+func signApp(cfg SigningConfig, isBundle bool) error {
+	if err := validateSigningMaterial(cfg.SigningMaterial, pki.PurposeCodeSigning); err != nil {
+		return err
 	}
 
-	// second pass: now that all of the sizing is right, let's do it again with the final contents (replacing the hashes and signature)
-	log.Debug("creating signature for binary")
-	_, sbBytes, err = sign.GenerateSigningSuperBlob(cfg.Identity, m, cfg.SigningMaterial, superBlobSize)
-	if err != nil {
-		return fmt.Errorf("failed to add signing data on pass=2: %w", err)
+	if err := checkOfflineTimestamp(cfg.SigningMaterial); err != nil {
+		return err
 	}
 
-	// (patch) append the superblob to the __LINKEDIT section
-	log.Debugf("patching binary with signature")
+	title := event.Title{
+		Default:      "Sign app archive",
+		WhileRunning: "Signing app archive",
+		OnSuccess:    "Signed app archive",
+	}
+	if isBundle {
+		title = event.Title{
+			Default:      "Sign app bundle",
+			WhileRunning: "Signing app bundle",
+			OnSuccess:    "Signed app bundle",
+		}
+	}
+	mon := bus.PublishTask(title, cfg.Path, -1)
 
-	codeSigningCmd, _, err := m.CodeSigningCmd()
+	opts := sign.BundleOptions{
+		ProvisioningProfiles: cfg.ProvisioningProfiles,
+		IdentifierPrefix:     cfg.IdentifierPrefix,
+		Binary:               cfg.binaryOptions(),
+	}
+	if cfg.explicitIdentity {
+		opts.Identifier = cfg.Identity
+	}
+
+	var (
+		report *sign.BundleReport
+		err    error
+	)
+	if isBundle {
+		report, err = sign.Bundle(cfg.Path, cfg.SigningMaterial, opts)
+	} else {
+		report, err = sign.IPA(cfg.Path, cfg.SigningMaterial, opts)
+	}
 	if err != nil {
+		mon.Err = err
+		return err
+	}
+	mon.SetCompleted()
+
+	bus.Report(fmt.Sprintf("Signed %d code objects within %s (in signing order):\n%s", len(report.Signed), cfg.Path, report))
+	return nil
+}
+
+// signArchive signs the selected binaries within the zip or tar.gz archive, each identified by its file name (unless
+// an identity is set with WithIdentity).
+func signArchive(cfg SigningConfig) error {
+	if err := validateSigningMaterial(cfg.SigningMaterial, pki.PurposeCodeSigning); err != nil {
 		return err
 	}
 
-	if err = m.Patch(sbBytes, len(sbBytes), uint64(codeSigningCmd.DataOffset)); err != nil {
-		return fmt.Errorf("failed to patch super blob onto macho binary: %w", err)
+	if err := checkOfflineTimestamp(cfg.SigningMaterial); err != nil {
+		return err
+	}
+
+	log.WithFields("archive", cfg.Path).Info("signing binaries within archive")
+
+	mon := bus.PublishTask(
+		event.Title{
+			Default:      "Sign archive",
+			WhileRunning: "Signing archive",
+			OnSuccess:    "Signed archive",
+		},
+		cfg.Path,
+		-1,
+	)
+
+	signed, err := sign.Archive(cfg.Path, cfg.ArchiveMembers, func(binPath, member string) error {
+		c := cfg
+		c.Path = binPath
+		c.identityFields.Basename = path.Base(member)
+		if !c.explicitIdentity {
+			c.Identity = path.Base(member)
+		}
+		if err := c.resolveIdentity(); err != nil {
+			return err
+		}
+
+		f, err := os.Open(binPath)
+		if err != nil {
+			return err
+		}
+		universal := macholibre.IsUniversalMachoBinary(f)
+		f.Close()
+
+		if universal {
+			return signMultiarchBinary(c)
+		}
+		return signSingleBinary(c)
+	})
+	if err != nil {
+		mon.Err = err
+		return err
 	}
+	mon.SetCompleted()
 
+	bus.Report(fmt.Sprintf("Signed %d binaries within %s:\n%s", len(signed), cfg.Path, strings.Join(signed, "\n")))
 	return nil
 }
 
@@ -259,6 +693,26 @@ func IsSigned(path string) (bool, error) {
 	}
 	defer f.Close()
 
+	if xar.IsArchive(f) {
+		by, err := io.ReadAll(f)
+		if err != nil {
+			return false, err
+		}
+		archive, err := xar.Parse(by)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse installer package: %w", err)
+		}
+		return archive.Signed(), nil
+	}
+
+	if info, err := f.Stat(); err == nil && dmg.IsDiskImage(f, info.Size()) {
+		_, size, err := dmg.CodeSignature(f, info.Size())
+		if err != nil {
+			return false, fmt.Errorf("failed to read disk image: %w", err)
+		}
+		return size > 0, nil
+	}
+
 	if macholibre.IsUniversalMachoBinary(f) {
 		log.WithFields("binary", path).Trace("binary is a universal binary")
 		mf, err := blacktopMacho.NewFatFile(f)
@@ -0,0 +1,280 @@
+package sign
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// MemberSigner signs the binary extracted from the given archive member to the given path in place.
+type MemberSigner func(binPath, member string) error
+
+// IsArchive indicates if the given path is a zip archive or a gzip compressed tar archive (by extension) which may
+// hold binaries to sign. iOS app archives are signed as an app instead (see IPA).
+func IsArchive(path string) bool {
+	return archiveKind(path) != ""
+}
+
+func archiveKind(p string) string {
+	name := strings.ToLower(filepath.Base(p))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// Archive signs the Mach-O members of the zip or gzip compressed tar archive at the given path in place, returning
+// the names of the signed members. Only members matching any of the given patterns (matched against the member name
+// and its base name, see path.Match) are signed, every Mach-O member is signed when there are no patterns. The
+// archive is rewritten in member order, keeping the metadata of every member (e.g. modes and modification times).
+func Archive(archivePath string, patterns []string, signMember MemberSigner) ([]string, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid archive member pattern %q: %w", p, err)
+		}
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "quill-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temp directory to extract archive members: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// write the new archive next to the original, so it can be swapped in place
+	tmp, err := os.CreateTemp(filepath.Dir(archivePath), "."+filepath.Base(archivePath)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signed archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	a := archiveRewriter{dir: dir, patterns: patterns, signMember: signMember}
+	switch archiveKind(archivePath) {
+	case "zip":
+		err = a.rewriteZip(archivePath, tmp)
+	case "tar.gz":
+		err = a.rewriteTarGz(archivePath, tmp)
+	default:
+		err = fmt.Errorf("unsupported archive: %q", archivePath)
+	}
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(a.signed) == 0 {
+		return nil, fmt.Errorf("no binaries to sign found within %q", archivePath)
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.PatchStarted, Path: archivePath})
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		return nil, fmt.Errorf("unable to replace archive: %w", err)
+	}
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.SignFinished, Path: archivePath})
+
+	return a.signed, nil
+}
+
+type archiveRewriter struct {
+	dir        string
+	patterns   []string
+	signMember MemberSigner
+	signed     []string
+}
+
+func (a archiveRewriter) selected(member string) bool {
+	if len(a.patterns) == 0 {
+		return true
+	}
+	for _, p := range a.patterns {
+		if ok, _ := path.Match(p, member); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(member)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sign extracts the given member content and signs it if it is a binary, returning the content to write back.
+func (a *archiveRewriter) sign(member string, content io.Reader) ([]byte, error) {
+	target := filepath.Join(a.dir, fmt.Sprintf("%d", len(a.signed)), path.Base(member))
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(filepath.Dir(target))
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0700)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(out, content); err != nil { //nolint:gosec // the archive is the input being signed
+		out.Close()
+		return nil, fmt.Errorf("unable to extract archive member %q: %w", member, err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	if isMacho, err := macho.IsMachoFile(target); err != nil || !isMacho {
+		// not a binary after all (the content is written back as is)
+		return os.ReadFile(target)
+	}
+
+	log.WithFields("member", member).Debug("signing archive member")
+	if err := a.signMember(target, member); err != nil {
+		return nil, fmt.Errorf("unable to sign archive member %q: %w", member, err)
+	}
+	a.signed = append(a.signed, member)
+	return os.ReadFile(target)
+}
+
+// candidate indicates if the given leading bytes of a member may be a Mach-O binary (thin or universal).
+func candidate(head []byte) bool {
+	if len(head) < 4 {
+		return false
+	}
+	switch macho.SigningOrder.Uint32(head) {
+	case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe, 0xcafebabe:
+		return true
+	}
+	return false
+}
+
+func (a *archiveRewriter) rewriteZip(archivePath string, w io.Writer) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("unable to open zip archive: %w", err)
+	}
+	defer r.Close()
+
+	zw := zip.NewWriter(w)
+	if err := zw.SetComment(r.Comment); err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		signed, err := a.zipMember(f)
+		if err != nil {
+			return err
+		}
+		if signed == nil {
+			// copy the member as is (without recompressing it)
+			if err := zw.Copy(f); err != nil {
+				return fmt.Errorf("unable to copy archive member %q: %w", f.Name, err)
+			}
+			continue
+		}
+
+		header := f.FileHeader
+		header.CRC32, header.CompressedSize, header.CompressedSize64, header.UncompressedSize, header.UncompressedSize64 = 0, 0, 0, 0, 0
+		entry, err := zw.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(signed); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *archiveRewriter) zipMember(f *zip.File) ([]byte, error) {
+	if !f.Mode().IsRegular() || !a.selected(f.Name) {
+		return nil, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archive member %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(rc, head)
+	if !candidate(head[:n]) {
+		return nil, nil
+	}
+	return a.sign(f.Name, io.MultiReader(bytes.NewReader(head[:n]), rc))
+}
+
+func (a *archiveRewriter) rewriteTarGz(archivePath string, w io.Writer) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("unable to open compressed tar archive: %w", err)
+	}
+	defer gr.Close()
+
+	gw := gzip.NewWriter(w)
+	gw.Header = gr.Header
+	tr, tw := tar.NewReader(gr), tar.NewWriter(gw)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read tar archive: %w", err)
+		}
+
+		var content io.Reader = tr
+		if header.Typeflag == tar.TypeReg && a.selected(header.Name) {
+			head := make([]byte, 4)
+			n, _ := io.ReadFull(tr, head)
+			content = io.MultiReader(bytes.NewReader(head[:n]), tr)
+
+			if candidate(head[:n]) {
+				signed, err := a.sign(header.Name, content)
+				if err != nil {
+					return err
+				}
+				header.Size = int64(len(signed))
+				content = bytes.NewReader(signed)
+			}
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, content); err != nil { //nolint:gosec // the archive is the input being signed
+			return fmt.Errorf("unable to copy archive member %q: %w", header.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
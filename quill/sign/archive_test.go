@@ -0,0 +1,227 @@
+package sign
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+type archiveMember struct {
+	name    string
+	mode    os.FileMode
+	content []byte
+}
+
+var archiveTime = time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+func testArchiveMembers(t *testing.T) []archiveMember {
+	t.Helper()
+
+	bin := filepath.Join(t.TempDir(), "tool")
+	writeUnsignedBinary(t, bin)
+	content, err := os.ReadFile(bin)
+	require.NoError(t, err)
+
+	return []archiveMember{
+		{name: "README.md", mode: 0644, content: []byte("# tool\n")},
+		{name: "bin/tool", mode: 0755, content: content},
+		{name: "lib/helper", mode: 0755, content: content},
+	}
+}
+
+func writeTestZip(t *testing.T, path string, members []archiveMember) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, m := range members {
+		header := &zip.FileHeader{Name: m.name, Method: zip.Deflate, Modified: archiveTime}
+		header.SetMode(m.mode)
+		w, err := zw.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write(m.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.SetComment("release"))
+	require.NoError(t, zw.Close())
+}
+
+func writeTarGz(t *testing.T, path string, members []archiveMember) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, m := range members {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: m.name, Mode: int64(m.mode), Size: int64(len(m.content)), ModTime: archiveTime, Typeflag: tar.TypeReg}))
+		_, err := tw.Write(m.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+}
+
+// readArchive returns the members of the given archive (the modes and contents, by name).
+func readArchive(t *testing.T, path string) map[string]archiveMember {
+	t.Helper()
+
+	members := make(map[string]archiveMember)
+	if archiveKind(path) == "zip" {
+		r, err := zip.OpenReader(path)
+		require.NoError(t, err)
+		defer r.Close()
+
+		assert.Equal(t, "release", r.Comment)
+		for _, f := range r.File {
+			assert.True(t, f.Modified.Equal(archiveTime), "modification time of %q", f.Name)
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+			members[f.Name] = archiveMember{name: f.Name, mode: f.Mode(), content: content}
+		}
+		return members
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.True(t, header.ModTime.Equal(archiveTime), "modification time of %q", header.Name)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		members[header.Name] = archiveMember{name: header.Name, mode: header.FileInfo().Mode(), content: content}
+	}
+	return members
+}
+
+func isSignedContent(t *testing.T, content []byte) bool {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "member")
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	m, err := macho.NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	return m.HasCodeSigningCmd()
+}
+
+func TestArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		archive  string
+		patterns []string
+		signed   []string
+	}{
+		{
+			name:    "zip",
+			archive: "release.zip",
+			signed:  []string{"bin/tool", "lib/helper"},
+		},
+		{
+			name:    "tar.gz",
+			archive: "release.tar.gz",
+			signed:  []string{"bin/tool", "lib/helper"},
+		},
+		{
+			name:     "zip members matching pattern",
+			archive:  "release.zip",
+			patterns: []string{"bin/*"},
+			signed:   []string{"bin/tool"},
+		},
+		{
+			name:     "tar.gz members matching base name",
+			archive:  "release.tgz",
+			patterns: []string{"helper"},
+			signed:   []string{"lib/helper"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := testArchiveMembers(t)
+			path := filepath.Join(t.TempDir(), tt.archive)
+			if archiveKind(path) == "zip" {
+				writeTestZip(t, path, members)
+			} else {
+				writeTarGz(t, path, members)
+			}
+
+			var identities []string
+			signed, err := Archive(path, tt.patterns, func(binPath, member string) error {
+				identities = append(identities, filepath.Base(member))
+				return Binary(binPath, filepath.Base(member), pki.SigningMaterial{})
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.signed, signed)
+			assert.Len(t, identities, len(tt.signed))
+
+			got := readArchive(t, path)
+			require.Len(t, got, len(members))
+			for _, want := range members {
+				m, ok := got[want.name]
+				require.True(t, ok, "missing member %q", want.name)
+				assert.Equal(t, want.mode, m.mode, "mode of %q", want.name)
+
+				if contains(tt.signed, want.name) {
+					assert.True(t, isSignedContent(t, m.content), "member %q must be signed", want.name)
+				} else {
+					assert.Equal(t, want.content, m.content, "member %q must be left as is", want.name)
+				}
+			}
+		})
+	}
+}
+
+func TestArchive_noBinaries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.zip")
+	writeTestZip(t, path, []archiveMember{{name: "README.md", mode: 0644, content: []byte("# tool\n")}})
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	_, err = Archive(path, nil, func(string, string) error {
+		t.Fatal("no member must be signed")
+		return nil
+	})
+	require.ErrorContains(t, err, "no binaries to sign")
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "the archive must be left untouched")
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/metrics"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/remediation"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+// Binary signs the single-arch binary at the given path in place with the given identity, replacing any existing
+// signature. An ad-hoc signature is created when the signing material has no signer.
+func Binary(path, id string, signingMaterial pki.SigningMaterial) error {
+	return BinaryWithOptions(path, id, signingMaterial, BinaryOptions{})
+}
+
+// BinaryWithOptions signs the single-arch binary at the given path in place (as Binary does), binding the given
+// bundle details (Info.plist, sealed resources, entitlements) to the signature.
+func BinaryWithOptions(path, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions) (err error) {
+	start := time.Now()
+	defer func() {
+		result := metrics.Result(err)
+		metrics.ObserveSince(metrics.SignDuration, start, metrics.Labels{"result": result})
+		metrics.Count(metrics.Signatures, 1, metrics.Labels{"result": result, "adhoc": strconv.FormatBool(signingMaterial.Signer == nil)})
+	}()
+
+	m, err := macho.NewFile(path)
+	if err != nil {
+		return err
+	}
+
+	linkerSigned, flagsErr := m.IsLinkerSigned()
+	if flagsErr != nil {
+		log.Debugf("unable to read the existing code directory flags: %v", flagsErr)
+	}
+	if linkerSigned {
+		if signingMaterial.Signer == nil && opts.PreserveLinkerSignature && !opts.bindsBundleDetails() && len(opts.LoadCommandEdits) == 0 {
+			log.WithFields("binary", path).Info("keeping the linker-generated ad-hoc signature")
+			return nil
+		}
+		log.WithFields("binary", path).Debug("replacing the linker-generated ad-hoc signature")
+	}
+
+	// a prepared binary keeps the size of the reserved signature space (see Prepare), and re-signing keeps the size of
+	// the existing signature when the new one fits, so the binary is patched in place without being resized
+	var fitExisting bool
+	if opts.SignatureSize == 0 {
+		var reserved bool
+		if opts.SignatureSize, reserved, err = existingSignatureSize(m); err != nil {
+			return err
+		}
+		fitExisting = opts.SignatureSize > 0 && !reserved
+	}
+
+	if err := prepareBinary(m, opts); err != nil {
+		return err
+	}
+
+	// the page hashes of the first pass are reused by the second pass (only the load commands change in between)
+	pages := pageHashCache{}
+
+	if fitExisting {
+		fits, err := fitsSignatureSize(m, id, signingMaterial, opts, pages)
+		if err != nil {
+			return err
+		}
+		if !fits {
+			log.WithFields("bytes", opts.SignatureSize).Debug("the new signature does not fit the space of the existing signature, growing it")
+			opts.SignatureSize, fitExisting = 0, false
+		}
+	}
+
+	sbBytes, err := signPasses(m, id, signingMaterial, opts, pages)
+	if fitExisting && errors.Is(err, errSignatureTooLarge) {
+		// the timestamp token is larger than allowed for, which is only known once it was requested
+		log.WithFields("bytes", opts.SignatureSize, "error", err).Debug("the new signature no longer fits the space of the existing signature, growing it")
+		opts.SignatureSize = 0
+		sbBytes, err = signPasses(m, id, signingMaterial, opts, pages)
+	}
+	if err != nil {
+		return err
+	}
+
+	// (patch) append the superblob to the __LINKEDIT section
+	log.Debugf("patching binary with signature")
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.PatchStarted, Path: path})
+
+	if err = m.WriteCodeSignature(sbBytes); err != nil {
+		return err
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.SignFinished, Path: path})
+
+	return nil
+}
+
+// signPasses generates the final signature of the (prepared) binary with two passes: the first one sizes the
+// signature, which is referenced by the load commands hashed by the second one.
+func signPasses(m *macho.File, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions, pages pageHashCache) ([]byte, error) {
+	// first pass: add the signed data with the dummy loader
+	log.Debugf("estimating signing material size")
+	paddingTarget, err := signaturePaddingTarget(opts.SignatureSize)
+	if err != nil {
+		return nil, err
+	}
+	superBlobSize, sbBytes, err := generateSigningSuperBlob(id, m, signingMaterial, opts, paddingTarget, pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add signing data on pass=1: %w", err)
+	}
+
+	// (patch) make certain offset and size references to the superblob are finalized in the binary
+	log.Debugf("patching binary with updated superblob offsets")
+	if err = UpdateSuperBlobOffsetReferences(m, uint64(len(sbBytes))); err != nil {
+		return nil, err
+	}
+
+	// second pass: now that all of the sizing is right, let's do it again with the final contents (replacing the hashes and signature)
+	log.Debug("creating signature for binary")
+	_, sbBytes, err = generateSigningSuperBlob(id, m, signingMaterial, opts, superBlobSize, pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add signing data on pass=2: %w", err)
+	}
+	return sbBytes, nil
+}
+
+// fitsSignatureSize indicates if the signature fits opts.SignatureSize with MaxTimestampTokenSize to spare, since the
+// size of timestamp tokens (and of the signature itself) varies between passes. The signature is generated without a
+// timestamp, so no timestamp is requested from the authority for a signature that does not fit.
+func fitsSignatureSize(m *macho.File, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions, pages pageHashCache) (bool, error) {
+	paddingTarget, err := signaturePaddingTarget(opts.SignatureSize)
+	if err != nil {
+		return false, err
+	}
+	paddingTarget -= MaxTimestampTokenSize
+	if paddingTarget <= 0 {
+		return false, nil
+	}
+
+	signingMaterial.Timestamp = timestamp.Config{}
+	_, _, err = generateSigningSuperBlob(id, m, signingMaterial, opts, paddingTarget, pages)
+	switch {
+	case errors.Is(err, errSignatureTooLarge):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("unable to estimate the signature size: %w", err)
+	}
+	return true, nil
+}
+
+// prepareBinary readies the binary for the signing passes: the existing signature is removed, the load command edits
+// are applied and an empty code signing load command is added.
+func prepareBinary(m *macho.File, opts BinaryOptions) error {
+	if err := checkLayout(m, opts.NormalizeLayout); err != nil {
+		return err
+	}
+
+	// check there already isn't a LcCodeSignature loader already (if there is, bail)
+	if m.HasCodeSigningCmd() {
+		log.Debug("binary already signed, removing signature...")
+		if err := m.RemoveSigningContent(); err != nil {
+			return fmt.Errorf("unable to remove existing code signature: %+v", err)
+		}
+	}
+
+	// (patch) edit the load commands while there is no code signature command, which would otherwise have to move
+	for _, edit := range opts.LoadCommandEdits {
+		if err := edit(m); err != nil {
+			return fmt.Errorf("unable to edit load commands: %w", err)
+		}
+	}
+
+	// (patch) add empty LcCodeSignature loader (offset and size references are not set)
+	return m.AddEmptyCodeSigningCmd()
+}
+
+// existingSignatureSize returns the size of the existing code signature of the binary, or of the space reserved for it
+// by Prepare (which is reported as well), zero when the binary is unsigned.
+func existingSignatureSize(m *macho.File) (int, bool, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil || cmd == nil {
+		return 0, false, err
+	}
+	reserved, err := m.HasReservedCodeSignature()
+	if err != nil {
+		return 0, false, err
+	}
+	return int(cmd.DataSize), reserved, nil
+}
+
+// checkLayout rejects binaries whose content does not end with their code signature (or __LINKEDIT), which signing
+// would silently overwrite or misplace, unless the layout is normalized.
+func checkLayout(m *macho.File, normalize bool) error {
+	err := m.CheckLayout()
+	switch {
+	case err == nil:
+		return nil
+	case normalize && macho.IsNormalizableLayout(err):
+		log.WithFields("reason", err).Info("normalizing the binary layout")
+		return m.NormalizeLayout()
+	case macho.IsNormalizableLayout(err):
+		return remediation.Wrap(err, remediation.UnexpectedLayout,
+			"pass --normalize-layout to drop the content no load command refers to before signing (it will be lost)", "")
+	}
+	return err
+}
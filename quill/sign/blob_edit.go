@@ -0,0 +1,101 @@
+package sign
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-restruct/restruct"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+// BlobKind is a blob of an existing signature that can be removed or replaced on its own (see EditBlob).
+type BlobKind string
+
+const (
+	// EntitlementsBlob is both the XML and DER entitlements blobs.
+	EntitlementsBlob BlobKind = "entitlements"
+	// RequirementsBlob is the internal requirements set (which holds the designated requirement).
+	RequirementsBlob BlobKind = "requirements"
+)
+
+// BlobKinds is every BlobKind that can be edited.
+var BlobKinds = []BlobKind{EntitlementsBlob, RequirementsBlob}
+
+// ParseBlobKind parses the user-facing name of a blob kind.
+func ParseBlobKind(value string) (BlobKind, error) {
+	kind := BlobKind(strings.ToLower(strings.TrimSpace(value)))
+	for _, k := range BlobKinds {
+		if kind == k {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("invalid blob %q (must be one of %s)", value, BlobKinds)
+}
+
+// BlobEdit removes or replaces a single blob of an existing signature.
+type BlobEdit struct {
+	Kind BlobKind
+	// Content replaces the blob: an (XML or binary) entitlements plist, or a compiled requirements set (as written by
+	// csreq -b). The blob is removed when empty (an empty requirements set is embedded in place of the requirements).
+	Content []byte
+}
+
+// Remove indicates the blob is removed rather than replaced.
+func (e BlobEdit) Remove() bool {
+	return len(e.Content) == 0
+}
+
+func (e BlobEdit) String() string {
+	if e.Remove() {
+		return fmt.Sprintf("remove %s", e.Kind)
+	}
+	return fmt.Sprintf("replace %s", e.Kind)
+}
+
+// Mutation returns the mutation removing or replacing the edited blob of the existing signature.
+func (e BlobEdit) Mutation() Mutation {
+	return func(sig *ExistingSignature) error {
+		switch e.Kind {
+		case EntitlementsBlob:
+			if e.Remove() {
+				sig.Entitlements = nil
+				return nil
+			}
+			ents, err := entitlements.ParsePlist(e.Content)
+			if err != nil {
+				return fmt.Errorf("unable to decode entitlements: %w", err)
+			}
+			sig.Entitlements = ents
+		case RequirementsBlob:
+			if e.Remove() {
+				empty := macho.NewBlob(macho.MagicRequirements, []byte{0, 0, 0, 0})
+				by, err := restruct.Pack(macho.SigningOrder, &empty)
+				if err != nil {
+					return fmt.Errorf("unable to encode empty requirements set: %w", err)
+				}
+				sig.Requirements = by
+				return nil
+			}
+			if _, err := macho.DecodeRequirementSet(e.Content); err != nil {
+				return fmt.Errorf("invalid requirements set: %w", err)
+			}
+			sig.Requirements = e.Content
+		default:
+			return fmt.Errorf("invalid blob %q (must be one of %s)", e.Kind, BlobKinds)
+		}
+		return nil
+	}
+}
+
+// EditBlob removes or replaces a single blob of the existing signature of the single-arch binary at the given path,
+// carrying over the rest of the existing signature (see Resign). Since the code directory hashes every blob, a new
+// signature is always generated (with the given signing material) rather than patching the existing one.
+func EditBlob(path string, signingMaterial pki.SigningMaterial, edit BlobEdit, opts BinaryOptions) error {
+	log.WithFields("binary", path).Infof("%s and generate a new signature", edit)
+
+	return Resign(path, signingMaterial, opts, edit.Mutation())
+}
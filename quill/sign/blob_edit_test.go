@@ -0,0 +1,116 @@
+package sign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+func TestParseBlobKind(t *testing.T) {
+	kind, err := ParseBlobKind(" Entitlements ")
+	require.NoError(t, err)
+	assert.Equal(t, EntitlementsBlob, kind)
+
+	kind, err = ParseBlobKind("requirements")
+	require.NoError(t, err)
+	assert.Equal(t, RequirementsBlob, kind)
+
+	_, err = ParseBlobKind("code-directory")
+	require.Error(t, err)
+}
+
+func TestBlobEdit_Mutation(t *testing.T) {
+	emptyRequirements := []byte{0xfa, 0xde, 0x0c, 0x01, 0, 0, 0, 12, 0, 0, 0, 0}
+	current := func() *ExistingSignature {
+		return &ExistingSignature{
+			Identifier:   "com.example.tool",
+			Entitlements: entitlements.Entitlements{"com.apple.security.get-task-allow": true},
+			Requirements: []byte("existing requirements"),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		edit    BlobEdit
+		want    *ExistingSignature
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "remove entitlements",
+			edit: BlobEdit{Kind: EntitlementsBlob},
+			want: &ExistingSignature{
+				Identifier:   "com.example.tool",
+				Requirements: []byte("existing requirements"),
+			},
+		},
+		{
+			name: "replace entitlements",
+			edit: BlobEdit{Kind: EntitlementsBlob, Content: []byte(entitlements.Entitlements{"com.apple.security.cs.allow-jit": true}.XML())},
+			want: &ExistingSignature{
+				Identifier:   "com.example.tool",
+				Entitlements: entitlements.Entitlements{"com.apple.security.cs.allow-jit": true},
+				Requirements: []byte("existing requirements"),
+			},
+		},
+		{
+			name:    "replace entitlements with an invalid plist",
+			edit:    BlobEdit{Kind: EntitlementsBlob, Content: []byte("not a plist")},
+			wantErr: require.Error,
+		},
+		{
+			name: "remove requirements",
+			edit: BlobEdit{Kind: RequirementsBlob},
+			want: &ExistingSignature{
+				Identifier:   "com.example.tool",
+				Entitlements: entitlements.Entitlements{"com.apple.security.get-task-allow": true},
+				Requirements: emptyRequirements,
+			},
+		},
+		{
+			name: "replace requirements",
+			edit: BlobEdit{Kind: RequirementsBlob, Content: emptyRequirements},
+			want: &ExistingSignature{
+				Identifier:   "com.example.tool",
+				Entitlements: entitlements.Entitlements{"com.apple.security.get-task-allow": true},
+				Requirements: emptyRequirements,
+			},
+		},
+		{
+			name:    "replace requirements with an entitlements blob",
+			edit:    BlobEdit{Kind: RequirementsBlob, Content: []byte{0xfa, 0xde, 0x71, 0x71, 0, 0, 0, 8}},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			sig := current()
+			err := tt.edit.Mutation()(sig)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, sig)
+		})
+	}
+}
+
+func TestNewRequirementsBlob_given(t *testing.T) {
+	requirements := []byte{0xfa, 0xde, 0x0c, 0x01, 0, 0, 0, 12, 0, 0, 0, 0}
+
+	blob, err := newRequirementsBlob("com.example.tool", pki.SigningMaterial{}, requirements)
+	require.NoError(t, err)
+	assert.Equal(t, macho.MagicRequirements, blob.Magic)
+	assert.Equal(t, uint32(len(requirements)), blob.Length)
+	assert.Equal(t, []byte{0, 0, 0, 0}, blob.Payload)
+
+	_, err = newRequirementsBlob("com.example.tool", pki.SigningMaterial{}, []byte{0xfa, 0xde, 0x0c, 0x02, 0, 0, 0, 8})
+	require.Error(t, err)
+}
@@ -0,0 +1,312 @@
+package sign
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	macholibre "github.com/anchore/go-macholibre"
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/provisioning"
+)
+
+// BundleOptions configures the signing of an app bundle.
+type BundleOptions struct {
+	// ProvisioningProfiles are embedded into the app and app extension bundles they authorize (selected by bundle
+	// identifier), the bundle is then signed with the entitlements of the selected profile. Without profiles, the
+	// profile already embedded within each bundle (if any) is kept and used.
+	ProvisioningProfiles []*provisioning.Profile
+	// Identifier overrides the signing identifier of the bundle (by default its CFBundleIdentifier), the nested code
+	// keeps its own identifiers. It may be an identity template (see ExpandIdentity).
+	Identifier string
+	// IdentifierPrefix prefixes the identifiers of nested code without an Info.plist (e.g. helper executables and
+	// libraries, identified by file name), see PrefixIdentifier.
+	IdentifierPrefix string
+	// Binary are the options applied to every binary signed within the bundle (the bundle details, such as the
+	// Info.plist and sealed resources, are added to them).
+	Binary BinaryOptions
+}
+
+// bundleInfo is the part of the Info.plist needed for signing.
+type bundleInfo struct {
+	raw        []byte
+	identifier string
+	executable string
+}
+
+// Bundle signs the app bundle at the given directory in place, both (shallow) iOS bundles and (deep) macOS bundles
+// are supported. All nested code (frameworks, libraries, app extensions, XPC services, helpers, and login items) is
+// signed first, innermost first, then the bundle resources are sealed (the _CodeSignature/CodeResources file) and the
+// main executable is signed, binding the Info.plist and sealed resources to the signature. The returned report lists
+// everything signed in signing order.
+func Bundle(dir string, signingMaterial pki.SigningMaterial, opts BundleOptions) (*BundleReport, error) {
+	root, err := planBundle(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	root.prefixIdentifiers(opts.IdentifierPrefix)
+	if opts.Identifier != "" {
+		if root.identifier, err = bundleIdentifier(dir, root, opts.Identifier); err != nil {
+			return nil, err
+		}
+	}
+
+	var report BundleReport
+	if _, err := signNode(dir, root, signingMaterial, opts, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// bundleIdentifier returns the identifier overriding the one of the given bundle, expanding identity templates (see
+// ExpandIdentity) for the bundle.
+func bundleIdentifier(dir string, root *codeNode, id string) (string, error) {
+	if !IsIdentityTemplate(id) {
+		return id, nil
+	}
+	name := filepath.Base(dir)
+	return ExpandIdentity(id, IdentityFields{
+		Basename: strings.TrimSuffix(name, filepath.Ext(name)),
+		BundleID: root.identifier,
+	})
+}
+
+// signNode signs the given code (and the code nested within it), returning its seal.
+func signNode(top string, node *codeNode, signingMaterial pki.SigningMaterial, opts BundleOptions, report *BundleReport) (*NestedCode, error) {
+	var (
+		seal *NestedCode
+		err  error
+	)
+	if node.layout == nil {
+		log.WithFields("path", node.path, "kind", node.kind).Info("signing nested code")
+		seal, err = signCode(node.path, node.identifier, signingMaterial, opts.Binary)
+	} else {
+		seal, err = signBundle(top, node, signingMaterial, opts, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.add(top, node)
+	return seal, nil
+}
+
+//nolint:funlen
+func signBundle(top string, node *codeNode, signingMaterial pki.SigningMaterial, opts BundleOptions, report *BundleReport) (*NestedCode, error) {
+	layout, info := node.layout, node.info
+	log.WithFields("bundle", node.path, "identifier", node.identifier).Info("signing bundle")
+
+	nested := map[string]NestedCode{}
+	for _, child := range node.nested {
+		seal, err := signNode(top, child, signingMaterial, opts, report)
+		if err != nil {
+			return nil, fmt.Errorf("unable to sign nested code %q: %w", child.rel, err)
+		}
+		nested[child.rel] = *seal
+	}
+
+	binOpts := opts.Binary
+	binOpts.InfoPlist = info.raw
+	var signingTeam string
+	if leaf := signingMaterial.Leaf(); leaf != nil && signingMaterial.Signer != nil && len(leaf.Subject.OrganizationalUnit) > 0 {
+		signingTeam = leaf.Subject.OrganizationalUnit[0]
+		binOpts.TeamID = signingTeam
+	}
+
+	if usesProvisioningProfile(node.kind) {
+		profile, err := bundleProfile(node.path, info.identifier, opts)
+		if err != nil {
+			return nil, err
+		}
+		if profile != nil {
+			log.WithFields("bundle", node.path, "profile", profile.Name).Debug("signing with provisioning profile")
+			binOpts.Entitlements = profile.EntitlementsFor(info.identifier)
+			if team := profile.TeamID(); team != "" && signingMaterial.Signer != nil {
+				binOpts.TeamID = team
+			}
+			// catch what would otherwise only be rejected when the app is installed or launched
+			if issues := profile.Inconsistencies(info.identifier, signingTeam, binOpts.Entitlements); len(issues) > 0 {
+				return nil, fmt.Errorf("bundle %q is not authorized by provisioning profile %q: %s", node.identifier, profile.Name, strings.Join(issues, "; "))
+			}
+			if signingMaterial.Signer != nil && !profile.AllowsCertificate(signingMaterial.Leaf()) {
+				return nil, fmt.Errorf("the signing certificate %q is not one of the developer certificates of provisioning profile %q", signingMaterial.Leaf().Subject.CommonName, profile.Name)
+			}
+		}
+	}
+
+	binOpts.KernelExtension = node.kind == KindKernelExtension
+	for _, w := range extensionWarnings(node, binOpts.Entitlements, signingMaterial) {
+		log.WithFields("bundle", node.path).Warn(w)
+		report.Warnings = append(report.Warnings, w)
+	}
+
+	sealDir := filepath.Join(layout.contents, "_CodeSignature")
+	if err := os.RemoveAll(sealDir); err != nil {
+		return nil, fmt.Errorf("unable to remove existing resource seal: %w", err)
+	}
+
+	var err error
+	binOpts.CodeResources, err = generateCodeResources(layout.contents, layout.executable(info), nested, layout.rules())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Mkdir(sealDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create resource seal directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(layout.contents, filepath.FromSlash(CodeResourcesPath)), binOpts.CodeResources, 0644); err != nil { //nolint:gosec
+		return nil, fmt.Errorf("unable to write resource seal: %w", err)
+	}
+
+	return signCode(filepath.Join(layout.contents, filepath.FromSlash(layout.executable(info))), node.identifier, signingMaterial, binOpts)
+}
+
+// bundleProfile embeds the provisioning profile authorizing the bundle (when profiles are given), returning the
+// profile the bundle is signed with.
+func bundleProfile(dir, identifier string, opts BundleOptions) (*provisioning.Profile, error) {
+	if len(opts.ProvisioningProfiles) == 0 {
+		// without an embedded profile none is required (e.g. for ad-hoc distribution outside of iOS)
+		p, err := provisioning.LoadEmbedded(dir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read embedded provisioning profile: %w", err)
+		}
+		return p, nil
+	}
+
+	p := provisioning.Select(opts.ProvisioningProfiles, identifier)
+	if p == nil {
+		return nil, fmt.Errorf("none of the provisioning profiles authorize the bundle identifier %q", identifier)
+	}
+	if p.Expired(time.Now()) {
+		log.WithFields("profile", p.Name, "expired", p.ExpirationDate).Warn("provisioning profile is expired, the app will not launch")
+	}
+	if err := provisioning.Embed(dir, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// usesProvisioningProfile indicates if the given kind of bundle is signed with the entitlements of a provisioning
+// profile.
+func usesProvisioningProfile(kind string) bool {
+	return kind == KindApp || kind == KindAppExtension || kind == KindSystemExtension
+}
+
+func readBundleInfo(path string) (*bundleInfo, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle, unable to read Info.plist: %w", err)
+	}
+
+	doc, err := entitlements.ParsePlist(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %q: %w", path, err)
+	}
+
+	info := bundleInfo{raw: raw}
+	info.identifier, _ = doc["CFBundleIdentifier"].(string)
+	info.executable, _ = doc["CFBundleExecutable"].(string)
+
+	switch {
+	case info.identifier == "":
+		return nil, fmt.Errorf("%q has no CFBundleIdentifier", path)
+	case info.executable == "":
+		return nil, fmt.Errorf("%q has no CFBundleExecutable", path)
+	case strings.Contains(info.executable, "..") || filepath.IsAbs(info.executable):
+		return nil, fmt.Errorf("invalid CFBundleExecutable %q", info.executable)
+	}
+	return &info, nil
+}
+
+// signCode signs the (single-arch or universal) binary at the given path, returning its seal.
+func signCode(path, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions) (*NestedCode, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	universal := macholibre.IsUniversalMachoBinary(f)
+	f.Close()
+
+	sealed := path
+	if universal {
+		dir, err := os.MkdirTemp("", "quill-extract-"+filepath.Base(path))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create temp directory to extract multi-arch binary: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		if sealed, err = signUniversal(path, dir, id, signingMaterial, opts); err != nil {
+			return nil, err
+		}
+	} else if err := BinaryWithOptions(path, id, signingMaterial, opts); err != nil {
+		return nil, err
+	}
+
+	// nested code is sealed by the signature of the first architecture
+	m, err := macho.NewReadOnlyFile(sealed)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	cdHash, err := m.HashCD(sha256.New())
+	if err != nil {
+		return nil, fmt.Errorf("unable to hash code directory of %q: %w", path, err)
+	}
+
+	requirement, err := designatedRequirement(id, cdHash[:macho.CDHashTruncatedSize], signingMaterial)
+	if err != nil {
+		return nil, err
+	}
+	return &NestedCode{CDHash: cdHash[:macho.CDHashTruncatedSize], Requirement: requirement}, nil
+}
+
+// signUniversal signs every architecture of the universal binary at the given path (extracted into the given
+// directory), returning the path of the first signed architecture.
+func signUniversal(path, dir, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	extracted, err := macholibre.Extract(f, dir)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("unable to extract multi-arch binary: %w", err)
+	}
+	if len(extracted) == 0 {
+		return "", fmt.Errorf("no architectures found within %q", path)
+	}
+
+	var paths []string
+	for _, ef := range extracted {
+		if err := BinaryWithOptions(ef.Path, id, signingMaterial, opts); err != nil {
+			return "", err
+		}
+		paths = append(paths, ef.Path)
+	}
+
+	if err := macholibre.Package(path, paths...); err != nil {
+		return "", fmt.Errorf("unable to package signed multi-arch binary: %w", err)
+	}
+	return paths[0], nil
+}
+
+// designatedRequirement returns the textual designated requirement of code signed with the given signing material
+// (ad-hoc signed code can only be identified by its code directory hash).
+func designatedRequirement(id string, cdHash []byte, signingMaterial pki.SigningMaterial) (string, error) {
+	if signingMaterial.Signer == nil {
+		return fmt.Sprintf("cdhash H\"%x\"", cdHash), nil
+	}
+
+	req, err := newRequirements(id, signingMaterial)
+	if err != nil {
+		return "", err
+	}
+	return macho.DecodeRequirement(req.Payload)
+}
@@ -0,0 +1,280 @@
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// the kinds of code signed within a bundle.
+const (
+	KindApp          = "app"
+	KindAppExtension = "app extension"
+	KindFramework    = "framework"
+	KindXPCService   = "XPC service"
+	KindPlugin       = "plug-in"
+	KindLibrary      = "library"
+	KindExecutable   = "executable"
+	// KindKernelExtension is a kext, loaded by the kernel.
+	KindKernelExtension = "kernel extension"
+	// KindSystemExtension is a system extension (or DriverKit driver), installed by its containing app.
+	KindSystemExtension = "system extension"
+)
+
+var bundleKinds = map[string]string{
+	".app":             KindApp,
+	".appex":           KindAppExtension,
+	".framework":       KindFramework,
+	".xpc":             KindXPCService,
+	".bundle":          KindPlugin,
+	".kext":            KindKernelExtension,
+	".systemextension": KindSystemExtension,
+	".dext":            KindSystemExtension,
+}
+
+// the directories holding nested code (relative to the bundle root, or the Contents directory of deep bundles). Each
+// location is signed in order: frameworks first since the remaining nested code typically links against them, and
+// deep bundles sign helper executables within the MacOS directory last.
+var (
+	shallowNestedCodeDirs = []string{"Frameworks", "PlugIns", "Extensions", "Watch", "AppClips"}
+	deepNestedCodeDirs    = []string{"Frameworks", "SharedFrameworks", "PlugIns", "Plug-ins", "XPCServices", "Helpers", "Library/LoginItems", "Library/SystemExtensions", "MacOS"}
+)
+
+// bundleLayout describes where the parts of a bundle are: (shallow) iOS bundles hold everything at the bundle root,
+// while (deep) macOS bundles hold everything within the Contents directory, with executables in Contents/MacOS.
+// Versioned frameworks hold everything within the current version directory (Versions/Current pointing to e.g.
+// Versions/A), with the executable at its root and the Info.plist within its Resources directory. Only the current
+// version is signed, the symlinks at the framework root are left as they are.
+type bundleLayout struct {
+	contents      string
+	deep          bool
+	infoPlist     string
+	executableDir string
+}
+
+func newBundleLayout(dir string) (bundleLayout, error) {
+	current := filepath.Join(dir, "Versions", "Current")
+	if info, err := os.Lstat(current); err == nil {
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return bundleLayout{}, fmt.Errorf("the Versions/Current of %q is not a symlink (was the framework copied without preserving symlinks?)", dir)
+		}
+		version, err := os.Readlink(current)
+		if err != nil {
+			return bundleLayout{}, fmt.Errorf("unable to read the current version of %q: %w", dir, err)
+		}
+		if version != filepath.Base(version) || version == ".." {
+			return bundleLayout{}, fmt.Errorf("the Versions/Current of %q must point to a directory within Versions: %q", dir, version)
+		}
+		return bundleLayout{contents: filepath.Join(dir, "Versions", version), deep: true, infoPlist: "Resources/Info.plist"}, nil
+	}
+
+	contents := filepath.Join(dir, "Contents")
+	if info, err := os.Stat(contents); err == nil && info.IsDir() {
+		return bundleLayout{contents: contents, deep: true, infoPlist: "Info.plist", executableDir: "MacOS"}, nil
+	}
+	return bundleLayout{contents: dir, infoPlist: "Info.plist"}, nil
+}
+
+// executable is the path of the main executable relative to the contents directory.
+func (l bundleLayout) executable(info *bundleInfo) string {
+	if l.executableDir != "" {
+		return l.executableDir + "/" + info.executable
+	}
+	return info.executable
+}
+
+func (l bundleLayout) rules() resourceRules {
+	if l.deep {
+		return deepResourceRules
+	}
+	return shallowResourceRules
+}
+
+func (l bundleLayout) nestedCodeDirs() []string {
+	if l.deep {
+		return deepNestedCodeDirs
+	}
+	return shallowNestedCodeDirs
+}
+
+// codeNode is signable code within a bundle along with the code nested within it, nested code must be signed before
+// the code containing it (which seals the nested code signature).
+type codeNode struct {
+	path       string
+	rel        string
+	kind       string
+	identifier string
+	// layout and info are only set for bundles.
+	layout *bundleLayout
+	info   *bundleInfo
+	nested []*codeNode
+}
+
+// planBundle finds all code within the bundle at the given directory (rel is the path within the containing bundle).
+// Code found outside of the nested code locations cannot be signed, which is an error since the bundle would not pass
+// verification.
+func planBundle(dir, rel string) (*codeNode, error) {
+	layout, err := newBundleLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := readBundleInfo(filepath.Join(layout.contents, filepath.FromSlash(layout.infoPlist)))
+	if err != nil {
+		return nil, err
+	}
+
+	node := codeNode{
+		path:       dir,
+		rel:        rel,
+		kind:       bundleKinds[filepath.Ext(dir)],
+		identifier: info.identifier,
+		layout:     &layout,
+		info:       info,
+	}
+	if node.kind == "" {
+		node.kind = KindPlugin
+	}
+
+	mainExecutable := layout.executable(info)
+	for _, nestedDir := range layout.nestedCodeDirs() {
+		if err := node.planNestedDir(nestedDir, mainExecutable); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := node.checkUnsignedCode(mainExecutable); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func (n *codeNode) planNestedDir(nestedDir, mainExecutable string) error {
+	entries, err := os.ReadDir(filepath.Join(n.layout.contents, filepath.FromSlash(nestedDir)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read nested code directory %q: %w", nestedDir, err)
+	}
+
+	for _, e := range entries {
+		rel := nestedDir + "/" + e.Name()
+		path := filepath.Join(n.layout.contents, filepath.FromSlash(rel))
+		ext := filepath.Ext(e.Name())
+
+		switch {
+		case e.IsDir() && bundleKinds[ext] != "":
+			child, err := planBundle(path, rel)
+			if err != nil {
+				return fmt.Errorf("unable to read nested bundle %q: %w", rel, err)
+			}
+			n.nested = append(n.nested, child)
+		case e.Type().IsRegular() && rel != mainExecutable:
+			kind := KindExecutable
+			if ext == ".dylib" {
+				kind = KindLibrary
+			} else if isMacho, _ := macho.IsMachoFile(path); !isMacho {
+				// e.g. scripts, which are sealed as resources
+				continue
+			}
+			n.nested = append(n.nested, &codeNode{
+				path:       path,
+				rel:        rel,
+				kind:       kind,
+				identifier: strings.TrimSuffix(e.Name(), ext),
+			})
+		}
+	}
+	return nil
+}
+
+// prefixIdentifiers prefixes the (file name) identifiers of the nested code which isn't a bundle.
+func (n *codeNode) prefixIdentifiers(prefix string) {
+	for _, child := range n.nested {
+		if child.layout == nil {
+			child.identifier = PrefixIdentifier(prefix, child.identifier)
+		}
+		child.prefixIdentifiers(prefix)
+	}
+}
+
+// checkUnsignedCode returns an error listing binaries within the bundle that would be left unsigned.
+func (n *codeNode) checkUnsignedCode(mainExecutable string) error {
+	planned := map[string]bool{mainExecutable: true}
+	for _, child := range n.nested {
+		planned[child.rel] = true
+	}
+
+	var unsigned []string
+	err := filepath.WalkDir(n.layout.contents, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(n.layout.contents, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if planned[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if isMacho, _ := macho.IsMachoFile(path); isMacho {
+			unsigned = append(unsigned, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to search for code within %q: %w", n.path, err)
+	}
+
+	if len(unsigned) > 0 {
+		return fmt.Errorf("code outside of the nested code locations of bundle %q would be left unsigned (move it into e.g. Frameworks, Helpers, or MacOS): %s", n.path, strings.Join(unsigned, ", "))
+	}
+	return nil
+}
+
+// BundleReport lists the code signed within a bundle in signing order (nested code before the code containing it).
+type BundleReport struct {
+	Signed []SignedCode
+	// Warnings are the issues found while signing which don't prevent signing, but may prevent the code from
+	// loading (e.g. missing entitlements).
+	Warnings []string
+}
+
+// SignedCode is an entry of a BundleReport.
+type SignedCode struct {
+	// Path is relative to the directory containing the signed bundle (starting with the bundle name).
+	Path       string
+	Kind       string
+	Identifier string
+}
+
+func (r *BundleReport) add(top string, n *codeNode) {
+	rel, err := filepath.Rel(filepath.Dir(top), n.path)
+	if err != nil {
+		rel = n.path
+	}
+	r.Signed = append(r.Signed, SignedCode{Path: filepath.ToSlash(rel), Kind: n.kind, Identifier: n.identifier})
+}
+
+func (r BundleReport) String() string {
+	var sb strings.Builder
+	for i, s := range r.Signed {
+		fmt.Fprintf(&sb, "%d. %s (%s %s)\n", i+1, s.Path, s.Kind, s.Identifier)
+	}
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&sb, "warning: %s\n", w)
+	}
+	return sb.String()
+}
@@ -0,0 +1,226 @@
+package sign
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+// machoHeader is a minimal (load command free) arm64 executable.
+func machoHeader() string {
+	by := make([]byte, 32)
+	binary.LittleEndian.PutUint32(by[0:], 0xfeedfacf) // MH_MAGIC_64
+	binary.LittleEndian.PutUint32(by[4:], 0x0100000c) // CPU_TYPE_ARM64
+	binary.LittleEndian.PutUint32(by[12:], 0x2)       // MH_EXECUTE
+	return string(by)
+}
+
+func infoPlist(identifier, executable string) string {
+	return entitlements.Entitlements{
+		"CFBundleIdentifier": identifier,
+		"CFBundleExecutable": executable,
+	}.XML()
+}
+
+// signingOrder flattens the plan in signing order.
+func signingOrder(top string, n *codeNode) []SignedCode {
+	var report BundleReport
+	var walk func(*codeNode)
+	walk = func(n *codeNode) {
+		for _, child := range n.nested {
+			walk(child)
+		}
+		report.add(top, n)
+	}
+	walk(n)
+	return report.Signed
+}
+
+func Test_planBundle(t *testing.T) {
+	bin := machoHeader()
+
+	dir := filepath.Join(t.TempDir(), "My.app")
+	writeFiles(t, dir, map[string]string{
+		"Contents/Info.plist":                                                            infoPlist("com.example.my", "My"),
+		"Contents/MacOS/My":                                                              bin,
+		"Contents/MacOS/my-cli":                                                          bin,
+		"Contents/MacOS/launch.sh":                                                       "#!/bin/sh",
+		"Contents/Resources/icon.icns":                                                   "icon",
+		"Contents/Frameworks/Foo.framework/Info.plist":                                   infoPlist("com.example.foo", "Foo"),
+		"Contents/Frameworks/Foo.framework/Foo":                                          bin,
+		"Contents/Frameworks/libbar.dylib":                                               bin,
+		"Contents/Frameworks/Baz.framework/Versions/A/Baz":                               bin,
+		"Contents/Frameworks/Baz.framework/Versions/A/Resources/Info.plist":              infoPlist("com.example.baz", "Baz"),
+		"Contents/Frameworks/Baz.framework/Versions/A/Resources/strings.txt":             "strings",
+		"Contents/XPCServices/Svc.xpc/Contents/Info.plist":                               infoPlist("com.example.svc", "Svc"),
+		"Contents/XPCServices/Svc.xpc/Contents/MacOS/Svc":                                bin,
+		"Contents/Helpers/helper":                                                        bin,
+		"Contents/Library/LoginItems/Login.app/Contents/Info.plist":                      infoPlist("com.example.login", "Login"),
+		"Contents/Library/LoginItems/Login.app/Contents/MacOS/Login":                     bin,
+		"Contents/Library/SystemExtensions/Filter.systemextension/Contents/Info.plist":   infoPlist("com.example.my.filter", "Filter"),
+		"Contents/Library/SystemExtensions/Filter.systemextension/Contents/MacOS/Filter": bin,
+	})
+
+	baz := filepath.Join(dir, "Contents", "Frameworks", "Baz.framework")
+	require.NoError(t, os.Symlink("A", filepath.Join(baz, "Versions", "Current")))
+	require.NoError(t, os.Symlink("Versions/Current/Baz", filepath.Join(baz, "Baz")))
+	require.NoError(t, os.Symlink("Versions/Current/Resources", filepath.Join(baz, "Resources")))
+
+	plan, err := planBundle(dir, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SignedCode{
+		{Path: "My.app/Contents/Frameworks/Baz.framework", Kind: KindFramework, Identifier: "com.example.baz"},
+		{Path: "My.app/Contents/Frameworks/Foo.framework", Kind: KindFramework, Identifier: "com.example.foo"},
+		{Path: "My.app/Contents/Frameworks/libbar.dylib", Kind: KindLibrary, Identifier: "libbar"},
+		{Path: "My.app/Contents/XPCServices/Svc.xpc", Kind: KindXPCService, Identifier: "com.example.svc"},
+		{Path: "My.app/Contents/Helpers/helper", Kind: KindExecutable, Identifier: "helper"},
+		{Path: "My.app/Contents/Library/LoginItems/Login.app", Kind: KindApp, Identifier: "com.example.login"},
+		{Path: "My.app/Contents/Library/SystemExtensions/Filter.systemextension", Kind: KindSystemExtension, Identifier: "com.example.my.filter"},
+		{Path: "My.app/Contents/MacOS/my-cli", Kind: KindExecutable, Identifier: "my-cli"},
+		{Path: "My.app", Kind: KindApp, Identifier: "com.example.my"},
+	}, signingOrder(dir, plan))
+
+	// nested code is sealed relative to the contents directory of its container
+	assert.Equal(t, "Frameworks/Baz.framework", plan.nested[0].rel)
+
+	// only code identified by file name is prefixed
+	plan.prefixIdentifiers("com.example.")
+	var ids []string
+	for _, s := range signingOrder(dir, plan) {
+		ids = append(ids, s.Identifier)
+	}
+	assert.Equal(t, []string{
+		"com.example.baz",
+		"com.example.foo",
+		"com.example.libbar",
+		"com.example.svc",
+		"com.example.helper",
+		"com.example.login",
+		"com.example.my.filter",
+		"com.example.my-cli",
+		"com.example.my",
+	}, ids)
+}
+
+func Test_newBundleLayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		symlinks   map[string]string
+		contents   string
+		infoPlist  string
+		executable string
+		wantErr    require.ErrorAssertionFunc
+	}{
+		{
+			name:       "shallow bundle",
+			files:      map[string]string{"Info.plist": ""},
+			contents:   ".",
+			infoPlist:  "Info.plist",
+			executable: "App",
+		},
+		{
+			name:       "deep bundle",
+			files:      map[string]string{"Contents/Info.plist": ""},
+			contents:   "Contents",
+			infoPlist:  "Info.plist",
+			executable: "MacOS/App",
+		},
+		{
+			name:       "versioned framework",
+			files:      map[string]string{"Versions/B/Resources/Info.plist": ""},
+			symlinks:   map[string]string{"Versions/Current": "B"},
+			contents:   "Versions/B",
+			infoPlist:  "Resources/Info.plist",
+			executable: "App",
+		},
+		{
+			name:    "current version is not a symlink",
+			files:   map[string]string{"Versions/Current/Resources/Info.plist": ""},
+			wantErr: require.Error,
+		},
+		{
+			name:     "current version outside of the versions directory",
+			files:    map[string]string{"Versions/A/Resources/Info.plist": ""},
+			symlinks: map[string]string{"Versions/Current": "../Versions/A"},
+			wantErr:  require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			for link, target := range tt.symlinks {
+				require.NoError(t, os.Symlink(target, filepath.Join(dir, filepath.FromSlash(link))))
+			}
+
+			layout, err := newBundleLayout(dir)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, filepath.Join(dir, filepath.FromSlash(tt.contents)), layout.contents)
+			assert.Equal(t, tt.infoPlist, layout.infoPlist)
+			assert.Equal(t, tt.executable, layout.executable(&bundleInfo{executable: "App"}))
+		})
+	}
+}
+
+func Test_planBundle_invalid(t *testing.T) {
+	bin := machoHeader()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "missing Info.plist",
+			files: map[string]string{
+				"My": bin,
+			},
+		},
+		{
+			name: "code outside of nested code locations",
+			files: map[string]string{
+				"Contents/Info.plist":       infoPlist("com.example.my", "My"),
+				"Contents/MacOS/My":         bin,
+				"Contents/Resources/helper": bin,
+			},
+		},
+		{
+			name: "invalid nested bundle",
+			files: map[string]string{
+				"Info.plist":                 infoPlist("com.example.my", "My"),
+				"My":                         bin,
+				"PlugIns/Widget.appex/notes": "no Info.plist",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "My.app")
+			writeFiles(t, dir, tt.files)
+
+			_, err := planBundle(dir, "")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBundleReport_String(t *testing.T) {
+	r := BundleReport{Signed: []SignedCode{
+		{Path: "My.app/Frameworks/Foo.framework", Kind: KindFramework, Identifier: "com.example.foo"},
+		{Path: "My.app", Kind: KindApp, Identifier: "com.example.my"},
+	}}
+	assert.Equal(t, "1. My.app/Frameworks/Foo.framework (framework com.example.foo)\n2. My.app (app com.example.my)\n", r.String())
+}
@@ -1,50 +1,330 @@
 package sign
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 (the remaining hashes are registered by the code directory hashing)
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
+	"io"
+	"sort"
+	"time"
+	"unsafe"
 
-	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
 
 	"github.com/anchore/quill/quill/macho"
 	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/timestamp"
 )
 
-func generateCMS(signingMaterial pki.SigningMaterial, cdBlob *macho.Blob) (*macho.Blob, error) {
-	cdBlobBytes, err := cdBlob.Pack()
-	if err != nil {
-		return nil, err
-	}
-
+// generateCMS signs the given (primary) code directory, binding the cdhashes of it and of the alternate code
+// directories to the signature.
+func generateCMS(signingMaterial pki.SigningMaterial, cdBlob *macho.Blob, alternateCDBlobs ...*macho.Blob) (*macho.Blob, error) {
 	var cmsBytes []byte
 	if signingMaterial.Signer != nil {
-		cmsBytes, err = signDetached(cdBlobBytes, signingMaterial)
+		attrs, err := cdHashesAttributes(append([]*macho.Blob{cdBlob}, alternateCDBlobs...))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create cdhashes attributes: %w", err)
+		}
+
+		// the code directory is streamed into the digests rather than encoded (it is not embedded into the signature)
+		cmsBytes, err = signDetachedContent(cdBlob, signingMaterial, attrs...)
 		if err != nil {
 			return nil, fmt.Errorf("unable to sign code directory: %w", err)
 		}
 	}
 
-	blob := macho.NewBlob(macho.MagicBlobwrapper, cmsBytes)
+	blob := macho.NewBlobWrapper(cmsBytes)
 
 	return &blob, nil
 }
 
-func signDetached(data []byte, signingMaterial pki.SigningMaterial) ([]byte, error) {
-	sd, err := cms.NewSignedData(data)
+// cdHashesAttributes creates the signed attributes listing the cdhash of every given code directory (both the plist
+// and the newer DER form, as codesign does).
+func cdHashesAttributes(cdBlobs []*macho.Blob) ([]protocol.Attribute, error) {
+	var (
+		cdHashes [][]byte
+		values   []asn1.RawValue
+	)
+	for _, cdBlob := range cdBlobs {
+		h, err := codeDirectoryHash(cdBlob)
+		if err != nil {
+			return nil, err
+		}
+		cdHash, err := contentDigest(h, cdBlob)
+		if err != nil {
+			return nil, err
+		}
+		cdHashes = append(cdHashes, cdHash)
+
+		der, err := asn1.Marshal(macho.CDHash{Algorithm: oid.CryptoHashToDigestAlgorithm[h], Digest: cdHash})
+		if err != nil {
+			return nil, err
+		}
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(der, &value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	plistAttr, err := protocol.NewAttribute(macho.OIDCDHashesPlist, macho.CDHashesPlist(cdHashes))
 	if err != nil {
 		return nil, err
 	}
 
-	if err = sd.Sign(signingMaterial.Certs, signingMaterial.Signer); err != nil {
+	hashesAttr := protocol.Attribute{Type: macho.OIDCDHashes}
+	if err := protocol.NewAnySet(values...).Encode(&hashesAttr.RawValue); err != nil {
 		return nil, err
 	}
 
-	sd.Detached()
+	return []protocol.Attribute{plistAttr, hashesAttr}, nil
+}
+
+// codeDirectoryHash returns the hash function of the given code directory, which is also the one its cdhash is
+// computed with.
+func codeDirectoryHash(cdBlob *macho.Blob) (crypto.Hash, error) {
+	var header macho.CodeDirectoryHeader
+	offset := int(unsafe.Offsetof(header.HashType))
+	if len(cdBlob.Payload) <= offset {
+		return 0, fmt.Errorf("code directory is too short")
+	}
+
+	switch ht := macho.HashType(cdBlob.Payload[offset]); ht {
+	case macho.HashTypeSha1:
+		return crypto.SHA1, nil
+	case macho.HashTypeSha256:
+		return crypto.SHA256, nil
+	case macho.HashTypeSha384:
+		return crypto.SHA384, nil
+	default:
+		return 0, fmt.Errorf("unsupported code directory hash type: %d", ht)
+	}
+}
+
+// signDetached creates a detached CMS signature over the given data (the signer info carries the content type and
+// message digest attributes, the signing time attribute unless omitted, along with the given signed attributes).
+func signDetached(data []byte, signingMaterial pki.SigningMaterial, attrs ...protocol.Attribute) ([]byte, error) {
+	return signDetachedContent(bytes.NewReader(data), signingMaterial, attrs...)
+}
+
+// signDetachedContent is like signDetached, but the content is written into the message digest as it is encoded
+// (the content is never embedded into a detached signature, so it is not held in memory as a whole).
+func signDetachedContent(content io.WriterTo, signingMaterial pki.SigningMaterial, attrs ...protocol.Attribute) ([]byte, error) {
+	cert, err := signingMaterial.SigningCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	// detached: the signed data is not embedded
+	eci := protocol.EncapsulatedContentInfo{EContentType: oid.ContentTypeData}
+
+	sd, err := protocol.NewSignedData(eci)
+	if err != nil {
+		return nil, err
+	}
+
+	// the full chain is needed to find the signing certificate, but only some of the chain may be embedded
+	for _, c := range signingMaterial.EmbeddedCerts() {
+		if err := sd.AddCertificate(c); err != nil {
+			return nil, fmt.Errorf("unable to set embedded certificates: %w", err)
+		}
+	}
+
+	signingTime, stamped := signingMaterial.SigningTime.Time(time.Now())
+	if stamped {
+		stAttr, err := protocol.NewAttribute(oid.AttributeSigningTime, signingTime)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append([]protocol.Attribute{stAttr}, attrs...)
+	}
+
+	alg := signingMaterial.SignatureAlgorithm
+	if err := alg.Validate(cert.PublicKey); err != nil {
+		return nil, err
+	}
+
+	messageDigest, err := contentDigest(alg.Hash(), content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to digest signed content: %w", err)
+	}
+
+	si, err := newSignerInfo(cert, alg, messageDigest, eci.EContentType, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	toSign, err := si.SignedAttrs.MarshaledForSigning()
+	if err != nil {
+		return nil, err
+	}
+	if si.Signature, err = signingMaterial.Signer.Sign(rand.Reader, digest(alg.Hash(), toSign), alg.SignerOpts(cert.PublicKey)); err != nil {
+		return nil, err
+	}
+
+	sd.DigestAlgorithms = append(sd.DigestAlgorithms, si.DigestAlgorithm)
+	sd.SignerInfos = append(sd.SignerInfos, *si)
+
+	der, err := sd.ContentInfoDER()
+	if err != nil {
+		return nil, err
+	}
 
-	if signingMaterial.TimestampServer != "" {
-		if err = sd.AddTimestamps(signingMaterial.TimestampServer); err != nil {
+	var timestamped time.Time
+	if signingMaterial.Timestamp.Enabled() {
+		if der, err = timestamp.NewClient(signingMaterial.Timestamp).AddToCMS(der); err != nil {
 			return nil, fmt.Errorf("unable to add timestamps (RFC3161): %w", err)
 		}
+		if timestamped, err = signatureTimestamp(der); err != nil {
+			return nil, err
+		}
+	}
+
+	if stamped {
+		if err := pki.CheckSigningTime(signingTime, signingMaterial, timestamped); err != nil {
+			return nil, err
+		}
+	}
+
+	return der, nil
+}
+
+// signatureTimestamp returns the time of the secure timestamp of the (single signer) CMS signature.
+func signatureTimestamp(der []byte) (time.Time, error) {
+	ci, err := protocol.ParseContentInfo(der)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sd, err := ci.SignedDataContent()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, si := range sd.SignerInfos {
+		t, _, err := timestamp.TokenTime(si)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to read the secure timestamp: %w", err)
+		}
+		return t, nil
+	}
+	return time.Time{}, nil
+}
+
+// newSignerInfo creates the (not yet signed) signer info for the content with the given digest.
+func newSignerInfo(cert *x509.Certificate, alg pki.SignatureAlgorithm, messageDigest []byte, contentType asn1.ObjectIdentifier, extraAttrs []protocol.Attribute) (*protocol.SignerInfo, error) {
+	sid, err := protocol.NewIssuerAndSerialNumber(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	h := alg.Hash()
+	digestAlgorithm := oid.CryptoHashToDigestAlgorithm[h]
+	signatureAlgorithm, err := signatureAlgorithmIdentifier(cert.PublicKeyAlgorithm, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	mdAttr, err := protocol.NewAttribute(oid.AttributeMessageDigest, messageDigest)
+	if err != nil {
+		return nil, err
+	}
+	ctAttr, err := protocol.NewAttribute(oid.AttributeContentType, contentType)
+	if err != nil {
+		return nil, err
 	}
 
-	return sd.ToDER()
+	attrs, err := sortAttributes(append([]protocol.Attribute{mdAttr, ctAttr}, extraAttrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.SignerInfo{
+		Version:            1,
+		SID:                sid,
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: digestAlgorithm},
+		SignedAttrs:        attrs,
+		SignatureAlgorithm: signatureAlgorithm,
+	}, nil
+}
+
+// pssParameters are the RSASSA-PSS-params of RFC 4055.
+type pssParameters struct {
+	Hash         pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF          pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength   int                      `asn1:"explicit,tag:2"`
+	TrailerField int                      `asn1:"optional,explicit,tag:3,default:1"`
+}
+
+// oidMGF1 is the mask generation function of RSASSA-PSS signatures.
+var oidMGF1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+
+// signatureAlgorithmIdentifier returns the signature algorithm of a signer info signed by a key of the given type.
+func signatureAlgorithmIdentifier(keyAlgorithm x509.PublicKeyAlgorithm, alg pki.SignatureAlgorithm) (pkix.AlgorithmIdentifier, error) {
+	h := alg.Hash()
+	digestAlgorithm := oid.CryptoHashToDigestAlgorithm[h]
+
+	if keyAlgorithm == x509.RSA && alg.PSS() {
+		hashAlgorithm := pkix.AlgorithmIdentifier{Algorithm: digestAlgorithm, Parameters: asn1.NullRawValue}
+		mgfParams, err := asn1.Marshal(hashAlgorithm)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		params, err := asn1.Marshal(pssParameters{
+			Hash:         hashAlgorithm,
+			MGF:          pkix.AlgorithmIdentifier{Algorithm: oidMGF1, Parameters: asn1.RawValue{FullBytes: mgfParams}},
+			SaltLength:   h.Size(),
+			TrailerField: 1,
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		return pkix.AlgorithmIdentifier{Algorithm: oid.SignatureAlgorithmRSAPSS, Parameters: asn1.RawValue{FullBytes: params}}, nil
+	}
+
+	signatureAlgorithm, ok := oid.X509PublicKeyAndDigestAlgorithmToSignatureAlgorithm[keyAlgorithm][digestAlgorithm.String()]
+	if !ok {
+		return pkix.AlgorithmIdentifier{}, fmt.Errorf("unsupported certificate public key algorithm")
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: signatureAlgorithm}, nil
+}
+
+// sortAttributes orders the attributes by their DER encoding, as required for a DER SET OF (the order in which the
+// signed attributes are encoded, so also the order verifiers hash them in).
+func sortAttributes(attrs []protocol.Attribute) (protocol.Attributes, error) {
+	encoded := make([][]byte, len(attrs))
+	order := make([]int, len(attrs))
+	for i, a := range attrs {
+		der, err := asn1.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i], order[i] = der, i
+	}
+	sort.Slice(order, func(i, j int) bool { return bytes.Compare(encoded[order[i]], encoded[order[j]]) < 0 })
+
+	sorted := make(protocol.Attributes, len(attrs))
+	for i, idx := range order {
+		sorted[i] = attrs[idx]
+	}
+	return sorted, nil
+}
+
+func digest(h crypto.Hash, data []byte) []byte {
+	hasher := h.New()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
+
+// contentDigest hashes the given content as it is written (e.g. a blob, see macho.Blob.WriteTo).
+func contentDigest(h crypto.Hash, content io.WriterTo) ([]byte, error) {
+	hasher := h.New()
+	if _, err := content.WriteTo(hasher); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
 }
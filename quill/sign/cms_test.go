@@ -0,0 +1,309 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"hash"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+func newTestSigningMaterial(t *testing.T, key crypto.Signer) pki.SigningMaterial {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "quill-test-signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return pki.SigningMaterial{
+		Signer: key,
+		Certs:  []*x509.Certificate{cert},
+	}
+}
+
+func Test_signDetached_keyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		key           crypto.Signer
+		wantSignature asn1.ObjectIdentifier
+	}{
+		{
+			name:          "RSA",
+			key:           rsaKey,
+			wantSignature: oid.SignatureAlgorithmSHA256WithRSA,
+		},
+		{
+			name:          "ECDSA P-256",
+			key:           p256Key,
+			wantSignature: oid.SignatureAlgorithmECDSAWithSHA256,
+		},
+		{
+			name:          "ECDSA P-384",
+			key:           p384Key,
+			wantSignature: oid.SignatureAlgorithmECDSAWithSHA256,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestSigningMaterial(t, tt.key)
+			data := []byte("code directory bytes")
+
+			der, err := signDetached(data, sm)
+			require.NoError(t, err)
+
+			ci, err := protocol.ParseContentInfo(der)
+			require.NoError(t, err)
+			sd, err := ci.SignedDataContent()
+			require.NoError(t, err)
+
+			require.Len(t, sd.SignerInfos, 1)
+			assert.Equal(t, tt.wantSignature, sd.SignerInfos[0].SignatureAlgorithm.Algorithm)
+
+			certs, err := sd.X509Certificates()
+			require.NoError(t, err)
+			_, err = sd.SignerInfos[0].FindCertificate(certs)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_generateCMS_cdHashes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sm := newTestSigningMaterial(t, key)
+
+	newCD := func(hasher hash.Hash) *macho.Blob {
+		cd, err := newCodeDirectory("id", hasher, 0, 0, 0x1000, [][]byte{make([]byte, hasher.Size())}, codeDirectoryOptions{
+			specialSlots: [][]byte{make([]byte, hasher.Size())},
+		})
+		require.NoError(t, err)
+		blob, err := cd.Blob()
+		require.NoError(t, err)
+		return blob
+	}
+	cdBlob, sha1CDBlob := newCD(sha256.New()), newCD(sha1.New())
+
+	cmsBlob, err := generateCMS(sm, cdBlob, sha1CDBlob)
+	require.NoError(t, err)
+
+	ci, err := protocol.ParseContentInfo(cmsBlob.Payload)
+	require.NoError(t, err)
+	sd, err := ci.SignedDataContent()
+	require.NoError(t, err)
+	require.Len(t, sd.SignerInfos, 1)
+	attrs := sd.SignerInfos[0].SignedAttrs
+
+	cdBytes, err := cdBlob.Pack()
+	require.NoError(t, err)
+	sha1CDBytes, err := sha1CDBlob.Pack()
+	require.NoError(t, err)
+	cdHash := hashBytes(sha256.New(), cdBytes)
+	sha1CDHash := hashBytes(sha1.New(), sha1CDBytes)
+
+	// the plist lists the truncated cdhashes in code directory order
+	rv, err := attrs.GetOnlyAttributeValueBytes(macho.OIDCDHashesPlist)
+	require.NoError(t, err)
+	var plist []byte
+	_, err = asn1.Unmarshal(rv.FullBytes, &plist)
+	require.NoError(t, err)
+	assert.Equal(t, string(macho.CDHashesPlist([][]byte{cdHash, sha1CDHash})), string(plist))
+	assert.Contains(t, string(plist), "\t\t<data>\n\t\t"+base64.StdEncoding.EncodeToString(cdHash[:macho.CDHashTruncatedSize])+"\n\t\t</data>\n")
+
+	// the full cdhashes carry their digest algorithm
+	var hashes []macho.CDHash
+	for _, attr := range attrs {
+		if !attr.Type.Equal(macho.OIDCDHashes) {
+			continue
+		}
+		rest := attr.RawValue.Bytes
+		for len(rest) > 0 {
+			var h macho.CDHash
+			rest, err = asn1.Unmarshal(rest, &h)
+			require.NoError(t, err)
+			hashes = append(hashes, h)
+		}
+	}
+	require.Len(t, hashes, 2)
+	assert.ElementsMatch(t, []macho.CDHash{
+		{Algorithm: oid.DigestAlgorithmSHA256, Digest: cdHash},
+		{Algorithm: oid.DigestAlgorithmSHA1, Digest: sha1CDHash},
+	}, hashes)
+}
+
+func Test_signDetached_signingTime(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pinned := time.Now().Add(-30 * time.Minute).Truncate(time.Second).UTC()
+
+	tests := []struct {
+		name        string
+		signingTime pki.SigningTime
+		want        time.Time
+		wantErr     require.ErrorAssertionFunc
+	}{
+		{
+			name:        "omitted",
+			signingTime: pki.SigningTime{Omit: true},
+		},
+		{
+			name:        "pinned",
+			signingTime: pki.SigningTime{At: pinned},
+			want:        pinned,
+		},
+		{
+			name:        "pinned before the certificate validity",
+			signingTime: pki.SigningTime{At: pinned.Add(-24 * time.Hour)},
+			wantErr:     require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			sm := newTestSigningMaterial(t, key)
+			sm.SigningTime = tt.signingTime
+
+			der, err := signDetached([]byte("code directory bytes"), sm)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			ci, err := protocol.ParseContentInfo(der)
+			require.NoError(t, err)
+			sd, err := ci.SignedDataContent()
+			require.NoError(t, err)
+			require.Len(t, sd.SignerInfos, 1)
+
+			si := sd.SignerInfos[0]
+			if tt.want.IsZero() {
+				assert.False(t, si.SignedAttrs.HasAttribute(oid.AttributeSigningTime))
+				return
+			}
+			got, err := si.GetSigningTimeAttribute()
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+}
+
+func Test_signDetached_signatureAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		key           crypto.Signer
+		alg           pki.SignatureAlgorithm
+		wantSignature asn1.ObjectIdentifier
+		wantDigest    asn1.ObjectIdentifier
+		x509Algorithm x509.SignatureAlgorithm
+		wantErr       require.ErrorAssertionFunc
+	}{
+		{
+			name:          "RSA PKCS #1 v1.5 with SHA-384",
+			key:           rsaKey,
+			alg:           pki.SignatureAlgorithm{Digest: crypto.SHA384},
+			wantSignature: oid.SignatureAlgorithmSHA384WithRSA,
+			wantDigest:    oid.DigestAlgorithmSHA384,
+			x509Algorithm: x509.SHA384WithRSA,
+		},
+		{
+			name:          "RSA PSS with SHA-256",
+			key:           rsaKey,
+			alg:           pki.SignatureAlgorithm{Padding: pki.PaddingPSS},
+			wantSignature: oid.SignatureAlgorithmRSAPSS,
+			wantDigest:    oid.DigestAlgorithmSHA256,
+			x509Algorithm: x509.SHA256WithRSAPSS,
+		},
+		{
+			name:          "RSA PSS with SHA-512",
+			key:           rsaKey,
+			alg:           pki.SignatureAlgorithm{Digest: crypto.SHA512, Padding: pki.PaddingPSS},
+			wantSignature: oid.SignatureAlgorithmRSAPSS,
+			wantDigest:    oid.DigestAlgorithmSHA512,
+			x509Algorithm: x509.SHA512WithRSAPSS,
+		},
+		{
+			name:          "ECDSA with SHA-384",
+			key:           p384Key,
+			alg:           pki.SignatureAlgorithm{Digest: crypto.SHA384},
+			wantSignature: oid.SignatureAlgorithmECDSAWithSHA384,
+			wantDigest:    oid.DigestAlgorithmSHA384,
+			x509Algorithm: x509.ECDSAWithSHA384,
+		},
+		{
+			name:    "ECDSA with PSS",
+			key:     p384Key,
+			alg:     pki.SignatureAlgorithm{Padding: pki.PaddingPSS},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			sm := newTestSigningMaterial(t, tt.key)
+			sm.SignatureAlgorithm = tt.alg
+
+			der, err := signDetached([]byte("code directory bytes"), sm)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			ci, err := protocol.ParseContentInfo(der)
+			require.NoError(t, err)
+			sd, err := ci.SignedDataContent()
+			require.NoError(t, err)
+			require.Len(t, sd.SignerInfos, 1)
+
+			si := sd.SignerInfos[0]
+			assert.Equal(t, tt.wantSignature, si.SignatureAlgorithm.Algorithm)
+			assert.Equal(t, tt.wantDigest, si.DigestAlgorithm.Algorithm)
+
+			signed, err := si.SignedAttrs.MarshaledForVerification()
+			require.NoError(t, err)
+			require.NoError(t, sm.Certs[0].CheckSignature(tt.x509Algorithm, signed, si.Signature))
+		})
+	}
+}
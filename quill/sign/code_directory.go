@@ -4,42 +4,155 @@ import (
 	"bytes"
 	"crypto/sha1" //nolint: gosec
 	"crypto/sha256"
-	"encoding/binary"
 	"fmt"
 	"hash"
+	"strconv"
+	"strings"
 	"unsafe"
 
-	"github.com/go-restruct/restruct"
-
+	"github.com/anchore/quill/quill/entitlements"
 	"github.com/anchore/quill/quill/macho"
 )
 
-func generateCodeDirectory(id string, hasher hash.Hash, m *macho.File, flags macho.CdFlag, requirementsHashBytes, entitlementsHashBytes []byte) (*macho.Blob, error) {
-	cd, err := newCodeDirectoryFromMacho(id, hasher, m, flags, requirementsHashBytes, entitlementsHashBytes)
+// DefaultCodeDirectoryVersion is the code directory version written unless another one is requested, it is the
+// newest version with all fields known to quill (and required for notarization, which checks the runtime version).
+const DefaultCodeDirectoryVersion = macho.SupportsRuntime
+
+// DefaultRuntimeVersion is the hardened runtime version written when neither requested nor recorded by the binary (as
+// the SDK version it was built with).
+const DefaultRuntimeVersion macho.Version = 0x000c0100 // 12.1
+
+// CodeDirectoryVersions are the code directory versions quill is able to write.
+var CodeDirectoryVersions = []macho.CdVersion{macho.SupportsTeamid, macho.SupportsCodelimit64, macho.SupportsExecseg, macho.SupportsRuntime}
+
+// ParseCodeDirectoryVersion parses a code directory version (e.g. "0x20400"), an empty value is the default version.
+func ParseCodeDirectoryVersion(s string) (macho.CdVersion, error) {
+	if s == "" {
+		return DefaultCodeDirectoryVersion, nil
+	}
+	v, err := strconv.ParseUint(s, 0, 32)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("invalid code directory version %q: %w", s, err)
+	}
+	version := macho.CdVersion(v)
+	if codeDirectoryHeaderSize(version) == 0 {
+		return 0, fmt.Errorf("unsupported code directory version %q (supported versions: %s)", s, FormatCodeDirectoryVersions())
 	}
+	return version, nil
+}
+
+// FormatCodeDirectoryVersions lists the code directory versions quill is able to write.
+func FormatCodeDirectoryVersions() string {
+	var out []string
+	for _, v := range CodeDirectoryVersions {
+		out = append(out, fmt.Sprintf("0x%x", uint32(v)))
+	}
+	return strings.Join(out, ", ")
+}
 
-	blob, err := packCodeDirectory(cd, macho.SigningOrder)
+// codeDirectoryHeaderSize is the size of the code directory header of the given version (see
+// macho.CodeDirectoryHeaderSize), zero for versions which cannot be written.
+func codeDirectoryHeaderSize(version macho.CdVersion) int {
+	for _, v := range CodeDirectoryVersions {
+		if v == version {
+			return macho.CodeDirectoryHeaderSize(version)
+		}
+	}
+	return 0
+}
+
+// codeDirectoryOptions are the fields of a code directory which don't derive from the binary contents.
+type codeDirectoryOptions struct {
+	// version is the format version to write (the default version when zero), fields introduced by newer versions
+	// are left out.
+	version macho.CdVersion
+	// teamID is optional.
+	teamID string
+	flags  macho.CdFlag
+	// runtimeVersion is the SDK version the hardened runtime behavior is based on (the SDK version recorded by the
+	// binary when zero, and DefaultRuntimeVersion when the binary does not record it).
+	runtimeVersion macho.Version
+	// execSegFlags are the flags of the executable segment (see execSegFlags).
+	execSegFlags macho.ExecSegFlag
+	// specialSlots are the special slot hashes, indexed by slot number minus one (e.g. the Info.plist hash first,
+	// followed by the requirements hash).
+	specialSlots [][]byte
+	// pages carries the page hashes over from a prior signing pass (nil hashes every page).
+	pages pageHashCache
+}
+
+// pageHashCache remembers the page hashes of a binary across signing passes, by hash size (one code directory is
+// generated per hash type). Between passes only the load commands are patched, so only the pages holding them are
+// hashed again.
+type pageHashCache map[int][][]byte
+
+func (c pageHashCache) hash(m *macho.File, hasher hash.Hash) ([][]byte, error) {
+	if c == nil {
+		return m.HashPages(hasher)
+	}
+
+	var (
+		hashes [][]byte
+		err    error
+	)
+	if previous, ok := c[hasher.Size()]; ok {
+		hashes, err = m.RehashHeaderPages(hasher, previous)
+	} else {
+		hashes, err = m.HashPages(hasher)
+	}
 	if err != nil {
 		return nil, err
 	}
+	c[hasher.Size()] = hashes
+	return hashes, nil
+}
 
-	return blob, nil
+// execSegEntitlements are the (boolean) entitlements relaxing the code signing enforcement of the executable segment,
+// as codesign sets the corresponding flags.
+var execSegEntitlements = []struct {
+	entitlement string
+	flag        macho.ExecSegFlag
+}{
+	{entitlement: "get-task-allow", flag: macho.ExecsegAllowUnsigned},
+	{entitlement: "run-unsigned-code", flag: macho.ExecsegAllowUnsigned},
+	{entitlement: "com.apple.private.cs.debugger", flag: macho.ExecsegDebugger},
+	{entitlement: "dynamic-codesigning", flag: macho.ExecsegJit},
+	{entitlement: "com.apple.private.skip-library-validation", flag: macho.ExecsegSkipLv},
+	{entitlement: "com.apple.private.amfi.can-load-cdhash", flag: macho.ExecsegCanLoadCdhash},
+	{entitlement: "com.apple.private.amfi.can-execute-cdhash", flag: macho.ExecsegCanExecCdhash},
 }
 
-func packCodeDirectory(cd *macho.CodeDirectory, order binary.ByteOrder) (*macho.Blob, error) {
-	cdBytes, err := restruct.Pack(order, cd)
+// execSegFlags returns the executable segment flags of the given binary signed with the given entitlements: only
+// executables (not libraries or bundles) are flagged as the main binary.
+func execSegFlags(m *macho.File, ents entitlements.Entitlements) macho.ExecSegFlag {
+	var flags macho.ExecSegFlag
+	if m.IsExecutable() {
+		flags |= macho.ExecsegMainBinary
+	}
+	for _, e := range execSegEntitlements {
+		if v, ok := ents[e.entitlement].(bool); ok && v {
+			flags |= e.flag
+		}
+	}
+	return flags
+}
+
+func generateCodeDirectory(id string, hasher hash.Hash, m *macho.File, opts codeDirectoryOptions) (*macho.Blob, error) {
+	cd, err := newCodeDirectoryFromMacho(id, hasher, m, opts)
 	if err != nil {
-		return nil, fmt.Errorf("unable to encode code directory: %w", err)
+		return nil, err
 	}
 
-	blob := macho.NewBlob(macho.MagicCodedirectory, cdBytes)
-	return &blob, nil
+	return cd.Blob()
 }
 
-func newCodeDirectoryFromMacho(id string, hasher hash.Hash, m *macho.File, flags macho.CdFlag, requirementsHashBytes, entitlementsHashBytes []byte) (*macho.CodeDirectory, error) {
-	textSeg := m.Segment("__TEXT")
+// newCodeDirectoryFromMacho creates the code directory for the given binary. The executable segment is the __TEXT
+// segment.
+func newCodeDirectoryFromMacho(id string, hasher hash.Hash, m *macho.File, opts codeDirectoryOptions) (*macho.CodeDirectory, error) {
+	var execOffset, execSize uint64
+	if textSeg := m.Segment("__TEXT"); textSeg != nil {
+		execOffset, execSize = textSeg.Offset, textSeg.Filesz
+	}
 
 	var codeSize uint32
 	if m.HasCodeSigningCmd() {
@@ -53,19 +166,46 @@ func newCodeDirectoryFromMacho(id string, hasher hash.Hash, m *macho.File, flags
 		codeSize = uint32(linkEditSeg.Offset + linkEditSeg.Filesz)
 	}
 
-	hashes, err := m.HashPages(hasher)
+	hashes, err := opts.pages.hash(m, hasher)
 	if err != nil {
 		return nil, err
 	}
 
-	return newCodeDirectory(id, hasher, textSeg.Offset, textSeg.Filesz, codeSize, hashes, flags, requirementsHashBytes, entitlementsHashBytes)
+	if opts.runtimeVersion == 0 {
+		opts.runtimeVersion, err = m.SDKVersion()
+		if err != nil {
+			return nil, fmt.Errorf("unable to detect the runtime version: %w", err)
+		}
+	}
+
+	return newCodeDirectory(id, hasher, execOffset, execSize, codeSize, hashes, opts)
 }
 
-func newCodeDirectory(id string, hasher hash.Hash, execOffset, execSize uint64, codeSize uint32, hashes [][]byte, flags macho.CdFlag, requirementsHashBytes, entitlementsHashBytes []byte) (*macho.CodeDirectory, error) {
-	cdSize := unsafe.Sizeof(macho.BlobHeader{}) + unsafe.Sizeof(macho.CodeDirectoryHeader{})
+//nolint:funlen
+func newCodeDirectory(id string, hasher hash.Hash, execOffset, execSize uint64, codeSize uint32, hashes [][]byte, opts codeDirectoryOptions) (*macho.CodeDirectory, error) {
+	teamID, specialSlots := opts.teamID, opts.specialSlots
+
+	version := opts.version
+	if version == 0 {
+		version = DefaultCodeDirectoryVersion
+	}
+	headerSize := codeDirectoryHeaderSize(version)
+	if headerSize == 0 {
+		return nil, fmt.Errorf("unsupported code directory version 0x%x", uint32(version))
+	}
+
+	cdSize := int(unsafe.Sizeof(macho.BlobHeader{})) + headerSize
 	idOff := int32(cdSize)
+	// note: the optional team identifier directly follows the identifier
+	var teamOff int32
+	if teamID != "" {
+		teamOff = idOff + int32(len(id)+1)
+	}
 	// note: the hash offset starts at the first non-special hash (page hashes). Special hashes (e.g. requirements hash) are written before the page hashes.
-	hashOff := idOff + int32(len(id)+1) + int32(len(requirementsHashBytes)) + int32(len(entitlementsHashBytes))
+	hashOff := idOff + int32(len(id)+1) + int32(len(specialSlots)*hasher.Size())
+	if teamID != "" {
+		hashOff += int32(len(teamID) + 1)
+	}
 
 	var ht macho.HashType
 	switch hasher.Size() {
@@ -84,12 +224,20 @@ func newCodeDirectory(id string, hasher hash.Hash, execOffset, execSize uint64,
 		return nil, fmt.Errorf("unable to write ID to code directory: %w", err)
 	}
 
-	// write hashes
-	if _, err := buff.Write(requirementsHashBytes); err != nil {
-		return nil, fmt.Errorf("unable to write requirements hash to code directory: %w", err)
+	if teamID != "" {
+		if _, err := buff.Write([]byte(teamID + "\000")); err != nil {
+			return nil, fmt.Errorf("unable to write team ID to code directory: %w", err)
+		}
 	}
-	if _, err := buff.Write(entitlementsHashBytes); err != nil {
-		return nil, fmt.Errorf("unable to write plist hash to code directory: %w", err)
+
+	// write the special slot hashes (slot -N is written first, slot -1 is directly before the page hashes)
+	for i := len(specialSlots) - 1; i >= 0; i-- {
+		if len(specialSlots[i]) != hasher.Size() {
+			return nil, fmt.Errorf("invalid hash size for special slot %d: %d", i+1, len(specialSlots[i]))
+		}
+		if _, err := buff.Write(specialSlots[i]); err != nil {
+			return nil, fmt.Errorf("unable to write special slot %d hash to code directory: %w", i+1, err)
+		}
 	}
 
 	for idx, hBytes := range hashes {
@@ -99,24 +247,33 @@ func newCodeDirectory(id string, hasher hash.Hash, execOffset, execSize uint64,
 		}
 	}
 
+	header := macho.CodeDirectoryHeader{
+		Version:       version,
+		Flags:         opts.flags,
+		HashOffset:    uint32(hashOff),
+		IdentOffset:   uint32(idOff),
+		NSpecialSlots: uint32(len(specialSlots)),
+		NCodeSlots:    uint32(len(hashes)),
+		CodeLimit:     codeSize,
+		HashSize:      uint8(hasher.Size()),
+		HashType:      ht,
+		TeamOffset:    uint32(teamOff),
+		PageSize:      uint8(macho.PageSizeBits),
+	}
+	if version >= macho.SupportsExecseg {
+		header.ExecSegBase = execOffset
+		header.ExecSegLimit = execSize
+		header.ExecSegFlags = opts.execSegFlags
+	}
+	if version >= macho.SupportsRuntime {
+		header.Runtime = uint32(opts.runtimeVersion)
+		if header.Runtime == 0 {
+			header.Runtime = uint32(DefaultRuntimeVersion)
+		}
+	}
+
 	return &macho.CodeDirectory{
-		CodeDirectoryHeader: macho.CodeDirectoryHeader{
-			Version:          macho.SupportsRuntime,
-			Flags:            flags,
-			HashOffset:       uint32(hashOff),
-			IdentOffset:      uint32(idOff),
-			NSpecialSlots:    uint32(2), // requirements + plist
-			NCodeSlots:       uint32(len(hashes)),
-			CodeLimit:        codeSize,
-			HashSize:         uint8(hasher.Size()),
-			HashType:         ht,
-			PageSize:         uint8(macho.PageSizeBits),
-			ExecSegBase:      execOffset,
-			ExecSegLimit:     execSize,
-			ExecSegFlags:     macho.ExecsegMainBinary,
-			Runtime:          0x0c0100,
-			PreEncryptOffset: 0x0,
-		},
-		Payload: buff.Bytes(),
+		CodeDirectoryHeader: header,
+		Payload:             buff.Bytes(),
 	}, nil
 }
@@ -2,6 +2,7 @@ package sign
 
 import (
 	"crypto/sha256"
+	debugMacho "debug/macho"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/anchore/quill/internal/test"
+	"github.com/anchore/quill/quill/entitlements"
 	"github.com/anchore/quill/quill/macho"
 )
 
@@ -87,7 +89,11 @@ func Test_newCodeDirectoryFromMacho(t *testing.T) {
 			pListBytes, err := hex.DecodeString(tt.pListHash)
 			require.NoError(t, err)
 
-			actualCD, err := newCodeDirectoryFromMacho(tt.id, tt.hasher, m, tt.flags, reqBytes, pListBytes)
+			actualCD, err := newCodeDirectoryFromMacho(tt.id, tt.hasher, m, codeDirectoryOptions{
+				flags:        tt.flags,
+				execSegFlags: execSegFlags(m, nil),
+				specialSlots: [][]byte{pListBytes, reqBytes},
+			})
 			require.NoError(t, err)
 
 			// make certain the headers match
@@ -113,7 +119,7 @@ func Test_newCodeDirectoryFromMacho(t *testing.T) {
 			require.NoError(t, err)
 
 			// grab the bytes for our CD that we crafted (not for hashing)...
-			blob, err := packCodeDirectory(actualCD, macho.SigningOrder)
+			blob, err := actualCD.Blob()
 			require.NoError(t, err)
 
 			actualCDBytes, err := restruct.Pack(macho.SigningOrder, blob)
@@ -184,7 +190,11 @@ func Test_generateCodeDirectory(t *testing.T) {
 			pListBytes, err := hex.DecodeString(tt.pListHash)
 			require.NoError(t, err)
 
-			cdBlob, err := generateCodeDirectory(tt.id, tt.hasher, m, tt.flags, reqBytes, pListBytes)
+			cdBlob, err := generateCodeDirectory(tt.id, tt.hasher, m, codeDirectoryOptions{
+				flags:        tt.flags,
+				execSegFlags: execSegFlags(m, nil),
+				specialSlots: [][]byte{pListBytes, reqBytes},
+			})
 			require.NoError(t, err)
 
 			cdBytes, err := cdBlob.Pack()
@@ -209,3 +219,169 @@ func Test_generateCodeDirectory(t *testing.T) {
 		})
 	}
 }
+
+func Test_execSegFlags(t *testing.T) {
+	executable := &macho.File{File: &debugMacho.File{FileHeader: debugMacho.FileHeader{Type: debugMacho.TypeExec}}}
+	library := &macho.File{File: &debugMacho.File{FileHeader: debugMacho.FileHeader{Type: debugMacho.TypeDylib}}}
+
+	tests := []struct {
+		name string
+		m    *macho.File
+		ents entitlements.Entitlements
+		want macho.ExecSegFlag
+	}{
+		{
+			name: "executable",
+			m:    executable,
+			want: macho.ExecsegMainBinary,
+		},
+		{
+			name: "library",
+			m:    library,
+		},
+		{
+			name: "debuggable executable",
+			m:    executable,
+			ents: entitlements.Entitlements{"get-task-allow": true},
+			want: macho.ExecsegMainBinary | macho.ExecsegAllowUnsigned,
+		},
+		{
+			name: "disabled entitlements are ignored",
+			m:    executable,
+			ents: entitlements.Entitlements{"get-task-allow": false, "dynamic-codesigning": "yes"},
+			want: macho.ExecsegMainBinary,
+		},
+		{
+			name: "library with jit and cdhash entitlements",
+			m:    library,
+			ents: entitlements.Entitlements{
+				"dynamic-codesigning":                       true,
+				"com.apple.private.amfi.can-load-cdhash":    true,
+				"com.apple.private.amfi.can-execute-cdhash": true,
+			},
+			want: macho.ExecsegJit | macho.ExecsegCanLoadCdhash | macho.ExecsegCanExecCdhash,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, execSegFlags(tt.m, tt.ents))
+		})
+	}
+}
+
+func TestParseCodeDirectoryVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    macho.CdVersion
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "default",
+			input: "",
+			want:  DefaultCodeDirectoryVersion,
+		},
+		{
+			name:  "hex",
+			input: "0x20400",
+			want:  macho.SupportsExecseg,
+		},
+		{
+			name:  "decimal",
+			input: "131584",
+			want:  macho.SupportsTeamid,
+		},
+		{
+			name:    "unsupported version",
+			input:   "0x20000",
+			wantErr: require.Error,
+		},
+		{
+			name:    "not a number",
+			input:   "latest",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseCodeDirectoryVersion(tt.input)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_newCodeDirectory_versions(t *testing.T) {
+	hasher := sha256.New()
+	hashes := [][]byte{make([]byte, hasher.Size()), make([]byte, hasher.Size())}
+
+	tests := []struct {
+		version    macho.CdVersion
+		headerSize int
+	}{
+		{version: macho.SupportsTeamid, headerSize: 44},
+		{version: macho.SupportsCodelimit64, headerSize: 56},
+		{version: macho.SupportsExecseg, headerSize: 80},
+		{version: macho.SupportsRuntime, headerSize: 88},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("0x%x", uint32(tt.version)), func(t *testing.T) {
+			cd, err := newCodeDirectory("my-id", hasher, 0, 0x4000, 0x8000, hashes, codeDirectoryOptions{
+				version:      tt.version,
+				teamID:       "TEAM",
+				execSegFlags: macho.ExecsegMainBinary,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.version, cd.Version)
+			assert.Equal(t, uint32(8+tt.headerSize), cd.IdentOffset)
+			assert.Equal(t, tt.version >= macho.SupportsExecseg, cd.ExecSegFlags != 0)
+			assert.Equal(t, tt.version >= macho.SupportsRuntime, cd.Runtime != 0)
+
+			blob, err := cd.Blob()
+			require.NoError(t, err)
+			by, err := blob.Pack()
+			require.NoError(t, err)
+
+			require.Len(t, by, 8+tt.headerSize+len(cd.Payload))
+			assert.Equal(t, "my-id\000TEAM\000", string(by[cd.IdentOffset:cd.HashOffset]))
+		})
+	}
+
+	_, err := newCodeDirectory("my-id", hasher, 0, 0, 0, hashes, codeDirectoryOptions{version: 0x20000})
+	require.Error(t, err)
+}
+
+func Test_newCodeDirectory_runtimeVersion(t *testing.T) {
+	hasher := sha256.New()
+	hashes := [][]byte{make([]byte, hasher.Size())}
+
+	tests := []struct {
+		name    string
+		version macho.Version
+		want    uint32
+	}{
+		{
+			name: "default",
+			want: uint32(DefaultRuntimeVersion),
+		},
+		{
+			name:    "explicit",
+			version: macho.NewVersion(14, 2, 0),
+			want:    0x000e0200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd, err := newCodeDirectory("my-id", hasher, 0, 0, 0x1000, hashes, codeDirectoryOptions{runtimeVersion: tt.version})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cd.Runtime)
+		})
+	}
+}
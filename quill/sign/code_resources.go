@@ -0,0 +1,240 @@
+package sign
+
+import (
+	"crypto/sha1" //nolint:gosec // the legacy resource seal hash
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+// CodeResourcesPath is the location of the sealed resources file relative to the bundle root.
+const CodeResourcesPath = "_CodeSignature/CodeResources"
+
+// NestedCode is the seal of signed code nested within a bundle (a framework, library, or app extension), which is
+// sealed by its code directory hash and designated requirement instead of by its contents.
+type NestedCode struct {
+	CDHash      []byte
+	Requirement string
+}
+
+// resourceRule is an entry of the resource rules: the rule with the highest weight matching a path decides if the
+// file is sealed (omitted files are not sealed, optional files may be missing at verification time, and nested
+// locations hold code sealed by its signature).
+type resourceRule struct {
+	pattern  string
+	re       *regexp.Regexp
+	omit     bool
+	optional bool
+	nested   bool
+	weight   float64
+}
+
+// resourceRules are the rules of a resource seal. The legacy "files" rules only exist for older verifiers, "files2"
+// is what current verifiers check.
+type resourceRules struct {
+	files  []resourceRule
+	files2 []resourceRule
+}
+
+// the default rules codesign uses for (shallow) iOS bundles and (deep) macOS bundles, where paths are relative to the
+// Contents directory.
+var (
+	shallowResourceRules = resourceRules{
+		files: []resourceRule{
+			{pattern: `^.*`},
+			{pattern: `^.*\.lproj/`, optional: true, weight: 1000},
+			{pattern: `^.*\.lproj/locversion.plist$`, omit: true, weight: 1100},
+			{pattern: `^Base\.lproj/`, weight: 1010},
+			{pattern: `^version.plist$`},
+		},
+		files2: []resourceRule{
+			{pattern: `^.*`},
+			{pattern: `.*\.dSYM($|/)`, weight: 11},
+			{pattern: `^(.*/)?\.DS_Store$`, omit: true, weight: 2000},
+			{pattern: `^.*\.lproj/`, optional: true, weight: 1000},
+			{pattern: `^.*\.lproj/locversion.plist$`, omit: true, weight: 1100},
+			{pattern: `^Base\.lproj/`, weight: 1010},
+			{pattern: `^Info\.plist$`, omit: true, weight: 20},
+			{pattern: `^PkgInfo$`, omit: true, weight: 20},
+			{pattern: `^embedded\.provisionprofile$`, weight: 20},
+			{pattern: `^version\.plist$`, weight: 20},
+		},
+	}
+	deepResourceRules = resourceRules{
+		files: []resourceRule{
+			{pattern: `^Resources/`},
+			{pattern: `^Resources/.*\.lproj/`, optional: true, weight: 1000},
+			{pattern: `^Resources/.*\.lproj/locversion.plist$`, omit: true, weight: 1100},
+			{pattern: `^Resources/Base\.lproj/`, weight: 1010},
+			{pattern: `^version.plist$`},
+		},
+		files2: []resourceRule{
+			{pattern: `.*\.dSYM($|/)`, weight: 11},
+			{pattern: `^(.*/)?\.DS_Store$`, omit: true, weight: 2000},
+			{pattern: `^(Frameworks|SharedFrameworks|PlugIns|Plug-ins|XPCServices|Helpers|MacOS|Library/(Automator|Spotlight|LoginItems))/`, nested: true, weight: 10},
+			{pattern: `^.*`},
+			{pattern: `^Info\.plist$`, omit: true, weight: 20},
+			{pattern: `^PkgInfo$`, omit: true, weight: 20},
+			{pattern: `^Resources/`, weight: 20},
+			{pattern: `^Resources/.*\.lproj/`, optional: true, weight: 1000},
+			{pattern: `^Resources/.*\.lproj/locversion.plist$`, omit: true, weight: 1100},
+			{pattern: `^Resources/Base\.lproj/`, weight: 1010},
+			{pattern: `^[^/]+$`, nested: true, weight: 10},
+			{pattern: `^embedded\.provisionprofile$`, weight: 20},
+			{pattern: `^version\.plist$`, weight: 20},
+		},
+	}
+)
+
+func init() {
+	for _, rules := range [][]resourceRule{shallowResourceRules.files, shallowResourceRules.files2, deepResourceRules.files, deepResourceRules.files2} {
+		for i := range rules {
+			rules[i].re = regexp.MustCompile(rules[i].pattern)
+		}
+	}
+}
+
+// match returns the rule deciding how the given path is sealed (paths not matching any rule are not sealed).
+func match(rules []resourceRule, path string) (resourceRule, bool) {
+	var best resourceRule
+	found := false
+	for _, r := range rules {
+		if !r.re.MatchString(path) {
+			continue
+		}
+		if !found || r.weight > best.weight {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// sealed indicates if the given path is sealed by the given rules, returning the deciding rule.
+func sealed(rules []resourceRule, path string) (resourceRule, bool) {
+	rule, ok := match(rules, path)
+	return rule, ok && !rule.omit
+}
+
+func rulesPlist(rules []resourceRule) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, r := range rules {
+		if !r.omit && !r.optional && !r.nested && r.weight == 0 {
+			out[r.pattern] = true
+			continue
+		}
+		rule := map[string]interface{}{}
+		if r.omit {
+			rule["omit"] = true
+		}
+		if r.optional {
+			rule["optional"] = true
+		}
+		if r.nested {
+			rule["nested"] = true
+		}
+		if r.weight != 0 {
+			rule["weight"] = r.weight
+		}
+		out[r.pattern] = rule
+	}
+	return out
+}
+
+// generateCodeResources seals the files within the given directory (the bundle root, or the Contents directory of
+// deep bundles) with the given rules, returning the contents of the CodeResources file. The main executable (signed
+// with the seal) is not sealed, nested code is sealed by its signature (keyed by the path relative to the directory).
+//
+//nolint:funlen
+func generateCodeResources(dir, mainExecutable string, nested map[string]NestedCode, rules resourceRules) ([]byte, error) {
+	files := map[string]interface{}{}
+	files2 := map[string]interface{}{}
+
+	for rel, code := range nested {
+		files2[rel] = map[string]interface{}{
+			"cdhash":      code.CDHash,
+			"requirement": code.Requirement,
+		}
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() || rel == mainExecutable || rel == CodeResourcesPath {
+			return nil
+		}
+
+		// files of nested code are only sealed within the legacy seal
+		inNested := isNested(nested, rel)
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("unable to read symlink %q: %w", rel, err)
+			}
+			if _, ok := sealed(rules.files2, rel); ok && !inNested {
+				files2[rel] = map[string]interface{}{"symlink": target}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read bundle resource %q: %w", rel, err)
+		}
+		sha1Hash := sha1.Sum(contents) //nolint:gosec
+		sha256Hash := sha256.Sum256(contents)
+
+		if rule, ok := sealed(rules.files, rel); ok {
+			if rule.optional {
+				files[rel] = map[string]interface{}{"hash": sha1Hash[:], "optional": true}
+			} else {
+				files[rel] = sha1Hash[:]
+			}
+		}
+
+		if rule, ok := sealed(rules.files2, rel); ok && !inNested {
+			seal := map[string]interface{}{"hash": sha1Hash[:], "hash2": sha256Hash[:]}
+			if rule.optional {
+				seal["optional"] = true
+			}
+			files2[rel] = seal
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal bundle resources: %w", err)
+	}
+
+	doc := entitlements.Entitlements{
+		"files":  files,
+		"files2": files2,
+		"rules":  rulesPlist(rules.files),
+		"rules2": rulesPlist(rules.files2),
+	}
+	return []byte(doc.XML()), nil
+}
+
+// isNested indicates if the given path is (or is within) nested code.
+func isNested(nested map[string]NestedCode, rel string) bool {
+	for n := range nested {
+		if rel == n || strings.HasPrefix(rel, n+"/") {
+			return true
+		}
+	}
+	return false
+}
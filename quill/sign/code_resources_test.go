@@ -0,0 +1,153 @@
+package sign
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	}
+}
+
+func Test_generateCodeResources(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"App":                                 "main executable",
+		"Info.plist":                          "info",
+		"Assets.car":                          "assets",
+		"en.lproj/Localizable.strings":        "strings",
+		"en.lproj/locversion.plist":           "locversion",
+		".DS_Store":                           "finder",
+		"embedded.mobileprovision":            "profile",
+		"_CodeSignature/CodeResources":        "previous seal",
+		"Frameworks/Foo.framework/Foo":        "framework",
+		"Frameworks/Foo.framework/Info.plist": "framework info",
+	})
+	require.NoError(t, os.Symlink("Assets.car", filepath.Join(dir, "link")))
+
+	nested := map[string]NestedCode{
+		"Frameworks/Foo.framework": {CDHash: []byte{1, 2, 3}, Requirement: `identifier "com.example.foo"`},
+	}
+
+	by, err := generateCodeResources(dir, "App", nested, shallowResourceRules)
+	require.NoError(t, err)
+
+	doc, err := entitlements.ParseXML(by)
+	require.NoError(t, err)
+
+	sha1Of := func(s string) []byte {
+		h := sha1.Sum([]byte(s)) //nolint:gosec
+		return h[:]
+	}
+	sha256Of := func(s string) []byte {
+		h := sha256.Sum256([]byte(s))
+		return h[:]
+	}
+
+	files := doc["files"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"Info.plist":                          sha1Of("info"),
+		"Assets.car":                          sha1Of("assets"),
+		"en.lproj/Localizable.strings":        map[string]interface{}{"hash": sha1Of("strings"), "optional": true},
+		".DS_Store":                           sha1Of("finder"),
+		"embedded.mobileprovision":            sha1Of("profile"),
+		"Frameworks/Foo.framework/Foo":        sha1Of("framework"),
+		"Frameworks/Foo.framework/Info.plist": sha1Of("framework info"),
+	}, files)
+
+	files2 := doc["files2"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"Assets.car":                   map[string]interface{}{"hash": sha1Of("assets"), "hash2": sha256Of("assets")},
+		"en.lproj/Localizable.strings": map[string]interface{}{"hash": sha1Of("strings"), "hash2": sha256Of("strings"), "optional": true},
+		"embedded.mobileprovision":     map[string]interface{}{"hash": sha1Of("profile"), "hash2": sha256Of("profile")},
+		"link":                         map[string]interface{}{"symlink": "Assets.car"},
+		"Frameworks/Foo.framework":     map[string]interface{}{"cdhash": []byte{1, 2, 3}, "requirement": `identifier "com.example.foo"`},
+	}, files2)
+
+	rules2 := doc["rules2"].(map[string]interface{})
+	assert.Equal(t, true, rules2["^.*"])
+	assert.Equal(t, map[string]interface{}{"omit": true, "weight": float64(20)}, rules2[`^Info\.plist$`])
+	assert.Contains(t, doc, "rules")
+}
+
+func Test_generateCodeResources_deep(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Info.plist":             "info",
+		"PkgInfo":                "APPL????",
+		"MacOS/App":              "main executable",
+		"MacOS/run.sh":           "script",
+		"Resources/icon.icns":    "icon",
+		"Helpers/tool":           "helper",
+		"embedded.provisionprof": "other",
+	})
+
+	nested := map[string]NestedCode{
+		"Helpers/tool": {CDHash: []byte{1, 2, 3}, Requirement: `identifier "tool"`},
+	}
+
+	by, err := generateCodeResources(dir, "MacOS/App", nested, deepResourceRules)
+	require.NoError(t, err)
+
+	doc, err := entitlements.ParseXML(by)
+	require.NoError(t, err)
+
+	files := doc["files"].(map[string]interface{})
+	assert.Equal(t, []string{"Resources/icon.icns"}, keys(files))
+
+	files2 := doc["files2"].(map[string]interface{})
+	assert.Equal(t, []string{"Helpers/tool", "MacOS/run.sh", "Resources/icon.icns", "embedded.provisionprof"}, keys(files2))
+	assert.Equal(t, map[string]interface{}{"nested": true, "weight": float64(10)}, doc["rules2"].(map[string]interface{})[`^[^/]+$`])
+}
+
+func keys(m map[string]interface{}) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func Test_match(t *testing.T) {
+	tests := []struct {
+		rules    []resourceRule
+		path     string
+		sealed   bool
+		optional bool
+	}{
+		{rules: shallowResourceRules.files2, path: "Assets.car", sealed: true},
+		{rules: shallowResourceRules.files2, path: "Info.plist"},
+		{rules: shallowResourceRules.files2, path: "sub/Info.plist", sealed: true},
+		{rules: shallowResourceRules.files2, path: "fr.lproj/Main.strings", sealed: true, optional: true},
+		{rules: shallowResourceRules.files2, path: "Base.lproj/Main.storyboardc", sealed: true},
+		{rules: shallowResourceRules.files2, path: "fr.lproj/locversion.plist"},
+		{rules: shallowResourceRules.files2, path: "sub/.DS_Store"},
+		{rules: deepResourceRules.files, path: "MacOS/run.sh"},
+		{rules: deepResourceRules.files, path: "Resources/fr.lproj/Main.strings", sealed: true, optional: true},
+		{rules: deepResourceRules.files2, path: "Resources/Base.lproj/Main.nib", sealed: true},
+		{rules: deepResourceRules.files2, path: "PkgInfo"},
+		{rules: deepResourceRules.files2, path: "Frameworks/Foo.framework/Foo", sealed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rule, ok := sealed(tt.rules, tt.path)
+			assert.Equal(t, tt.sealed, ok)
+			assert.Equal(t, tt.optional, rule.optional)
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package sign
+
+import (
+	"bytes"
+	debugMacho "debug/macho"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"unsafe"
+
+	macholibre "github.com/anchore/go-macholibre"
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// Detach returns the signature of the signed (single-arch or universal) binary at the given path, so that it can be
+// kept apart from the binary (and attached to an unsigned copy of the binary later, see Attach): the embedded
+// signature superblob of a single-arch binary, or a detached signature superblob indexing the embedded signature of
+// every architecture by CPU type.
+func Detach(path string) ([]byte, error) {
+	signatures := map[debugMacho.Cpu][]byte{}
+	universal, err := forEachArch(path, func(archPath string) error {
+		m, err := macho.NewReadOnlyFile(archPath)
+		if err != nil {
+			return err
+		}
+		defer m.Close()
+
+		if !m.HasCodeSigningCmd() {
+			return fmt.Errorf("the binary is not signed (%s)", m.Cpu)
+		}
+		cmd, _, err := m.CodeSigningCmd()
+		if err != nil {
+			return fmt.Errorf("unable to extract code signing cmd: %w", err)
+		}
+
+		superBlob, err := m.ReadBytes(uint64(cmd.DataOffset), uint64(cmd.DataSize))
+		if err != nil {
+			return fmt.Errorf("unable to read the signature: %w", err)
+		}
+		signatures[m.Cpu] = superBlob
+		return nil
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !universal {
+		for _, superBlob := range signatures {
+			return superBlob, nil
+		}
+	}
+	return packDetachedSignature(signatures)
+}
+
+// Attach patches the given signature (as returned by Detach) into the unsigned (single-arch or universal) binary at
+// the given path: the LC_CODE_SIGNATURE load command is added and the __LINKEDIT segment is grown to hold the
+// signature. The signature must have been made for this binary (the binary is laid out exactly as it was when signed),
+// which is checked against the code limit of the signature.
+func Attach(path string, signature []byte) error {
+	signatures, err := parseDetachedSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	if _, err := forEachArch(path, func(archPath string) error {
+		return attachBinary(archPath, signatures)
+	}, true); err != nil {
+		return err
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.SignFinished, Path: path})
+	return nil
+}
+
+// anyCPU indexes the signature when it is an embedded signature (which does not name the architecture it is for).
+const anyCPU = debugMacho.Cpu(0)
+
+// attachBinary patches the signature for the architecture of the single-arch binary at the given path into it.
+func attachBinary(path string, signatures map[debugMacho.Cpu][]byte) error {
+	m, err := macho.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	superBlob, ok := signatures[m.Cpu]
+	if !ok {
+		if superBlob, ok = signatures[anyCPU]; !ok {
+			return fmt.Errorf("the signature has no signature for %s", m.Cpu)
+		}
+	}
+
+	reserved, err := m.HasReservedCodeSignature()
+	if err != nil {
+		return err
+	}
+	if m.HasCodeSigningCmd() && !reserved {
+		return fmt.Errorf("the binary is already signed (%s), the signature can only be attached to an unsigned copy of the binary", m.Cpu)
+	}
+	if err := m.CheckLayout(); err != nil {
+		return err
+	}
+
+	codeLimit, err := superBlobCodeLimit(superBlob)
+	if err != nil {
+		return err
+	}
+
+	if reserved {
+		// the binary was prepared (see Prepare): the signature is dropped into the reserved space
+		return attachReserved(m, path, superBlob, codeLimit)
+	}
+
+	// the signature starts at the end of __LINKEDIT (see macho.File.AddEmptyCodeSigningCmd), which is checked before
+	// the binary is modified so that a signature made for another binary leaves it untouched
+	linkEditSeg := m.Segment("__LINKEDIT")
+	if linkEditSeg == nil {
+		return fmt.Errorf("binary has no __LINKEDIT segment")
+	}
+	if offset := linkEditSeg.Offset + linkEditSeg.Filesz; offset != codeLimit {
+		return fmt.Errorf("the signature was not made for this binary: it covers %d bytes, but the signature would start at offset %d", codeLimit, offset)
+	}
+
+	log.WithFields("binary", path, "bytes", len(superBlob)).Debug("attaching signature")
+
+	if err = m.AddEmptyCodeSigningCmd(); err != nil {
+		return err
+	}
+
+	if err = UpdateSuperBlobOffsetReferences(m, uint64(len(superBlob))); err != nil {
+		return err
+	}
+
+	return m.WriteCodeSignature(superBlob)
+}
+
+// attachReserved writes the signature into the space reserved for it by Prepare, leaving the rest of the binary as is.
+func attachReserved(m *macho.File, path string, superBlob []byte, codeLimit uint64) error {
+	codeSigningCmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return err
+	}
+	if uint64(codeSigningCmd.DataOffset) != codeLimit {
+		return fmt.Errorf("the signature was not made for this binary: it covers %d bytes, but the reserved space starts at offset %d", codeLimit, codeSigningCmd.DataOffset)
+	}
+
+	log.WithFields("binary", path, "bytes", len(superBlob), "reserved", codeSigningCmd.DataSize).Debug("attaching signature into the reserved space")
+	return m.WriteCodeSignature(superBlob)
+}
+
+// forEachArch calls the given function with the path of every architecture of the binary at the given path (the
+// binary itself when it is not universal), returning whether the binary is universal. When repack is set, the
+// architectures of a universal binary are packaged back into the binary afterwards.
+func forEachArch(path string, fn func(archPath string) error, repack bool) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if !macholibre.IsUniversalMachoBinary(f) {
+		return false, fn(path)
+	}
+
+	dir, err := os.MkdirTemp("", "quill-extract-"+filepath.Base(path))
+	if err != nil {
+		return true, fmt.Errorf("unable to create temp directory to extract multi-arch binary: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	extracted, err := macholibre.Extract(f, dir)
+	if err != nil {
+		return true, fmt.Errorf("unable to extract multi-arch binary: %w", err)
+	}
+
+	var paths []string
+	for _, ef := range extracted {
+		if err := fn(ef.Path); err != nil {
+			return true, err
+		}
+		paths = append(paths, ef.Path)
+	}
+
+	if repack {
+		if err := macholibre.Package(path, paths...); err != nil {
+			return true, fmt.Errorf("unable to package multi-arch binary: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// packDetachedSignature encodes a detached signature superblob: the index type of every entry is the CPU type of the
+// architecture the embedded signature is for.
+func packDetachedSignature(signatures map[debugMacho.Cpu][]byte) ([]byte, error) {
+	var cpus []debugMacho.Cpu
+	for cpu := range signatures {
+		cpus = append(cpus, cpu)
+	}
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i] < cpus[j] })
+
+	header := macho.SuperBlobHeader{Magic: macho.MagicDetachedSignature, Count: uint32(len(cpus))}
+	offset := uint32(unsafe.Sizeof(header)) + uint32(unsafe.Sizeof(macho.BlobIndex{}))*header.Count
+
+	var index []macho.BlobIndex
+	for _, cpu := range cpus {
+		index = append(index, macho.BlobIndex{Type: macho.SlotType(cpu), Offset: offset})
+		offset += uint32(len(signatures[cpu]))
+	}
+	header.Length = offset
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, macho.SigningOrder, header); err != nil {
+		return nil, fmt.Errorf("unable to encode detached signature: %w", err)
+	}
+	if err := binary.Write(&buf, macho.SigningOrder, index); err != nil {
+		return nil, fmt.Errorf("unable to encode detached signature: %w", err)
+	}
+	for _, cpu := range cpus {
+		buf.Write(signatures[cpu])
+	}
+	return buf.Bytes(), nil
+}
+
+// parseDetachedSignature returns the embedded signature superblob of every architecture (by CPU type) from the given
+// detached signature superblob, or the given embedded signature superblob itself (see anyCPU).
+func parseDetachedSignature(b []byte) (map[debugMacho.Cpu][]byte, error) {
+	superBlob, err := macho.ParseSuperBlob(b)
+	if err != nil {
+		return nil, err
+	}
+	entries := superBlob.Entries
+
+	switch magic := macho.Magic(macho.SigningOrder.Uint32(b)); magic {
+	case macho.MagicEmbeddedSignature:
+		return map[debugMacho.Cpu][]byte{anyCPU: b}, nil
+	case macho.MagicDetachedSignature:
+		// the embedded signature of every architecture extends up to the next one (rather than the length of its
+		// header), since the signed size of the signature (recorded by LC_CODE_SIGNATURE) includes trailing padding
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Offset < entries[j].Offset })
+
+		signatures := map[debugMacho.Cpu][]byte{}
+		for i, e := range entries {
+			end := uint64(len(b))
+			if i+1 < len(entries) {
+				end = uint64(entries[i+1].Offset)
+			}
+			if e.Magic != macho.MagicEmbeddedSignature {
+				return nil, fmt.Errorf("the signature for cpu=0x%x is not an embedded signature", uint32(e.Slot))
+			}
+			signatures[debugMacho.Cpu(e.Slot)] = b[e.Offset:end]
+		}
+		return signatures, nil
+	default:
+		return nil, fmt.Errorf("unexpected signature magic: 0x%x", uint32(magic))
+	}
+}
+
+// superBlobCodeLimit returns the code limit of the primary code directory of the given embedded signature superblob.
+func superBlobCodeLimit(b []byte) (uint64, error) {
+	superBlob, err := macho.ParseSuperBlob(b)
+	if err != nil {
+		return 0, err
+	}
+	cd := superBlob.Entry(macho.CsSlotCodedirectory)
+	if cd == nil {
+		return 0, fmt.Errorf("the signature has no code directory")
+	}
+	var header macho.CodeDirectoryHeader
+	start := unsafe.Sizeof(macho.BlobHeader{}) + unsafe.Offsetof(header.CodeLimit)
+	if uintptr(len(cd.Data)) < start+4 {
+		return 0, fmt.Errorf("code directory is too short")
+	}
+	return uint64(macho.SigningOrder.Uint32(cd.Data[start:])), nil
+}
@@ -0,0 +1,265 @@
+package sign
+
+import (
+	debugMacho "debug/macho"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/macho/machotest"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/testca"
+	"github.com/anchore/quill/quill/remediation"
+)
+
+// writeUnsignedBinary writes a minimal (unsigned) arm64 executable: a __TEXT segment followed by a __LINKEDIT segment.
+func writeUnsignedBinary(t *testing.T, path string, extraLoads ...interface{}) {
+	t.Helper()
+	machotest.Write(t, path, machotest.Config{Loads: extraLoads})
+}
+
+func TestAttach(t *testing.T) {
+	dir := t.TempDir()
+	signed, unsigned := filepath.Join(dir, "signed"), filepath.Join(dir, "unsigned")
+	writeUnsignedBinary(t, signed)
+	writeUnsignedBinary(t, unsigned)
+	require.NoError(t, Binary(signed, "com.example.tool", pki.SigningMaterial{}))
+
+	signature, err := Detach(signed)
+	require.NoError(t, err)
+
+	detached, err := packDetachedSignature(map[debugMacho.Cpu][]byte{debugMacho.CpuArm64: signature})
+	require.NoError(t, err)
+
+	want, err := os.ReadFile(signed)
+	require.NoError(t, err)
+
+	for name, sig := range map[string][]byte{"embedded": signature, "detached": detached} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool")
+			writeUnsignedBinary(t, path)
+
+			require.NoError(t, Attach(path, sig))
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "the attached binary must be identical to the signed binary")
+
+			// the signature cannot be attached twice
+			require.ErrorContains(t, Attach(path, sig), "already signed")
+		})
+	}
+
+	_, err = Detach(unsigned)
+	require.ErrorContains(t, err, "not signed")
+
+	other, err := packDetachedSignature(map[debugMacho.Cpu][]byte{debugMacho.CpuAmd64: signature})
+	require.NoError(t, err)
+	require.ErrorContains(t, Attach(unsigned, other), "no signature for CpuArm64")
+}
+
+func TestAttach_differentBinary(t *testing.T) {
+	dir := t.TempDir()
+	signed, other := filepath.Join(dir, "signed"), filepath.Join(dir, "other")
+	writeUnsignedBinary(t, signed)
+	require.NoError(t, Binary(signed, "com.example.tool", pki.SigningMaterial{}))
+	signature, err := Detach(signed)
+	require.NoError(t, err)
+
+	// a binary with a different layout: __LINKEDIT is larger, so the signature would start elsewhere
+	writeUnsignedBinary(t, other)
+	content, err := os.ReadFile(other)
+	require.NoError(t, err)
+	const linkEditFilesz = 32 + 72 + 48 // header, __TEXT segment, __LINKEDIT fields before filesz
+	binary.LittleEndian.PutUint64(content[linkEditFilesz:], 0x110)
+	content = append(content, make([]byte, 0x10)...)
+	require.NoError(t, os.WriteFile(other, content, 0700))
+
+	require.ErrorContains(t, Attach(other, signature), "the signature was not made for this binary")
+
+	after, err := os.ReadFile(other)
+	require.NoError(t, err)
+	assert.Equal(t, content, after, "the binary is left untouched")
+}
+
+func Test_parseDetachedSignature_invalid(t *testing.T) {
+	_, err := parseDetachedSignature([]byte{0xfa, 0xde, 0x0c, 0x02, 0, 0, 0, 12, 0, 0, 0, 0})
+	require.ErrorContains(t, err, "unexpected signature magic")
+
+	_, err = parseDetachedSignature([]byte{0xfa, 0xde, 0x0c, 0xc1, 0, 0, 0, 12, 0, 0, 0, 9})
+	require.ErrorContains(t, err, "malformed superblob: index exceeds the signature")
+}
+
+func TestBinary_sharedSigningMaterial(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	// the same material (parsed once) signs every binary concurrently
+	sm := pki.NewSigningMaterial(fixture.LeafKey, fixture.Chain())
+
+	dir := t.TempDir()
+	paths := make([]string, 8)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("tool-%d", i))
+		writeUnsignedBinary(t, paths[i])
+	}
+
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			errs[i] = Binary(p, "com.example.tool", *sm)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for i, p := range paths {
+		require.NoError(t, errs[i], p)
+
+		signature, err := Detach(p)
+		require.NoError(t, err)
+		assert.NotEmpty(t, signature)
+	}
+}
+
+func TestBinaryWithOptions_loadCommandEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	writeUnsignedBinary(t, path)
+
+	edits := BinaryOptions{LoadCommandEdits: []macho.LoadCommandEdit{macho.AddRpath("@executable_path/../lib")}}
+	require.NoError(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, edits))
+
+	// signing again replaces the signature, the run path is only added once
+	require.NoError(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, edits))
+
+	m, err := macho.NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+
+	cmds, err := m.LoadCommands()
+	require.NoError(t, err)
+	var types []macho.LoadCommandType
+	for _, c := range cmds {
+		types = append(types, c.Type)
+	}
+	assert.Equal(t, []macho.LoadCommandType{macho.LcSegment64, macho.LcSegment64, macho.LcRpath, macho.LcCodeSignature}, types)
+	assert.Equal(t, "@executable_path/../lib", cmds[2].Value.(macho.RpathCommand).Path)
+}
+
+func TestBinaryWithOptions_chainedFixups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	// the chained fixups and the exports trie fill __LINKEDIT, as modern linkers lay it out
+	writeUnsignedBinary(t, path,
+		macho.LinkEditDataCommand{Cmd: macho.LcDyldChainedFixups, Size: 16, DataOffset: 0x2000, DataSize: 0xc0},
+		macho.LinkEditDataCommand{Cmd: macho.LcDyldExportsTrie, Size: 16, DataOffset: 0x20c0, DataSize: 0x40},
+	)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var sizes []int
+	for i := 0; i < 3; i++ {
+		// the first pass signs the binary, the next ones replace the signature
+		require.NoError(t, Binary(path, "com.example.tool", pki.SigningMaterial{}))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		sizes = append(sizes, len(content))
+		assert.Equal(t, original[0x2000:0x2100], content[0x2000:0x2100], "the __LINKEDIT content is unchanged")
+
+		m, err := macho.NewReadOnlyFile(path)
+		require.NoError(t, err)
+
+		// the signature directly follows the exports trie and ends both __LINKEDIT and the binary
+		cmd, _, err := m.CodeSigningCmd()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0x2100), cmd.DataOffset)
+		linkEdit := m.Segment("__LINKEDIT")
+		assert.Equal(t, uint64(cmd.DataOffset)+uint64(cmd.DataSize), linkEdit.Offset+linkEdit.Filesz)
+		assert.Equal(t, int64(cmd.DataOffset)+int64(cmd.DataSize), m.Size())
+
+		contents, err := m.LinkEditContents()
+		require.NoError(t, err)
+		assert.Equal(t, []macho.LinkEditContent{
+			{Cmd: macho.LcDyldChainedFixups, Offset: 0x2000, Size: 0xc0},
+			{Cmd: macho.LcDyldExportsTrie, Offset: 0x20c0, Size: 0x40},
+		}, contents)
+		require.NoError(t, m.Close())
+	}
+	assert.Equal(t, []int{sizes[0], sizes[0], sizes[0]}, sizes, "re-signing does not grow the binary")
+}
+
+func TestBinaryWithOptions_trailingData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	writeUnsignedBinary(t, path)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(original, "appended payload"...), 0700))
+
+	// the signature would overwrite the appended data
+	err = Binary(path, "com.example.tool", pki.SigningMaterial{})
+	require.ErrorContains(t, err, "0x10 bytes of trailing data after the end of __LINKEDIT (at 0x2100)")
+	r, ok := remediation.Get(err)
+	require.True(t, ok)
+	assert.Equal(t, remediation.UnexpectedLayout, r.Code)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, append(original, "appended payload"...), content, "the binary is left untouched")
+
+	require.NoError(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, BinaryOptions{NormalizeLayout: true}))
+
+	m, err := macho.NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+	cmd, _, err := m.CodeSigningCmd()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x2100), cmd.DataOffset)
+	require.NoError(t, m.CheckLayout())
+}
+
+func TestBinaryWithOptions_resignInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	writeUnsignedBinary(t, path)
+
+	sign := func(id string, opts BinaryOptions) (int64, uint32) {
+		require.NoError(t, BinaryWithOptions(path, id, pki.SigningMaterial{}, opts))
+		m, err := macho.NewReadOnlyFile(path)
+		require.NoError(t, err)
+		defer m.Close()
+		cmd, _, err := m.CodeSigningCmd()
+		require.NoError(t, err)
+		require.NoError(t, m.CheckLayout())
+		return m.Size(), cmd.DataSize
+	}
+
+	size, dataSize := sign("com.example.tool.with.a.long.identifier", BinaryOptions{})
+
+	// a smaller signature is patched into the space of the existing signature
+	gotSize, gotDataSize := sign("com.example.tool", BinaryOptions{})
+	assert.Equal(t, size, gotSize)
+	assert.Equal(t, dataSize, gotDataSize)
+
+	// a signature without room to spare for a timestamp token grows the binary
+	tight := dataSize - 4*macho.PageSize + 0x40
+	_, gotDataSize = sign("com.example.tool", BinaryOptions{SignatureSize: int(tight)})
+	require.Equal(t, tight, gotDataSize)
+	_, gotDataSize = sign("com.example.tool", BinaryOptions{})
+	assert.Greater(t, gotDataSize, tight)
+
+	// a signature which does not fit grows the binary
+	large := entitlements.Entitlements{"com.example.large": strings.Repeat("x", 8*macho.PageSize)}
+	gotSize, gotDataSize = sign("com.example.tool", BinaryOptions{Entitlements: large})
+	assert.Greater(t, gotSize, size)
+	assert.Greater(t, gotDataSize, dataSize)
+}
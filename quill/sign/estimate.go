@@ -0,0 +1,65 @@
+package sign
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+// MaxTimestampTokenSize is the space reserved for the timestamp token by EstimateSignatureSize when timestamping is
+// enabled. Tokens are not requested while estimating, the allowance covers the token along with the certificate chain
+// embedded by the common timestamp authorities.
+const MaxTimestampTokenSize = 3 * macho.PageSize
+
+// EstimateSignatureSize returns the size of the code signature BinaryWithOptions writes for the given binary, signing
+// material and options, including MaxTimestampTokenSize when timestamping is enabled (re-signing a binary keeps the
+// size of the existing signature instead when the new one fits with MaxTimestampTokenSize to spare). The space can be
+// reserved ahead of signing (e.g. by a build system at link time, see macho.File.ReserveCodeSignature) and the
+// signature made to fill it exactly by passing the size as BinaryOptions.SignatureSize.
+//
+// The binary is not modified: the signature is generated for an in-memory copy, without contacting the timestamp
+// authority.
+func EstimateSignatureSize(m *macho.File, id string, signingMaterial pki.SigningMaterial, opts BinaryOptions) (int, error) {
+	c, err := m.Copy()
+	if err != nil {
+		return 0, fmt.Errorf("unable to copy binary: %w", err)
+	}
+	defer c.Close()
+
+	if err := prepareBinary(c, opts); err != nil {
+		return 0, err
+	}
+
+	// the first signing pass fixes the signature size: the code limit (and so the number of page hashes) covers the
+	// binary up to the empty code signing load command
+	stamped := signingMaterial.Timestamp.Enabled()
+	signingMaterial.Timestamp = timestamp.Config{}
+	opts.SignatureSize = 0
+
+	_, sbBytes, err := generateSigningSuperBlob(id, c, signingMaterial, opts, 0, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to generate signature: %w", err)
+	}
+
+	size := len(sbBytes)
+	if stamped {
+		size += MaxTimestampTokenSize
+	}
+	return size, nil
+}
+
+// signaturePaddingTarget returns the superblob padding target making the signature the given size, the length of a
+// superblob (the padding target) does not count its header.
+func signaturePaddingTarget(signatureSize int) (int, error) {
+	if signatureSize == 0 {
+		return 0, nil
+	}
+	target := signatureSize - int(unsafe.Sizeof(macho.SuperBlobHeader{}))
+	if target <= 0 {
+		return 0, fmt.Errorf("invalid signature size %d", signatureSize)
+	}
+	return target, nil
+}
@@ -0,0 +1,61 @@
+package sign
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+func TestEstimateSignatureSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	writeUnsignedBinary(t, path)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	signatureSize := func() int {
+		m, err := macho.NewReadOnlyFile(path)
+		require.NoError(t, err)
+		defer m.Close()
+		cmd, _, err := m.CodeSigningCmd()
+		require.NoError(t, err)
+		return int(cmd.DataSize)
+	}
+
+	estimate := func(material pki.SigningMaterial) int {
+		m, err := macho.NewReadOnlyFile(path)
+		require.NoError(t, err)
+		defer m.Close()
+		size, err := EstimateSignatureSize(m, "com.example.tool", material, BinaryOptions{})
+		require.NoError(t, err)
+		return size
+	}
+
+	size := estimate(pki.SigningMaterial{})
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, by, "estimating does not modify the binary")
+
+	// without timestamping the estimate is exactly the size of the signature written
+	require.NoError(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, BinaryOptions{}))
+	assert.Equal(t, size, signatureSize())
+
+	// an existing signature does not change the estimate
+	assert.Equal(t, size, estimate(pki.SigningMaterial{}))
+
+	// the timestamp token is accounted for without contacting the timestamp authority
+	stamped := pki.SigningMaterial{Timestamp: timestamp.Config{Servers: []string{"http://127.0.0.1:0"}}}
+	assert.Equal(t, size+MaxTimestampTokenSize, estimate(stamped))
+
+	// the signature fills the given size exactly
+	require.NoError(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, BinaryOptions{SignatureSize: size + MaxTimestampTokenSize}))
+	assert.Equal(t, size+MaxTimestampTokenSize, signatureSize())
+
+	require.Error(t, BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, BinaryOptions{SignatureSize: 0x100}))
+}
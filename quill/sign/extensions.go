@@ -0,0 +1,84 @@
+package sign
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/pki"
+)
+
+const (
+	// kextSigningOID is the certificate extension of Developer ID certificates allowed to sign kernel extensions.
+	kextSigningOID = "1.2.840.113635.100.6.1.18"
+
+	systemExtensionInstallEntitlement = "com.apple.developer.system-extension.install"
+)
+
+// systemExtensionEntitlements are the entitlements of which a system extension needs at least one to be loaded.
+var systemExtensionEntitlements = []string{
+	"com.apple.developer.endpoint-security.client",
+	"com.apple.developer.networking.networkextension",
+	"com.apple.developer.driverkit",
+}
+
+// extensionWarnings checks the stricter rules kernel and system extensions (and the apps installing them) are held
+// to, returning the issues which would prevent them from loading.
+func extensionWarnings(node *codeNode, ents entitlements.Entitlements, signingMaterial pki.SigningMaterial) []string {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	switch node.kind {
+	case KindKernelExtension:
+		switch {
+		case signingMaterial.Signer == nil:
+			warn("kernel extension %q is ad-hoc signed, kexts only load when signed with a Developer ID certificate", node.identifier)
+		case !hasExtension(signingMaterial.Leaf(), kextSigningOID):
+			warn("the signing certificate is not allowed to sign kernel extensions (request kext signing for your Developer ID certificate)")
+		}
+		if strings.HasPrefix(node.identifier, "com.apple.") {
+			warn("kernel extension %q uses an identifier reserved for Apple", node.identifier)
+		}
+	case KindSystemExtension:
+		if !hasAnyEntitlement(ents, systemExtensionEntitlements...) {
+			warn("system extension %q is signed without any system extension entitlement (e.g. %s), embed a provisioning profile granting one", node.identifier, systemExtensionEntitlements[0])
+		}
+	}
+
+	for _, child := range node.nested {
+		if child.kind != KindSystemExtension {
+			continue
+		}
+		if !strings.HasPrefix(child.identifier, node.identifier+".") {
+			warn("the identifier of system extension %q should be prefixed with the identifier of its app %q", child.identifier, node.identifier)
+		}
+		if !hasAnyEntitlement(ents, systemExtensionInstallEntitlement) {
+			warn("%q embeds system extension %q, but is signed without the %s entitlement", node.identifier, child.identifier, systemExtensionInstallEntitlement)
+		}
+	}
+	return warnings
+}
+
+func hasExtension(cert *x509.Certificate, oid string) bool {
+	if cert == nil {
+		return false
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() == oid {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyEntitlement(ents entitlements.Entitlements, keys ...string) bool {
+	for _, k := range keys {
+		if v, ok := ents[k]; ok && v != false {
+			return true
+		}
+	}
+	return false
+}
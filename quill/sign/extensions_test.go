@@ -0,0 +1,95 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/pki"
+)
+
+func Test_extensionWarnings(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	developerID := pki.SigningMaterial{Signer: key, Certs: []*x509.Certificate{{}}}
+	kextSigning := pki.SigningMaterial{Signer: key, Certs: []*x509.Certificate{{
+		Extensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 18}}},
+	}}}
+
+	sysext := &codeNode{kind: KindSystemExtension, identifier: "com.example.app.filter"}
+	misnamed := &codeNode{kind: KindSystemExtension, identifier: "com.other.filter"}
+
+	tests := []struct {
+		name            string
+		node            *codeNode
+		ents            entitlements.Entitlements
+		signingMaterial pki.SigningMaterial
+		want            []string
+	}{
+		{
+			name:            "kext signed with kext signing certificate",
+			node:            &codeNode{kind: KindKernelExtension, identifier: "com.example.driver"},
+			signingMaterial: kextSigning,
+		},
+		{
+			name:            "kext without kext signing certificate",
+			node:            &codeNode{kind: KindKernelExtension, identifier: "com.example.driver"},
+			signingMaterial: developerID,
+			want:            []string{"the signing certificate is not allowed to sign kernel extensions (request kext signing for your Developer ID certificate)"},
+		},
+		{
+			name: "ad-hoc kext with apple identifier",
+			node: &codeNode{kind: KindKernelExtension, identifier: "com.apple.driver"},
+			want: []string{
+				`kernel extension "com.apple.driver" is ad-hoc signed, kexts only load when signed with a Developer ID certificate`,
+				`kernel extension "com.apple.driver" uses an identifier reserved for Apple`,
+			},
+		},
+		{
+			name:            "system extension with entitlement",
+			node:            sysext,
+			ents:            entitlements.Entitlements{"com.apple.developer.endpoint-security.client": true},
+			signingMaterial: developerID,
+		},
+		{
+			name:            "system extension without entitlement",
+			node:            sysext,
+			signingMaterial: developerID,
+			want:            []string{`system extension "com.example.app.filter" is signed without any system extension entitlement (e.g. com.apple.developer.endpoint-security.client), embed a provisioning profile granting one`},
+		},
+		{
+			name:            "app installing system extension",
+			node:            &codeNode{kind: KindApp, identifier: "com.example.app", nested: []*codeNode{sysext}},
+			ents:            entitlements.Entitlements{"com.apple.developer.system-extension.install": true},
+			signingMaterial: developerID,
+		},
+		{
+			name:            "app installing misnamed system extension without entitlement",
+			node:            &codeNode{kind: KindApp, identifier: "com.example.app", nested: []*codeNode{misnamed}},
+			signingMaterial: developerID,
+			want: []string{
+				`the identifier of system extension "com.other.filter" should be prefixed with the identifier of its app "com.example.app"`,
+				`"com.example.app" embeds system extension "com.other.filter", but is signed without the com.apple.developer.system-extension.install entitlement`,
+			},
+		},
+		{
+			name:            "app without extensions",
+			node:            &codeNode{kind: KindApp, identifier: "com.example.app"},
+			signingMaterial: developerID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extensionWarnings(tt.node, tt.ents, tt.signingMaterial))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package sign
+
+import (
+	debugMacho "debug/macho"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// PrefixIdentifier prefixes the given (implicit) identifier, such as the file name of a command line tool, unless it
+// already is a reverse-DNS identifier (holding a dot). As with codesign --prefix, the prefix typically is a reverse-DNS
+// domain (e.g. "com.example."), the separating dot is added when missing.
+func PrefixIdentifier(prefix, id string) string {
+	if prefix == "" || strings.Contains(id, ".") {
+		return id
+	}
+	if !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return prefix + id
+}
+
+// InfoPlistIdentifier returns the CFBundleIdentifier of the Info.plist associated with the binary at the given path:
+// the Info.plist embedded into the binary (see macho.EmbeddedInfoPlist) or, when the binary is the main executable of
+// a bundle, the Info.plist of the bundle. An empty identifier is returned when there is no associated Info.plist.
+func InfoPlistIdentifier(path string) (string, error) {
+	raw, err := macho.EmbeddedInfoPlist(path)
+	if err != nil {
+		return "", err
+	}
+	if raw != nil {
+		doc, err := entitlements.ParsePlist(raw)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse the embedded Info.plist of %q: %w", path, err)
+		}
+		id, _ := doc["CFBundleIdentifier"].(string)
+		return id, nil
+	}
+
+	// the executable is either at the root of a (shallow) bundle or within the MacOS directory of a (deep) bundle
+	dir := filepath.Dir(path)
+	candidates := []string{filepath.Join(dir, "Info.plist")}
+	if filepath.Base(dir) == "MacOS" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(dir), "Info.plist"))
+	}
+	for _, c := range candidates {
+		// an Info.plist which does not describe a bundle is not associated with the binary
+		info, err := readBundleInfo(c)
+		if err == nil && info.executable == filepath.Base(path) {
+			return info.identifier, nil
+		}
+	}
+	return "", nil
+}
+
+// IsIdentityTemplate indicates if the given identity holds placeholders to expand for every signed artifact (see
+// ExpandIdentity).
+func IsIdentityTemplate(id string) bool {
+	return strings.Contains(id, "{{")
+}
+
+// IdentityFields are the values available to identity templates (see ExpandIdentity).
+type IdentityFields struct {
+	// Basename is the file name of the artifact being signed (e.g. "mytool"), without the extension for bundles (e.g.
+	// "MyApp" for "MyApp.app").
+	Basename string
+	// BundleID is the CFBundleIdentifier of the Info.plist associated with the artifact (see InfoPlistIdentifier).
+	BundleID string
+	// Arch is the architecture of the binary (e.g. "arm64"), each slice of a universal binary is expanded separately.
+	Arch string
+}
+
+// identityData exposes the identity fields to templates, failing on placeholders without a value.
+type identityData struct {
+	fields IdentityFields
+}
+
+func (d identityData) Basename() string {
+	return d.fields.Basename
+}
+
+func (d identityData) BundleID() (string, error) {
+	if d.fields.BundleID == "" {
+		return "", fmt.Errorf("there is no Info.plist with a CFBundleIdentifier for %q", d.fields.Basename)
+	}
+	return d.fields.BundleID, nil
+}
+
+func (d identityData) Arch() (string, error) {
+	if d.fields.Arch == "" {
+		return "", fmt.Errorf("%q is not a binary with an architecture", d.fields.Basename)
+	}
+	return d.fields.Arch, nil
+}
+
+// ParseIdentityTemplate checks that the given identity template is well-formed and only uses the known placeholders
+// ({{ .Basename }}, {{ .BundleID }}, and {{ .Arch }}).
+func ParseIdentityTemplate(id string) error {
+	_, err := ExpandIdentity(id, IdentityFields{Basename: "basename", BundleID: "bundle.id", Arch: "arch"})
+	return err
+}
+
+// ExpandIdentity expands the placeholders of the given identity template (Go template syntax) for a single artifact,
+// e.g. "com.example.{{ .Basename }}-{{ .Arch }}". Using a placeholder without a value for the artifact (such as
+// {{ .BundleID }} for a binary without an Info.plist) is an error, as is a template expanding to an empty identity.
+func ExpandIdentity(id string, fields IdentityFields) (string, error) {
+	tmpl, err := template.New("identity").Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid identity template %q: %w", id, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, identityData{fields: fields}); err != nil {
+		return "", fmt.Errorf("unable to expand identity template %q: %w", id, err)
+	}
+
+	expanded := strings.TrimSpace(sb.String())
+	if expanded == "" {
+		return "", fmt.Errorf("identity template %q expands to an empty identity for %q", id, fields.Basename)
+	}
+	return expanded, nil
+}
+
+// BinaryArch returns the architecture name of the single-arch binary at the given path (e.g. "arm64" or "x86_64").
+func BinaryArch(path string) (string, error) {
+	m, err := macho.NewReadOnlyFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer m.Close()
+	return archName(m.Cpu), nil
+}
+
+// archName returns the architecture name of the given CPU (as used by Apple tools).
+func archName(cpu debugMacho.Cpu) string {
+	switch cpu { //nolint:exhaustive
+	case debugMacho.CpuAmd64:
+		return "x86_64"
+	case debugMacho.Cpu386:
+		return "i386"
+	case debugMacho.CpuArm64:
+		return "arm64"
+	case debugMacho.CpuArm:
+		return "arm"
+	}
+	return strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+}
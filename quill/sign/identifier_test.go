@@ -0,0 +1,137 @@
+package sign
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixIdentifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		id     string
+		want   string
+	}{
+		{
+			name:   "bare identifier",
+			prefix: "com.example.",
+			id:     "mytool",
+			want:   "com.example.mytool",
+		},
+		{
+			name:   "missing separator",
+			prefix: "com.example",
+			id:     "mytool",
+			want:   "com.example.mytool",
+		},
+		{
+			name:   "reverse-DNS identifier",
+			prefix: "com.example.",
+			id:     "org.other.mytool",
+			want:   "org.other.mytool",
+		},
+		{
+			name: "no prefix",
+			id:   "mytool",
+			want: "mytool",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PrefixIdentifier(tt.prefix, tt.id))
+		})
+	}
+}
+
+func TestExpandIdentity(t *testing.T) {
+	fields := IdentityFields{Basename: "mytool", Arch: "arm64"}
+
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "basename and arch",
+			id:   "com.example.{{ .Basename }}-{{ .Arch }}",
+			want: "com.example.mytool-arm64",
+		},
+		{
+			name: "no placeholders",
+			id:   "com.example.tool",
+			want: "com.example.tool",
+		},
+		{
+			name:    "no bundle identifier",
+			id:      "{{ .BundleID }}",
+			wantErr: "no Info.plist with a CFBundleIdentifier",
+		},
+		{
+			name:    "unknown placeholder",
+			id:      "{{ .Version }}",
+			wantErr: "can't evaluate field Version",
+		},
+		{
+			name:    "malformed template",
+			id:      "{{ .Basename",
+			wantErr: "invalid identity template",
+		},
+		{
+			name:    "empty expansion",
+			id:      "{{ if false }}x{{ end }}",
+			wantErr: "expands to an empty identity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandIdentity(tt.id, fields)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.NoError(t, ParseIdentityTemplate("{{ .BundleID }}.{{ .Arch }}"))
+	assert.Error(t, ParseIdentityTemplate("{{ .Version }}"))
+}
+
+func TestInfoPlistIdentifier(t *testing.T) {
+	bin := machoHeader()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"My.app/Contents/Info.plist":   infoPlist("com.example.my", "My"),
+		"My.app/Contents/MacOS/My":     bin,
+		"My.app/Contents/MacOS/helper": bin,
+		"Shallow.app/Info.plist":       infoPlist("com.example.shallow", "Shallow"),
+		"Shallow.app/Shallow":          bin,
+		"tools/mytool":                 bin,
+		"other/Info.plist":             "not a plist",
+		"other/tool":                   bin,
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "My.app/Contents/MacOS/My", want: "com.example.my"},
+		{path: "My.app/Contents/MacOS/helper"},
+		{path: "Shallow.app/Shallow", want: "com.example.shallow"},
+		{path: "tools/mytool"},
+		{path: "other/tool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := InfoPlistIdentifier(filepath.Join(dir, filepath.FromSlash(tt.path)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package sign
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/pki"
+)
+
+// ipaPayloadDir is the directory of an iOS app archive holding the app bundle.
+const ipaPayloadDir = "Payload"
+
+// IsIPA indicates if the given path is an iOS app archive (a zip archive with the .ipa extension).
+func IsIPA(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".ipa") {
+		return false
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	r.Close()
+	return true
+}
+
+// IPA signs the iOS app archive (.ipa) at the given path in place: the archive is extracted, the app bundle within
+// the Payload directory is signed (see Bundle), and the archive is rebuilt.
+//
+//nolint:funlen
+func IPA(path string, signingMaterial pki.SigningMaterial, opts BundleOptions) (*BundleReport, error) {
+	dir, err := os.MkdirTemp("", "quill-ipa-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temp directory to extract app archive: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractZip(path, dir); err != nil {
+		return nil, fmt.Errorf("unable to extract app archive: %w", err)
+	}
+
+	apps, err := filepath.Glob(filepath.Join(dir, ipaPayloadDir, "*.app"))
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) != 1 {
+		return nil, fmt.Errorf("expected a single app bundle within the %s directory of the app archive, found %d", ipaPayloadDir, len(apps))
+	}
+
+	report, err := Bundle(apps[0], signingMaterial, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.PatchStarted, Path: path})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// write the new archive next to the original, so it can be swapped in place
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signed app archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeZip(tmp, dir); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("unable to rebuild app archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("unable to replace app archive: %w", err)
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.SignFinished, Path: path})
+	return report, nil
+}
+
+func extractZip(path, dir string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !within(dir, target) {
+			return fmt.Errorf("invalid archive entry %q", f.Name)
+		}
+
+		if err := extractZipEntry(dir, f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// within indicates if the given path is within the given directory (refusing archive entries escaping the
+// extraction directory).
+func within(dir, path string) bool {
+	return strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator))
+}
+
+func extractZipEntry(dir string, f *zip.File, target string) error {
+	mode := f.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("unable to read archive entry %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if mode&fs.ModeSymlink != 0 {
+		link, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		if filepath.IsAbs(string(link)) || !within(dir, filepath.Join(filepath.Dir(target), string(link))) {
+			return fmt.Errorf("invalid symlink archive entry %q", f.Name)
+		}
+		return os.Symlink(string(link), target)
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		// archives written without unix attributes
+		perm = 0644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil { //nolint:gosec // the archive is the input being signed
+		out.Close()
+		return fmt.Errorf("unable to extract archive entry %q: %w", f.Name, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, f.Modified, f.Modified)
+}
+
+func writeZip(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		switch {
+		case d.IsDir():
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entry, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = entry.Write([]byte(link))
+			return err
+		case !d.Type().IsRegular():
+			log.WithFields("path", header.Name).Warn("skipping irregular file within app archive")
+			return nil
+		}
+
+		header.Method = zip.Deflate
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(entry, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
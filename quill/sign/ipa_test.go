@@ -0,0 +1,119 @@
+package sign
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchive(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, contents := range entries {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+func TestIsIPA(t *testing.T) {
+	dir := t.TempDir()
+
+	ipa := filepath.Join(dir, "My.ipa")
+	writeArchive(t, ipa, map[string]string{"Payload/My.app/Info.plist": "info"})
+
+	notZip := filepath.Join(dir, "fake.ipa")
+	require.NoError(t, os.WriteFile(notZip, []byte("not a zip"), 0600))
+
+	zipExt := filepath.Join(dir, "My.zip")
+	writeArchive(t, zipExt, map[string]string{"Payload/My.app/Info.plist": "info"})
+
+	assert.True(t, IsIPA(ipa))
+	assert.False(t, IsIPA(notZip))
+	assert.False(t, IsIPA(zipExt))
+	assert.False(t, IsIPA(filepath.Join(dir, "missing.ipa")))
+}
+
+func Test_extractZip(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "extract app archive",
+			entries: map[string]string{
+				"Payload/My.app/Info.plist": "info",
+				"Payload/My.app/My":         "executable",
+			},
+		},
+		{
+			name:    "reject entry escaping the extraction directory",
+			entries: map[string]string{"../escaped": "contents"},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			path := filepath.Join(t.TempDir(), "My.ipa")
+			writeArchive(t, path, tt.entries)
+
+			dir := t.TempDir()
+			err := extractZip(path, dir)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			for name, contents := range tt.entries {
+				got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				require.NoError(t, err)
+				assert.Equal(t, contents, string(got))
+			}
+		})
+	}
+}
+
+func Test_writeZip_roundTrip(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"Payload/My.app/Info.plist":   "info",
+		"Payload/My.app/My":           "executable",
+		"Payload/My.app/en.lproj/a.s": "strings",
+	})
+	require.NoError(t, os.Symlink("My", filepath.Join(src, "Payload", "My.app", "link")))
+
+	path := filepath.Join(t.TempDir(), "My.ipa")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, writeZip(f, src))
+	require.NoError(t, f.Close())
+
+	dst := t.TempDir()
+	require.NoError(t, extractZip(path, dst))
+
+	for _, name := range []string{"Payload/My.app/Info.plist", "Payload/My.app/My", "Payload/My.app/en.lproj/a.s"} {
+		want, err := os.ReadFile(filepath.Join(src, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, "Payload", "My.app", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "My", link)
+}
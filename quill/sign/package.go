@@ -0,0 +1,85 @@
+package sign
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/metrics"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/xar"
+)
+
+// Package signs the flat installer package (xar archive) at the given path in place, replacing any existing
+// signature. Installer packages must be signed with a Developer ID Installer certificate and an RSA key, there is no
+// ad-hoc signature for installer packages.
+func Package(path string, signingMaterial pki.SigningMaterial) (err error) {
+	start := time.Now()
+	defer func() {
+		result := metrics.Result(err)
+		metrics.ObserveSince(metrics.SignDuration, start, metrics.Labels{"result": result})
+		metrics.Count(metrics.Signatures, 1, metrics.Labels{"result": result, "adhoc": "false"})
+	}()
+
+	if signingMaterial.Signer == nil {
+		return fmt.Errorf("installer packages cannot be ad-hoc signed (a Developer ID Installer certificate is required)")
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.ParseStarted, Path: path})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	archive, err := xar.Parse(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse installer package: %w", err)
+	}
+
+	if archive.Signed() {
+		log.Debug("package already signed, replacing signature...")
+	}
+
+	// the signing certificate comes first within the signature, followed by the remaining chain (the signing material
+	// is ordered from the root to the leaf)
+	embedded := signingMaterial.EmbeddedCerts()
+	certs := make([]*x509.Certificate, 0, len(embedded))
+	for i := len(embedded) - 1; i >= 0; i-- {
+		certs = append(certs, embedded[i])
+	}
+
+	log.Debug("creating signature for package")
+	err = archive.Sign(xar.SignOptions{
+		Key:          signingMaterial.Signer,
+		Certificates: certs,
+		CMS: func(checksum []byte) ([]byte, error) {
+			return signDetached(checksum, signingMaterial)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to sign installer package: %w", err)
+	}
+
+	out, err := archive.Bytes()
+	if err != nil {
+		return err
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.PatchStarted, Path: path})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, info.Mode()); err != nil {
+		return fmt.Errorf("unable to write signed package: %w", err)
+	}
+
+	lifecycle.Publish(lifecycle.Event{Type: lifecycle.SignFinished, Path: path})
+
+	return nil
+}
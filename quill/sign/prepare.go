@@ -0,0 +1,57 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// Prepare reserves signatureSize zeroed bytes for the code signature of every architecture of the unsigned
+// (single-arch or universal) binary at the given path without signing it: the LC_CODE_SIGNATURE load command is added
+// and __LINKEDIT is grown to end with the reserved space (see EstimateSignatureSize for the size to reserve). Signing
+// the prepared binary keeps the reserved size, so a signature made elsewhere for a copy of the prepared binary can be
+// dropped into the reserved space (see Attach) without moving any other content. The space of a binary prepared
+// already is reserved again with the given size.
+func Prepare(path string, signatureSize int) error {
+	if signatureSize <= 0 || uint64(signatureSize) > uint64(^uint32(0)) {
+		return fmt.Errorf("invalid signature size %d", signatureSize)
+	}
+
+	_, err := forEachArch(path, func(archPath string) error {
+		return prepareArch(archPath, uint32(signatureSize))
+	}, true)
+	return err
+}
+
+// prepareArch reserves the space for the code signature of the single-arch binary at the given path.
+func prepareArch(path string, signatureSize uint32) error {
+	m, err := macho.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	reserved, err := m.HasReservedCodeSignature()
+	if err != nil {
+		return err
+	}
+	if m.HasCodeSigningCmd() && !reserved {
+		return fmt.Errorf("the binary is already signed (%s), only unsigned binaries can be prepared", m.Cpu)
+	}
+
+	if err := prepareBinary(m, BinaryOptions{}); err != nil {
+		return err
+	}
+
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return err
+	}
+
+	log.WithFields("binary", path, "bytes", signatureSize).Debug("reserving code signature space")
+	if err := m.ReserveCodeSignature(cmd.DataOffset, signatureSize); err != nil {
+		return err
+	}
+	return m.WriteCodeSignature(nil)
+}
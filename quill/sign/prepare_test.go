@@ -0,0 +1,68 @@
+package sign
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+func TestPrepare(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tool")
+	writeUnsignedBinary(t, path)
+
+	codeSigningCmd := func(path string) (macho.CodeSigningCommand, bool) {
+		m, err := macho.NewReadOnlyFile(path)
+		require.NoError(t, err)
+		defer m.Close()
+		cmd, _, err := m.CodeSigningCmd()
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+		reserved, err := m.HasReservedCodeSignature()
+		require.NoError(t, err)
+		require.NoError(t, m.CheckLayout())
+		return *cmd, reserved
+	}
+
+	require.Error(t, Prepare(path, 0))
+
+	// the space follows __LINKEDIT, preparing again changes the reserved size
+	require.NoError(t, Prepare(path, 0x8000))
+	require.NoError(t, Prepare(path, 0x9000))
+	cmd, reserved := codeSigningCmd(path)
+	assert.True(t, reserved)
+	assert.Equal(t, uint32(0x2100), cmd.DataOffset)
+	assert.Equal(t, uint32(0x9000), cmd.DataSize)
+
+	prepared, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, prepared, 0xb100)
+
+	// signing a copy of the prepared binary keeps the reserved size
+	signedPath := filepath.Join(dir, "tool-signed")
+	require.NoError(t, os.WriteFile(signedPath, prepared, 0755))
+	require.NoError(t, Binary(signedPath, "com.example.tool", pki.SigningMaterial{}))
+	cmd, reserved = codeSigningCmd(signedPath)
+	assert.False(t, reserved)
+	assert.Equal(t, uint32(0x2100), cmd.DataOffset)
+	assert.Equal(t, uint32(0x9000), cmd.DataSize)
+
+	require.Error(t, Prepare(signedPath, 0x9000), "signed binaries cannot be prepared")
+
+	// the signature drops into the reserved space of the prepared binary
+	signature, err := Detach(signedPath)
+	require.NoError(t, err)
+	require.NoError(t, Attach(path, signature))
+
+	attached, err := os.ReadFile(path)
+	require.NoError(t, err)
+	signed, err := os.ReadFile(signedPath)
+	require.NoError(t, err)
+	assert.Equal(t, signed, attached)
+}
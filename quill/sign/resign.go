@@ -0,0 +1,239 @@
+package sign
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+)
+
+// externalSlots are the special slots whose content lives outside of the signature (so only their hashes can be
+// carried over).
+var externalSlots = []macho.SlotType{macho.CsSlotInfoslot, macho.CsSlotResourcedir}
+
+// ExistingSignature is what the existing signature of a binary binds, all of which is carried over by Resign unless
+// changed by a Mutation.
+type ExistingSignature struct {
+	Identifier string
+	// TeamID is empty when the code directory has no team identifier. It is only carried over when the new signing
+	// certificate is of the same team (the team follows the new signing material otherwise).
+	TeamID string
+	// Flags are the code directory flags (the ad-hoc and linker-signed flags follow the new signing material).
+	Flags macho.CdFlag
+	// CodeDirectoryVersion is the format version of the (primary) code directory.
+	CodeDirectoryVersion macho.CdVersion
+	// RuntimeVersion is the hardened runtime version (zero when the code directory predates it).
+	RuntimeVersion macho.Version
+	// Entitlements are the decoded XML entitlements (re-encoded in both the XML and DER form when re-signing).
+	Entitlements entitlements.Entitlements
+	// Requirements is the entire requirements set blob (the designated requirement is generated when nil).
+	Requirements []byte
+	// ExternalSlotHashes are the hashes (by hash type) of the bound special slots whose content lives outside of the
+	// signature: the Info.plist and sealed resources of a bundle.
+	ExternalSlotHashes map[macho.HashType]map[macho.SlotType][]byte
+}
+
+// Mutation changes an attribute of the existing signature before the binary is re-signed (see Resign).
+type Mutation func(*ExistingSignature) error
+
+// SetIdentifier changes the identifier. The designated requirement names the identifier, so the requirements are
+// generated again for the signing material.
+func SetIdentifier(id string) Mutation {
+	return func(s *ExistingSignature) error {
+		if id == "" {
+			return fmt.Errorf("the identifier cannot be empty")
+		}
+		s.Identifier = id
+		s.Requirements = nil
+		return nil
+	}
+}
+
+// SetEntitlements replaces the entitlements (nil removes them).
+func SetEntitlements(ents entitlements.Entitlements) Mutation {
+	return func(s *ExistingSignature) error {
+		s.Entitlements = ents
+		return nil
+	}
+}
+
+// AddFlags sets the given code directory flags (e.g. macho.Runtime).
+func AddFlags(flags macho.CdFlag) Mutation {
+	return func(s *ExistingSignature) error {
+		s.Flags |= flags
+		return nil
+	}
+}
+
+// ClearFlags clears the given code directory flags.
+func ClearFlags(flags macho.CdFlag) Mutation {
+	return func(s *ExistingSignature) error {
+		s.Flags &^= flags
+		return nil
+	}
+}
+
+// ReadExistingSignature reads what the existing signature of the single-arch binary at the given path binds.
+func ReadExistingSignature(path string) (*ExistingSignature, error) {
+	m, err := macho.NewReadOnlyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	return readExistingSignature(m)
+}
+
+func readExistingSignature(m *macho.File) (*ExistingSignature, error) {
+	if !m.HasCodeSigningCmd() {
+		return nil, fmt.Errorf("binary is not signed")
+	}
+
+	sig := ExistingSignature{
+		ExternalSlotHashes: map[macho.HashType]map[macho.SlotType][]byte{},
+	}
+
+	// the primary code directory comes first, followed by the alternate code directories (of other hash types)
+	for i := 0; ; i++ {
+		cdBytes, err := m.CDBytes(macho.SigningOrder, i)
+		if errors.Is(err, macho.ErrNoCodeDirectory) {
+			if i == 0 {
+				return nil, err
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cd, err := macho.ParseCodeDirectory(cdBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse code directory %d: %w", i, err)
+		}
+
+		if i == 0 {
+			if err := sig.setCodeDirectory(cd); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, slot := range externalSlots {
+			h, err := cd.SpecialSlotHash(slot)
+			if err != nil {
+				return nil, err
+			}
+			if h == nil || bytes.Equal(h, make([]byte, len(h))) {
+				continue
+			}
+			if sig.ExternalSlotHashes[cd.HashType] == nil {
+				sig.ExternalSlotHashes[cd.HashType] = map[macho.SlotType][]byte{}
+			}
+			sig.ExternalSlotHashes[cd.HashType][slot] = h
+		}
+	}
+
+	var err error
+	if sig.Requirements, err = m.SlotBytes(macho.CsSlotRequirements); err != nil {
+		return nil, fmt.Errorf("unable to read the requirements: %w", err)
+	}
+
+	entsBlob, err := m.SlotBytes(macho.CsSlotEntitlements)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the entitlements: %w", err)
+	}
+	if entsBlob != nil {
+		entsXML, err := macho.UnwrapBlob(entsBlob, macho.MagicEmbeddedEntitlements)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the entitlements: %w", err)
+		}
+		if sig.Entitlements, err = entitlements.ParseXML(entsXML); err != nil {
+			return nil, fmt.Errorf("unable to decode the entitlements: %w", err)
+		}
+	}
+
+	return &sig, nil
+}
+
+// setCodeDirectory carries over the fields of the primary code directory.
+func (s *ExistingSignature) setCodeDirectory(cd *macho.CodeDirectory) error {
+	var err error
+	if s.Identifier, err = cd.Identifier(); err != nil {
+		return fmt.Errorf("unable to read the identifier: %w", err)
+	}
+	if s.TeamID, err = cd.TeamID(); err != nil {
+		return fmt.Errorf("unable to read the team identifier: %w", err)
+	}
+	s.Flags = cd.Flags
+	s.CodeDirectoryVersion = cd.Version
+	s.RuntimeVersion = macho.Version(cd.Runtime)
+	return nil
+}
+
+// binaryOptions returns the given options with every attribute of the existing signature carried over.
+func (s ExistingSignature) binaryOptions(path string, signingMaterial pki.SigningMaterial, opts BinaryOptions) BinaryOptions {
+	flags := s.Flags &^ (macho.Adhoc | macho.LinkerSigned)
+	if signingMaterial.Signer == nil {
+		flags |= macho.Adhoc
+	}
+	opts.Flags = &flags
+	// the team of the code directory must be the team of the signing certificate (there is none for ad-hoc signatures)
+	opts.TeamID = teamID(signingMaterial, "")
+	opts.Entitlements = s.Entitlements
+	opts.Requirements = s.Requirements
+	opts.RuntimeVersion = s.RuntimeVersion
+	// the signature is regenerated, there is no linker signature left to preserve
+	opts.PreserveLinkerSignature = false
+
+	if codeDirectoryHeaderSize(s.CodeDirectoryVersion) != 0 {
+		opts.CodeDirectoryVersion = s.CodeDirectoryVersion
+	} else {
+		log.WithFields("binary", path, "version", fmt.Sprintf("0x%x", uint32(s.CodeDirectoryVersion))).Debug("unable to write the code directory version of the existing signature, using the default version")
+	}
+
+	// both the SHA-256 and SHA-1 code directories must bind the same special slots
+	sha256Hashes, sha1Hashes := s.ExternalSlotHashes[macho.HashTypeSha256], s.ExternalSlotHashes[macho.HashTypeSha1]
+	switch {
+	case len(sha256Hashes) == 0 && len(sha1Hashes) == 0:
+	case sameSlots(sha256Hashes, sha1Hashes):
+		opts.SpecialSlotHashes = s.ExternalSlotHashes
+	case opts.InfoPlist == nil && opts.CodeResources == nil:
+		log.WithFields("binary", path).Warn("the existing signature binds bundle details (Info.plist or sealed resources) that cannot be carried over, re-sign the bundle to bind them again")
+	}
+	return opts
+}
+
+func sameSlots(a, b map[macho.SlotType][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for slot := range a {
+		if _, ok := b[slot]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Resign signs the signed single-arch binary at the given path again, carrying over every attribute of the existing
+// signature (see ExistingSignature) after applying the given mutations. Only the CMS signature is made with the given
+// signing material, e.g. to flip a single flag without touching anything else.
+func Resign(path string, signingMaterial pki.SigningMaterial, opts BinaryOptions, mutations ...Mutation) error {
+	sig, err := ReadExistingSignature(path)
+	if err != nil {
+		return fmt.Errorf("unable to read the existing signature: %w", err)
+	}
+
+	for _, mutate := range mutations {
+		if err := mutate(sig); err != nil {
+			return err
+		}
+	}
+
+	log.WithFields("binary", path, "identifier", sig.Identifier, "mutations", len(mutations)).Info("re-signing binary, carrying over the existing signature")
+
+	return BinaryWithOptions(path, sig.Identifier, signingMaterial, sig.binaryOptions(path, signingMaterial, opts))
+}
@@ -0,0 +1,222 @@
+package sign
+
+import (
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestParseCodeDirectory(t *testing.T) {
+	infoPlistHash := hashBytes(sha256.New(), []byte("info plist"))
+	requirementsHash := hashBytes(sha256.New(), []byte("requirements"))
+
+	for _, version := range CodeDirectoryVersions {
+		t.Run(fmt.Sprintf("0x%x", uint32(version)), func(t *testing.T) {
+			hasher := sha256.New()
+			cd, err := newCodeDirectory("com.example.tool", hasher, 0, 0x4000, 0x8000, [][]byte{make([]byte, hasher.Size())}, codeDirectoryOptions{
+				version:        version,
+				teamID:         "TEAMID1234",
+				flags:          macho.Runtime | macho.RequireLv,
+				runtimeVersion: 0x000d0000,
+				specialSlots:   [][]byte{infoPlistHash, requirementsHash},
+			})
+			require.NoError(t, err)
+			blob, err := cd.Blob()
+			require.NoError(t, err)
+			blobBytes, err := blob.Pack()
+			require.NoError(t, err)
+
+			parsed, err := macho.ParseCodeDirectory(blobBytes)
+			require.NoError(t, err)
+
+			var sig ExistingSignature
+			require.NoError(t, sig.setCodeDirectory(parsed))
+			assert.Equal(t, "com.example.tool", sig.Identifier)
+			assert.Equal(t, "TEAMID1234", sig.TeamID)
+			assert.Equal(t, macho.Runtime|macho.RequireLv, sig.Flags)
+			assert.Equal(t, version, sig.CodeDirectoryVersion)
+			if version >= macho.SupportsRuntime {
+				assert.Equal(t, macho.Version(0x000d0000), sig.RuntimeVersion)
+			} else {
+				assert.Zero(t, sig.RuntimeVersion)
+			}
+
+			h, err := parsed.SpecialSlotHash(macho.CsSlotInfoslot)
+			require.NoError(t, err)
+			assert.Equal(t, infoPlistHash, h)
+
+			h, err = parsed.SpecialSlotHash(macho.CsSlotRequirements)
+			require.NoError(t, err)
+			assert.Equal(t, requirementsHash, h)
+
+			h, err = parsed.SpecialSlotHash(macho.CsSlotResourcedir)
+			require.NoError(t, err)
+			assert.Nil(t, h)
+		})
+	}
+
+	_, err := macho.ParseCodeDirectory([]byte{0xfa, 0xde, 0x0c, 0x02, 0, 0, 0, 12, 0, 2, 4, 0})
+	require.Error(t, err)
+}
+
+func TestExistingSignature_mutations(t *testing.T) {
+	sig := ExistingSignature{
+		Identifier:   "com.example.tool",
+		Flags:        macho.Runtime,
+		Entitlements: entitlements.Entitlements{"com.apple.security.get-task-allow": true},
+		Requirements: []byte("existing requirements"),
+	}
+
+	for _, mutate := range []Mutation{
+		AddFlags(macho.RequireLv | macho.Kill),
+		ClearFlags(macho.Runtime),
+		SetEntitlements(nil),
+		SetIdentifier("com.example.renamed"),
+	} {
+		require.NoError(t, mutate(&sig))
+	}
+
+	assert.Equal(t, ExistingSignature{
+		Identifier: "com.example.renamed",
+		Flags:      macho.RequireLv | macho.Kill,
+	}, sig)
+
+	require.Error(t, SetIdentifier("")(&sig))
+}
+
+func TestExistingSignature_binaryOptions(t *testing.T) {
+	sha256Hashes := map[macho.SlotType][]byte{macho.CsSlotInfoslot: hashBytes(sha256.New(), []byte("info plist"))}
+	sha1Hashes := map[macho.SlotType][]byte{macho.CsSlotInfoslot: hashBytes(sha1.New(), []byte("info plist"))}
+
+	sig := ExistingSignature{
+		Identifier:           "com.example.tool",
+		TeamID:               "TEAMID1234",
+		Flags:                macho.Runtime | macho.LinkerSigned | macho.Adhoc,
+		CodeDirectoryVersion: macho.SupportsExecseg,
+		RuntimeVersion:       0x000d0000,
+		Requirements:         []byte("existing requirements"),
+		ExternalSlotHashes: map[macho.HashType]map[macho.SlotType][]byte{
+			macho.HashTypeSha256: sha256Hashes,
+			macho.HashTypeSha1:   sha1Hashes,
+		},
+	}
+
+	opts := sig.binaryOptions("tool", pki.SigningMaterial{}, BinaryOptions{PreserveLinkerSignature: true, LibraryValidation: true})
+	require.NotNil(t, opts.Flags)
+	assert.Equal(t, macho.Runtime|macho.Adhoc, *opts.Flags)
+	assert.Empty(t, opts.TeamID, "an ad-hoc signature has no team")
+	assert.Equal(t, macho.SupportsExecseg, opts.CodeDirectoryVersion)
+	assert.Equal(t, macho.Version(0x000d0000), opts.RuntimeVersion)
+	assert.Equal(t, []byte("existing requirements"), opts.Requirements)
+	assert.Equal(t, sig.ExternalSlotHashes, opts.SpecialSlotHashes)
+	assert.False(t, opts.PreserveLinkerSignature)
+	assert.True(t, opts.LibraryValidation)
+
+	// hashes which are not available for every hash type cannot be carried over
+	delete(sig.ExternalSlotHashes, macho.HashTypeSha1)
+	opts = sig.binaryOptions("tool", pki.SigningMaterial{}, BinaryOptions{})
+	assert.Nil(t, opts.SpecialSlotHashes)
+}
+
+func TestExistingSignature_binaryOptions_teamID(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+	otherLeaf, otherKey, err := fixture.IssueLeaf("Other", "OTHERTEAM1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		signingMaterial pki.SigningMaterial
+		want            string
+	}{
+		{
+			name:            "same team",
+			signingMaterial: *pki.NewSigningMaterial(fixture.LeafKey, fixture.Chain()),
+			want:            testca.DefaultTeamID,
+		},
+		{
+			name:            "another team",
+			signingMaterial: *pki.NewSigningMaterial(otherKey, append([]*x509.Certificate{otherLeaf}, fixture.Chain()[1:]...)),
+			want:            "OTHERTEAM1",
+		},
+		{
+			name: "ad-hoc",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := ExistingSignature{Identifier: "com.example.tool", TeamID: testca.DefaultTeamID, Flags: macho.Runtime}
+			opts := sig.binaryOptions("tool", tt.signingMaterial, BinaryOptions{})
+			assert.Equal(t, tt.want, opts.TeamID)
+		})
+	}
+}
+
+func TestResign_teamID(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+	otherLeaf, otherKey, err := fixture.IssueLeaf("Other", "OTHERTEAM1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		signingMaterial pki.SigningMaterial
+		want            string
+	}{
+		{
+			name:            "material of another team",
+			signingMaterial: *pki.NewSigningMaterial(otherKey, append([]*x509.Certificate{otherLeaf}, fixture.Chain()[1:]...)),
+			want:            "OTHERTEAM1",
+		},
+		{
+			name: "ad-hoc material",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool")
+			writeUnsignedBinary(t, path)
+			require.NoError(t, Binary(path, "com.example.tool", *pki.NewSigningMaterial(fixture.LeafKey, fixture.Chain())))
+
+			sig, err := ReadExistingSignature(path)
+			require.NoError(t, err)
+			require.Equal(t, testca.DefaultTeamID, sig.TeamID)
+
+			require.NoError(t, Resign(path, tt.signingMaterial, BinaryOptions{}))
+
+			sig, err = ReadExistingSignature(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sig.TeamID)
+			assert.Equal(t, tt.signingMaterial.Signer == nil, sig.Flags&macho.Adhoc != 0)
+		})
+	}
+}
+
+func Test_specialSlotHashes_precomputed(t *testing.T) {
+	precomputed := hashBytes(sha256.New(), []byte("resources"))
+
+	hashes := specialSlotHashes(sha256.New, map[macho.SlotType][]byte{
+		macho.CsSlotRequirements: []byte("requirements"),
+	}, map[macho.SlotType][]byte{
+		macho.CsSlotRequirements: hashBytes(sha256.New(), []byte("stale")),
+		macho.CsSlotResourcedir:  precomputed,
+	})
+
+	require.Len(t, hashes, 3)
+	assert.Equal(t, make([]byte, sha256.Size), hashes[0])
+	assert.Equal(t, hashBytes(sha256.New(), []byte("requirements")), hashes[1])
+	assert.Equal(t, precomputed, hashes[2])
+}
@@ -1,41 +1,175 @@
 package sign
 
 import (
-	"bytes"
+	"crypto/sha1" //nolint: gosec
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
+	"unsafe"
 
 	"github.com/go-restruct/restruct"
 
+	"github.com/anchore/quill/quill/entitlements"
 	"github.com/anchore/quill/quill/macho"
 	"github.com/anchore/quill/quill/pki"
 )
 
+// BinaryOptions are the (optional) bundle details bound to the signature of a binary, as is done for the main
+// executable of an app bundle.
+type BinaryOptions struct {
+	// InfoPlist is the raw Info.plist of the bundle (hashed into the Info.plist special slot).
+	InfoPlist []byte
+	// CodeResources is the raw _CodeSignature/CodeResources file sealing the bundle resources (hashed into the
+	// resource directory special slot).
+	CodeResources []byte
+	// Entitlements are embedded in both the XML and DER form.
+	Entitlements entitlements.Entitlements
+	// TeamID is written into the code directory (this is how the OS compares the team of loaded code). When empty, the
+	// team of the signing certificate (its OU, as for Developer ID certificates) is used, as codesign does.
+	TeamID string
+	// CodeDirectoryVersion is the code directory format version to write (DefaultCodeDirectoryVersion when zero),
+	// older versions are understood by older verifiers but lack e.g. the hardened runtime version.
+	CodeDirectoryVersion macho.CdVersion
+	// RuntimeVersion is the SDK version the hardened runtime behavior is based on, which notarization checks (the SDK
+	// version recorded by the binary's LC_BUILD_VERSION load command when zero).
+	RuntimeVersion macho.Version
+	// KernelExtension indicates the binary is the executable of a kext. Kexts are loaded by the kernel, which has no
+	// notion of the hardened runtime, so the runtime flag is not set.
+	KernelExtension bool
+	// PreserveLinkerSignature keeps the ad-hoc signature generated by the linker when ad-hoc signing a binary carrying
+	// one (unless bundle details are bound to the signature), by default the linker signature is replaced.
+	PreserveLinkerSignature bool
+	// LibraryValidation requires every library loaded by the binary to be signed by Apple or by the same team
+	// (the library validation code directory flag).
+	LibraryValidation bool
+	// Requirements is a compiled requirements set (the entire blob, e.g. as written by csreq -b) embedded instead of the
+	// generated designated requirement.
+	Requirements []byte
+	// Flags are the code directory flags to write instead of the flags derived from the signing material (e.g. to
+	// carry over the flags of an existing signature).
+	Flags *macho.CdFlag
+	// SpecialSlotHashes are the hashes (by hash type) of special slots whose content is not at hand (e.g. carried over
+	// from an existing signature), content given by the other options takes precedence.
+	SpecialSlotHashes map[macho.HashType]map[macho.SlotType][]byte
+	// LoadCommandEdits are applied to the binary (e.g. adding a run path or changing the install name of a library)
+	// after removing any existing signature and before signing it.
+	LoadCommandEdits []macho.LoadCommandEdit
+	// NormalizeLayout drops the content following the code signature or __LINKEDIT which no load command refers to
+	// (e.g. data appended to the binary) before signing, such binaries are rejected by default (see
+	// macho.File.CheckLayout).
+	NormalizeLayout bool
+	// SignatureSize is the exact size of the code signature to write (e.g. as given by EstimateSignatureSize when the
+	// space was reserved ahead of signing), signing fails if the signature does not fit. The size is derived from the
+	// first signing pass when zero.
+	SignatureSize int
+}
+
+// errSignatureTooLarge is returned when the signature does not fit the size it must have (see BinaryOptions.SignatureSize).
+var errSignatureTooLarge = errors.New("the code signature does not fit in the given size")
+
+// bindsBundleDetails indicates any bundle details are bound to the signature.
+func (o BinaryOptions) bindsBundleDetails() bool {
+	return o.InfoPlist != nil || o.CodeResources != nil || len(o.Entitlements) > 0
+}
+
 func GenerateSigningSuperBlob(id string, m *macho.File, signingMaterial pki.SigningMaterial, paddingTarget int) (int, []byte, error) {
+	return GenerateSigningSuperBlobWithOptions(id, m, signingMaterial, BinaryOptions{}, paddingTarget)
+}
+
+// GenerateSigningSuperBlobWithOptions creates the code signing super blob for the given binary, binding the given
+// bundle details to the signature.
+func GenerateSigningSuperBlobWithOptions(id string, m *macho.File, signingMaterial pki.SigningMaterial, opts BinaryOptions, paddingTarget int) (int, []byte, error) {
+	return generateSigningSuperBlob(id, m, signingMaterial, opts, paddingTarget, nil)
+}
+
+// generateSigningSuperBlob creates the code signing super blob, reusing the page hashes of a prior pass found in the
+// given cache (if any).
+//
+//nolint:funlen
+func generateSigningSuperBlob(id string, m *macho.File, signingMaterial pki.SigningMaterial, opts BinaryOptions, paddingTarget int, pages pageHashCache) (int, []byte, error) {
 	var cdFlags macho.CdFlag
-	if signingMaterial.Signer != nil {
+	switch {
+	case signingMaterial.Signer != nil && opts.KernelExtension:
+		cdFlags = 0
+	case signingMaterial.Signer != nil:
 		// TODO: add options to enable more strict rules (such as macho.Hard)
 		// note: we must at least support the runtime option for notarization (requirement introduced in macOS 10.14 / Mojave).
 		// cdFlags = macho.Runtime | macho.Hard
 		cdFlags = macho.Runtime
-	} else {
+	default:
 		cdFlags = macho.Adhoc
 	}
+	if opts.Flags != nil {
+		cdFlags = *opts.Flags
+	}
+	if opts.LibraryValidation {
+		cdFlags |= macho.RequireLv
+	}
 
-	requirementsBlob, requirementsHashBytes, err := generateRequirements(id, sha256.New(), signingMaterial)
+	requirementsBlob, err := newRequirementsBlob(id, signingMaterial, opts.Requirements)
 	if err != nil {
 		return 0, nil, fmt.Errorf("unable to create requirements: %w", err)
 	}
 
-	// TODO: add entitlements, for the meantime, don't include it
-	entitlementsHashBytes := bytes.Repeat([]byte{0}, sha256.New().Size())
+	// the hashed contents of the special slots (each code directory holds the hashes of its own hash type)
+	specialSlotContents := map[macho.SlotType][]byte{}
+	addBlobSlot := func(slot macho.SlotType, blob *macho.Blob) error {
+		blobBytes, err := restruct.Pack(macho.SigningOrder, blob)
+		if err != nil {
+			return err
+		}
+		specialSlotContents[slot] = blobBytes
+		return nil
+	}
 
-	cdBlob, err := generateCodeDirectory(id, sha256.New(), m, cdFlags, requirementsHashBytes, entitlementsHashBytes)
+	if err := addBlobSlot(macho.CsSlotRequirements, requirementsBlob); err != nil {
+		return 0, nil, fmt.Errorf("unable to encode requirements blob: %w", err)
+	}
+	if opts.InfoPlist != nil {
+		specialSlotContents[macho.CsSlotInfoslot] = opts.InfoPlist
+	}
+	if opts.CodeResources != nil {
+		specialSlotContents[macho.CsSlotResourcedir] = opts.CodeResources
+	}
+
+	var entitlementsBlob, entitlementsDERBlob *macho.Blob
+	if len(opts.Entitlements) > 0 {
+		entitlementsBlob, entitlementsDERBlob, err = generateEntitlements(opts.Entitlements)
+		if err != nil {
+			return 0, nil, fmt.Errorf("unable to create entitlements: %w", err)
+		}
+		for slot, blob := range map[macho.SlotType]*macho.Blob{macho.CsSlotEntitlements: entitlementsBlob, macho.CsSlotEntitlementsDer: entitlementsDERBlob} {
+			if err := addBlobSlot(slot, blob); err != nil {
+				return 0, nil, fmt.Errorf("unable to encode entitlements blob: %w", err)
+			}
+		}
+	}
+
+	cdOpts := codeDirectoryOptions{
+		version:        opts.CodeDirectoryVersion,
+		runtimeVersion: opts.RuntimeVersion,
+		teamID:         teamID(signingMaterial, opts.TeamID),
+		flags:          cdFlags,
+		execSegFlags:   execSegFlags(m, opts.Entitlements),
+		pages:          pages,
+	}
+
+	// the SHA-256 code directory is the primary one (the one signed by the CMS signature), the SHA-1 code directory is
+	// only understood by older verifiers (both are bound to the signature by the cdhashes attributes)
+	cdOpts.specialSlots = specialSlotHashes(sha256.New, specialSlotContents, opts.SpecialSlotHashes[macho.HashTypeSha256])
+	cdBlob, err := generateCodeDirectory(id, sha256.New(), m, cdOpts)
 	if err != nil {
 		return 0, nil, fmt.Errorf("unable to create code directory: %w", err)
 	}
 
-	cmsBlob, err := generateCMS(signingMaterial, cdBlob)
+	cdOpts.specialSlots = specialSlotHashes(sha1.New, specialSlotContents, opts.SpecialSlotHashes[macho.HashTypeSha1])
+	sha1CDBlob, err := generateCodeDirectory(id, sha1.New(), m, cdOpts)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to create alternate code directory: %w", err)
+	}
+
+	cmsBlob, err := generateCMS(signingMaterial, cdBlob, sha1CDBlob)
 	if err != nil {
 		return 0, nil, fmt.Errorf("unable to create signature block: %w", err)
 	}
@@ -44,33 +178,108 @@ func GenerateSigningSuperBlob(id string, m *macho.File, signingMaterial pki.Sign
 
 	sb.Add(macho.CsSlotCodedirectory, cdBlob)
 	sb.Add(macho.CsSlotRequirements, requirementsBlob)
+	if entitlementsBlob != nil {
+		sb.Add(macho.CsSlotEntitlements, entitlementsBlob)
+		sb.Add(macho.CsSlotEntitlementsDer, entitlementsDERBlob)
+	}
+	sb.Add(macho.CsSlotAlternateCodedirectories, sha1CDBlob)
 	sb.Add(macho.CsSlotCmsSignature, cmsBlob)
 
+	if paddingTarget > 0 && int(sb.Length) > paddingTarget {
+		return 0, nil, fmt.Errorf("%w: %d bytes before padding, %d bytes available", errSignatureTooLarge, sb.Length, paddingTarget)
+	}
 	sb.Finalize(paddingTarget)
 
-	sbBytes, err := restruct.Pack(macho.SigningOrder, &sb)
+	return int(sb.Length), sb.Bytes(), nil
+}
+
+// teamID returns the team identifier to write into the code directory: the given one, or else the team of the signing
+// certificate (there is none for ad-hoc signatures).
+func teamID(signingMaterial pki.SigningMaterial, team string) string {
+	if team != "" || signingMaterial.Signer == nil {
+		return team
+	}
+	leaf, err := signingMaterial.SigningCertificate()
+	if err != nil || len(leaf.Subject.OrganizationalUnit) == 0 {
+		return ""
+	}
+	return leaf.Subject.OrganizationalUnit[0]
+}
+
+// generateEntitlements creates the XML and DER entitlements blobs.
+func generateEntitlements(ents entitlements.Entitlements) (*macho.Blob, *macho.Blob, error) {
+	der, err := ents.DER()
 	if err != nil {
-		return 0, nil, fmt.Errorf("unable to encode super blob: %w", err)
+		return nil, nil, err
 	}
 
-	return int(sb.Length), sbBytes, nil
+	xmlBlob := macho.NewBlob(macho.MagicEmbeddedEntitlements, []byte(ents.XML()))
+	derBlob := macho.NewBlob(macho.MagicEmbeddedEntitlementsDer, der)
+	return &xmlBlob, &derBlob, nil
 }
 
-func UpdateSuperBlobOffsetReferences(m *macho.File, numSbBytes uint64) error {
-	// (patch) patch  LcCodeSignature loader referencing the superblob offset
-	if err := m.UpdateCodeSigningCmdDataSize(int(numSbBytes)); err != nil {
-		return fmt.Errorf("unable to update code signature loader data size: %w", err)
+// newRequirementsBlob returns the given compiled requirements set, or generates the designated requirement when none
+// is given.
+func newRequirementsBlob(id string, signingMaterial pki.SigningMaterial, requirements []byte) (*macho.Blob, error) {
+	if requirements == nil {
+		blob, _, err := generateRequirements(id, sha256.New(), signingMaterial)
+		return blob, err
 	}
 
-	// (patch) update the __LINKEDIT segment sizes to be "oldsize + newsuperblobsize"
-	linkEditSegment := m.Segment("__LINKEDIT")
+	if _, err := macho.DecodeRequirementSet(requirements); err != nil {
+		return nil, fmt.Errorf("invalid requirements set: %w", err)
+	}
+	length := macho.SigningOrder.Uint32(requirements[unsafe.Offsetof(macho.BlobHeader{}.Length):])
+	blob := macho.NewBlob(macho.MagicRequirements, requirements[unsafe.Sizeof(macho.BlobHeader{}):length])
+	return &blob, nil
+}
 
-	linkEditSegment.Filesz += numSbBytes
-	for linkEditSegment.Filesz > linkEditSegment.Memsz {
-		linkEditSegment.Memsz *= 2
+// specialSlotHashes hashes the given special slot contents (along with the given precomputed hashes of slots without
+// content), the hashes are indexed by slot number (minus one) and unused slots (below the highest used slot) hold a
+// zero hash.
+func specialSlotHashes(newHash func() hash.Hash, contents map[macho.SlotType][]byte, precomputed map[macho.SlotType][]byte) [][]byte {
+	var specialSlots [][]byte
+	set := func(slot macho.SlotType, h []byte) {
+		for len(specialSlots) < int(slot) {
+			specialSlots = append(specialSlots, nil)
+		}
+		specialSlots[slot-1] = h
+	}
+	for slot, h := range precomputed {
+		if _, ok := contents[slot]; !ok {
+			set(slot, h)
+		}
 	}
-	if err := m.UpdateSegmentHeader(linkEditSegment.SegmentHeader); err != nil {
-		return fmt.Errorf("failed to update linkedit segment size: %w", err)
+	for slot, content := range contents {
+		set(slot, hashBytes(newHash(), content))
 	}
-	return nil
+
+	zero := make([]byte, newHash().Size())
+	for i := range specialSlots {
+		if specialSlots[i] == nil {
+			specialSlots[i] = zero
+		}
+	}
+	return specialSlots
+}
+
+func hashBytes(h hash.Hash, by []byte) []byte {
+	h.Write(by)
+	return h.Sum(nil)
+}
+
+// UpdateSuperBlobOffsetReferences reserves the given number of superblob bytes at the offset of the code signing load
+// command (see macho.File.ReserveCodeSignature for custom layouts).
+func UpdateSuperBlobOffsetReferences(m *macho.File, numSbBytes uint64) error {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+	if cmd == nil {
+		return fmt.Errorf("binary has no code signing loader command")
+	}
+
+	// (patch) patch LcCodeSignature loader referencing the superblob and update the __LINKEDIT segment sizes to be
+	// "oldsize + newsuperblobsize"
+	return m.ReserveCodeSignature(cmd.DataOffset, uint32(numSbBytes))
 }
@@ -1,12 +1,18 @@
 package quill
 
 import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/anchore/quill/internal/test"
+	"github.com/anchore/quill/quill/dmg"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/pki"
 )
 
 func TestSign(t *testing.T) {
@@ -288,3 +294,102 @@ func TestIsSigned(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSigned_diskImage(t *testing.T) {
+	newImage := func(offset, size uint64) string {
+		trailer := make([]byte, dmg.TrailerSize)
+		copy(trailer, dmg.TrailerMagic)
+		binary.BigEndian.PutUint64(trailer[296:], offset)
+		binary.BigEndian.PutUint64(trailer[304:], size)
+		path := filepath.Join(t.TempDir(), "release.dmg")
+		require.NoError(t, os.WriteFile(path, append([]byte("image-signature"), trailer...), 0600))
+		return path
+	}
+
+	signed, err := IsSigned(newImage(6, 9))
+	require.NoError(t, err)
+	assert.True(t, signed)
+
+	signed, err = IsSigned(newImage(0, 0))
+	require.NoError(t, err)
+	assert.False(t, signed)
+}
+
+func TestSigningConfig_resolveIdentity(t *testing.T) {
+	// a minimal (load command free) arm64 executable
+	bin := make([]byte, 32)
+	binary.LittleEndian.PutUint32(bin[0:], 0xfeedfacf)
+	binary.LittleEndian.PutUint32(bin[4:], 0x0100000c)
+	binary.LittleEndian.PutUint32(bin[12:], 0x2)
+
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "mytool")
+	require.NoError(t, os.WriteFile(tool, bin, 0600))
+
+	app := filepath.Join(dir, "My.app", "Contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(app, "MacOS"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(app, "MacOS", "My"), bin, 0600))
+	info := entitlements.Entitlements{"CFBundleIdentifier": "com.example.my", "CFBundleExecutable": "My"}.XML()
+	require.NoError(t, os.WriteFile(filepath.Join(app, "Info.plist"), []byte(info), 0600))
+	appBinary := filepath.Join(app, "MacOS", "My")
+
+	tests := []struct {
+		name     string
+		cfg      *SigningConfig
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "derived from the file name",
+			cfg:      NewSigningConfig(tool, pki.SigningMaterial{}),
+			expected: "mytool",
+		},
+		{
+			name:     "prefixed file name",
+			cfg:      NewSigningConfig(tool, pki.SigningMaterial{}).WithIdentifierPrefix("com.example."),
+			expected: "com.example.mytool",
+		},
+		{
+			name:     "explicit identity is not prefixed",
+			cfg:      NewSigningConfig(tool, pki.SigningMaterial{}).WithIdentity("tool").WithIdentifierPrefix("com.example."),
+			expected: "tool",
+		},
+		{
+			name:     "bundle identifier of the bundle executable",
+			cfg:      NewSigningConfig(appBinary, pki.SigningMaterial{}).WithIdentifierPrefix("org.other."),
+			expected: "com.example.my",
+		},
+		{
+			name:     "explicit identity wins over the bundle identifier",
+			cfg:      NewSigningConfig(appBinary, pki.SigningMaterial{}).WithIdentity("my-app"),
+			expected: "my-app",
+		},
+		{
+			name:     "identity template",
+			cfg:      NewSigningConfig(tool, pki.SigningMaterial{}).WithIdentity("com.example.{{ .Basename }}-{{ .Arch }}"),
+			expected: "com.example.mytool-arm64",
+		},
+		{
+			name:     "identity template with the bundle identifier",
+			cfg:      NewSigningConfig(appBinary, pki.SigningMaterial{}).WithIdentity("{{ .BundleID }}.{{ .Basename }}"),
+			expected: "com.example.my.My",
+		},
+		{
+			name:    "identity template without a bundle identifier",
+			cfg:     NewSigningConfig(tool, pki.SigningMaterial{}).WithIdentity("{{ .BundleID }}"),
+			wantErr: "no Info.plist with a CFBundleIdentifier",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.cfg.resolveIdentity())
+			err := tt.cfg.expandIdentity()
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.cfg.Identity)
+		})
+	}
+}
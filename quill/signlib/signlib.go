@@ -0,0 +1,259 @@
+/*
+Package signlib is a small API for embedding quill within release tooling. Sign, Notarize, and Staple work on readers
+and writers instead of file paths. The package does not depend on quill's CLI, terminal UI, or event bus, and it logs
+nothing unless a logger is configured with SetLogger. Notarize is the only entry point that talks to Apple, and it pulls
+in the S3 client used to upload submissions.
+*/
+package signlib
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	macholibre "github.com/anchore/go-macholibre"
+	"github.com/anchore/quill/quill/logging"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/notary"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/remediation"
+	"github.com/anchore/quill/quill/sign"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+const (
+	// DefaultNotarizeTimeout is the time allowed for a notarization submission to complete.
+	DefaultNotarizeTimeout = 15 * time.Minute
+	// DefaultPollInterval is the time between notarization status requests.
+	DefaultPollInterval = 10 * time.Second
+
+	httpTimeout = 30 * time.Second
+)
+
+// ErrStaplingNotSupported is returned by Staple: Apple only supports stapling tickets to app bundles, disk images,
+// and installer packages, never to bare Mach-O binaries (Gatekeeper looks up the ticket of a notarized binary online).
+var ErrStaplingNotSupported = errors.New("stapling a notarization ticket to a Mach-O binary is not supported")
+
+// SignOptions describes how to sign a binary.
+type SignOptions struct {
+	// Identity is the code directory identifier of the binary (required, e.g. the name of the binary).
+	Identity string
+	// Signer is the private key of the signing certificate. The binary is ad-hoc signed when this is nil.
+	Signer crypto.Signer
+	// Certificates is the signing certificate with its chain, in any order.
+	Certificates []*x509.Certificate
+	// TimestampServers are the RFC 3161 timestamp authority URLs, tried in order until one succeeds (no secure
+	// timestamp is requested when empty).
+	TimestampServers []string
+}
+
+// NotarizeOptions describes the App Store Connect API key used for notarization and how long to wait for results.
+type NotarizeOptions struct {
+	// IssuerID is the issuer of the App Store Connect API key.
+	IssuerID string
+	// KeyID is the identifier of the App Store Connect API key.
+	KeyID string
+	// Key is the App Store Connect API (ECDSA) private key.
+	Key *ecdsa.PrivateKey
+	// Timeout is the time allowed for the submission to complete (DefaultNotarizeTimeout when unset).
+	Timeout time.Duration
+	// PollInterval is the time between status requests (DefaultPollInterval when unset).
+	PollInterval time.Duration
+	// NoWait returns as soon as the submission is uploaded, without waiting for the result.
+	NoWait bool
+	// MaxWait and Deadline bound the wait for the result (unbound when unset, other than by Timeout): when the
+	// submission is still in progress once either is reached, Notarize returns the submission (with a "Pending"
+	// status) along with a *notary.PendingError, so the wait can be resumed later by submission ID.
+	MaxWait  time.Duration
+	Deadline time.Time
+}
+
+// Submission is the result of a notarization request.
+type Submission struct {
+	ID string
+	// Status is the final status reported by Apple ("Accepted", "Invalid", ...), empty when not waiting for results
+	// ("Pending" when the wait stopped at NotarizeOptions.MaxWait or NotarizeOptions.Deadline).
+	Status string
+}
+
+// SetLogger sets the logger used by all signlib calls (see the logging package for adapters to common loggers).
+func SetLogger(l logging.Logger) {
+	logging.Set(l)
+}
+
+// Sign reads a single-arch or universal binary from the given reader and writes the signed binary to the writer.
+func Sign(in io.Reader, out io.Writer, opts SignOptions) error {
+	if opts.Identity == "" {
+		return fmt.Errorf("an identity is required to sign a binary")
+	}
+
+	sm := *pki.NewSigningMaterial(opts.Signer, opts.Certificates)
+	sm.Timestamp = timestamp.Config{Servers: opts.TimestampServers}
+
+	if err := sm.Validate().Err(); err != nil {
+		return err
+	}
+
+	if network.Offline() && sm.Timestamp.Enabled() {
+		return remediation.Wrap(fmt.Errorf("timestamping was requested (%s), but network access is disabled (offline mode)", strings.Join(sm.Timestamp.Servers, ", ")),
+			remediation.TimestampOffline, "pass --timestamp-server \"\" to sign without a secure timestamp, or sign without --offline", "")
+	}
+
+	dir, err := os.MkdirTemp("", "quill-signlib-")
+	if err != nil {
+		return fmt.Errorf("unable to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := writeTemp(dir, "unsigned", in)
+	if err != nil {
+		return err
+	}
+
+	signed, err := signFile(dir, path, opts.Identity, sm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(signed)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(out, f); err != nil {
+		return fmt.Errorf("unable to write signed binary: %w", err)
+	}
+	return nil
+}
+
+// signFile signs the binary at the given path, returning the path of the signed binary.
+func signFile(dir, path, id string, sm pki.SigningMaterial) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if !macholibre.IsUniversalMachoBinary(f) {
+		return path, sign.Binary(path, id, sm)
+	}
+
+	extractDir := filepath.Join(dir, "arches")
+	if err := os.Mkdir(extractDir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create temp directory to extract multi-arch binary: %w", err)
+	}
+
+	extracted, err := macholibre.Extract(f, extractDir)
+	if err != nil {
+		return "", fmt.Errorf("unable to extract multi-arch binary: %w", err)
+	}
+
+	var paths []string
+	for _, ef := range extracted {
+		if err := sign.Binary(ef.Path, id, sm); err != nil {
+			return "", err
+		}
+		paths = append(paths, ef.Path)
+	}
+
+	packaged := filepath.Join(dir, "signed")
+	if err := macholibre.Package(packaged, paths...); err != nil {
+		return "", fmt.Errorf("unable to package signed multi-arch binary: %w", err)
+	}
+	return packaged, nil
+}
+
+// Notarize submits the (signed) binary, zip archive, disk image, or installer package read from the given reader to
+// Apple's notary service (binaries are zipped, other formats are submitted as is). The name is the file name of the
+// submission (e.g. the binary name, or "name.zip" for a zip archive).
+func Notarize(ctx context.Context, name string, in io.Reader, opts NotarizeOptions) (*Submission, error) {
+	if opts.Key == nil {
+		return nil, fmt.Errorf("an App Store Connect API key is required to notarize")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultNotarizeTimeout
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	token, err := notary.NewSignedToken(notary.TokenConfig{
+		Issuer:        opts.IssuerID,
+		PrivateKeyID:  opts.KeyID,
+		TokenLifetime: opts.Timeout + (2 * time.Minute),
+		Key:           opts.Key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "quill-signlib-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := writeTemp(dir, filepath.Base(name), in)
+	if err != nil {
+		return nil, err
+	}
+
+	bin, err := notary.NewPayload(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := notary.NewSubmission(notary.NewAPIClient(token, httpTimeout), bin)
+	if err := sub.Start(ctx); err != nil {
+		return nil, fmt.Errorf("unable to start submission: %w", err)
+	}
+
+	result := &Submission{ID: sub.ID()}
+	if opts.NoWait {
+		return result, nil
+	}
+
+	status, err := notary.PollStatus(ctx, sub, notary.StatusConfig{
+		Timeout:  opts.Timeout,
+		Poll:     opts.PollInterval,
+		Wait:     true,
+		MaxWait:  opts.MaxWait,
+		Deadline: opts.Deadline,
+	})
+	result.Status = string(status)
+	return result, err
+}
+
+// Staple would attach the notarization ticket to the artifact read from the given reader, however, quill only
+// produces Mach-O binaries, which cannot hold a stapled ticket. It always returns ErrStaplingNotSupported; it exists
+// so release pipelines can call it unconditionally and skip the step on this error.
+func Staple(_ context.Context, _ io.Reader, _ io.Writer) error {
+	return ErrStaplingNotSupported
+}
+
+func writeTemp(dir, name string, in io.Reader) (string, error) {
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "binary"
+	}
+	path := filepath.Join(dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("unable to create temp file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, in); err != nil {
+		return "", fmt.Errorf("unable to read binary: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,80 @@
+package signlib
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		opts    SignOptions
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:  "identity is required",
+			input: []byte("binary"),
+			opts:  SignOptions{},
+			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.ErrorContains(t, err, "an identity is required")
+			},
+		},
+		{
+			name:    "not a macho binary",
+			input:   []byte("not a binary"),
+			opts:    SignOptions{Identity: "bin"},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Sign(bytes.NewReader(tt.input), &out, tt.opts)
+			tt.wantErr(t, err)
+			assert.Zero(t, out.Len())
+		})
+	}
+}
+
+func TestNotarize_requiresKey(t *testing.T) {
+	_, err := Notarize(context.Background(), "bin", strings.NewReader("binary"), NotarizeOptions{})
+	require.ErrorContains(t, err, "App Store Connect API key is required")
+}
+
+func TestStaple(t *testing.T) {
+	err := Staple(context.Background(), strings.NewReader("binary"), &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrStaplingNotSupported)
+}
+
+// the point of this package is to be embeddable without the CLI and UI dependency tree
+func TestDependencies(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	out, err := exec.Command("go", "list", "-deps", ".").Output()
+	require.NoError(t, err)
+
+	forbidden := []string{
+		"github.com/anchore/quill/quill\n",
+		"github.com/anchore/quill/internal/bus",
+		"github.com/anchore/quill/cmd/",
+		"github.com/anchore/clio",
+		"github.com/charmbracelet/",
+		"github.com/spf13/cobra",
+		"github.com/wagoodman/go-partybus",
+		"github.com/sirupsen/logrus",
+	}
+
+	deps := string(out) + "\n"
+	for _, f := range forbidden {
+		assert.NotContains(t, deps, f)
+	}
+}
@@ -0,0 +1,94 @@
+package quill
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/sign"
+)
+
+// SignStream signs the binary (single-arch or universal) or flat installer package read from the given reader,
+// writing the signed result to the given writer (e.g. stdin to stdout within a pipeline). The content is buffered to a
+// temp file while signing, nothing is written until signing succeeds. Since there is no file name to derive the
+// identifier from, a binary without an embedded Info.plist must be given an identity with WithIdentity (unless its
+// existing signature is carried over).
+func SignStream(r io.Reader, w io.Writer, cfg SigningConfig) error {
+	dir, err := os.MkdirTemp("", "quill-stream-")
+	if err != nil {
+		return fmt.Errorf("unable to create temp directory to buffer the binary: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the temp directory holds nothing else, so no Info.plist is found next to the binary
+	binPath := filepath.Join(dir, "binary")
+	if err := bufferStream(r, binPath); err != nil {
+		return err
+	}
+
+	cfg.Path = binPath
+	if !cfg.explicitIdentity && !cfg.Resign && cfg.BlobEdit == nil {
+		if err := requireStreamIdentity(binPath); err != nil {
+			return err
+		}
+	}
+
+	if err := Sign(cfg); err != nil {
+		return err
+	}
+
+	f, err := os.Open(binPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return fmt.Errorf("unable to write signed binary: %w", err)
+	}
+	log.WithFields("bytes", n).Debug("wrote signed binary")
+	return nil
+}
+
+func bufferStream(r io.Reader, binPath string) error {
+	f, err := os.OpenFile(binPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0700)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to read binary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no binary to sign was read (the input is empty)")
+	}
+	log.WithFields("bytes", n).Debug("buffered binary to sign")
+	return nil
+}
+
+// requireStreamIdentity fails when the identifier of the given binary would be derived from its (temp) file name.
+func requireStreamIdentity(binPath string) error {
+	isMacho, err := macho.IsMachoFile(binPath)
+	if err != nil || !isMacho {
+		// installer packages have no identifier
+		return nil
+	}
+
+	id, err := sign.InfoPlistIdentifier(binPath)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("an identity is required to sign a binary read from a stream, since there is no file name to derive it from (set one with --identity)")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package quill
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/macho/machotest"
+	"github.com/anchore/quill/quill/pki"
+)
+
+func TestSignStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		cfg     *SigningConfig
+		wantErr string
+	}{
+		{
+			name:  "binary with an identity",
+			input: machotest.Binary(t, machotest.Config{}),
+			cfg:   NewSigningConfig("-", pki.SigningMaterial{}).WithIdentity("com.example.tool"),
+		},
+		{
+			name:    "binary without an identity",
+			input:   machotest.Binary(t, machotest.Config{}),
+			cfg:     NewSigningConfig("-", pki.SigningMaterial{}),
+			wantErr: "an identity is required",
+		},
+		{
+			name:    "empty input",
+			cfg:     NewSigningConfig("-", pki.SigningMaterial{}).WithIdentity("com.example.tool"),
+			wantErr: "the input is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := SignStream(bytes.NewReader(tt.input), &out, *tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Zero(t, out.Len(), "nothing must be written when signing fails")
+				return
+			}
+			require.NoError(t, err)
+
+			m, err := macho.NewReadOnlyFileFromBytes("signed", out.Bytes())
+			require.NoError(t, err)
+			defer m.Close()
+			assert.True(t, m.HasCodeSigningCmd())
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package ticket
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/anchore/quill/quill/dmg"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/xar"
+)
+
+// Kind is the kind of artifact a ticket is stapled to.
+type Kind string
+
+const (
+	KindBinary    Kind = "binary"
+	KindDiskImage Kind = "disk image"
+	KindPackage   Kind = "installer package"
+)
+
+const (
+	// packageTrailerMagic starts the trailer following the ticket appended to installer packages.
+	packageTrailerMagic = "t8lr"
+	// packageTrailerSize is the size of the package trailer: magic, version (2 bytes), type (2 bytes) and ticket
+	// length (4 bytes), little endian.
+	packageTrailerSize = 12
+)
+
+// Artifact is an artifact a notarization ticket may be stapled to.
+type Artifact struct {
+	Path string
+	Kind Kind
+	// Code is every part of the artifact a ticket must cover (every architecture of a universal binary, the artifact
+	// itself otherwise).
+	Code []Code
+	// Ticket is the stapled ticket, nil when none is stapled.
+	Ticket []byte
+}
+
+// Code is signed code within an artifact, covered by a ticket when any of its cdhashes is.
+type Code struct {
+	// Name describes the code (e.g. the architecture of a binary).
+	Name string
+	// CDHashes are the cdhashes of the code (truncated to 20 bytes), one per code directory.
+	CDHashes [][]byte
+}
+
+// ReadArtifact reads the code and the stapled ticket (if any) of the binary, disk image, or installer package at the
+// given path.
+func ReadArtifact(path string) (*Artifact, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a := Artifact{Path: path}
+	switch {
+	case xar.IsArchive(bytes.NewReader(content)):
+		a.Kind = KindPackage
+		err = a.readPackage(content)
+	case dmg.IsDiskImage(bytes.NewReader(content), int64(len(content))):
+		a.Kind = KindDiskImage
+		err = a.readDiskImage(content)
+	default:
+		a.Kind = KindBinary
+		err = a.readBinary(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s %q: %w", a.Kind, path, err)
+	}
+	return &a, nil
+}
+
+func (a *Artifact) readBinary(content []byte) error {
+	files, err := macho.NewReadOnlyFilesFromBytes(a.Path, content)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range files {
+		sb, err := m.ReadSuperBlob()
+		m.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Cpu, err)
+		}
+		// e.g. "arm64" for CpuArm64
+		arch := strings.TrimPrefix(strings.ToLower(m.Cpu.String()), "cpu")
+		if err := a.addSignature(arch, sb); err != nil {
+			return fmt.Errorf("%s: %w", m.Cpu, err)
+		}
+	}
+	return nil
+}
+
+func (a *Artifact) readDiskImage(content []byte) error {
+	offset, size, err := dmg.CodeSignature(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return fmt.Errorf("the disk image is not signed")
+	}
+
+	sb, err := macho.ParseSuperBlob(content[offset : offset+size])
+	if err != nil {
+		return err
+	}
+	return a.addSignature("disk image", sb)
+}
+
+// addSignature adds the code of the given signature, and its stapled ticket (if any).
+func (a *Artifact) addSignature(name string, sb *macho.ParsedSuperBlob) error {
+	code := Code{Name: name}
+	for _, e := range sb.CodeDirectories() {
+		cd, err := macho.ParseCodeDirectory(e.Data)
+		if err != nil {
+			return err
+		}
+		newHash, err := hashFunc(cd.HashType)
+		if err != nil {
+			return err
+		}
+		h := newHash()
+		h.Write(e.Data)
+		code.CDHashes = append(code.CDHashes, h.Sum(nil)[:cdHashSize])
+	}
+	if len(code.CDHashes) == 0 {
+		return fmt.Errorf("there is no code directory")
+	}
+	a.Code = append(a.Code, code)
+
+	if e := sb.Entry(macho.CsSlotTicketslot); e != nil && a.Ticket == nil {
+		t, err := macho.UnwrapBlobWrapper(e.Data)
+		if err != nil {
+			return fmt.Errorf("unable to read the stapled ticket: %w", err)
+		}
+		a.Ticket = t
+	}
+	return nil
+}
+
+func (a *Artifact) readPackage(content []byte) error {
+	archive, err := xar.Parse(content)
+	if err != nil {
+		return err
+	}
+
+	// the package is identified by the checksum of its table of contents (which its signature covers)
+	checksum, err := archive.TOCChecksum()
+	if err != nil {
+		return err
+	}
+	if len(checksum) < cdHashSize {
+		return fmt.Errorf("the table of contents checksum is too short (%d bytes)", len(checksum))
+	}
+	a.Code = append(a.Code, Code{Name: "package", CDHashes: [][]byte{checksum[:cdHashSize]}})
+
+	if len(content) < packageTrailerSize {
+		return nil
+	}
+	trailer := content[len(content)-packageTrailerSize:]
+	if string(trailer[:4]) != packageTrailerMagic {
+		return nil
+	}
+	length := uint64(binary.LittleEndian.Uint32(trailer[8:]))
+	if length > uint64(len(content)-packageTrailerSize) {
+		return fmt.Errorf("the stapled ticket (%d bytes) exceeds the package", length)
+	}
+	end := uint64(len(content) - packageTrailerSize)
+	a.Ticket = content[end-length : end]
+	return nil
+}
+
+func hashFunc(t macho.HashType) (func() hash.Hash, error) {
+	switch t {
+	case macho.HashTypeSha1:
+		return sha1.New, nil
+	case macho.HashTypeSha256, macho.HashTypeSha256Truncated:
+		return sha256.New, nil
+	case macho.HashTypeSha384:
+		return sha512.New384, nil
+	}
+	return nil, fmt.Errorf("unsupported hash type: %d", t)
+}
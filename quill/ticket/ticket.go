@@ -0,0 +1,171 @@
+/*
+Package ticket reads the notarization tickets stapled to artifacts (binaries, disk images, and installer packages), and
+checks the code they cover offline, as `stapler validate` does.
+
+A ticket is made of a header, the list of cdhashes it covers, and Apple's signature over both. All integers are little
+endian:
+
+	offset  size  field
+	0       4     magic ("s8ch")
+	4       4     version (1)
+	8       4     length of the entire ticket
+	12      4     record type
+	16      8     issue time (seconds since the Unix epoch)
+	24      8     expiration time (seconds since the Unix epoch, zero when the ticket does not expire)
+	32      4     number of cdhashes
+	36      ...   cdhashes: hash type (1 byte), hash size (1 byte), hash
+	...     ...   signature (the remainder of the ticket)
+
+Tickets are stapled to binaries and disk images within a blob wrapper in the ticket slot of their code signature
+superblob, and appended to installer packages followed by a trailer (see ReadArtifact).
+*/
+package ticket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// Magic starts every notarization ticket.
+const Magic = "s8ch"
+
+const (
+	version    = 1
+	headerSize = 36
+	// cdHashSize is the size of cdhashes (truncated to 20 bytes, whatever the hash type).
+	cdHashSize = 20
+)
+
+// RecordType is the kind of code a ticket was issued for.
+type RecordType uint32
+
+const (
+	// RecordTypeCode tickets cover signed code (binaries, bundles, and disk images) by cdhash.
+	RecordTypeCode RecordType = 1
+	// RecordTypePackage tickets cover installer packages by the checksum of their table of contents.
+	RecordTypePackage RecordType = 2
+)
+
+func (r RecordType) String() string {
+	switch r {
+	case RecordTypeCode:
+		return "code"
+	case RecordTypePackage:
+		return "installer package"
+	}
+	return fmt.Sprintf("unknown (%d)", uint32(r))
+}
+
+// Ticket is a parsed notarization ticket.
+type Ticket struct {
+	Version    uint32
+	RecordType RecordType
+	// IssuedAt is when Apple issued the ticket (after notarizing the code).
+	IssuedAt time.Time
+	// ExpiresAt is when the ticket stops being valid, zero when it does not expire (tickets usually do not expire,
+	// but Apple may revoke them, which is only known online).
+	ExpiresAt time.Time
+	// CDHashes are the (truncated) cdhashes of the code covered by the ticket.
+	CDHashes []CDHash
+	// Signature is Apple's signature over the ticket (which is not checked offline).
+	Signature []byte
+
+	// Raw is the entire ticket.
+	Raw []byte
+}
+
+// CDHash is a cdhash covered by a ticket.
+type CDHash struct {
+	HashType macho.HashType
+	Hash     []byte
+}
+
+func (h CDHash) String() string {
+	return fmt.Sprintf("%x", h.Hash)
+}
+
+// Parse reads the given notarization ticket, checking its structure.
+func Parse(b []byte) (*Ticket, error) {
+	if len(b) < headerSize {
+		return nil, fmt.Errorf("ticket too short (%d bytes)", len(b))
+	}
+	if string(b[:4]) != Magic {
+		return nil, fmt.Errorf("not a notarization ticket (magic=%q)", b[:4])
+	}
+
+	t := Ticket{Version: binary.LittleEndian.Uint32(b[4:])}
+	if t.Version != version {
+		return nil, fmt.Errorf("unsupported ticket version %d", t.Version)
+	}
+
+	length := uint64(binary.LittleEndian.Uint32(b[8:]))
+	if length < headerSize || length > uint64(len(b)) {
+		return nil, fmt.Errorf("invalid ticket length %d (%d bytes available)", length, len(b))
+	}
+	b = b[:length]
+	t.Raw = b
+
+	t.RecordType = RecordType(binary.LittleEndian.Uint32(b[12:]))
+	t.IssuedAt = unixTime(binary.LittleEndian.Uint64(b[16:]))
+	t.ExpiresAt = unixTime(binary.LittleEndian.Uint64(b[24:]))
+	if !t.ExpiresAt.IsZero() && t.ExpiresAt.Before(t.IssuedAt) {
+		return nil, fmt.Errorf("the ticket expires (%s) before it is issued (%s)", t.ExpiresAt.Format(time.RFC3339), t.IssuedAt.Format(time.RFC3339))
+	}
+
+	count := binary.LittleEndian.Uint32(b[32:])
+	at := uint64(headerSize)
+	for i := uint32(0); i < count; i++ {
+		if at+2 > length {
+			return nil, fmt.Errorf("cdhash %d exceeds the ticket", i)
+		}
+		hashType, size := macho.HashType(b[at]), uint64(b[at+1])
+		at += 2
+		if size != cdHashSize {
+			return nil, fmt.Errorf("cdhash %d has an invalid size (%d bytes)", i, size)
+		}
+		if at+size > length {
+			return nil, fmt.Errorf("cdhash %d exceeds the ticket", i)
+		}
+		t.CDHashes = append(t.CDHashes, CDHash{HashType: hashType, Hash: b[at : at+size]})
+		at += size
+	}
+	if len(t.CDHashes) == 0 {
+		return nil, fmt.Errorf("the ticket covers no cdhash")
+	}
+
+	t.Signature = b[at:]
+	if len(t.Signature) == 0 {
+		return nil, fmt.Errorf("the ticket is not signed")
+	}
+	return &t, nil
+}
+
+// unixTime converts seconds since the Unix epoch, zero (or a value out of range) is the zero time.
+func unixTime(seconds uint64) time.Time {
+	if seconds == 0 || seconds > uint64(1<<62) {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// Expired indicates if the ticket expired at the given time.
+func (t Ticket) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
+// Covers indicates if the ticket covers the given cdhash (only the first 20 bytes of longer hashes are compared).
+func (t Ticket) Covers(cdHash []byte) bool {
+	if len(cdHash) > cdHashSize {
+		cdHash = cdHash[:cdHashSize]
+	}
+	for _, h := range t.CDHashes {
+		if bytes.Equal(h.Hash, cdHash) {
+			return true
+		}
+	}
+	return false
+}
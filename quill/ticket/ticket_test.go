@@ -0,0 +1,144 @@
+package ticket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// newTicket encodes a ticket covering the given (20 byte) cdhashes, with a dummy signature.
+func newTicket(cdHashes ...[]byte) []byte {
+	return newExpiringTicket(0, cdHashes...)
+}
+
+// newExpiringTicket encodes a ticket issued at 1700000000 (2023-11-14) expiring at the given time.
+func newExpiringTicket(expires uint64, cdHashes ...[]byte) []byte {
+	var body bytes.Buffer
+	for _, h := range cdHashes {
+		body.WriteByte(byte(macho.HashTypeSha256))
+		body.WriteByte(byte(len(h)))
+		body.Write(h)
+	}
+	body.WriteString("signature")
+
+	b := make([]byte, headerSize, headerSize+body.Len())
+	copy(b, Magic)
+	binary.LittleEndian.PutUint32(b[4:], version)
+	binary.LittleEndian.PutUint32(b[8:], uint32(headerSize+body.Len()))
+	binary.LittleEndian.PutUint32(b[12:], 1)
+	binary.LittleEndian.PutUint64(b[16:], 1700000000)
+	binary.LittleEndian.PutUint64(b[24:], expires)
+	binary.LittleEndian.PutUint32(b[32:], uint32(len(cdHashes)))
+	return append(b, body.Bytes()...)
+}
+
+func fakeCDHash(b byte) []byte {
+	return bytes.Repeat([]byte{b}, cdHashSize)
+}
+
+func TestParse(t *testing.T) {
+	raw := newTicket(fakeCDHash(1), fakeCDHash(2))
+
+	// trailing bytes past the ticket length are ignored
+	tk, err := Parse(append(append([]byte(nil), raw...), 0, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), tk.Version)
+	assert.Equal(t, RecordTypeCode, tk.RecordType)
+	assert.Equal(t, "code", tk.RecordType.String())
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), tk.IssuedAt)
+	assert.True(t, tk.ExpiresAt.IsZero())
+	assert.False(t, tk.Expired(time.Now()))
+	assert.Equal(t, []CDHash{{HashType: macho.HashTypeSha256, Hash: fakeCDHash(1)}, {HashType: macho.HashTypeSha256, Hash: fakeCDHash(2)}}, tk.CDHashes)
+	assert.Equal(t, []byte("signature"), tk.Signature)
+	assert.Equal(t, raw, tk.Raw)
+
+	assert.True(t, tk.Covers(fakeCDHash(2)))
+	assert.True(t, tk.Covers(append(fakeCDHash(1), 0xff, 0xff)), "only the truncated hash is compared")
+	assert.False(t, tk.Covers(fakeCDHash(3)))
+	assert.Equal(t, "0101010101010101010101010101010101010101", tk.CDHashes[0].String())
+}
+
+func TestTicket_Expired(t *testing.T) {
+	tk, err := Parse(newExpiringTicket(1800000000, fakeCDHash(1)))
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1800000000, 0).UTC(), tk.ExpiresAt)
+	assert.False(t, tk.Expired(time.Unix(1800000000, 0)))
+	assert.True(t, tk.Expired(time.Unix(1800000001, 0)))
+}
+
+func TestParse_invalid(t *testing.T) {
+	valid := newTicket(fakeCDHash(1))
+	tests := []struct {
+		name  string
+		input func() []byte
+	}{
+		{
+			name:  "too short",
+			input: func() []byte { return valid[:headerSize-1] },
+		},
+		{
+			name: "unexpected magic",
+			input: func() []byte {
+				b := append([]byte(nil), valid...)
+				copy(b, "xar!")
+				return b
+			},
+		},
+		{
+			name: "unsupported version",
+			input: func() []byte {
+				b := append([]byte(nil), valid...)
+				binary.LittleEndian.PutUint32(b[4:], 2)
+				return b
+			},
+		},
+		{
+			name:  "truncated",
+			input: func() []byte { return valid[:len(valid)-1] },
+		},
+		{
+			name: "cdhashes exceed the ticket",
+			input: func() []byte {
+				b := append([]byte(nil), valid...)
+				binary.LittleEndian.PutUint32(b[32:], 100)
+				return b
+			},
+		},
+		{
+			name: "invalid cdhash size",
+			input: func() []byte {
+				b := append([]byte(nil), valid...)
+				b[headerSize+1] = 4
+				return b
+			},
+		},
+		{
+			name:  "expires before it is issued",
+			input: func() []byte { return newExpiringTicket(1600000000, fakeCDHash(1)) },
+		},
+		{
+			name:  "no cdhash",
+			input: func() []byte { return newTicket() },
+		},
+		{
+			name: "not signed",
+			input: func() []byte {
+				b := append([]byte(nil), valid[:len(valid)-len("signature")]...)
+				binary.LittleEndian.PutUint32(b[8:], uint32(len(b)))
+				return b
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input())
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package ticket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status is the notarization status of an artifact according to its stapled ticket.
+type Status string
+
+const (
+	// Stapled indicates the stapled ticket covers all the code of the artifact.
+	Stapled Status = "stapled"
+	// NotStapled indicates no ticket is stapled to the artifact (Gatekeeper looks the ticket up online).
+	NotStapled Status = "not stapled"
+	// NotCovered indicates the stapled ticket does not cover (all) the code of the artifact, e.g. the artifact was
+	// changed or signed again after notarization.
+	NotCovered Status = "not covered"
+	// Expired indicates the stapled ticket covers the artifact, but is past its expiration time.
+	Expired Status = "expired"
+	// Invalid indicates the stapled ticket is malformed.
+	Invalid Status = "invalid"
+)
+
+// Result is the outcome of validating the ticket stapled to an artifact.
+type Result struct {
+	Path   string
+	Kind   Kind
+	Status Status
+	// Message explains the status.
+	Message string
+	// Ticket is the stapled ticket (nil when none is stapled or when it is malformed).
+	Ticket *Ticket
+	// Uncovered names the code of the artifact the ticket does not cover.
+	Uncovered []string
+}
+
+// Valid indicates the stapled ticket covers the artifact.
+func (r Result) Valid() bool {
+	return r.Status == Stapled
+}
+
+// Validate checks the ticket stapled to the binary, disk image, or installer package at the given path offline (as
+// `stapler validate` does): the structure of the ticket, that it covers the cdhashes of all the code of the artifact,
+// and that it is not past its expiration time. Apple's signature over the ticket is not checked. An error is returned
+// when the artifact cannot be read.
+func Validate(path string) (*Result, error) {
+	a, err := ReadArtifact(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.Validate(), nil
+}
+
+// Validate checks the stapled ticket against the code of the artifact (see Validate).
+func (a Artifact) Validate() *Result {
+	r := Result{Path: a.Path, Kind: a.Kind}
+	if a.Ticket == nil {
+		r.Status = NotStapled
+		r.Message = fmt.Sprintf("no ticket is stapled to the %s", a.Kind)
+		return &r
+	}
+
+	t, err := Parse(a.Ticket)
+	if err != nil {
+		r.Status = Invalid
+		r.Message = fmt.Sprintf("the stapled ticket is malformed: %v", err)
+		return &r
+	}
+	r.Ticket = t
+
+	for _, c := range a.Code {
+		if !t.coversAny(c.CDHashes) {
+			r.Uncovered = append(r.Uncovered, c.Name)
+		}
+	}
+	if len(r.Uncovered) > 0 {
+		r.Status = NotCovered
+		r.Message = fmt.Sprintf("the stapled ticket does not cover the code of the %s (%s)", a.Kind, strings.Join(r.Uncovered, ", "))
+		return &r
+	}
+
+	if t.Expired(time.Now()) {
+		r.Status = Expired
+		r.Message = fmt.Sprintf("the stapled ticket covers the %s, but expired on %s", a.Kind, t.ExpiresAt.Format(time.RFC3339))
+		return &r
+	}
+
+	r.Status = Stapled
+	r.Message = fmt.Sprintf("the stapled ticket covers the %s", a.Kind)
+	return &r
+}
+
+func (t Ticket) coversAny(cdHashes [][]byte) bool {
+	for _, h := range cdHashes {
+		if t.Covers(h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package ticket
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/dmg"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/macho/machotest"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/sign"
+	"github.com/anchore/quill/quill/xar"
+)
+
+// signedSuperBlob ad-hoc signs a binary, returning its signature and the cdhash of its primary code directory.
+func signedSuperBlob(t *testing.T) (*macho.ParsedSuperBlob, []byte) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "signed")
+	machotest.Write(t, path, machotest.Config{})
+	require.NoError(t, sign.Binary(path, "com.example.tool", pki.SigningMaterial{}))
+
+	m, err := macho.NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer m.Close()
+	sb, err := m.ReadSuperBlob()
+	require.NoError(t, err)
+
+	h := sha256.Sum256(sb.Entry(macho.CsSlotCodedirectory).Data)
+	return sb, h[:cdHashSize]
+}
+
+// withTicket returns the given signature with the given ticket stapled to it.
+func withTicket(t *testing.T, sb *macho.ParsedSuperBlob, ticket []byte) []byte {
+	t.Helper()
+
+	out := macho.NewSuperBlob(macho.MagicEmbeddedSignature)
+	for _, e := range sb.Entries {
+		blob, err := macho.ParseBlob(e.Data)
+		require.NoError(t, err)
+		out.Add(e.Slot, &blob)
+	}
+	if ticket != nil {
+		wrapper := macho.NewBlobWrapper(ticket)
+		out.Add(macho.CsSlotTicketslot, &wrapper)
+	}
+	out.Finalize(0)
+	return out.Bytes()
+}
+
+func writeStapledBinary(t *testing.T, sb *macho.ParsedSuperBlob, ticket []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tool")
+	machotest.Write(t, path, machotest.Config{})
+	require.NoError(t, sign.Attach(path, withTicket(t, sb, ticket)))
+	return path
+}
+
+func writeDiskImage(t *testing.T, signature []byte) string {
+	t.Helper()
+
+	content := append(bytes.Repeat([]byte{0xdd}, 0x1000), signature...)
+	trailer := make([]byte, dmg.TrailerSize)
+	copy(trailer, dmg.TrailerMagic)
+	binary.BigEndian.PutUint64(trailer[296:], 0x1000)
+	binary.BigEndian.PutUint64(trailer[304:], uint64(len(signature)))
+
+	path := filepath.Join(t.TempDir(), "image.dmg")
+	require.NoError(t, os.WriteFile(path, append(content, trailer...), 0600))
+	return path
+}
+
+// writePackage writes an empty xar archive with the given ticket stapled to it, returning its TOC checksum.
+func writePackage(t *testing.T, ticket []byte) (string, []byte) {
+	t.Helper()
+
+	toc := `<?xml version="1.0" encoding="UTF-8"?>
+<xar><toc><checksum style="sha1"><offset>0</offset><size>20</size></checksum></toc></xar>`
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte(toc))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var out bytes.Buffer
+	require.NoError(t, binary.Write(&out, binary.BigEndian, struct {
+		Magic                 uint32
+		Size                  uint16
+		Version               uint16
+		TOCLengthCompressed   uint64
+		TOCLengthUncompressed uint64
+		ChecksumAlgorithm     uint32
+	}{xar.Magic, 28, 1, uint64(compressed.Len()), uint64(len(toc)), 1}))
+	out.Write(compressed.Bytes())
+	checksum := sha1.Sum(compressed.Bytes()) //nolint: gosec
+	out.Write(checksum[:])
+
+	if ticket != nil {
+		out.Write(ticket)
+		trailer := make([]byte, packageTrailerSize)
+		copy(trailer, packageTrailerMagic)
+		binary.LittleEndian.PutUint16(trailer[4:], 1)
+		binary.LittleEndian.PutUint16(trailer[6:], 1)
+		binary.LittleEndian.PutUint32(trailer[8:], uint32(len(ticket)))
+		out.Write(trailer)
+	}
+
+	path := filepath.Join(t.TempDir(), "installer.pkg")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0600))
+	return path, checksum[:]
+}
+
+func TestValidate_binary(t *testing.T) {
+	sb, cdHash := signedSuperBlob(t)
+
+	tests := []struct {
+		name      string
+		ticket    []byte
+		want      Status
+		uncovered []string
+	}{
+		{name: "stapled", ticket: newTicket(fakeCDHash(1), cdHash), want: Stapled},
+		{name: "not stapled", want: NotStapled},
+		{name: "expired", ticket: newExpiringTicket(1700000001, cdHash), want: Expired},
+		{name: "not covered", ticket: newTicket(fakeCDHash(1)), want: NotCovered, uncovered: []string{"arm64"}},
+		{name: "malformed", ticket: []byte("s8ch"), want: Invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Validate(writeStapledBinary(t, sb, tt.ticket))
+			require.NoError(t, err)
+			assert.Equal(t, KindBinary, r.Kind)
+			assert.Equal(t, tt.want, r.Status, r.Message)
+			assert.Equal(t, tt.want == Stapled, r.Valid())
+			assert.Equal(t, tt.uncovered, r.Uncovered)
+			assert.Equal(t, tt.want == Stapled || tt.want == NotCovered || tt.want == Expired, r.Ticket != nil)
+		})
+	}
+}
+
+func TestValidate_diskImage(t *testing.T) {
+	sb, cdHash := signedSuperBlob(t)
+
+	r, err := Validate(writeDiskImage(t, withTicket(t, sb, newTicket(cdHash))))
+	require.NoError(t, err)
+	assert.Equal(t, KindDiskImage, r.Kind)
+	assert.Equal(t, Stapled, r.Status, r.Message)
+
+	r, err = Validate(writeDiskImage(t, withTicket(t, sb, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, NotStapled, r.Status)
+
+	_, err = Validate(writeDiskImage(t, nil))
+	require.Error(t, err, "unsigned disk image")
+}
+
+func TestValidate_package(t *testing.T) {
+	path, _ := writePackage(t, nil)
+	r, err := Validate(path)
+	require.NoError(t, err)
+	assert.Equal(t, KindPackage, r.Kind)
+	assert.Equal(t, NotStapled, r.Status)
+
+	_, checksum := writePackage(t, nil)
+	path, _ = writePackage(t, newTicket(checksum))
+	r, err = Validate(path)
+	require.NoError(t, err)
+	assert.Equal(t, Stapled, r.Status, r.Message)
+
+	path, _ = writePackage(t, newTicket(fakeCDHash(1)))
+	r, err = Validate(path)
+	require.NoError(t, err)
+	assert.Equal(t, NotCovered, r.Status)
+}
@@ -0,0 +1,114 @@
+package timestamp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/github/smimesign/ietf-cms/timestamp"
+)
+
+// PKIFailureInfo bits (RFC 3161 section 2.4.2) describing why a timestamp request was rejected.
+var failureInfoNames = map[int]string{
+	0:  "unrecognized or unsupported algorithm",
+	2:  "transaction not permitted or supported",
+	5:  "the data submitted has the wrong format",
+	14: "the TSA's time source is not available",
+	15: "the requested TSA policy is not supported",
+	16: "the requested extension is not supported",
+	17: "the additional information requested is not available",
+	25: "the request cannot be handled due to system failure",
+}
+
+// TransientError is a failure that may succeed when retried (e.g. a connection failure, timeout, or a server error
+// response).
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("transient failure: %v", e.Err)
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient indicates if the given error was caused by a transient failure.
+func IsTransient(err error) bool {
+	var transient *TransientError
+	return errors.As(err, &transient)
+}
+
+// RejectedError indicates that the timestamp authority refused to issue a token for the request (for instance the
+// requested digest algorithm or policy is not supported). Retrying the same request will not succeed.
+type RejectedError struct {
+	// Status is the PKIStatus returned by the timestamp authority (e.g. 2 for "rejection").
+	Status int
+	// Reasons are the status strings and failure information returned by the timestamp authority.
+	Reasons []string
+}
+
+func newRejectedError(info timestamp.PKIStatusInfo) *RejectedError {
+	e := &RejectedError{Status: info.Status}
+	if strs, err := info.StatusString.Strings(); err == nil {
+		e.Reasons = append(e.Reasons, strs...)
+	}
+	for i := 0; i < info.FailInfo.BitLength; i++ {
+		if info.FailInfo.At(i) != 1 {
+			continue
+		}
+		name, ok := failureInfoNames[i]
+		if !ok {
+			name = fmt.Sprintf("failure info bit %d", i)
+		}
+		e.Reasons = append(e.Reasons, name)
+	}
+	return e
+}
+
+func (e *RejectedError) Error() string {
+	msg := fmt.Sprintf("timestamp request rejected by the TSA (status %d)", e.Status)
+	if len(e.Reasons) > 0 {
+		msg += ": " + strings.Join(e.Reasons, ", ")
+	}
+	return msg
+}
+
+// IsRejected indicates if the given error was caused by the timestamp authority rejecting the request.
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// serversError is the set of failures from every timestamp server tried. Use IsTransient, IsRejected, or errors.Is to
+// inspect the individual failures (matching if any server failed in that way).
+type serversError struct {
+	errs []error
+}
+
+func (e *serversError) Error() string {
+	var msgs []string
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("unable to get a timestamp from any of %d server(s): %s", len(e.errs), strings.Join(msgs, "; "))
+}
+
+func (e *serversError) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *serversError) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
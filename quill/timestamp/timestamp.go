@@ -0,0 +1,375 @@
+// Package timestamp requests RFC 3161 timestamp tokens from one or more timestamp authorities (TSAs) and attaches
+// them to CMS signatures.
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/lifecycle"
+	"github.com/anchore/quill/quill/metrics"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/remediation"
+)
+
+const (
+	contentTypeQuery = "application/timestamp-query"
+	contentTypeReply = "application/timestamp-reply"
+
+	// DefaultTimeout is the time allowed for a single timestamp request.
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultRetries is the number of times a request to a single server is retried after a transient failure.
+	DefaultRetries = 2
+
+	// DefaultBackoff is the delay before the first retry, doubling for every following retry.
+	DefaultBackoff = time.Second
+)
+
+// Config describes how timestamp tokens are requested.
+type Config struct {
+	// Servers are the RFC 3161 timestamp authority URLs, tried in order until one succeeds.
+	Servers []string
+	// Timeout is the time allowed for a single request to a server (DefaultTimeout when unset).
+	Timeout time.Duration
+	// Retries is the number of times a request to a server is retried after a transient failure before failing over
+	// to the next server (DefaultRetries when unset, a negative value disables retries).
+	Retries int
+	// Backoff is the delay before the first retry, doubling for every following retry (DefaultBackoff when unset).
+	Backoff time.Duration
+	// Hash is the digest algorithm used for the message imprint of the request (crypto.SHA256 when unset, SHA-384
+	// and SHA-512 are also supported).
+	Hash crypto.Hash
+	// DisableNonce omits the random nonce from requests (and skips checking the nonce of the returned tokens), which
+	// is only needed for timestamp servers that do not echo nonces back.
+	DisableNonce bool
+	// Roots are the trust anchors for timestamp authority certificates (DefaultRoots when unset).
+	Roots *x509.CertPool
+	// TLS is the TLS configuration used for HTTPS timestamp servers, e.g. a client certificate for mutual TLS or
+	// the CAs of a private timestamp authority (see TLSConfig). The default transport settings are used when unset.
+	TLS *tls.Config
+}
+
+// Enabled indicates if any timestamp server is configured.
+func (c Config) Enabled() bool {
+	return len(c.Servers) > 0
+}
+
+// requestHashes are the digest algorithms that may be used for the request message imprint (by user-facing name)
+var requestHashes = map[crypto.Hash]string{
+	crypto.SHA256: "sha256",
+	crypto.SHA384: "sha384",
+	crypto.SHA512: "sha512",
+}
+
+// ParseHash parses the user-facing name of a request digest algorithm ("sha256", "sha384", or "sha512"), an empty
+// value is the default (SHA-256).
+func ParseHash(value string) (crypto.Hash, error) {
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(value), "-", ""))
+	if name == "" {
+		return crypto.SHA256, nil
+	}
+	for hash, n := range requestHashes {
+		if n == name {
+			return hash, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported timestamp digest algorithm %q (must be one of sha256, sha384, or sha512)", value)
+}
+
+// ParseServers splits a comma separated list of timestamp server URLs (ignoring empty entries).
+func ParseServers(value string) []string {
+	var servers []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+// Client requests timestamp tokens, failing over between the configured servers. Clients are cheap to create: the
+// default roots and the embedded intermediates are only loaded once, and clients with the same TLS configuration share
+// their keep-alive connections to the servers (see sharedTransport).
+type Client struct {
+	config        Config
+	intermediates *x509.CertPool
+	http          *http.Client
+	sleep         func(time.Duration)
+}
+
+// NewClient creates a client for the given configuration.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	switch {
+	case cfg.Retries == 0:
+		cfg.Retries = DefaultRetries
+	case cfg.Retries < 0:
+		cfg.Retries = 0
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	if cfg.Hash == 0 {
+		cfg.Hash = crypto.SHA256
+	}
+	roots, intermediates := sharedPools()
+	if cfg.Roots == nil {
+		cfg.Roots = roots
+	}
+	return &Client{
+		config:        cfg,
+		intermediates: intermediates,
+		http:          newHTTPClient(cfg),
+		sleep:         time.Sleep,
+	}
+}
+
+func newHTTPClient(cfg Config) *http.Client {
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: sharedTransport(cfg.TLS),
+	}
+}
+
+// maxIdleConnsPerServer is the number of keep-alive connections kept to each timestamp server (enough for a few
+// concurrent signatures).
+const maxIdleConnsPerServer = 8
+
+var (
+	transportsLock sync.Mutex
+	transports     = map[*tls.Config]*http.Transport{}
+)
+
+// sharedTransport returns the transport for the given TLS configuration (nil for the defaults), which is shared by
+// every client of the process so a batch signing run reuses its connections to the timestamp servers instead of
+// dialing (and handshaking) for every signature.
+func sharedTransport(cfg *tls.Config) *http.Transport {
+	transportsLock.Lock()
+	defer transportsLock.Unlock()
+
+	if t, ok := transports[cfg]; ok {
+		return t
+	}
+	t := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     cfg,
+		MaxIdleConnsPerHost: maxIdleConnsPerServer,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	transports[cfg] = t
+	return t
+}
+
+// Token returns a timestamp token (a CMS ContentInfo wrapping a TSTInfo) over the given data, digested with the
+// configured hash. Each server is tried in order until one returns a valid token, retrying (with exponential backoff)
+// only on transient failures. A server rejecting the request (see RejectedError) is not retried.
+func (c *Client) Token(data []byte) (protocol.ContentInfo, error) {
+	if !c.config.Enabled() {
+		return protocol.ContentInfo{}, fmt.Errorf("no timestamp servers configured")
+	}
+
+	req, err := newRequest(data, c.config.Hash)
+	if err != nil {
+		return protocol.ContentInfo{}, err
+	}
+
+	var failures []error
+	for _, url := range c.config.Servers {
+		lifecycle.Publish(lifecycle.Event{Type: lifecycle.TimestampStarted, Detail: url})
+
+		token, err := c.requestWithRetries(url, req)
+		lifecycle.Publish(lifecycle.Event{Type: lifecycle.TimestampFinished, Detail: url, Err: err})
+		if err == nil {
+			log.WithFields("url", url).Debug("received timestamp token")
+			return token, nil
+		}
+
+		log.WithFields("url", url, "error", err).Warn("timestamp request failed")
+		failures = append(failures, fmt.Errorf("%s: %w", url, err))
+	}
+
+	return protocol.ContentInfo{}, remediation.Wrap(&serversError{errs: failures}, remediation.TimestampUnavailable,
+		"retry later or add fallback servers with --timestamp-server (a comma separated list), or pass --timestamp-server \"\" to sign without a secure timestamp (such binaries cannot be notarized)",
+		"")
+}
+
+func (c *Client) requestWithRetries(url string, req timestamp.Request) (protocol.ContentInfo, error) {
+	backoff := c.config.Backoff
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		token, err := c.request(url, req)
+		metrics.ObserveSince(metrics.TimestampLatency, start, metrics.Labels{"server": url, "result": metrics.Result(err)})
+		if err == nil || !IsTransient(err) || attempt >= c.config.Retries {
+			return token, err
+		}
+
+		log.WithFields("url", url, "attempt", attempt+1, "backoff", backoff, "error", err).Debug("retrying timestamp request")
+		c.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func newRequest(data []byte, hash crypto.Hash) (timestamp.Request, error) {
+	if _, ok := requestHashes[hash]; !ok {
+		return timestamp.Request{}, fmt.Errorf("unsupported timestamp digest algorithm %s", hash)
+	}
+
+	mi, err := timestamp.NewMessageImprint(hash, bytes.NewReader(data))
+	if err != nil {
+		return timestamp.Request{}, fmt.Errorf("unable to create message imprint: %w", err)
+	}
+
+	return timestamp.Request{
+		Version: 1,
+		// the TSA certificate is always requested since it is needed to verify the token (both by quill before
+		// embedding and by macOS when validating the signature)
+		CertReq:        true,
+		MessageImprint: mi,
+	}, nil
+}
+
+func (c *Client) request(url string, req timestamp.Request) (protocol.ContentInfo, error) {
+	var nilToken protocol.ContentInfo
+
+	if err := network.Allow(network.Timestamp, url); err != nil {
+		return nilToken, err
+	}
+
+	if !c.config.DisableNonce {
+		// a fresh nonce for every attempt, so a token can only be accepted as the answer to this exact request
+		req.Nonce = timestamp.GenerateNonce()
+	}
+
+	reqDER, err := asn1.Marshal(req)
+	if err != nil {
+		return nilToken, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(reqDER))
+	if err != nil {
+		return nilToken, err
+	}
+	httpReq.Header.Set("Content-Type", contentTypeQuery)
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		// connection failures and timeouts
+		return nilToken, &TransientError{Err: err}
+	}
+	defer func() {
+		// drain what is left of the body, so the connection can be reused for the next request
+		_, _ = io.Copy(io.Discard, io.LimitReader(httpResp.Body, 1<<20))
+		httpResp.Body.Close()
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status %d", httpResp.StatusCode)
+		if httpResp.StatusCode >= 500 || httpResp.StatusCode == http.StatusTooManyRequests {
+			return nilToken, &TransientError{Err: err}
+		}
+		return nilToken, err
+	}
+	if ct := httpResp.Header.Get("Content-Type"); ct != contentTypeReply {
+		return nilToken, fmt.Errorf("unexpected content-type %q", ct)
+	}
+
+	// timestamp responses are small, anything beyond this is not a timestamp response
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nilToken, &TransientError{Err: err}
+	}
+
+	resp, err := timestamp.ParseResponse(body)
+	if err != nil {
+		return nilToken, fmt.Errorf("unable to parse timestamp response: %w", err)
+	}
+
+	if resp.Status.GetError() != nil {
+		return nilToken, newRejectedError(resp.Status)
+	}
+
+	if err := c.verifyToken(req, resp.TimeStampToken); err != nil {
+		return nilToken, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+
+	return resp.TimeStampToken, nil
+}
+
+// AddToCMS requests a timestamp token over the signature of every signer within the given DER encoded CMS
+// SignedData, returning the re-encoded CMS with the tokens attached as unsigned attributes. No tokens are attached
+// unless a token could be fetched for every signer.
+func (c *Client) AddToCMS(der []byte) ([]byte, error) {
+	ci, err := protocol.ParseContentInfo(der)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CMS: %w", err)
+	}
+
+	sd, err := ci.SignedDataContent()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CMS signed data: %w", err)
+	}
+
+	attrs := make([]protocol.Attribute, len(sd.SignerInfos))
+	for i, si := range sd.SignerInfos {
+		token, err := c.Token(si.Signature)
+		if err != nil {
+			return nil, err
+		}
+
+		if attrs[i], err = protocol.NewAttribute(oid.AttributeTimeStampToken, token); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range attrs {
+		sd.SignerInfos[i].UnsignedAttrs = append(sd.SignerInfos[i].UnsignedAttrs, attrs[i])
+	}
+
+	return sd.ContentInfoDER()
+}
+
+// TokenTime returns the time of the timestamp token attached to the given signer, false is returned when the signer
+// is not timestamped.
+func TokenTime(si protocol.SignerInfo) (time.Time, bool, error) {
+	if !si.UnsignedAttrs.HasAttribute(oid.AttributeTimeStampToken) {
+		return time.Time{}, false, nil
+	}
+
+	rv, err := si.UnsignedAttrs.GetOnlyAttributeValueBytes(oid.AttributeTimeStampToken)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	ci, err := protocol.ParseContentInfo(rv.FullBytes)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to parse timestamp token: %w", err)
+	}
+
+	sd, err := ci.SignedDataContent()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to parse timestamp token signed data: %w", err)
+	}
+
+	info, err := timestamp.ParseInfo(sd.EncapContentInfo)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to parse timestamp token info: %w", err)
+	}
+	return info.GenTime, true, nil
+}
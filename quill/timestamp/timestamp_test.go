@@ -0,0 +1,360 @@
+package timestamp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/metrics"
+	"github.com/anchore/quill/quill/network"
+	"github.com/anchore/quill/quill/pki/testca"
+	"github.com/anchore/quill/quill/remediation"
+)
+
+func newTestTSA(t *testing.T) (*testca.Fixture, *testca.TSA, *httptest.Server) {
+	t.Helper()
+
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	tsa, err := fixture.NewTSA()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(tsa)
+	t.Cleanup(server.Close)
+
+	return fixture, tsa, server
+}
+
+func TestParseServers(t *testing.T) {
+	assert.Nil(t, ParseServers(""))
+	assert.Equal(t, []string{"http://a", "http://b"}, ParseServers(" http://a, ,http://b "))
+}
+
+func TestParseHash(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    crypto.Hash
+		wantErr require.ErrorAssertionFunc
+	}{
+		{value: "", want: crypto.SHA256},
+		{value: "sha256", want: crypto.SHA256},
+		{value: "SHA-384", want: crypto.SHA384},
+		{value: "sha512", want: crypto.SHA512},
+		{value: "sha1", wantErr: require.Error},
+		{value: "md5", wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			got, err := ParseHash(tt.value)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_Token_hash(t *testing.T) {
+	fixture, _, server := newTestTSA(t)
+
+	tests := []struct {
+		name    string
+		hash    crypto.Hash
+		want    crypto.Hash
+		wantErr require.ErrorAssertionFunc
+	}{
+		{name: "default", want: crypto.SHA256},
+		{name: "sha384", hash: crypto.SHA384, want: crypto.SHA384},
+		{name: "sha512", hash: crypto.SHA512, want: crypto.SHA512},
+		{name: "sha1 is not allowed", hash: crypto.SHA1, wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			token, err := NewClient(Config{Servers: []string{server.URL}, Hash: tt.hash, Roots: fixture.Roots()}).Token([]byte("signature"))
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			sd, err := token.SignedDataContent()
+			require.NoError(t, err)
+			info, err := timestamp.ParseInfo(sd.EncapContentInfo)
+			require.NoError(t, err)
+			got, err := info.MessageImprint.Hash()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_Token_reusesConnections(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+	tsa, err := fixture.NewTSA()
+	require.NoError(t, err)
+
+	var dialed int32
+	server := httptest.NewUnstartedServer(tsa)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&dialed, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	// every signature of a batch creates its own client
+	for i := 0; i < 3; i++ {
+		_, err := NewClient(Config{Servers: []string{server.URL}, Roots: fixture.Roots()}).Token([]byte("signature"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dialed))
+}
+
+func TestClient_Token_failover(t *testing.T) {
+	fixture, tsa, server := newTestTSA(t)
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(broken.Close)
+
+	tests := []struct {
+		name    string
+		servers []string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "single server",
+			servers: []string{server.URL},
+		},
+		{
+			name:    "falls back to the next server",
+			servers: []string{broken.URL, "http://127.0.0.1:1", server.URL},
+		},
+		{
+			name:    "all servers fail",
+			servers: []string{broken.URL, "http://127.0.0.1:1"},
+			wantErr: require.Error,
+		},
+		{
+			name:    "no servers",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			token, err := NewClient(Config{Servers: tt.servers, Retries: -1, Roots: fixture.Roots()}).Token([]byte("signature"))
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+
+			sd, err := token.SignedDataContent()
+			require.NoError(t, err)
+			assert.True(t, sd.EncapContentInfo.EContentType.Equal(oid.ContentTypeTSTInfo))
+		})
+	}
+
+	assert.Equal(t, 2, tsa.Requests())
+}
+
+func TestClient_AddToCMS(t *testing.T) {
+	fixture, tsa, server := newTestTSA(t)
+
+	// note: the fixture leaf has the (critical) Developer ID extension, which the CMS verification does not handle
+	sd, err := cms.NewSignedData([]byte("code directory"))
+	require.NoError(t, err)
+	require.NoError(t, sd.Sign([]*x509.Certificate{tsa.Certificate, fixture.Intermediate}, tsa.Key))
+	sd.Detached()
+
+	der, err := sd.ToDER()
+	require.NoError(t, err)
+
+	stamped, err := NewClient(Config{Servers: []string{server.URL}, Roots: fixture.Roots()}).AddToCMS(der)
+	require.NoError(t, err)
+
+	ci, err := protocol.ParseContentInfo(stamped)
+	require.NoError(t, err)
+	psd, err := ci.SignedDataContent()
+	require.NoError(t, err)
+	require.Len(t, psd.SignerInfos, 1)
+	assert.True(t, psd.SignerInfos[0].UnsignedAttrs.HasAttribute(oid.AttributeTimeStampToken))
+
+	// the signature must remain verifiable after re-encoding
+	parsed, err := cms.ParseSignedData(stamped)
+	require.NoError(t, err)
+	_, err = parsed.VerifyDetached([]byte("code directory"), x509.VerifyOptions{
+		Roots:     fixture.Roots(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	require.NoError(t, err)
+}
+
+func TestClient_Token_retries(t *testing.T) {
+	fixture, tsa, _ := newTestTSA(t)
+
+	tests := []struct {
+		name          string
+		failures      int
+		failureStatus int
+		rejectStatus  int
+		retries       int
+		wantAttempts  int
+		wantBackoffs  []time.Duration
+		wantErr       require.ErrorAssertionFunc
+		wantTransient bool
+		wantRejected  bool
+	}{
+		{
+			name:          "recovers after transient failures",
+			failures:      2,
+			failureStatus: http.StatusServiceUnavailable,
+			retries:       2,
+			wantAttempts:  3,
+			wantBackoffs:  []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:          "gives up after the configured retries",
+			failures:      5,
+			failureStatus: http.StatusBadGateway,
+			retries:       1,
+			wantAttempts:  2,
+			wantBackoffs:  []time.Duration{time.Second},
+			wantErr:       require.Error,
+			wantTransient: true,
+		},
+		{
+			name:          "retries disabled",
+			failures:      1,
+			failureStatus: http.StatusServiceUnavailable,
+			retries:       -1,
+			wantAttempts:  1,
+			wantErr:       require.Error,
+			wantTransient: true,
+		},
+		{
+			name:          "client errors are not retried",
+			failures:      1,
+			failureStatus: http.StatusBadRequest,
+			retries:       2,
+			wantAttempts:  1,
+			wantErr:       require.Error,
+		},
+		{
+			name:         "rejections are not retried",
+			rejectStatus: 2,
+			retries:      2,
+			wantAttempts: 1,
+			wantErr:      require.Error,
+			wantRejected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			tsa.Status = tt.rejectStatus
+			defer func() { tsa.Status = 0 }()
+
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts <= tt.failures {
+					w.WriteHeader(tt.failureStatus)
+					return
+				}
+				tsa.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			var backoffs []time.Duration
+			client := NewClient(Config{Servers: []string{server.URL}, Retries: tt.retries, Roots: fixture.Roots()})
+			client.sleep = func(d time.Duration) {
+				backoffs = append(backoffs, d)
+			}
+
+			_, err := client.Token([]byte("signature"))
+			tt.wantErr(t, err)
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantBackoffs, backoffs)
+			assert.Equal(t, tt.wantTransient, IsTransient(err))
+			assert.Equal(t, tt.wantRejected, IsRejected(err))
+		})
+	}
+}
+
+func TestClient_Token_offline(t *testing.T) {
+	fixture, tsa, server := newTestTSA(t)
+
+	network.SetOffline(true)
+	t.Cleanup(func() { network.SetOffline(false) })
+
+	_, err := NewClient(Config{Servers: []string{server.URL}, Roots: fixture.Roots()}).Token([]byte("signature"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, network.ErrOffline))
+	assert.Zero(t, tsa.Requests())
+}
+
+type latencyRecorder struct {
+	results []string
+}
+
+func (r *latencyRecorder) Count(metrics.Metric, float64, metrics.Labels) {}
+
+func (r *latencyRecorder) Observe(metric metrics.Metric, _ float64, labels metrics.Labels) {
+	if metric == metrics.TimestampLatency {
+		r.results = append(r.results, labels["result"])
+	}
+}
+
+func TestClient_Token_metrics(t *testing.T) {
+	fixture, _, server := newTestTSA(t)
+
+	r := &latencyRecorder{}
+	metrics.Set(r)
+	defer metrics.Set(nil)
+
+	_, err := NewClient(Config{Servers: []string{"http://127.0.0.1:1", server.URL}, Retries: -1, Roots: fixture.Roots()}).Token([]byte("signature"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{metrics.ResultFailure, metrics.ResultSuccess}, r.results)
+}
+
+func TestClient_Token_remediation(t *testing.T) {
+	_, err := NewClient(Config{Servers: []string{"http://127.0.0.1:1"}, Retries: -1}).Token([]byte("signature"))
+	require.Error(t, err)
+
+	r, ok := remediation.Get(err)
+	require.True(t, ok)
+	assert.Equal(t, remediation.TimestampUnavailable, r.Code)
+	assert.True(t, IsTransient(err))
+}
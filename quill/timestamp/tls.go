@@ -0,0 +1,58 @@
+package timestamp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/anchore/quill/quill/pki/load"
+)
+
+// TLSConfig creates the TLS configuration for a private timestamp authority from the given PEM encoded client
+// certificate and key (for mutual TLS, the key may be within the certificate file) and additional CA certificates
+// trusted for the server certificate (on top of the system roots). Each value may be a path or any other source
+// supported by load.BytesFromFileOrEnv, empty values are ignored.
+func TLSConfig(clientCertificate, clientKey string, cas []*x509.Certificate) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(cas) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, ca := range cas {
+			pool.AddCert(ca)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if clientCertificate == "" {
+		if clientKey != "" {
+			return nil, fmt.Errorf("a timestamp client key was given without a client certificate")
+		}
+		return cfg, nil
+	}
+
+	certPEM, err := load.BytesFromFileOrEnv(clientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read timestamp client certificate: %w", err)
+	}
+
+	keyPEM := certPEM
+	if clientKey != "" {
+		keyPEM, err = load.BytesFromFileOrEnv(clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read timestamp client key: %w", err)
+		}
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load timestamp client certificate: %w", err)
+	}
+	cfg.Certificates = []tls.Certificate{pair}
+
+	return cfg, nil
+}
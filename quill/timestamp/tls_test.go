@@ -0,0 +1,151 @@
+package timestamp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) string {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+func newClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "quill test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "quill test client"},
+	}, key.Public(), key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, writePEM(t, filepath.Join(dir, "client.crt"), "CERTIFICATE", der), writePEM(t, filepath.Join(dir, "client.key"), "PRIVATE KEY", keyDER)
+}
+
+func TestClient_Token_mutualTLS(t *testing.T) {
+	fixture, tsa, _ := newTestTSA(t)
+	dir := t.TempDir()
+
+	clientCert, certPath, keyPath := newClientCertificate(t, dir)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(tsa)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		cas     []*x509.Certificate
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "client certificate and CA bundle",
+			cert: certPath,
+			key:  keyPath,
+			cas:  []*x509.Certificate{server.Certificate()},
+		},
+		{
+			name:    "missing client certificate",
+			cas:     []*x509.Certificate{server.Certificate()},
+			wantErr: require.Error,
+		},
+		{
+			name:    "untrusted server certificate",
+			cert:    certPath,
+			key:     keyPath,
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			tlsCfg, err := TLSConfig(tt.cert, tt.key, tt.cas)
+			require.NoError(t, err)
+
+			client := NewClient(Config{Servers: []string{server.URL}, Retries: -1, Roots: fixture.Roots(), TLS: tlsCfg})
+			_, err = client.Token([]byte("signature"))
+			tt.wantErr(t, err)
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	_, certPath, keyPath := newClientCertificate(t, dir)
+
+	combined := filepath.Join(dir, "combined.pem")
+	certPEM, err := os.ReadFile(certPath)
+	require.NoError(t, err)
+	keyPEM, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(combined, append(certPEM, keyPEM...), 0600))
+
+	tests := []struct {
+		name      string
+		cert      string
+		key       string
+		wantPairs int
+		wantErr   require.ErrorAssertionFunc
+	}{
+		{name: "no client certificate"},
+		{name: "separate key", cert: certPath, key: keyPath, wantPairs: 1},
+		{name: "combined certificate and key", cert: combined, wantPairs: 1},
+		{name: "key without certificate", key: keyPath, wantErr: require.Error},
+		{name: "certificate without key", cert: certPath, wantErr: require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			cfg, err := TLSConfig(tt.cert, tt.key, nil)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Len(t, cfg.Certificates, tt.wantPairs)
+		})
+	}
+}
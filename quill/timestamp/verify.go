@@ -0,0 +1,166 @@
+package timestamp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"sync"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+
+	"github.com/anchore/quill/internal/log"
+	"github.com/anchore/quill/quill/pki/apple"
+)
+
+// weakHashes are digest algorithms that are not accepted within timestamp tokens
+var weakHashes = map[crypto.Hash]string{
+	crypto.MD5:  "MD5",
+	crypto.SHA1: "SHA-1",
+}
+
+// verifyToken checks a timestamp token before it is embedded into a signature: the token must be over the requested
+// message imprint (with the requested nonce, if any), must not use weak digest algorithms, and must be signed by a timestamping certificate that chains
+// to one of the configured roots.
+func (c *Client) verifyToken(req timestamp.Request, token protocol.ContentInfo) error {
+	sd, err := token.SignedDataContent()
+	if err != nil {
+		return fmt.Errorf("unable to parse token signed data: %w", err)
+	}
+
+	info, err := timestamp.ParseInfo(sd.EncapContentInfo)
+	if err != nil {
+		return fmt.Errorf("unable to parse token info: %w", err)
+	}
+
+	if info.Version != 1 {
+		return fmt.Errorf("unsupported token version %d", info.Version)
+	}
+
+	if !req.MessageImprint.Equal(info.MessageImprint) {
+		return fmt.Errorf("mismatched message imprint")
+	}
+
+	if req.Nonce != nil {
+		switch {
+		case info.Nonce == nil:
+			return fmt.Errorf("token is missing the requested nonce (the timestamp server may not support nonces)")
+		case req.Nonce.Cmp(info.Nonce) != 0:
+			return fmt.Errorf("mismatched nonce (possibly a replayed token)")
+		}
+	}
+
+	if err := checkDigests(info, sd); err != nil {
+		return err
+	}
+
+	der, err := asn1.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	tst, err := cms.ParseSignedData(der)
+	if err != nil {
+		return fmt.Errorf("unable to parse token: %w", err)
+	}
+
+	// the token is not required to carry the intermediates (e.g. the Apple Timestamp CA)
+	if _, err := tst.Verify(x509.VerifyOptions{
+		Roots:         c.config.Roots,
+		Intermediates: c.intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return fmt.Errorf("unable to verify token signature: %w", err)
+	}
+
+	return nil
+}
+
+func checkDigests(info timestamp.Info, sd *protocol.SignedData) error {
+	hash, err := info.MessageImprint.Hash()
+	if err != nil {
+		return fmt.Errorf("unsupported message imprint digest algorithm %s", info.MessageImprint.HashAlgorithm.Algorithm)
+	}
+	if name, weak := weakHashes[hash]; weak {
+		return fmt.Errorf("message imprint uses the weak digest algorithm %s", name)
+	}
+
+	if len(sd.SignerInfos) == 0 {
+		return fmt.Errorf("token has no signers")
+	}
+
+	for _, si := range sd.SignerInfos {
+		hash, err := si.Hash()
+		if err != nil {
+			return fmt.Errorf("unsupported token digest algorithm %s", si.DigestAlgorithm.Algorithm)
+		}
+		if name, weak := weakHashes[hash]; weak {
+			return fmt.Errorf("token is signed using the weak digest algorithm %s", name)
+		}
+
+		switch si.X509SignatureAlgorithm() {
+		case x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1, x509.MD5WithRSA:
+			return fmt.Errorf("token is signed using the weak signature algorithm %s", si.X509SignatureAlgorithm())
+		}
+	}
+	return nil
+}
+
+// DefaultRoots returns a new pool of the trust anchors used for timestamp authority certificates when none are
+// configured: the system roots and the Apple roots of the current trust store (see apple.GetTrustStore), only the
+// latter when the trust store is exclusive (see apple.SetExclusiveTrust).
+func DefaultRoots() *x509.CertPool {
+	if apple.ExclusiveTrust() {
+		pool := x509.NewCertPool()
+		for _, r := range apple.GetTrustStore().Roots() {
+			pool.AddCert(r)
+		}
+		return pool
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		log.WithFields("error", err).Debug("unable to load system roots for timestamp verification")
+		pool = x509.NewCertPool()
+	}
+
+	for _, r := range apple.GetTrustStore().Roots() {
+		pool.AddCert(r)
+	}
+	return pool
+}
+
+// trustSettings identifies the trust settings the shared pools were loaded for.
+type trustSettings struct {
+	store     *apple.TrustStore
+	exclusive bool
+}
+
+var (
+	sharedPoolsLock     sync.Mutex
+	sharedPoolsSettings trustSettings
+	sharedRoots         *x509.CertPool
+	sharedIntermediates *x509.CertPool
+)
+
+// sharedPools returns the default roots (see DefaultRoots) and the Apple intermediates of the current trust store,
+// which are only loaded once per trust store and shared by every client (they are never modified).
+func sharedPools() (roots, intermediates *x509.CertPool) {
+	sharedPoolsLock.Lock()
+	defer sharedPoolsLock.Unlock()
+
+	store := apple.GetTrustStore()
+	settings := trustSettings{store: store, exclusive: apple.ExclusiveTrust()}
+	if settings != sharedPoolsSettings || sharedRoots == nil {
+		sharedPoolsSettings = settings
+		sharedRoots = DefaultRoots()
+		sharedIntermediates = x509.NewCertPool()
+		for _, cert := range store.Intermediates() {
+			sharedIntermediates.AddCert(cert)
+		}
+	}
+	return sharedRoots, sharedIntermediates
+}
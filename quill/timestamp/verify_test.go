@@ -0,0 +1,183 @@
+package timestamp
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	"github.com/github/smimesign/ietf-cms/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/apple"
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestClient_verifyToken(t *testing.T) {
+	fixture, tsa, server := newTestTSA(t)
+
+	untrusted, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		tamperImprint bool
+		dropNonce     bool
+		tamperNonce   bool
+		disableNonce  bool
+		roots         *testca.Fixture
+		wantErr       require.ErrorAssertionFunc
+	}{
+		{
+			name:  "valid token",
+			roots: fixture,
+		},
+		{
+			name:    "untrusted TSA",
+			roots:   untrusted,
+			wantErr: require.Error,
+		},
+		{
+			name:          "mismatched imprint",
+			tamperImprint: true,
+			roots:         fixture,
+			wantErr:       require.Error,
+		},
+		{
+			name:        "mismatched nonce",
+			tamperNonce: true,
+			roots:       fixture,
+			wantErr:     require.Error,
+		},
+		{
+			name:      "missing nonce",
+			dropNonce: true,
+			roots:     fixture,
+			wantErr:   require.Error,
+		},
+		{
+			name:         "missing nonce with nonces disabled",
+			dropNonce:    true,
+			disableNonce: true,
+			roots:        fixture,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+
+			tsa.TamperImprint = tt.tamperImprint
+			tsa.DropNonce = tt.dropNonce
+			tsa.TamperNonce = tt.tamperNonce
+			defer func() { tsa.TamperImprint, tsa.DropNonce, tsa.TamperNonce = false, false, false }()
+
+			client := NewClient(Config{Servers: []string{server.URL}, Retries: -1, DisableNonce: tt.disableNonce, Roots: tt.roots.Roots()})
+			_, err := client.Token([]byte("signature"))
+			tt.wantErr(t, err)
+			if err != nil {
+				assert.False(t, IsTransient(err))
+			}
+		})
+	}
+}
+
+func Test_checkDigests(t *testing.T) {
+	imprint := func(alg pkix.AlgorithmIdentifier) timestamp.Info {
+		return timestamp.Info{MessageImprint: timestamp.MessageImprint{HashAlgorithm: alg}}
+	}
+	signedWith := func(digest, signature pkix.AlgorithmIdentifier) *protocol.SignedData {
+		return &protocol.SignedData{
+			SignerInfos: []protocol.SignerInfo{{DigestAlgorithm: digest, SignatureAlgorithm: signature}},
+		}
+	}
+
+	sha1 := pkix.AlgorithmIdentifier{Algorithm: oid.DigestAlgorithmSHA1}
+	sha256 := pkix.AlgorithmIdentifier{Algorithm: oid.DigestAlgorithmSHA256}
+	rsa := pkix.AlgorithmIdentifier{Algorithm: oid.PublicKeyAlgorithmRSA}
+	sha1WithRSA := pkix.AlgorithmIdentifier{Algorithm: oid.SignatureAlgorithmSHA1WithRSA}
+
+	tests := []struct {
+		name    string
+		info    timestamp.Info
+		sd      *protocol.SignedData
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "sha256",
+			info: imprint(sha256),
+			sd:   signedWith(sha256, rsa),
+		},
+		{
+			name:    "weak imprint",
+			info:    imprint(sha1),
+			sd:      signedWith(sha256, rsa),
+			wantErr: require.Error,
+		},
+		{
+			name:    "weak signer digest",
+			info:    imprint(sha256),
+			sd:      signedWith(sha1, rsa),
+			wantErr: require.Error,
+		},
+		{
+			name:    "weak signature algorithm",
+			info:    imprint(sha256),
+			sd:      signedWith(sha256, sha1WithRSA),
+			wantErr: require.Error,
+		},
+		{
+			name:    "no signers",
+			info:    imprint(sha256),
+			sd:      &protocol.SignedData{},
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			tt.wantErr(t, checkDigests(tt.info, tt.sd))
+		})
+	}
+}
+
+func TestSharedPools_trustStore(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	// ignore the Developer ID extensions of the leaf
+	leaf := *fixture.Leaf
+	leaf.UnhandledCriticalExtensions = nil
+
+	verifyLeaf := func() error {
+		roots, intermediates := sharedPools()
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		return err
+	}
+
+	require.Error(t, verifyLeaf(), "the fixture is not trusted by default")
+
+	// a curated bundle holding the fixture root and intermediate, which is the only source of trust
+	store, err := apple.ParseTrustStore(fixture.CertificatesPEM(), "fixture")
+	require.NoError(t, err)
+	apple.SetTrustStore(store)
+	apple.SetExclusiveTrust(true)
+	t.Cleanup(func() {
+		apple.SetTrustStore(nil)
+		apple.SetExclusiveTrust(false)
+	})
+
+	require.NoError(t, verifyLeaf(), "the pools follow the current trust store")
+
+	apple.SetTrustStore(nil)
+	require.Error(t, verifyLeaf())
+}
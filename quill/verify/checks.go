@@ -0,0 +1,406 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/github/smimesign/ietf-cms/oid"
+	"github.com/github/smimesign/ietf-cms/protocol"
+	cmsTimestamp "github.com/github/smimesign/ietf-cms/timestamp"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/pki/apple"
+)
+
+// specialSlots are the special slots which may be bound by a code directory, along with whether their content lives
+// outside of the binary (e.g. the Info.plist of a bundle).
+var specialSlots = []struct {
+	slot     macho.SlotType
+	name     string
+	external bool
+}{
+	{macho.CsSlotInfoslot, "info plist", true},
+	{macho.CsSlotRequirements, "requirements", false},
+	{macho.CsSlotResourcedir, "resource directory", true},
+	{macho.CsSlotApplication, "application", true},
+	{macho.CsSlotEntitlements, "entitlements", false},
+	{macho.CsSlotRepSpecific, "rep specific", true},
+	{macho.CsSlotEntitlementsDer, "entitlements (DER)", false},
+}
+
+var (
+	oidDeveloperIDApplication = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 13}
+	oidDeveloperIDInstaller   = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 14}
+)
+
+func (s signature) checkCodeDirectory(cd *codeDirectory) Check {
+	name := fmt.Sprintf("code directory (%s)", hashName(cd.HashType))
+	message := fmt.Sprintf("identifier %q", cd.identifier())
+
+	_, newHash, err := cd.hash()
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Message: err.Error()}
+	}
+	if size := newHash().Size(); cd.HashSize == 0 || int(cd.HashSize) > size {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("invalid hash size %d (the %s digest is %d bytes)", cd.HashSize, hashName(cd.HashType), size)}
+	}
+
+	return group(name, message, append([]Check{s.checkPages(cd)}, s.checkSpecialSlots(cd)...)...)
+}
+
+// checkPages hashes every page of the binary up to the code limit, comparing each with the code slots.
+func (s signature) checkPages(cd *codeDirectory) Check {
+	fail := func(format string, args ...interface{}) Check {
+		return Check{Name: "pages", Status: StatusFail, Message: fmt.Sprintf(format, args...)}
+	}
+
+	limit := cd.Limit()
+	if limit > uint64(len(s.code)) {
+		return fail("the code limit (%d bytes) exceeds the content of the binary before the signature (%d bytes)", limit, len(s.code))
+	}
+
+	var pages uint64
+	if pageSize := cd.PageBytes(); pageSize > 0 {
+		pages = (limit + pageSize - 1) / pageSize
+	}
+	if uint64(cd.NCodeSlots) != pages {
+		return fail("there are %d page hashes, but the binary has %d pages up to the code limit", cd.NCodeSlots, pages)
+	}
+
+	_, newHash, _ := cd.hash()
+	var mismatched []uint64
+	for i := uint64(0); i < pages; i++ {
+		start := i * cd.PageBytes()
+		end := start + cd.PageBytes()
+		if end > limit {
+			end = limit
+		}
+
+		want, err := cd.SlotHash(int64(i))
+		if err != nil {
+			return fail("%v", err)
+		}
+		if !bytes.Equal(hashBytes(newHash(), s.code[start:end])[:len(want)], want) {
+			mismatched = append(mismatched, start)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return fail("%d of %d page hashes do not match the binary (the first at offset 0x%x), it was changed after signing", len(mismatched), pages, mismatched[0])
+	}
+	return Check{Name: "pages", Status: StatusPass, Message: fmt.Sprintf("all %d page hashes match the binary", pages)}
+}
+
+// checkSpecialSlots compares the hash of every bound special slot with the blob of the slot.
+func (s signature) checkSpecialSlots(cd *codeDirectory) []Check {
+	_, newHash, _ := cd.hash()
+
+	var checks []Check
+	for _, special := range specialSlots {
+		c := Check{Name: fmt.Sprintf("%s slot", special.name)}
+
+		bound, err := cd.specialSlotHash(special.slot)
+		content := s.slot(special.slot)
+		switch {
+		case err != nil:
+			c.Status, c.Message = StatusFail, err.Error()
+		case bound == nil && content == nil:
+			continue
+		case bound == nil:
+			c.Status, c.Message = StatusWarn, "the blob is not bound by the code directory (it can be changed without invalidating the signature)"
+		case special.external:
+			c.Status, c.Message = StatusWarn, "the hash binds content outside of the binary (e.g. of the enclosing bundle), which is not verified"
+		case content == nil:
+			c.Status, c.Message = StatusFail, "the hash is bound by the code directory, but the blob is missing from the signature"
+		case bytes.Equal(hashBytes(newHash(), content)[:len(bound)], bound):
+			c.Status, c.Message = StatusPass, "the hash matches the blob"
+		default:
+			c.Status, c.Message = StatusFail, "the hash does not match the blob (it was changed after signing)"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkSignature verifies the CMS signature over the code directories along with the certificate chain and the
+// secure timestamp of the signer.
+func (s signature) checkSignature(cds []*codeDirectory, cfg Config) Check {
+	const name = "signature"
+	fail := func(format string, args ...interface{}) Check {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.adHoc() {
+		return Check{Name: name, Status: StatusWarn, Message: "ad-hoc signature: there is no signing identity to verify, the binary is only identified by its cdhash"}
+	}
+
+	si, certs, leaf, err := s.signer()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	timestampCheck, timestampTime := checkTimestamp(si, cfg)
+
+	return group(name, fmt.Sprintf("signed by %q", leaf.Subject.CommonName),
+		checkCMS(si, leaf, cds[0]),
+		checkCDHashes(si, cds),
+		checkChain(leaf, certs, timestampTime, cfg),
+		timestampCheck,
+	)
+}
+
+// checkCMS verifies the CMS signature is over the primary code directory and is made by the signing certificate.
+func checkCMS(si protocol.SignerInfo, leaf *x509.Certificate, cd *codeDirectory) Check {
+	const name = "CMS signature"
+	fail := func(format string, args ...interface{}) Check {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf(format, args...)}
+	}
+
+	h, err := si.Hash()
+	if err != nil {
+		return fail("unsupported digest algorithm: %v", err)
+	}
+	digest, err := si.GetMessageDigestAttribute()
+	if err != nil {
+		return fail("there is no message digest: %v", err)
+	}
+	if !bytes.Equal(digest, hashBytes(h.New(), cd.blob)) {
+		return fail("the signature is not over the code directory (the message digest does not match)")
+	}
+
+	signed, err := si.SignedAttrs.MarshaledForVerification()
+	if err != nil {
+		return fail("unable to encode the signed attributes: %v", err)
+	}
+	if err := leaf.CheckSignature(si.X509SignatureAlgorithm(), signed, si.Signature); err != nil {
+		return fail("the signature was not made by the signing certificate: %v", err)
+	}
+
+	return Check{Name: name, Status: StatusPass, Message: "the signature is valid over the code directory"}
+}
+
+// checkCDHashes verifies the signed cdhashes attribute lists every code directory, which is what binds the alternate
+// code directories to the signature.
+func checkCDHashes(si protocol.SignerInfo, cds []*codeDirectory) Check {
+	const name = "cdhashes"
+	fail := func(format string, args ...interface{}) Check {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if !si.SignedAttrs.HasAttribute(macho.OIDCDHashesPlist) {
+		if len(cds) > 1 {
+			return Check{Name: name, Status: StatusWarn, Message: "there is no signed cdhashes attribute, the alternate code directories are not bound to the signature"}
+		}
+		return Check{Name: name, Status: StatusPass, Message: "there is a single code directory (no cdhashes attribute is needed)"}
+	}
+
+	signed, err := signedCDHashes(si)
+	if err != nil {
+		return fail("unable to decode the cdhashes attribute: %v", err)
+	}
+	if len(signed) != len(cds) {
+		return fail("the signature lists %d cdhashes, but there are %d code directories", len(signed), len(cds))
+	}
+
+	for i, cd := range cds {
+		h, err := cd.cdHash()
+		if err != nil {
+			return fail("%v", err)
+		}
+		if len(h) > macho.CDHashTruncatedSize {
+			h = h[:macho.CDHashTruncatedSize]
+		}
+		if !bytes.Equal(h, signed[i]) {
+			return fail("the signed cdhash of the %s code directory does not match", hashName(cd.HashType))
+		}
+	}
+
+	return Check{Name: name, Status: StatusPass, Message: fmt.Sprintf("the cdhashes of all %d code directories are signed", len(cds))}
+}
+
+func signedCDHashes(si protocol.SignerInfo) ([][]byte, error) {
+	rv, err := si.SignedAttrs.GetOnlyAttributeValueBytes(macho.OIDCDHashesPlist)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if _, err := asn1.Unmarshal(rv.FullBytes, &raw); err != nil {
+		return nil, err
+	}
+
+	doc, err := entitlements.ParseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := doc["cdhashes"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no cdhashes array")
+	}
+
+	var hashes [][]byte
+	for _, v := range values {
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected cdhash value type: %T", v)
+		}
+		hashes = append(hashes, b)
+	}
+	return hashes, nil
+}
+
+// checkChain verifies the signing certificate chains to a trusted root at the time of signing. As Apple intends, this is
+// the time of the (valid) secure timestamp, so signatures timestamped before the signing certificate expired remain
+// valid. Without a secure timestamp the time of signing is unproven (the CMS signing time is asserted by the signer),
+// so the chain must be valid now.
+func checkChain(leaf *x509.Certificate, certs []*x509.Certificate, timestampTime *time.Time, cfg Config) Check {
+	const name = "certificate chain"
+
+	// the Developer ID marker extensions are critical, but unknown to the x509 package
+	unhandled := leaf.UnhandledCriticalExtensions[:0]
+	for _, ex := range leaf.UnhandledCriticalExtensions {
+		if !ex.Equal(oidDeveloperIDApplication) && !ex.Equal(oidDeveloperIDInstaller) {
+			unhandled = append(unhandled, ex)
+		}
+	}
+	leaf.UnhandledCriticalExtensions = unhandled
+
+	intermediates := x509.NewCertPool()
+	for _, c := range append(certs, trustedIntermediates()...) {
+		intermediates.AddCert(c)
+	}
+
+	now := time.Now()
+	validityTime := now
+	if timestampTime != nil {
+		validityTime = *timestampTime
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         cfg.roots(),
+		Intermediates: intermediates,
+		CurrentTime:   validityTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	switch {
+	case err != nil && timestampTime == nil && now.After(leaf.NotAfter):
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("the signing certificate expired on %s and there is no secure timestamp proving the binary was signed before", leaf.NotAfter.UTC().Format(time.RFC3339))}
+	case err != nil && timestampTime == nil:
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("the signing certificate is not trusted (there is no secure timestamp, so it must be valid now): %v", err)}
+	case err != nil:
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("the signing certificate is not trusted at the secure timestamp (%s): %v", validityTime.UTC().Format(time.RFC3339), err)}
+	}
+
+	chain := chains[0]
+	message := fmt.Sprintf("chains to the trusted root %q (%d certificates)", chain[len(chain)-1].Subject.CommonName, len(chain))
+	if timestampTime != nil {
+		message += fmt.Sprintf(" at the secure timestamp (%s)", validityTime.UTC().Format(time.RFC3339))
+		if now.After(leaf.NotAfter) {
+			message += fmt.Sprintf(", the signing certificate expired since (on %s)", leaf.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+	return Check{Name: name, Status: StatusPass, Message: message}
+}
+
+// checkTimestamp verifies the secure timestamp of the signer (if any) is over the signature and is made by a trusted
+// timestamp authority, the time of a valid timestamp is returned.
+func checkTimestamp(si protocol.SignerInfo, cfg Config) (Check, *time.Time) {
+	const name = "timestamp"
+	fail := func(format string, args ...interface{}) (Check, *time.Time) {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf(format, args...)}, nil
+	}
+
+	if !si.UnsignedAttrs.HasAttribute(oid.AttributeTimeStampToken) {
+		return Check{Name: name, Status: StatusWarn, Message: "there is no secure timestamp (which is required for notarization)"}, nil
+	}
+
+	rv, err := si.UnsignedAttrs.GetOnlyAttributeValueBytes(oid.AttributeTimeStampToken)
+	if err != nil {
+		return fail("unable to read the timestamp token: %v", err)
+	}
+
+	ci, err := protocol.ParseContentInfo(rv.FullBytes)
+	if err != nil {
+		return fail("unable to parse the timestamp token: %v", err)
+	}
+	sd, err := ci.SignedDataContent()
+	if err != nil {
+		return fail("unable to parse the timestamp token signed data: %v", err)
+	}
+	info, err := cmsTimestamp.ParseInfo(sd.EncapContentInfo)
+	if err != nil {
+		return fail("unable to parse the timestamp token info: %v", err)
+	}
+
+	h, err := info.MessageImprint.Hash()
+	if err != nil {
+		return fail("unsupported message imprint digest algorithm: %v", err)
+	}
+	imprint, err := cmsTimestamp.NewMessageImprint(h, bytes.NewReader(si.Signature))
+	if err != nil {
+		return fail("unable to compute the message imprint: %v", err)
+	}
+	if !imprint.Equal(info.MessageImprint) {
+		return fail("the timestamp is not over the signature (the message imprint does not match)")
+	}
+
+	token, err := cms.ParseSignedData(rv.FullBytes)
+	if err != nil {
+		return fail("unable to parse the timestamp token: %v", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range trustedIntermediates() {
+		intermediates.AddCert(c)
+	}
+	chains, err := token.Verify(x509.VerifyOptions{
+		Roots:         cfg.roots(),
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return fail("the timestamp authority is not trusted: %v", err)
+	}
+
+	authority := "an unknown authority"
+	if len(chains) > 0 && len(chains[0]) > 0 && len(chains[0][0]) > 0 {
+		authority = fmt.Sprintf("%q", chains[0][0][0].Subject.CommonName)
+	}
+
+	genTime := info.GenTime
+	return Check{Name: name, Status: StatusPass, Message: fmt.Sprintf("timestamped at %s by %s", genTime.UTC().Format(time.RFC3339), authority)}, &genTime
+}
+
+// checkRequirements decodes the requirements, showing the designated requirement.
+func (s signature) checkRequirements() Check {
+	const name = "requirements"
+
+	b := s.slot(macho.CsSlotRequirements)
+	if b == nil {
+		return Check{Name: name, Status: StatusWarn, Message: "there are no requirements"}
+	}
+
+	statements, err := macho.DecodeRequirementSet(b)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("unable to decode the requirements: %v", err)}
+	}
+
+	for _, r := range statements {
+		if r.Type == macho.DesignatedRequirementType {
+			return Check{Name: name, Status: StatusPass, Message: r.String()}
+		}
+	}
+	return Check{Name: name, Status: StatusPass, Message: "there is no explicit designated requirement (one is derived from the signature)"}
+}
+
+// trustedIntermediates returns the Apple intermediates of the current trust store (see apple.GetTrustStore).
+func trustedIntermediates() []*x509.Certificate {
+	return apple.GetTrustStore().Intermediates()
+}
@@ -0,0 +1,86 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// codeDirectory is a code directory read from a signature.
+type codeDirectory struct {
+	*macho.CodeDirectory
+	// blob is the entire code directory blob (hashed into the cdhash).
+	blob []byte
+}
+
+func parseCodeDirectory(blob []byte) (*codeDirectory, error) {
+	cd, err := macho.ParseCodeDirectory(blob)
+	if err != nil {
+		return nil, err
+	}
+	return &codeDirectory{CodeDirectory: cd, blob: blob}, nil
+}
+
+// hash returns the hash function of the page and special slot hashes (which is also the one of the cdhash).
+func (cd codeDirectory) hash() (crypto.Hash, func() hash.Hash, error) {
+	switch cd.HashType {
+	case macho.HashTypeSha1:
+		return crypto.SHA1, sha1.New, nil
+	case macho.HashTypeSha256, macho.HashTypeSha256Truncated:
+		return crypto.SHA256, sha256.New, nil
+	case macho.HashTypeSha384:
+		return crypto.SHA384, sha512.New384, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported hash type: %d", cd.HashType)
+}
+
+func (cd codeDirectory) identifier() string {
+	id, _ := cd.Identifier()
+	return id
+}
+
+// specialSlotHash returns the hash of the given special slot, nil is returned when the slot is not bound by the code
+// directory.
+func (cd codeDirectory) specialSlotHash(slot macho.SlotType) ([]byte, error) {
+	h, err := cd.SpecialSlotHash(slot)
+	if err != nil || bytes.Equal(h, make([]byte, len(h))) {
+		return nil, err
+	}
+	return h, nil
+}
+
+// cdHash returns the (untruncated) hash of the entire code directory.
+func (cd codeDirectory) cdHash() ([]byte, error) {
+	_, newHash, err := cd.hash()
+	if err != nil {
+		return nil, err
+	}
+	return hashBytes(newHash(), cd.blob), nil
+}
+
+func hashBytes(h hash.Hash, by []byte) []byte {
+	h.Write(by)
+	return h.Sum(nil)
+}
+
+func hashName(t macho.HashType) string {
+	switch t {
+	case macho.HashTypeSha1:
+		return "sha1"
+	case macho.HashTypeSha256:
+		return "sha256"
+	case macho.HashTypeSha256Truncated:
+		return "sha256 (truncated)"
+	case macho.HashTypeSha384:
+		return "sha384"
+	case macho.HashTypeSha512:
+		return "sha512"
+	}
+	return fmt.Sprintf("hash type %d", t)
+}
@@ -0,0 +1,78 @@
+package verify
+
+import (
+	debugMacho "debug/macho"
+	"fmt"
+	"os"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// detachedSignature is a signature kept apart from the binary: either the embedded signature superblob of a
+// single-arch binary, or a detached signature superblob made of the embedded signature of every architecture.
+type detachedSignature struct {
+	// embedded is the superblob when the signature is not indexed by architecture.
+	embedded []byte
+	byCPU    map[debugMacho.Cpu][]byte
+}
+
+func readDetachedSignature(path string) (*detachedSignature, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read detached signature: %w", err)
+	}
+	return parseDetachedSignature(b)
+}
+
+func parseDetachedSignature(b []byte) (*detachedSignature, error) {
+	if len(b) < 12 {
+		return nil, fmt.Errorf("detached signature is too short (%d bytes)", len(b))
+	}
+
+	switch magic := macho.Magic(macho.SigningOrder.Uint32(b)); magic {
+	case macho.MagicEmbeddedSignature:
+		return &detachedSignature{embedded: b}, nil
+	case macho.MagicDetachedSignature:
+		superBlob, err := macho.ParseSuperBlob(b)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read detached signature: %w", err)
+		}
+
+		d := detachedSignature{byCPU: make(map[debugMacho.Cpu][]byte)}
+		for _, entry := range superBlob.Entries {
+			// the index type of a detached signature is the CPU type of the signed architecture
+			d.byCPU[debugMacho.Cpu(entry.Slot)] = entry.Data
+		}
+		return &d, nil
+	default:
+		return nil, fmt.Errorf("unexpected detached signature magic: 0x%x", uint32(magic))
+	}
+}
+
+// signatureFor returns the signature of the given single-arch binary found within the detached signature. The
+// signature covers the content of the binary before its embedded signature (or the whole binary if there is none).
+func (d detachedSignature) signatureFor(m *macho.File) (*signature, error) {
+	superBlob := d.embedded
+	if superBlob == nil {
+		var ok bool
+		if superBlob, ok = d.byCPU[m.Cpu]; !ok {
+			return nil, fmt.Errorf("the detached signature has no signature for %s", cpuName(m.Cpu))
+		}
+	}
+
+	size := uint64(m.Size())
+	if m.HasCodeSigningCmd() {
+		cmd, _, err := m.CodeSigningCmd()
+		if err != nil {
+			return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+		}
+		size = uint64(cmd.DataOffset)
+	}
+
+	code, err := m.ReadBytes(0, size)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read binary: %w", err)
+	}
+
+	return newSignature(code, superBlob)
+}
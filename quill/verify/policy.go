@@ -0,0 +1,89 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+)
+
+// checkIdentifier fails unless the identifier of every code directory satisfies the identifier policy of the
+// configuration (see Config.Identifier and Config.IdentifierPattern).
+func checkIdentifier(cds []*codeDirectory, cfg Config) Check {
+	const name = "identifier policy"
+
+	for _, cd := range cds {
+		id := cd.identifier()
+		if cfg.Identifier != "" && id != cfg.Identifier {
+			return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("identifier %q is not the expected %q", id, cfg.Identifier)}
+		}
+		if cfg.IdentifierPattern != nil && !cfg.IdentifierPattern.MatchString(id) {
+			return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("identifier %q does not match %q", id, cfg.IdentifierPattern.String())}
+		}
+	}
+
+	// every code directory has the same identifier (there is at least the primary code directory)
+	return Check{Name: name, Status: StatusPass, Message: fmt.Sprintf("identifier %q is allowed", cds[0].identifier())}
+}
+
+// checkTeamID fails unless the team identifier of every code directory and the team of the signing certificate are the
+// team of the configuration (see Config.TeamID).
+func (s signature) checkTeamID(cds []*codeDirectory, cfg Config) Check {
+	const name = "team ID policy"
+	fail := func(format string, args ...interface{}) Check {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for _, cd := range cds {
+		team, err := cd.TeamID()
+		if err != nil {
+			return fail("unable to read the team identifier of the code directory: %v", err)
+		}
+		if team != cfg.TeamID {
+			return fail("the code directory team ID %q is not the expected %q", team, cfg.TeamID)
+		}
+	}
+
+	if s.adHoc() {
+		return fail("ad-hoc signature: there is no signing certificate of team %q", cfg.TeamID)
+	}
+	_, _, leaf, err := s.signer()
+	if err != nil {
+		return fail("%v", err)
+	}
+	// Apple places the team ID within the organizational unit of Developer ID certificates
+	if ou := leaf.Subject.OrganizationalUnit; len(ou) == 0 || ou[0] != cfg.TeamID {
+		return fail("the signing certificate team (OU=%q) is not the expected %q", strings.Join(ou, ", "), cfg.TeamID)
+	}
+
+	return Check{Name: name, Status: StatusPass, Message: fmt.Sprintf("the code directory and the signing certificate are of team %q", cfg.TeamID)}
+}
+
+// checkProvisioningProfile fails unless the provisioning profile of the configuration authorizes the signature: the
+// identifier, the team, and the signed entitlements (see provisioning.Profile.Inconsistencies).
+func (s signature) checkProvisioningProfile(cds []*codeDirectory, cfg Config) Check {
+	const name = "provisioning profile"
+	profile := cfg.ProvisioningProfile
+
+	var ents entitlements.Entitlements
+	if b := s.slot(macho.CsSlotEntitlements); b != nil {
+		raw, err := macho.UnwrapBlob(b, macho.MagicEmbeddedEntitlements)
+		if err != nil {
+			return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("unable to read the entitlements: %v", err)}
+		}
+		if ents, err = entitlements.ParseXML(raw); err != nil {
+			return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("unable to decode the entitlements: %v", err)}
+		}
+	}
+
+	team, err := cds[0].TeamID()
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("unable to read the team identifier of the code directory: %v", err)}
+	}
+
+	if issues := profile.Inconsistencies(cds[0].identifier(), team, ents); len(issues) > 0 {
+		return Check{Name: name, Status: StatusFail, Message: fmt.Sprintf("not authorized by %q: %s", profile.Name, strings.Join(issues, "; "))}
+	}
+	return Check{Name: name, Status: StatusPass, Message: fmt.Sprintf("the identifier, team, and %d entitlements are authorized by %q", len(ents), profile.Name)}
+}
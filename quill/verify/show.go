@@ -0,0 +1,79 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/list"
+	"gopkg.in/yaml.v3"
+)
+
+var icons = map[Status]string{
+	StatusPass: "✔",
+	StatusWarn: "⚠",
+	StatusFail: "✘",
+}
+
+// Show writes the report in the given format: "text" renders a tree of every check (with an icon for its status and
+// the explanation of its outcome), "json" and "yaml" encode the report itself.
+func Show(report Report, writer io.Writer, format string) error {
+	return show(report, report.Tree(), writer, format)
+}
+
+// ShowSummary writes the summary in the given format: "text" renders the tree of every report followed by the number
+// of signed, ad-hoc signed, and invalid artifacts, "json" and "yaml" encode the summary itself.
+func ShowSummary(summary Summary, writer io.Writer, format string) error {
+	var trees []string
+	for _, r := range summary.Reports {
+		trees = append(trees, r.Tree())
+	}
+	return show(summary, strings.Join(append(trees, summary.String()), "\n\n"), writer, format)
+}
+
+func show(v interface{}, text string, writer io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		enc := yaml.NewEncoder(writer)
+		defer enc.Close()
+		return enc.Encode(v)
+	case "text":
+		_, err := io.WriteString(writer, text+"\n")
+		return err
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// Tree renders every check of the report as a tree.
+func (r Report) Tree() string {
+	l := list.NewWriter()
+	l.SetStyle(list.StyleConnectedLight)
+	l.AppendItem(fmt.Sprintf("%s %s", icons[r.Status], r.Path))
+	l.Indent()
+	for _, c := range r.Checks {
+		appendCheck(l, c)
+	}
+	return l.Render()
+}
+
+func appendCheck(l list.Writer, c Check) {
+	item := fmt.Sprintf("%s %s", icons[c.Status], c.Name)
+	if c.Message != "" {
+		item += ": " + c.Message
+	}
+	l.AppendItem(item)
+
+	if len(c.Checks) == 0 {
+		return
+	}
+	l.Indent()
+	for _, child := range c.Checks {
+		appendCheck(l, child)
+	}
+	l.UnIndent()
+}
@@ -0,0 +1,169 @@
+package verify
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/github/smimesign/ietf-cms/protocol"
+
+	"github.com/anchore/quill/quill/macho"
+)
+
+// signature is the code signature of a single-arch binary along with the content of the binary it covers.
+type signature struct {
+	// code is the content of the binary covered by the page hashes (everything before the signature).
+	code  []byte
+	blobs []blob
+}
+
+// blob is a single blob of the superblob (including the blob header).
+type blob struct {
+	slot macho.SlotType
+	data []byte
+}
+
+func readSignature(m *macho.File) (*signature, error) {
+	cmd, _, err := m.CodeSigningCmd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract code signing cmd: %w", err)
+	}
+
+	if cmd == nil {
+		return nil, fmt.Errorf("the binary is not signed")
+	}
+
+	// the offset and size come from the binary, which is untrusted: they are checked against the size of the binary
+	code, err := m.ReadBytes(0, uint64(cmd.DataOffset))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read binary: %w", err)
+	}
+
+	superBlob, err := m.ReadBytes(uint64(cmd.DataOffset), uint64(cmd.DataSize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the signature: %w", err)
+	}
+
+	return newSignature(code, superBlob)
+}
+
+func newSignature(code, superBlob []byte) (*signature, error) {
+	blobs, err := parseSuperBlob(superBlob)
+	if err != nil {
+		return nil, err
+	}
+	return &signature{code: code, blobs: blobs}, nil
+}
+
+// parseSuperBlob returns every blob indexed by the given superblob (in index order).
+func parseSuperBlob(b []byte) ([]blob, error) {
+	superBlob, err := macho.ParseSuperBlob(b)
+	if err != nil {
+		return nil, err
+	}
+	if superBlob.Magic != macho.MagicEmbeddedSignature {
+		return nil, fmt.Errorf("unexpected superblob magic: 0x%x", uint32(superBlob.Magic))
+	}
+
+	var blobs []blob
+	for _, entry := range superBlob.Entries {
+		blobs = append(blobs, blob{slot: entry.Slot, data: entry.Data})
+	}
+	return blobs, nil
+}
+
+// slot returns the first blob for the given slot (nil if there is none).
+func (s signature) slot(t macho.SlotType) []byte {
+	for _, b := range s.blobs {
+		if b.slot == t {
+			return b.data
+		}
+	}
+	return nil
+}
+
+// adHoc returns true if there is no CMS signature (or only an empty one).
+func (s signature) adHoc() bool {
+	return len(s.slot(macho.CsSlotCmsSignature)) <= 8
+}
+
+// codeDirectories returns the primary code directory followed by the alternate code directories.
+func (s signature) codeDirectories() ([]*codeDirectory, error) {
+	primary := s.slot(macho.CsSlotCodedirectory)
+	if primary == nil {
+		return nil, fmt.Errorf("there is no code directory")
+	}
+
+	cdBlobs := []blob{{slot: macho.CsSlotCodedirectory, data: primary}}
+	for _, b := range s.blobs {
+		if b.slot >= macho.CsSlotAlternateCodedirectories && b.slot < macho.CsSlotAlternateCodedirectoryLimit {
+			cdBlobs = append(cdBlobs, b)
+		}
+	}
+
+	var cds []*codeDirectory
+	for _, b := range cdBlobs {
+		cd, err := parseCodeDirectory(b.data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse code directory (slot=0x%x): %w", uint32(b.slot), err)
+		}
+		cds = append(cds, cd)
+	}
+	return cds, nil
+}
+
+// signer reads the (first) signer of the CMS signature, along with the certificates of the CMS signature and the
+// signing certificate among them.
+func (s signature) signer() (protocol.SignerInfo, []*x509.Certificate, *x509.Certificate, error) {
+	var si protocol.SignerInfo
+
+	cms, err := macho.UnwrapBlobWrapper(s.slot(macho.CsSlotCmsSignature))
+	if err != nil {
+		return si, nil, nil, fmt.Errorf("unable to read the CMS signature: %w", err)
+	}
+	ci, err := protocol.ParseContentInfo(cms)
+	if err != nil {
+		return si, nil, nil, fmt.Errorf("unable to parse the CMS signature: %w", err)
+	}
+	psd, err := ci.SignedDataContent()
+	if err != nil {
+		return si, nil, nil, fmt.Errorf("unable to parse the CMS signed data: %w", err)
+	}
+	if len(psd.SignerInfos) == 0 {
+		return si, nil, nil, fmt.Errorf("the CMS signature has no signers")
+	}
+	si = psd.SignerInfos[0]
+
+	certs, err := psd.X509Certificates()
+	if err != nil {
+		return si, nil, nil, fmt.Errorf("unable to parse the certificates of the CMS signature: %w", err)
+	}
+	leaf, err := si.FindCertificate(certs)
+	if err != nil {
+		return si, nil, nil, fmt.Errorf("the signing certificate is not part of the CMS signature: %w", err)
+	}
+	return si, certs, leaf, nil
+}
+
+// checks verifies every part of the signature.
+func (s signature) checks(cfg Config) []Check {
+	cds, err := s.codeDirectories()
+	if err != nil {
+		return []Check{{Name: "code directory", Status: StatusFail, Message: err.Error()}}
+	}
+
+	var checks []Check
+	for _, cd := range cds {
+		checks = append(checks, s.checkCodeDirectory(cd))
+	}
+	checks = append(checks, s.checkSignature(cds, cfg), s.checkRequirements())
+	if cfg.Identifier != "" || cfg.IdentifierPattern != nil {
+		checks = append(checks, checkIdentifier(cds, cfg))
+	}
+	if cfg.TeamID != "" {
+		checks = append(checks, s.checkTeamID(cds, cfg))
+	}
+	if cfg.ProvisioningProfile != nil {
+		checks = append(checks, s.checkProvisioningProfile(cds, cfg))
+	}
+	return checks
+}
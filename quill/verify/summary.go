@@ -0,0 +1,85 @@
+package verify
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/anchore/quill/internal/machofind"
+)
+
+// Summary is the verification of several artifacts (e.g. every binary of a release).
+type Summary struct {
+	Status Status `json:"status"`
+	// Signed is the number of artifacts with a valid signature made by a signing identity.
+	Signed int `json:"signed"`
+	// AdHoc is the number of artifacts with a valid ad-hoc signature.
+	AdHoc int `json:"adHoc"`
+	// Invalid is the number of artifacts that are unsigned or failed any check.
+	Invalid int      `json:"invalid"`
+	Reports []Report `json:"reports"`
+}
+
+// Failed returns true if any artifact is invalid (or ad-hoc signed, when they are rejected).
+func (s Summary) Failed() bool {
+	return s.Status == StatusFail
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d artifact(s): %d signed, %d ad-hoc, %d invalid", len(s.Reports), s.Signed, s.AdHoc, s.Invalid)
+}
+
+// VerifyAll verifies every given binary (or every binary within the given directories), see Verify. The summary fails
+// when any artifact is invalid, or when any artifact is ad-hoc signed and the configuration rejects them.
+func VerifyAll(paths []string, cfg Config) (*Summary, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to verify %q: %w", p, err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		found, err := machofind.Binaries(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no darwin binaries found within %q", p)
+		}
+		files = append(files, found...)
+	}
+
+	if cfg.DetachedSignature != "" && len(files) != 1 {
+		return nil, fmt.Errorf("a detached signature can only be verified against a single binary (found %d)", len(files))
+	}
+
+	summary := Summary{Status: StatusPass}
+	for _, f := range files {
+		report, err := Verify(f, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to verify %q: %w", f, err)
+		}
+		summary.add(*report, cfg)
+	}
+	return &summary, nil
+}
+
+func (s *Summary) add(r Report, cfg Config) {
+	s.Reports = append(s.Reports, r)
+
+	switch {
+	case r.Failed():
+		s.Invalid++
+	case r.AdHoc:
+		s.AdHoc++
+		if cfg.RejectAdHoc {
+			s.Status = StatusFail
+		}
+	default:
+		s.Signed++
+	}
+	s.Status = worst(s.Status, r.Status)
+}
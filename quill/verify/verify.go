@@ -0,0 +1,286 @@
+package verify
+
+import (
+	"crypto/x509"
+	debugMacho "debug/macho"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	macholibre "github.com/anchore/go-macholibre"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/provisioning"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	// StatusWarn is a check that did not fail, but could not be fully made (or is likely to cause problems).
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+func (s Status) rank() int {
+	switch s {
+	case StatusWarn:
+		return 1
+	case StatusFail:
+		return 2
+	}
+	return 0
+}
+
+// worst returns the most severe of the given statuses (pass when none are given).
+func worst(statuses ...Status) Status {
+	result := StatusPass
+	for _, s := range statuses {
+		if s.rank() > result.rank() {
+			result = s
+		}
+	}
+	return result
+}
+
+// Check is a single verification step along with the steps it is made of (e.g. every slot bound by a code directory).
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Message explains the outcome of the check.
+	Message string  `json:"message,omitempty"`
+	Checks  []Check `json:"checks,omitempty"`
+}
+
+// group returns a check made of the given checks, its status is the worst status of the given checks.
+func group(name, message string, checks ...Check) Check {
+	c := Check{Name: name, Message: message, Checks: checks, Status: StatusPass}
+	for _, child := range checks {
+		c.Status = worst(c.Status, child.Status)
+	}
+	return c
+}
+
+// Report is the verification of a single file, with the checks of every architecture.
+type Report struct {
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	// AdHoc is true if any architecture is ad-hoc signed.
+	AdHoc  bool    `json:"adHoc"`
+	Checks []Check `json:"checks"`
+}
+
+// Failed returns true if any check failed.
+func (r Report) Failed() bool {
+	return r.Status == StatusFail
+}
+
+// Config configures how signatures are verified.
+type Config struct {
+	// Roots are the trust anchors of the signing and timestamp authority certificates (the system roots and the Apple
+	// roots embedded into quill when nil).
+	Roots *x509.CertPool
+	// RejectAdHoc fails a summary of several artifacts when any of them is ad-hoc signed (see VerifyAll).
+	RejectAdHoc bool
+	// DetachedSignature is the path of a signature kept apart from the binary, which is verified as though it were
+	// embedded (instead of the embedded signature of the binary).
+	DetachedSignature string
+	// Identifier fails verification unless the signature identifier is exactly this value (no check when empty).
+	Identifier string
+	// IdentifierPattern fails verification unless the signature identifier matches this expression (no check when
+	// nil). The expression is not anchored, e.g. `^com\.example\.` requires identifiers to start with "com.example.".
+	IdentifierPattern *regexp.Regexp
+	// TeamID fails verification unless both the team identifier of the code directories and the team of the signing
+	// certificate (its organizational unit) are this value (no check when empty). Ad-hoc signatures have no team.
+	TeamID string
+	// ProvisioningProfile fails verification unless the profile authorizes the signature (the identifier, team, and
+	// entitlements). When unset, the profile embedded within the bundle of a binary (if any) is used (see Verify).
+	ProvisioningProfile *provisioning.Profile
+}
+
+func (c Config) roots() *x509.CertPool {
+	if c.Roots != nil {
+		return c.Roots
+	}
+	return timestamp.DefaultRoots()
+}
+
+// Verify checks the signature of every architecture of the given (possibly multi-arch) binary: the page hashes and
+// the special slots bound by every code directory, the CMS signature over the code directories, the certificate
+// chain, the secure timestamp, and the requirements. When the configuration has a detached signature, it is verified
+// against the binary in place of the embedded signature. When the binary is the main executable of a bundle with an
+// embedded provisioning profile, the signature must also be authorized by the profile (unless the configuration has a
+// profile).
+func Verify(binPath string, cfg Config) (*Report, error) {
+	f, err := os.Open(binPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if cfg.ProvisioningProfile == nil {
+		if cfg.ProvisioningProfile, err = embeddedProfile(binPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var detached *detachedSignature
+	if cfg.DetachedSignature != "" {
+		if detached, err = readDetachedSignature(cfg.DetachedSignature); err != nil {
+			return nil, err
+		}
+	}
+
+	report := Report{Path: binPath}
+
+	if macholibre.IsUniversalMachoBinary(f) {
+		dir, err := os.MkdirTemp("", "quill-verify-"+path.Base(binPath))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create temp directory to extract multi-arch binary: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		extracted, err := macholibre.Extract(f, dir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to extract multi-arch binary: %w", err)
+		}
+		for _, ef := range extracted {
+			if err := report.add(ef.Path, cfg, detached); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := report.add(binPath, cfg, detached); err != nil {
+		return nil, err
+	}
+
+	report.Status = group("", "", report.Checks...).Status
+	return &report, nil
+}
+
+// VerifyFS is like Verify, but the binary (and the detached signature, if any) is read from the given file system
+// (e.g. an embed.FS or a zip.Reader) without writing temp files.
+func VerifyFS(fsys fs.FS, name string, cfg Config) (*Report, error) {
+	var detached *detachedSignature
+	if cfg.DetachedSignature != "" {
+		b, err := fs.ReadFile(fsys, cfg.DetachedSignature)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read detached signature: %w", err)
+		}
+		if detached, err = parseDetachedSignature(b); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := macho.OpenFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	report := Report{Path: name}
+	for _, m := range files {
+		report.addFile(m, cfg, detached)
+		m.Close()
+	}
+
+	report.Status = group("", "", report.Checks...).Status
+	return &report, nil
+}
+
+// add checks the signature of the single-arch binary at the given path (or the given detached signature, if any).
+func (r *Report) add(path string, cfg Config, detached *detachedSignature) error {
+	m, err := macho.NewReadOnlyFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to parse binary: %w", err)
+	}
+	defer m.Close()
+
+	r.addFile(m, cfg, detached)
+	return nil
+}
+
+// addFile checks the signature of the given single-arch binary (or the given detached signature, if any).
+func (r *Report) addFile(m *macho.File, cfg Config, detached *detachedSignature) {
+	arch := cpuName(m.Cpu)
+
+	var (
+		sig *signature
+		err error
+	)
+	switch {
+	case detached != nil:
+		sig, err = detached.signatureFor(m)
+	case !m.HasCodeSigningCmd():
+		r.Checks = append(r.Checks, Check{Name: arch, Status: StatusFail, Message: "the binary is not signed"})
+		return
+	default:
+		sig, err = readSignature(m)
+	}
+	if err != nil {
+		r.Checks = append(r.Checks, Check{Name: arch, Status: StatusFail, Message: err.Error()})
+		return
+	}
+
+	r.AdHoc = r.AdHoc || sig.adHoc()
+	r.Checks = append(r.Checks, group(arch, "", sig.checks(cfg)...))
+}
+
+// embeddedProfile reads the provisioning profile embedded within the bundle the binary at the given path is the main
+// executable of (nil when the binary is not the main executable of a bundle, or when the bundle has no embedded
+// profile).
+func embeddedProfile(binPath string) (*provisioning.Profile, error) {
+	dir := filepath.Dir(binPath)
+
+	var bundleDir, infoPlist string
+	switch {
+	case filepath.Base(dir) == "MacOS" && filepath.Base(filepath.Dir(dir)) == "Contents":
+		// deep (macOS) bundles: Name.app/Contents/MacOS/Name
+		bundleDir = filepath.Dir(filepath.Dir(dir))
+		infoPlist = filepath.Join(bundleDir, "Contents", "Info.plist")
+	case filepath.Ext(dir) == ".app" || filepath.Ext(dir) == ".appex":
+		// shallow (iOS) bundles: Name.app/Name
+		bundleDir = dir
+		infoPlist = filepath.Join(bundleDir, "Info.plist")
+	default:
+		return nil, nil
+	}
+
+	// other executables of the bundle (e.g. helpers) are not signed with the entitlements of the profile
+	b, err := os.ReadFile(infoPlist)
+	if err != nil {
+		return nil, nil
+	}
+	info, err := entitlements.ParsePlist(b)
+	if err != nil {
+		return nil, nil
+	}
+	if executable, _ := info["CFBundleExecutable"].(string); executable != filepath.Base(binPath) {
+		return nil, nil
+	}
+
+	p, err := provisioning.LoadEmbedded(bundleDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the provisioning profile of bundle %q: %w", bundleDir, err)
+	}
+	return p, nil
+}
+
+// cpuName returns the architecture name of the given CPU (as used by Apple tools).
+func cpuName(cpu debugMacho.Cpu) string {
+	switch cpu { //nolint:exhaustive
+	case debugMacho.CpuAmd64:
+		return "x86_64"
+	case debugMacho.Cpu386:
+		return "i386"
+	case debugMacho.CpuArm64:
+		return "arm64"
+	case debugMacho.CpuArm:
+		return "arm"
+	}
+	return strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+}
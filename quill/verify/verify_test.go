@@ -0,0 +1,633 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	debugMacho "debug/macho"
+	"encoding/binary"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill"
+	"github.com/anchore/quill/quill/entitlements"
+	"github.com/anchore/quill/quill/macho"
+	"github.com/anchore/quill/quill/macho/machotest"
+	"github.com/anchore/quill/quill/pki"
+	"github.com/anchore/quill/quill/pki/testca"
+	"github.com/anchore/quill/quill/provisioning"
+	"github.com/anchore/quill/quill/sign"
+	"github.com/anchore/quill/quill/timestamp"
+)
+
+// writeTestBinary writes a minimal (unsigned) arm64 executable whose __TEXT segment does not end on a page boundary.
+func writeTestBinary(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tool")
+	machotest.Write(t, path, machotest.Config{TextSize: 3*0x1000 + 0x200})
+	return path
+}
+
+func findCheck(t *testing.T, checks []Check, path ...string) Check {
+	t.Helper()
+	for _, c := range checks {
+		if c.Name == path[0] {
+			if len(path) == 1 {
+				return c
+			}
+			return findCheck(t, c.Checks, path[1:]...)
+		}
+	}
+	require.Failf(t, "check not found", "%q", path)
+	return Check{}
+}
+
+func TestVerify_adhoc(t *testing.T) {
+	path := writeTestBinary(t)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{
+		Entitlements: entitlements.Entitlements{"com.apple.security.cs.allow-jit": true},
+	}))
+
+	report, err := Verify(path, Config{})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusWarn, report.Status)
+	require.Len(t, report.Checks, 1)
+	arch := report.Checks[0].Checks
+	assert.Equal(t, "arm64", report.Checks[0].Name)
+
+	for _, cd := range []string{"code directory (sha256)", "code directory (sha1)"} {
+		assert.Equal(t, StatusPass, findCheck(t, arch, cd).Status, cd)
+		assert.Equal(t, "all 4 page hashes match the binary", findCheck(t, arch, cd, "pages").Message)
+		assert.Equal(t, StatusPass, findCheck(t, arch, cd, "entitlements slot").Status)
+		assert.Equal(t, StatusPass, findCheck(t, arch, cd, "requirements slot").Status)
+	}
+	assert.Equal(t, StatusWarn, findCheck(t, arch, "signature").Status)
+	assert.Equal(t, StatusPass, findCheck(t, arch, "requirements").Status)
+	assert.False(t, report.Failed())
+}
+
+func TestVerify_signed(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	path := writeTestBinary(t)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{
+		Signer: fixture.LeafKey,
+		Certs:  fixture.Chain(),
+	}, sign.BinaryOptions{}))
+
+	report, err := Verify(path, Config{Roots: fixture.Roots()})
+	require.NoError(t, err)
+
+	arch := report.Checks[0].Checks
+	for _, name := range []string{"CMS signature", "cdhashes", "certificate chain"} {
+		c := findCheck(t, arch, "signature", name)
+		assert.Equal(t, StatusPass, c.Status, "%s: %s", name, c.Message)
+	}
+	// the fixture signing material is not timestamped
+	assert.Equal(t, StatusWarn, findCheck(t, arch, "signature", "timestamp").Status)
+	assert.Contains(t, findCheck(t, arch, "requirements").Message, `designated => identifier "com.example.tool"`)
+
+	// the fixture root is not trusted by default
+	report, err = Verify(path, Config{})
+	require.NoError(t, err)
+	assert.True(t, report.Failed())
+	assert.Equal(t, StatusFail, findCheck(t, report.Checks[0].Checks, "signature", "certificate chain").Status)
+}
+
+func TestVerify_identifierPolicy(t *testing.T) {
+	path := writeTestBinary(t)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{}))
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		status  Status
+		message string
+	}{
+		{
+			name:    "expected identifier",
+			cfg:     Config{Identifier: "com.example.tool"},
+			status:  StatusPass,
+			message: `identifier "com.example.tool" is allowed`,
+		},
+		{
+			name:    "unexpected identifier",
+			cfg:     Config{Identifier: "com.example.other"},
+			status:  StatusFail,
+			message: `identifier "com.example.tool" is not the expected "com.example.other"`,
+		},
+		{
+			name:    "matching pattern",
+			cfg:     Config{IdentifierPattern: regexp.MustCompile(`^com\.example\.`)},
+			status:  StatusPass,
+			message: `identifier "com.example.tool" is allowed`,
+		},
+		{
+			name:    "pattern mismatch",
+			cfg:     Config{IdentifierPattern: regexp.MustCompile(`^com\.mycorp\.`)},
+			status:  StatusFail,
+			message: `identifier "com.example.tool" does not match "^com\\.mycorp\\."`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Verify(path, tt.cfg)
+			require.NoError(t, err)
+
+			c := findCheck(t, report.Checks[0].Checks, "identifier policy")
+			assert.Equal(t, tt.status, c.Status)
+			assert.Equal(t, tt.message, c.Message)
+			assert.Equal(t, tt.status == StatusFail, report.Failed())
+		})
+	}
+
+	// there is no policy by default
+	report, err := Verify(path, Config{})
+	require.NoError(t, err)
+	for _, c := range report.Checks[0].Checks {
+		assert.NotEqual(t, "identifier policy", c.Name)
+	}
+}
+
+func TestVerify_teamIDPolicy(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+	signingMaterial := pki.SigningMaterial{Signer: fixture.LeafKey, Certs: fixture.Chain()}
+
+	otherLeaf, otherKey, err := fixture.IssueLeaf("Other", "OTHERTEAM1")
+	require.NoError(t, err)
+	otherSigningMaterial := pki.SigningMaterial{Signer: otherKey, Certs: append([]*x509.Certificate{otherLeaf}, fixture.Chain()[1:]...)}
+
+	tests := []struct {
+		name            string
+		signingMaterial pki.SigningMaterial
+		teamID          string
+		pass            bool
+		message         string
+	}{
+		{
+			name:            "expected team",
+			signingMaterial: signingMaterial,
+			teamID:          testca.DefaultTeamID,
+			pass:            true,
+			message:         `the code directory and the signing certificate are of team "QUILLTEST1"`,
+		},
+		{
+			name:            "code directory of another team",
+			signingMaterial: signingMaterial,
+			teamID:          "OTHERTEAM1",
+			message:         `the code directory team ID "OTHERTEAM1" is not the expected "QUILLTEST1"`,
+		},
+		{
+			name:            "team of the signing certificate by default",
+			signingMaterial: signingMaterial,
+			pass:            true,
+			message:         `the code directory and the signing certificate are of team "QUILLTEST1"`,
+		},
+		{
+			name:    "no code directory team",
+			message: `the code directory team ID "" is not the expected "QUILLTEST1"`,
+		},
+		{
+			name:            "certificate of another team",
+			signingMaterial: otherSigningMaterial,
+			teamID:          testca.DefaultTeamID,
+			message:         `the signing certificate team (OU="OTHERTEAM1") is not the expected "QUILLTEST1"`,
+		},
+		{
+			name:    "ad-hoc",
+			teamID:  testca.DefaultTeamID,
+			message: `ad-hoc signature: there is no signing certificate of team "QUILLTEST1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestBinary(t)
+			require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", tt.signingMaterial, sign.BinaryOptions{TeamID: tt.teamID}))
+
+			report, err := Verify(path, Config{Roots: fixture.Roots(), TeamID: testca.DefaultTeamID})
+			require.NoError(t, err)
+
+			c := findCheck(t, report.Checks[0].Checks, "team ID policy")
+			assert.Equal(t, tt.message, c.Message)
+			if tt.pass {
+				assert.Equal(t, StatusPass, c.Status)
+				return
+			}
+			assert.Equal(t, StatusFail, c.Status)
+			assert.True(t, report.Failed())
+		})
+	}
+}
+
+func TestVerify_teamIDPolicy_signedWithDefaults(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	path := writeTestBinary(t)
+	require.NoError(t, quill.Sign(*quill.NewSigningConfig(path, *pki.NewSigningMaterial(fixture.LeafKey, fixture.Chain()))))
+
+	report, err := Verify(path, Config{Roots: fixture.Roots(), TeamID: testca.DefaultTeamID})
+	require.NoError(t, err)
+
+	c := findCheck(t, report.Checks[0].Checks, "team ID policy")
+	assert.Equal(t, StatusPass, c.Status, c.Message)
+	assert.False(t, report.Failed())
+}
+
+func TestVerify_expiredCertificate(t *testing.T) {
+	// the certificates were valid from 48 to 24 hours ago, the binary was signed 36 hours ago
+	signedAt := time.Now().Add(-36 * time.Hour)
+	fixture, err := testca.New(testca.Config{NotBefore: time.Now().Add(-48 * time.Hour), Validity: 24 * time.Hour})
+	require.NoError(t, err)
+
+	tsa, err := fixture.NewTSA()
+	require.NoError(t, err)
+	tsa.Time = signedAt
+	server := httptest.NewServer(tsa)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name      string
+		timestamp timestamp.Config
+		status    Status
+		message   string
+	}{
+		{
+			name:      "timestamped before the certificate expired",
+			timestamp: timestamp.Config{Servers: []string{server.URL}, Roots: fixture.Roots()},
+			status:    StatusPass,
+			message:   "the signing certificate expired since",
+		},
+		{
+			name:    "not timestamped",
+			status:  StatusFail,
+			message: "there is no secure timestamp proving the binary was signed before",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestBinary(t)
+			require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{
+				Signer:      fixture.LeafKey,
+				Certs:       fixture.Chain(),
+				Timestamp:   tt.timestamp,
+				SigningTime: pki.SigningTime{At: signedAt},
+			}, sign.BinaryOptions{}))
+
+			report, err := Verify(path, Config{Roots: fixture.Roots()})
+			require.NoError(t, err)
+
+			c := findCheck(t, report.Checks[0].Checks, "signature", "certificate chain")
+			assert.Equal(t, tt.status, c.Status, c.Message)
+			assert.Contains(t, c.Message, tt.message)
+		})
+	}
+}
+
+func TestVerify_provisioningProfile(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	newProfile := func(getTaskAllow bool) *provisioning.Profile {
+		return &provisioning.Profile{
+			Name:                          "Quill Test Profile",
+			TeamIdentifiers:               []string{testca.DefaultTeamID},
+			ApplicationIdentifierPrefixes: []string{testca.DefaultTeamID},
+			Entitlements: entitlements.Entitlements{
+				"application-identifier": testca.DefaultTeamID + ".com.example.*",
+				"get-task-allow":         getTaskAllow,
+			},
+		}
+	}
+
+	// a deep bundle with the binary as its main executable
+	contents := filepath.Join(t.TempDir(), "Tool.app", "Contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contents, "MacOS"), 0755))
+	path := filepath.Join(contents, "MacOS", "tool")
+	require.NoError(t, os.Rename(writeTestBinary(t), path))
+	require.NoError(t, os.WriteFile(filepath.Join(contents, "Info.plist"), []byte(entitlements.Entitlements{
+		"CFBundleExecutable": "tool",
+		"CFBundleIdentifier": "com.example.tool",
+	}.XML()), 0600))
+
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{
+		Signer: fixture.LeafKey,
+		Certs:  fixture.Chain(),
+	}, sign.BinaryOptions{
+		TeamID: testca.DefaultTeamID,
+		Entitlements: entitlements.Entitlements{
+			"application-identifier": testca.DefaultTeamID + ".com.example.tool",
+			"get-task-allow":         true,
+		},
+	}))
+
+	// there is no embedded profile
+	report, err := Verify(path, Config{Roots: fixture.Roots()})
+	require.NoError(t, err)
+	for _, c := range report.Checks[0].Checks {
+		assert.NotEqual(t, "provisioning profile", c.Name)
+	}
+
+	report, err = Verify(path, Config{Roots: fixture.Roots(), ProvisioningProfile: newProfile(false)})
+	require.NoError(t, err)
+	c := findCheck(t, report.Checks[0].Checks, "provisioning profile")
+	assert.Equal(t, StatusFail, c.Status)
+	assert.Equal(t, `not authorized by "Quill Test Profile": the entitlement "get-task-allow" is true, which is not authorized (the profile allows false)`, c.Message)
+
+	// the profile embedded within the bundle is used
+	doc := entitlements.Entitlements{
+		"Name":                        "Quill Test Profile",
+		"TeamIdentifier":              []interface{}{testca.DefaultTeamID},
+		"ApplicationIdentifierPrefix": []interface{}{testca.DefaultTeamID},
+		"Entitlements":                map[string]interface{}(newProfile(true).Entitlements),
+	}
+	raw, err := cms.Sign([]byte(doc.XML()), []*x509.Certificate{fixture.Leaf}, fixture.LeafKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(contents, provisioning.EmbeddedMacOSName), raw, 0600))
+
+	report, err = Verify(path, Config{Roots: fixture.Roots()})
+	require.NoError(t, err)
+	c = findCheck(t, report.Checks[0].Checks, "provisioning profile")
+	assert.Equal(t, StatusPass, c.Status, c.Message)
+	assert.Equal(t, `the identifier, team, and 2 entitlements are authorized by "Quill Test Profile"`, c.Message)
+}
+
+func TestVerify_modified(t *testing.T) {
+	path := writeTestBinary(t)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content[0x2010]++
+	require.NoError(t, os.WriteFile(path, content, 0700))
+
+	report, err := Verify(path, Config{})
+	require.NoError(t, err)
+
+	assert.True(t, report.Failed())
+	pages := findCheck(t, report.Checks[0].Checks, "code directory (sha256)", "pages")
+	assert.Equal(t, StatusFail, pages.Status)
+	assert.Contains(t, pages.Message, "1 of 4 page hashes do not match the binary (the first at offset 0x2000)")
+}
+
+func TestVerify_unsigned(t *testing.T) {
+	report, err := Verify(writeTestBinary(t), Config{})
+	require.NoError(t, err)
+
+	assert.True(t, report.Failed())
+	assert.Equal(t, []Check{{Name: "arm64", Status: StatusFail, Message: "the binary is not signed"}}, report.Checks)
+}
+
+func TestVerify_malformed(t *testing.T) {
+	// the code signing command follows the header and the two segment commands of the test binary
+	const cmdOffset = 32 + 2*72
+
+	tests := []struct {
+		name    string
+		corrupt func(content []byte, superBlob int)
+		want    string
+	}{
+		{
+			name: "signature size exceeds the binary",
+			corrupt: func(content []byte, _ int) {
+				binary.LittleEndian.PutUint32(content[cmdOffset+12:], 0xfffffff0)
+			},
+			want: "malformed binary",
+		},
+		{
+			name: "signature offset exceeds the binary",
+			corrupt: func(content []byte, _ int) {
+				binary.LittleEndian.PutUint32(content[cmdOffset+8:], 0xfffffff0)
+			},
+			want: "malformed binary",
+		},
+		{
+			name: "superblob count",
+			corrupt: func(content []byte, superBlob int) {
+				binary.BigEndian.PutUint32(content[superBlob+8:], 0xffffffff)
+			},
+			want: "malformed superblob",
+		},
+		{
+			name: "blob offset",
+			corrupt: func(content []byte, superBlob int) {
+				binary.BigEndian.PutUint32(content[superBlob+16:], 0xffffff00)
+			},
+			want: "malformed superblob",
+		},
+		{
+			name: "code directory hash size",
+			corrupt: func(content []byte, superBlob int) {
+				cd := superBlob + int(binary.BigEndian.Uint32(content[superBlob+16:]))
+				content[cd+36] = 0xff
+			},
+			want: "invalid hash size 255",
+		},
+		{
+			name: "code directory hash offset",
+			corrupt: func(content []byte, superBlob int) {
+				cd := superBlob + int(binary.BigEndian.Uint32(content[superBlob+16:]))
+				binary.BigEndian.PutUint32(content[cd+16:], 0xfffffff0)
+			},
+			want: "malformed code directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestBinary(t)
+			require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{}))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			tt.corrupt(content, int(binary.LittleEndian.Uint32(content[cmdOffset+8:])))
+			require.NoError(t, os.WriteFile(path, content, 0700))
+
+			report, err := Verify(path, Config{})
+			require.NoError(t, err)
+			assert.True(t, report.Failed())
+
+			b, err := json.Marshal(report)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), tt.want)
+		})
+	}
+}
+
+func TestShow(t *testing.T) {
+	report := Report{
+		Path:   "tool",
+		Status: StatusFail,
+		Checks: []Check{
+			{Name: "arm64", Status: StatusFail, Checks: []Check{
+				{Name: "pages", Status: StatusFail, Message: "1 of 4 page hashes do not match the binary"},
+				{Name: "signature", Status: StatusWarn, Message: "ad-hoc signature"},
+				{Name: "requirements", Status: StatusPass},
+			}},
+		},
+	}
+
+	var text strings.Builder
+	require.NoError(t, Show(report, &text, "text"))
+	assert.Equal(t, `── ✘ tool
+   └─ ✘ arm64
+      ├─ ✘ pages: 1 of 4 page hashes do not match the binary
+      ├─ ⚠ signature: ad-hoc signature
+      └─ ✔ requirements
+`, text.String())
+
+	var js bytes.Buffer
+	require.NoError(t, Show(report, &js, "json"))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	require.Error(t, Show(report, &js, "table"))
+}
+
+func TestVerifyAll(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	place := func(name string, signing *pki.SigningMaterial) {
+		path := writeTestBinary(t)
+		if signing != nil {
+			require.NoError(t, sign.BinaryWithOptions(path, "com.example."+name, *signing, sign.BinaryOptions{}))
+		}
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0700))
+	}
+	place("adhoc", &pki.SigningMaterial{})
+	place("signed", &pki.SigningMaterial{Signer: fixture.LeafKey, Certs: fixture.Chain()})
+	place("unsigned", nil)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a binary"), 0600))
+
+	summary, err := VerifyAll([]string{dir}, Config{Roots: fixture.Roots()})
+	require.NoError(t, err)
+	require.Len(t, summary.Reports, 3)
+	assert.Equal(t, filepath.Join(dir, "adhoc"), summary.Reports[0].Path)
+	assert.Equal(t, "3 artifact(s): 1 signed, 1 ad-hoc, 1 invalid", summary.String())
+	assert.True(t, summary.Failed())
+
+	// without the invalid binary the summary only fails when ad-hoc signatures are rejected
+	paths := []string{filepath.Join(dir, "adhoc"), filepath.Join(dir, "signed")}
+	summary, err = VerifyAll(paths, Config{Roots: fixture.Roots()})
+	require.NoError(t, err)
+	assert.False(t, summary.Failed())
+
+	summary, err = VerifyAll(paths, Config{Roots: fixture.Roots(), RejectAdHoc: true})
+	require.NoError(t, err)
+	assert.True(t, summary.Failed())
+	assert.Equal(t, 1, summary.AdHoc)
+
+	var text strings.Builder
+	require.NoError(t, ShowSummary(*summary, &text, "text"))
+	assert.True(t, strings.HasSuffix(text.String(), "\n\n2 artifact(s): 1 signed, 1 ad-hoc, 0 invalid\n"), text.String())
+
+	_, err = VerifyAll([]string{t.TempDir()}, Config{})
+	require.ErrorContains(t, err, "no darwin binaries found")
+}
+
+func TestVerify_detached(t *testing.T) {
+	path := writeTestBinary(t)
+	unsigned, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{}))
+
+	m, err := macho.NewReadOnlyFile(path)
+	require.NoError(t, err)
+	cmd, _, err := m.CodeSigningCmd()
+	require.NoError(t, err)
+	superBlob := make([]byte, cmd.DataSize)
+	_, err = m.ReadAt(superBlob, int64(cmd.DataOffset))
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	dir := t.TempDir()
+	embeddedPath := filepath.Join(dir, "tool.sig")
+	require.NoError(t, os.WriteFile(embeddedPath, superBlob, 0600))
+
+	// a detached signature indexes the embedded signature of every architecture by CPU type
+	var detached bytes.Buffer
+	require.NoError(t, binary.Write(&detached, macho.SigningOrder, macho.SuperBlobHeader{Magic: macho.MagicDetachedSignature, Length: uint32(20 + len(superBlob)), Count: 1}))
+	require.NoError(t, binary.Write(&detached, macho.SigningOrder, macho.BlobIndex{Type: macho.SlotType(debugMacho.CpuArm64), Offset: 20}))
+	detached.Write(superBlob)
+	detachedPath := filepath.Join(dir, "tool.detached")
+	require.NoError(t, os.WriteFile(detachedPath, detached.Bytes(), 0600))
+
+	for _, sigPath := range []string{embeddedPath, detachedPath} {
+		report, err := Verify(path, Config{DetachedSignature: sigPath})
+		require.NoError(t, err)
+		assert.False(t, report.Failed(), sigPath)
+		assert.Equal(t, "all 4 page hashes match the binary", findCheck(t, report.Checks[0].Checks, "code directory (sha256)", "pages").Message)
+	}
+
+	// the binary the signature was made for is not the unsigned binary (the signature load command was added)
+	unsignedPath := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(unsignedPath, unsigned, 0700))
+	report, err := Verify(unsignedPath, Config{DetachedSignature: detachedPath})
+	require.NoError(t, err)
+	assert.True(t, report.Failed())
+
+	wrongCPU := bytes.Replace(detached.Bytes(), []byte{0x01, 0x00, 0x00, 0x0c}, []byte{0x01, 0x00, 0x00, 0x07}, 1)
+	require.NoError(t, os.WriteFile(detachedPath, wrongCPU, 0600))
+	report, err = Verify(path, Config{DetachedSignature: detachedPath})
+	require.NoError(t, err)
+	assert.Equal(t, "the detached signature has no signature for arm64", report.Checks[0].Message)
+
+	_, err = VerifyAll([]string{path, unsignedPath}, Config{DetachedSignature: embeddedPath})
+	require.ErrorContains(t, err, "only be verified against a single binary")
+}
+
+func TestVerifyFS(t *testing.T) {
+	path := writeTestBinary(t)
+	unsigned, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, sign.BinaryWithOptions(path, "com.example.tool", pki.SigningMaterial{}, sign.BinaryOptions{}))
+	signed, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	superBlob, err := sign.Detach(path)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"bin/tool":          {Data: signed},
+		"bin/tool.unsigned": {Data: unsigned},
+		"bin/tool.sig":      {Data: superBlob},
+	}
+
+	// the report is the same as when verifying the binary on disk
+	want, err := Verify(path, Config{})
+	require.NoError(t, err)
+	want.Path = "bin/tool"
+
+	report, err := VerifyFS(fsys, "bin/tool", Config{})
+	require.NoError(t, err)
+	assert.Equal(t, want, report)
+
+	report, err = VerifyFS(fsys, "bin/tool", Config{DetachedSignature: "bin/tool.sig"})
+	require.NoError(t, err)
+	assert.False(t, report.Failed())
+
+	report, err = VerifyFS(fsys, "bin/tool.unsigned", Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "the binary is not signed", report.Checks[0].Message)
+
+	_, err = VerifyFS(fsys, "bin/missing", Config{})
+	require.Error(t, err)
+}
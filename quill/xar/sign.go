@@ -0,0 +1,422 @@
+package xar
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/md5" //nolint:gosec // only used to verify legacy TOC checksums
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1" //nolint:gosec // SHA-1 is the default xar TOC checksum (required by older installers)
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	xmldsigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+
+	// maxSignPasses bounds how often signing is repeated when the size of the CMS signature changes between passes
+	// (e.g. a differently sized timestamp token).
+	maxSignPasses = 5
+)
+
+// SignOptions describe how an archive is signed.
+type SignOptions struct {
+	// Key creates the "RSA" signature (PKCS #1 v1.5 over the TOC checksum), which must be an RSA key.
+	Key crypto.Signer
+	// Certificates are embedded into the signature elements (the signing certificate first).
+	Certificates []*x509.Certificate
+	// CMS creates the detached CMS signature over the TOC checksum ("x-signature" element), which is skipped when
+	// nil.
+	CMS func(checksum []byte) ([]byte, error)
+}
+
+// Signed indicates if the archive has a signature over its TOC.
+func (a *Archive) Signed() bool {
+	toc := a.TOC.Child("toc")
+	return toc != nil && (toc.Child("signature") != nil || toc.Child("x-signature") != nil)
+}
+
+// Certificates returns the certificates embedded into the signature of the archive (nil when unsigned).
+func (a *Archive) Certificates() ([]*x509.Certificate, error) {
+	toc := a.TOC.Child("toc")
+	if toc == nil {
+		return nil, nil
+	}
+
+	sig := toc.Child("signature")
+	if sig == nil {
+		sig = toc.Child("x-signature")
+	}
+	if sig == nil {
+		return nil, nil
+	}
+
+	var certs []*x509.Certificate
+	var err error
+	sig.Walk(func(e *Element) {
+		if e.Name != "X509Certificate" || err != nil {
+			return
+		}
+		var der []byte
+		if der, err = base64.StdEncoding.DecodeString(stripSpace(e.Text)); err != nil {
+			err = fmt.Errorf("unable to decode xar signature certificate: %w", err)
+			return
+		}
+		var c *x509.Certificate
+		if c, err = x509.ParseCertificate(der); err != nil {
+			err = fmt.Errorf("unable to parse xar signature certificate: %w", err)
+			return
+		}
+		certs = append(certs, c)
+	})
+	return certs, err
+}
+
+// VerifySignature checks that the TOC checksum matches the TOC and that the RSA signature over the checksum was made
+// by the embedded signing certificate (which is returned). The trust of the certificate is not checked.
+func (a *Archive) VerifySignature() (*x509.Certificate, error) {
+	toc := a.TOC.Child("toc")
+	if toc == nil || !a.Signed() {
+		return nil, fmt.Errorf("the xar archive is not signed")
+	}
+
+	hash, err := a.checksumHash()
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := toc.Child("checksum")
+	if checksum == nil {
+		return nil, fmt.Errorf("the xar TOC has no checksum")
+	}
+	expected, err := a.heapExtent(checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.New()
+	h.Write(a.compressed)
+	digest := h.Sum(nil)
+	if !bytes.Equal(digest, expected) {
+		return nil, fmt.Errorf("the xar TOC checksum does not match")
+	}
+
+	certs, err := a.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found within the xar signature")
+	}
+
+	sigElement := toc.Child("signature")
+	if sigElement == nil {
+		// there is only a CMS signature, which carries its own certificates
+		return certs[0], nil
+	}
+	sig, err := a.heapExtent(sigElement)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the xar signing certificate does not have an RSA key")
+	}
+	if err := rsa.VerifyPKCS1v15(pub, hash, digest, sig); err != nil {
+		return nil, fmt.Errorf("invalid xar signature: %w", err)
+	}
+	return certs[0], nil
+}
+
+// CMSSignature returns the detached CMS signature over the TOC checksum and the checksum itself (nil when there is
+// no CMS signature).
+func (a *Archive) CMSSignature() (signature, checksum []byte, err error) {
+	toc := a.TOC.Child("toc")
+	if toc == nil || toc.Child("x-signature") == nil || toc.Child("checksum") == nil {
+		return nil, nil, nil
+	}
+	if signature, err = a.heapExtent(toc.Child("x-signature")); err != nil {
+		return nil, nil, err
+	}
+	if checksum, err = a.heapExtent(toc.Child("checksum")); err != nil {
+		return nil, nil, err
+	}
+	return signature, checksum, nil
+}
+
+// TOCChecksum returns the checksum of the table of contents stored in the heap (which identifies the archive, e.g. for
+// notarization).
+func (a *Archive) TOCChecksum() ([]byte, error) {
+	toc := a.TOC.Child("toc")
+	if toc == nil || toc.Child("checksum") == nil {
+		return nil, fmt.Errorf("the archive has no table of contents checksum")
+	}
+	return a.heapExtent(toc.Child("checksum"))
+}
+
+func (a *Archive) heapExtent(e *Element) ([]byte, error) {
+	offset, size, err := e.extent("size")
+	if err != nil {
+		return nil, err
+	}
+	if offset > uint64(len(a.Heap)) || size > uint64(len(a.Heap))-offset {
+		return nil, fmt.Errorf("xar %q element references data beyond the heap (offset=%d, size=%d)", e.Name, offset, size)
+	}
+	return a.Heap[offset : offset+size], nil
+}
+
+func (a *Archive) checksumHash() (crypto.Hash, error) {
+	switch a.Checksum {
+	case ChecksumSHA1:
+		return crypto.SHA1, nil
+	case ChecksumSHA256:
+		return crypto.SHA256, nil
+	case ChecksumSHA512:
+		return crypto.SHA512, nil
+	case ChecksumMD5:
+		return crypto.MD5, nil
+	}
+	return 0, fmt.Errorf("unsupported xar checksum algorithm %q", a.Checksum)
+}
+
+// Sign replaces any existing signature of the archive with a signature over the checksum of the TOC. The heap is
+// laid out anew: the TOC checksum, the RSA signature, and the CMS signature come first, followed by all file
+// contents (with their offsets within the TOC updated accordingly).
+//
+//nolint:funlen
+func (a *Archive) Sign(opts SignOptions) error {
+	if opts.Key == nil {
+		return fmt.Errorf("a signing key is required to sign a xar archive")
+	}
+	pub, ok := opts.Key.Public().(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("xar archives can only be signed with an RSA key (got %T)", opts.Key.Public())
+	}
+	if len(opts.Certificates) == 0 {
+		return fmt.Errorf("a signing certificate is required to sign a xar archive")
+	}
+
+	hash, err := a.signingHash()
+	if err != nil {
+		return err
+	}
+
+	toc := a.TOC.Child("toc")
+	if toc == nil {
+		return fmt.Errorf("no toc element found")
+	}
+
+	contents, err := a.detachContents(toc)
+	if err != nil {
+		return err
+	}
+
+	toc.RemoveChildren("checksum")
+	toc.RemoveChildren("signature")
+	toc.RemoveChildren("x-signature")
+
+	checksumSize := hash.Size()
+	rsaSize := pub.Size()
+
+	checksum := NewElement("checksum", "", NewElement("offset", "0"), NewElement("size", strconv.Itoa(checksumSize)))
+	checksum.SetAttr("style", string(a.Checksum))
+	signature := signatureElement("signature", "RSA", checksumSize, rsaSize, opts.Certificates)
+	elements := []*Element{checksum, signature}
+
+	var xSignature *Element
+	if opts.CMS != nil {
+		xSignature = signatureElement("x-signature", "CMS", checksumSize+rsaSize, 0, opts.Certificates)
+		elements = append(elements, xSignature)
+	}
+	insertAfter(toc, "creation-time", elements...)
+
+	cmsSize := 0
+	for pass := 1; pass <= maxSignPasses; pass++ {
+		reserved := checksumSize + rsaSize + cmsSize
+		if xSignature != nil {
+			xSignature.setChildText("size", strconv.Itoa(cmsSize))
+		}
+		contents.relocate(uint64(reserved))
+
+		_, compressed, err := a.compressedTOC()
+		if err != nil {
+			return err
+		}
+
+		h := hash.New()
+		h.Write(compressed)
+		digest := h.Sum(nil)
+
+		rsaSig, err := opts.Key.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return fmt.Errorf("unable to sign xar TOC: %w", err)
+		}
+		if len(rsaSig) != rsaSize {
+			return fmt.Errorf("unexpected RSA signature size (expected=%d, actual=%d)", rsaSize, len(rsaSig))
+		}
+
+		var cmsSig []byte
+		if opts.CMS != nil {
+			if cmsSig, err = opts.CMS(digest); err != nil {
+				return fmt.Errorf("unable to create CMS signature of xar TOC: %w", err)
+			}
+			if len(cmsSig) != cmsSize {
+				// the TOC records the signature size, so the TOC (and signature) must be regenerated
+				cmsSize = len(cmsSig)
+				continue
+			}
+		}
+
+		a.compressed = compressed
+		heap := make([]byte, 0, reserved+len(contents.data))
+		heap = append(heap, digest...)
+		heap = append(heap, rsaSig...)
+		heap = append(heap, cmsSig...)
+		a.Heap = append(heap, contents.data...)
+		return nil
+	}
+
+	return fmt.Errorf("the CMS signature size did not settle after %d passes", maxSignPasses)
+}
+
+// signingHash returns the hash for the TOC checksum, switching weak (or missing) algorithms to SHA-1, which is what
+// productsign uses.
+func (a *Archive) signingHash() (crypto.Hash, error) {
+	switch a.Checksum {
+	case ChecksumNone, ChecksumMD5, "":
+		// note: the file checksums within the TOC keep their own (per element) algorithm
+		a.Checksum = ChecksumSHA1
+	}
+	return a.checksumHash()
+}
+
+func signatureElement(name, style string, offset, size int, certs []*x509.Certificate) *Element {
+	x509Data := NewElement("X509Data", "")
+	for _, c := range certs {
+		x509Data.Children = append(x509Data.Children, NewElement("X509Certificate", base64.StdEncoding.EncodeToString(c.Raw)))
+	}
+	keyInfo := NewElement("KeyInfo", "", x509Data)
+	keyInfo.SetAttr("xmlns", xmldsigNamespace)
+
+	e := NewElement(name, "",
+		NewElement("offset", strconv.Itoa(offset)),
+		NewElement("size", strconv.Itoa(size)),
+		keyInfo,
+	)
+	e.SetAttr("style", style)
+	return e
+}
+
+// insertAfter inserts the given elements after the first child with the given name (or first when there is none).
+func insertAfter(parent *Element, name string, elements ...*Element) {
+	at := 0
+	for i, c := range parent.Children {
+		if c.Name == name {
+			at = i + 1
+			break
+		}
+	}
+
+	children := make([]*Element, 0, len(parent.Children)+len(elements))
+	children = append(children, parent.Children[:at]...)
+	children = append(children, elements...)
+	children = append(children, parent.Children[at:]...)
+	parent.Children = children
+}
+
+// contents are the file contents of an archive, copied out of the heap so that they can be placed after the
+// signatures.
+type contents struct {
+	data []byte
+	refs []contentRef
+}
+
+type contentRef struct {
+	element *Element
+	// offset is relative to the start of the file contents
+	offset uint64
+}
+
+func (c contents) relocate(base uint64) {
+	for _, r := range c.refs {
+		r.element.setChildText("offset", strconv.FormatUint(base+r.offset, 10))
+	}
+}
+
+// detachContents collects the heap extents of all file data and extended attributes (every element with an offset
+// and length, except for the TOC checksum and signatures).
+func (a *Archive) detachContents(toc *Element) (*contents, error) {
+	type extent struct {
+		offset, length uint64
+		elements       []*Element
+	}
+	extents := make(map[uint64]*extent)
+
+	var err error
+	for _, child := range toc.Children {
+		switch child.Name {
+		case "checksum", "signature", "x-signature":
+			continue
+		}
+		child.Walk(func(e *Element) {
+			if err != nil || e.Child("offset") == nil || e.Child("length") == nil {
+				return
+			}
+			var offset, length uint64
+			if offset, length, err = e.extent("length"); err != nil {
+				return
+			}
+			if offset > uint64(len(a.Heap)) || length > uint64(len(a.Heap))-offset {
+				err = fmt.Errorf("xar %q element references data beyond the heap (offset=%d, length=%d)", e.Name, offset, length)
+				return
+			}
+			ex, ok := extents[offset]
+			if !ok {
+				ex = &extent{offset: offset}
+				extents[offset] = ex
+			}
+			if length > ex.length {
+				ex.length = length
+			}
+			ex.elements = append(ex.elements, e)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]uint64, 0, len(extents))
+	for o := range extents {
+		offsets = append(offsets, o)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	c := &contents{}
+	for _, o := range offsets {
+		ex := extents[o]
+		relative := uint64(len(c.data))
+		c.data = append(c.data, a.Heap[ex.offset:ex.offset+ex.length]...)
+		for _, e := range ex.elements {
+			c.refs = append(c.refs, contentRef{element: e, offset: relative})
+		}
+	}
+	return c, nil
+}
+
+func stripSpace(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
@@ -0,0 +1,188 @@
+package xar
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // the default xar TOC checksum
+	"crypto/x509"
+	"fmt"
+	"testing"
+
+	cms "github.com/github/smimesign/ietf-cms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anchore/quill/quill/pki/testca"
+)
+
+func TestArchive_Sign(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	leaf, key, err := fixture.IssueInstallerLeaf("Quill Test", testca.DefaultTeamID)
+	require.NoError(t, err)
+	certs := []*x509.Certificate{leaf, fixture.Intermediate, fixture.Root}
+
+	// the signing certificate has a critical Apple policy extension which the x509 package rejects during chain
+	// verification, so the CMS signature is verified against the signed checksum here
+	var signedChecksums [][]byte
+	signCMS := func(checksum []byte) ([]byte, error) {
+		signedChecksums = append(signedChecksums, checksum)
+		sd, err := cms.NewSignedData(checksum)
+		if err != nil {
+			return nil, err
+		}
+		if err := sd.Sign(certs, key); err != nil {
+			return nil, err
+		}
+		sd.Detached()
+		return sd.ToDER()
+	}
+
+	tests := []struct {
+		name     string
+		checksum Checksum
+		resign   bool
+		withCMS  bool
+	}{
+		{
+			name:     "sha1 TOC checksum",
+			checksum: ChecksumSHA1,
+			withCMS:  true,
+		},
+		{
+			name:     "sha256 TOC checksum",
+			checksum: ChecksumSHA256,
+			withCMS:  true,
+		},
+		{
+			name:     "md5 TOC checksum is upgraded",
+			checksum: ChecksumMD5,
+		},
+		{
+			name:     "replaces an existing signature",
+			checksum: ChecksumSHA1,
+			resign:   true,
+			withCMS:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse(newArchive(t, "<installer-gui-script/>", "payload", "payload"))
+			require.NoError(t, err)
+			a.Checksum = tt.checksum
+
+			opts := SignOptions{Key: key, Certificates: certs}
+			if tt.withCMS {
+				opts.CMS = signCMS
+			}
+
+			require.NoError(t, a.Sign(opts))
+			if tt.resign {
+				require.NoError(t, a.Sign(opts))
+			}
+
+			by, err := a.Bytes()
+			require.NoError(t, err)
+
+			signed, err := Parse(by)
+			require.NoError(t, err)
+			assert.True(t, signed.Signed())
+			assert.Equal(t, []string{"<installer-gui-script/>", "payload", "payload"}, fileContents(t, signed))
+
+			var names []string
+			for _, c := range signed.TOC.Child("toc").Children {
+				names = append(names, c.Name)
+			}
+			wantNames := []string{"creation-time", "checksum", "signature", "x-signature", "file", "file", "file"}
+			if !tt.withCMS {
+				wantNames = append(wantNames[:3], wantNames[4:]...)
+			}
+			assert.Equal(t, wantNames, names)
+
+			cert, err := signed.VerifySignature()
+			require.NoError(t, err)
+			assert.Equal(t, leaf, cert)
+
+			embedded, err := signed.Certificates()
+			require.NoError(t, err)
+			assert.Equal(t, certs, embedded)
+
+			sig, checksum, err := signed.CMSSignature()
+			require.NoError(t, err)
+			if !tt.withCMS {
+				assert.Nil(t, sig)
+				return
+			}
+			require.NotEmpty(t, signedChecksums)
+			assert.Equal(t, signedChecksums[len(signedChecksums)-1], checksum)
+			sd, err := cms.ParseSignedData(sig)
+			require.NoError(t, err)
+			assert.True(t, sd.IsDetached())
+		})
+	}
+}
+
+func TestArchive_Sign_tampered(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	a, err := Parse(newArchive(t, "payload"))
+	require.NoError(t, err)
+	require.NoError(t, a.Sign(SignOptions{Key: fixture.LeafKey, Certificates: []*x509.Certificate{fixture.Leaf}}))
+
+	by, err := a.Bytes()
+	require.NoError(t, err)
+
+	// flip a bit of the RSA signature (which follows the 20 byte checksum at the start of the heap)
+	by[len(by)-len(a.Heap)+sha1.Size] ^= 0x01
+	tampered, err := Parse(by)
+	require.NoError(t, err)
+	_, err = tampered.VerifySignature()
+	assert.ErrorContains(t, err, "invalid xar signature")
+}
+
+func TestArchive_Sign_errors(t *testing.T) {
+	fixture, err := testca.New(testca.Config{})
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts SignOptions
+		want string
+	}{
+		{
+			name: "no key",
+			opts: SignOptions{Certificates: []*x509.Certificate{fixture.Leaf}},
+			want: "a signing key is required",
+		},
+		{
+			name: "not an RSA key",
+			opts: SignOptions{Key: ecKey, Certificates: []*x509.Certificate{fixture.Leaf}},
+			want: "can only be signed with an RSA key",
+		},
+		{
+			name: "no certificates",
+			opts: SignOptions{Key: fixture.LeafKey},
+			want: "a signing certificate is required",
+		},
+		{
+			name: "CMS failure",
+			opts: SignOptions{Key: fixture.LeafKey, Certificates: []*x509.Certificate{fixture.Leaf}, CMS: func([]byte) ([]byte, error) {
+				return nil, fmt.Errorf("no timestamp")
+			}},
+			want: "no timestamp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse(newArchive(t, "payload"))
+			require.NoError(t, err)
+			require.ErrorContains(t, a.Sign(tt.opts), tt.want)
+		})
+	}
+}
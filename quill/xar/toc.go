@@ -0,0 +1,199 @@
+package xar
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Element is a node of the table of contents XML document. Names and attributes are kept exactly as written
+// (including namespace prefixes), text is only kept for elements without children.
+type Element struct {
+	Name     string
+	Attrs    []xml.Attr
+	Text     string
+	Children []*Element
+}
+
+// NewElement creates an element with the given text and children.
+func NewElement(name, text string, children ...*Element) *Element {
+	return &Element{Name: name, Text: text, Children: children}
+}
+
+// Child returns the first child element with the given name (nil if there is none).
+func (e *Element) Child(name string) *Element {
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildText returns the trimmed text of the first child element with the given name.
+func (e *Element) ChildText(name string) string {
+	if c := e.Child(name); c != nil {
+		return strings.TrimSpace(c.Text)
+	}
+	return ""
+}
+
+// Attr returns the value of the given attribute.
+func (e *Element) Attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// SetAttr sets the value of the given attribute.
+func (e *Element) SetAttr(name, value string) {
+	for i, a := range e.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			e.Attrs[i].Value = value
+			return
+		}
+	}
+	e.Attrs = append(e.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// RemoveChildren removes all child elements with the given name.
+func (e *Element) RemoveChildren(name string) {
+	var kept []*Element
+	for _, c := range e.Children {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	e.Children = kept
+}
+
+// Walk calls the given function for the element and all of its descendants (depth first).
+func (e *Element) Walk(fn func(*Element)) {
+	fn(e)
+	for _, c := range e.Children {
+		c.Walk(fn)
+	}
+}
+
+// extent is the heap location held by the "offset" and "size" (or "length") children of an element.
+func (e *Element) extent(sizeName string) (offset, size uint64, err error) {
+	offset, err = strconv.ParseUint(e.ChildText("offset"), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid offset of xar %q element: %w", e.Name, err)
+	}
+	size, err = strconv.ParseUint(e.ChildText(sizeName), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s of xar %q element: %w", sizeName, e.Name, err)
+	}
+	return offset, size, nil
+}
+
+func (e *Element) setChildText(name, text string) {
+	if c := e.Child(name); c != nil {
+		c.Text = text
+		return
+	}
+	e.Children = append(e.Children, NewElement(name, text))
+}
+
+func parseTOC(b []byte) (*Element, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+
+	var root *Element
+	var stack []*Element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse xar TOC: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &Element{Name: rawName(t.Name)}
+			for _, a := range t.Attr {
+				e.Attrs = append(e.Attrs, xml.Attr{Name: xml.Name{Local: rawName(a.Name)}, Value: a.Value})
+			}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, e)
+			case root == nil:
+				root = e
+			default:
+				return nil, fmt.Errorf("unable to parse xar TOC: multiple root elements")
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].Name != rawName(t.Name) {
+				return nil, fmt.Errorf("unable to parse xar TOC: unexpected end element %q", rawName(t.Name))
+			}
+			if e := stack[len(stack)-1]; len(e.Children) > 0 {
+				// drop the whitespace between child elements
+				e.Text = ""
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+
+	if root == nil || len(stack) != 0 {
+		return nil, fmt.Errorf("unable to parse xar TOC: incomplete document")
+	}
+	if root.Name != "xar" || root.Child("toc") == nil {
+		return nil, fmt.Errorf("unable to parse xar TOC: no toc element found")
+	}
+	return root, nil
+}
+
+func rawName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+func (e *Element) write(buf *bytes.Buffer, depth int) {
+	indent := strings.Repeat(" ", depth)
+
+	buf.WriteString(indent)
+	buf.WriteByte('<')
+	buf.WriteString(e.Name)
+	for _, a := range e.Attrs {
+		buf.WriteByte(' ')
+		buf.WriteString(a.Name.Local)
+		buf.WriteString(`="`)
+		_ = xml.EscapeText(buf, []byte(a.Value))
+		buf.WriteByte('"')
+	}
+
+	switch {
+	case len(e.Children) > 0:
+		buf.WriteString(">\n")
+		for _, c := range e.Children {
+			c.write(buf, depth+1)
+		}
+		buf.WriteString(indent)
+	case e.Text != "":
+		buf.WriteByte('>')
+		_ = xml.EscapeText(buf, []byte(e.Text))
+	default:
+		buf.WriteString("/>\n")
+		return
+	}
+
+	buf.WriteString("</")
+	buf.WriteString(e.Name)
+	buf.WriteString(">\n")
+}
@@ -0,0 +1,229 @@
+/*
+Package xar reads and writes xar archives, the container format of flat installer packages (.pkg files produced by
+productbuild and pkgbuild). An archive is a binary header, a zlib compressed XML table of contents (TOC) describing
+every file, and a heap holding the file contents as well as the checksum of the TOC and any signatures over it.
+*/
+package xar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// Magic is the first four bytes of every xar archive ("xar!").
+	Magic uint32 = 0x78617221
+
+	headerSize = 28
+	// the size of the (NUL padded) checksum name following the header when the algorithm is not built in
+	checksumNameSize = 36
+	version          = 1
+
+	// maxTOCSize guards against pathological (malformed) archives claiming huge TOCs.
+	maxTOCSize = 64 << 20
+)
+
+// Checksum is the digest algorithm used for the TOC checksum (and for the digests within the TOC).
+type Checksum string
+
+const (
+	ChecksumNone   Checksum = "none"
+	ChecksumSHA1   Checksum = "sha1"
+	ChecksumMD5    Checksum = "md5"
+	ChecksumSHA256 Checksum = "sha256"
+	ChecksumSHA512 Checksum = "sha512"
+)
+
+// the checksum algorithm identifiers within the header
+const (
+	checksumIDNone uint32 = iota
+	checksumIDSHA1
+	checksumIDMD5
+	checksumIDOther
+)
+
+type header struct {
+	Magic                 uint32
+	Size                  uint16
+	Version               uint16
+	TOCLengthCompressed   uint64
+	TOCLengthUncompressed uint64
+	ChecksumAlgorithm     uint32
+}
+
+// Archive is a parsed xar archive.
+type Archive struct {
+	// Checksum is the algorithm of the TOC checksum.
+	Checksum Checksum
+	// TOC is the root ("xar") element of the table of contents.
+	TOC *Element
+	// Heap holds the contents referenced by offset from the TOC.
+	Heap []byte
+
+	// compressed is the TOC as read (or as last signed), which the TOC checksum is computed over
+	compressed []byte
+}
+
+// IsArchive indicates if the given reader starts with the xar magic.
+func IsArchive(r io.ReaderAt) bool {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return false
+	}
+	return binary.BigEndian.Uint32(magic[:]) == Magic
+}
+
+// Parse reads the xar archive held within the given bytes.
+func Parse(data []byte) (*Archive, error) {
+	var h header
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &h); err != nil {
+		return nil, fmt.Errorf("unable to read xar header: %w", err)
+	}
+
+	if h.Magic != Magic {
+		return nil, fmt.Errorf("not a xar archive (magic=0x%x)", h.Magic)
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("unsupported xar version %d", h.Version)
+	}
+	if h.Size < headerSize || int(h.Size) > len(data) {
+		return nil, fmt.Errorf("invalid xar header size %d", h.Size)
+	}
+	if h.TOCLengthCompressed > uint64(len(data)-int(h.Size)) {
+		return nil, fmt.Errorf("the xar TOC (%d bytes) exceeds the archive size", h.TOCLengthCompressed)
+	}
+	if h.TOCLengthUncompressed > maxTOCSize {
+		return nil, fmt.Errorf("the xar TOC is too large (%d bytes)", h.TOCLengthUncompressed)
+	}
+
+	checksum, err := checksumFromHeader(h.ChecksumAlgorithm, data[headerSize:h.Size])
+	if err != nil {
+		return nil, err
+	}
+
+	tocStart := uint64(h.Size)
+	tocEnd := tocStart + h.TOCLengthCompressed
+
+	zr, err := zlib.NewReader(bytes.NewReader(data[tocStart:tocEnd]))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress xar TOC: %w", err)
+	}
+	tocBytes, err := io.ReadAll(io.LimitReader(zr, maxTOCSize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress xar TOC: %w", err)
+	}
+	if uint64(len(tocBytes)) != h.TOCLengthUncompressed {
+		return nil, fmt.Errorf("xar TOC size mismatch (header=%d, actual=%d)", h.TOCLengthUncompressed, len(tocBytes))
+	}
+
+	toc, err := parseTOC(tocBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archive{
+		Checksum:   checksum,
+		TOC:        toc,
+		Heap:       data[tocEnd:],
+		compressed: data[tocStart:tocEnd],
+	}, nil
+}
+
+func checksumFromHeader(id uint32, name []byte) (Checksum, error) {
+	switch id {
+	case checksumIDNone:
+		return ChecksumNone, nil
+	case checksumIDSHA1:
+		return ChecksumSHA1, nil
+	case checksumIDMD5:
+		return ChecksumMD5, nil
+	case checksumIDOther:
+		n := strings.TrimRight(string(name), "\x00")
+		if n == "" {
+			return "", fmt.Errorf("xar header is missing the checksum algorithm name")
+		}
+		return Checksum(strings.ToLower(n)), nil
+	}
+	return "", fmt.Errorf("unsupported xar checksum algorithm %d", id)
+}
+
+// MarshalTOC returns the (uncompressed) XML table of contents.
+func (a *Archive) MarshalTOC() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	a.TOC.write(&buf, 0)
+	return buf.Bytes()
+}
+
+func (a *Archive) compressedTOC() ([]byte, []byte, error) {
+	toc := a.MarshalTOC()
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(toc); err != nil {
+		return nil, nil, fmt.Errorf("unable to compress xar TOC: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("unable to compress xar TOC: %w", err)
+	}
+	return toc, buf.Bytes(), nil
+}
+
+func (a *Archive) header(toc, compressed []byte) ([]byte, error) {
+	h := header{
+		Magic:                 Magic,
+		Size:                  headerSize,
+		Version:               version,
+		TOCLengthCompressed:   uint64(len(compressed)),
+		TOCLengthUncompressed: uint64(len(toc)),
+	}
+
+	var name []byte
+	switch a.Checksum {
+	case ChecksumNone, "":
+		h.ChecksumAlgorithm = checksumIDNone
+	case ChecksumSHA1:
+		h.ChecksumAlgorithm = checksumIDSHA1
+	case ChecksumMD5:
+		h.ChecksumAlgorithm = checksumIDMD5
+	default:
+		if len(a.Checksum) >= checksumNameSize {
+			return nil, fmt.Errorf("xar checksum algorithm name %q is too long", a.Checksum)
+		}
+		h.ChecksumAlgorithm = checksumIDOther
+		h.Size += checksumNameSize
+		name = make([]byte, checksumNameSize)
+		copy(name, a.Checksum)
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.BigEndian, h); err != nil {
+		return nil, err
+	}
+	buf.Write(name)
+	return buf.Bytes(), nil
+}
+
+// Bytes returns the encoded archive. Note that the TOC checksum is only updated when signing (see Sign).
+func (a *Archive) Bytes() ([]byte, error) {
+	toc, compressed, err := a.compressedTOC()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := a.header(toc, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(h)+len(compressed)+len(a.Heap))
+	out = append(out, h...)
+	out = append(out, compressed...)
+	out = append(out, a.Heap...)
+	return out, nil
+}
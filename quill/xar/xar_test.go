@@ -0,0 +1,217 @@
+package xar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" //nolint:gosec // the default xar TOC checksum
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchive creates an unsigned xar archive (as written by pkgbuild) holding the given files uncompressed.
+func newArchive(t *testing.T, files ...string) []byte {
+	t.Helper()
+
+	var heap bytes.Buffer
+	var entries strings.Builder
+	for i, contents := range files {
+		offset := sha1.Size + heap.Len()
+		heap.WriteString(contents)
+		fmt.Fprintf(&entries, `<file id="%d"><name>file-%d</name><type>file</type><data><length>%d</length><offset>%d</offset><size>%d</size><encoding style="application/octet-stream"/></data></file>`,
+			i+1, i+1, len(contents), offset, len(contents))
+	}
+
+	toc := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<xar>
+ <toc>
+  <creation-time>2024-01-01T00:00:00</creation-time>
+  <checksum style="sha1"><offset>0</offset><size>20</size></checksum>
+  %s
+ </toc>
+</xar>`, entries.String())
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte(toc))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var out bytes.Buffer
+	require.NoError(t, binary.Write(&out, binary.BigEndian, header{
+		Magic:                 Magic,
+		Size:                  headerSize,
+		Version:               version,
+		TOCLengthCompressed:   uint64(compressed.Len()),
+		TOCLengthUncompressed: uint64(len(toc)),
+		ChecksumAlgorithm:     checksumIDSHA1,
+	}))
+	out.Write(compressed.Bytes())
+	checksum := sha1.Sum(compressed.Bytes()) //nolint:gosec
+	out.Write(checksum[:])
+	out.Write(heap.Bytes())
+	return out.Bytes()
+}
+
+// fileContents returns the contents of every file of the archive (in TOC order).
+func fileContents(t *testing.T, a *Archive) []string {
+	t.Helper()
+
+	var contents []string
+	for _, f := range a.TOC.Child("toc").Children {
+		if f.Name != "file" {
+			continue
+		}
+		data := f.Child("data")
+		require.NotNil(t, data)
+		offset, length, err := data.extent("length")
+		require.NoError(t, err)
+		contents = append(contents, string(a.Heap[offset:offset+length]))
+	}
+	return contents
+}
+
+func TestParse(t *testing.T) {
+	valid := newArchive(t, "<installer-gui-script/>", "payload")
+
+	corrupt := func(fn func(b []byte)) []byte {
+		b := append([]byte(nil), valid...)
+		fn(b)
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "valid archive",
+			data: valid,
+		},
+		{
+			name:    "too short",
+			data:    valid[:10],
+			wantErr: require.Error,
+		},
+		{
+			name:    "bad magic",
+			data:    corrupt(func(b []byte) { b[0] = 'y' }),
+			wantErr: require.Error,
+		},
+		{
+			name:    "bad version",
+			data:    corrupt(func(b []byte) { binary.BigEndian.PutUint16(b[6:], 2) }),
+			wantErr: require.Error,
+		},
+		{
+			name:    "TOC exceeds archive",
+			data:    corrupt(func(b []byte) { binary.BigEndian.PutUint64(b[8:], uint64(len(b))) }),
+			wantErr: require.Error,
+		},
+		{
+			name:    "TOC size mismatch",
+			data:    corrupt(func(b []byte) { binary.BigEndian.PutUint64(b[16:], 1) }),
+			wantErr: require.Error,
+		},
+		{
+			name:    "corrupt TOC",
+			data:    corrupt(func(b []byte) { b[headerSize+4] ^= 0xff }),
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				tt.wantErr = require.NoError
+			}
+			a, err := Parse(tt.data)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, ChecksumSHA1, a.Checksum)
+			assert.False(t, a.Signed())
+			assert.Equal(t, []string{"<installer-gui-script/>", "payload"}, fileContents(t, a))
+		})
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	assert.True(t, IsArchive(bytes.NewReader(newArchive(t, "payload"))))
+	assert.False(t, IsArchive(bytes.NewReader([]byte{0xcf, 0xfa, 0xed, 0xfe})))
+	assert.False(t, IsArchive(bytes.NewReader(nil)))
+}
+
+func TestArchive_Bytes(t *testing.T) {
+	a, err := Parse(newArchive(t, "one", "two"))
+	require.NoError(t, err)
+
+	a.Checksum = ChecksumSHA256
+	by, err := a.Bytes()
+	require.NoError(t, err)
+
+	// the checksum name follows the header for algorithms that are not built in
+	assert.Equal(t, uint16(headerSize+checksumNameSize), binary.BigEndian.Uint16(by[4:]))
+
+	b, err := Parse(by)
+	require.NoError(t, err)
+	assert.Equal(t, ChecksumSHA256, b.Checksum)
+	assert.Equal(t, a.MarshalTOC(), b.MarshalTOC())
+	assert.Equal(t, []string{"one", "two"}, fileContents(t, b))
+}
+
+func TestParseTOC_preservesUnknownElements(t *testing.T) {
+	toc, err := parseTOC([]byte(`<xar xmlns:ext="urn:example"><toc><ext:item ext:flag="a&amp;b">x &lt; y</ext:item></toc></xar>`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	toc.write(&buf, 0)
+
+	reparsed, err := parseTOC(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, toc, reparsed)
+
+	item := reparsed.Child("toc").Child("ext:item")
+	require.NotNil(t, item)
+	assert.Equal(t, "x < y", item.Text)
+	assert.Equal(t, "a&b", item.Attrs[0].Value)
+	assert.Equal(t, "ext:flag", item.Attrs[0].Name.Local)
+	assert.Equal(t, "urn:example", reparsed.Attr("xmlns:ext"))
+}
+
+func TestParseTOC_invalid(t *testing.T) {
+	for _, doc := range []string{
+		"",
+		"<xar>",
+		"<xar></toc>",
+		"<xar/><xar/>",
+		"<other><toc/></other>",
+		"<xar><files/></xar>",
+	} {
+		t.Run(strconv.Quote(doc), func(t *testing.T) {
+			_, err := parseTOC([]byte(doc))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestArchive_TOCChecksum(t *testing.T) {
+	data := newArchive(t, "payload")
+	a, err := Parse(data)
+	require.NoError(t, err)
+
+	checksum, err := a.TOCChecksum()
+	require.NoError(t, err)
+	want := sha1.Sum(a.compressed) //nolint:gosec
+	assert.Equal(t, want[:], checksum)
+
+	a.TOC.Child("toc").RemoveChildren("checksum")
+	_, err = a.TOCChecksum()
+	require.Error(t, err)
+}